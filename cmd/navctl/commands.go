@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"nav-tracker/pkg/client"
+	"nav-tracker/pkg/models"
+)
+
+// bulkPollInterval is how often reset checks on a bulk delete job.
+const bulkPollInterval = 200 * time.Millisecond
+
+func runStats(ctx context.Context, c *client.Client, args []string, out *output) error {
+	flags := out.flagSet("stats")
+	url := flags.String("url", "", "URL to report on; system stats when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *url == "" {
+		stats, err := c.SystemStats(ctx)
+		if err != nil {
+			return err
+		}
+		return out.printFields(stats, [][2]string{
+			{"unique urls", strconv.Itoa(stats.TotalUniqueURLs)},
+			{"page views", strconv.FormatInt(stats.TotalPageViews, 10)},
+			{"sessions", strconv.Itoa(stats.TotalSessions)},
+			{"average session", stats.AverageSessionLength.String()},
+			{"pages per session", strconv.FormatFloat(stats.PagesPerSession, 'f', 2, 64)},
+		})
+	}
+
+	stats, err := c.Stats(ctx, *url)
+	if err != nil {
+		return err
+	}
+	fields := [][2]string{
+		{"url", stats.URL},
+		{"distinct visitors", strconv.Itoa(stats.DistinctVisitors)},
+		{"page views", strconv.Itoa(stats.TotalPageViews)},
+		{"last visit", formatTime(stats.LastVisit)},
+	}
+	if stats.SamplingRate > 0 {
+		fields = append(fields, [2]string{"sampling rate", strconv.Itoa(stats.SamplingRate)})
+	}
+	return out.printFields(stats, fields)
+}
+
+func runTopURLs(ctx context.Context, c *client.Client, args []string, out *output) error {
+	flags := out.flagSet("top-urls")
+	limit := flags.Int("limit", 10, "Number of URLs to list")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	urls, err := c.TopURLs(ctx, *limit)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, len(urls))
+	for i, stats := range urls {
+		rows[i] = []string{stats.URL, strconv.Itoa(stats.DistinctVisitors), strconv.Itoa(stats.TotalPageViews), formatTime(stats.LastVisit)}
+	}
+	return out.print(urls, []string{"URL", "VISITORS", "PAGE VIEWS", "LAST VISIT"}, rows)
+}
+
+func runIngest(ctx context.Context, c *client.Client, args []string, out *output) error {
+	flags := out.flagSet("ingest")
+	file := flags.String("file", "", "NDJSON file of events to import, or - for stdin")
+	event := models.NavigationEvent{}
+	flags.StringVar(&event.VisitorID, "visitor", "", "Visitor ID")
+	flags.StringVar(&event.URL, "url", "", "URL viewed")
+	flags.StringVar(&event.Referrer, "referrer", "", "Referrer")
+	flags.StringVar(&event.EventID, "event-id", "", "Event ID, which makes retries safe")
+	flags.StringVar(&event.UserAgent, "user-agent", "", "User agent")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *file == "" {
+		if event.VisitorID == "" || event.URL == "" {
+			return fmt.Errorf("ingest: -visitor and -url are required without -file")
+		}
+		if err := c.Ingest(ctx, &event); err != nil {
+			return err
+		}
+		return out.printFields(map[string]string{"status": "recorded"}, [][2]string{{"status", "recorded"}})
+	}
+
+	events, err := readEvents(*file)
+	if err != nil {
+		return err
+	}
+	summary, err := c.IngestBatch(ctx, events)
+	if err != nil {
+		return err
+	}
+	return out.printFields(summary, [][2]string{
+		{"processed", strconv.Itoa(summary.Processed)},
+		{"imported", strconv.Itoa(summary.Imported)},
+		{"failed", strconv.Itoa(summary.Failed)},
+		{"elapsed", summary.Elapsed.String()},
+	})
+}
+
+// readEvents decodes one event per line of path, or of stdin for -.
+func readEvents(path string) ([]models.NavigationEvent, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var events []models.NavigationEvent
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event models.NavigationEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
+
+func runExport(ctx context.Context, c *client.Client, args []string, out *output) error {
+	flags := out.flagSet("export")
+	options := client.ExportOptions{}
+	flags.StringVar(&options.Format, "format", "ndjson", "Record format: ndjson or csv")
+	flags.StringVar(&options.URLPrefix, "url-prefix", "", "Only export URLs starting with this prefix")
+	from := flags.String("from", "", "Only export visitors last seen at or after this RFC 3339 time")
+	to := flags.String("to", "", "Only export visitors last seen before this RFC 3339 time")
+	path := flags.String("o", "", "File to write; stdout when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var err error
+	if options.From, err = parseTime("from", *from); err != nil {
+		return err
+	}
+	if options.To, err = parseTime("to", *to); err != nil {
+		return err
+	}
+
+	w := out.w
+	if *path != "" {
+		file, err := os.Create(*path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	return c.Export(ctx, options, w)
+}
+
+func runReset(ctx context.Context, c *client.Client, args []string, out *output) error {
+	flags := out.flagSet("reset")
+	url := flags.String("url", "", "URL to erase")
+	pattern := flags.String("pattern", "", "Erase every URL matching this regexp, in a background job")
+	yes := flags.Bool("yes", false, "Confirm erasing the URLs matching -pattern")
+	wait := flags.Bool("wait", true, "Wait for the -pattern job to complete")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	switch {
+	case (*url == "") == (*pattern == ""):
+		return fmt.Errorf("reset: exactly one of -url and -pattern is required")
+	case *url != "":
+		entry, err := c.DeleteURL(ctx, *url)
+		if err != nil {
+			return err
+		}
+		return out.printFields(entry, [][2]string{
+			{"url", entry.Target},
+			{"urls", strconv.Itoa(entry.URLs)},
+			{"visitors", strconv.Itoa(entry.Visitors)},
+			{"page views", strconv.FormatInt(entry.PageViews, 10)},
+			{"sessions", strconv.Itoa(entry.Sessions)},
+		})
+	case !*yes:
+		return fmt.Errorf("reset: -pattern erases every matching URL; add -yes to confirm")
+	}
+
+	job, err := c.StartBulkJob(ctx, models.BulkOperation{Operation: models.BulkDelete, Pattern: *pattern})
+	if err != nil {
+		return err
+	}
+	for *wait && job.Status != models.JobCompleted {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(bulkPollInterval):
+		}
+		if job, err = c.BulkJob(ctx, job.ID); err != nil {
+			return err
+		}
+	}
+	return out.printFields(job, [][2]string{
+		{"job", job.ID},
+		{"status", job.Status},
+		{"matched", strconv.Itoa(job.Matched)},
+		{"deleted", strconv.Itoa(job.Applied)},
+		{"skipped", strconv.Itoa(job.Skipped)},
+	})
+}
+
+func parseTime(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -%s: must be RFC 3339", name)
+	}
+	return t, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
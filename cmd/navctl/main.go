@@ -0,0 +1,102 @@
+// Command navctl queries and administers a running nav-tracker server
+// through the Go client.
+//
+// Usage:
+//
+//	navctl [global flags] <command> [flags]
+//
+// The commands are stats, top-urls, ingest, export and reset; run
+// "navctl <command> -h" for their flags.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"nav-tracker/pkg/client"
+)
+
+// command is a navctl subcommand. run parses args with flags and writes its
+// result to out in the chosen format.
+type command struct {
+	summary string
+	run     func(ctx context.Context, c *client.Client, args []string, out *output) error
+}
+
+var commands = map[string]command{
+	"stats":    {"Show a URL's visitors and page views, or system stats without -url", runStats},
+	"top-urls": {"List the URLs with the most distinct visitors", runTopURLs},
+	"ingest":   {"Record one event, or a file of NDJSON events with -file", runIngest},
+	"export":   {"Stream visitor records as NDJSON or CSV", runExport},
+	"reset":    {"Erase a URL, or every URL matching -pattern", runReset},
+}
+
+var commandOrder = []string{"stats", "top-urls", "ingest", "export", "reset"}
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "navctl: %v\n", err)
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+// run parses the global flags in args and runs the command they name.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("navctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	server := flags.String("server", envOr("NAVCTL_SERVER", "http://localhost:8080"), "Base URL of the server (NAVCTL_SERVER)")
+	apiKey := flags.String("api-key", os.Getenv("NAVCTL_API_KEY"), "API key sent as X-API-Key (NAVCTL_API_KEY)")
+	token := flags.String("token", os.Getenv("NAVCTL_TOKEN"), "Bearer token for servers in jwt auth mode (NAVCTL_TOKEN)")
+	timeout := flags.Duration("timeout", 30*time.Second, "Per-request timeout, including the whole of an export")
+	format := flags.String("output", formatTable, "Output format: table or json")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: navctl [global flags] <command> [flags]\n\nCommands:\n")
+		for _, name := range commandOrder {
+			fmt.Fprintf(stderr, "  %-9s %s\n", name, commands[name].summary)
+		}
+		fmt.Fprintf(stderr, "\nGlobal flags:\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *format != formatTable && *format != formatJSON {
+		return fmt.Errorf("invalid -output %q: must be table or json", *format)
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		flags.Usage()
+		return fmt.Errorf("unknown command %q", flags.Arg(0))
+	}
+
+	config := client.DefaultConfig(*server)
+	config.APIKey = *apiKey
+	config.Token = *token
+	config.Timeout = *timeout
+	c, err := client.New(config)
+	if err != nil {
+		return err
+	}
+
+	return cmd.run(ctx, c, flags.Args()[1:], &output{w: stdout, stderr: stderr, format: *format})
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nav-tracker/pkg/handlers"
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func newTestServer(t *testing.T) string {
+	tracker := storage.NewNavigationTracker()
+	mux := http.NewServeMux()
+	mux.Handle("/api/v1/ingest", handlers.IngestHandler(tracker))
+	mux.Handle("/api/v1/import", handlers.ImportHandler(tracker, 0))
+	mux.Handle("/api/v1/stats", handlers.StatsHandler(tracker))
+	mux.Handle("/api/v1/top-urls", handlers.TopURLsHandler(tracker))
+	mux.Handle("/api/v1/system-stats", handlers.SystemStatsHandler(tracker))
+	mux.Handle("/api/v1/export", handlers.ExportHandler(tracker))
+	mux.Handle("/api/v1/urls", handlers.URLsHandler(tracker))
+	mux.Handle("/api/v1/urls/bulk", handlers.BulkURLsHandler(tracker))
+	mux.Handle("/api/v1/urls/bulk/", handlers.BulkJobHandler(tracker))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func navctl(t *testing.T, server string, args ...string) (string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), append([]string{"-server", server}, args...), &stdout, &stderr)
+	return stdout.String(), err
+}
+
+func TestRun_Commands(t *testing.T) {
+	server := newTestServer(t)
+
+	if _, err := navctl(t, server, "ingest", "-visitor", "visitor1", "-url", "https://example.com/a"); err != nil {
+		t.Fatalf("Failed to ingest: %v", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "events.ndjson")
+	events := `{"visitor_id":"visitor2","url":"https://example.com/a"}
+
+{"visitor_id":"visitor2","url":"https://example.com/b"}
+`
+	if err := os.WriteFile(file, []byte(events), 0644); err != nil {
+		t.Fatalf("Failed to write events: %v", err)
+	}
+	out, err := navctl(t, server, "-output", "json", "ingest", "-file", file)
+	if err != nil {
+		t.Fatalf("Failed to ingest file: %v", err)
+	}
+	var summary models.ImportProgress
+	if err := json.Unmarshal([]byte(out), &summary); err != nil || summary.Imported != 2 {
+		t.Errorf("Expected 2 events imported, got %q", out)
+	}
+
+	out, err = navctl(t, server, "-output", "json", "stats", "-url", "https://example.com/a")
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	var stats models.VisitorStats
+	if err := json.Unmarshal([]byte(out), &stats); err != nil || stats.DistinctVisitors != 2 {
+		t.Errorf("Expected 2 visitors, got %q", out)
+	}
+
+	out, err = navctl(t, server, "top-urls", "-limit", "1")
+	if err != nil {
+		t.Fatalf("Failed to get top URLs: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "URL") || !strings.HasPrefix(lines[1], "https://example.com/a") {
+		t.Errorf("Expected a header and the top URL, got %q", out)
+	}
+
+	if out, err = navctl(t, server, "stats"); err != nil || !strings.Contains(out, "unique urls") {
+		t.Errorf("Expected system stats, got %q: %v", out, err)
+	}
+
+	out, err = navctl(t, server, "export", "-format", "csv", "-url-prefix", "https://example.com/b")
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	if lines := strings.Count(out, "\n"); lines != 2 {
+		t.Errorf("Expected a header and 1 record, got %q", out)
+	}
+
+	if _, err := navctl(t, server, "reset", "-url", "https://example.com/a"); err != nil {
+		t.Fatalf("Failed to reset URL: %v", err)
+	}
+	if _, err := navctl(t, server, "reset", "-pattern", "example"); err == nil {
+		t.Error("Expected a pattern reset without -yes to be refused")
+	}
+	out, err = navctl(t, server, "-output", "json", "reset", "-pattern", "example", "-yes")
+	if err != nil {
+		t.Fatalf("Failed to reset pattern: %v", err)
+	}
+	var job models.BulkJob
+	if err := json.Unmarshal([]byte(out), &job); err != nil || job.Status != models.JobCompleted || job.Applied != 1 {
+		t.Errorf("Expected a completed job deleting 1 URL, got %q", out)
+	}
+}
+
+func TestRun_InvalidArguments(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"no command", nil},
+		{"unknown command", []string{"purge"}},
+		{"invalid output", []string{"-output", "yaml", "stats"}},
+		{"ingest without url", []string{"ingest", "-visitor", "visitor1"}},
+		{"export with invalid time", []string{"export", "-from", "yesterday"}},
+		{"reset without target", []string{"reset"}},
+	}
+
+	for _, tt := range tests {
+		if _, err := navctl(t, "http://localhost:0", tt.args...); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	if _, err := navctl(t, "http://localhost:0"); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Expected usage without a command, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+type output struct {
+	w      io.Writer
+	stderr io.Writer
+	format string
+}
+
+// flagSet returns the flags of the named command, reporting errors and
+// usage on stderr.
+func (o *output) flagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet("navctl "+name, flag.ContinueOnError)
+	flags.SetOutput(o.stderr)
+	return flags
+}
+
+// print writes v as indented JSON, or as a table of headers and rows.
+func (o *output) print(v interface{}, headers []string, rows [][]string) error {
+	if o.format == formatJSON {
+		encoder := json.NewEncoder(o.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(o.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// printFields writes v as indented JSON, or as a two-column table of
+// field names and values.
+func (o *output) printFields(v interface{}, fields [][2]string) error {
+	rows := make([][]string, len(fields))
+	for i, field := range fields {
+		rows[i] = []string{field[0], field[1]}
+	}
+	return o.print(v, []string{"FIELD", "VALUE"}, rows)
+}
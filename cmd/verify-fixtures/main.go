@@ -0,0 +1,49 @@
+// Command verify-fixtures replays recorded API fixtures against a running
+// server and reports every response that no longer matches.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"nav-tracker/pkg/fixtures"
+)
+
+func main() {
+	dir := flag.String("dir", "testdata/fixtures", "Directory of recorded fixtures")
+	server := flag.String("server", "http://localhost:8080", "Base URL of the server to verify")
+	timeout := flag.Duration("timeout", 10*time.Second, "Per-request timeout")
+	flag.Parse()
+
+	recorded, err := fixtures.Load(*dir)
+	if err != nil {
+		log.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	client := &http.Client{Timeout: *timeout}
+	failed := 0
+	for _, f := range recorded {
+		diffs, err := fixtures.Replay(client, *server, f)
+		if err != nil {
+			log.Fatalf("%s: %v", f.Name, err)
+		}
+		if len(diffs) == 0 {
+			continue
+		}
+
+		failed++
+		fmt.Printf("FAIL %s (%s %s)\n", f.Name, f.Request.Method, f.Request.Path)
+		for _, diff := range diffs {
+			fmt.Printf("    %s\n", diff)
+		}
+	}
+
+	fmt.Printf("%d of %d fixtures matched\n", len(recorded)-failed, len(recorded))
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
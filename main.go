@@ -2,26 +2,115 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
 
+	"nav-tracker/pkg/config"
 	"nav-tracker/pkg/server"
+	"nav-tracker/pkg/version"
 )
 
 func main() {
-	port := flag.String("port", "8080", "Port to run the server on")
+	configPath := flag.String("config", "", "Path to a JSON or YAML config file")
+	port := flag.String("port", "", "Port to run the server on (overrides the config file and environment)")
+	storageBackend := flag.String("storage", "", "Counter storage backend: memory, sqlite or postgres (overrides the config file and environment)")
+	dsn := flag.String("dsn", "", "Database DSN for -storage=sqlite or postgres, e.g. file:nav-tracker.db")
+	showVersion := flag.Bool("version", false, "Print the build version and exit")
 	flag.Parse()
 
-	if envPort := os.Getenv("PORT"); envPort != "" {
-		*port = envPort
+	if *showVersion {
+		fmt.Println(version.String())
+		return
 	}
 
-	log.Printf("Starting Navigation Tracker on port %s", *port)
+	cfg, err := config.Load(*configPath, os.Environ())
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if *port != "" {
+		cfg.Port = *port
+	}
+	if *storageBackend != "" {
+		cfg.Storage.Backend = *storageBackend
+	}
+	if *dsn != "" {
+		cfg.Storage.DSN = *dsn
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	log.Printf("Starting Navigation Tracker %s on port %s", version.String(), cfg.Port)
 	log.Println("Available endpoints:")
-	log.Println("  POST /ingest - Record navigation events")
-	log.Println("  GET  /stats?url=<url> - Get distinct visitor count for a URL")
+	log.Println("  POST /api/v1/ingest - Record navigation events (202 Accepted when async ingestion is enabled; ?dry_run=true only validates)")
+	log.Println("  GET  /api/v1/stats?url=<url> - Get distinct visitor count for a URL")
+	log.Println("  GET  /api/v1/export?format=ndjson|csv - Stream visitor records")
+	log.Println("  GET  /api/v1/sessions?visitor_id=<id> - List a visitor's sessions")
+	log.Println("  GET  /api/v1/system-stats - Get tracker-wide statistics")
+	log.Println("  GET  /api/v1/cohorts?weeks=<n> - Get weekly retention matrix")
+	log.Println("  GET  /api/v1/actives?url=<url>&window=day|week|month - Get daily, weekly or monthly active visitors")
+	log.Println("  GET  /api/v1/realtime?url=<url> - Get the visitors online now")
+	log.Println("  GET  /api/v1/stats/compare?url=<url>&period=7d - Compare visitors and page views with the previous period")
+	log.Println("  POST /api/v1/stats/batch - Get the stats of several URLs, optionally over a time range")
+	log.Println("  GET  /api/v1/overlap?url_a=<url>&url_b=<url> - Count the visitors two URLs share")
+	log.Println("  GET  /api/v1/events/stats?url=<url>&type=<type> - Count a URL's page views, clicks and custom events")
+	log.Println("  GET  /api/v1/data-quality - Get suspicious and excluded visitor IDs")
+	log.Println("  GET  /dashboard - Operator dashboard")
+	log.Println("  GET  /api/v1/pixel?visitor_id=<id>&url=<url> - Record an event and return a 1x1 GIF")
+	log.Println("  GET  /api/v1/top-urls?limit=<n> - Get URLs with the most distinct visitors")
+	log.Println("  GET  /api/v1/categories - Get visitor rollups per URL category")
+	log.Println("  GET  /api/v1/referrers?url=<url> - Get top referring domains for a URL")
+	log.Println("  GET  /api/v1/paths?url=<url> - Get the most common next and previous pages of a URL")
+	log.Println("  GET  /api/v1/entry-pages?limit=10 - Get the URLs that most often start sessions")
+	log.Println("  GET  /api/v1/exit-pages?limit=10 - Get the URLs that most often end sessions")
+	log.Println("  GET  /api/v1/segments?url=<url>&key=<property> - Get distinct visitors per value of a visitor property")
+	log.Println("  GET  /api/v1/devices?url=<url> - Get browser/OS/device breakdown for a URL")
+	log.Println("  GET  /api/v1/geo?url=<url> - Get visitor breakdown by country for a URL")
+	log.Println("  GET  /api/v1/languages?url=<url> - Get visitor breakdown by preferred language for a URL or all URLs")
+	log.Println("  GET  /api/v1/visitors/{visitor_id} - Get a visitor's URLs, page views and sessions")
+	log.Println("  DELETE /api/v1/visitors/{visitor_id} - Erase a visitor's data")
+	log.Println("  GET  /api/v1/urls?prefix=<prefix>&q=<text> - List and search tracked URLs")
+	log.Println("  DELETE /api/v1/urls?url=<url> - Erase a URL's data")
+	log.Println("  GET  /api/v1/deletions - Get the data deletion audit log")
+	log.Println("  POST /api/v1/funnels - Define a funnel of ordered URL steps")
+	log.Println("  POST /api/v1/patterns - Register a URL pattern such as /product/* for grouped stats")
+	log.Println("  GET|PUT|POST|DELETE /api/v1/denylist - Manage the URL and referrer deny rules")
+	log.Println("  GET  /api/v1/stats?url=<url>&segment=plan:pro - Get distinct visitors whose latest properties match a segment")
+	log.Println("  GET  /api/v1/stats?pattern=<pattern> - Get distinct visitors and page views for a URL pattern")
+	log.Println("  GET  /api/v1/funnels/{id}/report - Get per-step visitors and drop-off for a funnel")
+	log.Println("  POST /api/v1/import?format=ndjson|csv - Import a file of historical events with progress reporting")
+	log.Println("  POST /api/v1/urls/bulk - Start a bulk delete, merge, categorize, freeze or retention job")
+	log.Println("  GET  /api/v1/urls/bulk/{id} - Get a bulk URL job's status and result summary")
+	log.Println("  GET|POST /api/v1/reports - List or define report schedules (when reports are enabled)")
+	log.Println("  GET|PUT|DELETE /api/v1/reports/{id} - Manage a report schedule")
+	log.Println("  POST /api/v1/reports/{id}/run - Generate and deliver a report now")
+	log.Println("  GET  /api/v1/slowlog - Get recent slow queries")
+	log.Println("  GET  /api/v1/metrics - Get request metrics per route")
+	log.Println("  GET  /api/v1/ingest-activity - Get per-minute ingest counts by API key and IP")
+	log.Println("  POST /api/v1/snapshot - Save a persistence snapshot (when snapshots are enabled)")
+	log.Println("  POST /api/v1/admin/compact - Compact persisted state (when snapshots or tiering are enabled)")
+	log.Println("  GET  /api/v1/stream - WebSocket stream of ingested events")
+	log.Println("  GET  /api/v1/events/tail?url=<url> - Server-Sent Events stream of ingested events")
+	log.Println("  GET  /api/v1/accuracy - Approximate vs exact distinct visitor error (when auditing is enabled)")
+	log.Println("  GET  /api/v1/cluster - Replication status of this node and its peers (when clustering is enabled)")
+	log.Println("  POST /api/v1/cluster/gossip - Merge counters pushed by a peer")
+	log.Println("  GET  /api/v1/tenants - List tenants with their state and stats (when tenancy is enabled)")
+	log.Println("  POST /api/v1/tenants - Create, suspend or reactivate a tenant")
+	log.Println("  DELETE /api/v1/tenants/{id} - Delete a tenant and purge its data")
+	log.Println("  GET  /api/v1/tenants/audit - Get the tenant lifecycle audit log")
+	log.Println("  GET  /api/v1/config - Get the configuration in effect")
+	log.Println("  PUT  /api/v1/config - Update the configuration (port, HTTP timeouts, CORS and validation apply immediately)")
+	log.Println("  GET  /api/v1/config/history - List configuration versions (?version=<n> for one with its configuration)")
+	log.Println("  POST /api/v1/config/rollback?version=<n> - Restore the configuration of an earlier version")
+	log.Println("  GET  /ready - Per-component startup status")
+	log.Println("  GET  /livez - Liveness probe")
+	log.Println("  GET  /readyz - Readiness probe with dependency checks")
+	log.Println("  GET  /api/v1/version - Get the build version, commit and date")
+	log.Println("  GET  /openapi.json - OpenAPI 3 document for this API")
+	log.Println("  GET  /docs - Swagger UI for the API")
 
-	srv := server.NewServer(*port)
+	srv := server.NewServerWithConfig(cfg)
 	if err := srv.Start(); err != nil {
 		log.Fatalf("Server failed to start: %v", err)
 	}
@@ -0,0 +1,211 @@
+// Package archive uploads every recorded event to S3-compatible object
+// storage as batches of gzip-compressed NDJSON.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+const objectContentType = "application/gzip"
+
+// Uploader stores an object; S3Client is the production implementation.
+type Uploader interface {
+	Put(ctx context.Context, key, contentType string, body []byte) error
+}
+
+// Archiver collects recorded events and uploads them in batches every
+// interval, or sooner once a batch is full. Events stay pending until their
+// batch is uploaded, so a failed upload is retried with the next flush.
+type Archiver struct {
+	config   models.ArchiveConfig
+	uploader Uploader
+
+	triggerC chan struct{}
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+	started  atomic.Bool
+
+	// flushMutex serialises flushes; mutex guards the fields below.
+	flushMutex     sync.Mutex
+	mutex          sync.Mutex
+	pending        []models.NavigationEvent
+	objects        int64
+	archivedEvents int64
+	uploadedBytes  int64
+	failedUploads  int64
+	droppedEvents  int64
+	lastUpload     time.Time
+	lastObject     string
+	lastError      string
+	lastErrorAt    time.Time
+}
+
+func NewArchiver(config models.ArchiveConfig, uploader Uploader) *Archiver {
+	return &Archiver{
+		config:   config,
+		uploader: uploader,
+		triggerC: make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+}
+
+// Record queues event for upload. It is a tracker listener, so it never
+// blocks on an upload.
+func (a *Archiver) Record(event models.NavigationEvent) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if len(a.pending) >= a.config.MaxPendingEvents {
+		a.droppedEvents++
+		return
+	}
+	a.pending = append(a.pending, event)
+	if len(a.pending)%a.config.BatchSize == 0 {
+		select {
+		case a.triggerC <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (a *Archiver) Start() {
+	if !a.started.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer close(a.doneCh)
+
+		ticker := time.NewTicker(a.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-a.triggerC:
+			case <-a.stopCh:
+				return
+			}
+
+			if err := a.Flush(context.Background()); err != nil {
+				log.Printf("Event archive upload failed: %v", err)
+			}
+		}
+	}()
+}
+
+// Stop ends periodic uploads and then uploads the events still pending,
+// giving up when ctx is done. It is safe to call more than once.
+func (a *Archiver) Stop(ctx context.Context) error {
+	a.stopOnce.Do(func() {
+		close(a.stopCh)
+	})
+	if a.started.Load() {
+		<-a.doneCh
+	}
+	return a.Flush(ctx)
+}
+
+// Flush uploads the pending events in batches, stopping at the first batch
+// that fails to upload.
+func (a *Archiver) Flush(ctx context.Context) error {
+	a.flushMutex.Lock()
+	defer a.flushMutex.Unlock()
+
+	for {
+		a.mutex.Lock()
+		batch := a.pending[:min(len(a.pending), a.config.BatchSize)]
+		a.mutex.Unlock()
+		if len(batch) == 0 {
+			return nil
+		}
+
+		key, size, err := a.upload(ctx, batch)
+
+		a.mutex.Lock()
+		if err != nil {
+			a.failedUploads++
+			a.lastError = err.Error()
+			a.lastErrorAt = time.Now().UTC()
+			a.mutex.Unlock()
+			return err
+		}
+		// Events recorded meanwhile were appended after the batch
+		a.pending = append([]models.NavigationEvent(nil), a.pending[len(batch):]...)
+		a.objects++
+		a.archivedEvents += int64(len(batch))
+		a.uploadedBytes += int64(size)
+		a.lastUpload = time.Now().UTC()
+		a.lastObject = key
+		a.mutex.Unlock()
+	}
+}
+
+// upload writes batch as one object, returning its key and size.
+func (a *Archiver) upload(ctx context.Context, batch []models.NavigationEvent) (string, int, error) {
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	encoder := json.NewEncoder(gz)
+	for i := range batch {
+		if err := encoder.Encode(&batch[i]); err != nil {
+			return "", 0, err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return "", 0, err
+	}
+
+	key, err := a.objectKey(time.Now())
+	if err != nil {
+		return "", 0, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, a.config.Timeout)
+	defer cancel()
+	if err := a.uploader.Put(ctx, key, objectContentType, body.Bytes()); err != nil {
+		return "", 0, err
+	}
+	return key, body.Len(), nil
+}
+
+// objectKey names an object uploaded at t under the prefix, partitioned by
+// UTC hour. The random suffix keeps instances sharing a bucket apart.
+func (a *Archiver) objectKey(t time.Time) (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	t = t.UTC()
+	return a.config.Prefix + t.Format("2006/01/02/15/20060102T150405.000Z") + "-" + hex.EncodeToString(suffix) + ".ndjson.gz", nil
+}
+
+func (a *Archiver) Stats() *models.ArchiveStats {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return &models.ArchiveStats{
+		Bucket:         a.config.Bucket,
+		Objects:        a.objects,
+		ArchivedEvents: a.archivedEvents,
+		UploadedBytes:  a.uploadedBytes,
+		FailedUploads:  a.failedUploads,
+		PendingEvents:  len(a.pending),
+		DroppedEvents:  a.droppedEvents,
+		LastUpload:     a.lastUpload,
+		LastObject:     a.lastObject,
+		LastError:      a.lastError,
+		LastErrorAt:    a.lastErrorAt,
+	}
+}
@@ -0,0 +1,124 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+type fakeUploader struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+	err     error
+}
+
+func (u *fakeUploader) Put(ctx context.Context, key, contentType string, body []byte) error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	if u.err != nil {
+		return u.err
+	}
+	if u.objects == nil {
+		u.objects = make(map[string][]byte)
+	}
+	u.objects[key] = body
+	return nil
+}
+
+// lines returns the number of events in each object.
+func (u *fakeUploader) lines(t *testing.T) map[string]int {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	lines := make(map[string]int)
+	for key, body := range u.objects {
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Failed to decompress %s: %v", key, err)
+		}
+		scanner := bufio.NewScanner(gz)
+		for scanner.Scan() {
+			lines[key]++
+		}
+	}
+	return lines
+}
+
+func testConfig() models.ArchiveConfig {
+	config := models.DefaultConfiguration().Archive
+	config.Bucket = "archive"
+	config.Interval = time.Hour
+	config.BatchSize = 2
+	config.MaxPendingEvents = 4
+	return config
+}
+
+func TestArchiver_Flush(t *testing.T) {
+	uploader := &fakeUploader{err: errors.New("unavailable")}
+	archiver := NewArchiver(testConfig(), uploader)
+	for i := 0; i < 5; i++ {
+		archiver.Record(models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/"})
+	}
+
+	if err := archiver.Flush(context.Background()); err == nil {
+		t.Fatal("Expected the failed upload to be reported")
+	}
+	stats := archiver.Stats()
+	if stats.PendingEvents != 4 || stats.DroppedEvents != 1 || stats.FailedUploads != 1 || stats.LastError == "" {
+		t.Errorf("Expected 4 events kept after a failure and 1 dropped, got %+v", stats)
+	}
+
+	uploader.err = nil
+	if err := archiver.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	lines := uploader.lines(t)
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 objects, got %v", lines)
+	}
+	for key, count := range lines {
+		if !strings.HasPrefix(key, "events/") || !strings.HasSuffix(key, ".ndjson.gz") || count != 2 {
+			t.Errorf("Expected 2 events under the prefix, got %d in %s", count, key)
+		}
+	}
+	stats = archiver.Stats()
+	if stats.Objects != 2 || stats.ArchivedEvents != 4 || stats.PendingEvents != 0 || stats.UploadedBytes == 0 {
+		t.Errorf("Expected 4 events archived in 2 objects, got %+v", stats)
+	}
+}
+
+func TestArchiver_UploadsFullBatchesAndOnStop(t *testing.T) {
+	uploader := &fakeUploader{}
+	archiver := NewArchiver(testConfig(), uploader)
+	archiver.Start()
+
+	archiver.Record(models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/"})
+	archiver.Record(models.NavigationEvent{VisitorID: "visitor2", URL: "https://example.com/"})
+	deadline := time.Now().Add(time.Second)
+	for archiver.Stats().Objects == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if objects := archiver.Stats().Objects; objects != 1 {
+		t.Fatalf("Expected a full batch to be uploaded, got %d objects", objects)
+	}
+
+	archiver.Record(models.NavigationEvent{VisitorID: "visitor3", URL: "https://example.com/"})
+	if err := archiver.Stop(context.Background()); err != nil {
+		t.Fatalf("Failed to stop: %v", err)
+	}
+	if err := archiver.Stop(context.Background()); err != nil {
+		t.Errorf("Expected a second stop to succeed, got %v", err)
+	}
+	if stats := archiver.Stats(); stats.ArchivedEvents != 3 || stats.PendingEvents != 0 {
+		t.Errorf("Expected every event archived by stop, got %+v", stats)
+	}
+}
@@ -0,0 +1,295 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+var (
+	ErrMalformedToken   = errors.New("token is malformed")
+	ErrInvalidSignature = errors.New("token signature is invalid")
+	ErrTokenExpired     = errors.New("token has expired")
+	ErrTokenNotYetValid = errors.New("token is not valid yet")
+	ErrInvalidClaims    = errors.New("token issuer or audience is not accepted")
+	ErrUnknownKey       = errors.New("token is signed with an unknown key")
+)
+
+// jwksMinInterval is the least time between JWKS fetches triggered by
+// tokens naming an unknown key, so forged kids can't hammer the JWKS URL.
+const jwksMinInterval = 10 * time.Second
+
+// Claims is what a verified token grants.
+type Claims struct {
+	Subject string
+	Tenant  string
+	Scopes  map[string]bool
+}
+
+// HasScope reports whether the token grants scope.
+func (c *Claims) HasScope(scope string) bool {
+	return c.Scopes[scope]
+}
+
+type claimsKey struct{}
+
+// WithClaims returns a copy of ctx carrying claims.
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFrom returns the claims of the request's verified token, if any.
+func ClaimsFrom(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+// JWTVerifier checks bearer tokens signed with the configured algorithm.
+// Tokens declaring any other algorithm, including none, are rejected.
+type JWTVerifier struct {
+	config    models.JWTConfig
+	publicKey *rsa.PublicKey
+	client    *http.Client
+	now       func() time.Time
+
+	mutex     sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// NewJWTVerifier returns a verifier for config, which must be valid.
+func NewJWTVerifier(config models.JWTConfig) *JWTVerifier {
+	v := &JWTVerifier{
+		config: config,
+		client: &http.Client{Timeout: 5 * time.Second},
+		now:    time.Now,
+	}
+	if config.Algorithm == models.JWTAlgorithmRS256 && config.PublicKey != "" {
+		v.publicKey, _ = models.ParseRSAPublicKey(config.PublicKey)
+	}
+	return v
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// Verify checks token's signature and registered claims, and returns the
+// scopes and tenant it grants.
+func (v *JWTVerifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrMalformedToken
+	}
+	if header.Algorithm != v.config.Algorithm {
+		return nil, fmt.Errorf("%w: algorithm %q is not accepted", ErrInvalidSignature, header.Algorithm)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	if err := v.verifySignature(header.KeyID, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+	if err := v.checkRegistered(claims); err != nil {
+		return nil, err
+	}
+	return v.grants(claims), nil
+}
+
+func (v *JWTVerifier) verifySignature(keyID, signed string, signature []byte) error {
+	if v.config.Algorithm == models.JWTAlgorithmHS256 {
+		mac := hmac.New(sha256.New, []byte(v.config.Secret))
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+
+	key := v.publicKey
+	if v.config.PublicKey == "" {
+		var err error
+		if key, err = v.jwksKey(keyID); err != nil {
+			return err
+		}
+	}
+	if key == nil {
+		return ErrUnknownKey
+	}
+	digest := sha256.Sum256([]byte(signed))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// checkRegistered checks exp, which is required, nbf, iss and aud.
+func (v *JWTVerifier) checkRegistered(claims map[string]interface{}) error {
+	now := v.now()
+	leeway := v.config.Leeway
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("%w: exp is required", ErrMalformedToken)
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(leeway)) {
+		return ErrTokenExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(leeway).Before(time.Unix(int64(nbf), 0)) {
+		return ErrTokenNotYetValid
+	}
+
+	if v.config.Issuer != "" && claims["iss"] != v.config.Issuer {
+		return ErrInvalidClaims
+	}
+	if v.config.Audience != "" && !containsString(claims["aud"], v.config.Audience) {
+		return ErrInvalidClaims
+	}
+	return nil
+}
+
+// grants maps the scope and tenant claims to Claims.
+func (v *JWTVerifier) grants(claims map[string]interface{}) *Claims {
+	granted := &Claims{Scopes: make(map[string]bool)}
+	granted.Subject, _ = claims["sub"].(string)
+	granted.Tenant, _ = claims[v.config.TenantClaim].(string)
+
+	var values []string
+	switch scope := claims[v.config.ScopeClaim].(type) {
+	case string:
+		values = strings.Fields(scope)
+	case []interface{}:
+		for _, value := range scope {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	for _, value := range values {
+		if len(v.config.Scopes) == 0 {
+			granted.Scopes[value] = true
+			continue
+		}
+		for _, scope := range v.config.Scopes[value] {
+			granted.Scopes[scope] = true
+		}
+	}
+	return granted
+}
+
+// jwksKey returns the JWKS key with id, fetching the set again when it is
+// older than JWKSRefresh or doesn't have the key.
+func (v *JWTVerifier) jwksKey(id string) (*rsa.PublicKey, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	now := v.now()
+	stale := now.Sub(v.fetchedAt) >= v.config.JWKSRefresh
+	key, known := v.keys[id]
+	if stale || (!known && now.Sub(v.fetchedAt) >= jwksMinInterval) {
+		keys, err := v.fetchJWKS()
+		if err != nil {
+			if known {
+				// Keep trusting the key we have until the set can be fetched
+				return key, nil
+			}
+			return nil, fmt.Errorf("%w: %v", ErrUnknownKey, err)
+		}
+		v.keys = keys
+		v.fetchedAt = now
+		key, known = keys[id]
+	}
+	if !known {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+type jwks struct {
+	Keys []struct {
+		KeyType string `json:"kty"`
+		KeyID   string `json:"kid"`
+		Use     string `json:"use"`
+		N       string `json:"n"`
+		E       string `json:"e"`
+	} `json:"keys"`
+}
+
+func (v *JWTVerifier) fetchJWKS() (map[string]*rsa.PublicKey, error) {
+	resp, err := v.client.Get(v.config.JWKSURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS responded with status %d", resp.StatusCode)
+	}
+	var set jwks
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %v", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.KeyType != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+		keys[k.KeyID] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// containsString reports whether claim, a string or an array of them,
+// includes want.
+func containsString(claim interface{}, want string) bool {
+	switch claim := claim.(type) {
+	case string:
+		return claim == want
+	case []interface{}:
+		for _, value := range claim {
+			if value == want {
+				return true
+			}
+		}
+	}
+	return false
+}
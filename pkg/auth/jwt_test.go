@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+var testNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to encode token segment: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(t *testing.T, secret string, header, claims map[string]interface{}) string {
+	signed := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	signed := encodeSegment(t, map[string]interface{}{"alg": "RS256", "kid": kid}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func newTestVerifier(config models.JWTConfig) *JWTVerifier {
+	v := NewJWTVerifier(config)
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func hs256Config() models.JWTConfig {
+	config := models.DefaultConfiguration().Auth.JWT
+	config.Secret = "secret"
+	return config
+}
+
+func TestJWTVerifier_HS256(t *testing.T) {
+	v := newTestVerifier(hs256Config())
+	header := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	exp := testNow.Add(time.Hour).Unix()
+
+	token := signHS256(t, "secret", header, map[string]interface{}{"sub": "svc", "scope": "ingest query", "tenant": "acme", "exp": exp})
+	claims, err := v.Verify(token)
+	if err != nil {
+		t.Fatalf("Expected a valid token, got %v", err)
+	}
+	if claims.Subject != "svc" || claims.Tenant != "acme" || !claims.HasScope("ingest") || !claims.HasScope("query") || claims.HasScope("admin") {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"wrong secret", signHS256(t, "other", header, map[string]interface{}{"exp": exp}), ErrInvalidSignature},
+		{"alg none", encodeSegment(t, map[string]interface{}{"alg": "none"}) + "." + encodeSegment(t, map[string]interface{}{"exp": exp}) + ".", ErrInvalidSignature},
+		{"expired", signHS256(t, "secret", header, map[string]interface{}{"exp": testNow.Add(-time.Hour).Unix()}), ErrTokenExpired},
+		{"not yet valid", signHS256(t, "secret", header, map[string]interface{}{"exp": exp, "nbf": testNow.Add(time.Hour).Unix()}), ErrTokenNotYetValid},
+		{"no exp", signHS256(t, "secret", header, map[string]interface{}{}), ErrMalformedToken},
+		{"two segments", "a.b", ErrMalformedToken},
+	}
+	for _, tt := range tests {
+		if _, err := v.Verify(tt.token); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	// Within the leeway an expired token is still accepted
+	token = signHS256(t, "secret", header, map[string]interface{}{"exp": testNow.Add(-10 * time.Second).Unix()})
+	if _, err := v.Verify(token); err != nil {
+		t.Errorf("Expected a token expired within the leeway to be accepted, got %v", err)
+	}
+}
+
+func TestJWTVerifier_IssuerAudienceAndScopeMapping(t *testing.T) {
+	config := hs256Config()
+	config.Issuer = "https://auth.example.com"
+	config.Audience = "nav-tracker"
+	config.ScopeClaim = "permissions"
+	config.Scopes = map[string][]string{"analytics:write": {"ingest"}, "analytics:admin": {"query", "admin"}}
+	v := newTestVerifier(config)
+	header := map[string]interface{}{"alg": "HS256"}
+	exp := testNow.Add(time.Hour).Unix()
+
+	token := signHS256(t, "secret", header, map[string]interface{}{
+		"iss":         "https://auth.example.com",
+		"aud":         []string{"other", "nav-tracker"},
+		"permissions": []string{"analytics:admin", "unmapped"},
+		"exp":         exp,
+	})
+	claims, err := v.Verify(token)
+	if err != nil {
+		t.Fatalf("Expected a valid token, got %v", err)
+	}
+	if claims.HasScope("ingest") || !claims.HasScope("query") || !claims.HasScope("admin") || claims.HasScope("unmapped") {
+		t.Errorf("Expected query and admin scopes, got %+v", claims.Scopes)
+	}
+
+	token = signHS256(t, "secret", header, map[string]interface{}{"iss": "https://auth.example.com", "aud": "someone-else", "exp": exp})
+	if _, err := v.Verify(token); !errors.Is(err, ErrInvalidClaims) {
+		t.Errorf("Expected the wrong audience to be refused, got %v", err)
+	}
+}
+
+func TestJWTVerifier_RS256PublicKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+
+	config := models.DefaultConfiguration().Auth.JWT
+	config.Algorithm = models.JWTAlgorithmRS256
+	config.PublicKey = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Expected a valid config, got %v", err)
+	}
+	v := newTestVerifier(config)
+
+	claims := map[string]interface{}{"scope": "admin", "exp": testNow.Add(time.Hour).Unix()}
+	if _, err := v.Verify(signRS256(t, key, "", claims)); err != nil {
+		t.Errorf("Expected a valid token, got %v", err)
+	}
+
+	// An HS256 token signed with the public key must not pass as RS256
+	forged := signHS256(t, config.PublicKey, map[string]interface{}{"alg": "HS256"}, claims)
+	if _, err := v.Verify(forged); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected the algorithm switch to be refused, got %v", err)
+	}
+}
+
+func TestJWTVerifier_JWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	fetches := 0
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer jwksServer.Close()
+
+	config := models.DefaultConfiguration().Auth.JWT
+	config.Algorithm = models.JWTAlgorithmRS256
+	config.JWKSURL = jwksServer.URL
+	v := NewJWTVerifier(config)
+	now := testNow
+	v.now = func() time.Time { return now }
+
+	claims := map[string]interface{}{"scope": "query", "exp": testNow.Add(time.Hour).Unix()}
+	for i := 0; i < 2; i++ {
+		if _, err := v.Verify(signRS256(t, key, "key-1", claims)); err != nil {
+			t.Fatalf("Expected a valid token, got %v", err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("Expected the JWKS to be fetched once, got %d", fetches)
+	}
+
+	// Unknown keys trigger a refetch, at most once per jwksMinInterval
+	for i := 0; i < 3; i++ {
+		if _, err := v.Verify(signRS256(t, key, "key-2", claims)); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("Expected an unknown key, got %v", err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("Expected no refetch within the minimum interval, got %d fetches", fetches)
+	}
+	now = now.Add(jwksMinInterval)
+	v.Verify(signRS256(t, key, "key-2", claims))
+	if fetches != 2 {
+		t.Errorf("Expected a refetch for an unknown key, got %d fetches", fetches)
+	}
+}
@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var (
+	ErrReplayedNonce      = errors.New("nonce has already been used")
+	ErrTimestampOutOfSync = errors.New("timestamp outside validity window")
+	ErrMissingNonce       = errors.New("nonce is required")
+)
+
+// NonceStore remembers nonces per key for the signature validity window so a
+// signed request cannot be replayed. Entries expire once their timestamp leaves
+// the window, after which the timestamp check alone rejects them. When path is
+// set the store is loaded from and saved to that file.
+type NonceStore struct {
+	window time.Duration
+	path   string
+	nonces map[string]map[string]time.Time
+	mutex  sync.Mutex
+	now    func() time.Time
+
+	replays int64
+	stale   int64
+	pruned  int64
+
+	started  bool
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+type NonceStats struct {
+	Tracked int   `json:"tracked"`
+	Replays int64 `json:"replays"`
+	Stale   int64 `json:"stale"`
+	Pruned  int64 `json:"pruned"`
+}
+
+type nonceFileEntry struct {
+	Key       string    `json:"key"`
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewNonceStore returns a store for window, loaded from path when set. If
+// the file cannot be loaded the store starts empty, still saving to path,
+// and the error is returned with it.
+func NewNonceStore(window time.Duration, path string) (*NonceStore, error) {
+	store := &NonceStore{
+		window: window,
+		path:   path,
+		nonces: make(map[string]map[string]time.Time),
+		now:    time.Now,
+		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	if path != "" {
+		if err := store.load(); err != nil {
+			return store, err
+		}
+	}
+
+	return store, nil
+}
+
+// Check accepts nonce for key if timestamp is within the window and the nonce
+// has not been seen before, recording it; otherwise it returns an error.
+func (s *NonceStore) Check(key, nonce string, timestamp time.Time) error {
+	if nonce == "" {
+		return ErrMissingNonce
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	skew := now.Sub(timestamp)
+	if skew > s.window || skew < -s.window {
+		s.stale++
+		return ErrTimestampOutOfSync
+	}
+
+	keyNonces := s.nonces[key]
+	if keyNonces == nil {
+		keyNonces = make(map[string]time.Time)
+		s.nonces[key] = keyNonces
+	}
+
+	if expiresAt, seen := keyNonces[nonce]; seen && expiresAt.After(now) {
+		s.replays++
+		return ErrReplayedNonce
+	}
+
+	keyNonces[nonce] = timestamp.Add(s.window)
+	return nil
+}
+
+// Prune drops expired nonces and returns how many were removed.
+func (s *NonceStore) Prune() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	removed := 0
+	for key, keyNonces := range s.nonces {
+		for nonce, expiresAt := range keyNonces {
+			if !expiresAt.After(now) {
+				delete(keyNonces, nonce)
+				removed++
+			}
+		}
+		if len(keyNonces) == 0 {
+			delete(s.nonces, key)
+		}
+	}
+
+	s.pruned += int64(removed)
+	return removed
+}
+
+func (s *NonceStore) Stats() NonceStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tracked := 0
+	for _, keyNonces := range s.nonces {
+		tracked += len(keyNonces)
+	}
+
+	return NonceStats{
+		Tracked: tracked,
+		Replays: s.replays,
+		Stale:   s.stale,
+		Pruned:  s.pruned,
+	}
+}
+
+// Start prunes (and, with persistence, saves) the store every interval until Stop.
+func (s *NonceStore) Start(interval time.Duration) {
+	s.started = true
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.Prune()
+				if err := s.Save(); err != nil {
+					log.Printf("Nonce store save failed: %v", err)
+				}
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts background pruning, if started, and saves the store.
+func (s *NonceStore) Stop() error {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	if s.started {
+		<-s.done
+	}
+	return s.Save()
+}
+
+// Save writes unexpired nonces to the store's path; it is a no-op without one.
+func (s *NonceStore) Save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mutex.Lock()
+	now := s.now()
+	entries := make([]nonceFileEntry, 0)
+	for key, keyNonces := range s.nonces {
+		for nonce, expiresAt := range keyNonces {
+			if expiresAt.After(now) {
+				entries = append(entries, nonceFileEntry{Key: key, Nonce: nonce, ExpiresAt: expiresAt})
+			}
+		}
+	}
+	s.mutex.Unlock()
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("encode nonces: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("save nonces: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("save nonces: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("save nonces: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *NonceStore) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("load nonces: %w", err)
+	}
+
+	var entries []nonceFileEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("decode nonces: %w", err)
+	}
+
+	now := s.now()
+	for _, entry := range entries {
+		if !entry.ExpiresAt.After(now) {
+			continue
+		}
+		if s.nonces[entry.Key] == nil {
+			s.nonces[entry.Key] = make(map[string]time.Time)
+		}
+		s.nonces[entry.Key][entry.Nonce] = entry.ExpiresAt
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestNonceStore(t *testing.T, path string, now *time.Time) *NonceStore {
+	store, err := NewNonceStore(5*time.Minute, path)
+	if err != nil {
+		t.Fatalf("Failed to create nonce store: %v", err)
+	}
+	store.now = func() time.Time { return *now }
+	return store
+}
+
+func TestNonceStore_RejectsReplay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestNonceStore(t, "", &now)
+
+	if err := store.Check("key1", "abc", now); err != nil {
+		t.Fatalf("Expected first use to succeed, got %v", err)
+	}
+
+	if err := store.Check("key1", "abc", now); err != ErrReplayedNonce {
+		t.Errorf("Expected ErrReplayedNonce, got %v", err)
+	}
+
+	if err := store.Check("key2", "abc", now); err != nil {
+		t.Errorf("Expected nonce to be scoped per key, got %v", err)
+	}
+
+	if err := store.Check("key1", "", now); err != ErrMissingNonce {
+		t.Errorf("Expected ErrMissingNonce, got %v", err)
+	}
+
+	stats := store.Stats()
+	if stats.Replays != 1 || stats.Tracked != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestNonceStore_RejectsOutOfWindowTimestamps(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestNonceStore(t, "", &now)
+
+	if err := store.Check("key1", "old", now.Add(-6*time.Minute)); err != ErrTimestampOutOfSync {
+		t.Errorf("Expected ErrTimestampOutOfSync for old timestamp, got %v", err)
+	}
+
+	if err := store.Check("key1", "future", now.Add(6*time.Minute)); err != ErrTimestampOutOfSync {
+		t.Errorf("Expected ErrTimestampOutOfSync for future timestamp, got %v", err)
+	}
+
+	if stats := store.Stats(); stats.Stale != 2 {
+		t.Errorf("Expected 2 stale attempts, got %d", stats.Stale)
+	}
+}
+
+func TestNonceStore_Prune(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestNonceStore(t, "", &now)
+
+	_ = store.Check("key1", "a", now.Add(-4*time.Minute))
+	_ = store.Check("key1", "b", now)
+
+	now = now.Add(2 * time.Minute)
+	if removed := store.Prune(); removed != 1 {
+		t.Errorf("Expected 1 pruned nonce, got %d", removed)
+	}
+
+	if stats := store.Stats(); stats.Tracked != 1 || stats.Pruned != 1 {
+		t.Errorf("Unexpected stats after prune: %+v", stats)
+	}
+}
+
+func TestNonceStore_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nonces.json")
+	now := time.Now()
+
+	store := newTestNonceStore(t, path, &now)
+	_ = store.Check("key1", "abc", now)
+	if err := store.Stop(); err != nil {
+		t.Fatalf("Failed to save nonce store: %v", err)
+	}
+
+	reloaded, err := NewNonceStore(5*time.Minute, path)
+	if err != nil {
+		t.Fatalf("Failed to reload nonce store: %v", err)
+	}
+	reloaded.now = func() time.Time { return now }
+
+	if err := reloaded.Check("key1", "abc", now); err != ErrReplayedNonce {
+		t.Errorf("Expected replay to be rejected after reload, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// Headers of signed requests
+const (
+	SiteHeader      = "X-Site-ID"
+	SignatureHeader = "X-Signature"
+)
+
+var (
+	ErrMissingSignature   = errors.New("request is not signed")
+	ErrMalformedSignature = errors.New("signature is malformed")
+	ErrUnknownSite        = errors.New("site is unknown")
+	ErrSignatureExpired   = errors.New("signature timestamp is outside the tolerance")
+	ErrSignatureMismatch  = errors.New("signature does not match the payload")
+)
+
+// MaxNonceLength bounds the nonces a signature may carry.
+const MaxNonceLength = 64
+
+// Sign returns the X-Signature value for payload signed with secret at
+// timestamp with nonce: "t=<unix seconds>,n=<nonce>,v1=<hex HMAC-SHA256>",
+// where the MAC covers the decimal timestamp, a period, the nonce, a period
+// and the payload. Each request must be signed with a new nonce.
+func Sign(secret, nonce string, timestamp time.Time, payload []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",n=" + nonce + ",v1=" + hex.EncodeToString(signatureMAC([]byte(secret), t, nonce, payload))
+}
+
+// NewNonce returns a random nonce to sign a request with.
+func NewNonce() string {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		panic(fmt.Sprintf("auth: read random nonce: %v", err))
+	}
+	return hex.EncodeToString(nonce)
+}
+
+func signatureMAC(secret []byte, timestamp, nonce string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write([]byte(nonce))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// validNonce reports whether nonce is 1 to MaxNonceLength letters, digits,
+// hyphens and underscores.
+func validNonce(nonce string) bool {
+	if nonce == "" || len(nonce) > MaxNonceLength {
+		return false
+	}
+	for _, c := range nonce {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// SignatureVerifier checks the signatures of payloads from the configured
+// sites, and refuses a signature whose nonce the site has already used.
+type SignatureVerifier struct {
+	secrets   map[string][]byte
+	tolerance time.Duration
+	nonces    *NonceStore
+	now       func() time.Time
+}
+
+// NewSignatureVerifier returns a verifier remembering nonces in nonces, whose
+// window must be at least config's tolerance.
+func NewSignatureVerifier(config models.SigningConfig, nonces *NonceStore) *SignatureVerifier {
+	secrets := make(map[string][]byte, len(config.Secrets))
+	for site, secret := range config.Secrets {
+		secrets[site] = []byte(secret)
+	}
+	return &SignatureVerifier{secrets: secrets, tolerance: config.Tolerance, nonces: nonces, now: time.Now}
+}
+
+// Verify checks that signature, an X-Signature value, was made for payload
+// with site's secret within the tolerance of now, and that its nonce has not
+// been used by the site before. Nonces are only recorded for signatures
+// that match, so forged requests cannot use up a site's nonces.
+func (v *SignatureVerifier) Verify(site, signature string, payload []byte) error {
+	if signature == "" {
+		return ErrMissingSignature
+	}
+	secret, ok := v.secrets[site]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownSite, site)
+	}
+
+	var timestamp, nonce string
+	var macs [][]byte
+	for _, part := range strings.Split(signature, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "n":
+			nonce = value
+		case "v1":
+			// Several v1 values let a site rotate its secret
+			mac, err := hex.DecodeString(value)
+			if err != nil {
+				return ErrMalformedSignature
+			}
+			macs = append(macs, mac)
+		}
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(macs) == 0 {
+		return ErrMalformedSignature
+	}
+	if nonce == "" {
+		return ErrMissingNonce
+	}
+	if !validNonce(nonce) {
+		return ErrMalformedSignature
+	}
+
+	skew := v.now().Sub(time.Unix(seconds, 0))
+	if skew > v.tolerance || skew < -v.tolerance {
+		return ErrSignatureExpired
+	}
+	expected := signatureMAC(secret, timestamp, nonce, payload)
+	for _, mac := range macs {
+		if hmac.Equal(mac, expected) {
+			return v.nonces.Check(site, nonce, time.Unix(seconds, 0))
+		}
+	}
+	return ErrSignatureMismatch
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestSignatureVerifier(t *testing.T) {
+	nonces, err := NewNonceStore(5*time.Minute, "")
+	if err != nil {
+		t.Fatalf("Failed to create nonce store: %v", err)
+	}
+	nonces.now = func() time.Time { return testNow }
+	v := NewSignatureVerifier(models.SigningConfig{
+		Enabled:   true,
+		Secrets:   map[string]string{"shop": "secret", "blog": "secret"},
+		Tolerance: 5 * time.Minute,
+	}, nonces)
+	v.now = func() time.Time { return testNow }
+	payload := []byte(`{"visitor_id":"v1","url":"https://example.com/"}`)
+	signature := Sign("secret", "n1", testNow, payload)
+	parts := strings.Split(signature, ",")
+
+	tests := []struct {
+		name      string
+		site      string
+		signature string
+		payload   []byte
+		err       error
+	}{
+		{"valid", "shop", signature, payload, nil},
+		{"replayed", "shop", signature, payload, ErrReplayedNonce},
+		{"same nonce on another site", "blog", signature, payload, nil},
+		{"within tolerance", "shop", Sign("secret", "n2", testNow.Add(-4*time.Minute), payload), payload, nil},
+		{"rotated secret", "shop", Sign("old", "n3", testNow, payload) + "," + strings.Split(Sign("secret", "n3", testNow, payload), ",")[2], payload, nil},
+		{"missing", "shop", "", payload, ErrMissingSignature},
+		{"unknown site", "news", signature, payload, ErrUnknownSite},
+		{"no timestamp", "shop", parts[1] + "," + parts[2], payload, ErrMalformedSignature},
+		{"no nonce", "shop", parts[0] + "," + parts[2], payload, ErrMissingNonce},
+		{"invalid nonce", "shop", Sign("secret", "n 4", testNow, payload), payload, ErrMalformedSignature},
+		{"not hex", "shop", "t=1,n=n5,v1=xyz", payload, ErrMalformedSignature},
+		{"too old", "shop", Sign("secret", "n6", testNow.Add(-6*time.Minute), payload), payload, ErrSignatureExpired},
+		{"too new", "shop", Sign("secret", "n7", testNow.Add(6*time.Minute), payload), payload, ErrSignatureExpired},
+		{"wrong secret", "shop", Sign("wrong", "n8", testNow, payload), payload, ErrSignatureMismatch},
+		{"tampered payload", "shop", Sign("secret", "n9", testNow, payload), []byte(`{"visitor_id":"v2","url":"https://example.com/"}`), ErrSignatureMismatch},
+		{"tampered nonce", "shop", strings.Replace(signature, "n=n1", "n=n10", 1), payload, ErrSignatureMismatch},
+		// A mismatched signature does not use up its nonce
+		{"nonce of a forged signature", "shop", Sign("secret", "n8", testNow, payload), payload, nil},
+	}
+
+	for _, tt := range tests {
+		if err := v.Verify(tt.site, tt.signature, tt.payload); !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.err, err)
+		}
+	}
+
+	if stats := nonces.Stats(); stats.Replays != 1 {
+		t.Errorf("Expected 1 replay, got %+v", stats)
+	}
+}
+
+func TestNewNonce(t *testing.T) {
+	a, b := NewNonce(), NewNonce()
+	if a == b || !validNonce(a) {
+		t.Errorf("Expected distinct valid nonces, got %q and %q", a, b)
+	}
+}
@@ -0,0 +1,420 @@
+// Package client is a typed Go client for the nav-tracker HTTP API. It
+// retries requests the server rejected before handling them, and idempotent
+// requests that failed in transit, with exponential backoff.
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"nav-tracker/pkg/auth"
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/version"
+)
+
+// Config configures a Client. Zero values take the defaults from DefaultConfig.
+type Config struct {
+	// BaseURL is the server's address, such as http://localhost:8080.
+	BaseURL string
+	// APIKey is sent as X-API-Key when set.
+	APIKey string
+	// Token is sent as a bearer token when set, for servers in jwt auth mode.
+	Token string
+	// SiteID and SigningSecret sign every request when set, for servers that
+	// require signed ingest requests.
+	SiteID        string
+	SigningSecret string
+	// Timeout bounds each attempt of a request.
+	Timeout time.Duration
+	// MaxRetries is the number of attempts after the first; negative disables retries.
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, doubled for each one after.
+	RetryBackoff time.Duration
+	// MaxBackoff caps the wait between attempts, including a server's Retry-After.
+	MaxBackoff time.Duration
+	// HTTPClient sends the requests; http.DefaultClient when nil.
+	HTTPClient *http.Client
+}
+
+// DefaultConfig returns the configuration for a client of baseURL.
+func DefaultConfig(baseURL string) Config {
+	return Config{
+		BaseURL:      baseURL,
+		Timeout:      10 * time.Second,
+		MaxRetries:   3,
+		RetryBackoff: 100 * time.Millisecond,
+		MaxBackoff:   5 * time.Second,
+	}
+}
+
+// Client calls the nav-tracker API. It is safe for concurrent use.
+type Client struct {
+	config  Config
+	baseURL *url.URL
+	http    *http.Client
+}
+
+// APIError is an error response from the server.
+type APIError struct {
+	StatusCode int
+	Message    string
+	// Code is set for errors a client is expected to act on, such as a suspended tenant.
+	Code string
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("nav-tracker: %d %s (%s)", e.StatusCode, e.Message, e.Code)
+	}
+	return fmt.Sprintf("nav-tracker: %d %s", e.StatusCode, e.Message)
+}
+
+// New returns a client for config.BaseURL.
+func New(config Config) (*Client, error) {
+	defaults := DefaultConfig(config.BaseURL)
+	if config.Timeout == 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = defaults.MaxRetries
+	}
+	if config.RetryBackoff == 0 {
+		config.RetryBackoff = defaults.RetryBackoff
+	}
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = defaults.MaxBackoff
+	}
+
+	baseURL, err := url.Parse(strings.TrimSuffix(config.BaseURL, "/"))
+	if err != nil || (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+		return nil, fmt.Errorf("client: base URL %q must be an absolute http or https URL", config.BaseURL)
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{config: config, baseURL: baseURL, http: httpClient}, nil
+}
+
+// Ingest records one event. Requests that failed in transit are only retried
+// when the event has an EventID, so that the server can drop a duplicate.
+func (c *Client) Ingest(ctx context.Context, event *models.NavigationEvent) error {
+	body, err := json.Marshal(versioned(event))
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        "/api/v1/ingest",
+		body:        body,
+		contentType: "application/json",
+		idempotent:  event.EventID != "",
+	}, nil)
+}
+
+// IngestBatch records events through the import endpoint and returns its
+// final summary. Imports are not retried once the server has accepted them,
+// and the Timeout must leave room for the server's import rate limit.
+func (c *Client) IngestBatch(ctx context.Context, events []models.NavigationEvent) (*models.ImportProgress, error) {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for i := range events {
+		if err := encoder.Encode(versioned(&events[i])); err != nil {
+			return nil, err
+		}
+	}
+
+	var summary *models.ImportProgress
+	err := c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        "/api/v1/import",
+		query:       url.Values{"format": {"ndjson"}},
+		body:        body.Bytes(),
+		contentType: "application/x-ndjson",
+	}, func(r io.Reader) error {
+		// The import streams progress lines; the last one is the summary
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			var progress models.ImportProgress
+			if err := json.Unmarshal(scanner.Bytes(), &progress); err != nil {
+				return fmt.Errorf("client: invalid import progress: %w", err)
+			}
+			summary = &progress
+		}
+		return scanner.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if summary == nil || !summary.Complete {
+		return summary, errors.New("client: import ended before completing")
+	}
+	return summary, nil
+}
+
+// Stats returns the distinct visitors and page views of pageURL.
+func (c *Client) Stats(ctx context.Context, pageURL string) (*models.VisitorStats, error) {
+	stats := &models.VisitorStats{}
+	err := c.getJSON(ctx, "/api/v1/stats", url.Values{"url": {pageURL}, "detailed": {"true"}, "limit": {"1"}}, stats)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// TopURLs returns up to limit URLs with the most distinct visitors.
+func (c *Client) TopURLs(ctx context.Context, limit int) ([]*models.VisitorStats, error) {
+	var response struct {
+		URLs []*models.VisitorStats `json:"urls"`
+	}
+	if err := c.getJSON(ctx, "/api/v1/top-urls", url.Values{"limit": {strconv.Itoa(limit)}}, &response); err != nil {
+		return nil, err
+	}
+	return response.URLs, nil
+}
+
+// SystemStats returns tracker-wide statistics.
+func (c *Client) SystemStats(ctx context.Context) (*models.SystemStats, error) {
+	stats := &models.SystemStats{}
+	if err := c.getJSON(ctx, "/api/v1/system-stats", nil, stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// ExportOptions filters Export; zero values leave a filter out. Format is
+// ndjson, the default, or csv.
+type ExportOptions struct {
+	Format    string
+	URLPrefix string
+	From      time.Time
+	To        time.Time
+}
+
+// Export streams the visitor records matching options to w, as the server
+// writes them. The whole export must finish within the Timeout, and it is not
+// retried once records have been written.
+func (c *Client) Export(ctx context.Context, options ExportOptions, w io.Writer) error {
+	query := url.Values{}
+	if options.Format != "" {
+		query.Set("format", options.Format)
+	}
+	if options.URLPrefix != "" {
+		query.Set("url_prefix", options.URLPrefix)
+	}
+	if !options.From.IsZero() {
+		query.Set("from", options.From.Format(time.RFC3339))
+	}
+	if !options.To.IsZero() {
+		query.Set("to", options.To.Format(time.RFC3339))
+	}
+
+	return c.do(ctx, request{method: http.MethodGet, path: "/api/v1/export", query: query}, func(r io.Reader) error {
+		_, err := io.Copy(w, r)
+		return err
+	})
+}
+
+// DeleteURL erases pageURL and everything recorded for it, and returns the
+// deletion's audit log entry.
+func (c *Client) DeleteURL(ctx context.Context, pageURL string) (*models.DeletionAuditEntry, error) {
+	entry := &models.DeletionAuditEntry{}
+	err := c.do(ctx, request{
+		method:     http.MethodDelete,
+		path:       "/api/v1/urls",
+		query:      url.Values{"url": {pageURL}},
+		idempotent: true,
+	}, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// StartBulkJob starts a background job applying op and returns it as queued.
+func (c *Client) StartBulkJob(ctx context.Context, op models.BulkOperation) (*models.BulkJob, error) {
+	body, err := json.Marshal(op)
+	if err != nil {
+		return nil, err
+	}
+	job := &models.BulkJob{}
+	err = c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        "/api/v1/urls/bulk",
+		body:        body,
+		contentType: "application/json",
+	}, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(job)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// BulkJob returns the current status of the bulk job with id.
+func (c *Client) BulkJob(ctx context.Context, id string) (*models.BulkJob, error) {
+	job := &models.BulkJob{}
+	if err := c.getJSON(ctx, "/api/v1/urls/bulk/"+url.PathEscape(id), nil, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// versioned returns a copy of event that names the schema version it was
+// written in, so the server keeps reading it the same way as the schema evolves.
+func versioned(event *models.NavigationEvent) *models.NavigationEvent {
+	copied := *event
+	if copied.SchemaVersion == 0 {
+		copied.SchemaVersion = models.CurrentSchemaVersion
+	}
+	return &copied
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, into interface{}) error {
+	return c.do(ctx, request{method: http.MethodGet, path: path, query: query, idempotent: true}, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(into)
+	})
+}
+
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        []byte
+	contentType string
+	// idempotent requests are also retried after transport errors and
+	// gateway failures, which may have reached the server.
+	idempotent bool
+}
+
+// do sends req, retrying as allowed, and passes a successful response body
+// to read when it is set.
+func (c *Client) do(ctx context.Context, req request, read func(io.Reader) error) error {
+	target := *c.baseURL
+	target.Path += req.path
+	target.RawQuery = req.query.Encode()
+
+	backoff := c.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		wait, err := c.attempt(ctx, target.String(), req, read)
+		if err == nil {
+			return nil
+		}
+		if wait < 0 || attempt >= c.config.MaxRetries {
+			return err
+		}
+
+		if wait < backoff {
+			wait = backoff
+		}
+		if wait > c.config.MaxBackoff {
+			wait = c.config.MaxBackoff
+		}
+		backoff *= 2
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends req once. A failed attempt that may be retried returns the
+// wait the server asked for, or zero; a negative wait means it must not be.
+func (c *Client) attempt(ctx context.Context, target string, req request, read func(io.Reader) error) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+	if err != nil {
+		return -1, err
+	}
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", "nav-tracker-client/"+version.Version)
+	if c.config.APIKey != "" {
+		httpReq.Header.Set("X-API-Key", c.config.APIKey)
+	}
+	if c.config.Token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.config.Token)
+	}
+	if c.config.SigningSecret != "" {
+		payload := req.body
+		if payload == nil {
+			payload = []byte(httpReq.URL.RawQuery)
+		}
+		httpReq.Header.Set(auth.SiteHeader, c.config.SiteID)
+		httpReq.Header.Set(auth.SignatureHeader, auth.Sign(c.config.SigningSecret, auth.NewNonce(), time.Now(), payload))
+	}
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		// A cancelled caller stops retries; a timed out attempt may be retried
+		if !req.idempotent || errors.Is(ctx.Err(), context.Canceled) {
+			return -1, err
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if read == nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			return 0, nil
+		}
+		if err := read(resp.Body); err != nil {
+			return -1, err
+		}
+		return 0, nil
+	}
+
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	var errorResponse models.ErrorResponse
+	if data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10)); json.Unmarshal(data, &errorResponse) == nil && errorResponse.Error != "" {
+		apiErr.Message, apiErr.Code = errorResponse.Error, errorResponse.Code
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		// Rejected before the request was handled, so always safe to retry
+		return retryAfter(resp.Header.Get("Retry-After")), apiErr
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		if req.idempotent {
+			return 0, apiErr
+		}
+	}
+	return -1, apiErr
+}
+
+// retryAfter reads a Retry-After header given in seconds.
+func retryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
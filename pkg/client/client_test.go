@@ -0,0 +1,242 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/auth"
+	"nav-tracker/pkg/handlers"
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func newTestClient(t *testing.T, handler http.Handler, configure func(*Config)) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	config := DefaultConfig(server.URL)
+	config.RetryBackoff = time.Millisecond
+	if configure != nil {
+		configure(&config)
+	}
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client
+}
+
+func TestClient_AgainstHandlers(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	mux := http.NewServeMux()
+	mux.Handle("/api/v1/ingest", handlers.IngestHandler(tracker))
+	mux.Handle("/api/v1/stats", handlers.StatsHandler(tracker))
+	mux.Handle("/api/v1/top-urls", handlers.TopURLsHandler(tracker))
+	mux.Handle("/api/v1/system-stats", handlers.SystemStatsHandler(tracker))
+	mux.Handle("/api/v1/import", handlers.ImportHandler(tracker, 0))
+	client := newTestClient(t, mux, nil)
+	ctx := context.Background()
+
+	if err := client.Ingest(ctx, &models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/a"}); err != nil {
+		t.Fatalf("Failed to ingest: %v", err)
+	}
+	summary, err := client.IngestBatch(ctx, []models.NavigationEvent{
+		{VisitorID: "visitor2", URL: "https://example.com/a"},
+		{VisitorID: "visitor2", URL: "https://example.com/b"},
+		{VisitorID: "", URL: "https://example.com/b"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to ingest batch: %v", err)
+	}
+	if summary.Imported != 2 || summary.Failed != 1 {
+		t.Errorf("Expected 2 imported and 1 failed, got %+v", summary)
+	}
+
+	stats, err := client.Stats(ctx, "https://example.com/a")
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats.DistinctVisitors != 2 || stats.TotalPageViews != 2 {
+		t.Errorf("Expected 2 visitors and 2 views, got %+v", stats)
+	}
+
+	top, err := client.TopURLs(ctx, 1)
+	if err != nil {
+		t.Fatalf("Failed to get top URLs: %v", err)
+	}
+	if len(top) != 1 || top[0].URL != "https://example.com/a" {
+		t.Errorf("Expected /a to lead, got %+v", top)
+	}
+
+	system, err := client.SystemStats(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get system stats: %v", err)
+	}
+	if system.TotalPageViews != 3 {
+		t.Errorf("Expected 3 page views, got %d", system.TotalPageViews)
+	}
+
+	_, err = client.Stats(ctx, "")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected a 400 APIError, got %v", err)
+	}
+}
+
+func TestClient_ExportAndDelete(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	mux := http.NewServeMux()
+	mux.Handle("/api/v1/ingest", handlers.IngestHandler(tracker))
+	mux.Handle("/api/v1/export", handlers.ExportHandler(tracker))
+	mux.Handle("/api/v1/urls", handlers.URLsHandler(tracker))
+	mux.Handle("/api/v1/urls/bulk", handlers.BulkURLsHandler(tracker))
+	mux.Handle("/api/v1/urls/bulk/", handlers.BulkJobHandler(tracker))
+	client := newTestClient(t, mux, nil)
+	ctx := context.Background()
+
+	for _, url := range []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"} {
+		if err := client.Ingest(ctx, &models.NavigationEvent{VisitorID: "visitor1", URL: url}); err != nil {
+			t.Fatalf("Failed to ingest: %v", err)
+		}
+	}
+
+	var export strings.Builder
+	if err := client.Export(ctx, ExportOptions{Format: "csv", URLPrefix: "https://example.com/a"}, &export); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	if lines := strings.Count(export.String(), "\n"); lines != 2 {
+		t.Errorf("Expected a header and 1 record, got %q", export.String())
+	}
+
+	entry, err := client.DeleteURL(ctx, "https://example.com/a")
+	if err != nil {
+		t.Fatalf("Failed to delete URL: %v", err)
+	}
+	if entry.URLs != 1 || entry.Visitors != 1 {
+		t.Errorf("Expected 1 URL and 1 visitor deleted, got %+v", entry)
+	}
+
+	job, err := client.StartBulkJob(ctx, models.BulkOperation{Operation: models.BulkDelete, Pattern: "example\\.com/[bc]"})
+	if err != nil {
+		t.Fatalf("Failed to start bulk job: %v", err)
+	}
+	for job.Status != models.JobCompleted {
+		time.Sleep(time.Millisecond)
+		if job, err = client.BulkJob(ctx, job.ID); err != nil {
+			t.Fatalf("Failed to get bulk job: %v", err)
+		}
+	}
+	if job.Applied != 2 {
+		t.Errorf("Expected 2 URLs deleted, got %+v", job)
+	}
+}
+
+func TestClient_SignsRequests(t *testing.T) {
+	nonces, _ := auth.NewNonceStore(time.Minute, "")
+	verifier := auth.NewSignatureVerifier(models.SigningConfig{Enabled: true, Secrets: map[string]string{"shop": "secret"}, Tolerance: time.Minute}, nonces)
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == http.MethodGet {
+			body = []byte(r.URL.RawQuery)
+		}
+		if err := verifier.Verify(r.Header.Get(auth.SiteHeader), r.Header.Get(auth.SignatureHeader), body); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}), func(config *Config) {
+		config.SiteID = "shop"
+		config.SigningSecret = "secret"
+	})
+
+	if err := client.Ingest(context.Background(), &models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/"}); err != nil {
+		t.Errorf("Expected a signed ingest, got %v", err)
+	}
+	if _, err := client.Stats(context.Background(), "https://example.com/"); err != nil {
+		t.Errorf("Expected a signed query, got %v", err)
+	}
+	// Each request is signed with a new nonce
+	if err := client.Ingest(context.Background(), &models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/"}); err != nil {
+		t.Errorf("Expected a second signed ingest of the same event, got %v", err)
+	}
+}
+
+func TestClient_RetriesRejectedRequests(t *testing.T) {
+	var attempts atomic.Int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}), nil)
+
+	if err := client.Ingest(context.Background(), &models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/"}); err != nil {
+		t.Fatalf("Expected ingest to succeed after retries, got %v", err)
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts.Load())
+	}
+}
+
+func TestClient_RetriesOnlyIdempotentGatewayFailures(t *testing.T) {
+	var attempts atomic.Int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}), func(config *Config) { config.MaxRetries = 2 })
+	ctx := context.Background()
+
+	if err := client.Ingest(ctx, &models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/"}); err == nil {
+		t.Fatal("Expected ingest to fail")
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("Expected an event without an ID not to be retried, got %d attempts", attempts.Load())
+	}
+
+	attempts.Store(0)
+	if _, err := client.SystemStats(ctx); err == nil {
+		t.Fatal("Expected system stats to fail")
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("Expected a GET to be retried twice, got %d attempts", attempts.Load())
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}), func(config *Config) {
+		config.Timeout = 20 * time.Millisecond
+		config.MaxRetries = -1
+	})
+
+	start := time.Now()
+	if _, err := client.SystemStats(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the attempt to time out quickly, took %v", elapsed)
+	}
+}
+
+func TestNew_InvalidBaseURL(t *testing.T) {
+	for _, baseURL := range []string{"", "localhost:8080", "ftp://example.com"} {
+		if _, err := New(DefaultConfig(baseURL)); err == nil {
+			t.Errorf("Expected %q to be rejected", baseURL)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package clientip
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+
+	"nav-tracker/pkg/models"
+)
+
+// Resolver determines the IP a request originated from, looking through the
+// forwarding headers set by trusted reverse proxies.
+type Resolver struct {
+	trusted []*net.IPNet
+}
+
+// NewResolver returns a resolver trusting the given proxy IPs and CIDRs,
+// which must already have been validated.
+func NewResolver(trustedProxies []string) *Resolver {
+	resolver := &Resolver{}
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			resolver.trusted = append(resolver.trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			resolver.trusted = append(resolver.trusted, network)
+		}
+	}
+	return resolver
+}
+
+func (res *Resolver) isTrusted(ip net.IP) bool {
+	for _, network := range res.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the IP r originated from, or nil if it cannot be parsed.
+// Forwarding headers are ignored unless the peer is a trusted proxy; then
+// X-Forwarded-For is walked from the right, skipping trusted proxies, and
+// X-Real-IP is used when X-Forwarded-For is absent.
+func (res *Resolver) ClientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !res.isTrusted(ip) {
+		return ip
+	}
+
+	forwarded := r.Header.Values("X-Forwarded-For")
+	if len(forwarded) == 0 {
+		if realIP := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); realIP != nil {
+			return realIP
+		}
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(forwarded, ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			// Anything left of a malformed entry cannot be trusted
+			return ip
+		}
+		ip = hop
+		if !res.isTrusted(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// Anonymizer applies a PrivacyConfig.AnonymizeIP mode to client IPs.
+type Anonymizer struct {
+	mode string
+	salt string
+}
+
+func NewAnonymizer(config models.PrivacyConfig) *Anonymizer {
+	return &Anonymizer{mode: config.AnonymizeIP, salt: config.Salt}
+}
+
+// Anonymize returns ip as it should be stored on events.
+func (a *Anonymizer) Anonymize(ip net.IP) string {
+	switch a.mode {
+	case models.IPAnonymizeTruncate:
+		if v4 := ip.To4(); v4 != nil {
+			return v4.Mask(net.CIDRMask(24, 32)).String()
+		}
+		return ip.Mask(net.CIDRMask(48, 128)).String()
+	case models.IPAnonymizeHash:
+		sum := sha256.Sum256([]byte(a.salt + ip.String()))
+		return hex.EncodeToString(sum[:])
+	default:
+		return ip.String()
+	}
+}
+
+// AnonymizeString is Anonymize for an IP in text form. Anything that does
+// not parse as an IP is dropped, returning "".
+func (a *Anonymizer) AnonymizeString(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	return a.Anonymize(parsed)
+}
+
+// Enricher returns an ingest hook that sets the event's IP to the client IP,
+// replacing any IP the client supplied. The tracker anonymizes it when the
+// event is recorded.
+func Enricher(resolver *Resolver) func(r *http.Request, event *models.NavigationEvent) {
+	return func(r *http.Request, event *models.NavigationEvent) {
+		event.IP = ""
+		if ip := resolver.ClientIP(r); ip != nil {
+			event.IP = ip.String()
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package clientip
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestResolver_ClientIP(t *testing.T) {
+	resolver := NewResolver([]string{"10.0.0.0/8", "192.0.2.1"})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		expected   string
+	}{
+		{"direct", "203.0.113.7:4321", nil, "", "203.0.113.7"},
+		{"untrusted peer", "198.51.100.1:4321", []string{"203.0.113.7"}, "203.0.113.9", "198.51.100.1"},
+		{"trusted peer", "10.0.0.1:4321", []string{"203.0.113.7"}, "", "203.0.113.7"},
+		{"proxy chain", "10.0.0.1:4321", []string{"198.51.100.5, 203.0.113.7, 192.0.2.1"}, "", "203.0.113.7"},
+		{"repeated headers", "10.0.0.1:4321", []string{"203.0.113.7", "10.1.1.1"}, "", "203.0.113.7"},
+		{"all trusted", "10.0.0.1:4321", []string{"10.0.0.2, 10.0.0.3"}, "", "10.0.0.2"},
+		{"malformed entry", "10.0.0.1:4321", []string{"203.0.113.7, garbage, 10.0.0.2"}, "", "10.0.0.2"},
+		{"real ip", "10.0.0.1:4321", nil, "203.0.113.9", "203.0.113.9"},
+		{"ipv6 peer", "[2001:db8::1]:4321", []string{"203.0.113.7"}, "", "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/ingest", nil)
+		req.RemoteAddr = tt.remoteAddr
+		for _, value := range tt.forwarded {
+			req.Header.Add("X-Forwarded-For", value)
+		}
+		if tt.realIP != "" {
+			req.Header.Set("X-Real-IP", tt.realIP)
+		}
+
+		if ip := resolver.ClientIP(req); ip.String() != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, ip)
+		}
+	}
+}
+
+func TestAnonymizer_Anonymize(t *testing.T) {
+	tests := []struct {
+		mode     string
+		ip       string
+		expected string
+	}{
+		{models.IPAnonymizeNone, "203.0.113.7", "203.0.113.7"},
+		{"", "2001:db8::1", "2001:db8::1"},
+		{models.IPAnonymizeTruncate, "203.0.113.7", "203.0.113.0"},
+		{models.IPAnonymizeTruncate, "2001:db8:abcd:12::1", "2001:db8:abcd::"},
+	}
+
+	for _, tt := range tests {
+		anonymizer := NewAnonymizer(models.PrivacyConfig{AnonymizeIP: tt.mode})
+		if got := anonymizer.Anonymize(net.ParseIP(tt.ip)); got != tt.expected {
+			t.Errorf("%s %s: expected %s, got %s", tt.mode, tt.ip, tt.expected, got)
+		}
+	}
+
+	hasher := NewAnonymizer(models.PrivacyConfig{AnonymizeIP: models.IPAnonymizeHash, Salt: "pepper"})
+	hashed := hasher.Anonymize(net.ParseIP("203.0.113.7"))
+	if len(hashed) != 64 || hashed != hasher.Anonymize(net.ParseIP("203.0.113.7")) {
+		t.Errorf("Expected a stable SHA-256 digest, got %q", hashed)
+	}
+	if hashed == hasher.Anonymize(net.ParseIP("203.0.113.8")) {
+		t.Error("Expected different IPs to hash differently")
+	}
+}
+
+func TestAnonymizer_AnonymizeString(t *testing.T) {
+	anonymizer := NewAnonymizer(models.PrivacyConfig{AnonymizeIP: models.IPAnonymizeTruncate})
+	if got := anonymizer.AnonymizeString("203.0.113.7"); got != "203.0.113.0" {
+		t.Errorf("Expected 203.0.113.0, got %q", got)
+	}
+	for _, ip := range []string{"", "not-an-ip", "203.0.113.7:80"} {
+		if got := anonymizer.AnonymizeString(ip); got != "" {
+			t.Errorf("Expected %q to be dropped, got %q", ip, got)
+		}
+	}
+}
+
+func TestEnricher(t *testing.T) {
+	enrich := Enricher(NewResolver([]string{"10.0.0.1"}))
+
+	req := httptest.NewRequest("POST", "/ingest", nil)
+	req.RemoteAddr = "10.0.0.1:4321"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	event := &models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com", IP: "198.51.100.1"}
+	enrich(req, event)
+
+	if event.IP != "203.0.113.7" {
+		t.Errorf("Expected client-supplied IP to be replaced with 203.0.113.7, got %s", event.IP)
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"nav-tracker/pkg/models"
+)
+
+// ApplyEnv overlays config with the NAVTRACKER_* variables in environ, given
+// as KEY=value pairs. A variable is named by the upper-cased JSON path of a
+// field joined with underscores, e.g. NAVTRACKER_RATE_LIMIT_ENABLED=true or
+// NAVTRACKER_SNAPSHOT_FLUSH_INTERVAL=30s. Lists of strings are comma
+// separated; other lists, maps and whole sections take a JSON or YAML value,
+// e.g. NAVTRACKER_URL_CATEGORIES='[{"category":"blog","pattern":"/blog/"}]'.
+// PORT is honored as an alias of NAVTRACKER_PORT, which wins if both are set.
+func ApplyEnv(config *models.Configuration, environ []string) error {
+	fields := make(map[string]reflect.Value)
+	collectEnvFields(reflect.ValueOf(config).Elem(), strings.TrimSuffix(EnvPrefix, "_"), fields)
+
+	values := make(map[string]string)
+	legacyPort, hasLegacyPort := "", false
+	for _, entry := range environ {
+		key, value, ok := strings.Cut(entry, "=")
+		switch {
+		case !ok:
+		case key == "PORT":
+			legacyPort, hasLegacyPort = value, true
+		case strings.HasPrefix(key, EnvPrefix):
+			values[key] = value
+		}
+	}
+	if _, ok := values[EnvPrefix+"PORT"]; !ok && hasLegacyPort {
+		values[EnvPrefix+"PORT"] = legacyPort
+	}
+
+	// A section is applied before its fields, so NAVTRACKER_RATE_LIMIT_ENABLED
+	// overrides the enabled flag in NAVTRACKER_RATE_LIMIT
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("%s: unknown configuration variable", key)
+		}
+		if err := assignEnv(field, values[key], key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectEnvFields maps the variable name of every field of struct v, and of
+// the fields of nested sections, to the field.
+func collectEnvFields(v reflect.Value, prefix string, fields map[string]reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		name := jsonName(v.Type().Field(i))
+		if name == "" {
+			continue
+		}
+		key := prefix + "_" + strings.ToUpper(name)
+		field := v.Field(i)
+		fields[key] = field
+		if field.Kind() == reflect.Struct {
+			collectEnvFields(field, key, fields)
+		}
+	}
+}
+
+func assignEnv(field reflect.Value, value, key string) error {
+	var raw interface{}
+	switch {
+	case field.Kind() == reflect.String:
+		raw = value
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		items := []interface{}{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		raw = items
+	default:
+		// Scalars such as true, 10 and 30s parse as YAML too
+		if err := yaml.Unmarshal([]byte(value), &raw); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return assign(field, raw, key)
+}
@@ -0,0 +1,278 @@
+// Package config loads the service configuration from defaults, an optional
+// JSON or YAML file and NAVTRACKER_* environment variables.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"nav-tracker/pkg/models"
+)
+
+// EnvPrefix starts the name of every environment variable Load reads.
+const EnvPrefix = "NAVTRACKER_"
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// Load returns the default configuration overlaid with the file at path, if
+// path is not empty, and then with the NAVTRACKER_* variables in environ, so
+// the environment takes precedence over the file. The result is validated.
+func Load(path string, environ []string) (*models.Configuration, error) {
+	config := models.DefaultConfiguration()
+	if path != "" {
+		if err := LoadFile(config, path); err != nil {
+			return nil, err
+		}
+	}
+	if err := ApplyEnv(config, environ); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return config, nil
+}
+
+// LoadFile overlays config with the JSON (.json) or YAML (.yaml, .yml) file
+// at path. Keys are the configuration's JSON field names; sections and fields
+// that are absent keep their current values. Durations may be given as
+// strings such as "10m" or as integer nanoseconds.
+func LoadFile(config *models.Configuration, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	var raw interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&raw)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	default:
+		return fmt.Errorf("config file %s: unsupported extension, use .json, .yaml or .yml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	if raw == nil {
+		return nil
+	}
+
+	if err := assign(reflect.ValueOf(config).Elem(), raw, ""); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// assign stores raw, as decoded from JSON or YAML into an interface{}, in v.
+// path names v in error messages.
+func assign(v reflect.Value, raw interface{}, path string) error {
+	if v.Type() == durationType {
+		switch value := raw.(type) {
+		case string:
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("%s: invalid duration %q", path, value)
+			}
+			v.SetInt(int64(d))
+			return nil
+		default:
+			n, err := toInt(raw)
+			if err != nil {
+				return fmt.Errorf("%s: duration must be a string such as \"10m\" or nanoseconds", path)
+			}
+			v.SetInt(n)
+			return nil
+		}
+	}
+
+	if v.Type() == timeType {
+		switch value := raw.(type) {
+		case time.Time:
+			// YAML decodes unquoted timestamps itself
+			v.Set(reflect.ValueOf(value))
+			return nil
+		case string:
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return fmt.Errorf("%s: invalid time %q, expected RFC 3339 such as \"2027-01-01T00:00:00Z\"", path, value)
+			}
+			v.Set(reflect.ValueOf(t))
+			return nil
+		default:
+			return fmt.Errorf("%s: time must be an RFC 3339 string", path)
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		fields, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an object", path)
+		}
+		for key, value := range fields {
+			field, ok := fieldByJSONName(v, key)
+			if !ok {
+				return fmt.Errorf("%s: unknown field", joinPath(path, key))
+			}
+			if err := assign(field, value, joinPath(path, key)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		entries, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an object", path)
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(entries))
+		for key, value := range entries {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := assign(elem, value, joinPath(path, key)); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+		}
+		v.Set(m)
+	case reflect.Slice:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected a list", path)
+		}
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := assign(s.Index(i), item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Pointer:
+		if raw == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		elem := reflect.New(v.Type().Elem())
+		if err := assign(elem.Elem(), raw, path); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.String:
+		s, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected a string", path)
+		}
+		v.SetString(s)
+	case reflect.Bool:
+		b, ok := raw.(bool)
+		if !ok {
+			return fmt.Errorf("%s: expected true or false", path)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt(raw)
+		if err != nil {
+			return fmt.Errorf("%s: expected an integer", path)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := toInt(raw)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%s: expected a non-negative integer", path)
+		}
+		v.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat(raw)
+		if err != nil {
+			return fmt.Errorf("%s: expected a number", path)
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("%s: unsupported field type %s", path, v.Type())
+	}
+	return nil
+}
+
+// fieldByJSONName returns the field of struct v whose json tag is name.
+func fieldByJSONName(v reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		if jsonName(v.Type().Field(i)) == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// jsonName returns the name a field is encoded under, or "" if it is not encoded.
+func jsonName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func toInt(raw interface{}) (int64, error) {
+	switch n := raw.(type) {
+	case int:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint64:
+		return int64(n), nil
+	case float64:
+		if n != float64(int64(n)) {
+			return 0, fmt.Errorf("not an integer")
+		}
+		return int64(n), nil
+	case json.Number:
+		return n.Int64()
+	case string:
+		return strconv.ParseInt(n, 10, 64)
+	}
+	return 0, fmt.Errorf("not an integer")
+}
+
+func toFloat(raw interface{}) (float64, error) {
+	switch n := raw.(type) {
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case uint64:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	case json.Number:
+		return n.Float64()
+	case string:
+		return strconv.ParseFloat(n, 64)
+	}
+	return 0, fmt.Errorf("not a number")
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoad_YAML(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+port: "9090"
+session_idle_timeout: 45m
+rate_limit:
+  enabled: true
+  ip_requests_per_second: 5
+cors:
+  allowed_origins: ["https://*.example.com"]
+url_categories:
+  - category: blog
+    pattern: /blog/
+`)
+
+	config, err := Load(path, nil)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if config.Port != "9090" || config.SessionIdleTimeout != 45*time.Minute {
+		t.Errorf("Expected port 9090 and 45m timeout, got %s and %s", config.Port, config.SessionIdleTimeout)
+	}
+	if !config.RateLimit.Enabled || config.RateLimit.IPRequestsPerSecond != 5 {
+		t.Errorf("Expected rate limiting at 5 rps, got %+v", config.RateLimit)
+	}
+	if config.RateLimit.IPBurst == 0 {
+		t.Error("Expected fields missing from the file to keep their defaults")
+	}
+	if len(config.URLCategories) != 1 || config.URLCategories[0].Category != "blog" {
+		t.Errorf("Expected one category rule, got %+v", config.URLCategories)
+	}
+}
+
+func TestLoad_JSON(t *testing.T) {
+	path := writeFile(t, "config.json", `{"snapshot": {"flush_interval": 60000000000}, "dedup": {"ttl": "5m"}}`)
+
+	config, err := Load(path, nil)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if config.Snapshot.FlushInterval != time.Minute || config.Dedup.TTL != 5*time.Minute {
+		t.Errorf("Expected durations as nanoseconds and strings, got %s and %s", config.Snapshot.FlushInterval, config.Dedup.TTL)
+	}
+}
+
+func TestLoad_EnvOverridesFile(t *testing.T) {
+	path := writeFile(t, "config.yaml", "port: \"9090\"\nrate_limit:\n  enabled: false\n")
+	environ := []string{
+		"NAVTRACKER_PORT=7070",
+		"PORT=6060",
+		"NAVTRACKER_RATE_LIMIT={\"enabled\": true, \"ip_burst\": 3}",
+		"NAVTRACKER_RATE_LIMIT_IP_BURST=7",
+		"NAVTRACKER_DEDUP_TTL=30s",
+		"NAVTRACKER_DATA_QUALITY_EXCLUDED_VISITOR_IDS=test, bot",
+		"HOME=/root",
+	}
+
+	config, err := Load(path, environ)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if config.Port != "7070" {
+		t.Errorf("Expected NAVTRACKER_PORT to win over PORT and the file, got %s", config.Port)
+	}
+	if !config.RateLimit.Enabled || config.RateLimit.IPBurst != 7 {
+		t.Errorf("Expected field variable to override its section, got %+v", config.RateLimit)
+	}
+	if config.Dedup.TTL != 30*time.Second {
+		t.Errorf("Expected 30s dedup TTL, got %s", config.Dedup.TTL)
+	}
+	ids := config.DataQuality.ExcludedVisitorIDs
+	if len(ids) != 2 || ids[0] != "test" || ids[1] != "bot" {
+		t.Errorf("Expected comma separated IDs, got %v", ids)
+	}
+
+	config, err = Load("", []string{"PORT=6060"})
+	if err != nil || config.Port != "6060" {
+		t.Errorf("Expected PORT to set the port, got %v", err)
+	}
+}
+
+func TestLoad_Times(t *testing.T) {
+	sunset := time.Date(2027, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	yamlPath := writeFile(t, "config.yaml", "legacy_routes:\n  sunset: 2027-06-01T00:00:00Z\n")
+	jsonPath := writeFile(t, "config.json", `{"legacy_routes": {"sunset": "2027-06-01T00:00:00Z"}}`)
+	for _, path := range []string{yamlPath, jsonPath} {
+		config, err := Load(path, nil)
+		if err != nil {
+			t.Fatalf("Failed to load %s: %v", filepath.Base(path), err)
+		}
+		if !config.LegacyRoutes.Sunset.Equal(sunset) {
+			t.Errorf("%s: expected sunset %s, got %s", filepath.Base(path), sunset, config.LegacyRoutes.Sunset)
+		}
+	}
+
+	config, err := Load("", []string{"NAVTRACKER_LEGACY_ROUTES_SUNSET=2027-06-01T00:00:00Z"})
+	if err != nil {
+		t.Fatalf("Failed to load from the environment: %v", err)
+	}
+	if !config.LegacyRoutes.Sunset.Equal(sunset) {
+		t.Errorf("Expected sunset %s from the environment, got %s", sunset, config.LegacyRoutes.Sunset)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		environ []string
+		message string
+	}{
+		{"unknown field", "config.yaml", "rate_limit:\n  burst: 3\n", nil, "rate_limit.burst: unknown field"},
+		{"wrong type", "config.json", `{"rate_limit": {"enabled": "yes"}}`, nil, "rate_limit.enabled"},
+		{"bad duration", "config.yaml", "dedup:\n  ttl: soon\n", nil, "invalid duration"},
+		{"bad time", "config.json", `{"legacy_routes": {"sunset": "next june"}}`, nil, "invalid time"},
+		{"extension", "config.toml", "port = 1", nil, "unsupported extension"},
+		{"unknown variable", "", "", []string{"NAVTRACKER_NOPE=1"}, "NAVTRACKER_NOPE"},
+		{"invalid config", "", "", []string{"NAVTRACKER_TOP_URLS_MODE=fuzzy"}, "invalid configuration"},
+	}
+
+	for _, tt := range tests {
+		path := ""
+		if tt.file != "" {
+			path = writeFile(t, tt.file, tt.content)
+		}
+		_, err := Load(path, tt.environ)
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.message, err)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// Package dashboard serves a single-page operator dashboard built into the
+// binary, which polls the JSON API from the browser.
+package dashboard
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed static/index.html
+var index []byte
+
+// Handler serves the dashboard page on GET and HEAD requests.
+func Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(index)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler_ServesPage(t *testing.T) {
+	req := httptest.NewRequest("GET", "/dashboard", nil)
+	w := httptest.NewRecorder()
+	Handler()(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		t.Errorf("Expected HTML content type, got %s", contentType)
+	}
+	for _, endpoint := range []string{"/api/v1/top-urls", "/api/v1/system-stats", "/api/v1/ingest-activity", "/api/v1/actives"} {
+		if !strings.Contains(w.Body.String(), endpoint) {
+			t.Errorf("Expected dashboard to poll %s", endpoint)
+		}
+	}
+}
+
+func TestHandler_MethodNotAllowed(t *testing.T) {
+	req := httptest.NewRequest("POST", "/dashboard", nil)
+	w := httptest.NewRecorder()
+	Handler()(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
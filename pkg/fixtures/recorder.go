@@ -0,0 +1,101 @@
+package fixtures
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Recorder captures each request handled by the wrapped handler, with its
+// response, as a fixture in dir. It is meant for development servers only:
+// bodies are held in memory and every request is written to disk.
+type Recorder struct {
+	dir      string
+	sequence atomic.Uint64
+}
+
+func NewRecorder(dir string) *Recorder {
+	return &Recorder{dir: dir}
+}
+
+// Middleware records every request except WebSocket upgrades, which cannot
+// be replayed as a single request/response pair.
+func (rec *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		capture := &captureWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(capture, r)
+
+		fixture := &Fixture{
+			Name: fmt.Sprintf("%06d_%s_%s", rec.sequence.Add(1), r.Method, fixtureName(r.URL.Path)),
+			Request: Request{
+				Method:  r.Method,
+				Path:    r.URL.Path,
+				Query:   r.URL.RawQuery,
+				Headers: sanitizeHeaders(r.Header),
+				Body:    string(body),
+			},
+			Response: Response{
+				Status:      capture.status,
+				ContentType: capture.Header().Get("Content-Type"),
+				Body:        capture.body.String(),
+			},
+		}
+
+		if err := Save(rec.dir, fixture); err != nil {
+			log.Printf("Failed to record fixture %s: %v", fixture.Name, err)
+		}
+	})
+}
+
+// fixtureName turns a request path into a file-name-safe slug.
+func fixtureName(path string) string {
+	name := strings.Trim(path, "/")
+	if name == "" {
+		return "root"
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// captureWriter tees the response body while passing it through.
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (cw *captureWriter) WriteHeader(status int) {
+	cw.status = status
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *captureWriter) Write(b []byte) (int, error) {
+	cw.body.Write(b)
+	return cw.ResponseWriter.Write(b)
+}
+
+func (cw *captureWriter) Flush() {
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,69 @@
+package fixtures
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecorder_Middleware(t *testing.T) {
+	dir := t.TempDir()
+	recorder := NewRecorder(dir)
+
+	handler := recorder.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"success":true}`))
+	}))
+
+	req := httptest.NewRequest("POST", "/ingest?debug=1", strings.NewReader(`{"visitor_id":"v1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", "secret")
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated || w.Body.String() != `{"success":true}` {
+		t.Fatalf("Expected the response to pass through, got %d %q", w.Code, w.Body.String())
+	}
+
+	recorded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+	if len(recorded) != 1 {
+		t.Fatalf("Expected 1 fixture, got %d", len(recorded))
+	}
+
+	f := recorded[0]
+	if f.Name != "000001_POST_ingest" {
+		t.Errorf("Expected name 000001_POST_ingest, got %s", f.Name)
+	}
+	if f.Request.Query != "debug=1" || f.Request.Body != `{"visitor_id":"v1"}` {
+		t.Errorf("Expected query and body recorded, got %+v", f.Request)
+	}
+	if _, exists := f.Request.Headers["X-Api-Key"]; exists {
+		t.Error("Expected API key to be redacted")
+	}
+	if _, exists := f.Request.Headers["Authorization"]; exists {
+		t.Error("Expected Authorization to be redacted")
+	}
+	if f.Response.Status != http.StatusCreated || f.Response.ContentType != "application/json" {
+		t.Errorf("Expected response recorded, got %+v", f.Response)
+	}
+}
+
+func TestRecorder_SkipsWebSocket(t *testing.T) {
+	dir := t.TempDir()
+	handler := NewRecorder(dir).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "/api/v1/stream", nil)
+	req.Header.Set("Upgrade", "websocket")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	recorded, _ := Load(dir)
+	if len(recorded) != 0 {
+		t.Errorf("Expected no fixtures for WebSocket upgrades, got %d", len(recorded))
+	}
+}
@@ -0,0 +1,141 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// VolatileFields are JSON keys whose values legitimately change between runs
+// and are ignored when comparing bodies.
+var VolatileFields = map[string]bool{
+	"timestamp":    true,
+	"last_updated": true,
+	"generated_at": true,
+	"created_at":   true,
+	"started_at":   true,
+	"duration":     true,
+	"resume_token": true,
+	"checkpoint":   true,
+}
+
+// Replay sends f's request to baseURL and returns a description of every
+// difference from the recorded response. An empty result means it matched.
+func Replay(client *http.Client, baseURL string, f *Fixture) ([]string, error) {
+	target := strings.TrimSuffix(baseURL, "/") + f.Request.Path
+	if f.Request.Query != "" {
+		target += "?" + f.Request.Query
+	}
+
+	req, err := http.NewRequest(f.Request.Method, target, strings.NewReader(f.Request.Body))
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range f.Request.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []string
+	if resp.StatusCode != f.Response.Status {
+		diffs = append(diffs, fmt.Sprintf("status: recorded %d, got %d", f.Response.Status, resp.StatusCode))
+	}
+	return append(diffs, diffBodies(f.Response.Body, string(body))...), nil
+}
+
+// diffBodies compares JSON bodies structurally, ignoring VolatileFields, and
+// any other body line by line (which also covers NDJSON).
+func diffBodies(recorded, actual string) []string {
+	var diffs []string
+
+	recordedLines := strings.Split(strings.TrimSpace(recorded), "\n")
+	actualLines := strings.Split(strings.TrimSpace(actual), "\n")
+	if len(recordedLines) != len(actualLines) {
+		diffs = append(diffs, fmt.Sprintf("body: recorded %d lines, got %d", len(recordedLines), len(actualLines)))
+	}
+
+	for i := 0; i < len(recordedLines) && i < len(actualLines); i++ {
+		var want, got interface{}
+		if json.Unmarshal([]byte(recordedLines[i]), &want) != nil || json.Unmarshal([]byte(actualLines[i]), &got) != nil {
+			if recordedLines[i] != actualLines[i] {
+				diffs = append(diffs, fmt.Sprintf("body line %d: recorded %q, got %q", i+1, recordedLines[i], actualLines[i]))
+			}
+			continue
+		}
+		diffs = append(diffs, diffJSON(fmt.Sprintf("line %d", i+1), want, got)...)
+	}
+
+	return diffs
+}
+
+func diffJSON(path string, want, got interface{}) []string {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: recorded an object, got %v", path, got)}
+		}
+
+		keys := make([]string, 0, len(w)+len(g))
+		for key := range w {
+			keys = append(keys, key)
+		}
+		for key := range g {
+			if _, exists := w[key]; !exists {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		var diffs []string
+		for _, key := range keys {
+			if VolatileFields[key] {
+				continue
+			}
+			wv, inWant := w[key]
+			gv, inGot := g[key]
+			switch {
+			case !inWant:
+				diffs = append(diffs, fmt.Sprintf("%s.%s: unexpected field", path, key))
+			case !inGot:
+				diffs = append(diffs, fmt.Sprintf("%s.%s: missing field", path, key))
+			default:
+				diffs = append(diffs, diffJSON(path+"."+key, wv, gv)...)
+			}
+		}
+		return diffs
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: recorded an array, got %v", path, got)}
+		}
+		if len(w) != len(g) {
+			return []string{fmt.Sprintf("%s: recorded %d elements, got %d", path, len(w), len(g))}
+		}
+
+		var diffs []string
+		for i := range w {
+			diffs = append(diffs, diffJSON(fmt.Sprintf("%s[%d]", path, i), w[i], g[i])...)
+		}
+		return diffs
+	default:
+		if !reflect.DeepEqual(want, got) {
+			return []string{fmt.Sprintf("%s: recorded %v, got %v", path, want, got)}
+		}
+		return nil
+	}
+}
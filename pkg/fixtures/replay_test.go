@@ -0,0 +1,50 @@
+package fixtures
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"url":"` + r.URL.Query().Get("url") + `","distinct_visitors":2,"last_updated":"2025-01-02T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	f := &Fixture{
+		Name:    "000001_GET_stats",
+		Request: Request{Method: "GET", Path: "/stats", Query: "url=a"},
+		Response: Response{
+			Status: http.StatusOK,
+			Body:   `{"url":"a","distinct_visitors":2,"last_updated":"2024-01-01T00:00:00Z"}`,
+		},
+	}
+
+	diffs, err := Replay(server.Client(), server.URL, f)
+	if err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("Expected volatile fields to be ignored, got %v", diffs)
+	}
+
+	f.Response.Body = `{"url":"a","distinct_visitors":3}`
+	diffs, err = Replay(server.Client(), server.URL, f)
+	if err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	if len(diffs) != 1 || diffs[0] != "line 1.distinct_visitors: recorded 3, got 2" {
+		t.Errorf("Expected one count difference, got %v", diffs)
+	}
+}
+
+func TestDiffBodies_NonJSON(t *testing.T) {
+	if diffs := diffBodies("a,b\n1,2", "a,b\n1,2"); len(diffs) != 0 {
+		t.Errorf("Expected identical CSV to match, got %v", diffs)
+	}
+	if diffs := diffBodies("a,b\n1,2", "a,b\n1,3"); len(diffs) != 1 {
+		t.Errorf("Expected one line difference, got %v", diffs)
+	}
+}
@@ -0,0 +1,217 @@
+// Package format renders response values according to a client's requested
+// duration format and float precision.
+package format
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the response format f.
+func NewContext(ctx context.Context, f models.ResponseFormatConfig) context.Context {
+	return context.WithValue(ctx, contextKey{}, f)
+}
+
+// FromContext returns the response format stored in ctx, or the unformatted
+// default when there is none.
+func FromContext(ctx context.Context) models.ResponseFormatConfig {
+	if f, ok := ctx.Value(contextKey{}).(models.ResponseFormatConfig); ok {
+		return f
+	}
+	return models.ResponseFormatConfig{DurationFormat: models.DurationFormatNanoseconds, Precision: -1}
+}
+
+// FromQuery overrides defaults with the duration_format and precision query parameters.
+func FromQuery(defaults models.ResponseFormatConfig, query url.Values) (models.ResponseFormatConfig, error) {
+	f := defaults
+	if durations := query.Get("duration_format"); durations != "" {
+		f.DurationFormat = durations
+	}
+	if precision := query.Get("precision"); precision != "" {
+		parsed, err := strconv.Atoi(precision)
+		if err != nil || parsed < 0 {
+			return f, fmt.Errorf("precision must be a non-negative integer")
+		}
+		f.Precision = parsed
+	}
+	if err := f.Validate(); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+var (
+	durationType      = reflect.TypeOf(time.Duration(0))
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Apply returns v rewritten as generic JSON values with durations and floats
+// formatted by f. Field names, omitempty and embedded structs follow
+// encoding/json; values with their own JSON or text encoding, such as
+// time.Time, are left untouched. Objects lose their field order, so callers
+// should skip Apply when f.IsDefault().
+func Apply(v interface{}, f models.ResponseFormatConfig) interface{} {
+	return formatter(f).value(reflect.ValueOf(v))
+}
+
+type formatter models.ResponseFormatConfig
+
+func (f formatter) value(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	if v.Type() == durationType {
+		return f.duration(time.Duration(v.Int()))
+	}
+	if v.Kind() != reflect.Pointer && v.Kind() != reflect.Interface && v.CanInterface() &&
+		(v.Type().Implements(marshalerType) || v.Type().Implements(textMarshalerType)) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return f.value(v.Elem())
+	case reflect.Float32, reflect.Float64:
+		return f.float(v.Float())
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.Struct:
+		object := make(map[string]interface{})
+		f.fields(v, object)
+		return object
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		object := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			object[fmt.Sprint(iter.Key().Interface())] = f.value(iter.Value())
+		}
+		return object
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes()
+		}
+		fallthrough
+	case reflect.Array:
+		array := make([]interface{}, v.Len())
+		for i := range array {
+			array[i] = f.value(v.Index(i))
+		}
+		return array
+	default:
+		// Channels and functions cannot be encoded either way.
+		return nil
+	}
+}
+
+// fields adds v's exported fields to object, flattening untagged embedded structs.
+func (f formatter) fields(v reflect.Value, object map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		value := v.Field(i)
+		if field.Anonymous && name == "" {
+			for value.Kind() == reflect.Pointer {
+				if value.IsNil() {
+					break
+				}
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				f.fields(value, object)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if hasOption(options, "omitempty") && isEmpty(value) {
+			continue
+		}
+		object[name] = f.value(value)
+	}
+}
+
+func (f formatter) duration(d time.Duration) interface{} {
+	switch f.DurationFormat {
+	case models.DurationFormatMilliseconds:
+		return f.float(float64(d) / float64(time.Millisecond))
+	case models.DurationFormatString:
+		return d.String()
+	default:
+		return int64(d)
+	}
+}
+
+func (f formatter) float(x float64) float64 {
+	if f.Precision < 0 || math.IsNaN(x) || math.IsInf(x, 0) {
+		return x
+	}
+	scale := math.Pow10(f.Precision)
+	return math.Round(x*scale) / scale
+}
+
+func hasOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmpty matches encoding/json's omitempty rule.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
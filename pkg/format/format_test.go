@@ -0,0 +1,101 @@
+package format
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+type inner struct {
+	Rate float64 `json:"rate"`
+}
+
+type sample struct {
+	inner
+	Name     string        `json:"name"`
+	Elapsed  time.Duration `json:"elapsed"`
+	When     time.Time     `json:"when"`
+	Optional *inner        `json:"optional,omitempty"`
+	Skipped  string        `json:"-"`
+	Nested   []inner       `json:"nested"`
+	hidden   int
+}
+
+func encode(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	return string(data)
+}
+
+func TestApply(t *testing.T) {
+	when := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	value := &sample{
+		inner:   inner{Rate: 33.333333},
+		Name:    "a",
+		Elapsed: 1500 * time.Millisecond,
+		When:    when,
+		Skipped: "x",
+		Nested:  []inner{{Rate: 0.12345}},
+		hidden:  1,
+	}
+
+	cases := []struct {
+		format   models.ResponseFormatConfig
+		expected string
+	}{
+		{
+			models.ResponseFormatConfig{DurationFormat: models.DurationFormatMilliseconds, Precision: 2},
+			`{"elapsed":1500,"name":"a","nested":[{"rate":0.12}],"rate":33.33,"when":"2024-01-01T00:00:00Z"}`,
+		},
+		{
+			models.ResponseFormatConfig{DurationFormat: models.DurationFormatString, Precision: -1},
+			`{"elapsed":"1.5s","name":"a","nested":[{"rate":0.12345}],"rate":33.333333,"when":"2024-01-01T00:00:00Z"}`,
+		},
+	}
+
+	for _, tc := range cases {
+		if got := encode(t, Apply(value, tc.format)); got != tc.expected {
+			t.Errorf("Expected %s, got %s", tc.expected, got)
+		}
+	}
+}
+
+func TestApply_Maps(t *testing.T) {
+	value := map[string]interface{}{
+		"average": 2 * time.Second,
+		"ratios":  map[int]float64{1: 0.5555},
+		"empty":   nil,
+	}
+
+	got := encode(t, Apply(value, models.ResponseFormatConfig{DurationFormat: models.DurationFormatMilliseconds, Precision: 1}))
+	expected := `{"average":2000,"empty":null,"ratios":{"1":0.6}}`
+	if got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestFromQuery(t *testing.T) {
+	defaults := FromContext(context.Background())
+
+	f, err := FromQuery(defaults, url.Values{"duration_format": {"ms"}, "precision": {"3"}})
+	if err != nil || f.DurationFormat != models.DurationFormatMilliseconds || f.Precision != 3 {
+		t.Errorf("Expected ms with precision 3, got %+v (%v)", f, err)
+	}
+
+	for _, query := range []url.Values{{"duration_format": {"hours"}}, {"precision": {"-1"}}, {"precision": {"99"}}} {
+		if _, err := FromQuery(defaults, query); err == nil {
+			t.Errorf("Expected %v to be rejected", query)
+		}
+	}
+
+	if f, _ := FromQuery(defaults, url.Values{}); !f.IsDefault() {
+		t.Errorf("Expected no parameters to keep the default, got %+v", f)
+	}
+}
@@ -0,0 +1,77 @@
+package geo
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/oschwald/geoip2-golang"
+
+	"nav-tracker/pkg/models"
+)
+
+// Location is the geographic position resolved for a client IP. Country and
+// Region are ISO codes; City is the English name.
+type Location struct {
+	Country string
+	Region  string
+	City    string
+}
+
+type Resolver interface {
+	Lookup(ip net.IP) (Location, error)
+}
+
+// MaxMindResolver resolves IPs against a MaxMind GeoIP2/GeoLite2 City database.
+type MaxMindResolver struct {
+	db *geoip2.Reader
+}
+
+func OpenMaxMind(path string) (*MaxMindResolver, error) {
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &MaxMindResolver{db: db}, nil
+}
+
+func (m *MaxMindResolver) Lookup(ip net.IP) (Location, error) {
+	record, err := m.db.City(ip)
+	if err != nil {
+		return Location{}, err
+	}
+
+	location := Location{
+		Country: record.Country.IsoCode,
+		City:    record.City.Names["en"],
+	}
+	if len(record.Subdivisions) > 0 {
+		location.Region = record.Subdivisions[0].IsoCode
+	}
+
+	return location, nil
+}
+
+func (m *MaxMindResolver) Close() error {
+	return m.db.Close()
+}
+
+// Enricher returns an ingest hook that overwrites the event's location with
+// the one resolved for the IP clientIP returns. Lookup failures leave the
+// event as-is.
+func Enricher(resolver Resolver, clientIP func(r *http.Request) net.IP) func(r *http.Request, event *models.NavigationEvent) {
+	return func(r *http.Request, event *models.NavigationEvent) {
+		ip := clientIP(r)
+		if ip == nil {
+			return
+		}
+
+		location, err := resolver.Lookup(ip)
+		if err != nil {
+			return
+		}
+
+		event.Country = location.Country
+		event.Region = location.Region
+		event.City = location.City
+	}
+}
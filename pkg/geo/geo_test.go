@@ -0,0 +1,45 @@
+package geo
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+type fakeResolver map[string]Location
+
+func (f fakeResolver) Lookup(ip net.IP) (Location, error) {
+	location, ok := f[ip.String()]
+	if !ok {
+		return Location{}, errors.New("not found")
+	}
+	return location, nil
+}
+
+func TestEnricher(t *testing.T) {
+	resolver := fakeResolver{
+		"203.0.113.7": {Country: "KE", Region: "30", City: "Nairobi"},
+	}
+	enrich := Enricher(resolver, func(r *http.Request) net.IP { return net.ParseIP(r.Header.Get("X-Forwarded-For")) })
+
+	req := httptest.NewRequest("POST", "/ingest", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	event := &models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com", Country: "US"}
+	enrich(req, event)
+
+	if event.Country != "KE" || event.Region != "30" || event.City != "Nairobi" {
+		t.Errorf("Expected KE/30/Nairobi, got %s/%s/%s", event.Country, event.Region, event.City)
+	}
+
+	unknown := httptest.NewRequest("POST", "/ingest", nil)
+	event = &models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com"}
+	enrich(unknown, event)
+
+	if event.Country != "" {
+		t.Errorf("Expected no country for unresolved IP, got %s", event.Country)
+	}
+}
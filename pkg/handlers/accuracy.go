@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"nav-tracker/pkg/models"
+)
+
+// AccuracySource builds a report of approximate count error against exact audit counts
+type AccuracySource func() (*models.AccuracyReport, error)
+
+// AccuracyHandler handles GET requests to retrieve the approximate counting accuracy audit
+func AccuracyHandler(source AccuracySource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		report, err := source()
+		if err != nil {
+			log.Printf("Error building accuracy report: %v", err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to build accuracy report")
+			return
+		}
+
+		respondWithJSON(w, r, http.StatusOK, report)
+	}
+}
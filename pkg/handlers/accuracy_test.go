@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestAccuracyHandler(t *testing.T) {
+	handler := AccuracyHandler(func() (*models.AccuracyReport, error) {
+		return &models.AccuracyReport{SampledURLs: 1}, nil
+	})
+
+	req := httptest.NewRequest("GET", "/api/v1/accuracy", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	failing := AccuracyHandler(func() (*models.AccuracyReport, error) {
+		return nil, errors.New("redis down")
+	})
+	w = httptest.NewRecorder()
+	failing(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// ActivesHandler handles GET requests to count daily, weekly or monthly active visitors, overall or for a URL
+func ActivesHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		window := r.URL.Query().Get("window")
+		if window == "" {
+			window = models.ActiveWindowDay
+		}
+
+		actives, ok := tracker.Actives().Count(models.NormalizeURL(r.URL.Query().Get("url")), window, time.Now())
+		if !ok {
+			respondWithError(w, http.StatusBadRequest, "Invalid window: must be day, week or month")
+			return
+		}
+
+		respondWithJSON(w, r, http.StatusOK, actives)
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestActivesHandler_Success(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := ActivesHandler(tracker)
+
+	now := time.Now()
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: now},
+		{VisitorID: "visitor2", URL: "https://example.com/b", Timestamp: now},
+		{VisitorID: "visitor3", URL: "https://example.com/a", Timestamp: now.AddDate(0, 0, -3)},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query    string
+		visitors int
+	}{
+		{"", 2},
+		{"?window=week", 3},
+		{"?window=week&url=https://example.com/a", 2},
+		{"?window=day&url=https://example.com/a", 1},
+		{"?window=week&url=HTTPS://Example.com/A/%23top", 2},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/v1/actives"+tt.query, nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %q, got %d", http.StatusOK, tt.query, w.Code)
+		}
+
+		var response models.ActiveVisitors
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response.Visitors != tt.visitors {
+			t.Errorf("Expected %d visitors for %q, got %d", tt.visitors, tt.query, response.Visitors)
+		}
+	}
+}
+
+func TestActivesHandler_InvalidWindow(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := ActivesHandler(tracker)
+
+	req := httptest.NewRequest("GET", "/api/v1/actives?window=year", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"nav-tracker/pkg/monitoring"
+)
+
+// defaultActivityRange is the lookback used when from is not given
+const defaultActivityRange = time.Hour
+
+// IngestActivityHandler handles GET requests to retrieve per-minute ingest counters by API key and IP
+func IngestActivityHandler(activity *monitoring.IngestActivity) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		query := r.URL.Query()
+
+		format := query.Get("format")
+		if format == "" {
+			format = "json"
+		}
+		if format != "json" && format != "csv" {
+			respondWithError(w, http.StatusBadRequest, "Invalid format: must be json or csv")
+			return
+		}
+
+		to, err := parseTimeParam(query.Get("to"))
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid to: must be RFC3339")
+			return
+		}
+		if to.IsZero() {
+			to = time.Now().UTC()
+		}
+
+		from, err := parseTimeParam(query.Get("from"))
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid from: must be RFC3339")
+			return
+		}
+		if from.IsZero() {
+			from = to.Add(-defaultActivityRange)
+		}
+		if !from.Before(to) {
+			respondWithError(w, http.StatusBadRequest, "Invalid time range: from must be before to")
+			return
+		}
+
+		buckets := activity.Query(from, to, query.Get("key"))
+
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv")
+			w.WriteHeader(http.StatusOK)
+
+			cw := csv.NewWriter(w)
+			_ = cw.Write([]string{"minute", "api_key", "ip", "accepted", "rejected"})
+			for _, bucket := range buckets {
+				_ = cw.Write([]string{
+					bucket.Minute.Format(time.RFC3339),
+					bucket.APIKey,
+					bucket.IP,
+					strconv.FormatInt(bucket.Accepted, 10),
+					strconv.FormatInt(bucket.Rejected, 10),
+				})
+			}
+			cw.Flush()
+			return
+		}
+
+		response := map[string]interface{}{
+			"from":    from,
+			"to":      to,
+			"window":  activity.Window().String(),
+			"buckets": buckets,
+		}
+
+		respondWithJSON(w, r, http.StatusOK, response)
+	}
+}
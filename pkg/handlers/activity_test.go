@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/monitoring"
+)
+
+func TestIngestActivityHandler(t *testing.T) {
+	activity := monitoring.NewIngestActivity(24 * time.Hour)
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	activity.Record("key1", "10.0.0.1", true, base)
+	activity.Record("key2", "10.0.0.2", false, base)
+	handler := IngestActivityHandler(activity)
+
+	req := httptest.NewRequest("GET", "/api/v1/ingest-activity?from=2024-01-01T11:00:00Z&to=2024-01-01T13:00:00Z&key=key1", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Buckets []monitoring.ActivityBucket `json:"buckets"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if len(response.Buckets) != 1 || response.Buckets[0].APIKey != "key1" || response.Buckets[0].Accepted != 1 {
+		t.Errorf("Unexpected buckets: %+v", response.Buckets)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/ingest-activity?from=2024-01-01T11:00:00Z&to=2024-01-01T13:00:00Z&format=csv", nil)
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	rows, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(rows) != 3 || rows[2][1] != "key2" || rows[2][4] != "1" {
+		t.Errorf("Unexpected CSV rows: %v", rows)
+	}
+}
+
+func TestIngestActivityHandler_InvalidParams(t *testing.T) {
+	handler := IngestActivityHandler(monitoring.NewIngestActivity(time.Hour))
+
+	tests := []string{
+		"/api/v1/ingest-activity?format=xml",
+		"/api/v1/ingest-activity?from=yesterday",
+		"/api/v1/ingest-activity?from=2024-01-02T00:00:00Z&to=2024-01-01T00:00:00Z",
+	}
+
+	for _, target := range tests {
+		req := httptest.NewRequest("GET", target, nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, target, w.Code)
+		}
+	}
+}
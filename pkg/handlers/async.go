@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// EventQueue accepts events to be recorded asynchronously. Enqueue reports
+// false when the event was refused because the queue is full.
+type EventQueue interface {
+	Enqueue(event models.NavigationEvent) bool
+}
+
+// respondQueueFull tells the client to retry an event the queue refused
+func respondQueueFull(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", "1")
+	respondWithError(w, http.StatusServiceUnavailable, "Ingest queue is full")
+}
+
+// AsyncIngestHandler handles POST requests to validate navigation events and
+// queue them for recording, responding 202 Accepted
+func AsyncIngestHandler(tracker *storage.NavigationTracker, queue EventQueue, enrichers ...EventEnricher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		event, err := decodeIngestEvent(r, enrichers)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, decodeErrorMessage(err))
+			return
+		}
+		if err := tracker.ValidateEvent(&event); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if !queue.Enqueue(event) {
+			respondQueueFull(w)
+			return
+		}
+
+		respondWithJSON(w, r, http.StatusAccepted, map[string]interface{}{
+			"success": true,
+			"message": "Event accepted for processing",
+		})
+	}
+}
+
+// AsyncPixelHandler handles pixel requests, queueing their event for
+// recording before returning the pixel
+func AsyncPixelHandler(tracker *storage.NavigationTracker, queue EventQueue, enrichers ...EventEnricher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		event, err := pixelEvent(r, tracker, enrichers)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if !queue.Enqueue(event) {
+			respondQueueFull(w)
+			return
+		}
+
+		writePixel(w, http.StatusOK)
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// sliceQueue accepts up to capacity events.
+type sliceQueue struct {
+	events   []models.NavigationEvent
+	capacity int
+}
+
+func (q *sliceQueue) Enqueue(event models.NavigationEvent) bool {
+	if len(q.events) >= q.capacity {
+		return false
+	}
+	q.events = append(q.events, event)
+	return true
+}
+
+func TestAsyncIngestHandler(t *testing.T) {
+	queue := &sliceQueue{capacity: 1}
+	handler := AsyncIngestHandler(storage.NewNavigationTracker(), queue)
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"visitor_id":"visitor1","url":"https://example.com/a"}`, http.StatusAccepted},
+		{`{"visitor_id":"visitor1"}`, http.StatusBadRequest},
+		{`not json`, http.StatusBadRequest},
+		{`{"visitor_id":"visitor2","url":"https://example.com/a"}`, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/ingest", strings.NewReader(tt.body))
+		req.Header.Set("User-Agent", "test-agent")
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.body, tt.status, w.Code)
+		}
+		if tt.status == http.StatusServiceUnavailable && w.Header().Get("Retry-After") == "" {
+			t.Error("Expected Retry-After when the queue is full")
+		}
+	}
+
+	if len(queue.events) != 1 || queue.events[0].UserAgent != "test-agent" {
+		t.Errorf("Expected one queued event with the request's user agent, got %+v", queue.events)
+	}
+}
+
+func TestAsyncPixelHandler(t *testing.T) {
+	queue := &sliceQueue{capacity: 1}
+	handler := AsyncPixelHandler(storage.NewNavigationTracker(), queue)
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/v1/pixel?visitor_id=visitor1&url=https://example.com/a", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/gif" {
+		t.Errorf("Expected a pixel, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/v1/pixel?visitor_id=visitor1", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a missing url, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/v1/pixel?visitor_id=visitor2&url=https://example.com/a", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d with a full queue, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
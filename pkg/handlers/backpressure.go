@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// ErrorCodeMemoryPressure is the error code of responses to events refused
+// while the tracker's memory backpressure is on.
+const ErrorCodeMemoryPressure = "MEMORY_PRESSURE"
+
+// BackpressureHandler refuses requests with 503 while the tracker's memory
+// backpressure is on, before next queues or imports their events
+func BackpressureHandler(tracker *storage.NavigationTracker, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if retryAfter := tracker.Backpressure(); retryAfter > 0 {
+			respondMemoryPressure(w, retryAfter)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// respondMemoryPressure tells the client to resend an event refused because
+// the tracked data is over its memory limit after retryAfter.
+func respondMemoryPressure(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeJSON(w, http.StatusServiceUnavailable, models.ErrorResponse{
+		Error: "Tracked data is over its memory limit",
+		Code:  ErrorCodeMemoryPressure,
+	})
+}
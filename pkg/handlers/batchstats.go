@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// BatchStatsHandler handles POST requests for the stats of several URLs, optionally over a time range
+func BatchStatsHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		var req models.BatchStatsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+		if len(req.URLs) == 0 {
+			respondWithError(w, http.StatusBadRequest, "urls is required")
+			return
+		}
+		if len(req.URLs) > models.MaxBatchStatsURLs {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("urls must list at most %d URLs", models.MaxBatchStatsURLs))
+			return
+		}
+		ranged := !req.From.IsZero()
+		if !ranged && !req.To.IsZero() {
+			respondWithError(w, http.StatusBadRequest, "to requires from")
+			return
+		}
+		if ranged && req.To.IsZero() {
+			req.To = time.Now().UTC()
+		}
+
+		results := make([]models.BatchStatsResult, len(req.URLs))
+		for i, raw := range req.URLs {
+			results[i].URL = raw
+			if err := tracker.Validation().ValidateURL(raw); err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+
+			url := models.NormalizeURL(raw)
+			if !ranged {
+				results[i].Stats = tracker.GetVisitorStats(url)
+				continue
+			}
+			period, err := tracker.Actives().Range(url, req.From, req.To)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid time range: "+err.Error())
+				return
+			}
+			results[i].Period = period
+		}
+
+		respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+			"results": results,
+			"count":   len(results),
+		})
+	}
+}
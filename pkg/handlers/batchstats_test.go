@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestBatchStatsHandler_Success(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := BatchStatsHandler(tracker)
+
+	now := time.Now()
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: now},
+		{VisitorID: "visitor2", URL: "https://example.com/a", Timestamp: now.AddDate(0, 0, -3)},
+		{VisitorID: "visitor1", URL: "https://example.com/b", Timestamp: now},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	body := `{"urls": ["https://example.com/a", "", "https://example.com/b", "https://example.com/none"]}`
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/api/v1/stats/batch", bytes.NewBufferString(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Results []models.BatchStatsResult `json:"results"`
+		Count   int                       `json:"count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Count != 4 || len(response.Results) != 4 {
+		t.Fatalf("Expected 4 results, got %+v", response)
+	}
+	if stats := response.Results[0].Stats; stats == nil || stats.DistinctVisitors != 2 {
+		t.Errorf("Expected 2 visitors on /a, got %+v", response.Results[0])
+	}
+	if response.Results[1].Error == "" || response.Results[1].Stats != nil {
+		t.Errorf("Expected an error for the empty URL, got %+v", response.Results[1])
+	}
+	if stats := response.Results[2].Stats; stats == nil || stats.DistinctVisitors != 1 {
+		t.Errorf("Expected 1 visitor on /b, got %+v", response.Results[2])
+	}
+	if stats := response.Results[3].Stats; stats == nil || stats.DistinctVisitors != 0 {
+		t.Errorf("Expected no visitors on an unknown URL, got %+v", response.Results[3])
+	}
+
+	// Only today's visitor is in a range starting today
+	body = fmt.Sprintf(`{"urls": ["https://example.com/a"], "from": %q}`, now.UTC().Format(time.RFC3339))
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/api/v1/stats/batch", bytes.NewBufferString(body)))
+	response.Results = nil
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if period := response.Results[0].Period; period == nil || period.DistinctVisitors != 1 || response.Results[0].Stats != nil {
+		t.Errorf("Expected 1 visitor in the range, got %+v", response.Results[0])
+	}
+}
+
+func TestBatchStatsHandler_InvalidRequest(t *testing.T) {
+	handler := BatchStatsHandler(storage.NewNavigationTracker())
+
+	tooMany := make([]string, models.MaxBatchStatsURLs+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("https://example.com/%d", i)
+	}
+	urls, _ := json.Marshal(tooMany)
+
+	for _, body := range []string{
+		`not json`,
+		`{"urls": []}`,
+		`{"urls": ` + string(urls) + `}`,
+		`{"urls": ["https://example.com/a"], "to": "2024-03-01T00:00:00Z"}`,
+		`{"urls": ["https://example.com/a"], "from": "2024-03-01T00:00:00Z", "to": "2024-02-01T00:00:00Z"}`,
+		`{"urls": ["https://example.com/a"], "from": "yesterday"}`,
+	} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("POST", "/api/v1/stats/batch", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %.40s, got %d", http.StatusBadRequest, body, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/v1/stats/batch", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
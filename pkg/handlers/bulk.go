@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+const bulkJobsPath = "/api/v1/urls/bulk/"
+
+// BulkURLsHandler handles POST requests to start a bulk URL operation and GET requests to list recent jobs
+func BulkURLsHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			jobs := tracker.BulkJobs().List()
+			respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+				"jobs":  jobs,
+				"count": len(jobs),
+			})
+		case http.MethodPost:
+			var op models.BulkOperation
+			if err := json.NewDecoder(r.Body).Decode(&op); err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+				return
+			}
+
+			job, err := tracker.BulkJobs().Submit(op)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			respondWithJSON(w, r, http.StatusAccepted, job)
+		default:
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// BulkJobHandler handles GET requests to /api/v1/urls/bulk/{id}
+func BulkJobHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		id := strings.TrimPrefix(r.URL.Path, bulkJobsPath)
+		job, exists := tracker.BulkJobs().Get(id)
+		if !exists {
+			respondWithError(w, http.StatusNotFound, "Job not found")
+			return
+		}
+
+		respondWithJSON(w, r, http.StatusOK, job)
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestBulkURLsHandler_DeleteByPattern(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	for _, url := range []string{"https://example.com/old/a", "https://example.com/old/b", "https://example.com/new"} {
+		tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url})
+	}
+
+	body := `{"operation":"delete","pattern":"/old/"}`
+	req := httptest.NewRequest("POST", "/api/v1/urls/bulk", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	BulkURLsHandler(tracker)(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+
+	var job models.BulkJob
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for job.Status != models.JobCompleted && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		req = httptest.NewRequest("GET", "/api/v1/urls/bulk/"+job.ID, nil)
+		w = httptest.NewRecorder()
+		BulkJobHandler(tracker)(w, req)
+		json.Unmarshal(w.Body.Bytes(), &job)
+	}
+
+	if job.Status != models.JobCompleted || job.Matched != 2 || job.Applied != 2 {
+		t.Errorf("Expected a completed job deleting 2 URLs, got %+v", job)
+	}
+	if stats := tracker.GetSystemStats(); stats.TotalUniqueURLs != 1 {
+		t.Errorf("Expected 1 URL to remain, got %d", stats.TotalUniqueURLs)
+	}
+}
+
+func TestBulkURLsHandler_InvalidOperation(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+
+	req := httptest.NewRequest("POST", "/api/v1/urls/bulk", strings.NewReader(`{"operation":"explode","urls":["https://example.com/"]}`))
+	w := httptest.NewRecorder()
+	BulkURLsHandler(tracker)(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestBulkJobHandler_NotFound(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+
+	req := httptest.NewRequest("GET", "/api/v1/urls/bulk/job_42", nil)
+	w := httptest.NewRecorder()
+	BulkJobHandler(tracker)(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/storage"
+)
+
+// CategoriesHandler handles GET requests to retrieve visitor rollups per URL category
+func CategoriesHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		response := map[string]interface{}{
+			"categories": tracker.GetCategoryStats(),
+		}
+
+		respondWithJSON(w, r, http.StatusOK, response)
+	}
+}
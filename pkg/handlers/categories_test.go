@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestCategoriesHandler_Success(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := CategoriesHandler(tracker)
+
+	events := []models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/blog/hello"},
+		{VisitorID: "visitor2", URL: "https://example.com/blog/world"},
+		{VisitorID: "visitor1", URL: "https://example.com/product/42"},
+	}
+	for i := range events {
+		if err := tracker.RecordEvent(&events[i]); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/categories", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Categories []models.CategoryStats `json:"categories"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if len(response.Categories) != 2 {
+		t.Fatalf("Expected 2 categories, got %d", len(response.Categories))
+	}
+
+	if response.Categories[0].Category != "article" || response.Categories[0].DistinctVisitors != 2 {
+		t.Errorf("Expected article with 2 visitors first, got %+v", response.Categories[0])
+	}
+}
+
+func TestCategoriesHandler_WrongMethod(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := CategoriesHandler(tracker)
+
+	req := httptest.NewRequest("POST", "/api/v1/categories", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
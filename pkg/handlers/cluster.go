@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"nav-tracker/pkg/storage/gossip"
+)
+
+// ClusterGossipHandler handles POST requests from peers pushing their counters in replicated mode
+func ClusterGossipHandler(backend *gossip.Backend) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		if !backend.Authorized(r.Header.Get(gossip.SecretHeader)) {
+			respondWithError(w, http.StatusUnauthorized, "Invalid cluster secret")
+			return
+		}
+
+		var message gossip.Message
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+
+		if err := backend.Merge(message); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		respondWithJSON(w, r, http.StatusOK, gossip.Ack{Origin: backend.Origin()})
+	}
+}
+
+// ClusterHandler handles GET requests to retrieve this node's replication status
+func ClusterHandler(backend *gossip.Backend) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		respondWithJSON(w, r, http.StatusOK, backend.Status())
+	}
+}
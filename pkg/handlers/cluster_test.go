@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage/gossip"
+)
+
+func newClusterBackend() *gossip.Backend {
+	return gossip.NewBackend(models.ClusterConfig{
+		Enabled:   true,
+		NodeID:    "node-1",
+		Secret:    "secret",
+		Interval:  time.Hour,
+		Timeout:   time.Second,
+		Precision: 4,
+	})
+}
+
+func TestClusterGossipHandler(t *testing.T) {
+	backend := newClusterBackend()
+	handler := ClusterGossipHandler(backend)
+	body := `{"node": "node-2", "counters": {"node-2@1": {"/home": {"registers": "AQAAAAAAAAAAAAAAAAAAAA==", "page_views": 3}}}}`
+
+	tests := []struct {
+		name   string
+		method string
+		secret string
+		body   string
+		status int
+	}{
+		{"wrong method", http.MethodGet, "secret", "", http.StatusMethodNotAllowed},
+		{"wrong secret", http.MethodPost, "guess", body, http.StatusUnauthorized},
+		{"invalid JSON", http.MethodPost, "secret", "{", http.StatusBadRequest},
+		{"other precision", http.MethodPost, "secret", `{"counters": {"n@1": {"/home": {"registers": "AQ==", "page_views": 1}}}}`, http.StatusBadRequest},
+		{"merged", http.MethodPost, "secret", body, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/api/v1/cluster/gossip", strings.NewReader(tt.body))
+		req.Header.Set(gossip.SecretHeader, tt.secret)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.status, w.Code, w.Body.String())
+		}
+	}
+
+	if views, _ := backend.PageViews("/home"); views != 3 {
+		t.Errorf("Expected 3 merged page views, got %d", views)
+	}
+}
+
+func TestClusterHandler(t *testing.T) {
+	backend := newClusterBackend()
+	backend.Record(&models.NavigationEvent{URL: "/home", VisitorID: "v1"})
+
+	req := httptest.NewRequest("GET", "/api/v1/cluster", nil)
+	w := httptest.NewRecorder()
+	ClusterHandler(backend)(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var status models.ClusterStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if status.Node != "node-1" || status.URLs != 1 || status.Origins != 1 {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+}
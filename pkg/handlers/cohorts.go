@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"nav-tracker/pkg/storage"
+)
+
+const (
+	defaultCohortWeeks = 8
+	maxCohortWeeks     = 52
+)
+
+// CohortsHandler handles GET requests to retrieve the weekly visitor retention matrix
+func CohortsHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		weeks := defaultCohortWeeks
+		if weeksParam := r.URL.Query().Get("weeks"); weeksParam != "" {
+			parsed, err := strconv.Atoi(weeksParam)
+			if err != nil || parsed < 1 || parsed > maxCohortWeeks {
+				respondWithError(w, http.StatusBadRequest, "Invalid weeks: must be between 1 and 52")
+				return
+			}
+			weeks = parsed
+		}
+
+		response := map[string]interface{}{
+			"weeks":   weeks,
+			"cohorts": tracker.Cohorts().Matrix(weeks, time.Now()),
+		}
+
+		respondWithJSON(w, r, http.StatusOK, response)
+	}
+}
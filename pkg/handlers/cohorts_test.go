@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestCohortsHandler_Success(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := CohortsHandler(tracker)
+
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/a"}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/cohorts?weeks=4", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Weeks   int                `json:"weeks"`
+		Cohorts []models.CohortRow `json:"cohorts"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if len(response.Cohorts) != 4 {
+		t.Fatalf("Expected 4 cohorts, got %d", len(response.Cohorts))
+	}
+
+	current := response.Cohorts[3]
+	if current.Visitors != 1 || len(current.Retention) != 1 || current.Retention[0] != 1 {
+		t.Errorf("Unexpected current cohort: %+v", current)
+	}
+}
+
+func TestCohortsHandler_InvalidWeeks(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := CohortsHandler(tracker)
+
+	for _, weeks := range []string{"0", "53", "abc"} {
+		req := httptest.NewRequest("GET", "/api/v1/cohorts?weeks="+weeks, nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for weeks=%s, got %d", http.StatusBadRequest, weeks, w.Code)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"nav-tracker/pkg/storage"
+)
+
+// CompactHandler handles POST requests to compact the persisted snapshot and
+// cold tier files and GET requests to list recent runs
+func CompactHandler(compactor *storage.Compactor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			runs := compactor.History()
+			respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+				"runs":  runs,
+				"count": len(runs),
+			})
+		case http.MethodPost:
+			result, err := compactor.Compact()
+			if err != nil {
+				log.Printf("Error compacting storage: %v", err)
+				respondWithError(w, http.StatusInternalServerError, "Failed to compact storage")
+				return
+			}
+
+			respondWithJSON(w, r, http.StatusOK, result)
+		default:
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
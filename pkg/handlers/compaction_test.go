@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestCompactHandler(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	flusher := storage.NewSnapshotFlusher(tracker, models.SnapshotConfig{Path: filepath.Join(t.TempDir(), "snapshot.json")})
+	handler := CompactHandler(storage.NewCompactor(tracker, flusher, models.CompactionConfig{}))
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/api/v1/admin/compact", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var result models.CompactionResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if result.Trigger != models.CompactionManual || result.SnapshotBytes == 0 {
+		t.Errorf("Expected a manual compaction rewriting the snapshot, got %+v", result)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/v1/admin/compact", nil))
+	var history struct {
+		Runs  []models.CompactionResult `json:"runs"`
+		Count int                       `json:"count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &history); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected the runs listed, got %d: %s", w.Code, w.Body.String())
+	}
+	if history.Count != 1 || history.Runs[0].Trigger != models.CompactionManual {
+		t.Errorf("Expected the manual run listed, got %+v", history)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("PUT", "/api/v1/admin/compact", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+const defaultComparePeriod = "7d"
+
+// CompareHandler handles GET requests comparing a URL's distinct visitors and page views with the previous period
+func CompareHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		period := r.URL.Query().Get("period")
+		if period == "" {
+			period = defaultComparePeriod
+		}
+		days, err := strconv.Atoi(strings.TrimSuffix(period, "d"))
+		if err != nil || !strings.HasSuffix(period, "d") || days < 1 || days > models.MaxComparePeriodDays {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid period: must be 1d to %dd", models.MaxComparePeriodDays))
+			return
+		}
+
+		// An empty URL compares every URL
+		url := models.NormalizeURL(r.URL.Query().Get("url"))
+		comparison, err := tracker.Actives().Compare(url, days, time.Now())
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		respondWithJSON(w, r, http.StatusOK, comparison)
+	}
+}
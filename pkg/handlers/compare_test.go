@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestCompareHandler_Success(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := CompareHandler(tracker)
+
+	now := time.Now()
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: now},
+		{VisitorID: "visitor2", URL: "https://example.com/a", Timestamp: now},
+		{VisitorID: "visitor3", URL: "https://example.com/a", Timestamp: now.AddDate(0, 0, -8)},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/stats/compare?url=https://example.com/a&period=7d", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response models.PeriodComparison
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Current.DistinctVisitors != 2 || response.Previous.DistinctVisitors != 1 {
+		t.Errorf("Expected 2 visitors against 1, got %+v", response)
+	}
+	if response.Change.DistinctVisitorsPercent == nil || *response.Change.DistinctVisitorsPercent != 100 {
+		t.Errorf("Expected +100%%, got %+v", response.Change)
+	}
+}
+
+func TestCompareHandler_InvalidPeriod(t *testing.T) {
+	handler := CompareHandler(storage.NewNavigationTracker())
+
+	for _, period := range []string{"7", "0d", "16d", "1w"} {
+		req := httptest.NewRequest("GET", "/api/v1/stats/compare?period="+period, nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, period, w.Code)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"nav-tracker/pkg/auth"
+	"nav-tracker/pkg/middleware"
+	"nav-tracker/pkg/models"
+)
+
+var (
+	// ErrConfigVersionNotFound is returned for a rollback to a version the
+	// configuration history no longer keeps.
+	ErrConfigVersionNotFound = errors.New("configuration version not found")
+	// ErrInvalidConfigVersion is returned for a rollback to a configuration
+	// that is no longer valid.
+	ErrInvalidConfigVersion = errors.New("configuration version is no longer valid")
+	// ErrConfigCanaryRunning is returned for a configuration change or a new
+	// canary while a canary runs.
+	ErrConfigCanaryRunning = errors.New("a configuration canary is running; promote or abort it first")
+	// ErrNoConfigCanary is returned to promote or abort a canary when none
+	// runs.
+	ErrNoConfigCanary = errors.New("no configuration canary is running")
+	// ErrInvalidConfigCanary is returned for a canary configuration that
+	// cannot be tried alongside the stable one.
+	ErrInvalidConfigCanary = errors.New("invalid configuration canary")
+)
+
+// ConfigUpdateFunc applies a validated configuration on behalf of actor. It
+// returns the top-level sections that changed, split into those applied
+// immediately and those that only take effect after a restart, or an error
+// if a section could not be applied, in which case the configuration is left
+// unchanged.
+type ConfigUpdateFunc func(config *models.Configuration, actor string) (applied, restartRequired []string, err error)
+
+// ConfigRollbackFunc applies the configuration of an earlier version on
+// behalf of actor, returning the new version it was recorded as and the
+// changed sections as ConfigUpdateFunc does.
+type ConfigRollbackFunc func(version int, actor string) (*models.ConfigVersion, []string, []string, error)
+
+// ConfigCanaryStartFunc starts trying config on percent of visitors on
+// behalf of actor.
+type ConfigCanaryStartFunc func(config *models.Configuration, percent int, actor string) (*models.ConfigCanary, error)
+
+// ConfigCanaryEndFunc ends the running canary on behalf of actor, returning
+// its final stats and, when it was promoted, the version it was recorded as.
+type ConfigCanaryEndFunc func(actor string) (*models.ConfigVersion, *models.ConfigCanary, error)
+
+// ConfigHandler handles GET requests for the configuration in effect and PUT
+// requests updating it. A PUT body only needs the fields being changed.
+func ConfigHandler(current func() *models.Configuration, update ConfigUpdateFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			respondWithJSON(w, r, http.StatusOK, current())
+		case http.MethodPut:
+			config, ok := decodeConfigChanges(w, r, current())
+			if !ok {
+				return
+			}
+
+			applied, restartRequired, err := update(config, configActor(r))
+			if errors.Is(err, ErrConfigCanaryRunning) {
+				respondWithError(w, http.StatusConflict, err.Error())
+				return
+			}
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Failed to apply configuration: "+err.Error())
+				return
+			}
+			respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+				"config":           config,
+				"applied":          applied,
+				"restart_required": restartRequired,
+			})
+		default:
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// ConfigHistoryHandler handles GET requests listing the configuration
+// versions, or returning one with its configuration when version is given
+func ConfigHistoryHandler(list func() []*models.ConfigVersion, get func(version int) (*models.ConfigVersion, bool)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		raw := r.URL.Query().Get("version")
+		if raw == "" {
+			versions := list()
+			respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+				"versions": versions,
+				"count":    len(versions),
+			})
+			return
+		}
+
+		number, err := strconv.Atoi(raw)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid version: must be an integer")
+			return
+		}
+		version, ok := get(number)
+		if !ok {
+			respondWithError(w, http.StatusNotFound, "Configuration version not found")
+			return
+		}
+		respondWithJSON(w, r, http.StatusOK, version)
+	}
+}
+
+// ConfigRollbackHandler handles POST requests restoring the configuration of
+// an earlier version, given as the version query parameter
+func ConfigRollbackHandler(rollback ConfigRollbackFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		number, err := strconv.Atoi(r.URL.Query().Get("version"))
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Missing or invalid query parameter: version")
+			return
+		}
+
+		version, applied, restartRequired, err := rollback(number, configActor(r))
+		switch {
+		case errors.Is(err, ErrConfigVersionNotFound):
+			respondWithError(w, http.StatusNotFound, "Configuration version not found")
+		case errors.Is(err, ErrInvalidConfigVersion), errors.Is(err, ErrConfigCanaryRunning):
+			respondWithError(w, http.StatusConflict, err.Error())
+		case err != nil:
+			respondWithError(w, http.StatusInternalServerError, "Failed to apply configuration: "+err.Error())
+		default:
+			respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+				"version":          version,
+				"applied":          applied,
+				"restart_required": restartRequired,
+			})
+		}
+	}
+}
+
+// ConfigCanaryHandler handles GET requests for the running configuration
+// canary and POST requests starting one on the percent query parameter of
+// visitors. As with a PUT to /api/v1/config, the body only needs the fields
+// being changed.
+func ConfigCanaryHandler(current func() *models.Configuration, canary func() (*models.ConfigCanary, bool), start ConfigCanaryStartFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			status, ok := canary()
+			if !ok {
+				respondWithError(w, http.StatusNotFound, ErrNoConfigCanary.Error())
+				return
+			}
+			respondWithJSON(w, r, http.StatusOK, status)
+		case http.MethodPost:
+			percent, err := strconv.Atoi(r.URL.Query().Get("percent"))
+			if err != nil || percent < 1 || percent > 100 {
+				respondWithError(w, http.StatusBadRequest, "Missing or invalid query parameter: percent must be between 1 and 100")
+				return
+			}
+			config, ok := decodeConfigChanges(w, r, current())
+			if !ok {
+				return
+			}
+
+			status, err := start(config, percent, configActor(r))
+			switch {
+			case errors.Is(err, ErrConfigCanaryRunning):
+				respondWithError(w, http.StatusConflict, err.Error())
+			case errors.Is(err, ErrInvalidConfigCanary):
+				respondWithError(w, http.StatusBadRequest, err.Error())
+			case err != nil:
+				respondWithError(w, http.StatusInternalServerError, "Failed to start canary: "+err.Error())
+			default:
+				respondWithJSON(w, r, http.StatusCreated, status)
+			}
+		default:
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// ConfigCanaryEndHandler handles POST requests promoting the running canary
+// to the configuration in effect, or aborting it, with end
+func ConfigCanaryEndHandler(end ConfigCanaryEndFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		version, status, err := end(configActor(r))
+		switch {
+		case errors.Is(err, ErrNoConfigCanary):
+			respondWithError(w, http.StatusNotFound, err.Error())
+		case err != nil:
+			respondWithError(w, http.StatusInternalServerError, "Failed to apply configuration: "+err.Error())
+		default:
+			response := map[string]interface{}{"canary": status}
+			if version != nil {
+				response["version"] = version
+			}
+			respondWithJSON(w, r, http.StatusOK, response)
+		}
+	}
+}
+
+// decodeConfigChanges applies the fields in r's body to a copy of current
+// and validates the result, answering the request itself when that fails.
+func decodeConfigChanges(w http.ResponseWriter, r *http.Request, current *models.Configuration) (*models.Configuration, bool) {
+	config, err := current.Clone()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to copy configuration")
+		return nil, false
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return nil, false
+	}
+	if err := config.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return config, true
+}
+
+// configActor identifies who made a configuration change: the subject of
+// the request's token, a fingerprint of its API key, or else its remote
+// address. API keys are fingerprinted so the history never holds them.
+func configActor(r *http.Request) string {
+	if claims, ok := auth.ClaimsFrom(r.Context()); ok && claims.Subject != "" {
+		return "token:" + claims.Subject
+	}
+	if key := r.Header.Get(middleware.APIKeyHeader); key != "" {
+		sum := sha256.Sum256([]byte(key))
+		return "api_key:" + hex.EncodeToString(sum[:4])
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestConfigHandler_Put(t *testing.T) {
+	current := models.DefaultConfiguration()
+	var updated *models.Configuration
+	handler := ConfigHandler(
+		func() *models.Configuration { return current },
+		func(config *models.Configuration, actor string) ([]string, []string, error) {
+			updated = config
+			return []string{"cors"}, []string{}, nil
+		},
+	)
+
+	body := `{"cors": {"allowed_origins": ["https://*.example.com"]}}`
+	req := httptest.NewRequest("PUT", "/config", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if updated == nil || len(updated.CORS.AllowedOrigins) != 1 {
+		t.Fatalf("Expected update to receive the new origins, got %+v", updated)
+	}
+	if len(updated.CORS.AllowedMethods) == 0 || updated.Port != current.Port {
+		t.Error("Expected fields missing from the body to keep their current values")
+	}
+	if len(current.CORS.AllowedOrigins) != 0 {
+		t.Error("Expected the current configuration not to be modified")
+	}
+
+	var response struct {
+		Applied         []string `json:"applied"`
+		RestartRequired []string `json:"restart_required"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Applied) != 1 || response.Applied[0] != "cors" {
+		t.Errorf("Expected cors to be applied, got %v", response.Applied)
+	}
+}
+
+func TestConfigHandler_PutInvalid(t *testing.T) {
+	handler := ConfigHandler(
+		func() *models.Configuration { return models.DefaultConfiguration() },
+		func(config *models.Configuration, actor string) ([]string, []string, error) {
+			t.Error("Expected invalid configuration not to be applied")
+			return nil, nil, nil
+		},
+	)
+
+	bodies := []string{
+		`{"cors": {"allowed_origins": ["example.com"]}}`,
+		`{"unknown": true}`,
+		`not json`,
+	}
+	for _, body := range bodies {
+		req := httptest.NewRequest("PUT", "/config", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+		}
+	}
+}
+
+func TestConfigHandler_WrongMethod(t *testing.T) {
+	handler := ConfigHandler(models.DefaultConfiguration, nil)
+
+	req := httptest.NewRequest("POST", "/config", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+func TestConfigRollbackHandler(t *testing.T) {
+	var actor string
+	handler := ConfigRollbackHandler(func(version int, by string) (*models.ConfigVersion, []string, []string, error) {
+		actor = by
+		switch version {
+		case 1:
+			return &models.ConfigVersion{Version: 3, Origin: models.ConfigRollback, RollbackOf: 1}, []string{"cors"}, []string{}, nil
+		case 2:
+			return nil, nil, nil, ErrInvalidConfigVersion
+		default:
+			return nil, nil, nil, ErrConfigVersionNotFound
+		}
+	})
+
+	tests := []struct {
+		target   string
+		expected int
+	}{
+		{"/config/rollback?version=1", http.StatusOK},
+		{"/config/rollback?version=2", http.StatusConflict},
+		{"/config/rollback?version=9", http.StatusNotFound},
+		{"/config/rollback?version=latest", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", tt.target, nil)
+		req.Header.Set("X-API-Key", "secret")
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != tt.expected {
+			t.Errorf("Expected status %d for %s, got %d", tt.expected, tt.target, w.Code)
+		}
+	}
+	if actor == "" || strings.Contains(actor, "secret") {
+		t.Errorf("Expected the API key fingerprinted, got %q", actor)
+	}
+}
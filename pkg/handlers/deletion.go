@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// DeleteVisitorHandler handles DELETE requests to /api/v1/visitors/{visitor_id}
+// purging a visitor's data. Deleting an unknown visitor succeeds and is audited.
+// If the counter backend could not be purged the entry is returned with 503.
+func DeleteVisitorHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		visitorID := strings.TrimPrefix(r.URL.Path, visitorsPath)
+		if visitorID == "" || strings.Contains(visitorID, "/") {
+			respondWithError(w, http.StatusNotFound, "Not found")
+			return
+		}
+
+		entry := tracker.DeleteVisitor(visitorKey(r, tracker, visitorID), r.RemoteAddr)
+		respondWithJSON(w, r, deletionStatus(entry), entry)
+	}
+}
+
+// DeleteURLHandler handles DELETE requests to /api/v1/urls?url= purging a URL's data
+func DeleteURLHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		url := r.URL.Query().Get("url")
+		if url == "" {
+			respondWithError(w, http.StatusBadRequest, "Missing required query parameter: url")
+			return
+		}
+
+		entry := tracker.DeleteURL(models.NormalizeURL(url), r.RemoteAddr)
+		respondWithJSON(w, r, deletionStatus(entry), entry)
+	}
+}
+
+// deletionStatus is 503 when the counter backend kept the deleted data, so
+// the caller retries; repeating a deletion is safe.
+func deletionStatus(entry *models.DeletionAuditEntry) int {
+	if entry.BackendError != "" {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+// DeletionLogHandler handles GET requests for the data deletion audit log
+func DeletionLogHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		respondWithJSON(w, r, http.StatusOK, tracker.DeletionLog())
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestDeleteVisitorHandler(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com"}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	req := httptest.NewRequest("DELETE", "/api/v1/visitors/visitor1", nil)
+	w := httptest.NewRecorder()
+	DeleteVisitorHandler(tracker)(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var entry models.DeletionAuditEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if entry.Kind != models.DeletionVisitor || entry.Target != "visitor1" || entry.URLs != 1 {
+		t.Errorf("Unexpected deletion entry %+v", entry)
+	}
+	if tracker.GetDistinctVisitors("https://example.com") != 0 {
+		t.Error("Expected visitor to be deleted")
+	}
+}
+
+func TestDeleteURLHandler(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/Page"}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	req := httptest.NewRequest("DELETE", "/api/v1/urls?url=https://example.com/Page/", nil)
+	w := httptest.NewRecorder()
+	DeleteURLHandler(tracker)(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if tracker.GetDistinctVisitors("https://example.com/page") != 0 {
+		t.Error("Expected normalized URL to be deleted")
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/deletions", nil)
+	w = httptest.NewRecorder()
+	DeletionLogHandler(tracker)(w, req)
+
+	var entries []models.DeletionAuditEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Target != "https://example.com/page" || entries[0].Visitors != 1 {
+		t.Errorf("Expected URL deletion in audit log, got %+v", entries)
+	}
+}
+
+func TestDeleteHandlers_BackendDown(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	tracker.SetCounterBackend(downBackend{})
+
+	for _, tc := range []struct {
+		handler http.HandlerFunc
+		target  string
+	}{
+		{DeleteVisitorHandler(tracker), "/api/v1/visitors/visitor1"},
+		{DeleteURLHandler(tracker), "/api/v1/urls?url=https://example.com"},
+	} {
+		req := httptest.NewRequest("DELETE", tc.target, nil)
+		w := httptest.NewRecorder()
+		tc.handler(w, req)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected status %d, got %d", tc.target, http.StatusServiceUnavailable, w.Code)
+		}
+		var entry models.DeletionAuditEntry
+		if err := json.Unmarshal(w.Body.Bytes(), &entry); err != nil || entry.BackendError == "" {
+			t.Errorf("%s: expected the backend error in the entry, got %+v (%v)", tc.target, entry, err)
+		}
+	}
+}
+
+func TestDeleteHandlers_BadRequests(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+
+	tests := []struct {
+		handler  http.HandlerFunc
+		method   string
+		path     string
+		expected int
+	}{
+		{DeleteVisitorHandler(tracker), "GET", "/api/v1/visitors/visitor1", http.StatusMethodNotAllowed},
+		{DeleteVisitorHandler(tracker), "DELETE", "/api/v1/visitors/", http.StatusNotFound},
+		{DeleteURLHandler(tracker), "DELETE", "/api/v1/urls", http.StatusBadRequest},
+		{DeleteURLHandler(tracker), "POST", "/api/v1/urls?url=https://example.com", http.StatusMethodNotAllowed},
+		{DeletionLogHandler(tracker), "DELETE", "/api/v1/deletions", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		w := httptest.NewRecorder()
+		tt.handler(w, req)
+
+		if w.Code != tt.expected {
+			t.Errorf("Expected status %d for %s %s, got %d", tt.expected, tt.method, tt.path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+type denyRuleRequest struct {
+	List string `json:"list"`
+	models.DenyRule
+}
+
+// DenylistHandler handles GET requests to list the deny rules with their
+// rejection counts, PUT requests to replace them, and POST and DELETE
+// requests to add or remove one rule
+func DenylistHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			respondWithDenylist(w, r, tracker)
+		case http.MethodPut:
+			var config models.DenylistConfig
+			if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+				return
+			}
+			if err := tracker.SetDenylist(config); err != nil {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			respondWithDenylist(w, r, tracker)
+		case http.MethodPost:
+			var req denyRuleRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+				return
+			}
+
+			err := tracker.AddDenyRule(req.List, req.DenyRule)
+			switch {
+			case errors.Is(err, storage.ErrDenyRuleExists):
+				respondWithError(w, http.StatusConflict, err.Error())
+			case err != nil:
+				respondWithError(w, http.StatusBadRequest, err.Error())
+			default:
+				respondWithJSON(w, r, http.StatusCreated, req)
+			}
+		case http.MethodDelete:
+			var req denyRuleRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+				return
+			}
+
+			removed, err := tracker.RemoveDenyRule(req.List, req.DenyRule)
+			switch {
+			case err != nil:
+				respondWithError(w, http.StatusBadRequest, err.Error())
+			case !removed:
+				respondWithError(w, http.StatusNotFound, "Deny rule not found")
+			default:
+				respondWithDenylist(w, r, tracker)
+			}
+		default:
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+func respondWithDenylist(w http.ResponseWriter, r *http.Request, tracker *storage.NavigationTracker) {
+	denylist := tracker.Denylist()
+	respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+		"urls":      denylist.URLs,
+		"referrers": denylist.Referrers,
+		"stats":     tracker.DenylistStats(),
+	})
+}
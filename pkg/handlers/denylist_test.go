@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestDenylistHandler(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := DenylistHandler(tracker)
+
+	tests := []struct {
+		method string
+		body   string
+		status int
+	}{
+		{"POST", `{"list": "referrers", "match": "exact", "pattern": "spam.example"}`, http.StatusCreated},
+		{"POST", `{"list": "referrers", "match": "exact", "pattern": "spam.example"}`, http.StatusConflict},
+		{"POST", `{"list": "urls", "match": "glob", "pattern": "*"}`, http.StatusBadRequest},
+		{"POST", `not json`, http.StatusBadRequest},
+		{"DELETE", `{"list": "urls", "match": "exact", "pattern": "https://example.com/"}`, http.StatusNotFound},
+		{"PUT", `{"urls": [{"match": "regex", "pattern": "("}]}`, http.StatusBadRequest},
+		{"PATCH", ``, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(tt.method, "/api/v1/denylist", strings.NewReader(tt.body)))
+		if w.Code != tt.status {
+			t.Errorf("Expected status %d for %s %s, got %d", tt.status, tt.method, tt.body, w.Code)
+		}
+	}
+
+	ingest := httptest.NewRecorder()
+	IngestHandler(tracker)(ingest, httptest.NewRequest("POST", "/ingest", strings.NewReader(
+		`{"visitor_id": "visitor1", "url": "https://example.com/", "referrer": "https://spam.example/"}`)))
+	if ingest.Code != http.StatusForbidden {
+		t.Errorf("Expected a denylisted event to be rejected with 403, got %d", ingest.Code)
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/v1/denylist", nil))
+	var response struct {
+		URLs      []models.DenyRule    `json:"urls"`
+		Referrers []models.DenyRule    `json:"referrers"`
+		Stats     models.DenylistStats `json:"stats"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(response.Referrers) != 1 || len(response.URLs) != 0 || response.Stats.RejectedReferrers != 1 {
+		t.Errorf("Unexpected denylist: %+v", response)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("PUT", "/api/v1/denylist", strings.NewReader(`{"urls": [{"match": "prefix", "pattern": "https://example.com/wp-"}]}`)))
+	if w.Code != http.StatusOK || len(tracker.Denylist().URLs) != 1 || len(tracker.Denylist().Referrers) != 0 {
+		t.Errorf("Expected PUT to replace every rule, got %d and %+v", w.Code, tracker.Denylist())
+	}
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// DevicesHandler handles GET requests to retrieve the browser, OS and device breakdown for a URL
+func DevicesHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		urlParam := r.URL.Query().Get("url")
+		if urlParam == "" {
+			respondWithError(w, http.StatusBadRequest, "Missing required query parameter: url")
+			return
+		}
+
+		response := map[string]interface{}{
+			"url":     urlParam,
+			"devices": tracker.GetDeviceBreakdown(models.NormalizeURL(urlParam)),
+		}
+
+		respondWithJSON(w, r, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+const firefoxLinuxUA = "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"
+
+func TestDevicesHandler_UsesRequestUserAgent(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	ingest := IngestHandler(tracker)
+	handler := DevicesHandler(tracker)
+
+	jsonData, _ := json.Marshal(models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/a"})
+	req := httptest.NewRequest("POST", "/ingest", bytes.NewBuffer(jsonData))
+	req.Header.Set("User-Agent", firefoxLinuxUA)
+	w := httptest.NewRecorder()
+	ingest(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/devices?url=HTTPS://Example.com/A/%23top", nil)
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Devices models.DeviceBreakdown `json:"devices"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if response.Devices.Browsers["Firefox"] != 1 || response.Devices.OperatingSystems["Linux"] != 1 {
+		t.Errorf("Unexpected device breakdown: %+v", response.Devices)
+	}
+}
+
+func TestDevicesHandler_MissingURL(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := DevicesHandler(tracker)
+
+	req := httptest.NewRequest("GET", "/api/v1/devices", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
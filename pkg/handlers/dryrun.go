@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"nav-tracker/pkg/storage"
+)
+
+// DryRunIngestHandler handles POST requests with dry_run=true by validating
+// and normalizing the event and returning what would be stored without
+// recording it, and passes every other request to next
+func DryRunIngestHandler(tracker *storage.NavigationTracker, next http.Handler, enrichers ...EventEnricher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		param := r.URL.Query().Get("dry_run")
+		if param == "" || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		dryRun, err := strconv.ParseBool(param)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid dry_run: must be true or false")
+			return
+		}
+		if !dryRun {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		event, err := decodeIngestEvent(r, enrichers)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, decodeErrorMessage(err))
+			return
+		}
+
+		result, err := tracker.DryRun(&event)
+		var violation *storage.SchemaViolationError
+		switch {
+		case errors.Is(err, storage.ErrURLFrozen):
+			respondWithError(w, http.StatusConflict, "URL is frozen")
+		case errors.Is(err, storage.ErrVisitorCapReached):
+			respondWithError(w, http.StatusConflict, "URL has reached its visitor cap")
+		case errors.Is(err, storage.ErrDenied):
+			respondWithError(w, http.StatusForbidden, "Event is denylisted")
+		case errors.Is(err, storage.ErrTimestampOutOfWindow):
+			respondWithTimestampOutOfWindow(w, err)
+		case errors.As(err, &violation):
+			respondWithSchemaViolation(w, violation)
+		case err != nil:
+			respondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			respondWithJSON(w, r, http.StatusOK, result)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestDryRunIngestHandler(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := DryRunIngestHandler(tracker, IngestHandler(tracker))
+	body := `{"visitor_id": "visitor1", "url": "HTTPS://Example.com/page/"}`
+
+	req := httptest.NewRequest("POST", "/ingest?dry_run=true", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var result models.DryRunResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if !result.DryRun || result.Event.URL != "https://example.com/page" || !result.NewURL {
+		t.Errorf("Expected the normalized event for a new URL, got %+v", result)
+	}
+	if visitors := tracker.GetDistinctVisitors("https://example.com/page"); visitors != 0 {
+		t.Errorf("Expected nothing recorded, got %d visitors", visitors)
+	}
+
+	// Without dry_run, or with it false, the event is recorded
+	for _, target := range []string{"/ingest", "/ingest?dry_run=false"} {
+		w = httptest.NewRecorder()
+		handler(w, httptest.NewRequest("POST", target, strings.NewReader(body)))
+		if w.Code != http.StatusCreated {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusCreated, target, w.Code)
+		}
+	}
+	if visitors := tracker.GetDistinctVisitors("https://example.com/page"); visitors != 1 {
+		t.Errorf("Expected the event recorded, got %d visitors", visitors)
+	}
+}
+
+func TestDryRunIngestHandler_Errors(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := DryRunIngestHandler(tracker, IngestHandler(tracker))
+
+	tests := []struct {
+		target string
+		body   string
+		status int
+	}{
+		{"/ingest?dry_run=maybe", `{"visitor_id": "visitor1", "url": "https://example.com/"}`, http.StatusBadRequest},
+		{"/ingest?dry_run=true", `{"visitor_id": "visitor1"}`, http.StatusBadRequest},
+		{"/ingest?dry_run=true", `not json`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("POST", tt.target, strings.NewReader(tt.body)))
+		if w.Code != tt.status {
+			t.Errorf("Expected status %d for %s %s, got %d", tt.status, tt.target, tt.body, w.Code)
+		}
+	}
+}
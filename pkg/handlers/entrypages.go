@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+const (
+	defaultSessionPagesLimit = 10
+	maxSessionPagesLimit     = 100
+)
+
+// EntryPagesHandler handles GET requests for the URLs that most often start sessions
+func EntryPagesHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return sessionPagesHandler(tracker.GetEntryPages)
+}
+
+// ExitPagesHandler handles GET requests for the URLs that most often end sessions
+func ExitPagesHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return sessionPagesHandler(tracker.GetExitPages)
+}
+
+func sessionPagesHandler(report func(limit int) *models.SessionPagesReport) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		limit, err := parseLimitParam(r, defaultSessionPagesLimit, maxSessionPagesLimit)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit: must be a positive integer")
+			return
+		}
+
+		respondWithJSON(w, r, http.StatusOK, report(limit))
+	}
+}
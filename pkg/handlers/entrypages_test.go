@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestEntryAndExitPagesHandlers(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	for _, url := range []string{"https://example.com/a", "https://example.com/b"} {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	tests := []struct {
+		handler http.HandlerFunc
+		url     string
+	}{
+		{EntryPagesHandler(tracker), "https://example.com/a"},
+		{ExitPagesHandler(tracker), "https://example.com/b"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		tt.handler(w, httptest.NewRequest("GET", "/?limit=5", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var report models.SessionPagesReport
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if report.TotalSessions != 1 || len(report.Pages) != 1 || report.Pages[0].URL != tt.url || report.Pages[0].Share != 100 {
+			t.Errorf("Expected %s in every session, got %+v", tt.url, report)
+		}
+
+		w = httptest.NewRecorder()
+		tt.handler(w, httptest.NewRequest("GET", "/?limit=0", nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for an invalid limit, got %d", w.Code)
+		}
+
+		w = httptest.NewRecorder()
+		tt.handler(w, httptest.NewRequest("POST", "/", nil))
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected 405, got %d", w.Code)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// EventTypeStatsHandler handles GET requests counting a URL's events by type,
+// optionally only those whose indexed property has a given value
+func EventTypeStatsHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		url := r.URL.Query().Get("url")
+		if url == "" {
+			respondWithError(w, http.StatusBadRequest, "Missing required query parameter: url")
+			return
+		}
+		url = models.NormalizeURL(url)
+
+		eventType := r.URL.Query().Get("type")
+		if eventType != "" {
+			if err := models.ValidateEventType(eventType); err != nil {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		if filter := r.URL.Query().Get("property"); filter != "" {
+			property, value, ok := strings.Cut(filter, ":")
+			if !ok || eventType == "" {
+				respondWithError(w, http.StatusBadRequest, "property must be name:value and requires type")
+				return
+			}
+			stats, err := tracker.FilteredEventTypeStats(url, eventType, property, value)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			respondWithJSON(w, r, http.StatusOK, stats)
+			return
+		}
+
+		respondWithJSON(w, r, http.StatusOK, tracker.EventTypeStats(url, eventType))
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestEventTypeStatsHandler(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	ingest := IngestHandler(tracker)
+	for _, body := range []string{
+		`{"visitor_id": "visitor1", "url": "https://example.com/"}`,
+		`{"visitor_id": "visitor1", "url": "https://example.com/", "event_type": "click", "payload": {"button": "buy"}}`,
+	} {
+		w := httptest.NewRecorder()
+		ingest(w, httptest.NewRequest("POST", "/api/v1/ingest", bytes.NewBufferString(body)))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+	}
+	w := httptest.NewRecorder()
+	ingest(w, httptest.NewRequest("POST", "/api/v1/ingest", bytes.NewBufferString(`{"visitor_id": "visitor1", "url": "https://example.com/", "event_type": "scroll"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown event type to be refused, got %d", w.Code)
+	}
+
+	handler := EventTypeStatsHandler(tracker)
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/v1/events/stats?url=https://example.com/&type=click", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var stats models.EventTypeStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(stats.Events) != 1 || stats.Events[0].Type != models.EventTypeClick || stats.Events[0].Count != 1 {
+		t.Errorf("Expected 1 click, got %+v", stats.Events)
+	}
+
+	for _, target := range []string{"/api/v1/events/stats", "/api/v1/events/stats?url=https://example.com/&type=custom:"} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", target, http.StatusBadRequest, w.Code)
+		}
+	}
+}
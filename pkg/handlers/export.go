@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// exportFlushInterval is the number of records written between flushes
+const exportFlushInterval = 500
+
+// ExportHandler handles GET requests to stream visitor records as NDJSON or CSV
+func ExportHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		query := r.URL.Query()
+
+		format := query.Get("format")
+		if format == "" {
+			format = "ndjson"
+		}
+		if format != "ndjson" && format != "csv" {
+			respondWithError(w, http.StatusBadRequest, "Invalid format: must be ndjson or csv")
+			return
+		}
+
+		filter := storage.ExportFilter{
+			URLPrefix: query.Get("url_prefix"),
+			Trace:     storage.QueryTraceFromContext(r.Context()),
+		}
+
+		var err error
+		if filter.From, err = parseTimeParam(query.Get("from")); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid from: must be RFC3339")
+			return
+		}
+		if filter.To, err = parseTimeParam(query.Get("to")); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid to: must be RFC3339")
+			return
+		}
+		if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+			respondWithError(w, http.StatusBadRequest, "Invalid time range: from must be before to")
+			return
+		}
+
+		resumeToken := query.Get("resume_token")
+		resumable := query.Get("resumable") == "true" || resumeToken != ""
+		if resumable && format != "ndjson" {
+			respondWithError(w, http.StatusBadRequest, "Resumable exports are only supported for ndjson")
+			return
+		}
+
+		checkpoint := exportCheckpoint{Filter: exportFilterKey(query)}
+		if resumeToken != "" {
+			resumed, err := decodeExportCheckpoint(resumeToken)
+			if err != nil || resumed.Filter != checkpoint.Filter {
+				respondWithError(w, http.StatusBadRequest, "Invalid resume_token")
+				return
+			}
+			checkpoint = resumed
+		} else if resumable {
+			checkpoint.Epoch = tracker.ExportEpoch()
+		}
+		filter.Epoch = checkpoint.Epoch
+		filter.After = storage.ExportPosition{URL: checkpoint.URL, VisitorID: checkpoint.VisitorID}
+
+		flusher, _ := w.(http.Flusher)
+		flush := func() {
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+
+		written := 0
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv")
+			w.WriteHeader(http.StatusOK)
+
+			cw := csv.NewWriter(w)
+			_ = cw.Write([]string{"url", "visitor_id", "first_seen", "last_seen", "page_views"})
+			err = tracker.Export(filter, func(record *models.ExportRecord) error {
+				if err := cw.Write([]string{
+					record.URL,
+					record.VisitorID,
+					record.FirstSeen.Format(time.RFC3339Nano),
+					record.LastSeen.Format(time.RFC3339Nano),
+					strconv.FormatInt(record.PageViews, 10),
+				}); err != nil {
+					return err
+				}
+				written++
+				if written%exportFlushInterval == 0 {
+					cw.Flush()
+					flush()
+				}
+				return cw.Error()
+			})
+			cw.Flush()
+		} else {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+
+			encoder := json.NewEncoder(w)
+			writeCheckpoint := func(complete bool) error {
+				return encoder.Encode(map[string]interface{}{
+					"checkpoint": checkpoint.encode(),
+					"complete":   complete,
+				})
+			}
+
+			err = tracker.Export(filter, func(record *models.ExportRecord) error {
+				if err := encoder.Encode(record); err != nil {
+					return err
+				}
+				checkpoint.URL, checkpoint.VisitorID = record.URL, record.VisitorID
+				written++
+				if written%exportFlushInterval == 0 {
+					if resumable {
+						if err := writeCheckpoint(false); err != nil {
+							return err
+						}
+					}
+					flush()
+				}
+				return nil
+			})
+			if err == nil && resumable {
+				err = writeCheckpoint(true)
+			}
+		}
+
+		if err != nil {
+			log.Printf("Error streaming export: %v", err)
+		}
+	}
+}
+
+// exportCheckpoint is the position carried by a resume token: the snapshot
+// epoch, the last record written and the filter the export was started with.
+type exportCheckpoint struct {
+	Epoch     uint64 `json:"e"`
+	URL       string `json:"u,omitempty"`
+	VisitorID string `json:"v,omitempty"`
+	Filter    string `json:"f"`
+}
+
+func (c exportCheckpoint) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeExportCheckpoint(token string) (exportCheckpoint, error) {
+	var checkpoint exportCheckpoint
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return checkpoint, err
+	}
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return checkpoint, err
+	}
+	if checkpoint.Epoch == 0 {
+		return checkpoint, fmt.Errorf("missing epoch")
+	}
+
+	return checkpoint, nil
+}
+
+// exportFilterKey ties a resume token to the filter parameters it was issued for
+func exportFilterKey(query url.Values) string {
+	return query.Get("url_prefix") + "|" + query.Get("from") + "|" + query.Get("to")
+}
+
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
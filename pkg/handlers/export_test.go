@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func seedExportTracker(t *testing.T) *storage.NavigationTracker {
+	tracker := storage.NewNavigationTracker()
+
+	events := []models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/blog/a", Timestamp: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{VisitorID: "visitor2", URL: "https://example.com/blog/a", Timestamp: time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC)},
+		{VisitorID: "visitor1", URL: "https://example.com/shop", Timestamp: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for i := range events {
+		if err := tracker.RecordEvent(&events[i]); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	return tracker
+}
+
+func TestExportHandler_NDJSON(t *testing.T) {
+	tracker := seedExportTracker(t)
+	handler := ExportHandler(tracker)
+
+	req := httptest.NewRequest("GET", "/api/v1/export", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Errorf("Expected Content-Type application/x-ndjson, got %s", contentType)
+	}
+
+	var records []models.ExportRecord
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var record models.ExportRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Failed to unmarshal line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %d", len(records))
+	}
+
+	if records[0].URL != "https://example.com/blog/a" || records[0].VisitorID != "visitor1" {
+		t.Errorf("Expected first record for visitor1 on blog/a, got %+v", records[0])
+	}
+
+	if records[0].PageViews != 1 {
+		t.Errorf("Expected 1 page view, got %d", records[0].PageViews)
+	}
+}
+
+func TestExportHandler_CSVWithFilters(t *testing.T) {
+	tracker := seedExportTracker(t)
+	handler := ExportHandler(tracker)
+
+	req := httptest.NewRequest("GET", "/api/v1/export?format=csv&url_prefix=https://example.com/blog&from=2024-01-03T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("Expected header and 1 row, got %d rows", len(rows))
+	}
+
+	if rows[0][0] != "url" {
+		t.Errorf("Expected CSV header, got %v", rows[0])
+	}
+
+	if rows[1][1] != "visitor2" {
+		t.Errorf("Expected visitor2 in filtered export, got %v", rows[1])
+	}
+}
+
+func TestExportHandler_InvalidParams(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := ExportHandler(tracker)
+
+	tests := []string{
+		"/api/v1/export?format=xml",
+		"/api/v1/export?from=yesterday",
+		"/api/v1/export?from=2024-01-02T00:00:00Z&to=2024-01-01T00:00:00Z",
+	}
+
+	for _, target := range tests {
+		req := httptest.NewRequest("GET", target, nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, target, w.Code)
+		}
+	}
+}
+
+func TestExportHandler_WrongMethod(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := ExportHandler(tracker)
+
+	req := httptest.NewRequest("POST", "/api/v1/export", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+func TestExportHandler_ResumeFromCheckpoint(t *testing.T) {
+	tracker := seedExportTracker(t)
+	handler := ExportHandler(tracker)
+
+	req := httptest.NewRequest("GET", "/api/v1/export?resumable=true", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 3 records and a final checkpoint, got %d lines", len(lines))
+	}
+
+	var final struct {
+		Checkpoint string `json:"checkpoint"`
+		Complete   bool   `json:"complete"`
+	}
+	if err := json.Unmarshal([]byte(lines[3]), &final); err != nil || final.Checkpoint == "" || !final.Complete {
+		t.Fatalf("Expected final checkpoint line, got %q", lines[3])
+	}
+
+	// Build a checkpoint positioned after the first record, as a periodic
+	// checkpoint would be, and resume from it.
+	checkpoint, err := decodeExportCheckpoint(final.Checkpoint)
+	if err != nil {
+		t.Fatalf("Failed to decode checkpoint: %v", err)
+	}
+	checkpoint.URL, checkpoint.VisitorID = "https://example.com/blog/a", "visitor1"
+
+	newEvent := &models.NavigationEvent{VisitorID: "visitor0", URL: "https://example.com/blog/a"}
+	if err := tracker.RecordEvent(newEvent); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/export?resume_token="+checkpoint.encode(), nil)
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	lines = strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 2 remaining records and a final checkpoint, got %d lines", len(lines))
+	}
+
+	var record models.ExportRecord
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("Failed to unmarshal record: %v", err)
+	}
+	if record.VisitorID != "visitor2" {
+		t.Errorf("Expected resume at visitor2 excluding post-epoch visitor0, got %+v", record)
+	}
+}
+
+func TestExportHandler_InvalidResumeToken(t *testing.T) {
+	tracker := seedExportTracker(t)
+	handler := ExportHandler(tracker)
+
+	token := exportCheckpoint{Epoch: 1, Filter: exportFilterKey(nil)}.encode()
+
+	tests := []string{
+		"/api/v1/export?resume_token=not-a-token",
+		"/api/v1/export?resume_token=" + token + "&url_prefix=https://example.com/shop",
+		"/api/v1/export?resumable=true&format=csv",
+	}
+
+	for _, target := range tests {
+		req := httptest.NewRequest("GET", target, nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, target, w.Code)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"nav-tracker/pkg/storage"
+)
+
+const funnelsPath = "/api/v1/funnels/"
+
+type createFunnelRequest struct {
+	Name  string   `json:"name"`
+	Steps []string `json:"steps"`
+}
+
+// FunnelsHandler handles GET requests to list funnels and POST requests to define a new one
+func FunnelsHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			funnels := tracker.Funnels().List()
+			respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+				"funnels": funnels,
+				"count":   len(funnels),
+			})
+		case http.MethodPost:
+			var req createFunnelRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+				return
+			}
+
+			funnel, err := tracker.Funnels().Create(req.Name, req.Steps)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			respondWithJSON(w, r, http.StatusCreated, funnel)
+		default:
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// FunnelReportHandler handles GET requests to /api/v1/funnels/{id}/report
+func FunnelReportHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, funnelsPath), "/report")
+		if !ok || id == "" || strings.Contains(id, "/") {
+			respondWithError(w, http.StatusNotFound, "Not found")
+			return
+		}
+
+		funnel, exists := tracker.Funnels().Get(id)
+		if !exists {
+			respondWithError(w, http.StatusNotFound, "Funnel not found")
+			return
+		}
+
+		respondWithJSON(w, r, http.StatusOK, tracker.FunnelReport(funnel))
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestFunnelsHandler_CreateAndReport(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	create := FunnelsHandler(tracker)
+	report := FunnelReportHandler(tracker)
+
+	for _, url := range []string{"https://example.com/landing", "https://example.com/signup"} {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor2", URL: "https://example.com/landing"}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	body, _ := json.Marshal(createFunnelRequest{
+		Name:  "signup",
+		Steps: []string{"https://example.com/landing", "https://example.com/signup"},
+	})
+	req := httptest.NewRequest("POST", "/api/v1/funnels", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	create(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	var funnel models.Funnel
+	if err := json.Unmarshal(w.Body.Bytes(), &funnel); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/funnels/"+funnel.ID+"/report", nil)
+	w = httptest.NewRecorder()
+	report(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response models.FunnelReport
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if len(response.Steps) != 2 || response.Steps[0].Visitors != 2 || response.Steps[1].Visitors != 1 {
+		t.Errorf("Unexpected funnel report: %+v", response.Steps)
+	}
+
+	if response.Steps[1].DropOffRate != 0.5 {
+		t.Errorf("Expected drop-off rate 0.5, got %f", response.Steps[1].DropOffRate)
+	}
+}
+
+func TestFunnelsHandler_InvalidSteps(t *testing.T) {
+	handler := FunnelsHandler(storage.NewNavigationTracker())
+
+	body, _ := json.Marshal(createFunnelRequest{Name: "short", Steps: []string{"https://example.com"}})
+	req := httptest.NewRequest("POST", "/api/v1/funnels", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestFunnelReportHandler_NotFound(t *testing.T) {
+	handler := FunnelReportHandler(storage.NewNavigationTracker())
+
+	for _, path := range []string{"/api/v1/funnels/missing/report", "/api/v1/funnels/funnel_1", "/api/v1/funnels//report"} {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusNotFound, w.Code)
+		}
+	}
+}
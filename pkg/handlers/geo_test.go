@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestGeoHandler_UsesIngestEnricher(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	ingest := IngestHandler(tracker, func(r *http.Request, event *models.NavigationEvent) {
+		event.Country = "KE"
+	})
+	handler := GeoHandler(tracker)
+
+	jsonData, _ := json.Marshal(models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/a"})
+	req := httptest.NewRequest("POST", "/ingest", bytes.NewBuffer(jsonData))
+	w := httptest.NewRecorder()
+	ingest(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/geo?url=HTTPS://Example.com/A/%23top", nil)
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Countries []models.CountryStats `json:"countries"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if len(response.Countries) != 1 || response.Countries[0].Country != "KE" || response.Countries[0].DistinctVisitors != 1 {
+		t.Errorf("Unexpected geo breakdown: %+v", response.Countries)
+	}
+}
+
+func TestGeoHandler_MissingURL(t *testing.T) {
+	handler := GeoHandler(storage.NewNavigationTracker())
+
+	req := httptest.NewRequest("GET", "/api/v1/geo", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+const (
+	// importProgressInterval is the number of lines processed between progress reports
+	importProgressInterval = 1000
+	maxImportErrorSamples  = 20
+	// maxImportErrorKinds bounds the distinct messages counted in a summary;
+	// further kinds are counted under importOtherErrors.
+	maxImportErrorKinds = 50
+	importOtherErrors   = "other errors"
+	maxImportLineBytes  = 1 << 20
+)
+
+// importCSVColumns maps the CSV header names accepted by an import to the
+// event fields they set.
+var importCSVColumns = map[string]func(event *models.NavigationEvent, value string) error{
+	"event_id":   func(e *models.NavigationEvent, v string) error { e.EventID = v; return nil },
+	"visitor_id": func(e *models.NavigationEvent, v string) error { e.VisitorID = v; return nil },
+	"url":        func(e *models.NavigationEvent, v string) error { e.URL = v; return nil },
+	"session_id": func(e *models.NavigationEvent, v string) error { e.SessionID = v; return nil },
+	"referrer":   func(e *models.NavigationEvent, v string) error { e.Referrer = v; return nil },
+	"user_agent": func(e *models.NavigationEvent, v string) error { e.UserAgent = v; return nil },
+	"country":    func(e *models.NavigationEvent, v string) error { e.Country = v; return nil },
+	"region":     func(e *models.NavigationEvent, v string) error { e.Region = v; return nil },
+	"city":       func(e *models.NavigationEvent, v string) error { e.City = v; return nil },
+	"language":   func(e *models.NavigationEvent, v string) error { e.Language = v; return nil },
+	"timestamp": func(e *models.NavigationEvent, v string) error {
+		if v == "" {
+			return nil
+		}
+		timestamp, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return fmt.Errorf("invalid timestamp: must be RFC3339")
+		}
+		e.Timestamp = timestamp
+		return nil
+	},
+}
+
+// importSource yields the events of an import body one line at a time. A
+// line that cannot be decoded is returned as lineErr; err ends the import.
+type importSource interface {
+	next() (event *models.NavigationEvent, line int, lineErr error, err error)
+}
+
+type ndjsonSource struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newNDJSONSource(r io.Reader) *ndjsonSource {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLineBytes)
+	return &ndjsonSource{scanner: scanner}
+}
+
+func (s *ndjsonSource) next() (*models.NavigationEvent, int, error, error) {
+	for s.scanner.Scan() {
+		s.line++
+		data := strings.TrimSpace(s.scanner.Text())
+		if data == "" {
+			continue
+		}
+
+		event, err := models.DecodeEvent([]byte(data))
+		if err != nil {
+			var schemaErr *models.SchemaError
+			if errors.As(err, &schemaErr) {
+				return nil, s.line, err, nil
+			}
+			return nil, s.line, errors.New("invalid JSON"), nil
+		}
+		return &event, s.line, nil, nil
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		return nil, s.line, nil, err
+	}
+	return nil, s.line, nil, io.EOF
+}
+
+type csvSource struct {
+	reader  *csv.Reader
+	columns []func(event *models.NavigationEvent, value string) error
+}
+
+// newCSVSource reads the header row, which must name only known columns and
+// include visitor_id and url.
+func newCSVSource(r io.Reader) (*csvSource, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("missing CSV header")
+	}
+
+	source := &csvSource{reader: reader}
+	seen := make(map[string]bool, len(header))
+	for _, name := range header {
+		name = strings.TrimSpace(name)
+		set, known := importCSVColumns[name]
+		if !known {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		seen[name] = true
+		source.columns = append(source.columns, set)
+	}
+	if !seen["visitor_id"] || !seen["url"] {
+		return nil, fmt.Errorf("CSV header must include visitor_id and url")
+	}
+
+	return source, nil
+}
+
+func (s *csvSource) next() (*models.NavigationEvent, int, error, error) {
+	record, err := s.reader.Read()
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, parseErr.Line, parseErr.Err, nil
+	}
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	line, _ := s.reader.FieldPos(0)
+
+	event := &models.NavigationEvent{}
+	for i, value := range record {
+		if err := s.columns[i](event, value); err != nil {
+			return nil, line, err, nil
+		}
+	}
+	return event, line, nil, nil
+}
+
+// importSummary accumulates an import's progress.
+type importSummary struct {
+	models.ImportProgress
+	started time.Time
+}
+
+func (s *importSummary) fail(line int, err error) {
+	s.Failed++
+
+	message := err.Error()
+	if _, counted := s.Errors[message]; !counted && len(s.Errors) >= maxImportErrorKinds {
+		message = importOtherErrors
+	}
+	s.Errors[message]++
+
+	if len(s.ErrorSamples) < maxImportErrorSamples {
+		s.ErrorSamples = append(s.ErrorSamples, models.ImportError{Line: line, Error: err.Error()})
+	}
+}
+
+func (s *importSummary) progress() *models.ImportProgress {
+	s.Elapsed = time.Since(s.started)
+	return &s.ImportProgress
+}
+
+// ImportHandler handles POST requests streaming NDJSON or CSV files of
+// historical events into the tracker, at most maxRate events per second
+func ImportHandler(tracker *storage.NavigationTracker, maxRate int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "ndjson"
+			if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+				format = "csv"
+			}
+		}
+
+		rate := maxRate
+		if param := r.URL.Query().Get("rate"); param != "" {
+			requested, err := strconv.Atoi(param)
+			if err != nil || requested < 1 {
+				respondWithError(w, http.StatusBadRequest, "Invalid rate: must be a positive integer")
+				return
+			}
+			if rate == 0 || requested < rate {
+				rate = requested
+			}
+		}
+
+		var source importSource
+		switch format {
+		case "ndjson":
+			source = newNDJSONSource(r.Body)
+		case "csv":
+			csvSource, err := newCSVSource(r.Body)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid CSV: "+err.Error())
+				return
+			}
+			source = csvSource
+		default:
+			respondWithError(w, http.StatusBadRequest, "Invalid format: must be ndjson or csv")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+
+		flusher, _ := w.(http.Flusher)
+		encoder := json.NewEncoder(w)
+		report := func(progress *models.ImportProgress) {
+			if err := encoder.Encode(progress); err != nil {
+				log.Printf("Error writing import progress: %v", err)
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+
+		summary := &importSummary{
+			ImportProgress: models.ImportProgress{Errors: make(map[string]int)},
+			started:        time.Now(),
+		}
+
+		var interval time.Duration
+		if rate > 0 {
+			interval = time.Second / time.Duration(rate)
+		}
+
+		for {
+			event, line, lineErr, err := source.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				// The summary is reported incomplete so the caller knows to resume
+				log.Printf("Import stopped after %d lines: %v", summary.Processed, err)
+				report(summary.progress())
+				return
+			}
+
+			summary.Processed++
+			if lineErr != nil {
+				summary.fail(line, lineErr)
+			} else if err := tracker.RecordEvent(event); err != nil {
+				summary.fail(line, err)
+			} else {
+				summary.Imported++
+			}
+
+			if summary.Processed%importProgressInterval == 0 {
+				report(summary.progress())
+			}
+
+			// Pace events to the rate from the start of the import so that a
+			// slow stretch is made up for rather than lost
+			if interval > 0 {
+				wait := time.Until(summary.started.Add(time.Duration(summary.Processed) * interval))
+				if wait > 0 {
+					select {
+					case <-time.After(wait):
+					case <-r.Context().Done():
+						log.Printf("Import cancelled after %d lines", summary.Processed)
+						return
+					}
+				}
+			}
+		}
+
+		summary.Complete = true
+		report(summary.progress())
+	}
+}
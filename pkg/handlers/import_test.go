@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// decodeImportReports parses every progress line of an import response.
+func decodeImportReports(t *testing.T, w *httptest.ResponseRecorder) []models.ImportProgress {
+	t.Helper()
+
+	var reports []models.ImportProgress
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var progress models.ImportProgress
+		if err := json.Unmarshal(scanner.Bytes(), &progress); err != nil {
+			t.Fatalf("Failed to unmarshal progress line %q: %v", scanner.Text(), err)
+		}
+		reports = append(reports, progress)
+	}
+	if len(reports) == 0 {
+		t.Fatal("Expected at least one progress report")
+	}
+	return reports
+}
+
+func TestImportHandler_NDJSON(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := ImportHandler(tracker, 0)
+
+	var body strings.Builder
+	for i := 0; i < importProgressInterval+1; i++ {
+		fmt.Fprintf(&body, `{"visitor_id":"visitor%d","url":"https://example.com/a","timestamp":"2023-06-01T12:00:00Z"}`+"\n", i%10)
+	}
+	body.WriteString("not json\n\n")
+	body.WriteString(`{"url":"https://example.com/a"}` + "\n")
+
+	req := httptest.NewRequest("POST", "/api/v1/import", strings.NewReader(body.String()))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	reports := decodeImportReports(t, w)
+	if len(reports) != 2 || reports[0].Complete || reports[0].Processed != importProgressInterval {
+		t.Fatalf("Expected one progress report and a summary, got %+v", reports)
+	}
+
+	summary := reports[1]
+	if !summary.Complete || summary.Processed != importProgressInterval+3 || summary.Imported != importProgressInterval+1 || summary.Failed != 2 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+	if summary.Errors["invalid JSON"] != 1 || summary.Errors["invalid event: visitor_id is required"] != 1 {
+		t.Errorf("Expected errors grouped by message, got %v", summary.Errors)
+	}
+	if len(summary.ErrorSamples) != 2 || summary.ErrorSamples[0].Line != importProgressInterval+2 || summary.ErrorSamples[1].Line != importProgressInterval+4 {
+		t.Errorf("Expected error samples with line numbers, got %+v", summary.ErrorSamples)
+	}
+
+	stats := tracker.GetVisitorStats("https://example.com/a")
+	if stats.DistinctVisitors != 10 || stats.TotalPageViews != importProgressInterval+1 {
+		t.Errorf("Expected 10 visitors and %d page views, got %d and %d", importProgressInterval+1, stats.DistinctVisitors, stats.TotalPageViews)
+	}
+}
+
+func TestImportHandler_CSV(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := ImportHandler(tracker, 0)
+
+	body := "visitor_id,url,timestamp\n" +
+		"visitor1,https://example.com/a,2023-06-01T12:00:00Z\n" +
+		"visitor2,https://example.com/a,yesterday\n" +
+		"visitor3,https://example.com/a\n" +
+		"visitor4,https://example.com/a,\n"
+
+	req := httptest.NewRequest("POST", "/api/v1/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/csv")
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	reports := decodeImportReports(t, w)
+	summary := reports[len(reports)-1]
+	if !summary.Complete || summary.Imported != 2 || summary.Failed != 2 {
+		t.Fatalf("Expected 2 imported and 2 failed, got %+v", summary)
+	}
+	if summary.ErrorSamples[0].Line != 3 || summary.ErrorSamples[0].Error != "invalid timestamp: must be RFC3339" {
+		t.Errorf("Expected a timestamp error on line 3, got %+v", summary.ErrorSamples[0])
+	}
+	if summary.ErrorSamples[1].Line != 4 {
+		t.Errorf("Expected a field count error on line 4, got %+v", summary.ErrorSamples[1])
+	}
+}
+
+func TestImportHandler_RateLimited(t *testing.T) {
+	handler := ImportHandler(storage.NewNavigationTracker(), 1000)
+
+	body := strings.Repeat(`{"visitor_id":"visitor1","url":"https://example.com/a"}`+"\n", 5)
+	req := httptest.NewRequest("POST", "/api/v1/import?rate=50", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	handler(w, req)
+
+	// Five events at 50 per second take at least 100ms
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected import to be paced to 50 events per second, took %v", elapsed)
+	}
+	if summary := decodeImportReports(t, w); summary[0].Imported != 5 {
+		t.Errorf("Expected 5 events imported, got %+v", summary[0])
+	}
+}
+
+func TestImportHandler_InvalidRequests(t *testing.T) {
+	handler := ImportHandler(storage.NewNavigationTracker(), 0)
+
+	tests := []struct {
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"GET", "/api/v1/import", "", http.StatusMethodNotAllowed},
+		{"POST", "/api/v1/import?format=xml", "", http.StatusBadRequest},
+		{"POST", "/api/v1/import?rate=0", "", http.StatusBadRequest},
+		{"POST", "/api/v1/import?format=csv", "", http.StatusBadRequest},
+		{"POST", "/api/v1/import?format=csv", "visitor_id,url,colour\n", http.StatusBadRequest},
+		{"POST", "/api/v1/import?format=csv", "visitor_id,timestamp\n", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.target, tt.status, w.Code)
+		}
+	}
+}
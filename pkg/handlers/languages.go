@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// LanguagesHandler handles GET requests to retrieve the per-language visitor
+// breakdown for a URL, or across URLs without one
+func LanguagesHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		urlParam := r.URL.Query().Get("url")
+		languages := tracker.GetLanguageBreakdown(models.NormalizeURL(urlParam))
+		respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+			"url":       urlParam,
+			"languages": languages,
+			"count":     len(languages),
+		})
+	}
+}
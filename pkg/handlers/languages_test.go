@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestLanguagesHandler_UsesAcceptLanguage(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	ingest := IngestHandler(tracker)
+	handler := LanguagesHandler(tracker)
+
+	for _, event := range []models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a"},
+		{VisitorID: "visitor2", URL: "https://example.com/a", Language: "es"},
+	} {
+		jsonData, _ := json.Marshal(event)
+		req := httptest.NewRequest("POST", "/ingest", bytes.NewBuffer(jsonData))
+		req.Header.Set("Accept-Language", "fr-ch, fr;q=0.9, en;q=0.8")
+		w := httptest.NewRecorder()
+		ingest(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+	}
+
+	for _, url := range []string{"/api/v1/languages?url=https://EXAMPLE.com/a/", "/api/v1/languages"} {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Languages []models.LanguageStats `json:"languages"`
+			Count     int                    `json:"count"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		if response.Count != 2 || response.Languages[0].Language != "es" || response.Languages[1].Language != "fr-CH" {
+			t.Errorf("Unexpected language breakdown for %s: %+v", url, response.Languages)
+		}
+	}
+}
+
+func TestLanguagesHandler_MethodNotAllowed(t *testing.T) {
+	handler := LanguagesHandler(storage.NewNavigationTracker())
+
+	req := httptest.NewRequest("POST", "/api/v1/languages", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
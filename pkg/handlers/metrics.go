@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/monitoring"
+)
+
+// MetricsHandler handles GET requests to retrieve request metrics per route
+func MetricsHandler(collector *monitoring.MetricsCollector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		respondWithJSON(w, r, http.StatusOK, collector.GetMetrics())
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/monitoring"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	collector := monitoring.NewMetricsCollector()
+	collector.RecordRequest("/stats", 10*time.Millisecond, http.StatusOK)
+	handler := MetricsHandler(collector)
+
+	req := httptest.NewRequest("GET", "/api/v1/metrics", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var metrics monitoring.PerformanceMetrics
+	if err := json.Unmarshal(w.Body.Bytes(), &metrics); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if metrics.TotalRequests != 1 {
+		t.Errorf("Expected 1 request, got %d", metrics.TotalRequests)
+	}
+}
@@ -2,42 +2,141 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"nav-tracker/pkg/format"
 	"nav-tracker/pkg/models"
 	"nav-tracker/pkg/storage"
 )
 
+const (
+	defaultVisitorPageSize = 100
+	maxVisitorPageSize     = 1000
+)
+
+// DegradedHeader is set to DegradedBackendUnavailable on the 202 answering
+// an event the counter backend did not record. The event was still counted
+// in memory and passed on to listeners, so it must not be retried.
+const DegradedHeader = "X-Degraded"
+
+// DegradedBackendUnavailable is the DegradedHeader value and the code of
+// responses to events the counter backend did not record.
+const DegradedBackendUnavailable = "BACKEND_UNAVAILABLE"
+
+// ErrorCodeTimestampOutOfWindow is the error code of responses to events
+// refused because their timestamps are outside the acceptance window.
+const ErrorCodeTimestampOutOfWindow = "TIMESTAMP_OUT_OF_WINDOW"
+
+// EventEnricher annotates an ingested event with request-derived fields before it is recorded
+type EventEnricher func(r *http.Request, event *models.NavigationEvent)
+
 // IngestHandler handles POST requests to record navigation events
-func IngestHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+func IngestHandler(tracker *storage.NavigationTracker, enrichers ...EventEnricher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
 			return
 		}
 
-		var event models.NavigationEvent
-		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+		event, err := decodeIngestEvent(r, enrichers)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, decodeErrorMessage(err))
 			return
 		}
 
-		if err := tracker.RecordEvent(&event); err != nil {
+		degraded, err := tracker.RecordEventStatus(&event)
+		if err != nil {
+			if errors.Is(err, storage.ErrURLFrozen) {
+				respondWithError(w, http.StatusConflict, "URL is frozen")
+				return
+			}
+			if errors.Is(err, storage.ErrVisitorCapReached) {
+				respondWithError(w, http.StatusConflict, "URL has reached its visitor cap")
+				return
+			}
+			if errors.Is(err, storage.ErrDenied) {
+				respondWithError(w, http.StatusForbidden, "Event is denylisted")
+				return
+			}
+			if errors.Is(err, storage.ErrTimestampOutOfWindow) {
+				respondWithTimestampOutOfWindow(w, err)
+				return
+			}
+			if errors.Is(err, storage.ErrMemoryPressure) {
+				respondMemoryPressure(w, tracker.Backpressure())
+				return
+			}
+			var violation *storage.SchemaViolationError
+			if errors.As(err, &violation) {
+				respondWithSchemaViolation(w, violation)
+				return
+			}
 			log.Printf("Error recording event: %v", err)
 			respondWithError(w, http.StatusInternalServerError, "Failed to record event")
 			return
 		}
 
+		if degraded {
+			w.Header().Set(DegradedHeader, DegradedBackendUnavailable)
+			respondWithJSON(w, r, http.StatusAccepted, map[string]interface{}{
+				"success":  true,
+				"message":  "Event recorded; storage backend unavailable",
+				"degraded": true,
+				"code":     DegradedBackendUnavailable,
+			})
+			return
+		}
+
 		response := map[string]interface{}{
 			"success": true,
 			"message": "Event recorded successfully",
 		}
 
-		respondWithJSON(w, http.StatusCreated, response)
+		respondWithJSON(w, r, http.StatusCreated, response)
 	}
 }
 
+// decodeIngestEvent reads the event in an ingest request body, in any
+// supported schema version, and applies enrichers.
+func decodeIngestEvent(r *http.Request, enrichers []EventEnricher) (models.NavigationEvent, error) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return models.NavigationEvent{}, err
+	}
+	event, err := models.DecodeEvent(data)
+	if err != nil {
+		return event, err
+	}
+
+	if event.UserAgent == "" {
+		event.UserAgent = r.UserAgent()
+	}
+	if event.Language == "" {
+		event.Language = models.PrimaryLanguage(r.Header.Get("Accept-Language"))
+	}
+
+	for _, enrich := range enrichers {
+		enrich(r, &event)
+	}
+
+	return event, nil
+}
+
+// decodeErrorMessage describes why an ingest request body could not be decoded.
+func decodeErrorMessage(err error) string {
+	var schemaErr *models.SchemaError
+	if errors.As(err, &schemaErr) {
+		return schemaErr.Error()
+	}
+	return "Invalid JSON format"
+}
+
 // StatsHandler handles GET requests to retrieve visitor statistics for a URL
 func StatsHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -47,24 +146,185 @@ func StatsHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
 		}
 
 		urlParam := r.URL.Query().Get("url")
+		if pattern := r.URL.Query().Get("pattern"); pattern != "" && urlParam == "" {
+			stats, exists := tracker.GetPatternStats(pattern)
+			if !exists {
+				respondWithError(w, http.StatusNotFound, "Pattern not found")
+				return
+			}
+			respondWithJSON(w, r, http.StatusOK, stats)
+			return
+		}
 		if urlParam == "" {
 			respondWithError(w, http.StatusBadRequest, "Missing required query parameter: url")
 			return
 		}
 
+		if raw := r.URL.Query().Get("segment"); raw != "" {
+			if r.URL.Query().Get("detailed") == "true" || r.URL.Query().Get("dimension") != "" {
+				respondWithError(w, http.StatusBadRequest, "segment cannot be combined with detailed or dimension")
+				return
+			}
+			segment, err := storage.ParseSegment(raw)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid segment: "+err.Error())
+				return
+			}
+			respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+				"url":               urlParam,
+				"segment":           segment.String(),
+				"distinct_visitors": tracker.GetSegmentVisitors(models.NormalizeURL(urlParam), segment),
+			})
+			return
+		}
+
+		var dimension map[string]interface{}
+		if name := r.URL.Query().Get("dimension"); name != "" {
+			count, approximate, ok := tracker.GetDistinctDimension(urlParam, name)
+			if !ok {
+				respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Unknown dimension: %s", name))
+				return
+			}
+			dimension = map[string]interface{}{
+				"dimension":       name,
+				"distinct_values": count,
+				"approximate":     approximate,
+			}
+		}
+
+		if r.URL.Query().Get("detailed") == "true" {
+			respondWithDetailedStats(w, r, tracker, urlParam, dimension)
+			return
+		}
+
 		distinctVisitors := tracker.GetDistinctVisitors(urlParam)
 
 		response := map[string]interface{}{
 			"url":               urlParam,
 			"distinct_visitors": distinctVisitors,
 		}
+		for key, value := range dimension {
+			response[key] = value
+		}
+
+		respondWithJSON(w, r, http.StatusOK, response)
+	}
+}
+
+// respondWithDetailedStats writes URL stats plus one bounded page of visitors.
+// The cursor is the offset of the next page; limit is capped at maxVisitorPageSize.
+// Fields in extra are added to the response.
+func respondWithDetailedStats(w http.ResponseWriter, r *http.Request, tracker *storage.NavigationTracker, url string, extra map[string]interface{}) {
+	opts, message := parseListOptions(r, defaultVisitorPageSize, maxVisitorPageSize, storage.SortByLastVisit, storage.SortByVisitCount)
+	if message != "" {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+	offset, limit := opts.Offset, opts.Limit
+
+	stats := tracker.GetVisitorStats(url)
+	trace := storage.QueryTraceFromContext(r.Context())
+	visitors, total := tracker.GetVisitors(url, opts, trace)
+
+	response := map[string]interface{}{
+		"url":               url,
+		"distinct_visitors": stats.DistinctVisitors,
+		"total_page_views":  stats.TotalPageViews,
+		"devices":           tracker.GetDeviceBreakdown(url),
+		"visitors":          visitors,
+		"limit":             limit,
+		"truncated":         offset+len(visitors) < total,
+	}
+	if status := tracker.VisitorCapStatus(url); status != nil {
+		response["visitor_cap"] = status
+	}
+
+	if offset+len(visitors) < total {
+		response["next_cursor"] = strconv.Itoa(offset + len(visitors))
+	}
+	for key, value := range extra {
+		response[key] = value
+	}
+
+	respondWithJSON(w, r, http.StatusOK, response)
+}
+
+// parseLimitParam reads the limit query parameter, defaulting to def and capping at max
+func parseLimitParam(r *http.Request, def, max int) (int, error) {
+	limitParam := r.URL.Query().Get("limit")
+	if limitParam == "" {
+		return def, nil
+	}
+
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("invalid limit %q", limitParam)
+	}
+
+	if limit > max {
+		limit = max
+	}
+
+	return limit, nil
+}
+
+// parseListOptions reads limit, cursor (or offset), sort and order. The first
+// of sorts is the default. It returns an error message for invalid values.
+func parseListOptions(r *http.Request, defLimit, maxLimit int, sorts ...string) (storage.ListOptions, string) {
+	query := r.URL.Query()
+
+	limit, err := parseLimitParam(r, defLimit, maxLimit)
+	if err != nil {
+		return storage.ListOptions{}, "Invalid limit: must be a positive integer"
+	}
+	opts := storage.ListOptions{Limit: limit, Sort: sorts[0]}
+
+	cursor := query.Get("cursor")
+	if cursor == "" {
+		cursor = query.Get("offset")
+	}
+	if cursor != "" {
+		parsed, err := strconv.Atoi(cursor)
+		if err != nil || parsed < 0 {
+			return storage.ListOptions{}, "Invalid cursor"
+		}
+		opts.Offset = parsed
+	}
+
+	if sortBy := query.Get("sort"); sortBy != "" {
+		valid := false
+		for _, s := range sorts {
+			valid = valid || s == sortBy
+		}
+		if !valid {
+			return storage.ListOptions{}, fmt.Sprintf("Invalid sort: must be one of %s", strings.Join(sorts, ", "))
+		}
+		opts.Sort = sortBy
+	}
 
-		respondWithJSON(w, http.StatusOK, response)
+	switch query.Get("order") {
+	case "", "desc":
+	case "asc":
+		opts.Ascending = true
+	default:
+		return storage.ListOptions{}, "Invalid order: must be asc or desc"
 	}
+
+	return opts, ""
 }
 
 // Helper functions for JSON responses
-func respondWithJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+
+// respondWithJSON writes data in the response format negotiated for r.
+func respondWithJSON(w http.ResponseWriter, r *http.Request, statusCode int, data interface{}) {
+	if f := format.FromContext(r.Context()); !f.IsDefault() {
+		data = format.Apply(data, f)
+	}
+
+	writeJSON(w, statusCode, data)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 
@@ -74,9 +334,14 @@ func respondWithJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 }
 
 func respondWithError(w http.ResponseWriter, statusCode int, message string) {
-	errorResponse := map[string]interface{}{
-		"error": message,
-	}
+	writeJSON(w, statusCode, models.ErrorResponse{Error: message})
+}
 
-	respondWithJSON(w, statusCode, errorResponse)
+// respondWithTimestampOutOfWindow answers an event refused because its
+// timestamp is too far from the server's clock.
+func respondWithTimestampOutOfWindow(w http.ResponseWriter, err error) {
+	writeJSON(w, http.StatusBadRequest, models.ErrorResponse{
+		Error: err.Error(),
+		Code:  ErrorCodeTimestampOutOfWindow,
+	})
 }
@@ -3,9 +3,13 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"nav-tracker/pkg/models"
 	"nav-tracker/pkg/storage"
@@ -70,6 +74,62 @@ func TestIngestHandler_InvalidJSON(t *testing.T) {
 	}
 }
 
+// testSchemaVersion is a schema registered only in tests, in which url was
+// called page and the session ID is required.
+const testSchemaVersion = 99
+
+func init() {
+	models.RegisterEventSchema(models.EventSchema{
+		Version:  testSchemaVersion,
+		Renames:  map[string]string{"page": "url"},
+		Required: []string{"page", "session_id"},
+	})
+}
+
+func TestIngestHandler_SchemaVersions(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := IngestHandler(tracker)
+
+	tests := []struct {
+		body    string
+		status  int
+		message string
+	}{
+		{`{"visitor_id": "visitor1", "url": "https://example.com/v0"}`, http.StatusCreated, ""},
+		{`{"schema_version": 1, "visitor_id": "visitor1", "url": "https://example.com/v1"}`, http.StatusCreated, ""},
+		{`{"schema_version": 99, "visitor_id": "visitor1", "page": "https://example.com/v99", "session_id": "s1"}`, http.StatusCreated, ""},
+		{`{"schema_version": 99, "visitor_id": "visitor1", "page": "https://example.com/v99"}`, http.StatusBadRequest, "schema_version 99: session_id is required"},
+		{`{"schema_version": 7, "visitor_id": "visitor1", "url": "https://example.com/v7"}`, http.StatusBadRequest, "schema_version 7: unsupported version; supported versions are [1 99]"},
+		{`{"schema_version": "1", "visitor_id": "visitor1", "url": "https://example.com/"}`, http.StatusBadRequest, "schema_version must be an integer"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("POST", "/ingest", bytes.NewBufferString(tt.body)))
+		if w.Code != tt.status {
+			t.Errorf("Expected status %d for %s, got %d: %s", tt.status, tt.body, w.Code, w.Body.String())
+			continue
+		}
+		var response models.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response.Error != tt.message {
+			t.Errorf("Expected error %q for %s, got %q", tt.message, tt.body, response.Error)
+		}
+	}
+
+	for _, url := range []string{"https://example.com/v0", "https://example.com/v1", "https://example.com/v99"} {
+		if tracker.GetDistinctVisitors(url) != 1 {
+			t.Errorf("Expected the event for %s to be recorded", url)
+		}
+	}
+
+	// Events built in code must still name a known version
+	if err := tracker.RecordEvent(&models.NavigationEvent{SchemaVersion: 7, VisitorID: "visitor1", URL: "https://example.com/"}); err == nil {
+		t.Error("Expected an unknown schema version to fail validation")
+	}
+}
+
 func TestIngestHandler_ValidationError(t *testing.T) {
 	tracker := storage.NewNavigationTracker()
 	handler := IngestHandler(tracker)
@@ -274,3 +334,280 @@ func TestConcurrentAccess(t *testing.T) {
 		t.Errorf("Expected 10 distinct visitors, got %d", count)
 	}
 }
+
+func TestStatsHandler_DetailedPagination(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := StatsHandler(tracker)
+
+	for i := 0; i < 5; i++ {
+		err := tracker.RecordEvent(&models.NavigationEvent{
+			VisitorID: "visitor" + string(rune('0'+i)),
+			URL:       "https://example.com/page1",
+		})
+		if err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	cursor := ""
+	seen := 0
+	for page := 0; page < 3; page++ {
+		target := "/stats?url=https://example.com/page1&detailed=true&limit=2&sort=visit_count"
+		if cursor != "" {
+			target += "&cursor=" + cursor
+		}
+
+		req := httptest.NewRequest("GET", target, nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Visitors   []models.VisitorDetail `json:"visitors"`
+			Truncated  bool                   `json:"truncated"`
+			NextCursor string                 `json:"next_cursor"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		seen += len(response.Visitors)
+		if page < 2 && (!response.Truncated || response.NextCursor == "") {
+			t.Errorf("Expected truncated page %d with next cursor", page)
+		}
+		if page == 2 && response.Truncated {
+			t.Errorf("Expected last page not to be truncated")
+		}
+		cursor = response.NextCursor
+	}
+
+	if seen != 5 {
+		t.Errorf("Expected to page through 5 visitors, got %d", seen)
+	}
+}
+
+func TestStatsHandler_DetailedLimitCap(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := StatsHandler(tracker)
+
+	req := httptest.NewRequest("GET", "/stats?url=https://example.com/page1&detailed=true&limit=1000000", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if limit, ok := response["limit"].(float64); !ok || int(limit) != maxVisitorPageSize {
+		t.Errorf("Expected limit capped at %d, got %v", maxVisitorPageSize, response["limit"])
+	}
+
+	for _, target := range []string{
+		"/stats?url=https://example.com/page1&detailed=true&limit=0",
+		"/stats?url=https://example.com/page1&detailed=true&cursor=-1",
+		"/stats?url=https://example.com/page1&detailed=true&sort=name",
+	} {
+		req := httptest.NewRequest("GET", target, nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, target, w.Code)
+		}
+	}
+}
+
+func TestStatsHandler_Dimension(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Dimensions = []models.DimensionConfig{{Name: "account_id", Mode: models.DimensionModeExact}}
+	tracker := storage.NewNavigationTrackerWithConfig(config)
+	handler := StatsHandler(tracker)
+
+	for i, account := range []string{"acme", "acme", "globex"} {
+		err := tracker.RecordEvent(&models.NavigationEvent{
+			VisitorID:  fmt.Sprintf("visitor%d", i),
+			URL:        "https://example.com/page1",
+			Properties: map[string]string{"account_id": account},
+		})
+		if err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/stats?url=https://example.com/page1&dimension=account_id", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response["distinct_visitors"] != float64(3) || response["distinct_values"] != float64(2) || response["dimension"] != "account_id" {
+		t.Errorf("Expected 3 visitors and 2 accounts, got %v", response)
+	}
+
+	req = httptest.NewRequest("GET", "/stats?url=https://example.com/page1&dimension=plan", nil)
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for unknown dimension, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestStatsHandler_Segment(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := StatsHandler(tracker)
+
+	for i, plan := range []string{"pro", "pro", "free"} {
+		err := tracker.RecordEvent(&models.NavigationEvent{
+			VisitorID:  fmt.Sprintf("visitor%d", i),
+			URL:        "https://example.com/page1",
+			Properties: map[string]string{"plan": plan},
+		})
+		if err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/stats?url=HTTPS://Example.com/Page1/%23top&segment=plan:pro", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response["distinct_visitors"] != float64(2) || response["segment"] != "plan:pro" {
+		t.Errorf("Expected 2 pro visitors, got %v", response)
+	}
+
+	for _, query := range []string{"segment=plan", "segment=plan:pro&detailed=true"} {
+		req = httptest.NewRequest("GET", "/stats?url=https://example.com/page1&"+query, nil)
+		w = httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+}
+
+func TestIngestHandler_VisitorCap(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.VisitorCap = models.VisitorCapConfig{MaxVisitorsPerURL: 1, Overflow: models.VisitorCapReject}
+	tracker := storage.NewNavigationTrackerWithConfig(config)
+	ingest := IngestHandler(tracker)
+
+	for i, expected := range []int{http.StatusCreated, http.StatusConflict} {
+		body := fmt.Sprintf(`{"visitor_id": "visitor%d", "url": "https://example.com"}`, i)
+		w := httptest.NewRecorder()
+		ingest(w, httptest.NewRequest("POST", "/ingest", bytes.NewBufferString(body)))
+		if w.Code != expected {
+			t.Errorf("Visitor %d: expected status %d, got %d", i, expected, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	StatsHandler(tracker)(w, httptest.NewRequest("GET", "/stats?url=https://example.com&detailed=true", nil))
+	var response struct {
+		VisitorCap *models.VisitorCapStatus `json:"visitor_cap"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.VisitorCap == nil || !response.VisitorCap.Reached || response.VisitorCap.MaxVisitors != 1 {
+		t.Errorf("Expected the cap to be reported as reached, got %+v", response.VisitorCap)
+	}
+}
+
+// downBackend is a counter backend that always fails.
+type downBackend struct{}
+
+func (downBackend) Record(event *models.NavigationEvent) error { return errors.New("backend down") }
+func (downBackend) DistinctVisitors(url string) (int, error)   { return 0, errors.New("backend down") }
+func (downBackend) PageViews(url string) (int64, error)        { return 0, errors.New("backend down") }
+func (downBackend) ForgetVisitor(visitorID string) error       { return errors.New("backend down") }
+func (downBackend) DeleteURL(url string) error                 { return errors.New("backend down") }
+func (downBackend) MergeURL(source, target string) error       { return errors.New("backend down") }
+
+func TestIngestHandler_CircuitOpen(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	tracker.SetCounterBackend(storage.NewCircuitBreaker(downBackend{}, models.CircuitBreakerConfig{Enabled: true, FailureThreshold: 1, Cooldown: time.Minute}))
+	ingest := IngestHandler(tracker)
+
+	notified := 0
+	tracker.AddListener(func(event models.NavigationEvent) { notified++ })
+
+	// The backend fails the first event and the open breaker refuses the
+	// second; both are counted in memory, so neither is an error to retry
+	for i := 0; i < 2; i++ {
+		body := fmt.Sprintf(`{"visitor_id": "visitor%d", "url": "https://example.com"}`, i)
+		w := httptest.NewRecorder()
+		ingest(w, httptest.NewRequest("POST", "/ingest", bytes.NewBufferString(body)))
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Event %d: expected status %d, got %d", i, http.StatusAccepted, w.Code)
+		}
+		if w.Header().Get(DegradedHeader) != DegradedBackendUnavailable {
+			t.Errorf("Event %d: expected the %s header, got %q", i, DegradedHeader, w.Header().Get(DegradedHeader))
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response["degraded"] != true || response["success"] != true {
+			t.Errorf("Event %d: expected a degraded success, got %s", i, w.Body.String())
+		}
+	}
+	if notified != 2 || tracker.GetDistinctVisitors("https://example.com") != 2 {
+		t.Errorf("Expected both events counted and passed to listeners, got %d notified", notified)
+	}
+
+	pixel := PixelHandler(tracker)
+	w := httptest.NewRecorder()
+	pixel(w, httptest.NewRequest("GET", "/pixel?visitor_id=visitor3&url=https://example.com", nil))
+	if w.Code != http.StatusAccepted || w.Header().Get(DegradedHeader) != DegradedBackendUnavailable || w.Header().Get("Content-Type") != "image/gif" {
+		t.Errorf("Expected a degraded pixel, got %d with %v", w.Code, w.Header())
+	}
+}
+
+func TestIngestHandler_TimestampOutOfWindow(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Timestamps = models.TimestampConfig{MaxPast: time.Hour, Policy: models.TimestampReject}
+	ingest := IngestHandler(storage.NewNavigationTrackerWithConfig(config))
+
+	body := fmt.Sprintf(`{"visitor_id": "visitor1", "url": "https://example.com", "timestamp": %q}`, time.Now().Add(-2*time.Hour).Format(time.RFC3339))
+	w := httptest.NewRecorder()
+	ingest(w, httptest.NewRequest("POST", "/ingest", bytes.NewBufferString(body)))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), ErrorCodeTimestampOutOfWindow) {
+		t.Errorf("Expected 400 with the %s code, got %d: %s", ErrorCodeTimestampOutOfWindow, w.Code, w.Body.String())
+	}
+}
+
+func TestIngestHandler_MemoryBackpressure(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Eviction = models.EvictionConfig{MaxBytes: 1, Mode: models.EvictionModeBackpressure, HighWatermark: 1, LowWatermark: 0.5, RetryAfter: 1500 * time.Millisecond}
+	tracker := storage.NewNavigationTrackerWithConfig(config)
+	ingest := IngestHandler(tracker)
+
+	for i, expected := range []int{http.StatusCreated, http.StatusServiceUnavailable} {
+		body := fmt.Sprintf(`{"visitor_id": "visitor%d", "url": "https://example.com"}`, i)
+		w := httptest.NewRecorder()
+		ingest(w, httptest.NewRequest("POST", "/ingest", bytes.NewBufferString(body)))
+		if w.Code != expected {
+			t.Fatalf("Event %d: expected status %d, got %d", i, expected, w.Code)
+		}
+		if expected == http.StatusServiceUnavailable && (w.Header().Get("Retry-After") != "2" || !strings.Contains(w.Body.String(), ErrorCodeMemoryPressure)) {
+			t.Errorf("Expected a 2s Retry-After and the %s code, got %q: %s", ErrorCodeMemoryPressure, w.Header().Get("Retry-After"), w.Body.String())
+		}
+	}
+
+	// Queued and imported events are refused before they are accepted
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusAccepted) })
+	w := httptest.NewRecorder()
+	BackpressureHandler(tracker, next)(w, httptest.NewRequest("POST", "/ingest", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 from the backpressure handler, got %d", w.Code)
+	}
+}
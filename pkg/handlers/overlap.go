@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// OverlapHandler handles GET requests counting the visitors two URLs share
+func OverlapHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		a, b := r.URL.Query().Get("url_a"), r.URL.Query().Get("url_b")
+		if a == "" || b == "" {
+			respondWithError(w, http.StatusBadRequest, "Missing required query parameters: url_a and url_b")
+			return
+		}
+		respondWithJSON(w, r, http.StatusOK, tracker.Overlap(models.NormalizeURL(a), models.NormalizeURL(b)))
+	}
+}
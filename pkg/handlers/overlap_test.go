@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestOverlapHandler(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := OverlapHandler(tracker)
+
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a"},
+		{VisitorID: "visitor2", URL: "https://example.com/a"},
+		{VisitorID: "visitor2", URL: "https://example.com/b"},
+		{VisitorID: "visitor3", URL: "https://example.com/b"},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/v1/overlap?url_a=https://EXAMPLE.com/a&url_b=https://example.com/b", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var overlap models.URLOverlap
+	if err := json.Unmarshal(w.Body.Bytes(), &overlap); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if overlap.URLA != "https://example.com/a" || overlap.Both != 1 || overlap.OnlyA != 1 || overlap.OnlyB != 1 {
+		t.Errorf("Expected 1 shared visitor and 1 on each URL only, got %+v", overlap)
+	}
+
+	for _, target := range []string{"/api/v1/overlap?url_a=https://example.com/a", "/api/v1/overlap"} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", target, http.StatusBadRequest, w.Code)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/api/v1/overlap", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+const (
+	defaultPathLimit = 10
+	maxPathLimit     = 100
+)
+
+// PathsHandler handles GET requests for the most common next and previous pages of a URL
+func PathsHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		url := r.URL.Query().Get("url")
+		if url == "" {
+			respondWithError(w, http.StatusBadRequest, "Missing required query parameter: url")
+			return
+		}
+
+		limit, err := parseLimitParam(r, defaultPathLimit, maxPathLimit)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit: must be a positive integer")
+			return
+		}
+
+		respondWithJSON(w, r, http.StatusOK, tracker.GetPaths(models.NormalizeURL(url), limit))
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestPathsHandler_Success(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := PathsHandler(tracker)
+	base := time.Now().Add(-time.Hour)
+
+	for i, url := range []string{"https://example.com/a", "https://example.com/b", "https://example.com/a", "https://example.com/c"} {
+		event := &models.NavigationEvent{VisitorID: "visitor1", URL: url, Timestamp: base.Add(time.Duration(i) * time.Minute)}
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/paths?url=https://EXAMPLE.com/a", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var report models.PathReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if report.TotalNext != 2 || len(report.Next) != 2 || report.Next[0].Probability != 0.5 {
+		t.Errorf("Expected two equally likely next pages, got %+v", report.Next)
+	}
+	if report.TotalPrevious != 1 || report.Previous[0].URL != "https://example.com/b" {
+		t.Errorf("Expected /b as the only previous page, got %+v", report.Previous)
+	}
+}
+
+func TestPathsHandler_InvalidRequests(t *testing.T) {
+	handler := PathsHandler(storage.NewNavigationTracker())
+
+	tests := []struct {
+		method string
+		target string
+		status int
+	}{
+		{"GET", "/api/v1/paths", http.StatusBadRequest},
+		{"GET", "/api/v1/paths?url=https://example.com/a&limit=0", http.StatusBadRequest},
+		{"POST", "/api/v1/paths?url=https://example.com/a", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.target, tt.status, w.Code)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"nav-tracker/pkg/storage"
+)
+
+type registerPatternRequest struct {
+	Pattern string `json:"pattern"`
+}
+
+// PatternsHandler handles GET requests to list URL patterns with their stats and POST requests to register one
+func PatternsHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			patterns := tracker.ListPatterns()
+			respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+				"patterns": patterns,
+				"count":    len(patterns),
+			})
+		case http.MethodPost:
+			var req registerPatternRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+				return
+			}
+
+			stats, err := tracker.RegisterPattern(req.Pattern)
+			switch {
+			case errors.Is(err, storage.ErrPatternExists):
+				respondWithError(w, http.StatusConflict, err.Error())
+			case err != nil:
+				respondWithError(w, http.StatusBadRequest, err.Error())
+			default:
+				respondWithJSON(w, r, http.StatusCreated, stats)
+			}
+		default:
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
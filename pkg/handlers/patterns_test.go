@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestPatternsHandler(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := PatternsHandler(tracker)
+
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/product/1"}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"pattern": "/product/*"}`, http.StatusCreated},
+		{`{"pattern": "/product/*"}`, http.StatusConflict},
+		{`{"pattern": ""}`, http.StatusBadRequest},
+		{`not json`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("POST", "/api/v1/patterns", strings.NewReader(tt.body)))
+		if w.Code != tt.status {
+			t.Errorf("Expected status %d for %s, got %d", tt.status, tt.body, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/v1/patterns", nil))
+	var response struct {
+		Patterns []models.PatternStats `json:"patterns"`
+		Count    int                   `json:"count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Count != 1 || response.Patterns[0].DistinctVisitors != 1 {
+		t.Errorf("Unexpected pattern list: %+v", response)
+	}
+}
+
+func TestStatsHandler_Pattern(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := StatsHandler(tracker)
+
+	if _, err := tracker.RegisterPattern("/product/*"); err != nil {
+		t.Fatalf("Failed to register pattern: %v", err)
+	}
+	for _, url := range []string{"https://example.com/product/1", "https://example.com/product/2"} {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/stats?pattern=/product/*", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var stats models.PatternStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if stats.DistinctVisitors != 1 || stats.TotalPageViews != 2 || stats.URLCount != 2 {
+		t.Errorf("Unexpected pattern stats: %+v", stats)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/stats?pattern=/missing/*", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
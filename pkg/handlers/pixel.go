@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// transparentGIF is a 1x1 transparent GIF89a image.
+var transparentGIF = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// PixelHandler handles GET requests that record an event from query parameters
+// and return a tracking pixel, for clients that cannot POST JSON
+func PixelHandler(tracker *storage.NavigationTracker, enrichers ...EventEnricher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		event, err := pixelEvent(r, tracker, enrichers)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		degraded, err := tracker.RecordEventStatus(&event)
+		if err != nil {
+			if errors.Is(err, storage.ErrURLFrozen) {
+				respondWithError(w, http.StatusConflict, "URL is frozen")
+				return
+			}
+			if errors.Is(err, storage.ErrVisitorCapReached) {
+				respondWithError(w, http.StatusConflict, "URL has reached its visitor cap")
+				return
+			}
+			if errors.Is(err, storage.ErrDenied) {
+				respondWithError(w, http.StatusForbidden, "Event is denylisted")
+				return
+			}
+			if errors.Is(err, storage.ErrTimestampOutOfWindow) {
+				respondWithTimestampOutOfWindow(w, err)
+				return
+			}
+			if errors.Is(err, storage.ErrMemoryPressure) {
+				respondMemoryPressure(w, tracker.Backpressure())
+				return
+			}
+			log.Printf("Error recording event: %v", err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to record event")
+			return
+		}
+
+		status := http.StatusOK
+		if degraded {
+			w.Header().Set(DegradedHeader, DegradedBackendUnavailable)
+			status = http.StatusAccepted
+		}
+		writePixel(w, status)
+	}
+}
+
+// pixelEvent builds and validates the event described by a pixel request's
+// query parameters, then applies enrichers.
+func pixelEvent(r *http.Request, tracker *storage.NavigationTracker, enrichers []EventEnricher) (models.NavigationEvent, error) {
+	query := r.URL.Query()
+	event := models.NavigationEvent{
+		EventID:   query.Get("event_id"),
+		VisitorID: query.Get("visitor_id"),
+		URL:       query.Get("url"),
+		SessionID: query.Get("session_id"),
+		Referrer:  query.Get("referrer"),
+		UserAgent: r.UserAgent(),
+		Language:  models.PrimaryLanguage(r.Header.Get("Accept-Language")),
+	}
+	if event.Referrer == "" {
+		event.Referrer = r.Referer()
+	}
+
+	if err := tracker.ValidateEvent(&event); err != nil {
+		return event, err
+	}
+
+	for _, enrich := range enrichers {
+		enrich(r, &event)
+	}
+
+	return event, nil
+}
+
+func writePixel(w http.ResponseWriter, status int) {
+	// Every load must reach the server, so nothing may cache the pixel.
+	w.Header().Set("Content-Type", "image/gif")
+	w.Header().Set("Content-Length", strconv.Itoa(len(transparentGIF)))
+	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.WriteHeader(status)
+	_, _ = w.Write(transparentGIF)
+}
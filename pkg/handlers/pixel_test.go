@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"bytes"
+	"image/gif"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/storage"
+)
+
+func TestPixelHandler_Success(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := PixelHandler(tracker)
+
+	req := httptest.NewRequest("GET", "/api/v1/pixel?visitor_id=visitor1&url=https://Example.com/newsletter", nil)
+	req.Header.Set("Referer", "https://mail.example.org/")
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/gif" {
+		t.Errorf("Expected Content-Type image/gif, got %s", ct)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc == "" {
+		t.Errorf("Expected a Cache-Control header")
+	}
+
+	img, err := gif.Decode(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to decode GIF: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 1 || bounds.Dy() != 1 {
+		t.Errorf("Expected a 1x1 image, got %v", bounds)
+	}
+
+	if visitors := tracker.GetDistinctVisitors("https://example.com/newsletter"); visitors != 1 {
+		t.Errorf("Expected the normalized URL to have 1 visitor, got %d", visitors)
+	}
+}
+
+func TestPixelHandler_MissingParams(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := PixelHandler(tracker)
+
+	req := httptest.NewRequest("GET", "/api/v1/pixel?url=https://example.com/", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestPixelHandler_MethodNotAllowed(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := PixelHandler(tracker)
+
+	req := httptest.NewRequest("POST", "/api/v1/pixel", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// LivezHandler handles GET requests for the liveness probe. It only shows that
+// the process is serving requests, so it never checks dependencies.
+func LivezHandler(started time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+			"status":     "ok",
+			"started_at": started,
+			"uptime":     time.Since(started),
+		})
+	}
+}
+
+// ReadyzHandler handles GET requests for the readiness probe, returning 503
+// unless every dependency check passes
+func ReadyzHandler(checks func() []models.CheckResult) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		results := checks()
+		ready := true
+		for _, result := range results {
+			if result.Status != models.CheckPass {
+				ready = false
+				break
+			}
+		}
+
+		statusCode := http.StatusOK
+		if !ready {
+			statusCode = http.StatusServiceUnavailable
+		}
+
+		respondWithJSON(w, r, statusCode, map[string]interface{}{
+			"ready":  ready,
+			"checks": results,
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestLivezHandler(t *testing.T) {
+	handler := LivezHandler(time.Now().Add(-time.Minute))
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/livez", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Status string        `json:"status"`
+		Uptime time.Duration `json:"uptime"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Status != "ok" || response.Uptime < time.Minute {
+		t.Errorf("Expected ok with at least a minute of uptime, got %+v", response)
+	}
+}
+
+func TestReadyzHandler(t *testing.T) {
+	results := []models.CheckResult{{Name: "redis", Status: models.CheckPass}}
+	handler := ReadyzHandler(func() []models.CheckResult { return results })
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	results = append(results, models.CheckResult{Name: "memory", Status: models.CheckFail, Error: "no headroom"})
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/readyz", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+const schemasPath = "/api/v1/schemas/"
+
+// ErrorCodeSchemaViolation is the error code of responses to events whose
+// properties violate the schema of their type; Fields lists the violations.
+const ErrorCodeSchemaViolation = "SCHEMA_VIOLATION"
+
+// SchemasHandler handles GET requests to list property schemas and POST
+// requests to register one for an event type
+func SchemasHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			schemas := tracker.PropertySchemas().List()
+			respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+				"schemas": schemas,
+				"count":   len(schemas),
+			})
+		case http.MethodPost:
+			var schema models.PropertySchema
+			if err := json.NewDecoder(r.Body).Decode(&schema); err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+				return
+			}
+
+			registered, err := tracker.PropertySchemas().Put(schema)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			respondWithJSON(w, r, http.StatusCreated, registered)
+		default:
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// SchemaHandler handles GET, PUT and DELETE requests to
+// /api/v1/schemas/{event_type}
+func SchemaHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		eventType := strings.TrimPrefix(r.URL.Path, schemasPath)
+		if eventType == "" || strings.Contains(eventType, "/") {
+			respondWithError(w, http.StatusNotFound, "Not found")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			schema, exists := tracker.PropertySchemas().Get(eventType)
+			if !exists {
+				respondWithError(w, http.StatusNotFound, "Schema not found")
+				return
+			}
+			respondWithJSON(w, r, http.StatusOK, schema)
+		case http.MethodPut:
+			var schema models.PropertySchema
+			if err := json.NewDecoder(r.Body).Decode(&schema); err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+				return
+			}
+			schema.EventType = eventType
+
+			registered, err := tracker.PropertySchemas().Put(schema)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			respondWithJSON(w, r, http.StatusOK, registered)
+		case http.MethodDelete:
+			if !tracker.PropertySchemas().Delete(eventType) {
+				respondWithError(w, http.StatusNotFound, "Schema not found")
+				return
+			}
+			respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+				"success": true,
+				"message": "Schema deleted",
+			})
+		default:
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// respondWithSchemaViolation answers an event refused by the schema of its
+// type, listing each property in violation.
+func respondWithSchemaViolation(w http.ResponseWriter, err *storage.SchemaViolationError) {
+	writeJSON(w, http.StatusBadRequest, models.ErrorResponse{
+		Error:  err.Error(),
+		Code:   ErrorCodeSchemaViolation,
+		Fields: err.Fields,
+	})
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestSchemasHandler(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	schemas := SchemasHandler(tracker)
+	w := httptest.NewRecorder()
+	schemas(w, httptest.NewRequest("POST", "/api/v1/schemas", bytes.NewBufferString(
+		`{"event_type": "custom:signup", "properties": [{"name": "plan", "type": "string", "required": true, "indexed": true}]}`)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	ingest := IngestHandler(tracker)
+	w = httptest.NewRecorder()
+	ingest(w, httptest.NewRequest("POST", "/api/v1/ingest", bytes.NewBufferString(
+		`{"visitor_id": "visitor1", "url": "https://example.com/", "event_type": "custom:signup", "properties": {"plan": "pro"}}`)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	ingest(w, httptest.NewRequest("POST", "/api/v1/ingest", bytes.NewBufferString(
+		`{"visitor_id": "visitor1", "url": "https://example.com/", "event_type": "custom:signup", "properties": {"seats": "3"}}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	var response models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Code != ErrorCodeSchemaViolation || len(response.Fields) != 2 || response.Fields[0].Field != "properties.plan" {
+		t.Errorf("Expected field-level violations, got %+v", response)
+	}
+
+	w = httptest.NewRecorder()
+	EventTypeStatsHandler(tracker)(w, httptest.NewRequest("GET", "/api/v1/events/stats?url=https://example.com/&type=custom:signup&property=plan:pro", nil))
+	var stats models.EventTypeStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if w.Code != http.StatusOK || len(stats.Events) != 1 || stats.Events[0].Count != 1 {
+		t.Errorf("Expected 1 pro signup, got %d: %s", w.Code, w.Body.String())
+	}
+
+	schema := SchemaHandler(tracker)
+	w = httptest.NewRecorder()
+	schema(w, httptest.NewRequest("DELETE", "/api/v1/schemas/custom:signup", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	w = httptest.NewRecorder()
+	schema(w, httptest.NewRequest("GET", "/api/v1/schemas/custom:signup", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/storage"
+)
+
+// DataQualityHandler handles GET requests to retrieve the report of suspicious and excluded visitor IDs
+func DataQualityHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		respondWithJSON(w, r, http.StatusOK, tracker.DataQualityReport())
+	}
+}
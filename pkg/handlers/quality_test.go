@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestDataQualityHandler_Success(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := DataQualityHandler(tracker)
+
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "undefined", URL: "https://example.com/a"}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/data-quality", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var report models.DataQualityReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(report.Suspicious) != 1 || report.Suspicious[0].VisitorID != "undefined" {
+		t.Errorf("Expected placeholder visitor ID to be flagged, got %+v", report.Suspicious)
+	}
+}
+
+func TestDataQualityHandler_MethodNotAllowed(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := DataQualityHandler(tracker)
+
+	req := httptest.NewRequest("POST", "/api/v1/data-quality", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/version"
+)
+
+// ReadyHandler handles GET requests reporting whether every component has started
+func ReadyHandler(status func() []models.ComponentStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		components := status()
+		ready := true
+		for _, component := range components {
+			if component.State != models.ComponentReady {
+				ready = false
+				break
+			}
+		}
+
+		statusCode := http.StatusOK
+		if !ready {
+			statusCode = http.StatusServiceUnavailable
+		}
+
+		respondWithJSON(w, r, statusCode, map[string]interface{}{
+			"ready":      ready,
+			"components": components,
+			"build":      version.Info(),
+		})
+	}
+}
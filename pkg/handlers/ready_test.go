@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestReadyHandler(t *testing.T) {
+	components := []models.ComponentStatus{
+		{Name: "snapshot", State: models.ComponentReady},
+		{Name: "http", State: models.ComponentStarting},
+	}
+	handler := ReadyHandler(func() []models.ComponentStatus { return components })
+
+	req := httptest.NewRequest("GET", "/ready", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d while starting, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	components[1].State = models.ComponentReady
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d when ready, got %d", http.StatusOK, w.Code)
+	}
+}
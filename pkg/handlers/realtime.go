@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// RealtimeHandler handles GET requests to count the visitors online now, overall or for a URL
+func RealtimeHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		url := models.NormalizeURL(r.URL.Query().Get("url"))
+		respondWithJSON(w, r, http.StatusOK, tracker.Realtime(url, time.Now()))
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestRealtimeHandler(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	for _, event := range []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a"},
+		{VisitorID: "visitor2", URL: "https://example.com/b"},
+	} {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	handler := RealtimeHandler(tracker)
+	tests := []struct {
+		target   string
+		visitors int
+	}{
+		{"/api/v1/realtime", 2},
+		{"/api/v1/realtime?url=https://EXAMPLE.com/a", 1},
+		{"/api/v1/realtime?url=https://example.com/c", 0},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", tt.target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d", tt.target, http.StatusOK, w.Code)
+		}
+		var realtime models.RealtimeVisitors
+		if err := json.Unmarshal(w.Body.Bytes(), &realtime); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if realtime.Visitors != tt.visitors {
+			t.Errorf("%s: expected %d visitors online, got %d", tt.target, tt.visitors, realtime.Visitors)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/api/v1/realtime", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
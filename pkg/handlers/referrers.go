@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+const (
+	defaultReferrerLimit = 10
+	maxReferrerLimit     = 100
+)
+
+// ReferrersHandler handles GET requests to retrieve the top referring domains for a URL
+func ReferrersHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		urlParam := r.URL.Query().Get("url")
+		if urlParam == "" {
+			respondWithError(w, http.StatusBadRequest, "Missing required query parameter: url")
+			return
+		}
+
+		limit, err := parseLimitParam(r, defaultReferrerLimit, maxReferrerLimit)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit: must be a positive integer")
+			return
+		}
+
+		response := map[string]interface{}{
+			"url":       urlParam,
+			"referrers": tracker.GetTopReferrers(models.NormalizeURL(urlParam), limit),
+		}
+
+		respondWithJSON(w, r, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestReferrersHandler_Success(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := ReferrersHandler(tracker)
+
+	events := []models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", Referrer: "https://www.google.com/search?q=x"},
+		{VisitorID: "visitor2", URL: "https://example.com/a", Referrer: "https://google.com/"},
+		{VisitorID: "visitor3", URL: "https://example.com/a", Referrer: "https://news.ycombinator.com/item?id=1"},
+		{VisitorID: "visitor4", URL: "https://example.com/a"},
+	}
+	for i := range events {
+		if err := tracker.RecordEvent(&events[i]); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/referrers?url=HTTPS://Example.com/A/%23top&limit=1", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Referrers []models.ReferrerStats `json:"referrers"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if len(response.Referrers) != 1 {
+		t.Fatalf("Expected 1 referrer, got %d", len(response.Referrers))
+	}
+
+	if response.Referrers[0].Domain != "google.com" || response.Referrers[0].DistinctVisitors != 2 {
+		t.Errorf("Expected google.com with 2 visitors, got %+v", response.Referrers[0])
+	}
+}
+
+func TestReferrersHandler_MissingURL(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := ReferrersHandler(tracker)
+
+	req := httptest.NewRequest("GET", "/api/v1/referrers", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/reports"
+)
+
+const reportsPath = "/api/v1/reports/"
+
+// ReportsHandler handles GET requests to list report schedules and POST
+// requests to create one
+func ReportsHandler(scheduler *reports.Scheduler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			schedules := scheduler.List()
+			respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+				"reports": schedules,
+				"count":   len(schedules),
+			})
+		case http.MethodPost:
+			var schedule models.ReportSchedule
+			if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+				return
+			}
+
+			created, err := scheduler.Create(schedule)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			respondWithJSON(w, r, http.StatusCreated, created)
+		default:
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// ReportHandler handles GET, PUT and DELETE requests to /api/v1/reports/{id}
+// and POST requests to /api/v1/reports/{id}/run, which generates and
+// delivers the report at once
+func ReportHandler(scheduler *reports.Scheduler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, run := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, reportsPath), "/run")
+		if id == "" || strings.Contains(id, "/") {
+			respondWithError(w, http.StatusNotFound, "Not found")
+			return
+		}
+
+		if run {
+			if r.Method != http.MethodPost {
+				respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+				return
+			}
+
+			report, err := scheduler.Run(id, time.Now())
+			switch {
+			case errors.Is(err, reports.ErrScheduleNotFound):
+				respondWithError(w, http.StatusNotFound, "Report schedule not found")
+			case err != nil:
+				respondWithError(w, http.StatusBadGateway, "Report generated but not delivered: "+err.Error())
+			default:
+				respondWithJSON(w, r, http.StatusOK, report)
+			}
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			schedule, exists := scheduler.Get(id)
+			if !exists {
+				respondWithError(w, http.StatusNotFound, "Report schedule not found")
+				return
+			}
+			respondWithJSON(w, r, http.StatusOK, schedule)
+		case http.MethodPut:
+			var schedule models.ReportSchedule
+			if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid JSON format")
+				return
+			}
+
+			updated, err := scheduler.Update(id, schedule)
+			switch {
+			case errors.Is(err, reports.ErrScheduleNotFound):
+				respondWithError(w, http.StatusNotFound, "Report schedule not found")
+			case err != nil:
+				respondWithError(w, http.StatusBadRequest, err.Error())
+			default:
+				respondWithJSON(w, r, http.StatusOK, updated)
+			}
+		case http.MethodDelete:
+			if !scheduler.Delete(id) {
+				respondWithError(w, http.StatusNotFound, "Report schedule not found")
+				return
+			}
+			respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+				"success": true,
+				"message": "Report schedule deleted",
+			})
+		default:
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
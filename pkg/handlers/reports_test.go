@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/reports"
+	"nav-tracker/pkg/storage"
+)
+
+func newTestScheduler() *reports.Scheduler {
+	config := models.DefaultConfiguration().Reports
+	config.Enabled = true
+	config.MaxRetries = 0
+	return reports.NewScheduler(config, storage.NewNavigationTracker())
+}
+
+func TestReportsHandler_CRUD(t *testing.T) {
+	scheduler := newTestScheduler()
+	list := ReportsHandler(scheduler)
+	single := ReportHandler(scheduler)
+
+	body, _ := json.Marshal(models.ReportSchedule{
+		Name:      "Daily",
+		Frequency: models.ReportDaily,
+		Delivery:  models.ReportDelivery{Webhook: "http://127.0.0.1:0/hook"},
+	})
+	w := httptest.NewRecorder()
+	list(w, httptest.NewRequest("POST", "/api/v1/reports", bytes.NewBuffer(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var created models.ReportSchedule
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	list(w, httptest.NewRequest("POST", "/api/v1/reports", bytes.NewBufferString(`{"name":"Bad","frequency":"daily"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a schedule without delivery, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	single(w, httptest.NewRequest("PUT", "/api/v1/reports/"+created.ID, bytes.NewBufferString(`{"name":"Weekly","frequency":"weekly","weekday":"friday","delivery":{"webhook":"http://127.0.0.1:0/hook"}}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	single(w, httptest.NewRequest("GET", "/api/v1/reports/"+created.ID, nil))
+	var fetched models.ReportSchedule
+	if err := json.Unmarshal(w.Body.Bytes(), &fetched); err != nil || fetched.Frequency != models.ReportWeekly {
+		t.Errorf("Expected the updated schedule, got %s", w.Body.String())
+	}
+
+	// Delivery to an unreachable webhook fails after the report is generated
+	w = httptest.NewRecorder()
+	single(w, httptest.NewRequest("POST", "/api/v1/reports/"+created.ID+"/run", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("Expected status %d, got %d", http.StatusBadGateway, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	single(w, httptest.NewRequest("DELETE", "/api/v1/reports/"+created.ID, nil))
+	if w.Code != http.StatusNoContent && w.Code != http.StatusOK {
+		t.Errorf("Expected the schedule to be deleted, got %d", w.Code)
+	}
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/api/v1/reports/"+created.ID, nil),
+		httptest.NewRequest("POST", "/api/v1/reports/"+created.ID+"/run", nil),
+		httptest.NewRequest("DELETE", "/api/v1/reports/"+created.ID, nil),
+	} {
+		w = httptest.NewRecorder()
+		single(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for %s %s, got %d", http.StatusNotFound, req.Method, req.URL.Path, w.Code)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	list(w, httptest.NewRequest("GET", "/api/v1/reports", nil))
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"count":0`)) {
+		t.Errorf("Expected no schedules listed, got %d: %s", w.Code, w.Body.String())
+	}
+}
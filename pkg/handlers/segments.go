@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// SegmentsHandler handles GET requests for a URL's distinct visitors per value of a custom property
+func SegmentsHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		urlParam := r.URL.Query().Get("url")
+		key := r.URL.Query().Get("key")
+		if urlParam == "" || key == "" {
+			respondWithError(w, http.StatusBadRequest, "Missing required query parameters: url and key")
+			return
+		}
+
+		response := map[string]interface{}{
+			"url":      urlParam,
+			"key":      key,
+			"segments": tracker.GetSegmentBreakdown(models.NormalizeURL(urlParam), key),
+		}
+
+		respondWithJSON(w, r, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestSegmentsHandler_Success(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := SegmentsHandler(tracker)
+
+	events := []models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", Properties: map[string]string{"plan": "pro"}},
+		{VisitorID: "visitor2", URL: "https://example.com/a", Properties: map[string]string{"plan": "pro"}},
+		{VisitorID: "visitor3", URL: "https://example.com/a", Properties: map[string]string{"plan": "free"}},
+	}
+	for i := range events {
+		if err := tracker.RecordEvent(&events[i]); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/segments?url=HTTPS://Example.com/A/%23top&key=plan", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Segments []models.SegmentStats `json:"segments"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if len(response.Segments) != 2 || response.Segments[0].Value != "pro" || response.Segments[0].DistinctVisitors != 2 {
+		t.Errorf("Expected pro with 2 visitors first, got %+v", response.Segments)
+	}
+}
+
+func TestSegmentsHandler_MissingParams(t *testing.T) {
+	handler := SegmentsHandler(storage.NewNavigationTracker())
+
+	for _, query := range []string{"?url=https://example.com/a", "?key=plan"} {
+		req := httptest.NewRequest("GET", "/api/v1/segments"+query, nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// SessionsHandler handles GET requests to list a visitor's sessions or count sessions for a URL
+func SessionsHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		query := r.URL.Query()
+		visitorID := query.Get("visitor_id")
+		urlParam := query.Get("url")
+
+		switch {
+		case visitorID != "":
+			visitorID = visitorKey(r, tracker, visitorID)
+			sessions := tracker.Sessions().GetVisitorSessions(visitorID)
+			respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+				"visitor_id": visitorID,
+				"sessions":   sessions,
+				"count":      len(sessions),
+			})
+		case urlParam != "":
+			respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+				"url":      urlParam,
+				"sessions": tracker.Sessions().GetURLSessionCount(models.NormalizeURL(urlParam)),
+			})
+		default:
+			respondWithError(w, http.StatusBadRequest, "Missing required query parameter: visitor_id or url")
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestSessionsHandler_ByVisitor(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := SessionsHandler(tracker)
+
+	for _, url := range []string{"https://example.com/a", "https://example.com/b"} {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/sessions?visitor_id=visitor1", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Count    int              `json:"count"`
+		Sessions []models.Session `json:"sessions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if response.Count != 1 || len(response.Sessions) != 1 {
+		t.Fatalf("Expected 1 session, got %d", response.Count)
+	}
+
+	if response.Sessions[0].PageViews != 2 {
+		t.Errorf("Expected 2 page views, got %d", response.Sessions[0].PageViews)
+	}
+}
+
+func TestSessionsHandler_ByURL(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := SessionsHandler(tracker)
+
+	for _, visitor := range []string{"visitor1", "visitor2"} {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: visitor, URL: "https://example.com/a"}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/sessions?url=HTTPS://Example.com/A/%23top", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if sessions, ok := response["sessions"].(float64); !ok || int(sessions) != 2 {
+		t.Errorf("Expected 2 sessions, got %v", response["sessions"])
+	}
+}
+
+func TestSessionsHandler_MissingParams(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := SessionsHandler(tracker)
+
+	req := httptest.NewRequest("GET", "/api/v1/sessions", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/monitoring"
+)
+
+// SlowLogHandler handles GET requests to retrieve recent slow queries
+func SlowLogHandler(slowLog *monitoring.SlowLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		entries, total := slowLog.Entries()
+
+		response := map[string]interface{}{
+			"threshold": slowLog.Threshold().String(),
+			"total":     total,
+			"entries":   entries,
+		}
+
+		respondWithJSON(w, r, http.StatusOK, response)
+	}
+}
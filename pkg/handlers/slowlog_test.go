@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/monitoring"
+)
+
+func TestSlowLogHandler(t *testing.T) {
+	slowLog := monitoring.NewSlowLog(100*time.Millisecond, 10)
+	slowLog.Record(monitoring.SlowQueryEntry{Endpoint: "/stats", Duration: time.Second, MatchedURLs: 3})
+	handler := SlowLogHandler(slowLog)
+
+	req := httptest.NewRequest("GET", "/api/v1/slowlog", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Threshold string                      `json:"threshold"`
+		Total     int64                       `json:"total"`
+		Entries   []monitoring.SlowQueryEntry `json:"entries"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if response.Threshold != "100ms" || response.Total != 1 || len(response.Entries) != 1 {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+
+	if response.Entries[0].MatchedURLs != 3 {
+		t.Errorf("Expected matched_urls 3, got %d", response.Entries[0].MatchedURLs)
+	}
+}
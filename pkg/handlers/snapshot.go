@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"nav-tracker/pkg/storage"
+)
+
+// SnapshotHandler handles POST requests to write a persistence snapshot to disk
+func SnapshotHandler(tracker *storage.NavigationTracker, path string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		start := time.Now()
+		if err := tracker.SaveSnapshot(path); err != nil {
+			log.Printf("Error saving snapshot: %v", err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to save snapshot")
+			return
+		}
+
+		response := map[string]interface{}{
+			"success":  true,
+			"path":     path,
+			"duration": time.Since(start).String(),
+		}
+
+		respondWithJSON(w, r, http.StatusOK, response)
+	}
+}
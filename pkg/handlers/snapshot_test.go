@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestSnapshotHandler(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com"}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	handler := SnapshotHandler(tracker, path)
+
+	req := httptest.NewRequest("POST", "/api/v1/snapshot", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	restored := storage.NewNavigationTracker()
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if got := restored.GetDistinctVisitors("https://example.com"); got != 1 {
+		t.Errorf("Expected 1 visitor in snapshot, got %d", got)
+	}
+}
+
+func TestSnapshotHandler_WrongMethod(t *testing.T) {
+	handler := SnapshotHandler(storage.NewNavigationTracker(), filepath.Join(t.TempDir(), "snapshot.json"))
+
+	req := httptest.NewRequest("GET", "/api/v1/snapshot", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
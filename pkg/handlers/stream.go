@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"nav-tracker/pkg/middleware"
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+	"nav-tracker/pkg/stream"
+)
+
+const (
+	streamBufferSize        = 256
+	streamWriteTimeout      = 10 * time.Second
+	streamPingInterval      = 30 * time.Second
+	minStreamSnapshotPeriod = time.Second
+)
+
+// streamOriginAllowed reports whether a browser on r's Origin may open a
+// stream: one on the server's own origin or an origin cors allows. Requests
+// without an Origin do not come from a browser page and are allowed.
+func streamOriginAllowed(r *http.Request, cors *middleware.CORSPolicy) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if parsed, err := url.Parse(origin); err == nil && strings.EqualFold(parsed.Host, r.Host) {
+		return true
+	}
+	return cors != nil && cors.Allows(origin)
+}
+
+type streamMessage struct {
+	Type    string                  `json:"type"`
+	Event   *models.NavigationEvent `json:"event,omitempty"`
+	Stats   *models.SystemStats     `json:"stats,omitempty"`
+	Dropped int64                   `json:"dropped,omitempty"`
+}
+
+// StreamHandler handles GET requests that upgrade to a WebSocket streaming
+// ingested events. Browsers may only connect from the server's origin or an
+// origin cors allows
+func StreamHandler(hub *stream.Hub, tracker *storage.NavigationTracker, cors *middleware.CORSPolicy) http.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 4096,
+		CheckOrigin:     func(r *http.Request) bool { return streamOriginAllowed(r, cors) },
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		query := r.URL.Query()
+		filter := stream.Filter{
+			URL:       models.NormalizeURL(query.Get("url")),
+			URLPrefix: query.Get("url_prefix"),
+		}
+
+		var snapshotPeriod time.Duration
+		if raw := query.Get("snapshot_interval"); raw != "" {
+			parsed, err := time.ParseDuration(raw)
+			if err != nil || parsed < minStreamSnapshotPeriod {
+				respondWithError(w, http.StatusBadRequest, "Invalid snapshot_interval: must be a duration of at least 1s")
+				return
+			}
+			snapshotPeriod = parsed
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// Upgrade has already written an HTTP error response.
+			log.Printf("WebSocket upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		sub := hub.Subscribe(filter, streamBufferSize)
+		defer hub.Unsubscribe(sub)
+
+		// The read loop only services control frames and notices disconnects.
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		pingTicker := time.NewTicker(streamPingInterval)
+		defer pingTicker.Stop()
+
+		var snapshots <-chan time.Time
+		if snapshotPeriod > 0 {
+			snapshotTicker := time.NewTicker(snapshotPeriod)
+			defer snapshotTicker.Stop()
+			snapshots = snapshotTicker.C
+		}
+
+		send := func(message streamMessage) bool {
+			_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			return conn.WriteJSON(message) == nil
+		}
+
+		for {
+			select {
+			case event, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+				if dropped := sub.TakeDropped(); dropped > 0 {
+					if !send(streamMessage{Type: "dropped", Dropped: dropped}) {
+						return
+					}
+				}
+				if !send(streamMessage{Type: "event", Event: &event}) {
+					return
+				}
+			case <-snapshots:
+				if !send(streamMessage{Type: "stats", Stats: tracker.GetSystemStats()}) {
+					return
+				}
+			case <-pingTicker.C:
+				deadline := time.Now().Add(streamWriteTimeout)
+				if err := conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+					return
+				}
+			case <-closed:
+				return
+			}
+		}
+	}
+}
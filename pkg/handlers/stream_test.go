@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"nav-tracker/pkg/middleware"
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+	"nav-tracker/pkg/stream"
+)
+
+func dialStream(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/stream" + query
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial stream: %v", err)
+	}
+	return conn
+}
+
+func waitForSubscribers(t *testing.T, hub *stream.Hub, n int) {
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.Subscribers() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %d subscribers", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStreamHandler_PushesFilteredEvents(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	hub := stream.NewHub()
+	tracker.AddListener(hub.Publish)
+
+	server := httptest.NewServer(StreamHandler(hub, tracker, nil))
+	defer server.Close()
+
+	conn := dialStream(t, server, "?url=https://EXAMPLE.com/a")
+	defer conn.Close()
+	waitForSubscribers(t, hub, 1)
+
+	for _, url := range []string{"https://example.com/b", "https://example.com/a"} {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var message streamMessage
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+
+	if message.Type != "event" || message.Event == nil || message.Event.URL != "https://example.com/a" {
+		t.Errorf("Expected event for /a, got %+v", message)
+	}
+}
+
+func TestStreamHandler_Snapshots(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	hub := stream.NewHub()
+
+	server := httptest.NewServer(StreamHandler(hub, tracker, nil))
+	defer server.Close()
+
+	conn := dialStream(t, server, "?snapshot_interval=1s")
+	defer conn.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	var message streamMessage
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+
+	if message.Type != "stats" || message.Stats == nil {
+		t.Errorf("Expected stats snapshot, got %+v", message)
+	}
+}
+
+func TestStreamHandler_UnsubscribesOnDisconnect(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	hub := stream.NewHub()
+
+	server := httptest.NewServer(StreamHandler(hub, tracker, nil))
+	defer server.Close()
+
+	conn := dialStream(t, server, "")
+	waitForSubscribers(t, hub, 1)
+
+	conn.Close()
+	waitForSubscribers(t, hub, 0)
+}
+
+func TestStreamHandler_InvalidSnapshotInterval(t *testing.T) {
+	handler := StreamHandler(stream.NewHub(), storage.NewNavigationTracker(), nil)
+
+	req := httptest.NewRequest("GET", "/api/v1/stream?snapshot_interval=10ms", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestStreamHandler_ChecksOrigin(t *testing.T) {
+	cors := middleware.NewCORSPolicy(models.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}})
+	server := httptest.NewServer(StreamHandler(stream.NewHub(), storage.NewNavigationTracker(), cors))
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/stream"
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"", true},
+		{server.URL, true},
+		{"https://app.example.com", true},
+		{"https://evil.example.com", false},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.origin != "" {
+			header.Set("Origin", tt.origin)
+		}
+		conn, resp, err := websocket.DefaultDialer.Dial(wsURL, header)
+		if tt.allowed != (err == nil) {
+			t.Errorf("Origin %q: expected allowed=%v, got %v", tt.origin, tt.allowed, err)
+		}
+		if err == nil {
+			conn.Close()
+		} else if resp != nil && resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Origin %q: expected status %d, got %d", tt.origin, http.StatusForbidden, resp.StatusCode)
+		}
+	}
+
+	cors.Update(models.CORSConfig{AllowedOrigins: []string{"https://evil.example.com"}})
+	header := http.Header{"Origin": []string{"https://evil.example.com"}}
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if err != nil {
+		t.Fatalf("Expected an origin allowed by the updated policy to connect, got %v", err)
+	}
+	conn.Close()
+}
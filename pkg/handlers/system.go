@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// SystemStatsSource adds subsystem-specific fields to the system stats response
+type SystemStatsSource func(stats *models.SystemStats)
+
+// SystemStatsHandler handles GET requests to retrieve tracker-wide statistics
+func SystemStatsHandler(tracker *storage.NavigationTracker, sources ...SystemStatsSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		stats := tracker.GetSystemStats()
+		for _, source := range sources {
+			source(stats)
+		}
+
+		respondWithJSON(w, r, http.StatusOK, stats)
+	}
+}
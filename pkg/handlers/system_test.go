@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/format"
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestSystemStatsHandler(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := SystemStatsHandler(tracker)
+
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/a"}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/system-stats", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var stats models.SystemStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if stats.TotalUniqueURLs != 1 || stats.TotalSessions != 1 || stats.PagesPerSession != 1 {
+		t.Errorf("Unexpected system stats: %+v", stats)
+	}
+}
+
+func TestSystemStatsHandler_Sources(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := SystemStatsHandler(tracker, func(stats *models.SystemStats) {
+		stats.Syslog = &models.SyslogStats{Malformed: 3}
+	})
+
+	req := httptest.NewRequest("GET", "/api/v1/system-stats", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var stats models.SystemStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if stats.Syslog == nil || stats.Syslog.Malformed != 3 {
+		t.Errorf("Expected syslog stats from source, got %+v", stats.Syslog)
+	}
+}
+
+func TestSystemStatsHandler_ResponseFormat(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := SystemStatsHandler(tracker)
+
+	tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/a"})
+
+	req := httptest.NewRequest("GET", "/api/v1/system-stats", nil)
+	f := models.ResponseFormatConfig{DurationFormat: models.DurationFormatString, Precision: 1}
+	req = req.WithContext(format.NewContext(req.Context(), f))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var stats map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if length, ok := stats["average_session_length"].(string); !ok || length != "0s" {
+		t.Errorf("Expected the session length as a duration string, got %v", stats["average_session_length"])
+	}
+}
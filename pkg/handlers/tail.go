@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/stream"
+)
+
+// tailHeartbeatInterval is how often an idle tail sends a comment line so
+// that proxies do not close the connection.
+var tailHeartbeatInterval = 15 * time.Second
+
+// TailHandler handles GET requests for a Server-Sent Events stream of
+// ingested events, for clients that cannot use the WebSocket stream
+func TailHandler(hub *stream.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		filter := stream.Filter{URL: models.NormalizeURL(r.URL.Query().Get("url"))}
+
+		// Each write gets its own deadline so the server's write timeout
+		// does not cut off a long-lived stream
+		rc := http.NewResponseController(w)
+		extendDeadline := func() {
+			_ = rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		}
+
+		header := w.Header()
+		header.Set("Content-Type", "text/event-stream")
+		header.Set("Cache-Control", "no-cache")
+		header.Set("Connection", "keep-alive")
+		header.Set("X-Accel-Buffering", "no")
+
+		extendDeadline()
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		sub := hub.Subscribe(filter, streamBufferSize)
+		defer hub.Unsubscribe(sub)
+
+		send := func(format string, args ...interface{}) bool {
+			extendDeadline()
+			if _, err := fmt.Fprintf(w, format, args...); err != nil {
+				return false
+			}
+			return rc.Flush() == nil
+		}
+
+		heartbeat := time.NewTicker(tailHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case event, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+				if dropped := sub.TakeDropped(); dropped > 0 {
+					if !send("event: dropped\ndata: {\"dropped\":%d}\n\n", dropped) {
+						return
+					}
+				}
+				data, err := json.Marshal(event)
+				if err != nil {
+					continue
+				}
+				if !send("event: navigation\ndata: %s\n\n", data) {
+					return
+				}
+			case <-heartbeat.C:
+				if !send(": heartbeat\n\n") {
+					return
+				}
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+	"nav-tracker/pkg/stream"
+)
+
+func openTail(t *testing.T, ctx context.Context, server *httptest.Server, query string) *bufio.Reader {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/events/tail"+query, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open tail: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+// readSSE returns the next message's lines, without the blank line ending it
+func readSSE(t *testing.T, reader *bufio.Reader) []string {
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestTailHandler_StreamsFilteredEvents(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	hub := stream.NewHub()
+	tracker.AddListener(hub.Publish)
+
+	server := httptest.NewServer(TailHandler(hub))
+	// Registered before openTail's cleanup so the stream closes first
+	t.Cleanup(server.Close)
+
+	reader := openTail(t, context.Background(), server, "?url=https://EXAMPLE.com/a")
+	waitForSubscribers(t, hub, 1)
+
+	for _, url := range []string{"https://example.com/b", "https://example.com/a"} {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	lines := readSSE(t, reader)
+	if len(lines) != 2 || lines[0] != "event: navigation" || !strings.HasPrefix(lines[1], "data: ") {
+		t.Fatalf("Expected a navigation event, got %q", lines)
+	}
+	var event models.NavigationEvent
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &event); err != nil {
+		t.Fatalf("Failed to decode event: %v", err)
+	}
+	if event.URL != "https://example.com/a" || event.VisitorID != "visitor1" {
+		t.Errorf("Expected the /a event, got %+v", event)
+	}
+}
+
+func TestTailHandler_Heartbeat(t *testing.T) {
+	defer func(interval time.Duration) { tailHeartbeatInterval = interval }(tailHeartbeatInterval)
+	tailHeartbeatInterval = 10 * time.Millisecond
+
+	hub := stream.NewHub()
+	server := httptest.NewServer(TailHandler(hub))
+	t.Cleanup(server.Close)
+
+	reader := openTail(t, context.Background(), server, "")
+	if lines := readSSE(t, reader); len(lines) != 1 || lines[0] != ": heartbeat" {
+		t.Errorf("Expected a heartbeat comment, got %q", lines)
+	}
+}
+
+func TestTailHandler_UnsubscribesOnDisconnect(t *testing.T) {
+	hub := stream.NewHub()
+	server := httptest.NewServer(TailHandler(hub))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	openTail(t, ctx, server, "")
+	waitForSubscribers(t, hub, 1)
+
+	cancel()
+	waitForSubscribers(t, hub, 0)
+}
+
+func TestTailHandler_MethodNotAllowed(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/events/tail", nil)
+	rr := httptest.NewRecorder()
+
+	TailHandler(stream.NewHub())(rr, req)
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"nav-tracker/pkg/storage"
+)
+
+const (
+	defaultTopURLsLimit = 10
+	maxTopURLsLimit     = 1000
+)
+
+// TopURLsHandler handles GET requests to retrieve a sorted page of URLs, by default those with the most distinct visitors
+func TopURLsHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		opts, message := parseListOptions(r, defaultTopURLsLimit, maxTopURLsLimit, storage.SortByVisitors, storage.SortByViews, storage.SortByLastVisit)
+		if message != "" {
+			respondWithError(w, http.StatusBadRequest, message)
+			return
+		}
+
+		urls, total := tracker.GetTopURLs(opts)
+		response := map[string]interface{}{
+			"urls":   urls,
+			"total":  total,
+			"limit":  opts.Limit,
+			"offset": opts.Offset,
+		}
+		if next := opts.Offset + len(urls); next < total {
+			response["next_cursor"] = strconv.Itoa(next)
+		}
+
+		respondWithJSON(w, r, http.StatusOK, response)
+	}
+}
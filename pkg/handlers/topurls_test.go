@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestTopURLsHandler_Success(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := TopURLsHandler(tracker)
+
+	events := []models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a"},
+		{VisitorID: "visitor2", URL: "https://example.com/a"},
+		{VisitorID: "visitor1", URL: "https://example.com/b"},
+	}
+	for i := range events {
+		if err := tracker.RecordEvent(&events[i]); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/top-urls?limit=1", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		URLs []models.VisitorStats `json:"urls"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if len(response.URLs) != 1 || response.URLs[0].URL != "https://example.com/a" {
+		t.Errorf("Expected only /a, got %+v", response.URLs)
+	}
+}
+
+func TestTopURLsHandler_InvalidLimit(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := TopURLsHandler(tracker)
+
+	req := httptest.NewRequest("GET", "/api/v1/top-urls?limit=0", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestTopURLsHandler_Pagination(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := TopURLsHandler(tracker)
+
+	for _, url := range []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"} {
+		tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url})
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/top-urls?limit=2&sort=views&order=asc", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response struct {
+		URLs       []models.VisitorStats `json:"urls"`
+		Total      int                   `json:"total"`
+		NextCursor string                `json:"next_cursor"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(response.URLs) != 2 || response.Total != 3 || response.NextCursor != "2" {
+		t.Fatalf("Expected 2 of 3 URLs with cursor 2, got %+v", response)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/top-urls?limit=2&sort=views&order=asc&cursor="+response.NextCursor, nil)
+	w = httptest.NewRecorder()
+	handler(w, req)
+
+	response.NextCursor = ""
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if len(response.URLs) != 1 || response.URLs[0].URL != "https://example.com/c" || response.NextCursor != "" {
+		t.Errorf("Expected only /c on the last page, got %+v", response)
+	}
+}
+
+func TestTopURLsHandler_InvalidSort(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := TopURLsHandler(tracker)
+
+	for _, query := range []string{"sort=visit_count", "order=up", "offset=-1"} {
+		req := httptest.NewRequest("GET", "/api/v1/top-urls?"+query, nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/storage"
+)
+
+const (
+	defaultURLsLimit = 100
+	maxURLsLimit     = 1000
+)
+
+// URLsHandler handles GET requests to list tracked URLs and DELETE requests
+// to purge one
+func URLsHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	list := ListURLsHandler(tracker)
+	purge := DeleteURLHandler(tracker)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			purge(w, r)
+			return
+		}
+		list(w, r)
+	}
+}
+
+// ListURLsHandler handles GET requests to list tracked URLs in order, filtered
+// by prefix and substring, with their visitors, page views and last visit
+func ListURLsHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		limit, err := parseLimitParam(r, defaultURLsLimit, maxURLsLimit)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit: must be a positive integer")
+			return
+		}
+
+		query := r.URL.Query()
+		urls, total, more := tracker.ListURLs(storage.URLQuery{
+			Prefix:   query.Get("prefix"),
+			Contains: query.Get("q"),
+			After:    query.Get("cursor"),
+			Limit:    limit,
+		})
+		response := map[string]interface{}{
+			"urls":  urls,
+			"total": total,
+			"limit": limit,
+		}
+		if more {
+			response["next_cursor"] = urls[len(urls)-1].URL
+		}
+
+		respondWithJSON(w, r, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestURLsHandler(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := URLsHandler(tracker)
+
+	for _, url := range []string{"https://example.com/a", "https://example.com/b", "https://example.com/c", "https://other.com/a"} {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	list := func(target string) (int, map[string]json.RawMessage) {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", target, nil))
+		var response map[string]json.RawMessage
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	code, response := list("/api/v1/urls?prefix=https://example.com/&limit=2")
+	if code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, code)
+	}
+	var urls []models.VisitorStats
+	json.Unmarshal(response["urls"], &urls)
+	if len(urls) != 2 || urls[0].URL != "https://example.com/a" || string(response["total"]) != "3" || string(response["next_cursor"]) != `"https://example.com/b"` {
+		t.Fatalf("Unexpected first page: %v", response)
+	}
+
+	_, response = list("/api/v1/urls?prefix=https://example.com/&limit=2&cursor=https://example.com/b")
+	json.Unmarshal(response["urls"], &urls)
+	if len(urls) != 1 || urls[0].URL != "https://example.com/c" || response["next_cursor"] != nil {
+		t.Errorf("Unexpected last page: %v", response)
+	}
+
+	if code, _ := list("/api/v1/urls?limit=0"); code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid limit, got %d", http.StatusBadRequest, code)
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("DELETE", "/api/v1/urls?url=https://other.com/a", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if _, response := list("/api/v1/urls?q=other"); string(response["total"]) != "0" {
+		t.Errorf("Expected the deleted URL to be unlisted, got %v", response)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/api/v1/urls", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/version"
+)
+
+// VersionHandler handles GET requests for the version, commit and build date of the binary
+func VersionHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		respondWithJSON(w, r, http.StatusOK, version.Info())
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/version"
+)
+
+func TestVersionHandler(t *testing.T) {
+	defer func(v string) { version.Version = v }(version.Version)
+	version.Version = "v1.4.0"
+
+	req := httptest.NewRequest("GET", "/api/v1/version", nil)
+	w := httptest.NewRecorder()
+	VersionHandler()(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var info models.BuildInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if info.Version != "v1.4.0" || info.GoVersion == "" {
+		t.Errorf("Expected injected version and Go version, got %+v", info)
+	}
+}
+
+func TestVersionHandler_WrongMethod(t *testing.T) {
+	req := httptest.NewRequest("POST", "/api/v1/version", nil)
+	w := httptest.NewRecorder()
+	VersionHandler()(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"nav-tracker/pkg/storage"
+)
+
+const visitorsPath = "/api/v1/visitors/"
+
+// visitorKey returns the stored form of visitorID. With raw=true the ID is
+// the one the client sent and is hashed first when visitor IDs are hashed.
+func visitorKey(r *http.Request, tracker *storage.NavigationTracker, visitorID string) string {
+	if r.URL.Query().Get("raw") == "true" {
+		return tracker.VisitorKey(visitorID)
+	}
+	return visitorID
+}
+
+// VisitorProfileHandler handles GET requests to /api/v1/visitors/{visitor_id}
+func VisitorProfileHandler(tracker *storage.NavigationTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		visitorID := strings.TrimPrefix(r.URL.Path, visitorsPath)
+		if visitorID == "" || strings.Contains(visitorID, "/") {
+			respondWithError(w, http.StatusNotFound, "Not found")
+			return
+		}
+
+		profile, exists := tracker.GetVisitorProfile(visitorKey(r, tracker, visitorID))
+		if !exists {
+			respondWithError(w, http.StatusNotFound, "Visitor not found")
+			return
+		}
+
+		respondWithJSON(w, r, http.StatusOK, profile)
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func TestVisitorProfileHandler(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	handler := VisitorProfileHandler(tracker)
+
+	for _, url := range []string{"https://example.com/a", "https://example.com/b"} {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/visitors/visitor1", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var profile models.VisitorProfile
+	if err := json.Unmarshal(w.Body.Bytes(), &profile); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if profile.VisitorID != "visitor1" || len(profile.URLs) != 2 || profile.TotalPageViews != 2 || profile.SessionCount != 1 {
+		t.Errorf("Unexpected profile: %+v", profile)
+	}
+}
+
+func TestVisitorProfileHandler_NotFound(t *testing.T) {
+	handler := VisitorProfileHandler(storage.NewNavigationTracker())
+
+	for _, path := range []string{"/api/v1/visitors/missing", "/api/v1/visitors/", "/api/v1/visitors/a/b"} {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusNotFound, w.Code)
+		}
+	}
+}
+
+func TestVisitorProfileHandler_RawVisitorID(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Privacy = models.PrivacyConfig{HashVisitorIDs: true, Salt: "pepper"}
+	tracker := storage.NewNavigationTrackerWithConfig(config)
+	handler := VisitorProfileHandler(tracker)
+
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com"}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/api/v1/visitors/visitor1", http.StatusNotFound},
+		{"/api/v1/visitors/visitor1?raw=true", http.StatusOK},
+		{"/api/v1/visitors/" + tracker.VisitorKey("visitor1"), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.status {
+			t.Errorf("Expected status %d for %s, got %d", tt.status, tt.path, w.Code)
+		}
+	}
+}
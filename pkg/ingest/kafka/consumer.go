@@ -0,0 +1,159 @@
+// Package kafka feeds navigation events consumed from Kafka topics into the
+// tracker.
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+const retryDelay = time.Second
+
+// Message is a record fetched from a topic partition.
+type Message struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Value     []byte
+}
+
+// Reader is a consumer group member reading the configured topics. Its method
+// set matches github.com/segmentio/kafka-go's Reader, which backs it, apart
+// from Lag, which reports the messages behind the high watermark across the
+// partitions it reads.
+type Reader interface {
+	FetchMessage(ctx context.Context) (Message, error)
+	CommitMessages(ctx context.Context, messages ...Message) error
+	Lag() int64
+	Close() error
+}
+
+// EventRecorder is the subset of the tracker the consumer feeds.
+type EventRecorder interface {
+	RecordEvent(event *models.NavigationEvent) error
+}
+
+// Consumer records each message's NavigationEvent JSON and commits its offset
+// for the consumer group once it has been handled, so a restart resumes after
+// the last handled message. Delivery is at least once; set event_id to have
+// redelivered events deduplicated.
+type Consumer struct {
+	config   models.KafkaConfig
+	reader   Reader
+	recorder EventRecorder
+
+	cancel   context.CancelFunc
+	done     chan struct{}
+	stopOnce sync.Once
+
+	received  int64
+	accepted  int64
+	malformed int64
+	rejected  int64
+}
+
+// NewConsumer creates a consumer that connects with NewReader on Start.
+func NewConsumer(config models.KafkaConfig, recorder EventRecorder) *Consumer {
+	return &Consumer{config: config, recorder: recorder}
+}
+
+// NewConsumerWithReader creates a consumer that reads from reader.
+func NewConsumerWithReader(config models.KafkaConfig, reader Reader, recorder EventRecorder) *Consumer {
+	return &Consumer{config: config, reader: reader, recorder: recorder}
+}
+
+func (c *Consumer) Start() error {
+	if c.reader == nil {
+		reader, err := NewReader(c.config)
+		if err != nil {
+			return fmt.Errorf("kafka connect: %w", err)
+		}
+		c.reader = reader
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.done = make(chan struct{})
+	go c.run(ctx)
+
+	log.Printf("Kafka consumer group %s started on %v", c.config.GroupID, c.config.Topics)
+	return nil
+}
+
+// Stop waits for the message in hand to be recorded and committed, then
+// leaves the consumer group.
+func (c *Consumer) Stop() {
+	c.stopOnce.Do(func() {
+		if c.cancel == nil {
+			return
+		}
+		c.cancel()
+		<-c.done
+		if err := c.reader.Close(); err != nil {
+			log.Printf("Failed to close Kafka reader: %v", err)
+		}
+	})
+}
+
+func (c *Consumer) Stats() *models.KafkaStats {
+	stats := &models.KafkaStats{
+		Received:  atomic.LoadInt64(&c.received),
+		Accepted:  atomic.LoadInt64(&c.accepted),
+		Malformed: atomic.LoadInt64(&c.malformed),
+		Rejected:  atomic.LoadInt64(&c.rejected),
+	}
+	if c.reader != nil {
+		stats.Lag = c.reader.Lag()
+	}
+	return stats
+}
+
+func (c *Consumer) run(ctx context.Context) {
+	defer close(c.done)
+
+	for {
+		message, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Failed to fetch Kafka message: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+
+		c.handle(message)
+
+		// The message is handled, so commit even if Stop was called meanwhile
+		if err := c.reader.CommitMessages(context.Background(), message); err != nil {
+			log.Printf("Failed to commit Kafka offset %s/%d@%d: %v", message.Topic, message.Partition, message.Offset, err)
+		}
+	}
+}
+
+// handle records message. Malformed and rejected messages are counted and
+// skipped so that they cannot stall their partition.
+func (c *Consumer) handle(message Message) {
+	atomic.AddInt64(&c.received, 1)
+
+	event, err := models.DecodeEvent(message.Value)
+	if err != nil {
+		atomic.AddInt64(&c.malformed, 1)
+		return
+	}
+	if err := c.recorder.RecordEvent(&event); err != nil {
+		atomic.AddInt64(&c.rejected, 1)
+		return
+	}
+	atomic.AddInt64(&c.accepted, 1)
+}
@@ -0,0 +1,205 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	kafkago "github.com/segmentio/kafka-go"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+type fakeReader struct {
+	messages  chan Message
+	committed []int64
+	closed    bool
+	mutex     sync.Mutex
+}
+
+func newFakeReader(values ...string) *fakeReader {
+	reader := &fakeReader{messages: make(chan Message, len(values))}
+	for i, value := range values {
+		reader.messages <- Message{Topic: "navigation", Offset: int64(i), Value: []byte(value)}
+	}
+	return reader
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (Message, error) {
+	select {
+	case message := <-r.messages:
+		return message, nil
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	}
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, messages ...Message) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, message := range messages {
+		r.committed = append(r.committed, message.Offset)
+	}
+	return nil
+}
+
+func (r *fakeReader) Lag() int64 {
+	return int64(len(r.messages))
+}
+
+func (r *fakeReader) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.closed = true
+	return nil
+}
+
+func (r *fakeReader) commits() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.committed)
+}
+
+type recordingTracker struct {
+	events []*models.NavigationEvent
+	mutex  sync.Mutex
+}
+
+func (rt *recordingTracker) RecordEvent(event *models.NavigationEvent) error {
+	if err := event.Validate(nil); err != nil {
+		return err
+	}
+
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+	rt.events = append(rt.events, event)
+	return nil
+}
+
+func waitFor(t *testing.T, check func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if check() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Timed out waiting for Kafka messages")
+}
+
+func TestConsumer_RecordsAndCommits(t *testing.T) {
+	tracker := &recordingTracker{}
+	reader := newFakeReader(
+		`{"visitor_id": "user1", "url": "https://example.com/a"}`,
+		`not json`,
+		`{"visitor_id": "bad!", "url": "https://example.com/a"}`,
+		`{"schema_version": 1000, "visitor_id": "user1", "url": "https://example.com/a"}`,
+	)
+
+	consumer := NewConsumerWithReader(models.DefaultConfiguration().Kafka, reader, tracker)
+	if err := consumer.Start(); err != nil {
+		t.Fatalf("Failed to start consumer: %v", err)
+	}
+
+	waitFor(t, func() bool { return reader.commits() == 4 })
+	consumer.Stop()
+
+	stats := consumer.Stats()
+	if stats.Received != 4 || stats.Accepted != 1 || stats.Malformed != 2 || stats.Rejected != 1 || stats.Lag != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if len(tracker.events) != 1 || tracker.events[0].VisitorID != "user1" {
+		t.Errorf("Expected one recorded event, got %+v", tracker.events)
+	}
+	if !reader.closed {
+		t.Error("Expected reader to be closed on stop")
+	}
+
+	// Stopping again is a no-op
+	consumer.Stop()
+}
+
+// fakeGroupReader stands in for kafka-go's Reader, serving messages from
+// partitions that each end at the given high watermark.
+type fakeGroupReader struct {
+	messages  chan kafkago.Message
+	committed []kafkago.Message
+	mutex     sync.Mutex
+}
+
+func (r *fakeGroupReader) FetchMessage(ctx context.Context) (kafkago.Message, error) {
+	select {
+	case message := <-r.messages:
+		return message, nil
+	case <-ctx.Done():
+		return kafkago.Message{}, ctx.Err()
+	}
+}
+
+func (r *fakeGroupReader) CommitMessages(ctx context.Context, messages ...kafkago.Message) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.committed = append(r.committed, messages...)
+	return nil
+}
+
+func (r *fakeGroupReader) Close() error {
+	return nil
+}
+
+func (r *fakeGroupReader) commits() []kafkago.Message {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]kafkago.Message(nil), r.committed...)
+}
+
+func TestConsumer_IngestsThroughKafkaGoReader(t *testing.T) {
+	group := &fakeGroupReader{messages: make(chan kafkago.Message, 3)}
+	group.messages <- kafkago.Message{Topic: "navigation", Partition: 0, Offset: 10, HighWaterMark: 20,
+		Value: []byte(`{"visitor_id": "user1", "url": "https://example.com/a"}`)}
+	group.messages <- kafkago.Message{Topic: "navigation", Partition: 1, Offset: 4, HighWaterMark: 5,
+		Value: []byte(`{"visitor_id": "user2", "url": "https://example.com/a"}`)}
+	group.messages <- kafkago.Message{Topic: "navigation", Partition: 0, Offset: 11, HighWaterMark: 20,
+		Value: []byte(`{"visitor_id": "user1", "url": "https://example.com/b"}`)}
+
+	defer func(newReader func(models.KafkaConfig) (Reader, error)) { NewReader = newReader }(NewReader)
+	NewReader = func(config models.KafkaConfig) (Reader, error) {
+		return newGroupReader(group), nil
+	}
+
+	tracker := storage.NewNavigationTracker()
+	config := models.KafkaConfig{Enabled: true, Brokers: []string{"kafka:9092"}, Topics: []string{"navigation"}, GroupID: "nav-tracker"}
+	consumer := NewConsumer(config, tracker)
+	if err := consumer.Start(); err != nil {
+		t.Fatalf("Failed to start consumer: %v", err)
+	}
+	waitFor(t, func() bool { return len(group.commits()) == 3 })
+	consumer.Stop()
+
+	if visitors := tracker.GetDistinctVisitors("https://example.com/a"); visitors != 2 {
+		t.Errorf("Expected 2 visitors on /a, got %d", visitors)
+	}
+	commits := group.commits()
+	if commits[2].Partition != 0 || commits[2].Offset != 11 || commits[1].Partition != 1 || commits[1].Offset != 4 {
+		t.Errorf("Expected each message's offset committed on its partition, got %+v", commits)
+	}
+	// Partition 0 is 8 messages behind, partition 1 has caught up
+	if stats := consumer.Stats(); stats.Accepted != 3 || stats.Lag != 8 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestNewReader_UsesKafkaGo(t *testing.T) {
+	reader, err := NewReader(models.KafkaConfig{Enabled: true, Brokers: []string{"localhost:9092"}, Topics: []string{"navigation"}, GroupID: "nav-tracker"})
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	if _, ok := reader.(*groupReader).reader.(*kafkago.Reader); !ok {
+		t.Errorf("Expected a kafka-go reader, got %T", reader.(*groupReader).reader)
+	}
+	if err := reader.Close(); err != nil {
+		t.Errorf("Failed to close reader: %v", err)
+	}
+}
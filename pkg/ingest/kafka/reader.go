@@ -0,0 +1,84 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+
+	kafkago "github.com/segmentio/kafka-go"
+
+	"nav-tracker/pkg/models"
+)
+
+// NewReader connects a Reader for config, a kafka-go consumer group reader.
+// Tests replace it.
+var NewReader = func(config models.KafkaConfig) (Reader, error) {
+	reader := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers:     config.Brokers,
+		GroupID:     config.GroupID,
+		GroupTopics: config.Topics,
+	})
+	return newGroupReader(reader), nil
+}
+
+// messageReader is the subset of kafka-go's Reader that groupReader uses.
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafkago.Message, error)
+	CommitMessages(ctx context.Context, messages ...kafkago.Message) error
+	Close() error
+}
+
+// groupReader adapts a kafka-go Reader to Reader. kafka-go reports no lag
+// for consumer groups, so it keeps the lag behind the high watermark of the
+// last message fetched from each partition.
+type groupReader struct {
+	reader messageReader
+
+	mutex sync.Mutex
+	lags  map[topicPartition]int64
+}
+
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+func newGroupReader(reader messageReader) *groupReader {
+	return &groupReader{reader: reader, lags: make(map[topicPartition]int64)}
+}
+
+func (r *groupReader) FetchMessage(ctx context.Context) (Message, error) {
+	m, err := r.reader.FetchMessage(ctx)
+	if err != nil {
+		return Message{}, err
+	}
+
+	r.mutex.Lock()
+	r.lags[topicPartition{m.Topic, m.Partition}] = max(m.HighWaterMark-m.Offset-1, 0)
+	r.mutex.Unlock()
+
+	return Message{Topic: m.Topic, Partition: m.Partition, Offset: m.Offset, Value: m.Value}, nil
+}
+
+func (r *groupReader) CommitMessages(ctx context.Context, messages ...Message) error {
+	commits := make([]kafkago.Message, len(messages))
+	for i, m := range messages {
+		commits[i] = kafkago.Message{Topic: m.Topic, Partition: m.Partition, Offset: m.Offset}
+	}
+	return r.reader.CommitMessages(ctx, commits...)
+}
+
+// Lag sums the lag of every partition a message has been fetched from.
+func (r *groupReader) Lag() int64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var lag int64
+	for _, partitionLag := range r.lags {
+		lag += partitionLag
+	}
+	return lag
+}
+
+func (r *groupReader) Close() error {
+	return r.reader.Close()
+}
@@ -0,0 +1,112 @@
+// Package queue decouples ingest requests from the tracker: handlers enqueue
+// validated events and a pool of workers records them.
+package queue
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"nav-tracker/pkg/models"
+)
+
+// EventRecorder is the subset of the tracker the workers feed.
+type EventRecorder interface {
+	RecordEvent(event *models.NavigationEvent) error
+}
+
+// Queue is a bounded queue of events drained by a fixed pool of workers.
+// Events are recorded concurrently, so those from one visitor may be applied
+// out of order; sessions are still built from their timestamps.
+type Queue struct {
+	config   models.AsyncIngestConfig
+	recorder EventRecorder
+	events   chan models.NavigationEvent
+	workers  sync.WaitGroup
+
+	// mutex guards closed so that no event is sent on the closed channel.
+	mutex    sync.RWMutex
+	closed   bool
+	stopOnce sync.Once
+
+	enqueued  atomic.Int64
+	processed atomic.Int64
+	failed    atomic.Int64
+	dropped   atomic.Int64
+}
+
+func New(config models.AsyncIngestConfig, recorder EventRecorder) *Queue {
+	return &Queue{
+		config:   config,
+		recorder: recorder,
+		events:   make(chan models.NavigationEvent, config.QueueSize),
+	}
+}
+
+func (q *Queue) Start() error {
+	for i := 0; i < q.config.Workers; i++ {
+		q.workers.Add(1)
+		go q.work()
+	}
+	log.Printf("Async ingestion started with %d workers and a queue of %d events", q.config.Workers, q.config.QueueSize)
+	return nil
+}
+
+// Enqueue adds event to the queue without blocking. It reports false, and
+// counts the event as dropped, when the queue is full or stopped.
+func (q *Queue) Enqueue(event models.NavigationEvent) bool {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	if q.closed {
+		q.dropped.Add(1)
+		return false
+	}
+
+	select {
+	case q.events <- event:
+		q.enqueued.Add(1)
+		return true
+	default:
+		q.dropped.Add(1)
+		return false
+	}
+}
+
+// Stop stops accepting events and waits for the workers to record every
+// event already queued.
+func (q *Queue) Stop() {
+	q.stopOnce.Do(func() {
+		q.mutex.Lock()
+		q.closed = true
+		close(q.events)
+		q.mutex.Unlock()
+
+		q.workers.Wait()
+	})
+}
+
+func (q *Queue) work() {
+	defer q.workers.Done()
+
+	for event := range q.events {
+		if err := q.recorder.RecordEvent(&event); err != nil {
+			q.failed.Add(1)
+			log.Printf("Error recording queued event: %v", err)
+			continue
+		}
+		q.processed.Add(1)
+	}
+}
+
+func (q *Queue) Stats() *models.IngestQueueStats {
+	return &models.IngestQueueStats{
+		Depth:     len(q.events),
+		Capacity:  cap(q.events),
+		Workers:   q.config.Workers,
+		Enqueued:  q.enqueued.Load(),
+		Processed: q.processed.Load(),
+		Failed:    q.failed.Load(),
+		Dropped:   q.dropped.Load(),
+	}
+}
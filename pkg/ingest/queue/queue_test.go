@@ -0,0 +1,98 @@
+package queue
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// blockingRecorder holds every RecordEvent call until release is closed.
+type blockingRecorder struct {
+	release chan struct{}
+	mutex   sync.Mutex
+	events  []string
+}
+
+func (b *blockingRecorder) RecordEvent(event *models.NavigationEvent) error {
+	<-b.release
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if event.VisitorID == "reject" {
+		return errors.New("rejected")
+	}
+	b.events = append(b.events, event.VisitorID)
+	return nil
+}
+
+func TestQueue_RecordsEvents(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	q := New(models.AsyncIngestConfig{Enabled: true, QueueSize: 100, Workers: 4}, tracker)
+	if err := q.Start(); err != nil {
+		t.Fatalf("Failed to start queue: %v", err)
+	}
+
+	for _, visitorID := range []string{"visitor1", "visitor2", "visitor3"} {
+		if !q.Enqueue(models.NavigationEvent{VisitorID: visitorID, URL: "https://example.com/a"}) {
+			t.Fatalf("Expected %s to be queued", visitorID)
+		}
+	}
+	q.Stop()
+
+	if visitors := tracker.GetDistinctVisitors("https://example.com/a"); visitors != 3 {
+		t.Errorf("Expected Stop to drain 3 events, got %d visitors", visitors)
+	}
+	stats := q.Stats()
+	if stats.Enqueued != 3 || stats.Processed != 3 || stats.Depth != 0 || stats.Capacity != 100 || stats.Workers != 4 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	if q.Enqueue(models.NavigationEvent{VisitorID: "visitor4", URL: "https://example.com/a"}) {
+		t.Error("Expected a stopped queue to refuse events")
+	}
+}
+
+func TestQueue_DropsWhenFull(t *testing.T) {
+	recorder := &blockingRecorder{release: make(chan struct{})}
+	q := New(models.AsyncIngestConfig{Enabled: true, QueueSize: 2, Workers: 1}, recorder)
+	if err := q.Start(); err != nil {
+		t.Fatalf("Failed to start queue: %v", err)
+	}
+
+	// The worker may hold one event, so at most three fit before a drop
+	accepted := 0
+	for i := 0; i < 5; i++ {
+		if q.Enqueue(models.NavigationEvent{VisitorID: "visitor1"}) {
+			accepted++
+		}
+	}
+	if accepted < 2 || accepted > 3 {
+		t.Errorf("Expected 2 or 3 events to be accepted, got %d", accepted)
+	}
+	if stats := q.Stats(); stats.Dropped != int64(5-accepted) {
+		t.Errorf("Expected %d dropped events, got %+v", 5-accepted, stats)
+	}
+
+	close(recorder.release)
+	q.Stop()
+	if len(recorder.events) != accepted {
+		t.Errorf("Expected %d recorded events, got %d", accepted, len(recorder.events))
+	}
+}
+
+func TestQueue_CountsFailures(t *testing.T) {
+	recorder := &blockingRecorder{release: make(chan struct{})}
+	close(recorder.release)
+	q := New(models.AsyncIngestConfig{Enabled: true, QueueSize: 10, Workers: 2}, recorder)
+	_ = q.Start()
+
+	q.Enqueue(models.NavigationEvent{VisitorID: "visitor1"})
+	q.Enqueue(models.NavigationEvent{VisitorID: "reject"})
+	q.Stop()
+
+	if stats := q.Stats(); stats.Processed != 1 || stats.Failed != 1 {
+		t.Errorf("Expected 1 processed and 1 failed event, got %+v", stats)
+	}
+}
@@ -0,0 +1,181 @@
+package syslog
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"nav-tracker/pkg/models"
+)
+
+const maxMessageSize = 64 * 1024
+
+// EventRecorder is the subset of the tracker the listener feeds.
+type EventRecorder interface {
+	RecordEvent(event *models.NavigationEvent) error
+}
+
+// Listener accepts syslog messages over UDP or newline-framed TCP and records
+// each parsed message as a navigation event.
+type Listener struct {
+	config   models.SyslogConfig
+	parser   *Parser
+	recorder EventRecorder
+
+	packetConn net.PacketConn
+	listener   net.Listener
+	conns      map[net.Conn]struct{}
+	connsMutex sync.Mutex
+	wg         sync.WaitGroup
+	stopOnce   sync.Once
+
+	received  int64
+	accepted  int64
+	malformed int64
+	rejected  int64
+}
+
+func NewListener(config models.SyslogConfig, recorder EventRecorder) *Listener {
+	return &Listener{
+		config:   config,
+		parser:   NewParser(config.Format, config.Fields),
+		recorder: recorder,
+		conns:    make(map[net.Conn]struct{}),
+	}
+}
+
+func (l *Listener) Start() error {
+	switch l.config.Network {
+	case "udp":
+		conn, err := net.ListenPacket("udp", l.config.Address)
+		if err != nil {
+			return fmt.Errorf("syslog listen: %w", err)
+		}
+		l.packetConn = conn
+		l.wg.Add(1)
+		go l.serveUDP()
+	case "tcp":
+		listener, err := net.Listen("tcp", l.config.Address)
+		if err != nil {
+			return fmt.Errorf("syslog listen: %w", err)
+		}
+		l.listener = listener
+		l.wg.Add(1)
+		go l.serveTCP()
+	default:
+		return fmt.Errorf("syslog: unsupported network %q", l.config.Network)
+	}
+
+	log.Printf("Syslog listener started on %s/%s", l.config.Network, l.Addr())
+	return nil
+}
+
+func (l *Listener) Addr() string {
+	if l.packetConn != nil {
+		return l.packetConn.LocalAddr().String()
+	}
+	if l.listener != nil {
+		return l.listener.Addr().String()
+	}
+	return ""
+}
+
+func (l *Listener) Stop() {
+	l.stopOnce.Do(func() {
+		if l.packetConn != nil {
+			_ = l.packetConn.Close()
+		}
+		if l.listener != nil {
+			_ = l.listener.Close()
+		}
+
+		l.connsMutex.Lock()
+		for conn := range l.conns {
+			_ = conn.Close()
+		}
+		l.connsMutex.Unlock()
+
+		l.wg.Wait()
+	})
+}
+
+func (l *Listener) Stats() *models.SyslogStats {
+	return &models.SyslogStats{
+		Received:  atomic.LoadInt64(&l.received),
+		Accepted:  atomic.LoadInt64(&l.accepted),
+		Malformed: atomic.LoadInt64(&l.malformed),
+		Rejected:  atomic.LoadInt64(&l.rejected),
+	}
+}
+
+func (l *Listener) serveUDP() {
+	defer l.wg.Done()
+
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, _, err := l.packetConn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			if strings.TrimSpace(line) != "" {
+				l.handleLine(line)
+			}
+		}
+	}
+}
+
+func (l *Listener) serveTCP() {
+	defer l.wg.Done()
+
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		l.connsMutex.Lock()
+		l.conns[conn] = struct{}{}
+		l.connsMutex.Unlock()
+
+		l.wg.Add(1)
+		go func() {
+			defer l.wg.Done()
+			defer func() {
+				l.connsMutex.Lock()
+				delete(l.conns, conn)
+				l.connsMutex.Unlock()
+				_ = conn.Close()
+			}()
+
+			scanner := bufio.NewScanner(conn)
+			scanner.Buffer(make([]byte, 4096), maxMessageSize)
+			for scanner.Scan() {
+				if line := scanner.Text(); strings.TrimSpace(line) != "" {
+					l.handleLine(line)
+				}
+			}
+		}()
+	}
+}
+
+func (l *Listener) handleLine(line string) {
+	atomic.AddInt64(&l.received, 1)
+
+	event, err := l.parser.Parse(line)
+	if err != nil {
+		atomic.AddInt64(&l.malformed, 1)
+		return
+	}
+
+	if err := l.recorder.RecordEvent(event); err != nil {
+		atomic.AddInt64(&l.rejected, 1)
+		return
+	}
+
+	atomic.AddInt64(&l.accepted, 1)
+}
@@ -0,0 +1,107 @@
+package syslog
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+type recordingTracker struct {
+	events []*models.NavigationEvent
+	mutex  sync.Mutex
+}
+
+func (rt *recordingTracker) RecordEvent(event *models.NavigationEvent) error {
+	if err := event.Validate(nil); err != nil {
+		return err
+	}
+
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+	rt.events = append(rt.events, event)
+	return nil
+}
+
+func (rt *recordingTracker) count() int {
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+	return len(rt.events)
+}
+
+func waitForCount(t *testing.T, check func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if check() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Timed out waiting for syslog messages")
+}
+
+func TestListener_UDP(t *testing.T) {
+	tracker := &recordingTracker{}
+	config := models.DefaultConfiguration().Syslog
+	config.Address = "127.0.0.1:0"
+
+	listener := NewListener(config, tracker)
+	if err := listener.Start(); err != nil {
+		t.Fatalf("Failed to start listener: %v", err)
+	}
+	defer listener.Stop()
+
+	conn, err := net.Dial("udp", listener.Addr())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	messages := []string{
+		"visitor_id=user1 url=https://example.com/a",
+		"garbage",
+		"visitor_id=bad! url=https://example.com/a",
+	}
+	for _, message := range messages {
+		if _, err := conn.Write([]byte(message)); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+
+	waitForCount(t, func() bool { return listener.Stats().Received == 3 })
+
+	stats := listener.Stats()
+	if stats.Accepted != 1 || stats.Malformed != 1 || stats.Rejected != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestListener_TCP(t *testing.T) {
+	tracker := &recordingTracker{}
+	config := models.DefaultConfiguration().Syslog
+	config.Network = "tcp"
+	config.Address = "127.0.0.1:0"
+
+	listener := NewListener(config, tracker)
+	if err := listener.Start(); err != nil {
+		t.Fatalf("Failed to start listener: %v", err)
+	}
+
+	conn, err := net.Dial("tcp", listener.Addr())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	payload := "visitor_id=user1 url=https://example.com/a\n{\"visitor_id\":\"user2\",\"url\":\"https://example.com/b\"}\n"
+	if _, err := conn.Write([]byte(payload)); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+
+	waitForCount(t, func() bool { return tracker.count() == 2 })
+
+	// Stop must not hang on the still-open client connection.
+	listener.Stop()
+}
@@ -0,0 +1,159 @@
+package syslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+const (
+	FormatAuto = "auto"
+	FormatKV   = "kv"
+	FormatJSON = "json"
+)
+
+// Parser turns a syslog line into a NavigationEvent. The syslog header is not
+// interpreted: JSON payloads start at the first '{', and key=value payloads
+// are any whitespace-separated key=value (or key="quoted value") tokens.
+type Parser struct {
+	format string
+	fields models.SyslogFieldMap
+}
+
+func NewParser(format string, fields models.SyslogFieldMap) *Parser {
+	if format == "" {
+		format = FormatAuto
+	}
+	return &Parser{format: format, fields: fields}
+}
+
+func (p *Parser) Parse(line string) (*models.NavigationEvent, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, fmt.Errorf("empty line")
+	}
+
+	var values map[string]string
+	var err error
+
+	switch p.format {
+	case FormatJSON:
+		values, err = parseJSONPayload(line)
+	case FormatKV:
+		values, err = parseKVPayload(line)
+	default:
+		if strings.Contains(line, "{") {
+			values, err = parseJSONPayload(line)
+		} else {
+			values, err = parseKVPayload(line)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	event := &models.NavigationEvent{
+		VisitorID: values[p.fields.VisitorID],
+		URL:       values[p.fields.URL],
+		SessionID: values[p.fields.SessionID],
+	}
+
+	if raw := values[p.fields.Timestamp]; raw != "" {
+		timestamp, err := parseTimestamp(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", p.fields.Timestamp, err)
+		}
+		event.Timestamp = timestamp
+	}
+
+	if event.VisitorID == "" || event.URL == "" {
+		return nil, fmt.Errorf("missing %s or %s", p.fields.VisitorID, p.fields.URL)
+	}
+
+	return event, nil
+}
+
+func parseJSONPayload(line string) (map[string]string, error) {
+	start := strings.Index(line, "{")
+	if start < 0 {
+		return nil, fmt.Errorf("no JSON payload")
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(line[start:]), &raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON payload: %w", err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch v := value.(type) {
+		case string:
+			values[key] = v
+		case float64:
+			values[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+
+	return values, nil
+}
+
+func parseKVPayload(line string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	for i := 0; i < len(line); {
+		for i < len(line) && line[i] == ' ' {
+			i++
+		}
+
+		start := i
+		for i < len(line) && line[i] != ' ' && line[i] != '=' {
+			i++
+		}
+		if i >= len(line) || line[i] != '=' {
+			// Header token such as "<34>Oct" or "host"; skip it.
+			for i < len(line) && line[i] != ' ' {
+				i++
+			}
+			continue
+		}
+		key := line[start:i]
+		i++
+
+		var value string
+		if i < len(line) && line[i] == '"' {
+			end := strings.IndexByte(line[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted value for %s", key)
+			}
+			value = line[i+1 : i+1+end]
+			i += end + 2
+		} else {
+			valueStart := i
+			for i < len(line) && line[i] != ' ' {
+				i++
+			}
+			value = line[valueStart:i]
+		}
+
+		if key != "" {
+			values[key] = value
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no key=value pairs")
+	}
+
+	return values, nil
+}
+
+func parseTimestamp(raw string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
@@ -0,0 +1,74 @@
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func defaultFields() models.SyslogFieldMap {
+	return models.DefaultConfiguration().Syslog.Fields
+}
+
+func TestParser_KeyValue(t *testing.T) {
+	parser := NewParser(FormatAuto, defaultFields())
+
+	event, err := parser.Parse(`<34>Oct 11 22:14:15 web01 nav: visitor_id=user1 url="https://example.com/a" timestamp=1704067200`)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	if event.VisitorID != "user1" || event.URL != "https://example.com/a" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+
+	if !event.Timestamp.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected timestamp: %v", event.Timestamp)
+	}
+}
+
+func TestParser_JSON(t *testing.T) {
+	parser := NewParser(FormatAuto, defaultFields())
+
+	event, err := parser.Parse(`<165>1 2024-01-01T00:00:00Z host app - - - {"visitor_id":"user2","url":"https://example.com/b","session_id":"s1"}`)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	if event.VisitorID != "user2" || event.SessionID != "s1" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+}
+
+func TestParser_CustomTemplate(t *testing.T) {
+	parser := NewParser(FormatKV, models.SyslogFieldMap{VisitorID: "uid", URL: "page"})
+
+	event, err := parser.Parse(`uid=abc page=https://example.com/c`)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	if event.VisitorID != "abc" || event.URL != "https://example.com/c" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+}
+
+func TestParser_Malformed(t *testing.T) {
+	parser := NewParser(FormatAuto, defaultFields())
+
+	lines := []string{
+		"",
+		"just some text",
+		`visitor_id=user1`,
+		`{"visitor_id": "user1", "url":`,
+		`visitor_id=user1 url="https://example.com/unterminated`,
+		`visitor_id=user1 url=https://example.com/a timestamp=yesterday`,
+	}
+
+	for _, line := range lines {
+		if _, err := parser.Parse(line); err == nil {
+			t.Errorf("Expected error parsing %q", line)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"nav-tracker/pkg/clientip"
+	"nav-tracker/pkg/monitoring"
+)
+
+// IngestActivity counts each request in activity by API key and the client
+// IP resolver resolves, treating responses below 400 as accepted.
+func IngestActivity(activity *monitoring.IngestActivity, resolver *clientip.Resolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			activity.Record(r.Header.Get(APIKeyHeader), clientIP(resolver, r), recorder.status < http.StatusBadRequest, time.Now())
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/clientip"
+	"nav-tracker/pkg/monitoring"
+)
+
+func TestIngestActivity_Middleware(t *testing.T) {
+	activity := monitoring.NewIngestActivity(time.Hour)
+	status := http.StatusCreated
+	handler := IngestActivity(activity, clientip.NewResolver([]string{"10.0.0.0/8"}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+
+	for _, code := range []int{http.StatusCreated, http.StatusBadRequest} {
+		status = code
+		req := httptest.NewRequest("POST", "/ingest", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		req.Header.Set(APIKeyHeader, "key1")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	buckets := activity.Query(time.Now().Add(-time.Minute), time.Now().Add(time.Minute), "key1")
+	if len(buckets) != 1 {
+		t.Fatalf("Expected 1 bucket, got %d", len(buckets))
+	}
+
+	if buckets[0].IP != "203.0.113.7" || buckets[0].Accepted != 1 || buckets[0].Rejected != 1 {
+		t.Errorf("Unexpected bucket: %+v", buckets[0])
+	}
+}
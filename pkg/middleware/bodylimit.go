@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"nav-tracker/pkg/models"
+)
+
+// ErrorCodeRequestTooLarge is the error code of responses to bodies over
+// their endpoint's limit.
+const ErrorCodeRequestTooLarge = "REQUEST_TOO_LARGE"
+
+// BodyLimit caps request bodies at the limit config sets for their path. A
+// declared Content-Length over the limit is rejected before the handler runs;
+// other bodies, including chunked ones, are wrapped in http.MaxBytesReader,
+// and once a handler reads past the limit its response is replaced with the
+// same 413, unless it has already started writing one.
+func BodyLimit(config models.BodyLimitConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := config.Limit(r.URL.Path)
+			if limit == 0 || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.ContentLength > limit {
+				respondTooLarge(w, limit)
+				return
+			}
+
+			lw := &limitedWriter{ResponseWriter: w, limit: limit}
+			body := &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, limit), writer: lw}
+			r.Body = body
+			next.ServeHTTP(lw, r)
+		})
+	}
+}
+
+func respondTooLarge(w http.ResponseWriter, limit int64) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Connection", "close")
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	_ = json.NewEncoder(w).Encode(models.ErrorResponse{
+		Error: "Request body exceeds the limit of " + strconv.FormatInt(limit, 10) + " bytes",
+		Code:  ErrorCodeRequestTooLarge,
+	})
+}
+
+// limitedBody notes on its writer when a read went past the limit.
+type limitedBody struct {
+	io.ReadCloser
+	writer *limitedWriter
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		b.writer.exceeded = true
+	}
+	return n, err
+}
+
+// limitedWriter replaces the handler's response with a 413 once the body
+// limit was exceeded, discarding whatever the handler writes instead.
+type limitedWriter struct {
+	http.ResponseWriter
+	limit       int64
+	exceeded    bool
+	wroteHeader bool
+	discard     bool
+}
+
+func (lw *limitedWriter) WriteHeader(status int) {
+	if lw.wroteHeader {
+		return
+	}
+	lw.wroteHeader = true
+
+	if lw.exceeded {
+		lw.discard = true
+		// Drop headers describing the handler's own body
+		lw.Header().Del("Content-Length")
+		lw.Header().Del("Content-Encoding")
+		respondTooLarge(lw.ResponseWriter, lw.limit)
+		return
+	}
+	lw.ResponseWriter.WriteHeader(status)
+}
+
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	if !lw.wroteHeader {
+		lw.WriteHeader(http.StatusOK)
+	}
+	if lw.discard {
+		return len(p), nil
+	}
+	return lw.ResponseWriter.Write(p)
+}
+
+func (lw *limitedWriter) Flush() {
+	if flusher, ok := lw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (lw *limitedWriter) Unwrap() http.ResponseWriter {
+	return lw.ResponseWriter
+}
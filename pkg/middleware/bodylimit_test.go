@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestBodyLimit(t *testing.T) {
+	config := models.BodyLimitConfig{Default: 16, Endpoints: map[string]int64{"/small": 4, "/unlimited": 0}}
+	// The handler answers 400 when it cannot read the whole body, as the
+	// JSON handlers do
+	handler := BodyLimit(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "bad body", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+
+	tests := []struct {
+		path    string
+		body    string
+		chunked bool
+		status  int
+	}{
+		{"/ingest", strings.Repeat("a", 16), false, http.StatusOK},
+		{"/ingest", strings.Repeat("a", 17), false, http.StatusRequestEntityTooLarge},
+		{"/ingest", strings.Repeat("a", 17), true, http.StatusRequestEntityTooLarge},
+		{"/small", "abcde", true, http.StatusRequestEntityTooLarge},
+		{"/small", "abcd", true, http.StatusOK},
+		{"/unlimited", strings.Repeat("a", 100), true, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+		if tt.chunked {
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("Expected status %d for %d bytes to %s (chunked=%v), got %d", tt.status, len(tt.body), tt.path, tt.chunked, w.Code)
+			continue
+		}
+		if w.Code == http.StatusOK {
+			if w.Body.String() != tt.body {
+				t.Errorf("Expected the body to reach the handler, got %q", w.Body.String())
+			}
+			continue
+		}
+
+		var response models.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Code != ErrorCodeRequestTooLarge {
+			t.Errorf("Expected a %s error, got %q", ErrorCodeRequestTooLarge, w.Body.String())
+		}
+	}
+}
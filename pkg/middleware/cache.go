@@ -0,0 +1,254 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// ResponseCache keeps the responses of read endpoints for a TTL. Each entry
+// belongs to the tracked URL it describes, or to every URL when its scope is
+// empty, and is dropped when an event for that URL is recorded.
+type ResponseCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mutex    sync.Mutex
+	entries  map[string]*cachedResponse
+	scopes   map[string]map[string]bool
+	inflight map[*pendingResponse]bool
+}
+
+type cachedResponse struct {
+	status  int
+	header  http.Header
+	body    []byte
+	etag    string
+	scope   string
+	expires time.Time
+}
+
+// pendingResponse marks a response being computed, so that an invalidation
+// arriving meanwhile keeps it from being cached.
+type pendingResponse struct {
+	scope string
+	stale bool
+}
+
+// NewResponseCache returns nil when caching is disabled; Cached still adds
+// ETags with a nil cache.
+func NewResponseCache(config models.ResponseCacheConfig) *ResponseCache {
+	if !config.Enabled {
+		return nil
+	}
+	return &ResponseCache{
+		ttl:        config.TTL,
+		maxEntries: config.MaxEntries,
+		entries:    make(map[string]*cachedResponse),
+		scopes:     make(map[string]map[string]bool),
+		inflight:   make(map[*pendingResponse]bool),
+	}
+}
+
+// Invalidate drops the responses for url and those covering every URL.
+func (c *ResponseCache) Invalidate(url string) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.dropScope(url)
+	c.dropScope("")
+	for pending := range c.inflight {
+		if pending.scope == url || pending.scope == "" {
+			pending.stale = true
+		}
+	}
+}
+
+// dropScope removes the entries in scope; the caller must hold the lock.
+func (c *ResponseCache) dropScope(scope string) {
+	for key := range c.scopes[scope] {
+		delete(c.entries, key)
+	}
+	delete(c.scopes, scope)
+}
+
+// remove deletes key's entry; the caller must hold the lock.
+func (c *ResponseCache) remove(key string) {
+	entry := c.entries[key]
+	if entry == nil {
+		return
+	}
+	delete(c.entries, key)
+	delete(c.scopes[entry.scope], key)
+	if len(c.scopes[entry.scope]) == 0 {
+		delete(c.scopes, entry.scope)
+	}
+}
+
+func (c *ResponseCache) get(key string, now time.Time) *cachedResponse {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry := c.entries[key]
+	if entry != nil && !now.Before(entry.expires) {
+		c.remove(key)
+		return nil
+	}
+	return entry
+}
+
+func (c *ResponseCache) begin(scope string) *pendingResponse {
+	pending := &pendingResponse{scope: scope}
+	c.mutex.Lock()
+	c.inflight[pending] = true
+	c.mutex.Unlock()
+	return pending
+}
+
+// finish stores entry unless its scope was invalidated while it was computed
+// or the cache is full of live entries.
+func (c *ResponseCache) finish(pending *pendingResponse, key string, entry *cachedResponse, now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.inflight, pending)
+	if entry == nil || pending.stale {
+		return
+	}
+
+	if len(c.entries) >= c.maxEntries {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				c.remove(k)
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			return
+		}
+	}
+
+	c.remove(key)
+	entry.expires = now.Add(c.ttl)
+	c.entries[key] = entry
+	if c.scopes[entry.scope] == nil {
+		c.scopes[entry.scope] = make(map[string]bool)
+	}
+	c.scopes[entry.scope][key] = true
+}
+
+// bufferedResponse holds a handler's response until its ETag is known.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (br *bufferedResponse) Header() http.Header {
+	return br.header
+}
+
+func (br *bufferedResponse) WriteHeader(status int) {
+	br.status = status
+}
+
+func (br *bufferedResponse) Write(data []byte) (int, error) {
+	return br.body.Write(data)
+}
+
+// Cached serves GET requests from cache, keyed by path and query, with scope
+// naming the tracked URL a request reads ("" for all of them). Every
+// response carries a strong ETag of its body, and a request whose
+// If-None-Match lists it gets 304 Not Modified. Only 200 responses are
+// cached; X-Cache reports HIT or MISS when cache is non-nil.
+func Cached(cache *ResponseCache, scope func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := r.URL.Path + "?" + r.URL.Query().Encode()
+			if cache != nil {
+				if entry := cache.get(key, time.Now()); entry != nil {
+					w.Header().Set("X-Cache", "HIT")
+					writeCached(w, r, entry)
+					return
+				}
+			}
+
+			var pending *pendingResponse
+			if cache != nil {
+				pending = cache.begin(scope(r))
+			}
+
+			buffered := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+			next.ServeHTTP(buffered, r)
+
+			sum := sha256.Sum256(buffered.body.Bytes())
+			entry := &cachedResponse{
+				status: buffered.status,
+				header: buffered.header,
+				body:   buffered.body.Bytes(),
+				etag:   `"` + hex.EncodeToString(sum[:16]) + `"`,
+			}
+
+			if cache != nil {
+				stored := entry
+				if entry.status != http.StatusOK {
+					stored = nil
+				} else {
+					stored.scope = pending.scope
+				}
+				cache.finish(pending, key, stored, time.Now())
+				w.Header().Set("X-Cache", "MISS")
+			}
+			writeCached(w, r, entry)
+		})
+	}
+}
+
+func writeCached(w http.ResponseWriter, r *http.Request, entry *cachedResponse) {
+	header := w.Header()
+	for name, values := range entry.header {
+		header[name] = values
+	}
+
+	if entry.status != http.StatusOK {
+		w.WriteHeader(entry.status)
+		_, _ = w.Write(entry.body)
+		return
+	}
+
+	header.Set("ETag", entry.etag)
+	if etagMatches(r.Header.Get("If-None-Match"), entry.etag) {
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(entry.body)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, using the
+// weak comparison RFC 9110 requires for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// countingHandler answers with the number of times it has been called.
+func countingHandler(calls *int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		if r.URL.Query().Get("url") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"calls": %d}`, *calls)
+	})
+}
+
+func byURL(r *http.Request) string {
+	return r.URL.Query().Get("url")
+}
+
+func TestCached_ServesAndInvalidates(t *testing.T) {
+	cache := NewResponseCache(models.ResponseCacheConfig{Enabled: true, TTL: time.Minute, MaxEntries: 10})
+	calls := 0
+	handler := Cached(cache, byURL)(countingHandler(&calls))
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		return w
+	}
+
+	first := get("/stats?url=a")
+	second := get("/stats?url=a")
+	if calls != 1 || second.Body.String() != first.Body.String() {
+		t.Errorf("Expected second request to be served from cache, got %d calls", calls)
+	}
+	if first.Header().Get("X-Cache") != "MISS" || second.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("Expected MISS then HIT, got %s and %s", first.Header().Get("X-Cache"), second.Header().Get("X-Cache"))
+	}
+	if second.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected cached Content-Type, got %q", second.Header().Get("Content-Type"))
+	}
+
+	// Events for other URLs leave the entry in place
+	cache.Invalidate("b")
+	get("/stats?url=a")
+	if calls != 1 {
+		t.Errorf("Expected entry to survive another URL's invalidation, got %d calls", calls)
+	}
+
+	cache.Invalidate("a")
+	get("/stats?url=a")
+	if calls != 2 {
+		t.Errorf("Expected invalidated entry to be recomputed, got %d calls", calls)
+	}
+
+	// Errors are not cached
+	get("/stats")
+	get("/stats")
+	if calls != 4 {
+		t.Errorf("Expected error responses not to be cached, got %d calls", calls)
+	}
+}
+
+func TestCached_AllURLScope(t *testing.T) {
+	cache := NewResponseCache(models.ResponseCacheConfig{Enabled: true, TTL: time.Minute, MaxEntries: 10})
+	calls := 0
+	handler := Cached(cache, func(r *http.Request) string { return "" })(countingHandler(&calls))
+
+	for i := 0; i < 2; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/top-urls?url=x", nil))
+		cache.Invalidate("any")
+	}
+	if calls != 2 {
+		t.Errorf("Expected any event to invalidate all-URL entries, got %d calls", calls)
+	}
+}
+
+func TestCached_TTLAndMaxEntries(t *testing.T) {
+	cache := NewResponseCache(models.ResponseCacheConfig{Enabled: true, TTL: time.Minute, MaxEntries: 1})
+	calls := 0
+	handler := Cached(cache, byURL)(countingHandler(&calls))
+
+	for _, target := range []string{"/stats?url=a", "/stats?url=b", "/stats?url=b"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+	}
+	if calls != 3 {
+		t.Errorf("Expected a full cache to store nothing more, got %d calls", calls)
+	}
+
+	key := "/stats?url=a"
+	if cache.get(key, time.Now()) == nil {
+		t.Fatal("Expected entry to be cached")
+	}
+	if cache.get(key, time.Now().Add(time.Minute)) != nil {
+		t.Error("Expected entry to expire after the TTL")
+	}
+}
+
+func TestCached_ETag(t *testing.T) {
+	for _, cache := range []*ResponseCache{nil, NewResponseCache(models.ResponseCacheConfig{Enabled: true, TTL: time.Minute, MaxEntries: 10})} {
+		calls := 0
+		handler := Cached(cache, byURL)(countingHandler(&calls))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/stats?url=a", nil))
+		etag := w.Header().Get("ETag")
+		if etag == "" {
+			t.Fatal("Expected an ETag")
+		}
+
+		req := httptest.NewRequest("GET", "/stats?url=a", nil)
+		req.Header.Set("If-None-Match", `"other", W/`+etag)
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		// Without a cache the handler runs again and produces a new body
+		expected := http.StatusNotModified
+		if cache == nil {
+			expected = http.StatusOK
+		}
+		if w.Code != expected {
+			t.Errorf("Expected status %d with cache %v, got %d", expected, cache != nil, w.Code)
+		}
+		if w.Code == http.StatusNotModified && w.Body.Len() != 0 {
+			t.Error("Expected empty 304 body")
+		}
+	}
+}
+
+func TestCached_ETagWithoutCache(t *testing.T) {
+	handler := Cached(nil, byURL)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"distinct_visitors": 3}`)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/stats?url=a", nil))
+
+	req := httptest.NewRequest("GET", "/stats?url=a", nil)
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected status %d, got %d", http.StatusNotModified, w.Code)
+	}
+	if w.Header().Get("X-Cache") != "" {
+		t.Errorf("Expected no X-Cache header without a cache, got %q", w.Header().Get("X-Cache"))
+	}
+}
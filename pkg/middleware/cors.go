@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"nav-tracker/pkg/models"
+)
+
+// CORSPolicy holds the CORS configuration in effect, which can be replaced
+// while requests are being served.
+type CORSPolicy struct {
+	config atomic.Pointer[models.CORSConfig]
+}
+
+func NewCORSPolicy(config models.CORSConfig) *CORSPolicy {
+	p := &CORSPolicy{}
+	p.Update(config)
+	return p
+}
+
+// Update replaces the policy for subsequent requests.
+func (p *CORSPolicy) Update(config models.CORSConfig) {
+	p.config.Store(&config)
+}
+
+// Config returns the policy in effect.
+func (p *CORSPolicy) Config() models.CORSConfig {
+	return *p.config.Load()
+}
+
+// Allows reports whether the policy in effect allows origin.
+func (p *CORSPolicy) Allows(origin string) bool {
+	return allowedOrigin(p.config.Load(), origin) != ""
+}
+
+// allowedOrigin returns the Access-Control-Allow-Origin value for origin, or
+// "" if origin is not allowed. A wildcard is echoed back as the origin when
+// credentials are allowed, since browsers reject "*" with credentials.
+func allowedOrigin(config *models.CORSConfig, origin string) string {
+	for _, allowed := range config.AllowedOrigins {
+		switch {
+		case allowed == "*":
+			if config.AllowCredentials {
+				return origin
+			}
+			return "*"
+		case allowed == origin:
+			return origin
+		case strings.Contains(allowed, "://*."):
+			// scheme://*.domain matches scheme://sub.domain at any depth
+			scheme, domain, _ := strings.Cut(allowed, "://*.")
+			if originScheme, host, ok := strings.Cut(origin, "://"); ok && originScheme == scheme && strings.HasSuffix(host, "."+domain) {
+				return origin
+			}
+		}
+	}
+	return ""
+}
+
+// CORS sets CORS response headers for allowed origins and answers preflight
+// requests itself. Requests from other origins are served without CORS
+// headers, which makes browsers withhold the response from the page.
+func CORS(policy *CORSPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			config := policy.config.Load()
+			header := w.Header()
+			header.Add("Vary", "Origin")
+
+			allowed := allowedOrigin(config, origin)
+			if allowed != "" {
+				header.Set("Access-Control-Allow-Origin", allowed)
+				if config.AllowCredentials {
+					header.Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				if allowed != "" {
+					header.Set("Access-Control-Allow-Methods", strings.Join(config.AllowedMethods, ", "))
+					header.Set("Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ", "))
+					if config.MaxAge > 0 {
+						header.Set("Access-Control-Max-Age", strconv.Itoa(int(config.MaxAge.Seconds())))
+					}
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestCORS_AllowedOrigins(t *testing.T) {
+	policy := NewCORSPolicy(models.CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type"},
+	})
+	handler := CORS(policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		origin   string
+		expected string
+	}{
+		{"https://app.example.com", "https://app.example.com"},
+		{"https://evil.example.com", ""},
+		{"https://a.b.example.org", "https://a.b.example.org"},
+		{"https://example.org", ""},
+		{"http://a.example.org", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/v1/top-urls", nil)
+		req.Header.Set("Origin", tt.origin)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.expected {
+			t.Errorf("Expected Access-Control-Allow-Origin %q for %s, got %q", tt.expected, tt.origin, got)
+		}
+	}
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	policy := NewCORSPolicy(models.CORSConfig{
+		AllowedOrigins:   []string{"*"},
+		AllowedMethods:   []string{"GET", "PUT"},
+		AllowedHeaders:   []string{"Content-Type", "X-API-Key"},
+		AllowCredentials: true,
+		MaxAge:           time.Minute,
+	})
+	called := false
+	handler := CORS(policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+
+	req := httptest.NewRequest("OPTIONS", "/api/v1/config", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "PUT")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if called {
+		t.Error("Expected preflight not to reach the handler")
+	}
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	header := w.Header()
+	if header.Get("Access-Control-Allow-Origin") != "https://app.example.com" || header.Get("Access-Control-Allow-Credentials") != "true" {
+		t.Errorf("Expected wildcard to echo the origin with credentials, got %v", header)
+	}
+	if header.Get("Access-Control-Allow-Methods") != "GET, PUT" || header.Get("Access-Control-Max-Age") != "60" {
+		t.Errorf("Unexpected preflight headers: %v", header)
+	}
+}
+
+func TestCORS_Update(t *testing.T) {
+	policy := NewCORSPolicy(models.CORSConfig{})
+	handler := CORS(policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	request := func() string {
+		req := httptest.NewRequest("GET", "/stats", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	if got := request(); got != "" {
+		t.Errorf("Expected no CORS headers without allowed origins, got %q", got)
+	}
+	policy.Update(models.CORSConfig{AllowedOrigins: []string{"*"}})
+	if got := request(); got != "*" {
+		t.Errorf("Expected updated policy to allow any origin, got %q", got)
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/format"
+	"nav-tracker/pkg/models"
+)
+
+// ResponseFormat resolves each request's response format from defaults and
+// the duration_format and precision query parameters, rejecting invalid
+// parameters with 400.
+func ResponseFormat(defaults models.ResponseFormatConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			f, err := format.FromQuery(defaults, r.URL.Query())
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid response format: "+err.Error())
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(format.NewContext(r.Context(), f)))
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/format"
+	"nav-tracker/pkg/models"
+)
+
+func TestResponseFormat(t *testing.T) {
+	defaults := models.DefaultConfiguration().ResponseFormat
+
+	var got models.ResponseFormatConfig
+	handler := ResponseFormat(defaults)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = format.FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/api/v1/system-stats?duration_format=string&precision=2", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if got.DurationFormat != models.DurationFormatString || got.Precision != 2 {
+		t.Errorf("Expected string durations with precision 2, got %+v", got)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/system-stats?precision=abc", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"nav-tracker/pkg/monitoring"
+)
+
+// RequestMetrics records each request in collector under the mux pattern that
+// matched it rather than its raw path, so IDs in paths never create new
+// endpoints. Requests no pattern matches are counted as OtherEndpoint.
+func RequestMetrics(collector *monitoring.MetricsCollector, mux *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			endpoint := monitoring.OtherEndpoint
+			if _, pattern := mux.Handler(r); pattern != "" {
+				endpoint = pattern
+			}
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			start := time.Now()
+			next.ServeHTTP(recorder, r)
+
+			collector.RecordRequest(endpoint, time.Since(start), recorder.status)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nav-tracker/pkg/monitoring"
+)
+
+func TestRequestMetrics_UsesRoutePattern(t *testing.T) {
+	collector := monitoring.NewMetricsCollector()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/visitors/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := RequestMetrics(collector, mux)(mux)
+
+	for _, path := range []string{"/api/v1/visitors/v1", "/api/v1/visitors/v2", "/api/v1/visitors/v3"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/no/such/route", nil))
+
+	metrics := collector.GetMetrics()
+
+	if visitors := metrics.EndpointMetrics["/api/v1/visitors/"]; visitors == nil || visitors.RequestCount != 3 {
+		t.Errorf("Expected 3 requests under the route pattern, got %+v", visitors)
+	}
+
+	if other := metrics.EndpointMetrics[monitoring.OtherEndpoint]; other == nil || other.ErrorCount != 1 {
+		t.Errorf("Expected unmatched request counted as an error under %s, got %+v", monitoring.OtherEndpoint, other)
+	}
+
+	if len(metrics.EndpointMetrics) != 2 {
+		t.Errorf("Expected 2 endpoints, got %d", len(metrics.EndpointMetrics))
+	}
+}
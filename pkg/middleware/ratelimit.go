@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/clientip"
+	"nav-tracker/pkg/models"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+
+	bucketIdleTTL      = 10 * time.Minute
+	bucketPruneEvery   = time.Minute
+	maxRetryAfterDelay = time.Hour
+)
+
+// TokenBucketLimiter keeps one token bucket per key. Buckets refill at rate
+// tokens per second up to burst, and idle buckets are pruned lazily.
+type TokenBucketLimiter struct {
+	rate      float64
+	burst     int
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+	now       func() time.Time
+	mutex     sync.Mutex
+}
+
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+func NewTokenBucketLimiter(rate float64, burst int) *TokenBucketLimiter {
+	return &TokenBucketLimiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// Decision is the outcome of taking a token from a bucket. Reset is how long
+// until the bucket has refilled to its full burst.
+type Decision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+// Allow consumes a token for key. It returns whether the request is allowed,
+// the tokens left afterwards, and how long to wait before retrying if denied.
+func (l *TokenBucketLimiter) Allow(key string) (bool, int, time.Duration) {
+	decision := l.Take(key)
+	return decision.Allowed, decision.Remaining, decision.RetryAfter
+}
+
+// Take consumes a token for key and reports the resulting bucket state.
+func (l *TokenBucketLimiter) Take(key string) Decision {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	bucket := l.buckets[key]
+	if bucket == nil {
+		bucket = &tokenBucket{tokens: float64(l.burst), lastSeen: now}
+		l.buckets[key] = bucket
+	}
+
+	elapsed := now.Sub(bucket.lastSeen).Seconds()
+	bucket.tokens = math.Min(float64(l.burst), bucket.tokens+elapsed*l.rate)
+	bucket.lastSeen = now
+
+	decision := Decision{Limit: l.burst}
+	if bucket.tokens < 1 {
+		decision.RetryAfter = l.refillTime(1 - bucket.tokens)
+	} else {
+		bucket.tokens--
+		decision.Allowed = true
+		decision.Remaining = int(bucket.tokens)
+	}
+	decision.Reset = l.refillTime(float64(l.burst) - bucket.tokens)
+
+	return decision
+}
+
+// refillTime returns how long the bucket takes to gain tokens.
+func (l *TokenBucketLimiter) refillTime(tokens float64) time.Duration {
+	if l.rate <= 0 {
+		return maxRetryAfterDelay
+	}
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+func (l *TokenBucketLimiter) Limit() int {
+	return l.burst
+}
+
+func (l *TokenBucketLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < bucketPruneEvery {
+		return
+	}
+	l.lastPrune = now
+
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.lastSeen) > bucketIdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimit enforces per client IP token buckets and, for requests whose
+// X-API-Key is one of knownKeys, per key buckets as well, setting rate limit
+// headers from the emptier bucket on every response and replying 429 with
+// Retry-After when a bucket is empty. Other API keys are limited by IP
+// alone, so made-up keys cannot buy a fresh bucket. Client IPs are resolved
+// by resolver, so clients behind trusted proxies get buckets of their own.
+func RateLimit(config models.RateLimitConfig, knownKeys map[string]string, resolver *clientip.Resolver) func(http.Handler) http.Handler {
+	ipLimiter := NewTokenBucketLimiter(config.IPRequestsPerSecond, config.IPBurst)
+	keyLimiter := NewTokenBucketLimiter(config.KeyRequestsPerSecond, config.KeyBurst)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			decision := ipLimiter.Take("ip:" + clientIP(resolver, r))
+			if apiKey := r.Header.Get(APIKeyHeader); decision.Allowed && apiKey != "" {
+				if _, known := knownKeys[apiKey]; known {
+					keyDecision := keyLimiter.Take("key:" + apiKey)
+					if !keyDecision.Allowed || keyDecision.Remaining <= decision.Remaining {
+						decision = keyDecision
+					}
+				}
+			}
+			setRateLimitHeaders(w.Header(), config.Headers, decision)
+
+			if !decision.Allowed {
+				seconds := ceilSeconds(decision.RetryAfter)
+				if seconds < 1 {
+					seconds = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				respondWithError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// setRateLimitHeaders writes the legacy X-RateLimit-* headers (Reset as a Unix
+// timestamp) and/or the IETF draft RateLimit-* headers (Reset in seconds).
+// An empty style sends both.
+func setRateLimitHeaders(header http.Header, style string, decision Decision) {
+	if style == "" {
+		style = models.RateLimitHeadersBoth
+	}
+
+	limit := strconv.Itoa(decision.Limit)
+	remaining := strconv.Itoa(decision.Remaining)
+
+	if style == models.RateLimitHeadersLegacy || style == models.RateLimitHeadersBoth {
+		header.Set("X-RateLimit-Limit", limit)
+		header.Set("X-RateLimit-Remaining", remaining)
+		header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(decision.Reset).Unix(), 10))
+	}
+
+	if style == models.RateLimitHeadersDraft || style == models.RateLimitHeadersBoth {
+		header.Set("RateLimit-Limit", limit)
+		header.Set("RateLimit-Remaining", remaining)
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// clientIP returns the IP resolver resolves for r, or RemoteAddr as given
+// when it does not parse.
+func clientIP(resolver *clientip.Resolver, r *http.Request) string {
+	if ip := resolver.ClientIP(r); ip != nil {
+		return ip.String()
+	}
+	return r.RemoteAddr
+}
+
+func respondWithError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(models.ErrorResponse{Error: message})
+}
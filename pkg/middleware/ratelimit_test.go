@@ -0,0 +1,263 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/clientip"
+	"nav-tracker/pkg/models"
+)
+
+func TestTokenBucketLimiter_Allow(t *testing.T) {
+	limiter := NewTokenBucketLimiter(1, 2)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	allowed, remaining, _ := limiter.Allow("client")
+	if !allowed || remaining != 1 {
+		t.Errorf("Expected first request allowed with 1 remaining, got %v/%d", allowed, remaining)
+	}
+
+	allowed, remaining, _ = limiter.Allow("client")
+	if !allowed || remaining != 0 {
+		t.Errorf("Expected second request allowed with 0 remaining, got %v/%d", allowed, remaining)
+	}
+
+	allowed, _, retryAfter := limiter.Allow("client")
+	if allowed {
+		t.Error("Expected third request to be limited")
+	}
+	if retryAfter != time.Second {
+		t.Errorf("Expected retry after 1s, got %v", retryAfter)
+	}
+
+	allowed, _, _ = limiter.Allow("other")
+	if !allowed {
+		t.Error("Expected a different key to have its own bucket")
+	}
+
+	now = now.Add(time.Second)
+	allowed, _, _ = limiter.Allow("client")
+	if !allowed {
+		t.Error("Expected request allowed after refill")
+	}
+}
+
+func TestTokenBucketLimiter_PrunesIdleBuckets(t *testing.T) {
+	limiter := NewTokenBucketLimiter(1, 1)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	limiter.Allow("client")
+	now = now.Add(bucketIdleTTL + bucketPruneEvery)
+	limiter.Allow("other")
+
+	if _, exists := limiter.buckets["client"]; exists {
+		t.Error("Expected idle bucket to be pruned")
+	}
+}
+
+func TestTokenBucketLimiter_TakeReset(t *testing.T) {
+	limiter := NewTokenBucketLimiter(2, 4)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	decision := limiter.Take("client")
+	if decision.Limit != 4 || decision.Remaining != 3 || decision.Reset != 500*time.Millisecond {
+		t.Errorf("Expected limit 4, remaining 3, reset 500ms, got %+v", decision)
+	}
+
+	limiter.Take("client")
+	decision = limiter.Take("client")
+	if decision.Remaining != 1 || decision.Reset != 1500*time.Millisecond {
+		t.Errorf("Expected remaining 1, reset 1.5s, got %+v", decision)
+	}
+
+	now = now.Add(time.Second)
+	decision = limiter.Take("client")
+	if decision.Remaining != 2 || decision.Reset != time.Second {
+		t.Errorf("Expected refill to remaining 2, reset 1s, got %+v", decision)
+	}
+}
+
+func TestRateLimit_HeadersTrackConsumption(t *testing.T) {
+	config := models.DefaultConfiguration().RateLimit
+	config.IPRequestsPerSecond = 1
+	config.IPBurst = 3
+
+	handler := RateLimit(config, nil, clientip.NewResolver(nil))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i, want := range []struct{ remaining, reset string }{{"2", "1"}, {"1", "2"}, {"0", "3"}} {
+		req := httptest.NewRequest("GET", "/stats", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if got := w.Header().Get("RateLimit-Remaining"); got != want.remaining {
+			t.Errorf("Request %d: expected RateLimit-Remaining %s, got %s", i, want.remaining, got)
+		}
+		if got := w.Header().Get("RateLimit-Reset"); got != want.reset {
+			t.Errorf("Request %d: expected RateLimit-Reset %s, got %s", i, want.reset, got)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != want.remaining {
+			t.Errorf("Request %d: expected X-RateLimit-Remaining %s, got %s", i, want.remaining, got)
+		}
+
+		reset, err := strconv.ParseInt(w.Header().Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil || reset < time.Now().Unix() {
+			t.Errorf("Request %d: expected future X-RateLimit-Reset timestamp, got %q", i, w.Header().Get("X-RateLimit-Reset"))
+		}
+	}
+}
+
+func TestRateLimit_HeaderStyle(t *testing.T) {
+	config := models.DefaultConfiguration().RateLimit
+	config.Headers = models.RateLimitHeadersDraft
+
+	handler := RateLimit(config, nil, clientip.NewResolver(nil))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/stats", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Header().Get("RateLimit-Limit") == "" {
+		t.Error("Expected RateLimit-Limit header for draft style")
+	}
+	if w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Error("Expected no X-RateLimit-Limit header for draft style")
+	}
+}
+
+func TestRateLimit_Middleware(t *testing.T) {
+	config := models.RateLimitConfig{
+		Enabled:              true,
+		IPRequestsPerSecond:  0.001,
+		IPBurst:              2,
+		KeyRequestsPerSecond: 0.001,
+		KeyBurst:             3,
+	}
+
+	handler := RateLimit(config, map[string]string{"key1": "tenant1"}, clientip.NewResolver(nil))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		req := httptest.NewRequest("GET", "/stats", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status %d, got %d", i, http.StatusOK, w.Code)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != wantRemaining {
+			t.Errorf("Request %d: expected remaining %s, got %s", i, wantRemaining, got)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("Request %d: expected limit 2, got %s", i, got)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/stats", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After header on 429")
+	}
+
+	// Neither a known nor a made-up key escapes the IP bucket
+	for _, apiKey := range []string{"key1", "random"} {
+		req = httptest.NewRequest("GET", "/stats", nil)
+		req.Header.Set(APIKeyHeader, apiKey)
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected API key %s to be limited by its IP, got status %d", apiKey, w.Code)
+		}
+	}
+}
+
+func TestRateLimit_KnownKeyBucket(t *testing.T) {
+	config := models.RateLimitConfig{
+		Enabled:              true,
+		IPRequestsPerSecond:  0.001,
+		IPBurst:              10,
+		KeyRequestsPerSecond: 0.001,
+		KeyBurst:             2,
+	}
+
+	handler := RateLimit(config, map[string]string{"key1": "tenant1"}, clientip.NewResolver(nil))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(apiKey, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/stats", nil)
+		req.RemoteAddr = ip + ":1234"
+		req.Header.Set(APIKeyHeader, apiKey)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// A known key is limited across IPs, and reports its own bucket
+	for i, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		w := serve("key1", ip)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status %d, got %d", i, http.StatusOK, w.Code)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("Request %d: expected key limit 2, got %s", i, got)
+		}
+	}
+	if w := serve("key1", "10.0.0.3"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the known key's bucket to be empty, got status %d", w.Code)
+	}
+
+	// Unknown keys share their IP's bucket, however many are made up
+	for i := 0; i < 5; i++ {
+		if w := serve(fmt.Sprintf("random%d", i), "10.0.0.4"); w.Header().Get("X-RateLimit-Limit") != "10" || w.Header().Get("X-RateLimit-Remaining") != strconv.Itoa(9-i) {
+			t.Errorf("Request %d: expected the IP bucket, got limit %s remaining %s", i, w.Header().Get("X-RateLimit-Limit"), w.Header().Get("X-RateLimit-Remaining"))
+		}
+	}
+}
+
+func TestRateLimit_ClientsBehindTrustedProxy(t *testing.T) {
+	config := models.RateLimitConfig{
+		Enabled:             true,
+		IPRequestsPerSecond: 0.001,
+		IPBurst:             1,
+	}
+
+	handler := RateLimit(config, nil, clientip.NewResolver([]string{"10.0.0.0/8"}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(forwardedFor string) int {
+		req := httptest.NewRequest("GET", "/stats", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := request("203.0.113.7"); code != http.StatusOK {
+		t.Fatalf("Expected the first client allowed, got %d", code)
+	}
+	if code := request("203.0.113.8"); code != http.StatusOK {
+		t.Errorf("Expected a second client behind the proxy to get its own bucket, got %d", code)
+	}
+	if code := request("203.0.113.7"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected the first client limited, got %d", code)
+	}
+}
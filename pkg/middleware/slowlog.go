@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"nav-tracker/pkg/monitoring"
+	"nav-tracker/pkg/storage"
+)
+
+// statusRecorder captures the response status while still letting streaming
+// handlers flush.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	sr.status = status
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func (sr *statusRecorder) Flush() {
+	if flusher, ok := sr.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets WebSocket upgrades through wrapped handlers.
+func (sr *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := sr.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	return hijacker.Hijack()
+}
+
+// SlowQueryLog times each request and records it in slowLog, together with
+// the lock wait and matched URL count the tracker reports through the
+// request's QueryTrace, when it exceeds the log's threshold.
+func SlowQueryLog(slowLog *monitoring.SlowLog) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			trace := &storage.QueryTrace{}
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			start := time.Now()
+			next.ServeHTTP(recorder, r.WithContext(storage.WithQueryTrace(r.Context(), trace)))
+			duration := time.Since(start)
+
+			if duration < slowLog.Threshold() {
+				return
+			}
+
+			params := make(map[string]string)
+			for key, values := range r.URL.Query() {
+				if len(values) > 0 {
+					params[key] = values[0]
+				}
+			}
+
+			slowLog.Record(monitoring.SlowQueryEntry{
+				Timestamp:   start.UTC(),
+				Endpoint:    r.URL.Path,
+				Params:      params,
+				StatusCode:  recorder.status,
+				Duration:    duration,
+				LockWait:    trace.LockWait,
+				MatchedURLs: trace.MatchedURLs,
+			})
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/monitoring"
+	"nav-tracker/pkg/storage"
+)
+
+func TestSlowQueryLog_RecordsSlowRequests(t *testing.T) {
+	slowLog := monitoring.NewSlowLog(10*time.Millisecond, 10)
+
+	handler := SlowQueryLog(slowLog)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace := storage.QueryTraceFromContext(r.Context())
+		if trace == nil {
+			t.Fatal("Expected query trace in request context")
+		}
+		trace.MatchedURLs = 42
+		trace.LockWait = 5 * time.Millisecond
+
+		if r.URL.Query().Get("slow") == "true" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	req := httptest.NewRequest("GET", "/api/v1/export?url_prefix=https://example.com", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if _, total := slowLog.Entries(); total != 0 {
+		t.Errorf("Expected fast request not to be logged, got %d entries", total)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/export?url_prefix=https://example.com&slow=true", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries, _ := slowLog.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 slow entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Endpoint != "/api/v1/export" || entry.Params["url_prefix"] != "https://example.com" {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+
+	if entry.MatchedURLs != 42 || entry.LockWait != 5*time.Millisecond || entry.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected trace details and status in entry, got %+v", entry)
+	}
+}
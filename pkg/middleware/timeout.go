@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"nav-tracker/pkg/models"
+)
+
+// ErrorCodeRequestTimeout is the error code of responses to requests whose
+// handler ran past its deadline.
+const ErrorCodeRequestTimeout = "REQUEST_TIMEOUT"
+
+// Timeout runs handlers with the deadline config sets for the request path
+// on their context. The handler's response is buffered; if the deadline
+// passes first, the client gets 503 with the REQUEST_TIMEOUT code instead
+// and anything the handler writes afterwards is discarded. Handlers keep
+// running until they notice the cancelled context, so paths that stream
+// their response should have no deadline.
+func Timeout(config models.HandlerTimeoutConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := config.Timeout(r.URL.Path)
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.mutex.Lock()
+				defer tw.mutex.Unlock()
+				for key, values := range tw.header {
+					w.Header()[key] = values
+				}
+				if tw.status == 0 {
+					tw.status = http.StatusOK
+				}
+				w.WriteHeader(tw.status)
+				w.Write(tw.body.Bytes())
+			case <-ctx.Done():
+				tw.mutex.Lock()
+				defer tw.mutex.Unlock()
+				tw.timedOut = true
+				if r.Context().Err() != nil {
+					// The client went away; there is no one to answer
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_ = json.NewEncoder(w).Encode(models.ErrorResponse{
+					Error: "Request timed out after " + timeout.String(),
+					Code:  ErrorCodeRequestTimeout,
+				})
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers a response until the handler finishes, and refuses
+// writes once the deadline has passed.
+type timeoutWriter struct {
+	mutex    sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+	tw.status = status
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.body.Write(p)
+}
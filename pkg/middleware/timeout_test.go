@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestTimeout(t *testing.T) {
+	config := models.HandlerTimeoutConfig{Default: 20 * time.Millisecond, Endpoints: map[string]time.Duration{"/unbounded": 0}}
+	cancelled := make(chan bool, 1)
+	handler := Timeout(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("slow") == "" {
+			w.Header().Set("X-Handler", "done")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("ok"))
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			cancelled <- true
+		case <-time.After(100 * time.Millisecond):
+			cancelled <- false
+		}
+		_, _ = w.Write([]byte("too late"))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+	if w.Code != http.StatusCreated || w.Body.String() != "ok" || w.Header().Get("X-Handler") != "done" {
+		t.Errorf("Expected the handler's response, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/slow?slow=1", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	var response models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Code != ErrorCodeRequestTimeout {
+		t.Errorf("Expected a %s error, got %s", ErrorCodeRequestTimeout, w.Body.String())
+	}
+	if !<-cancelled {
+		t.Error("Expected the handler's context to be cancelled")
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/unbounded?slow=1", nil))
+	<-cancelled
+	if w.Code != http.StatusOK || w.Body.String() != "too late" {
+		t.Errorf("Expected a path without a deadline to finish, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestTimeout_PropagatesPanics(t *testing.T) {
+	handler := Timeout(models.HandlerTimeoutConfig{Default: time.Second})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("Expected the handler's panic, got %v", p)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
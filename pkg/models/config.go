@@ -0,0 +1,1853 @@
+package models
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultSessionIdleTimeout = 30 * time.Minute
+	DefaultURLCategory        = "other"
+	DefaultTenant             = "default"
+)
+
+// Rate limit header styles
+const (
+	RateLimitHeadersLegacy = "legacy"
+	RateLimitHeadersDraft  = "draft"
+	RateLimitHeadersBoth   = "both"
+	RateLimitHeadersNone   = "none"
+)
+
+// Middleware names for MiddlewareConfig chains
+const (
+	MiddlewareCORS           = "cors"
+	MiddlewareRateLimit      = "rate_limit"
+	MiddlewareMetrics        = "metrics"
+	MiddlewareResponseFormat = "response_format"
+	MiddlewareSlowLog        = "slow_log"
+	MiddlewareIngestActivity = "ingest_activity"
+	MiddlewareBodyLimit      = "body_limit"
+	MiddlewareTimeout        = "timeout"
+)
+
+var middlewareNames = map[string]bool{
+	MiddlewareCORS:           true,
+	MiddlewareRateLimit:      true,
+	MiddlewareMetrics:        true,
+	MiddlewareResponseFormat: true,
+	MiddlewareSlowLog:        true,
+	MiddlewareIngestActivity: true,
+	MiddlewareBodyLimit:      true,
+	MiddlewareTimeout:        true,
+}
+
+// Top URL ranking modes
+const (
+	TopURLsExact  = "exact"
+	TopURLsSketch = "sketch"
+)
+
+// Response duration formats
+const (
+	DurationFormatNanoseconds  = "ns"
+	DurationFormatMilliseconds = "ms"
+	DurationFormatString       = "string"
+)
+
+type Configuration struct {
+	Port                string               `json:"port"`
+	HTTP                HTTPConfig           `json:"http"`
+	SessionIdleTimeout  time.Duration        `json:"session_idle_timeout"`
+	RateLimit           RateLimitConfig      `json:"rate_limit"`
+	Middleware          MiddlewareConfig     `json:"middleware"`
+	LegacyRoutes        LegacyRoutesConfig   `json:"legacy_routes"`
+	CORS                CORSConfig           `json:"cors"`
+	BodyLimit           BodyLimitConfig      `json:"body_limit"`
+	HandlerTimeout      HandlerTimeoutConfig `json:"handler_timeout"`
+	Validation          ValidationConfig     `json:"validation"`
+	URLCategories       []CategoryRule       `json:"url_categories"`
+	Dimensions          []DimensionConfig    `json:"dimensions"`
+	TopURLs             TopURLsConfig        `json:"top_urls"`
+	Retention           RetentionConfig      `json:"retention"`
+	Dedup               DedupConfig          `json:"dedup"`
+	Eviction            EvictionConfig       `json:"eviction"`
+	VisitorCap          VisitorCapConfig     `json:"visitor_cap"`
+	Sampling            SamplingConfig       `json:"sampling"`
+	Denylist            DenylistConfig       `json:"denylist"`
+	DataQuality         DataQualityConfig    `json:"data_quality"`
+	Privacy             PrivacyConfig        `json:"privacy"`
+	ClientIP            ClientIPConfig       `json:"client_ip"`
+	Syslog              SyslogConfig         `json:"syslog"`
+	Kafka               KafkaConfig          `json:"kafka"`
+	GeoIP               GeoIPConfig          `json:"geoip"`
+	Redis               RedisConfig          `json:"redis"`
+	Cluster             ClusterConfig        `json:"cluster"`
+	CircuitBreaker      CircuitBreakerConfig `json:"circuit_breaker"`
+	Storage             StorageConfig        `json:"storage"`
+	Heartbeat           HeartbeatConfig      `json:"heartbeat"`
+	Snapshot            SnapshotConfig       `json:"snapshot"`
+	ConfigHistory       ConfigHistoryConfig  `json:"config_history"`
+	Tiering             TieringConfig        `json:"tiering"`
+	Compaction          CompactionConfig     `json:"compaction"`
+	Realtime            RealtimeConfig       `json:"realtime"`
+	Archive             ArchiveConfig        `json:"archive"`
+	Timestamps          TimestampConfig      `json:"timestamps"`
+	Fixtures            FixturesConfig       `json:"fixtures"`
+	Tenancy             TenancyConfig        `json:"tenancy"`
+	Auth                AuthConfig           `json:"auth"`
+	Alerting            AlertingConfig       `json:"alerting"`
+	Reports             ReportsConfig        `json:"reports"`
+	SlowQueryThreshold  time.Duration        `json:"slow_query_threshold"`
+	SlowQueryLogSize    int                  `json:"slow_query_log_size"`
+	IngestActivityTTL   time.Duration        `json:"ingest_activity_ttl"`
+	MetricsMaxEndpoints int                  `json:"metrics_max_endpoints"`
+	ResponseFormat      ResponseFormatConfig `json:"response_format"`
+	ResponseCache       ResponseCacheConfig  `json:"response_cache"`
+	Import              ImportConfig         `json:"import"`
+	Probes              ProbesConfig         `json:"probes"`
+	AsyncIngest         AsyncIngestConfig    `json:"async_ingest"`
+}
+
+// AsyncIngestConfig enables asynchronous ingestion: POST /ingest and the
+// pixel validate each event, queue it for one of Workers to record and
+// respond at once. At most QueueSize events wait; beyond that requests are
+// refused with 503.
+type AsyncIngestConfig struct {
+	Enabled   bool `json:"enabled"`
+	QueueSize int  `json:"queue_size"`
+	Workers   int  `json:"workers"`
+}
+
+// ProbesConfig tunes the /readyz dependency checks. Each check must finish
+// within CheckTimeout. The memory check fails when less than
+// MinMemoryHeadroom (a fraction) of the Go memory limit (GOMEMLIMIT) is free,
+// and the Kafka check when consumer lag exceeds MaxKafkaLag (0 disables it).
+type ProbesConfig struct {
+	CheckTimeout      time.Duration `json:"check_timeout"`
+	MinMemoryHeadroom float64       `json:"min_memory_headroom"`
+	MaxKafkaLag       int64         `json:"max_kafka_lag"`
+}
+
+// ImportConfig limits POST /api/v1/import. Events are recorded at no more
+// than MaxEventsPerSecond (unlimited when zero); a request may ask for less
+// with the rate query parameter.
+type ImportConfig struct {
+	MaxEventsPerSecond int `json:"max_events_per_second"`
+}
+
+// ResponseCacheConfig controls the cache of /stats and /api/v1/top-urls
+// responses. An entry is served for at most TTL and dropped as soon as an
+// event is recorded for its URL (any URL, for top URLs); at most MaxEntries
+// responses are kept.
+type ResponseCacheConfig struct {
+	Enabled    bool          `json:"enabled"`
+	TTL        time.Duration `json:"ttl"`
+	MaxEntries int           `json:"max_entries"`
+}
+
+// ResponseFormatConfig controls how JSON responses render numbers. DurationFormat
+// is ns (integer nanoseconds), ms (fractional milliseconds) or string (e.g.
+// "1m30s"); floats are rounded to Precision decimal places unless it is
+// negative. Clients can override both with the duration_format and precision
+// query parameters.
+type ResponseFormatConfig struct {
+	DurationFormat string `json:"duration_format"`
+	Precision      int    `json:"precision"`
+}
+
+// MaxResponsePrecision bounds ResponseFormatConfig.Precision.
+const MaxResponsePrecision = 15
+
+// IsDefault reports whether f leaves responses as encoding/json renders them.
+func (f ResponseFormatConfig) IsDefault() bool {
+	return (f.DurationFormat == "" || f.DurationFormat == DurationFormatNanoseconds) && f.Precision < 0
+}
+
+func (f ResponseFormatConfig) Validate() error {
+	switch f.DurationFormat {
+	case "", DurationFormatNanoseconds, DurationFormatMilliseconds, DurationFormatString:
+	default:
+		return fmt.Errorf("duration_format must be ns, ms or string")
+	}
+	if f.Precision > MaxResponsePrecision {
+		return fmt.Errorf("precision must be at most %d", MaxResponsePrecision)
+	}
+	return nil
+}
+
+// RateLimitConfig sets token bucket limits. Every request is limited per
+// client IP; those whose X-API-Key is listed in tenancy.api_keys are also
+// limited per key, and unknown keys count as IP traffic. Headers selects
+// which rate limit response headers are sent: legacy, draft, both or none.
+// It is off by default, as behind a proxy not yet listed in
+// ClientIPConfig.TrustedProxies every client would share one bucket.
+type RateLimitConfig struct {
+	Enabled              bool    `json:"enabled"`
+	IPRequestsPerSecond  float64 `json:"ip_requests_per_second"`
+	IPBurst              int     `json:"ip_burst"`
+	KeyRequestsPerSecond float64 `json:"key_requests_per_second"`
+	KeyBurst             int     `json:"key_burst"`
+	Headers              string  `json:"headers"`
+}
+
+// MiddlewareConfig lists the middleware wrapped around each route group,
+// outermost first. Global wraps every request, including tenant routing and
+// the tenant API; Ingest covers event ingestion, Query the read endpoints, and
+// Admin the operational and mutating endpoints. The event stream is only
+// covered by Global. A middleware may appear in Global or in groups, not
+// both. rate_limit is a no-op unless rate limiting is enabled, and shares its
+// buckets across groups. timeout may not wrap ingestion: an event whose
+// request timed out would still be recorded after the client was told it
+// failed, and counted twice when retried.
+type MiddlewareConfig struct {
+	Global []string `json:"global"`
+	Ingest []string `json:"ingest"`
+	Query  []string `json:"query"`
+	Admin  []string `json:"admin"`
+}
+
+// Validate checks that every chain names known middleware at most once.
+func (m MiddlewareConfig) Validate() error {
+	global := make(map[string]bool, len(m.Global))
+	groups := []struct {
+		name  string
+		chain []string
+	}{{"global", m.Global}, {"ingest", m.Ingest}, {"query", m.Query}, {"admin", m.Admin}}
+
+	for _, group := range groups {
+		seen := make(map[string]bool, len(group.chain))
+		for _, name := range group.chain {
+			if !middlewareNames[name] {
+				return fmt.Errorf("middleware.%s: unknown middleware %q", group.name, name)
+			}
+			if seen[name] {
+				return fmt.Errorf("middleware.%s: %s is listed twice", group.name, name)
+			}
+			seen[name] = true
+			if name == MiddlewareTimeout && (group.name == "global" || group.name == "ingest") {
+				return fmt.Errorf("middleware.%s: timeout cannot wrap ingestion, which must not time out after recording an event", group.name)
+			}
+			if group.name == "global" {
+				global[name] = true
+			} else if global[name] {
+				return fmt.Errorf("middleware.%s: %s is already global", group.name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// CORSConfig allows browsers on AllowedOrigins to call the API. An origin is
+// "*" for any origin, an exact scheme://host[:port], or scheme://*.domain for
+// any subdomain of domain. With no origins no CORS headers are sent. It can be
+// changed at runtime through the config API.
+type CORSConfig struct {
+	AllowedOrigins   []string      `json:"allowed_origins"`
+	AllowedMethods   []string      `json:"allowed_methods"`
+	AllowedHeaders   []string      `json:"allowed_headers"`
+	AllowCredentials bool          `json:"allow_credentials"`
+	MaxAge           time.Duration `json:"max_age"`
+}
+
+// Validate checks the origin patterns and that preflight results are cacheable.
+func (c CORSConfig) Validate() error {
+	for i, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		scheme, host, ok := strings.Cut(origin, "://")
+		if !ok || scheme == "" || host == "" || strings.ContainsAny(host, "/?#") ||
+			strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+			return fmt.Errorf("cors.allowed_origins[%d]: must be *, scheme://host or scheme://*.domain", i)
+		}
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("cors: max_age must not be negative")
+	}
+	return nil
+}
+
+// HandlerTimeoutConfig bounds how long handlers behind the timeout middleware
+// may take. Endpoints maps a path to its own deadline, where 0 means none;
+// other paths get Default. A request past its deadline has its context
+// cancelled and is answered with 503 and the REQUEST_TIMEOUT code.
+type HandlerTimeoutConfig struct {
+	Default   time.Duration            `json:"default"`
+	Endpoints map[string]time.Duration `json:"endpoints"`
+}
+
+// Timeout returns the deadline for path, 0 when it has none.
+func (c HandlerTimeoutConfig) Timeout(path string) time.Duration {
+	if timeout, exists := c.Endpoints[path]; exists {
+		return timeout
+	}
+	return c.Default
+}
+
+// Validate checks that no deadline is negative.
+func (c HandlerTimeoutConfig) Validate() error {
+	if c.Default < 0 {
+		return fmt.Errorf("handler_timeout: default must not be negative")
+	}
+	for path, timeout := range c.Endpoints {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("handler_timeout: endpoint %q must be a path starting with /", path)
+		}
+		if timeout < 0 {
+			return fmt.Errorf("handler_timeout: timeout for %s must not be negative", path)
+		}
+	}
+	return nil
+}
+
+// CircuitBreakerConfig guards the counter backend (Redis, SQLite, PostgreSQL
+// or replicated mode). After FailureThreshold consecutive failed calls the
+// breaker opens and calls fail at once for Cooldown, after which a single
+// trial call decides whether it closes again.
+type CircuitBreakerConfig struct {
+	Enabled          bool          `json:"enabled"`
+	FailureThreshold int           `json:"failure_threshold"`
+	Cooldown         time.Duration `json:"cooldown"`
+}
+
+// LegacyRoutesConfig controls the unversioned paths kept for backward
+// compatibility, such as /ingest for /api/v1/ingest. They are served by the
+// same handlers as their successors but answer with Deprecation and Link
+// headers, and a Sunset header once Sunset is set. Disabling them leaves only
+// the /api/v1 paths.
+type LegacyRoutesConfig struct {
+	Enabled bool      `json:"enabled"`
+	Sunset  time.Time `json:"sunset"`
+}
+
+// BodyLimitConfig caps request body sizes for the body_limit middleware.
+// Endpoints maps a path to its own limit in bytes, where 0 means unlimited;
+// other paths get Default. Bodies over the limit are rejected with 413 and
+// the REQUEST_TOO_LARGE code, whether or not they declare a Content-Length.
+type BodyLimitConfig struct {
+	Default   int64            `json:"default"`
+	Endpoints map[string]int64 `json:"endpoints"`
+}
+
+// Limit returns the body size limit for path, 0 when it is unlimited.
+func (c BodyLimitConfig) Limit(path string) int64 {
+	if limit, exists := c.Endpoints[path]; exists {
+		return limit
+	}
+	return c.Default
+}
+
+// Validate checks that the limits are usable.
+func (c BodyLimitConfig) Validate() error {
+	if c.Default < 1 {
+		return fmt.Errorf("body_limit: default must be positive")
+	}
+	for path, limit := range c.Endpoints {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("body_limit.endpoints: %q must be a path starting with /", path)
+		}
+		if limit < 0 {
+			return fmt.Errorf("body_limit.endpoints[%s]: must not be negative", path)
+		}
+	}
+	return nil
+}
+
+// ValidationConfig sets the rules events must pass at ingest. Visitor, event
+// and session IDs must match VisitorIDPattern and be at most
+// MaxVisitorIDLength characters; URLs and referrers are capped at
+// MaxURLLength. When AllowedURLSchemes is set only those schemes are
+// accepted, and AllowRelativeURLs accepts paths such as /pricing.
+type ValidationConfig struct {
+	VisitorIDPattern   string   `json:"visitor_id_pattern"`
+	MaxVisitorIDLength int      `json:"max_visitor_id_length"`
+	MaxURLLength       int      `json:"max_url_length"`
+	AllowedURLSchemes  []string `json:"allowed_url_schemes"`
+	AllowRelativeURLs  bool     `json:"allow_relative_urls"`
+}
+
+// Validate checks that the pattern compiles and the limits are usable.
+func (c ValidationConfig) Validate() error {
+	if _, err := regexp.Compile(c.VisitorIDPattern); err != nil {
+		return fmt.Errorf("validation: invalid visitor_id_pattern: %w", err)
+	}
+	if c.MaxVisitorIDLength < 1 || c.MaxURLLength < 1 {
+		return fmt.Errorf("validation: max_visitor_id_length and max_url_length must be positive")
+	}
+	for _, scheme := range c.AllowedURLSchemes {
+		if scheme == "" || strings.ContainsAny(scheme, ":/") {
+			return fmt.Errorf("validation: invalid URL scheme %q", scheme)
+		}
+	}
+	return nil
+}
+
+// CategoryRule tags URLs whose normalized form matches Pattern (a regular
+// expression) with Category. Rules are evaluated in order; the first match wins.
+type CategoryRule struct {
+	Category string `json:"category"`
+	Pattern  string `json:"pattern"`
+}
+
+// TopURLsConfig selects how top URLs are ranked. Exact sorts every URL on each
+// request; sketch keeps the Size URLs with the most distinct visitors in a heap
+// maintained at ingest time, estimating counts with a Count-Min Sketch of
+// SketchDepth rows of SketchWidth counters.
+type TopURLsConfig struct {
+	Mode        string `json:"mode"`
+	Size        int    `json:"size"`
+	SketchWidth int    `json:"sketch_width"`
+	SketchDepth int    `json:"sketch_depth"`
+}
+
+// RetentionConfig expires URLs whose most recent event is older than their
+// TTL. The first rule whose Pattern matches the normalized URL sets the TTL;
+// other URLs use MaxEventAge. A zero TTL keeps URLs forever.
+type RetentionConfig struct {
+	MaxEventAge   time.Duration   `json:"max_event_age"`
+	Rules         []RetentionRule `json:"rules"`
+	SweepInterval time.Duration   `json:"sweep_interval"`
+}
+
+// DedupConfig remembers the EventIDs of the last Size events for TTL, so that
+// an event resent with the same EventID is acknowledged without being counted
+// again. A zero Size disables deduplication.
+type DedupConfig struct {
+	Size int           `json:"size"`
+	TTL  time.Duration `json:"ttl"`
+}
+
+// Eviction modes
+const (
+	EvictionModeEvict        = "evict"
+	EvictionModeBackpressure = "backpressure"
+)
+
+// EvictionConfig bounds the approximate size of the in-memory URL statistics.
+// In evict mode, once they exceed MaxBytes, the least recently recorded URLs
+// are evicted until they fit in TargetRatio of it. In backpressure mode
+// nothing is deleted: once they reach HighWatermark of MaxBytes, events are
+// refused, and clients told to retry after RetryAfter, until they are back
+// under LowWatermark of it. A zero MaxBytes disables both.
+type EvictionConfig struct {
+	MaxBytes      int64         `json:"max_bytes"`
+	Mode          string        `json:"mode"`
+	TargetRatio   float64       `json:"target_ratio"`
+	HighWatermark float64       `json:"high_watermark"`
+	LowWatermark  float64       `json:"low_watermark"`
+	RetryAfter    time.Duration `json:"retry_after"`
+}
+
+// Visitor cap overflow strategies.
+const (
+	VisitorCapReject      = "reject"
+	VisitorCapApproximate = "approximate"
+	VisitorCapEvictOldest = "evict_oldest"
+)
+
+// VisitorCapConfig bounds the visitor records kept per URL. Once a URL has
+// MaxVisitorsPerURL, events from new visitors are handled by Overflow:
+// reject refuses them, approximate counts them in a HyperLogLog sketch
+// without keeping their records, and evict_oldest drops the least recently
+// seen visitors to make room. A zero MaxVisitorsPerURL disables the cap.
+type VisitorCapConfig struct {
+	MaxVisitorsPerURL int    `json:"max_visitors_per_url"`
+	Overflow          string `json:"overflow"`
+}
+
+// SamplingConfig makes ingestion cheaper at high traffic by recording only
+// every Rate-th repeat view of each URL, counted as Rate page views. A
+// visitor's first view of a URL is always recorded, so distinct visitors
+// stay exact. A Rate of 0 or 1 records every view.
+type SamplingConfig struct {
+	Rate int `json:"rate"`
+}
+
+// Policies for event timestamps outside the acceptance window.
+const (
+	TimestampReject = "reject"
+	TimestampClamp  = "clamp"
+	TimestampServer = "server"
+)
+
+// TimestampConfig sets how far client-supplied event timestamps may stray
+// from the server's clock. An event dated more than MaxPast before or
+// MaxFuture after the server's time is out of window, and 0 leaves that side
+// open. Policy decides what happens to it: reject refuses it, clamp moves its
+// timestamp to the nearest edge of the window and server dates it on arrival.
+// ServerTime ignores client timestamps altogether.
+type TimestampConfig struct {
+	MaxPast    time.Duration `json:"max_past"`
+	MaxFuture  time.Duration `json:"max_future"`
+	Policy     string        `json:"policy"`
+	ServerTime bool          `json:"server_time"`
+}
+
+// Deny rule match types.
+const (
+	DenyExact  = "exact"
+	DenyPrefix = "prefix"
+	DenyRegex  = "regex"
+)
+
+// Denylist names.
+const (
+	DenylistURLs      = "urls"
+	DenylistReferrers = "referrers"
+)
+
+// DenyRule matches a value exactly, by prefix or by regular expression.
+// Exact and prefix rules are case-insensitive.
+type DenyRule struct {
+	Match   string `json:"match"`
+	Pattern string `json:"pattern"`
+}
+
+// Validate checks the match type and that a regex pattern compiles.
+func (r DenyRule) Validate() error {
+	if r.Pattern == "" {
+		return fmt.Errorf("pattern is required")
+	}
+	switch r.Match {
+	case DenyExact, DenyPrefix:
+	case DenyRegex:
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	default:
+		return fmt.Errorf("match must be exact, prefix or regex")
+	}
+	return nil
+}
+
+// DenylistConfig rejects events at ingest whose normalized URL matches a
+// rule in URLs, or whose referrer domain matches one in Referrers.
+type DenylistConfig struct {
+	URLs      []DenyRule `json:"urls"`
+	Referrers []DenyRule `json:"referrers"`
+}
+
+// Validate checks every rule.
+func (c DenylistConfig) Validate() error {
+	for i, rule := range c.URLs {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("denylist.urls[%d]: %w", i, err)
+		}
+	}
+	for i, rule := range c.Referrers {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("denylist.referrers[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// DataQualityConfig flags visitor IDs with at least SuspiciousShare of a
+// URL's page views once it has MinPageViews. Events from ExcludedVisitorIDs
+// still count as page views but never as visitors.
+type DataQualityConfig struct {
+	ExcludedVisitorIDs []string `json:"excluded_visitor_ids"`
+	SuspiciousShare    float64  `json:"suspicious_share"`
+	MinPageViews       int64    `json:"min_page_views"`
+}
+
+const (
+	DimensionModeExact = "exact"
+	DimensionModeHLL   = "hll"
+)
+
+// DefaultHLLPrecision is used for hll dimensions that do not set Precision.
+const DefaultHLLPrecision = 12
+
+// DimensionConfig counts the distinct values of the event property Name per
+// URL, alongside distinct visitors. Mode exact keeps every value; hll keeps a
+// HyperLogLog sketch of 2^Precision one-byte registers, with a standard error
+// of about 1.04/sqrt(2^Precision).
+type DimensionConfig struct {
+	Name      string `json:"name"`
+	Mode      string `json:"mode"`
+	Precision int    `json:"precision,omitempty"`
+}
+
+// PrivacyConfig controls what personal data is stored. With HashVisitorIDs,
+// visitor IDs are replaced at ingestion by the hex SHA-256 digest of Salt
+// followed by the ID, so raw IDs are never stored, streamed or exported.
+// Changing Salt makes returning visitors look new. AnonymizeIP controls the
+// client IP attached to events from any source: none keeps it, truncate
+// zeroes the last octet of IPv4 addresses and all but the first 48 bits of
+// IPv6 addresses, and hash replaces it with the salted digest like visitor
+// IDs. Values that are not IPs are dropped.
+type PrivacyConfig struct {
+	HashVisitorIDs bool   `json:"hash_visitor_ids"`
+	Salt           string `json:"salt"`
+	AnonymizeIP    string `json:"anonymize_ip"`
+}
+
+// IP anonymization modes for PrivacyConfig.AnonymizeIP.
+const (
+	IPAnonymizeNone     = "none"
+	IPAnonymizeTruncate = "truncate"
+	IPAnonymizeHash     = "hash"
+)
+
+// ClientIPConfig controls how the client IP of an ingest request is resolved.
+// X-Forwarded-For and X-Real-IP are only honoured on requests arriving from
+// one of TrustedProxies (IPs or CIDRs), and the client is the right-most
+// X-Forwarded-For address that is not itself a trusted proxy.
+type ClientIPConfig struct {
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+type RetentionRule struct {
+	Pattern string        `json:"pattern"`
+	TTL     time.Duration `json:"ttl"`
+}
+
+// Enabled reports whether any URL can expire.
+func (r RetentionConfig) Enabled() bool {
+	return r.MaxEventAge > 0 || len(r.Rules) > 0
+}
+
+// SyslogConfig controls the optional syslog ingestion listener. Network is
+// "udp" or "tcp"; Format is "auto", "kv" or "json".
+type SyslogConfig struct {
+	Enabled bool           `json:"enabled"`
+	Network string         `json:"network"`
+	Address string         `json:"address"`
+	Format  string         `json:"format"`
+	Fields  SyslogFieldMap `json:"fields"`
+}
+
+// SyslogFieldMap names the payload keys that map onto NavigationEvent fields.
+type SyslogFieldMap struct {
+	VisitorID string `json:"visitor_id"`
+	URL       string `json:"url"`
+	SessionID string `json:"session_id"`
+	Timestamp string `json:"timestamp"`
+}
+
+// KafkaConfig controls the optional Kafka ingestion consumer, which reads
+// NavigationEvent JSON from Topics as a member of consumer group GroupID.
+type KafkaConfig struct {
+	Enabled bool     `json:"enabled"`
+	Brokers []string `json:"brokers"`
+	Topics  []string `json:"topics"`
+	GroupID string   `json:"group_id"`
+}
+
+// GeoIPConfig enables enrichment of ingested events with the location of the
+// client IP, looked up in a MaxMind City database. The client IP is resolved
+// as configured in ClientIPConfig.
+type GeoIPConfig struct {
+	Enabled      bool   `json:"enabled"`
+	DatabasePath string `json:"database_path"`
+	// Deprecated: TrustForwardedFor is ignored, as it let any client choose
+	// its location. List the proxies in ClientIPConfig.TrustedProxies.
+	TrustForwardedFor bool `json:"trust_forwarded_for"`
+}
+
+// RedisConfig enables the shared Redis counter backend. With HyperLogLog set,
+// distinct visitors are approximated (~0.8% error) in constant memory per URL
+// instead of being counted exactly with sets. AuditSampleRate is the fraction
+// of URLs that are additionally counted exactly to measure that error.
+type RedisConfig struct {
+	Enabled         bool          `json:"enabled"`
+	Address         string        `json:"address"`
+	Password        string        `json:"password"`
+	DB              int           `json:"db"`
+	KeyPrefix       string        `json:"key_prefix"`
+	HyperLogLog     bool          `json:"hyperloglog"`
+	AuditSampleRate float64       `json:"audit_sample_rate"`
+	Timeout         time.Duration `json:"timeout"`
+}
+
+// ArchiveConfig enables archiving every recorded event to S3-compatible
+// object storage, such as AWS S3, GCS through its interoperability endpoint
+// or MinIO. Events are batched into gzip-compressed NDJSON objects under
+// Prefix, uploaded every Interval or once BatchSize events are waiting. A
+// failed upload is retried with the next batch; once MaxPendingEvents are
+// waiting, further events are dropped and counted. With PathStyle the bucket
+// is addressed as Endpoint/Bucket rather than as a subdomain of Endpoint.
+type ArchiveConfig struct {
+	Enabled          bool          `json:"enabled"`
+	Endpoint         string        `json:"endpoint"`
+	Region           string        `json:"region"`
+	Bucket           string        `json:"bucket"`
+	Prefix           string        `json:"prefix"`
+	AccessKeyID      string        `json:"access_key_id"`
+	SecretAccessKey  string        `json:"secret_access_key"`
+	PathStyle        bool          `json:"path_style"`
+	Interval         time.Duration `json:"interval"`
+	BatchSize        int           `json:"batch_size"`
+	MaxPendingEvents int           `json:"max_pending_events"`
+	Timeout          time.Duration `json:"timeout"`
+}
+
+// Validate checks that the storage location, credentials and batching are set.
+func (c ArchiveConfig) Validate() error {
+	endpoint, err := url.Parse(c.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return fmt.Errorf("archive: endpoint must be an http or https URL")
+	}
+	if c.Region == "" || c.Bucket == "" {
+		return fmt.Errorf("archive: region and bucket are required")
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return fmt.Errorf("archive: access_key_id and secret_access_key are required")
+	}
+	if c.Interval <= 0 || c.Timeout <= 0 {
+		return fmt.Errorf("archive: interval and timeout must be positive")
+	}
+	if c.BatchSize < 1 || c.MaxPendingEvents < c.BatchSize {
+		return fmt.Errorf("archive: batch_size must be positive and max_pending_events at least batch_size")
+	}
+	return nil
+}
+
+// ClusterConfig enables replicated mode, in which instances keep their
+// per-URL counters as HyperLogLog sketches of 2^Precision registers and page
+// view counts, and push the counters that changed to every peer each
+// Interval. Peers are base URLs such as http://10.0.0.2:8080, and every
+// node must share Secret. NodeID defaults to the hostname.
+type ClusterConfig struct {
+	Enabled   bool          `json:"enabled"`
+	NodeID    string        `json:"node_id"`
+	Peers     []string      `json:"peers"`
+	Secret    string        `json:"secret"`
+	Interval  time.Duration `json:"interval"`
+	Timeout   time.Duration `json:"timeout"`
+	Precision int           `json:"precision"`
+}
+
+// Bounds of ClusterConfig.Precision.
+const (
+	MinClusterPrecision = 4
+	MaxClusterPrecision = 16
+)
+
+// Validate checks the peers, secret and gossip timing.
+func (c ClusterConfig) Validate() error {
+	if c.Secret == "" {
+		return fmt.Errorf("cluster: secret is required")
+	}
+	for _, peer := range c.Peers {
+		parsed, err := url.Parse(peer)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("cluster: peer %q must be an http or https base URL", peer)
+		}
+	}
+	if c.Interval <= 0 || c.Timeout <= 0 {
+		return fmt.Errorf("cluster: interval and timeout must be positive")
+	}
+	if c.Precision < MinClusterPrecision || c.Precision > MaxClusterPrecision {
+		return fmt.Errorf("cluster: precision must be between %d and %d", MinClusterPrecision, MaxClusterPrecision)
+	}
+	return nil
+}
+
+// Storage backends selectable with StorageConfig.Backend.
+const (
+	StorageBackendMemory   = "memory"
+	StorageBackendSQLite   = "sqlite"
+	StorageBackendPostgres = "postgres"
+)
+
+// StorageConfig selects where the per-URL counters are kept. With the sqlite
+// and postgres backends, events and per-URL aggregates are also written to
+// tables in the database at DSN, where they can be queried with SQL. The
+// connection pool and batch settings apply to the postgres backend, which
+// buffers events and writes up to BatchSize of them per transaction.
+type StorageConfig struct {
+	Backend         string        `json:"backend"`
+	DSN             string        `json:"dsn"`
+	Timeout         time.Duration `json:"timeout"`
+	MaxOpenConns    int           `json:"max_open_conns"`
+	MaxIdleConns    int           `json:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `json:"conn_max_lifetime"`
+	BatchSize       int           `json:"batch_size"`
+	FlushInterval   time.Duration `json:"flush_interval"`
+}
+
+// HeartbeatConfig enables periodic pings of an external heartbeat URL while
+// the service is healthy.
+type HeartbeatConfig struct {
+	Enabled  bool          `json:"enabled"`
+	URL      string        `json:"url"`
+	Interval time.Duration `json:"interval"`
+	Timeout  time.Duration `json:"timeout"`
+}
+
+// SnapshotConfig enables restoring tracker state from Path on boot and saving
+// it there on shutdown or on demand. With FlushInterval set, changes are also
+// flushed in the background at least that often, which bounds how much is
+// lost on a crash; FlushMaxEvents triggers an earlier flush once that many
+// events are pending.
+type SnapshotConfig struct {
+	Enabled        bool          `json:"enabled"`
+	Path           string        `json:"path"`
+	FlushInterval  time.Duration `json:"flush_interval"`
+	FlushMaxEvents int           `json:"flush_max_events"`
+}
+
+// ConfigHistoryConfig keeps the last MaxVersions configurations accepted
+// through the config API. They are persisted to Path, which defaults to the
+// snapshot path followed by .config when snapshots are enabled, so changes
+// survive restarts; without a path the history is kept in memory only.
+type ConfigHistoryConfig struct {
+	Path        string `json:"path"`
+	MaxVersions int    `json:"max_versions"`
+}
+
+// TieringConfig moves URLs with no events for IdleAfter out of memory into
+// per-shard files under Dir, checking every SweepInterval. A cold URL is
+// loaded back when it is read or receives an event; at most HotCacheSize
+// loaded URLs are kept in memory until they become active again. The files
+// are scratch space, recreated on boot; use snapshots to persist state.
+type TieringConfig struct {
+	Enabled       bool          `json:"enabled"`
+	Dir           string        `json:"dir"`
+	IdleAfter     time.Duration `json:"idle_after"`
+	HotCacheSize  int           `json:"hot_cache_size"`
+	SweepInterval time.Duration `json:"sweep_interval"`
+}
+
+// CompactionConfig schedules compaction of the files persisted by snapshots
+// and tiering every Interval; 0 leaves it to POST /api/v1/admin/compact.
+type CompactionConfig struct {
+	Interval time.Duration `json:"interval"`
+}
+
+// RealtimeConfig sets how recently a visitor must have had an event to count
+// as online now. Window is a whole number of minutes, at most MaxRealtimeWindow.
+type RealtimeConfig struct {
+	Window time.Duration `json:"window"`
+}
+
+// MaxRealtimeWindow is the longest realtime window.
+const MaxRealtimeWindow = time.Hour
+
+// ReportsConfig generates the Schedules' reports when they fall due, checking
+// every CheckInterval, and delivers them to a webhook or by email through
+// SMTP. Failed deliveries are retried up to MaxRetries times, doubling
+// RetryBackoff after each attempt. Schedules can also be managed through
+// /api/v1/reports. Those created there are saved to Path, which defaults to
+// the snapshot path followed by .reports when snapshots are enabled; changes
+// to the configured Schedules last until restart.
+type ReportsConfig struct {
+	Enabled        bool             `json:"enabled"`
+	Path           string           `json:"path"`
+	CheckInterval  time.Duration    `json:"check_interval"`
+	WebhookTimeout time.Duration    `json:"webhook_timeout"`
+	MaxRetries     int              `json:"max_retries"`
+	RetryBackoff   time.Duration    `json:"retry_backoff"`
+	SMTP           SMTPConfig       `json:"smtp"`
+	Schedules      []ReportSchedule `json:"schedules"`
+}
+
+// SMTPConfig is the mail server reports are emailed through. Without a
+// Username the server is used without authentication.
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+}
+
+// Validate checks every schedule and that emailed schedules have a server.
+func (c ReportsConfig) Validate() error {
+	if c.CheckInterval <= 0 || c.MaxRetries < 0 || c.RetryBackoff < 0 {
+		return fmt.Errorf("reports: check_interval must be positive and retries must not be negative")
+	}
+	ids := make(map[string]bool, len(c.Schedules))
+	for i, schedule := range c.Schedules {
+		if err := schedule.Validate(); err != nil {
+			return fmt.Errorf("reports.schedules[%d]: %w", i, err)
+		}
+		if len(schedule.Delivery.Email) > 0 && (c.SMTP.Host == "" || c.SMTP.From == "") {
+			return fmt.Errorf("reports.schedules[%d]: email delivery requires smtp.host and smtp.from", i)
+		}
+		if schedule.ID != "" {
+			if ids[schedule.ID] {
+				return fmt.Errorf("reports.schedules[%d]: id %q is used twice", i, schedule.ID)
+			}
+			ids[schedule.ID] = true
+		}
+	}
+	return nil
+}
+
+// Alert rule metrics
+const (
+	AlertMetricURLVisitors = "url_visitors"
+	AlertMetricErrorRate   = "error_rate"
+)
+
+// AlertingConfig evaluates Rules every Interval and POSTs to a rule's Webhook
+// when it starts or stops firing. Failed deliveries are retried up to
+// MaxRetries times, doubling RetryBackoff after each attempt.
+type AlertingConfig struct {
+	Enabled        bool          `json:"enabled"`
+	Interval       time.Duration `json:"interval"`
+	Rules          []AlertRule   `json:"rules"`
+	WebhookTimeout time.Duration `json:"webhook_timeout"`
+	MaxRetries     int           `json:"max_retries"`
+	RetryBackoff   time.Duration `json:"retry_backoff"`
+}
+
+// AlertRule fires when Metric exceeds Threshold. url_visitors counts the
+// distinct visitors of URL seen within Window; error_rate is the percentage
+// of requests answered with a 4xx or 5xx status since the last evaluation.
+// Template, if set, is a text/template for the JSON payload; see
+// monitoring.AlertEvent for the available fields.
+type AlertRule struct {
+	Name      string        `json:"name"`
+	Metric    string        `json:"metric"`
+	URL       string        `json:"url,omitempty"`
+	Window    time.Duration `json:"window,omitempty"`
+	Threshold float64       `json:"threshold"`
+	Webhook   string        `json:"webhook"`
+	Template  string        `json:"template,omitempty"`
+}
+
+// Tenant lifecycle states. Suspended tenants can still query but not ingest;
+// tenants pending deletion reject every request until they are purged.
+const (
+	TenantActive          = "active"
+	TenantSuspended       = "suspended"
+	TenantPendingDeletion = "pending-deletion"
+	TenantPurged          = "purged"
+)
+
+// TenancyConfig partitions all tracker state by tenant. A request's tenant
+// comes from APIKeys when its X-API-Key is listed there; otherwise from the
+// X-Tenant-ID header when AllowHeader is set; otherwise DefaultTenant. At
+// most MaxTenants tenants are created.
+//
+// Every tenant starts with Quota, which can be rebalanced at runtime. Usage
+// is checked against it every QuotaCheckInterval; QuotaWebhook is notified
+// when a tenant reaches 80% or 100% of it or falls back below 80%, and a
+// tenant at 100% has its events refused until it is back under.
+type TenancyConfig struct {
+	Enabled            bool              `json:"enabled"`
+	APIKeys            map[string]string `json:"api_keys"`
+	AllowHeader        bool              `json:"allow_header"`
+	MaxTenants         int               `json:"max_tenants"`
+	Quota              TenantQuota       `json:"quota"`
+	QuotaCheckInterval time.Duration     `json:"quota_check_interval"`
+	QuotaWebhook       string            `json:"quota_webhook"`
+}
+
+// TenantQuota bounds what a tenant's tracker may hold: the approximate bytes
+// of its URL statistics, its URLs and its visitors. A zero limit is
+// unbounded.
+type TenantQuota struct {
+	MaxBytes    int64 `json:"max_bytes"`
+	MaxURLs     int   `json:"max_urls"`
+	MaxVisitors int   `json:"max_visitors"`
+}
+
+// Validate checks that no limit is negative.
+func (q TenantQuota) Validate() error {
+	if q.MaxBytes < 0 || q.MaxURLs < 0 || q.MaxVisitors < 0 {
+		return fmt.Errorf("quota limits must not be negative")
+	}
+	return nil
+}
+
+// Percent returns the highest share of any of q's limits that usage takes
+// up, as a percentage, or 0 when q is unbounded.
+func (q TenantQuota) Percent(usage TenantUsage) float64 {
+	var percent float64
+	if q.MaxBytes > 0 {
+		percent = math.Max(percent, float64(usage.Bytes)/float64(q.MaxBytes)*100)
+	}
+	if q.MaxURLs > 0 {
+		percent = math.Max(percent, float64(usage.URLs)/float64(q.MaxURLs)*100)
+	}
+	if q.MaxVisitors > 0 {
+		percent = math.Max(percent, float64(usage.Visitors)/float64(q.MaxVisitors)*100)
+	}
+	return math.Round(percent*100) / 100
+}
+
+// Authentication modes
+const (
+	AuthModeAPIKey = "api_key"
+	AuthModeJWT    = "jwt"
+)
+
+// JWT signing algorithms
+const (
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmRS256 = "RS256"
+)
+
+// Scopes a JWT grants, one per route group: ingest for event ingestion,
+// query for the read endpoints and the event stream, and admin for the
+// operational and mutating endpoints and the tenant API.
+const (
+	ScopeIngest = "ingest"
+	ScopeQuery  = "query"
+	ScopeAdmin  = "admin"
+)
+
+// AuthConfig selects how requests are authenticated. In api_key mode, the
+// default, X-API-Key only selects the tenant. In jwt mode every request
+// needs an Authorization: Bearer token carrying the scope of its route
+// group, except the health probes and the cluster gossip endpoint, which
+// checks the cluster secret instead; the token's tenant claim selects the
+// tenant in place of X-API-Key. Signing applies in either mode.
+type AuthConfig struct {
+	Mode    string        `json:"mode"`
+	JWT     JWTConfig     `json:"jwt"`
+	Signing SigningConfig `json:"signing"`
+}
+
+// SigningConfig requires every ingest request to be signed with the secret
+// of the site it names in X-Site-ID: X-Signature carries the time it was
+// signed, a nonce and the HMAC-SHA256 of both and the request body (the raw
+// query for the pixel). Secrets maps site IDs to their secrets. Signatures
+// made more than Tolerance before or after the server's time are refused,
+// as are nonces the site has used within it.
+//
+// Used nonces are pruned every NoncePruneInterval once their signatures
+// have expired, and saved to NoncePath then and on shutdown so replays stay
+// refused across a restart. NoncePath defaults to the snapshot path with a
+// .nonces suffix when snapshots are enabled; without either, nonces are
+// only kept in memory.
+type SigningConfig struct {
+	Enabled            bool              `json:"enabled"`
+	Secrets            map[string]string `json:"secrets,omitempty"`
+	Tolerance          time.Duration     `json:"tolerance"`
+	NoncePath          string            `json:"nonce_path,omitempty"`
+	NoncePruneInterval time.Duration     `json:"nonce_prune_interval"`
+}
+
+// JWTConfig verifies bearer tokens. HS256 tokens are checked against
+// Secret; RS256 tokens against the PEM PublicKey, or the key matching their
+// kid in the JWKS at JWKSURL, fetched again every JWKSRefresh or when a
+// token names an unknown key. Tokens must not be expired, and must carry
+// Issuer and Audience when those are set; Leeway allows for clock skew.
+//
+// The scopes are read from ScopeClaim, a space-separated string or an array.
+// Scopes maps each claim value to the scopes it grants; when empty, the
+// values are taken as scope names. The tenant is read from TenantClaim.
+type JWTConfig struct {
+	Algorithm   string              `json:"algorithm"`
+	Secret      string              `json:"secret,omitempty"`
+	PublicKey   string              `json:"public_key,omitempty"`
+	JWKSURL     string              `json:"jwks_url,omitempty"`
+	JWKSRefresh time.Duration       `json:"jwks_refresh"`
+	Issuer      string              `json:"issuer,omitempty"`
+	Audience    string              `json:"audience,omitempty"`
+	Leeway      time.Duration       `json:"leeway"`
+	ScopeClaim  string              `json:"scope_claim"`
+	TenantClaim string              `json:"tenant_claim"`
+	Scopes      map[string][]string `json:"scopes,omitempty"`
+}
+
+// Validate checks that the algorithm has a key to verify against and that
+// the claim mapping grants known scopes.
+func (c JWTConfig) Validate() error {
+	switch c.Algorithm {
+	case JWTAlgorithmHS256:
+		if c.Secret == "" {
+			return fmt.Errorf("auth.jwt: secret is required for HS256")
+		}
+	case JWTAlgorithmRS256:
+		if (c.PublicKey == "") == (c.JWKSURL == "") {
+			return fmt.Errorf("auth.jwt: exactly one of public_key and jwks_url is required for RS256")
+		}
+		if c.PublicKey != "" {
+			if _, err := ParseRSAPublicKey(c.PublicKey); err != nil {
+				return fmt.Errorf("auth.jwt: public_key: %w", err)
+			}
+		}
+		if c.JWKSURL != "" {
+			parsed, err := url.Parse(c.JWKSURL)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return fmt.Errorf("auth.jwt: jwks_url must be an http or https URL")
+			}
+			if c.JWKSRefresh <= 0 {
+				return fmt.Errorf("auth.jwt: jwks_refresh must be positive")
+			}
+		}
+	default:
+		return fmt.Errorf("auth.jwt: algorithm must be HS256 or RS256")
+	}
+	if c.Leeway < 0 {
+		return fmt.Errorf("auth.jwt: leeway must not be negative")
+	}
+	if c.ScopeClaim == "" || c.TenantClaim == "" {
+		return fmt.Errorf("auth.jwt: scope_claim and tenant_claim are required")
+	}
+	for value, scopes := range c.Scopes {
+		for _, scope := range scopes {
+			switch scope {
+			case ScopeIngest, ScopeQuery, ScopeAdmin:
+			default:
+				return fmt.Errorf("auth.jwt: scopes[%q]: unknown scope %q", value, scope)
+			}
+		}
+	}
+	return nil
+}
+
+// ParseRSAPublicKey decodes a PEM-encoded PKIX or PKCS #1 RSA public key.
+func ParseRSAPublicKey(data string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("not an RSA public key")
+	}
+	return rsaKey, nil
+}
+
+// HTTPConfig holds the HTTP server timeouts; zero disables a timeout.
+// Changing them, or the port, through the config API replaces the server,
+// giving in-flight requests on the old one up to DrainTimeout to finish.
+// DrainTimeout also bounds the drain on shutdown.
+type HTTPConfig struct {
+	ReadTimeout       time.Duration `json:"read_timeout"`
+	ReadHeaderTimeout time.Duration `json:"read_header_timeout"`
+	WriteTimeout      time.Duration `json:"write_timeout"`
+	IdleTimeout       time.Duration `json:"idle_timeout"`
+	DrainTimeout      time.Duration `json:"drain_timeout"`
+}
+
+// FixturesConfig enables recording every API request and response into Dir
+// as replayable fixture files. Intended for development servers only.
+type FixturesConfig struct {
+	Record bool   `json:"record"`
+	Dir    string `json:"dir"`
+}
+
+func DefaultConfiguration() *Configuration {
+	return &Configuration{
+		Port:               "8080",
+		SessionIdleTimeout: DefaultSessionIdleTimeout,
+		HTTP: HTTPConfig{
+			ReadHeaderTimeout: 10 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			DrainTimeout:      30 * time.Second,
+		},
+		RateLimit: RateLimitConfig{
+			IPRequestsPerSecond:  50,
+			IPBurst:              100,
+			KeyRequestsPerSecond: 200,
+			KeyBurst:             400,
+			Headers:              RateLimitHeadersBoth,
+		},
+		Middleware: MiddlewareConfig{
+			Global: []string{MiddlewareCORS, MiddlewareRateLimit, MiddlewareMetrics, MiddlewareResponseFormat, MiddlewareBodyLimit},
+			Ingest: []string{MiddlewareIngestActivity},
+			Query:  []string{MiddlewareSlowLog, MiddlewareTimeout},
+			Admin:  []string{},
+		},
+		LegacyRoutes: LegacyRoutesConfig{Enabled: true},
+		HandlerTimeout: HandlerTimeoutConfig{
+			Default: 30 * time.Second,
+			Endpoints: map[string]time.Duration{
+				// Exports stream every record
+				"/api/v1/export": 0,
+			},
+		},
+		CircuitBreaker: CircuitBreakerConfig{
+			Enabled:          true,
+			FailureThreshold: 5,
+			Cooldown:         30 * time.Second,
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{"Content-Type", "Authorization", "X-API-Key", "X-Tenant-ID", "X-Site-ID", "X-Signature"},
+			MaxAge:         10 * time.Minute,
+		},
+		BodyLimit: BodyLimitConfig{
+			Default: 1 << 20,
+			Endpoints: map[string]int64{
+				"/api/v1/ingest": 64 << 10,
+				"/ingest":        64 << 10,
+				// Imports stream their body and may be arbitrarily large
+				"/api/v1/import": 0,
+				// A peer's first push after joining carries every counter
+				"/api/v1/cluster/gossip": 64 << 20,
+			},
+		},
+		Validation: ValidationConfig{
+			VisitorIDPattern:   DefaultVisitorIDPattern,
+			MaxVisitorIDLength: MaxVisitorIDLength,
+			MaxURLLength:       MaxURLLength,
+			AllowRelativeURLs:  true,
+		},
+		URLCategories: []CategoryRule{
+			{Category: "api", Pattern: `^[a-z]+://[^/]+/api(/|$)`},
+			{Category: "checkout", Pattern: `/(checkout|cart|basket)(/|$|\?)`},
+			{Category: "search", Pattern: `/search(/|$|\?)|[?&](q|query|search)=`},
+			{Category: "product", Pattern: `/(product|products|item|p)/`},
+			{Category: "article", Pattern: `/(blog|article|articles|news|posts?)/`},
+		},
+		TopURLs: TopURLsConfig{
+			Mode:        TopURLsExact,
+			Size:        100,
+			SketchWidth: 2048,
+			SketchDepth: 4,
+		},
+		Dedup: DedupConfig{
+			Size: 100000,
+			TTL:  10 * time.Minute,
+		},
+		Eviction: EvictionConfig{
+			Mode:          EvictionModeEvict,
+			TargetRatio:   0.9,
+			HighWatermark: 0.95,
+			LowWatermark:  0.85,
+			RetryAfter:    5 * time.Second,
+		},
+		VisitorCap: VisitorCapConfig{
+			Overflow: VisitorCapApproximate,
+		},
+		DataQuality: DataQualityConfig{
+			SuspiciousShare: 0.5,
+			MinPageViews:    50,
+		},
+		Retention: RetentionConfig{
+			SweepInterval: time.Minute,
+		},
+		SlowQueryThreshold:  500 * time.Millisecond,
+		SlowQueryLogSize:    100,
+		IngestActivityTTL:   24 * time.Hour,
+		MetricsMaxEndpoints: 100,
+		ResponseFormat: ResponseFormatConfig{
+			DurationFormat: DurationFormatNanoseconds,
+			Precision:      -1,
+		},
+		ResponseCache: ResponseCacheConfig{
+			Enabled:    false,
+			TTL:        5 * time.Second,
+			MaxEntries: 10000,
+		},
+		AsyncIngest: AsyncIngestConfig{
+			Enabled:   false,
+			QueueSize: 10000,
+			Workers:   4,
+		},
+		Probes: ProbesConfig{
+			CheckTimeout:      2 * time.Second,
+			MinMemoryHeadroom: 0.1,
+			MaxKafkaLag:       10000,
+		},
+		Import: ImportConfig{
+			MaxEventsPerSecond: 10000,
+		},
+		Redis: RedisConfig{
+			Enabled:   false,
+			Address:   "localhost:6379",
+			KeyPrefix: "navtracker:",
+			Timeout:   2 * time.Second,
+		},
+		Cluster: ClusterConfig{
+			Interval:  5 * time.Second,
+			Timeout:   5 * time.Second,
+			Precision: 10,
+		},
+		Storage: StorageConfig{
+			Backend:         StorageBackendMemory,
+			Timeout:         2 * time.Second,
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			BatchSize:       500,
+			FlushInterval:   time.Second,
+		},
+		Snapshot: SnapshotConfig{
+			Enabled:        false,
+			Path:           "nav-tracker-snapshot.json",
+			FlushInterval:  time.Minute,
+			FlushMaxEvents: 10000,
+		},
+		ConfigHistory: ConfigHistoryConfig{
+			MaxVersions: 100,
+		},
+		Tiering: TieringConfig{
+			Enabled:       false,
+			Dir:           "nav-tracker-cold",
+			IdleAfter:     24 * time.Hour,
+			HotCacheSize:  10000,
+			SweepInterval: 5 * time.Minute,
+		},
+		Compaction: CompactionConfig{
+			Interval: time.Hour,
+		},
+		Realtime: RealtimeConfig{
+			Window: 5 * time.Minute,
+		},
+		Fixtures: FixturesConfig{
+			Record: false,
+			Dir:    "testdata/fixtures",
+		},
+		Tenancy: TenancyConfig{
+			Enabled:            false,
+			MaxTenants:         100,
+			QuotaCheckInterval: 30 * time.Second,
+		},
+		Auth: AuthConfig{
+			Mode: AuthModeAPIKey,
+			JWT: JWTConfig{
+				Algorithm:   JWTAlgorithmHS256,
+				JWKSRefresh: time.Hour,
+				Leeway:      30 * time.Second,
+				ScopeClaim:  "scope",
+				TenantClaim: "tenant",
+			},
+			Signing: SigningConfig{
+				Enabled:            false,
+				Tolerance:          5 * time.Minute,
+				NoncePruneInterval: time.Minute,
+			},
+		},
+		Alerting: AlertingConfig{
+			Enabled:        false,
+			Interval:       time.Minute,
+			WebhookTimeout: 10 * time.Second,
+			MaxRetries:     3,
+			RetryBackoff:   time.Second,
+		},
+		Reports: ReportsConfig{
+			Enabled:        false,
+			CheckInterval:  time.Minute,
+			WebhookTimeout: 10 * time.Second,
+			MaxRetries:     3,
+			RetryBackoff:   time.Second,
+			SMTP:           SMTPConfig{Port: 587},
+		},
+		Heartbeat: HeartbeatConfig{
+			Enabled:  false,
+			Interval: time.Minute,
+			Timeout:  10 * time.Second,
+		},
+		Syslog: SyslogConfig{
+			Enabled: false,
+			Network: "udp",
+			Address: ":5514",
+			Format:  "auto",
+			Fields: SyslogFieldMap{
+				VisitorID: "visitor_id",
+				URL:       "url",
+				SessionID: "session_id",
+				Timestamp: "timestamp",
+			},
+		},
+		Kafka: KafkaConfig{
+			Enabled: false,
+			GroupID: "nav-tracker",
+		},
+		Timestamps: TimestampConfig{
+			MaxFuture: 5 * time.Minute,
+			Policy:    TimestampClamp,
+		},
+		Archive: ArchiveConfig{
+			Enabled:          false,
+			Endpoint:         "https://s3.amazonaws.com",
+			Region:           "us-east-1",
+			Prefix:           "events/",
+			Interval:         time.Minute,
+			BatchSize:        10000,
+			MaxPendingEvents: 100000,
+			Timeout:          30 * time.Second,
+		},
+	}
+}
+
+// Clone returns a deep copy of c.
+func (c *Configuration) Clone() (*Configuration, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	clone := &Configuration{}
+	if err := json.Unmarshal(data, clone); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// RedactedSecret stands in for a secret in a Redacted configuration.
+const RedactedSecret = "[REDACTED]"
+
+// Redacted returns a deep copy of c with its secrets replaced by
+// RedactedSecret: passwords, access keys, the cluster and JWT secrets, the
+// pseudonymization salt, the signing secrets and the API keys tenants are
+// resolved from. Unset secrets stay empty.
+func (c *Configuration) Redacted() (*Configuration, error) {
+	clone, err := c.Clone()
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range clone.secrets() {
+		if *secret != "" {
+			*secret = RedactedSecret
+		}
+	}
+	for site := range clone.Auth.Signing.Secrets {
+		clone.Auth.Signing.Secrets[site] = RedactedSecret
+	}
+	if len(clone.Tenancy.APIKeys) > 0 {
+		keys := make([]string, 0, len(clone.Tenancy.APIKeys))
+		for key := range clone.Tenancy.APIKeys {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		redacted := make(map[string]string, len(keys))
+		for i, key := range keys {
+			redacted[fmt.Sprintf("%s %d", RedactedSecret, i+1)] = clone.Tenancy.APIKeys[key]
+		}
+		clone.Tenancy.APIKeys = redacted
+	}
+	return clone, nil
+}
+
+// KeepSecrets replaces every secret Redacted would hide with its value in
+// from, so a redacted configuration can be put back into effect.
+func (c *Configuration) KeepSecrets(from *Configuration) {
+	secrets, kept := c.secrets(), from.secrets()
+	for i := range secrets {
+		*secrets[i] = *kept[i]
+	}
+	c.Auth.Signing.Secrets = copyStrings(from.Auth.Signing.Secrets)
+	c.Tenancy.APIKeys = copyStrings(from.Tenancy.APIKeys)
+}
+
+func (c *Configuration) secrets() []*string {
+	return []*string{
+		&c.Privacy.Salt,
+		&c.Redis.Password,
+		&c.Cluster.Secret,
+		&c.Archive.SecretAccessKey,
+		&c.Auth.JWT.Secret,
+		&c.Reports.SMTP.Password,
+	}
+}
+
+func copyStrings(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+func (c *Configuration) Validate() error {
+	if c.Port == "" {
+		return fmt.Errorf("port is required")
+	}
+	if c.HTTP.ReadTimeout < 0 || c.HTTP.ReadHeaderTimeout < 0 || c.HTTP.WriteTimeout < 0 || c.HTTP.IdleTimeout < 0 {
+		return fmt.Errorf("http: timeouts must not be negative")
+	}
+	if c.HTTP.DrainTimeout <= 0 {
+		return fmt.Errorf("http: drain_timeout must be positive")
+	}
+
+	if c.RateLimit.Enabled {
+		if c.RateLimit.IPRequestsPerSecond <= 0 || c.RateLimit.IPBurst < 1 {
+			return fmt.Errorf("rate_limit: ip limits must be positive")
+		}
+		if c.RateLimit.KeyRequestsPerSecond <= 0 || c.RateLimit.KeyBurst < 1 {
+			return fmt.Errorf("rate_limit: key limits must be positive")
+		}
+		switch c.RateLimit.Headers {
+		case "", RateLimitHeadersLegacy, RateLimitHeadersDraft, RateLimitHeadersBoth, RateLimitHeadersNone:
+		default:
+			return fmt.Errorf("rate_limit: headers must be legacy, draft, both or none")
+		}
+	}
+
+	if err := c.Middleware.Validate(); err != nil {
+		return err
+	}
+	if err := c.CORS.Validate(); err != nil {
+		return err
+	}
+	if err := c.BodyLimit.Validate(); err != nil {
+		return err
+	}
+	if err := c.HandlerTimeout.Validate(); err != nil {
+		return err
+	}
+	if err := c.Validation.Validate(); err != nil {
+		return err
+	}
+	if c.CircuitBreaker.Enabled && (c.CircuitBreaker.FailureThreshold < 1 || c.CircuitBreaker.Cooldown <= 0) {
+		return fmt.Errorf("circuit_breaker: failure_threshold and cooldown must be positive")
+	}
+
+	if c.Privacy.HashVisitorIDs && c.Privacy.Salt == "" {
+		return fmt.Errorf("privacy: salt is required when hash_visitor_ids is set")
+	}
+	switch c.Privacy.AnonymizeIP {
+	case "", IPAnonymizeNone, IPAnonymizeTruncate:
+	case IPAnonymizeHash:
+		if c.Privacy.Salt == "" {
+			return fmt.Errorf("privacy: salt is required when anonymize_ip is hash")
+		}
+	default:
+		return fmt.Errorf("privacy: anonymize_ip must be none, truncate or hash")
+	}
+	for _, proxy := range c.ClientIP.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("client_ip: invalid trusted proxy %q: must be an IP or CIDR", proxy)
+		}
+	}
+
+	dimensions := make(map[string]bool, len(c.Dimensions))
+	for i, dimension := range c.Dimensions {
+		if !propertyKeyRegex.MatchString(dimension.Name) {
+			return fmt.Errorf("dimensions[%d]: name must be a valid property key", i)
+		}
+		if dimensions[dimension.Name] {
+			return fmt.Errorf("dimensions[%d]: duplicate dimension %s", i, dimension.Name)
+		}
+		dimensions[dimension.Name] = true
+		switch dimension.Mode {
+		case DimensionModeExact:
+		case DimensionModeHLL:
+			if dimension.Precision != 0 && (dimension.Precision < 4 || dimension.Precision > 16) {
+				return fmt.Errorf("dimensions[%d]: precision must be between 4 and 16", i)
+			}
+		default:
+			return fmt.Errorf("dimensions[%d]: mode must be exact or hll", i)
+		}
+	}
+
+	for i, rule := range c.URLCategories {
+		if rule.Category == "" {
+			return fmt.Errorf("url_categories[%d]: category is required", i)
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("url_categories[%d]: invalid pattern: %w", i, err)
+		}
+	}
+
+	switch c.TopURLs.Mode {
+	case "", TopURLsExact:
+	case TopURLsSketch:
+		if c.TopURLs.Size < 1 || c.TopURLs.SketchWidth < 1 || c.TopURLs.SketchDepth < 1 {
+			return fmt.Errorf("top_urls: size, sketch_width and sketch_depth must be positive")
+		}
+	default:
+		return fmt.Errorf("top_urls: mode must be exact or sketch")
+	}
+
+	if c.Sampling.Rate < 0 {
+		return fmt.Errorf("sampling: rate must not be negative")
+	}
+
+	switch c.Timestamps.Policy {
+	case TimestampReject, TimestampClamp, TimestampServer:
+	default:
+		return fmt.Errorf("timestamps: policy must be reject, clamp or server")
+	}
+	if c.Timestamps.MaxPast < 0 || c.Timestamps.MaxFuture < 0 {
+		return fmt.Errorf("timestamps: max_past and max_future must not be negative")
+	}
+
+	if c.Dedup.Size < 0 {
+		return fmt.Errorf("dedup: size must not be negative")
+	}
+	if c.Dedup.Size > 0 && c.Dedup.TTL <= 0 {
+		return fmt.Errorf("dedup: ttl must be positive")
+	}
+
+	if err := c.Denylist.Validate(); err != nil {
+		return err
+	}
+
+	if c.Eviction.MaxBytes < 0 {
+		return fmt.Errorf("eviction: max_bytes must not be negative")
+	}
+	switch c.Eviction.Mode {
+	case "", EvictionModeEvict:
+		if c.Eviction.MaxBytes > 0 && (c.Eviction.TargetRatio <= 0 || c.Eviction.TargetRatio >= 1) {
+			return fmt.Errorf("eviction: target_ratio must be in (0, 1)")
+		}
+	case EvictionModeBackpressure:
+		if c.Eviction.MaxBytes > 0 && (c.Eviction.LowWatermark <= 0 || c.Eviction.LowWatermark >= c.Eviction.HighWatermark || c.Eviction.HighWatermark > 1) {
+			return fmt.Errorf("eviction: watermarks must satisfy 0 < low_watermark < high_watermark <= 1")
+		}
+		if c.Eviction.RetryAfter < time.Second {
+			return fmt.Errorf("eviction: retry_after must be at least 1s")
+		}
+	default:
+		return fmt.Errorf("eviction: mode must be evict or backpressure")
+	}
+
+	if c.VisitorCap.MaxVisitorsPerURL < 0 {
+		return fmt.Errorf("visitor_cap: max_visitors_per_url must not be negative")
+	}
+	switch c.VisitorCap.Overflow {
+	case VisitorCapReject, VisitorCapApproximate, VisitorCapEvictOldest:
+	default:
+		return fmt.Errorf("visitor_cap: overflow must be reject, approximate or evict_oldest")
+	}
+
+	if c.DataQuality.SuspiciousShare <= 0 || c.DataQuality.SuspiciousShare > 1 {
+		return fmt.Errorf("data_quality: suspicious_share must be in (0, 1]")
+	}
+	if c.DataQuality.MinPageViews < 1 {
+		return fmt.Errorf("data_quality: min_page_views must be positive")
+	}
+	for i, visitorID := range c.DataQuality.ExcludedVisitorIDs {
+		if visitorID == "" {
+			return fmt.Errorf("data_quality.excluded_visitor_ids[%d]: must not be empty", i)
+		}
+	}
+
+	if c.Retention.MaxEventAge < 0 {
+		return fmt.Errorf("retention: max_event_age must not be negative")
+	}
+	for i, rule := range c.Retention.Rules {
+		if rule.TTL < 0 {
+			return fmt.Errorf("retention.rules[%d]: ttl must not be negative", i)
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("retention.rules[%d]: invalid pattern: %w", i, err)
+		}
+	}
+	if c.Retention.Enabled() && c.Retention.SweepInterval <= 0 {
+		return fmt.Errorf("retention: sweep_interval must be positive")
+	}
+
+	if c.Syslog.Enabled {
+		if c.Syslog.Network != "udp" && c.Syslog.Network != "tcp" {
+			return fmt.Errorf("syslog: network must be udp or tcp")
+		}
+		switch c.Syslog.Format {
+		case "auto", "kv", "json":
+		default:
+			return fmt.Errorf("syslog: format must be auto, kv or json")
+		}
+		if c.Syslog.Fields.VisitorID == "" || c.Syslog.Fields.URL == "" {
+			return fmt.Errorf("syslog: visitor_id and url fields are required")
+		}
+	}
+
+	if c.Kafka.Enabled && (len(c.Kafka.Brokers) == 0 || len(c.Kafka.Topics) == 0 || c.Kafka.GroupID == "") {
+		return fmt.Errorf("kafka: brokers, topics and group_id are required")
+	}
+
+	if c.Archive.Enabled {
+		if err := c.Archive.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Redis.Enabled && (c.Redis.Address == "" || c.Redis.Timeout <= 0) {
+		return fmt.Errorf("redis: address and a positive timeout are required")
+	}
+	if c.Redis.AuditSampleRate < 0 || c.Redis.AuditSampleRate > 1 {
+		return fmt.Errorf("redis: audit_sample_rate must be between 0 and 1")
+	}
+
+	if c.Cluster.Enabled {
+		if err := c.Cluster.Validate(); err != nil {
+			return err
+		}
+		if c.Redis.Enabled || (c.Storage.Backend != "" && c.Storage.Backend != StorageBackendMemory) {
+			return fmt.Errorf("cluster: replicated mode cannot be combined with redis, sqlite or postgres")
+		}
+	}
+
+	switch c.Storage.Backend {
+	case "", StorageBackendMemory:
+	case StorageBackendSQLite:
+		if c.Storage.DSN == "" || c.Storage.Timeout <= 0 {
+			return fmt.Errorf("storage: dsn and a positive timeout are required for sqlite")
+		}
+		if c.Redis.Enabled {
+			return fmt.Errorf("storage: the sqlite backend cannot be combined with redis")
+		}
+	case StorageBackendPostgres:
+		if c.Storage.DSN == "" || c.Storage.Timeout <= 0 {
+			return fmt.Errorf("storage: dsn and a positive timeout are required for postgres")
+		}
+		if c.Storage.MaxOpenConns < 1 || c.Storage.MaxIdleConns < 0 || c.Storage.MaxIdleConns > c.Storage.MaxOpenConns {
+			return fmt.Errorf("storage: max_open_conns must be positive and max_idle_conns between 0 and max_open_conns")
+		}
+		if c.Storage.ConnMaxLifetime < 0 {
+			return fmt.Errorf("storage: conn_max_lifetime must not be negative")
+		}
+		if c.Storage.BatchSize < 1 || c.Storage.FlushInterval <= 0 {
+			return fmt.Errorf("storage: batch_size and flush_interval must be positive for postgres")
+		}
+		if c.Redis.Enabled {
+			return fmt.Errorf("storage: the postgres backend cannot be combined with redis")
+		}
+	default:
+		return fmt.Errorf("storage: backend must be memory, sqlite or postgres")
+	}
+
+	if c.Snapshot.Enabled && c.Snapshot.Path == "" {
+		return fmt.Errorf("snapshot: path is required")
+	}
+	if c.Snapshot.FlushInterval < 0 || c.Snapshot.FlushMaxEvents < 0 {
+		return fmt.Errorf("snapshot: flush_interval and flush_max_events must not be negative")
+	}
+
+	if c.ConfigHistory.MaxVersions < 1 {
+		return fmt.Errorf("config_history: max_versions must be at least 1")
+	}
+
+	if c.Compaction.Interval < 0 {
+		return fmt.Errorf("compaction: interval must not be negative")
+	}
+
+	if w := c.Realtime.Window; w < time.Minute || w > MaxRealtimeWindow || w%time.Minute != 0 {
+		return fmt.Errorf("realtime: window must be a whole number of minutes from 1m to 1h")
+	}
+
+	if c.Heartbeat.Enabled && (c.Heartbeat.URL == "" || c.Heartbeat.Interval <= 0) {
+		return fmt.Errorf("heartbeat: url and a positive interval are required")
+	}
+
+	if c.MetricsMaxEndpoints < 1 {
+		return fmt.Errorf("metrics_max_endpoints must be positive")
+	}
+
+	if err := c.ResponseFormat.Validate(); err != nil {
+		return fmt.Errorf("response_format: %w", err)
+	}
+	if c.ResponseCache.Enabled && (c.ResponseCache.TTL <= 0 || c.ResponseCache.MaxEntries <= 0) {
+		return fmt.Errorf("response_cache: ttl and max_entries must be positive")
+	}
+
+	if c.AsyncIngest.Enabled && (c.AsyncIngest.QueueSize < 1 || c.AsyncIngest.Workers < 1) {
+		return fmt.Errorf("async_ingest: queue_size and workers must be positive")
+	}
+
+	if c.Probes.CheckTimeout <= 0 {
+		return fmt.Errorf("probes: check_timeout must be positive")
+	}
+	if c.Probes.MinMemoryHeadroom < 0 || c.Probes.MinMemoryHeadroom >= 1 || c.Probes.MaxKafkaLag < 0 {
+		return fmt.Errorf("probes: min_memory_headroom must be in [0, 1) and max_kafka_lag must not be negative")
+	}
+
+	if c.Import.MaxEventsPerSecond < 0 {
+		return fmt.Errorf("import: max_events_per_second must not be negative")
+	}
+
+	if c.Tenancy.Enabled {
+		if c.Tenancy.MaxTenants < 1 {
+			return fmt.Errorf("tenancy: max_tenants must be positive")
+		}
+		if err := c.Tenancy.Quota.Validate(); err != nil {
+			return fmt.Errorf("tenancy: %w", err)
+		}
+		if c.Tenancy.QuotaCheckInterval <= 0 {
+			return fmt.Errorf("tenancy: quota_check_interval must be positive")
+		}
+		if c.Tenancy.QuotaWebhook != "" {
+			parsed, err := url.Parse(c.Tenancy.QuotaWebhook)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return fmt.Errorf("tenancy: quota_webhook must be an http or https URL")
+			}
+		}
+		if c.Redis.Enabled || c.Storage.Backend == StorageBackendSQLite || c.Storage.Backend == StorageBackendPostgres || c.Snapshot.Enabled || c.Tiering.Enabled || c.AsyncIngest.Enabled || c.Reports.Enabled || c.Cluster.Enabled || c.Archive.Enabled {
+			return fmt.Errorf("tenancy: redis, sqlite, postgres, snapshots, tiering, async ingestion, reports, clustering and archival are not yet supported with multiple tenants")
+		}
+	}
+
+	switch c.Auth.Mode {
+	case "", AuthModeAPIKey:
+	case AuthModeJWT:
+		if err := c.Auth.JWT.Validate(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("auth: mode must be api_key or jwt")
+	}
+	if c.Auth.Signing.Enabled {
+		if len(c.Auth.Signing.Secrets) == 0 {
+			return fmt.Errorf("auth.signing: at least one site secret is required")
+		}
+		for site, secret := range c.Auth.Signing.Secrets {
+			if site == "" || secret == "" {
+				return fmt.Errorf("auth.signing: site IDs and secrets must not be empty")
+			}
+		}
+		if c.Auth.Signing.Tolerance <= 0 {
+			return fmt.Errorf("auth.signing: tolerance must be positive")
+		}
+		if c.Auth.Signing.NoncePruneInterval <= 0 {
+			return fmt.Errorf("auth.signing: nonce_prune_interval must be positive")
+		}
+	}
+
+	if c.Tiering.Enabled {
+		if c.Tiering.Dir == "" {
+			return fmt.Errorf("tiering: dir is required")
+		}
+		if c.Tiering.IdleAfter <= 0 || c.Tiering.SweepInterval <= 0 || c.Tiering.HotCacheSize < 0 {
+			return fmt.Errorf("tiering: idle_after and sweep_interval must be positive and hot_cache_size must not be negative")
+		}
+	}
+
+	if c.Alerting.Enabled {
+		if c.Alerting.Interval <= 0 || c.Alerting.MaxRetries < 0 || c.Alerting.RetryBackoff < 0 {
+			return fmt.Errorf("alerting: interval must be positive and retries must not be negative")
+		}
+		for i, rule := range c.Alerting.Rules {
+			if rule.Name == "" || rule.Webhook == "" {
+				return fmt.Errorf("alerting.rules[%d]: name and webhook are required", i)
+			}
+			switch rule.Metric {
+			case AlertMetricURLVisitors:
+				if rule.URL == "" || rule.Window <= 0 {
+					return fmt.Errorf("alerting.rules[%d]: url_visitors requires url and a positive window", i)
+				}
+			case AlertMetricErrorRate:
+			default:
+				return fmt.Errorf("alerting.rules[%d]: metric must be url_visitors or error_rate", i)
+			}
+		}
+	}
+
+	if c.Reports.Enabled {
+		if err := c.Reports.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Fixtures.Record && c.Fixtures.Dir == "" {
+		return fmt.Errorf("fixtures: dir is required")
+	}
+
+	if c.GeoIP.Enabled && c.GeoIP.DatabasePath == "" {
+		return fmt.Errorf("geoip: database_path is required")
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Property types. Properties are sent as strings; number and boolean
+// properties must parse as such.
+const (
+	PropertyTypeString  = "string"
+	PropertyTypeNumber  = "number"
+	PropertyTypeBoolean = "boolean"
+)
+
+// PropertyDefinition declares one property of an event type. Indexed
+// properties have their events counted per value, so event stats can be
+// filtered on them.
+type PropertyDefinition struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required,omitempty"`
+	Indexed  bool   `json:"indexed,omitempty"`
+}
+
+// PropertySchema lists the properties events of EventType may carry. Unless
+// AllowAdditional is set, properties it does not declare are refused.
+type PropertySchema struct {
+	EventType       string               `json:"event_type"`
+	Properties      []PropertyDefinition `json:"properties"`
+	AllowAdditional bool                 `json:"allow_additional,omitempty"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+}
+
+// FieldError is one field of a request that was refused, and why.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validate checks the event type and that each property is declared once,
+// with a valid name and a known type.
+func (s PropertySchema) Validate() error {
+	if err := ValidateEventType(s.EventType); err != nil {
+		return err
+	}
+	if len(s.Properties) > MaxProperties {
+		return fmt.Errorf("properties must have at most %d entries", MaxProperties)
+	}
+
+	names := make(map[string]bool, len(s.Properties))
+	for i, property := range s.Properties {
+		if !propertyKeyRegex.MatchString(property.Name) {
+			return fmt.Errorf("properties[%d]: name %q is invalid", i, property.Name)
+		}
+		if names[property.Name] {
+			return fmt.Errorf("properties[%d]: %s is declared twice", i, property.Name)
+		}
+		names[property.Name] = true
+
+		switch property.Type {
+		case PropertyTypeString, PropertyTypeNumber, PropertyTypeBoolean:
+		default:
+			return fmt.Errorf("properties[%d]: type must be string, number or boolean", i)
+		}
+	}
+	return nil
+}
+
+// Check returns the violations of properties against the schema, in the
+// order the schema declares them, then undeclared properties by name.
+func (s PropertySchema) Check(properties map[string]string) []FieldError {
+	var violations []FieldError
+	declared := make(map[string]bool, len(s.Properties))
+	for _, property := range s.Properties {
+		declared[property.Name] = true
+		field := "properties." + property.Name
+
+		value, exists := properties[property.Name]
+		if !exists {
+			if property.Required {
+				violations = append(violations, FieldError{Field: field, Message: "is required"})
+			}
+			continue
+		}
+		switch property.Type {
+		case PropertyTypeNumber:
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				violations = append(violations, FieldError{Field: field, Message: "must be a number"})
+			}
+		case PropertyTypeBoolean:
+			if _, err := strconv.ParseBool(value); err != nil {
+				violations = append(violations, FieldError{Field: field, Message: "must be a boolean"})
+			}
+		}
+	}
+
+	if !s.AllowAdditional {
+		var undeclared []FieldError
+		for name := range properties {
+			if !declared[name] {
+				undeclared = append(undeclared, FieldError{Field: "properties." + name, Message: "is not declared in the schema"})
+			}
+		}
+		sort.Slice(undeclared, func(i, j int) bool { return undeclared[i].Field < undeclared[j].Field })
+		violations = append(violations, undeclared...)
+	}
+	return violations
+}
+
+// Indexed reports whether the schema declares the property name indexed.
+func (s PropertySchema) Indexed(name string) bool {
+	for _, property := range s.Properties {
+		if property.Name == name {
+			return property.Indexed
+		}
+	}
+	return false
+}
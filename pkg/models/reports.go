@@ -0,0 +1,121 @@
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Report frequencies
+const (
+	ReportDaily  = "daily"
+	ReportWeekly = "weekly"
+)
+
+// Report formats
+const (
+	ReportFormatJSON = "json"
+	ReportFormatCSV  = "csv"
+	ReportFormatHTML = "html"
+)
+
+const (
+	DefaultReportTopURLs = 10
+	MaxReportTopURLs     = 100
+)
+
+// ReportSchedule generates a report at Hour UTC every day, or every week on
+// Weekday, covering the day or week that just ended: total visitors and page
+// views with their change from the period before, and the TopURLs URLs with
+// the most visitors. It is rendered in Format and delivered to Delivery.
+type ReportSchedule struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Frequency string         `json:"frequency"`
+	Hour      int            `json:"hour"`
+	Weekday   string         `json:"weekday,omitempty"`
+	Format    string         `json:"format"`
+	TopURLs   int            `json:"top_urls"`
+	Delivery  ReportDelivery `json:"delivery"`
+
+	CreatedAt time.Time  `json:"created_at"`
+	NextRun   time.Time  `json:"next_run"`
+	LastRun   *time.Time `json:"last_run,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// ReportDelivery lists where a report is sent: POSTed to Webhook and
+// emailed to each address in Email.
+type ReportDelivery struct {
+	Webhook string   `json:"webhook,omitempty"`
+	Email   []string `json:"email,omitempty"`
+}
+
+// ParseWeekday reads a weekday name such as "monday", ignoring case.
+func ParseWeekday(name string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(name, day.String()) {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// Validate checks the schedule's settings. An empty Format means json and a
+// zero TopURLs the default.
+func (s ReportSchedule) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch s.Frequency {
+	case ReportDaily:
+		if s.Weekday != "" {
+			return fmt.Errorf("weekday is only used by weekly schedules")
+		}
+	case ReportWeekly:
+		if _, ok := ParseWeekday(s.Weekday); !ok {
+			return fmt.Errorf("weekly schedules require a weekday such as monday")
+		}
+	default:
+		return fmt.Errorf("frequency must be daily or weekly")
+	}
+	if s.Hour < 0 || s.Hour > 23 {
+		return fmt.Errorf("hour must be between 0 and 23")
+	}
+	switch s.Format {
+	case "", ReportFormatJSON, ReportFormatCSV, ReportFormatHTML:
+	default:
+		return fmt.Errorf("format must be json, csv or html")
+	}
+	if s.TopURLs < 0 || s.TopURLs > MaxReportTopURLs {
+		return fmt.Errorf("top_urls must be between 0 and %d", MaxReportTopURLs)
+	}
+
+	if s.Delivery.Webhook == "" && len(s.Delivery.Email) == 0 {
+		return fmt.Errorf("delivery requires a webhook or email addresses")
+	}
+	if s.Delivery.Webhook != "" {
+		parsed, err := url.Parse(s.Delivery.Webhook)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("delivery.webhook must be an http or https URL")
+		}
+	}
+	for i, address := range s.Delivery.Email {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("delivery.email[%d]: invalid address", i)
+		}
+	}
+	return nil
+}
+
+// Report is one generated report.
+type Report struct {
+	Schedule    string           `json:"schedule"`
+	Name        string           `json:"name"`
+	Frequency   string           `json:"frequency"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	Summary     PeriodComparison `json:"summary"`
+	TopURLs     []*VisitorStats  `json:"top_urls"`
+}
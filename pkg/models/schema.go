@@ -0,0 +1,159 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// CurrentSchemaVersion is the version of the NavigationEvent JSON schema.
+// Events without a schema_version are read as version 1.
+const CurrentSchemaVersion = 1
+
+// EventSchema describes how events of one schema version map onto the
+// current NavigationEvent.
+type EventSchema struct {
+	Version int
+	// Renames maps a field name in this version to its current name.
+	Renames map[string]string
+	// Required lists the fields, by their name in this version, that its
+	// events must set.
+	Required []string
+	// Decode replaces the default decoding, which applies Renames and reads
+	// the result as the current NavigationEvent, for changes renames cannot
+	// express. Required fields are checked before it runs.
+	Decode func(fields map[string]json.RawMessage) (NavigationEvent, error)
+}
+
+// SchemaError reports an event that does not conform to its schema version.
+type SchemaError struct {
+	Version int
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	if e.Version == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("schema_version %d: %s", e.Version, e.Message)
+}
+
+var (
+	schemasMutex sync.RWMutex
+	eventSchemas = map[int]EventSchema{
+		1: {Version: 1},
+	}
+)
+
+// RegisterEventSchema adds the decoder for a schema version. It panics if
+// the version is not positive or already registered, so it belongs in init.
+func RegisterEventSchema(schema EventSchema) {
+	schemasMutex.Lock()
+	defer schemasMutex.Unlock()
+
+	if schema.Version < 1 {
+		panic(fmt.Sprintf("models: invalid event schema version %d", schema.Version))
+	}
+	if _, exists := eventSchemas[schema.Version]; exists {
+		panic(fmt.Sprintf("models: event schema version %d registered twice", schema.Version))
+	}
+	eventSchemas[schema.Version] = schema
+}
+
+// SchemaVersions returns the registered schema versions in ascending order.
+func SchemaVersions() []int {
+	schemasMutex.RLock()
+	defer schemasMutex.RUnlock()
+
+	versions := make([]int, 0, len(eventSchemas))
+	for version := range eventSchemas {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+func eventSchema(version int) (EventSchema, bool) {
+	schemasMutex.RLock()
+	defer schemasMutex.RUnlock()
+
+	schema, exists := eventSchemas[version]
+	return schema, exists
+}
+
+// DecodeEvent reads a JSON event in any registered schema version into the
+// current NavigationEvent, whose SchemaVersion records the version it was
+// written in. Syntax errors are returned as they are from encoding/json and
+// schema violations as a *SchemaError.
+func DecodeEvent(data []byte) (NavigationEvent, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return NavigationEvent{}, err
+	}
+
+	version := 1
+	if raw, exists := fields["schema_version"]; exists && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return NavigationEvent{}, &SchemaError{Message: "schema_version must be an integer"}
+		}
+	}
+	schema, exists := eventSchema(version)
+	if !exists {
+		return NavigationEvent{}, &SchemaError{Version: version, Message: fmt.Sprintf("unsupported version; supported versions are %v", SchemaVersions())}
+	}
+
+	for _, name := range schema.Required {
+		if raw, exists := fields[name]; !exists || string(raw) == "null" || string(raw) == `""` {
+			return NavigationEvent{}, &SchemaError{Version: version, Message: name + " is required"}
+		}
+	}
+
+	var event NavigationEvent
+	switch {
+	case schema.Decode != nil:
+		decoded, err := schema.Decode(fields)
+		if err != nil {
+			return NavigationEvent{}, &SchemaError{Version: version, Message: err.Error()}
+		}
+		event = decoded
+	case len(schema.Renames) == 0:
+		if err := json.Unmarshal(data, &event); err != nil {
+			return NavigationEvent{}, err
+		}
+	default:
+		for old, current := range schema.Renames {
+			if raw, exists := fields[old]; exists {
+				delete(fields, old)
+				fields[current] = raw
+			}
+		}
+		renamed, err := json.Marshal(fields)
+		if err != nil {
+			return NavigationEvent{}, err
+		}
+		if err := json.Unmarshal(renamed, &event); err != nil {
+			return NavigationEvent{}, err
+		}
+	}
+
+	if event.EventType != "" {
+		if err := ValidateEventType(event.EventType); err != nil {
+			return NavigationEvent{}, &SchemaError{Version: version, Message: err.Error()}
+		}
+	}
+	event.SchemaVersion = version
+	return event, nil
+}
+
+// validateSchemaVersion checks that an event built without DecodeEvent
+// names a registered schema version.
+func (ne *NavigationEvent) validateSchemaVersion() error {
+	if ne.SchemaVersion == 0 {
+		return nil
+	}
+	if _, exists := eventSchema(ne.SchemaVersion); !exists {
+		return &SchemaError{Version: ne.SchemaVersion, Message: "unsupported version"}
+	}
+	return nil
+}
@@ -1,70 +1,1017 @@
 package models
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type NavigationEvent struct {
-	VisitorID string    `json:"visitor_id"`
-	URL       string    `json:"url"`
+	// SchemaVersion is the event schema the client wrote; see DecodeEvent.
+	SchemaVersion int    `json:"schema_version,omitempty"`
+	EventID       string `json:"event_id,omitempty"`
+	VisitorID     string `json:"visitor_id"`
+	URL           string `json:"url"`
+	SessionID     string `json:"session_id,omitempty"`
+	Referrer      string `json:"referrer,omitempty"`
+	UserAgent     string `json:"user_agent,omitempty"`
+	Country       string `json:"country,omitempty"`
+	Region        string `json:"region,omitempty"`
+	City          string `json:"city,omitempty"`
+	// Language is the visitor's preferred language tag, such as en-US. An
+	// Accept-Language value is reduced to its preferred tag on recording.
+	Language  string    `json:"language,omitempty"`
 	Timestamp time.Time `json:"timestamp,omitempty"`
+	// IP is the client IP resolved at ingestion, anonymized as configured.
+	IP string `json:"ip,omitempty"`
+	// Properties carries custom attributes such as an account ID; configured
+	// dimensions count their distinct values per URL.
+	Properties map[string]string `json:"properties,omitempty"`
+	// EventType is pageview, the default, click or custom:<name>. Only page
+	// views count as visits; other types are counted per URL on their own.
+	EventType string `json:"event_type,omitempty"`
+	// Payload is arbitrary JSON passed on to the event stream and not stored.
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Event types. Custom types are named with the custom: prefix.
+const (
+	EventTypePageView     = "pageview"
+	EventTypeClick        = "click"
+	EventTypeCustomPrefix = "custom:"
+)
+
+// IsPageView reports whether the event is a navigation, which every event
+// without an EventType is.
+func (ne *NavigationEvent) IsPageView() bool {
+	return ne.EventType == "" || ne.EventType == EventTypePageView
+}
+
+// ErrorResponse is the body of every error response. Code is set for errors
+// a client is expected to act on, such as a suspended tenant, and Fields
+// for requests refused field by field.
+type ErrorResponse struct {
+	Error  string       `json:"error"`
+	Code   string       `json:"code,omitempty"`
+	Fields []FieldError `json:"fields,omitempty"`
 }
 
 type VisitorStats struct {
 	URL              string    `json:"url"`
 	DistinctVisitors int       `json:"distinct_visitors"`
 	TotalPageViews   int       `json:"total_page_views"`
+	SamplingRate     int       `json:"sampling_rate,omitempty"` // set when TotalPageViews is scaled from sampled views
+	LastVisit        time.Time `json:"last_visit,omitempty"`
 	LastUpdated      time.Time `json:"last_updated"`
 }
 
+type VisitorDetail struct {
+	VisitorID string    `json:"visitor_id"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	PageViews int64     `json:"page_views"`
+}
+
+type VisitorProfile struct {
+	VisitorID      string        `json:"visitor_id"`
+	FirstSeen      time.Time     `json:"first_seen"`
+	LastSeen       time.Time     `json:"last_seen"`
+	TotalPageViews int64         `json:"total_page_views"`
+	SessionCount   int           `json:"session_count"`
+	URLs           []*VisitorURL `json:"urls"`
+	// Properties are the latest custom properties the visitor sent.
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type VisitorURL struct {
+	URL       string    `json:"url"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	PageViews int64     `json:"page_views"`
+}
+
+type Session struct {
+	SessionID string        `json:"session_id"`
+	VisitorID string        `json:"visitor_id"`
+	StartTime time.Time     `json:"start_time"`
+	EndTime   time.Time     `json:"end_time"`
+	Duration  time.Duration `json:"duration"`
+	PageViews int           `json:"page_views"`
+	URLs      []string      `json:"urls"`
+}
+
+type SystemStats struct {
+	TotalUniqueURLs      int                  `json:"total_unique_urls"`
+	TotalPageViews       int64                `json:"total_page_views"`
+	TotalSessions        int                  `json:"total_sessions"`
+	AverageSessionLength time.Duration        `json:"average_session_length"`
+	PagesPerSession      float64              `json:"pages_per_session"`
+	Syslog               *SyslogStats         `json:"syslog,omitempty"`
+	Kafka                *KafkaStats          `json:"kafka,omitempty"`
+	Archive              *ArchiveStats        `json:"archive,omitempty"`
+	Signing              *SigningStats        `json:"signing,omitempty"`
+	IngestQueue          *IngestQueueStats    `json:"ingest_queue,omitempty"`
+	Retention            *RetentionStats      `json:"retention,omitempty"`
+	Persistence          *FlushStats          `json:"persistence,omitempty"`
+	Tiering              *TieringStats        `json:"tiering,omitempty"`
+	Compaction           *CompactionStats     `json:"compaction,omitempty"`
+	Dedup                *DedupStats          `json:"dedup,omitempty"`
+	Eviction             *EvictionStats       `json:"eviction,omitempty"`
+	VisitorCap           *VisitorCapStats     `json:"visitor_cap,omitempty"`
+	Sampling             *SamplingStats       `json:"sampling,omitempty"`
+	Timestamps           *TimestampStats      `json:"timestamps,omitempty"`
+	Denylist             *DenylistStats       `json:"denylist,omitempty"`
+	CircuitBreaker       *CircuitBreakerStats `json:"circuit_breaker,omitempty"`
+	URLDistribution      *URLDistribution     `json:"url_distribution,omitempty"`
+	// Degraded names the resources under pressure, such as DegradedMemory
+	// while memory backpressure refuses events.
+	Degraded  []string  `json:"degraded,omitempty"`
+	Tenant    string    `json:"tenant,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type FlushStats struct {
+	PendingEvents     int64         `json:"pending_events"`
+	Flushes           int64         `json:"flushes"`
+	FailedFlushes     int64         `json:"failed_flushes"`
+	LastFlush         time.Time     `json:"last_flush"`
+	LastFlushDuration time.Duration `json:"last_flush_duration"`
+	MaxLossWindow     time.Duration `json:"max_loss_window"`
+}
+
+// CompactionResult reports one compaction: the bytes of disk space it freed
+// from cold tier files and the snapshot, and how long it took. URLs and
+// VisitorRecords are held by the snapshot it wrote; DroppedURLs and
+// DroppedVisitorRecords were in the snapshot it replaced but not in the new
+// one, as they were deleted, expired or evicted since, so a restart no
+// longer restores them. Error is set on failed runs.
+type CompactionResult struct {
+	Trigger               string        `json:"trigger"`
+	StartedAt             time.Time     `json:"started_at"`
+	Duration              time.Duration `json:"duration"`
+	ReclaimedBytes        int64         `json:"reclaimed_bytes"`
+	ColdFiles             int           `json:"cold_files_rewritten"`
+	SnapshotBytes         int64         `json:"snapshot_bytes"`
+	TempFiles             int           `json:"temp_files_removed"`
+	URLs                  int           `json:"urls"`
+	VisitorRecords        int64         `json:"visitor_records"`
+	DroppedURLs           int           `json:"dropped_urls"`
+	DroppedVisitorRecords int64         `json:"dropped_visitor_records"`
+	Error                 string        `json:"error,omitempty"`
+}
+
+// Compaction triggers
+const (
+	CompactionManual    = "manual"
+	CompactionScheduled = "scheduled"
+)
+
+// CompactionStats sums the compactions run so far. DroppedURLs and
+// DroppedVisitorRecords total those of each run, to explain drops in the
+// counts restored from the snapshot.
+type CompactionStats struct {
+	Interval              time.Duration     `json:"interval"`
+	Runs                  int64             `json:"runs"`
+	FailedRuns            int64             `json:"failed_runs"`
+	ReclaimedBytes        int64             `json:"reclaimed_bytes"`
+	DroppedURLs           int64             `json:"dropped_urls"`
+	DroppedVisitorRecords int64             `json:"dropped_visitor_records"`
+	Last                  *CompactionResult `json:"last,omitempty"`
+	LastError             string            `json:"last_error,omitempty"`
+}
+
+type TieringStats struct {
+	HotURLs    int   `json:"hot_urls"`
+	ColdURLs   int   `json:"cold_urls"`
+	ColdBytes  int64 `json:"cold_bytes"`
+	Demotions  int64 `json:"demotions"`
+	Promotions int64 `json:"promotions"`
+}
+
+type SyslogStats struct {
+	Received  int64 `json:"received"`
+	Accepted  int64 `json:"accepted"`
+	Malformed int64 `json:"malformed"`
+	Rejected  int64 `json:"rejected"`
+}
+
+// KafkaStats counts consumed messages; Lag is the number of messages behind
+// the latest offsets of the assigned partitions.
+type KafkaStats struct {
+	Received  int64 `json:"received"`
+	Accepted  int64 `json:"accepted"`
+	Malformed int64 `json:"malformed"`
+	Rejected  int64 `json:"rejected"`
+	Lag       int64 `json:"lag"`
+}
+
+// TimestampStats counts the events whose client timestamps were out of the
+// acceptance window, by what became of them, and those dated by the server
+// because ServerTime ignores client timestamps.
+type TimestampStats struct {
+	MaxPast    time.Duration `json:"max_past"`
+	MaxFuture  time.Duration `json:"max_future"`
+	Policy     string        `json:"policy"`
+	ServerTime bool          `json:"server_time"`
+	Clamped    int64         `json:"clamped"`
+	Overridden int64         `json:"overridden"`
+	Rejected   int64         `json:"rejected"`
+}
+
+// ArchiveStats describes event archival. Pending events are waiting to be
+// uploaded, including those of failed uploads; dropped events were refused
+// because too many were pending.
+type ArchiveStats struct {
+	Bucket         string    `json:"bucket"`
+	Objects        int64     `json:"objects"`
+	ArchivedEvents int64     `json:"archived_events"`
+	UploadedBytes  int64     `json:"uploaded_bytes"`
+	FailedUploads  int64     `json:"failed_uploads"`
+	PendingEvents  int       `json:"pending_events"`
+	DroppedEvents  int64     `json:"dropped_events"`
+	LastUpload     time.Time `json:"last_upload"`
+	LastObject     string    `json:"last_object,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+	LastErrorAt    time.Time `json:"last_error_at"`
+}
+
+// SigningStats describes signed ingest. Replays counts requests refused for
+// reusing a nonce their site had already signed with; tracked nonces are
+// remembered until their signatures leave the tolerance.
+type SigningStats struct {
+	TrackedNonces int   `json:"tracked_nonces"`
+	Replays       int64 `json:"replays"`
+	PrunedNonces  int64 `json:"pruned_nonces"`
+}
+
+// IngestQueueStats describes the async ingestion queue. Failed events were
+// dequeued but rejected by the tracker; dropped events were refused because
+// the queue was full.
+type IngestQueueStats struct {
+	Depth     int   `json:"depth"`
+	Capacity  int   `json:"capacity"`
+	Workers   int   `json:"workers"`
+	Enqueued  int64 `json:"enqueued"`
+	Processed int64 `json:"processed"`
+	Failed    int64 `json:"failed"`
+	Dropped   int64 `json:"dropped"`
+}
+
+type DedupStats struct {
+	Hits       int64 `json:"hits"`
+	TrackedIDs int   `json:"tracked_ids"`
+}
+
+type DenylistStats struct {
+	URLRules          int   `json:"url_rules"`
+	ReferrerRules     int   `json:"referrer_rules"`
+	RejectedURLs      int64 `json:"rejected_urls"`
+	RejectedReferrers int64 `json:"rejected_referrers"`
+}
+
+// DegradedMemory is the SystemStats.Degraded entry reported while memory
+// backpressure refuses events.
+const DegradedMemory = "memory"
+
+// EvictionStats reports the tracked size of the URL statistics. In
+// backpressure mode, Backpressure tells whether events are being refused,
+// since DegradedSince, and RejectedEvents counts the events refused.
+type EvictionStats struct {
+	TrackedBytes    int64     `json:"tracked_bytes"`
+	MaxBytes        int64     `json:"max_bytes"`
+	Mode            string    `json:"mode"`
+	EvictedURLs     int64     `json:"evicted_urls"`
+	EvictedVisitors int64     `json:"evicted_visitors"`
+	LastEviction    time.Time `json:"last_eviction"`
+	Backpressure    bool      `json:"backpressure,omitempty"`
+	DegradedSince   time.Time `json:"degraded_since,omitempty"`
+	RejectedEvents  int64     `json:"rejected_events,omitempty"`
+}
+
+// VisitorCapStats counts the events from visitors beyond a URL's visitor
+// cap, and the visitors evicted to make room for them.
+type VisitorCapStats struct {
+	MaxVisitorsPerURL int    `json:"max_visitors_per_url"`
+	Overflow          string `json:"overflow"`
+	OverflowEvents    int64  `json:"overflow_events"`
+	EvictedVisitors   int64  `json:"evicted_visitors"`
+}
+
+// SamplingStats counts the repeat views seen and recorded in sampling mode.
+// EffectiveRate is the share of them recorded, which tends to 1/Rate.
+type SamplingStats struct {
+	Rate          int     `json:"rate"`
+	RepeatViews   int64   `json:"repeat_views"`
+	RecordedViews int64   `json:"recorded_views"`
+	EffectiveRate float64 `json:"effective_rate"`
+}
+
+// VisitorCapStatus reports whether a URL has reached the visitor cap. Its
+// distinct visitors are Approximate once visitors beyond the cap have been
+// counted in a sketch.
+type VisitorCapStatus struct {
+	MaxVisitors int    `json:"max_visitors"`
+	Overflow    string `json:"overflow"`
+	Reached     bool   `json:"reached"`
+	Approximate bool   `json:"approximate"`
+}
+
+// ClusterStatus describes this node in replicated mode. Origins counts the
+// node incarnations whose counters it holds, its own included.
+type ClusterStatus struct {
+	Node    string        `json:"node"`
+	Origin  string        `json:"origin"`
+	Origins int           `json:"origins"`
+	URLs    int           `json:"urls"`
+	Merges  int64         `json:"merges"`
+	Peers   []ClusterPeer `json:"peers"`
+}
+
+// ClusterPeer reports the pushes to one peer. Pending is the number of
+// counters changed since its last successful push.
+type ClusterPeer struct {
+	URL       string    `json:"url"`
+	Pushes    int64     `json:"pushes"`
+	Failures  int64     `json:"failures"`
+	Pending   int       `json:"pending"`
+	LastPush  time.Time `json:"last_push,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// URLDistribution describes how visitors and page views are spread across
+// URLs. The page view percentiles are estimated from buckets at most an
+// eighth as wide as the counts in them, and are exact below 8 views.
+type URLDistribution struct {
+	Visitors        VisitorHistogram `json:"visitors"`
+	MedianPageViews float64          `json:"median_page_views"`
+	P90PageViews    float64          `json:"p90_page_views"`
+	P99PageViews    float64          `json:"p99_page_views"`
+}
+
+// VisitorHistogram counts URLs by their number of distinct visitors. URLs
+// whose only visitors were excluded are not counted.
+type VisitorHistogram struct {
+	One             int `json:"1"`
+	TwoToTen        int `json:"2-10"`
+	ElevenToHundred int `json:"11-100"`
+	OverHundred     int `json:"101+"`
+}
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// CircuitBreakerStats describes the counter backend's circuit breaker. Trips
+// counts how often it opened and Rejected the calls failed while it was open.
+type CircuitBreakerStats struct {
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Trips               int64     `json:"trips"`
+	Rejected            int64     `json:"rejected"`
+	OpenedAt            time.Time `json:"opened_at,omitempty"`
+}
+
+type RetentionStats struct {
+	ExpiringURLs    int       `json:"expiring_urls"`
+	ExpiredURLs     int64     `json:"expired_urls"`
+	ExpiredVisitors int64     `json:"expired_visitors"`
+	LastSweep       time.Time `json:"last_sweep,omitempty"`
+}
+
+// Component lifecycle states
+const (
+	ComponentPending  = "pending"
+	ComponentStarting = "starting"
+	ComponentReady    = "ready"
+	ComponentFailed   = "failed"
+	ComponentStopped  = "stopped"
+)
+
+type AccuracyReport struct {
+	SampledURLs       int            `json:"sampled_urls"`
+	MeanRelativeError float64        `json:"mean_relative_error"`
+	MaxRelativeError  float64        `json:"max_relative_error"`
+	URLs              []*URLAccuracy `json:"urls"`
+	GeneratedAt       time.Time      `json:"generated_at"`
+}
+
+type URLAccuracy struct {
+	URL           string  `json:"url"`
+	Exact         int64   `json:"exact"`
+	Approximate   int64   `json:"approximate"`
+	RelativeError float64 `json:"relative_error"`
+}
+
+// TenantInfo describes a tenant. QuotaUsed is the highest share of any of
+// its quota's limits that Usage takes up, as a percentage.
+type TenantInfo struct {
+	ID        string       `json:"id"`
+	State     string       `json:"state"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	Quota     TenantQuota  `json:"quota"`
+	Usage     *TenantUsage `json:"usage,omitempty"`
+	QuotaUsed float64      `json:"quota_used"`
+	Stats     *SystemStats `json:"stats,omitempty"`
+}
+
+// TenantUsage is what a tracker holds: the approximate bytes of its URL
+// statistics, its URLs and its visitors.
+type TenantUsage struct {
+	Bytes    int64 `json:"bytes"`
+	URLs     int   `json:"urls"`
+	Visitors int   `json:"visitors"`
+}
+
+type TenantAuditEntry struct {
+	Time   time.Time `json:"time"`
+	Tenant string    `json:"tenant"`
+	From   string    `json:"from,omitempty"`
+	To     string    `json:"to"`
+	Actor  string    `json:"actor,omitempty"`
+}
+
+const (
+	DeletionVisitor = "visitor"
+	DeletionURL     = "url"
+)
+
+// Copies of events outside the tracker, listed in
+// DeletionAuditEntry.Unreached.
+const (
+	DeletionCopyArchive = "archive"
+	DeletionCopyKafka   = "kafka"
+	DeletionCopySyslog  = "syslog"
+)
+
+// Origins of configuration versions.
+const (
+	ConfigStartup   = "startup"
+	ConfigUpdate    = "update"
+	ConfigRollback  = "rollback"
+	ConfigPromotion = "canary_promotion"
+)
+
+// ConfigVersion is one configuration in the change history: the one in
+// effect at startup, or one accepted through the config API, with the
+// sections it changed and who changed them. RollbackOf is the version a
+// rollback restored. Config is redacted, and left out of history listings.
+type ConfigVersion struct {
+	Version    int            `json:"version"`
+	Time       time.Time      `json:"time"`
+	Origin     string         `json:"origin"`
+	Actor      string         `json:"actor,omitempty"`
+	Changed    []string       `json:"changed"`
+	RollbackOf int            `json:"rollback_of,omitempty"`
+	Config     *Configuration `json:"config,omitempty"`
+}
+
+// ConfigCanary is a configuration tried on Percent of the visitors whose
+// events are ingested, alongside the stable configuration the others stay
+// on, with the events of each side counted. Config is redacted.
+type ConfigCanary struct {
+	Percent   int            `json:"percent"`
+	Sections  []string       `json:"sections"`
+	StartedAt time.Time      `json:"started_at"`
+	Actor     string         `json:"actor,omitempty"`
+	Stable    CanaryArmStats `json:"stable"`
+	Canary    CanaryArmStats `json:"canary"`
+	Config    *Configuration `json:"config"`
+}
+
+// CanaryArmStats counts the events validated against one side of a
+// configuration canary: those refused, and the URLs first recorded from
+// them. URLsPerThousand is the URL cardinality growth, in new URLs per
+// 1,000 events.
+type CanaryArmStats struct {
+	Events          int64   `json:"events"`
+	Rejected        int64   `json:"rejected"`
+	RejectionRate   float64 `json:"rejection_rate"`
+	NewURLs         int64   `json:"new_urls"`
+	URLsPerThousand float64 `json:"new_urls_per_1000_events"`
+}
+
+// NewCanaryArmStats returns the stats of a canary side with its rates.
+func NewCanaryArmStats(events, rejected, newURLs int64) CanaryArmStats {
+	stats := CanaryArmStats{Events: events, Rejected: rejected, NewURLs: newURLs}
+	if events > 0 {
+		stats.RejectionRate = float64(rejected) / float64(events)
+		stats.URLsPerThousand = float64(newURLs) * 1000 / float64(events)
+	}
+	return stats
+}
+
+// Add returns the stats of both sides' events together.
+func (s CanaryArmStats) Add(other CanaryArmStats) CanaryArmStats {
+	return NewCanaryArmStats(s.Events+other.Events, s.Rejected+other.Rejected, s.NewURLs+other.NewURLs)
+}
+
+// DeletionAuditEntry records a data deletion request: what was deleted, by
+// whom, and how much data it removed.
+type DeletionAuditEntry struct {
+	Time      time.Time `json:"time"`
+	Kind      string    `json:"kind"`
+	Target    string    `json:"target"`
+	Actor     string    `json:"actor,omitempty"`
+	URLs      int       `json:"urls"`
+	Visitors  int       `json:"visitors"`
+	PageViews int64     `json:"page_views"`
+	Sessions  int       `json:"sessions"`
+	// BackendError is set when the counter backend could not be purged too;
+	// the deletion is safe to repeat.
+	BackendError string `json:"backend_error,omitempty"`
+	// Unreached lists the configured copies of events outside the tracker,
+	// which the deletion cannot purge: the archive, and the Kafka topics and
+	// syslog senders events were ingested from.
+	Unreached []string `json:"unreached,omitempty"`
+}
+
+// BuildInfo identifies the binary that served a response or wrote a data file.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+type ComponentStatus struct {
+	Name      string        `json:"name"`
+	State     string        `json:"state"`
+	DependsOn []string      `json:"depends_on,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	StartedAt time.Time     `json:"started_at,omitempty"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// Readiness check outcomes.
+const (
+	CheckPass = "pass"
+	CheckFail = "fail"
+)
+
+type CheckResult struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// SegmentStats counts a URL's visitors whose latest value of a property is
+// Value.
+type SegmentStats struct {
+	Value            string `json:"value"`
+	DistinctVisitors int    `json:"distinct_visitors"`
+}
+
+type ReferrerStats struct {
+	Domain           string    `json:"domain"`
+	DistinctVisitors int       `json:"distinct_visitors"`
+	FirstSeen        time.Time `json:"first_seen"`
+	LastSeen         time.Time `json:"last_seen"`
+}
+
+type DeviceBreakdown struct {
+	Browsers         map[string]int64 `json:"browsers"`
+	OperatingSystems map[string]int64 `json:"operating_systems"`
+	DeviceTypes      map[string]int64 `json:"device_types"`
+}
+
+type CountryStats struct {
+	Country          string `json:"country"`
+	DistinctVisitors int    `json:"distinct_visitors"`
+	PageViews        int64  `json:"page_views"`
+}
+
+type LanguageStats struct {
+	Language         string `json:"language"`
+	DistinctVisitors int    `json:"distinct_visitors"`
+	PageViews        int64  `json:"page_views"`
+}
+
+type CategoryStats struct {
+	Category         string `json:"category"`
+	DistinctVisitors int    `json:"distinct_visitors"`
+	TotalPageViews   int64  `json:"total_page_views"`
+	URLCount         int    `json:"url_count"`
+}
+
+// URLPattern groups URLs for aggregate stats. A pattern starting with "/"
+// is matched against the URL's path, any other against the whole URL; "*"
+// matches within one path segment and "**" across segments.
+type URLPattern struct {
+	Pattern   string    `json:"pattern"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PatternStats struct {
+	Pattern          string `json:"pattern"`
+	DistinctVisitors int    `json:"distinct_visitors"`
+	TotalPageViews   int64  `json:"total_page_views"`
+	URLCount         int    `json:"url_count"`
+}
+
+type CohortRow struct {
+	CohortStart time.Time `json:"cohort_start"`
+	Visitors    int       `json:"visitors"`
+	Retention   []float64 `json:"retention"`
+}
+
+// Data quality reasons
+const (
+	QualityReasonPlaceholder     = "placeholder"
+	QualityReasonOverRepresented = "over_represented"
+)
+
+// SuspiciousVisitorID is a visitor ID that may stand for many real visitors.
+// URLs and PageViews cover the URLs it was flagged on; MaxShare is the largest
+// fraction of one URL's page views it accounts for.
+type SuspiciousVisitorID struct {
+	VisitorID   string   `json:"visitor_id"`
+	Reasons     []string `json:"reasons"`
+	URLs        int      `json:"urls"`
+	PageViews   int64    `json:"page_views"`
+	MaxShare    float64  `json:"max_share"`
+	MaxShareURL string   `json:"max_share_url"`
+}
+
+type DataQualityReport struct {
+	GeneratedAt        time.Time              `json:"generated_at"`
+	ExcludedVisitorIDs []string               `json:"excluded_visitor_ids"`
+	ExcludedPageViews  int64                  `json:"excluded_page_views"`
+	Suspicious         []*SuspiciousVisitorID `json:"suspicious"`
+}
+
+// Actives windows
+const (
+	ActiveWindowDay   = "day"
+	ActiveWindowWeek  = "week"
+	ActiveWindowMonth = "month"
+)
+
+// ActiveVisitors counts the distinct visitors seen on URL, or on any URL when
+// it is empty, between Start and End.
+type ActiveVisitors struct {
+	URL      string    `json:"url,omitempty"`
+	Window   string    `json:"window"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Visitors int       `json:"visitors"`
+}
+
+// RealtimeVisitors counts the visitors online now: those with an event
+// during the Window before AsOf, on URL or on any URL when it is empty.
+type RealtimeVisitors struct {
+	URL      string        `json:"url,omitempty"`
+	Window   time.Duration `json:"window"`
+	AsOf     time.Time     `json:"as_of"`
+	Visitors int           `json:"visitors"`
+}
+
+// MaxComparePeriodDays is the longest period that can be compared with the
+// one before it within the month of daily actives kept.
+const MaxComparePeriodDays = 15
+
+// PeriodStats counts the distinct visitors and page views between Start and End.
+type PeriodStats struct {
+	Start            time.Time `json:"start"`
+	End              time.Time `json:"end"`
+	DistinctVisitors int       `json:"distinct_visitors"`
+	PageViews        int64     `json:"page_views"`
+}
+
+// PeriodDelta is the change from the previous period to the current one.
+// The percentages are nil when the previous period had none.
+type PeriodDelta struct {
+	DistinctVisitors        int      `json:"distinct_visitors"`
+	DistinctVisitorsPercent *float64 `json:"distinct_visitors_percent"`
+	PageViews               int64    `json:"page_views"`
+	PageViewsPercent        *float64 `json:"page_views_percent"`
+}
+
+// PeriodComparison compares a period ending today with the one before it, on
+// URL or on any URL when it is empty.
+type PeriodComparison struct {
+	URL      string      `json:"url,omitempty"`
+	Period   string      `json:"period"`
+	Current  PeriodStats `json:"current"`
+	Previous PeriodStats `json:"previous"`
+	Change   PeriodDelta `json:"change"`
+}
+
+// MaxBatchStatsURLs is the most URLs one batch stats request may ask for.
+const MaxBatchStatsURLs = 100
+
+// BatchStatsRequest asks for the stats of several URLs at once. When From is
+// set, each URL's visitors and page views are counted over the whole UTC days
+// from From to To, which defaults to now, instead of over all time.
+type BatchStatsRequest struct {
+	URLs []string  `json:"urls"`
+	From time.Time `json:"from,omitempty"`
+	To   time.Time `json:"to,omitempty"`
+}
+
+// BatchStatsResult holds one URL's stats in a batch: Stats without a time
+// range, Period with one, or Error when the URL is invalid.
+type BatchStatsResult struct {
+	URL    string        `json:"url"`
+	Stats  *VisitorStats `json:"stats,omitempty"`
+	Period *PeriodStats  `json:"period,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// URLOverlap splits the distinct visitors of two URLs into those who visited
+// both and those who visited only one. Jaccard is Both over the visitors of
+// either, or 0 when neither has any.
+type URLOverlap struct {
+	URLA    string  `json:"url_a"`
+	URLB    string  `json:"url_b"`
+	Both    int     `json:"both"`
+	OnlyA   int     `json:"only_a"`
+	OnlyB   int     `json:"only_b"`
+	Jaccard float64 `json:"jaccard"`
+}
+
+// EventTypeCount counts the events of one type recorded for a URL.
+type EventTypeCount struct {
+	Type     string    `json:"type"`
+	Count    int64     `json:"count"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+type EventTypeStats struct {
+	URL    string           `json:"url"`
+	Events []EventTypeCount `json:"events"`
+}
+
+type Funnel struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Steps     []string  `json:"steps"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FunnelStep struct {
+	URL            string  `json:"url"`
+	Visitors       int     `json:"visitors"`
+	ConversionRate float64 `json:"conversion_rate"`
+	DropOffRate    float64 `json:"drop_off_rate"`
+}
+
+type FunnelReport struct {
+	FunnelID    string       `json:"funnel_id"`
+	Name        string       `json:"name"`
+	Steps       []FunnelStep `json:"steps"`
+	GeneratedAt time.Time    `json:"generated_at"`
+}
+
+// PathTransition is one page reached directly after (or before) a URL within
+// a session. Probability is Count as a share of all transitions out of (or
+// into) the URL.
+type PathTransition struct {
+	URL         string  `json:"url"`
+	Count       int     `json:"count"`
+	Probability float64 `json:"probability"`
+}
+
+type PathReport struct {
+	URL           string           `json:"url"`
+	TotalNext     int              `json:"total_next"`
+	TotalPrevious int              `json:"total_previous"`
+	Next          []PathTransition `json:"next"`
+	Previous      []PathTransition `json:"previous"`
+}
+
+// SessionPage is a URL that starts (or ends) sessions. Share is Sessions as
+// a percentage of all sessions.
+type SessionPage struct {
+	URL      string  `json:"url"`
+	Sessions int     `json:"sessions"`
+	Share    float64 `json:"share"`
+}
+
+// SessionPagesReport lists the most common entry or exit pages.
+type SessionPagesReport struct {
+	TotalSessions int           `json:"total_sessions"`
+	Pages         []SessionPage `json:"pages"`
+}
+
+// DryRunResult is the event an ingest request would store, after
+// normalization and defaults, and how it would be counted.
+type DryRunResult struct {
+	DryRun     bool            `json:"dry_run"`
+	Event      NavigationEvent `json:"event"`
+	Duplicate  bool            `json:"duplicate"`
+	Excluded   bool            `json:"excluded"`
+	NewURL     bool            `json:"new_url"`
+	NewVisitor bool            `json:"new_visitor"`
+	// OverVisitorCap is set when the event is from a new visitor of a URL
+	// that has reached the visitor cap.
+	OverVisitorCap bool `json:"over_visitor_cap,omitempty"`
+}
+
+// Bulk URL operations
+const (
+	BulkDelete     = "delete"
+	BulkMerge      = "merge"
+	BulkCategorize = "categorize"
+	BulkFreeze     = "freeze"
+	BulkUnfreeze   = "unfreeze"
+	BulkRetention  = "retention"
+)
+
+// Bulk job states
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+)
+
+// BulkOperation applies one operation to the listed URLs and to every tracked
+// URL matching Pattern. Target is the canonical URL for merge, Category the
+// new category for categorize, and TTL the new retention for retention ("0s"
+// keeps the URL forever).
+type BulkOperation struct {
+	Operation string   `json:"operation"`
+	URLs      []string `json:"urls,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"`
+	Target    string   `json:"target,omitempty"`
+	Category  string   `json:"category,omitempty"`
+	TTL       string   `json:"ttl,omitempty"`
+}
+
+type BulkJob struct {
+	ID          string        `json:"id"`
+	Status      string        `json:"status"`
+	Operation   BulkOperation `json:"operation"`
+	SubmittedAt time.Time     `json:"submitted_at"`
+	StartedAt   time.Time     `json:"started_at,omitempty"`
+	FinishedAt  time.Time     `json:"finished_at,omitempty"`
+	Matched     int           `json:"matched"`
+	Applied     int           `json:"applied"`
+	Skipped     int           `json:"skipped"`
+}
+
+// ImportProgress reports an import's progress and, once Complete, its
+// outcome. Errors counts rejected lines by error message and ErrorSamples
+// keeps the first few with their line numbers.
+type ImportProgress struct {
+	Processed    int            `json:"processed"`
+	Imported     int            `json:"imported"`
+	Failed       int            `json:"failed"`
+	Errors       map[string]int `json:"errors,omitempty"`
+	ErrorSamples []ImportError  `json:"error_samples,omitempty"`
+	Complete     bool           `json:"complete"`
+	Elapsed      time.Duration  `json:"elapsed"`
+}
+
+type ImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type ExportRecord struct {
+	URL       string    `json:"url"`
+	VisitorID string    `json:"visitor_id"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	PageViews int64     `json:"page_views"`
+}
+
 const (
 	MinVisitorIDLength = 1
 	MaxVisitorIDLength = 255
 	MaxURLLength       = 2048
+	MaxUserAgentLength = 1024
+	MaxGeoFieldLength  = 128
+	MaxLanguageLength  = 512
+	// MaxLanguageTagLength bounds one tag, as RFC 5646 advises for storage.
+	MaxLanguageTagLength = 35
+	MaxProperties        = 20
+	MaxPropertyLength    = 255
+	MaxPayloadLength     = 4096
 )
 
-var visitorIDRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+var propertyKeyRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_.-]{0,63}$`)
+
+// Validate checks the event against rules, or the default rules when rules
+// is nil.
+func (ne *NavigationEvent) Validate(rules *ValidationRules) error {
+	if err := ne.validateSchemaVersion(); err != nil {
+		return err
+	}
 
-func (ne *NavigationEvent) Validate() error {
 	if ne.VisitorID == "" {
 		return fmt.Errorf("visitor_id is required")
 	}
 
-	if len(ne.VisitorID) < MinVisitorIDLength || len(ne.VisitorID) > MaxVisitorIDLength {
-		return fmt.Errorf("visitor_id must be between %d and %d characters", MinVisitorIDLength, MaxVisitorIDLength)
+	if rules == nil {
+		rules = defaultValidation
+	}
+	if err := rules.validateID("visitor_id", ne.VisitorID); err != nil {
+		return err
+	}
+
+	if ne.EventID != "" {
+		if err := rules.validateID("event_id", ne.EventID); err != nil {
+			return err
+		}
 	}
 
-	if !visitorIDRegex.MatchString(ne.VisitorID) {
-		return fmt.Errorf("visitor_id contains invalid characters")
+	if ne.SessionID != "" {
+		if err := rules.validateID("session_id", ne.SessionID); err != nil {
+			return err
+		}
 	}
 
 	if ne.URL == "" {
 		return fmt.Errorf("url is required")
 	}
 
-	if len(ne.URL) > MaxURLLength {
-		return fmt.Errorf("url exceeds maximum length of %d characters", MaxURLLength)
+	if err := rules.validateURL(ne.URL); err != nil {
+		return err
+	}
+
+	if len(ne.Referrer) > rules.maxURLLength {
+		return fmt.Errorf("referrer exceeds maximum length of %d characters", rules.maxURLLength)
+	}
+
+	if len(ne.UserAgent) > MaxUserAgentLength {
+		return fmt.Errorf("user_agent exceeds maximum length of %d characters", MaxUserAgentLength)
+	}
+
+	if len(ne.Country) > MaxGeoFieldLength || len(ne.Region) > MaxGeoFieldLength || len(ne.City) > MaxGeoFieldLength {
+		return fmt.Errorf("country, region and city must be at most %d characters", MaxGeoFieldLength)
+	}
+
+	if len(ne.Language) > MaxLanguageLength {
+		return fmt.Errorf("language must be at most %d characters", MaxLanguageLength)
+	}
+
+	if len(ne.Properties) > MaxProperties {
+		return fmt.Errorf("properties must have at most %d entries", MaxProperties)
+	}
+
+	for key, value := range ne.Properties {
+		if !propertyKeyRegex.MatchString(key) {
+			return fmt.Errorf("property key %q is invalid", key)
+		}
+
+		if len(value) > MaxPropertyLength {
+			return fmt.Errorf("property %s exceeds maximum length of %d characters", key, MaxPropertyLength)
+		}
+	}
+
+	if ne.EventType != "" {
+		if err := ValidateEventType(ne.EventType); err != nil {
+			return err
+		}
 	}
 
-	if _, err := url.ParseRequestURI(ne.URL); err != nil {
-		return fmt.Errorf("url is not a valid URI")
+	if len(ne.Payload) > MaxPayloadLength {
+		return fmt.Errorf("payload exceeds maximum length of %d bytes", MaxPayloadLength)
 	}
 
 	return nil
 }
 
+// ValidateEventType checks that eventType is pageview, click or a custom
+// type whose name is a valid property key.
+func ValidateEventType(eventType string) error {
+	if name, custom := strings.CutPrefix(eventType, EventTypeCustomPrefix); custom {
+		if !propertyKeyRegex.MatchString(name) {
+			return fmt.Errorf("event_type %q is invalid", eventType)
+		}
+		return nil
+	}
+	if eventType != EventTypePageView && eventType != EventTypeClick {
+		return fmt.Errorf("event_type must be pageview, click or custom:<name>")
+	}
+	return nil
+}
+
 func (ne *NavigationEvent) NormalizeURL() {
-	if ne.URL == "" {
-		return
+	ne.URL = NormalizeURL(ne.URL)
+}
+
+// NormalizeURL returns raw as events store it: without a fragment, with the
+// scheme, host and path lowercased and any trailing slash trimmed from the
+// path. A URL that does not parse is returned unchanged.
+func NormalizeURL(raw string) string {
+	if raw == "" {
+		return raw
 	}
 
-	parsedURL, err := url.Parse(ne.URL)
+	parsedURL, err := url.Parse(raw)
 	if err != nil {
-		return
+		return raw
 	}
 
 	parsedURL.Fragment = ""
@@ -76,7 +1023,95 @@ func (ne *NavigationEvent) NormalizeURL() {
 		parsedURL.Path = strings.TrimSuffix(parsedURL.Path, "/")
 	}
 
-	ne.URL = parsedURL.String()
+	return parsedURL.String()
+}
+
+// NormalizeLanguage reduces Language to the preferred tag it lists, in
+// canonical case, or to "" when it lists none.
+func (ne *NavigationEvent) NormalizeLanguage() {
+	ne.Language = PrimaryLanguage(ne.Language)
+}
+
+// PrimaryLanguage returns the language tag an Accept-Language value prefers
+// most, such as en-US for "en-US,en;q=0.9,fr;q=0.8", or "" when it names no
+// valid tag. Ties keep the first tag listed; * and tags with q=0 are skipped.
+func PrimaryLanguage(acceptLanguage string) string {
+	best, bestQ := "", 0.0
+	for _, entry := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(entry, ";")
+		tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(strings.TrimSpace(name), "q") {
+				parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				if err != nil || parsed < 0 || parsed > 1 {
+					parsed = 0
+				}
+				q = parsed
+			}
+		}
+		if q > bestQ && validLanguageTag(tag) {
+			best, bestQ = tag, q
+		}
+	}
+	return canonicalLanguageTag(best)
+}
+
+// validLanguageTag reports whether tag is shaped like a BCP 47 tag: a
+// primary language of 2 to 8 letters followed by subtags of 1 to 8 letters
+// and digits, at most MaxLanguageTagLength characters in all.
+func validLanguageTag(tag string) bool {
+	if tag == "" || len(tag) > MaxLanguageTagLength {
+		return false
+	}
+	for i, subtag := range strings.Split(tag, "-") {
+		if len(subtag) < 1 || len(subtag) > 8 || (i == 0 && len(subtag) < 2) {
+			return false
+		}
+		for _, c := range subtag {
+			letter := ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+			if !letter && (i == 0 || c < '0' || c > '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// canonicalLanguageTag cases tag as BCP 47 recommends: the language in lower
+// case, a two-letter region in upper case and a four-letter script in title
+// case, as in zh-Hant-TW.
+func canonicalLanguageTag(tag string) string {
+	subtags := strings.Split(strings.ToLower(tag), "-")
+	for i := 1; i < len(subtags); i++ {
+		// Subtags after a singleton, as in en-x-private, keep lower case
+		if len(subtags[i]) == 1 {
+			break
+		}
+		switch len(subtags[i]) {
+		case 2:
+			subtags[i] = strings.ToUpper(subtags[i])
+		case 4:
+			subtags[i] = strings.ToUpper(subtags[i][:1]) + subtags[i][1:]
+		}
+	}
+	return strings.Join(subtags, "-")
+}
+
+// ReferrerDomain returns the lowercased host of the referrer without a leading
+// "www.", or "" when there is no usable referrer.
+func (ne *NavigationEvent) ReferrerDomain() string {
+	if ne.Referrer == "" {
+		return ""
+	}
+
+	parsedURL, err := url.Parse(ne.Referrer)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(parsedURL.Hostname()), "www.")
 }
 
 func (ne *NavigationEvent) SetDefaults() {
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// DefaultVisitorIDPattern is the pattern IDs must match unless configured
+// otherwise.
+const DefaultVisitorIDPattern = `^[a-zA-Z0-9_-]+$`
+
+// ValidationRules is the compiled form of a ValidationConfig. Each tracker
+// holds its own, so rules can change while running without affecting
+// anything else.
+type ValidationRules struct {
+	visitorID     *regexp.Regexp
+	maxIDLength   int
+	maxURLLength  int
+	schemes       map[string]bool
+	allowRelative bool
+}
+
+// defaultValidation is applied when no rules are given.
+var defaultValidation = mustCompileValidation(ValidationConfig{
+	VisitorIDPattern:   DefaultVisitorIDPattern,
+	MaxVisitorIDLength: MaxVisitorIDLength,
+	MaxURLLength:       MaxURLLength,
+	AllowRelativeURLs:  true,
+})
+
+// DefaultValidationRules returns the rules applied unless configured
+// otherwise.
+func DefaultValidationRules() *ValidationRules {
+	return defaultValidation
+}
+
+// CompileValidation validates config and compiles it into the rules
+// NavigationEvent.Validate applies to IDs and URLs.
+func CompileValidation(config ValidationConfig) (*ValidationRules, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	rules := &ValidationRules{
+		visitorID:     regexp.MustCompile(config.VisitorIDPattern),
+		maxIDLength:   config.MaxVisitorIDLength,
+		maxURLLength:  config.MaxURLLength,
+		allowRelative: config.AllowRelativeURLs,
+	}
+	if len(config.AllowedURLSchemes) > 0 {
+		rules.schemes = make(map[string]bool, len(config.AllowedURLSchemes))
+		for _, scheme := range config.AllowedURLSchemes {
+			rules.schemes[strings.ToLower(scheme)] = true
+		}
+	}
+	return rules, nil
+}
+
+func mustCompileValidation(config ValidationConfig) *ValidationRules {
+	rules, err := CompileValidation(config)
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}
+
+// ValidateURL checks raw as NavigationEvent.Validate checks an event's URL.
+func (r *ValidationRules) ValidateURL(raw string) error {
+	if raw == "" {
+		return fmt.Errorf("url is required")
+	}
+	return r.validateURL(raw)
+}
+
+// validateID checks an ID against the maximum length and pattern.
+func (r *ValidationRules) validateID(field, id string) error {
+	if len(id) > r.maxIDLength {
+		return fmt.Errorf("%s must be at most %d characters", field, r.maxIDLength)
+	}
+	if !r.visitorID.MatchString(id) {
+		return fmt.Errorf("%s contains invalid characters", field)
+	}
+	return nil
+}
+
+// validateURL checks that raw is a URI with an allowed scheme, or a path
+// when relative URLs are allowed.
+func (r *ValidationRules) validateURL(raw string) error {
+	if len(raw) > r.maxURLLength {
+		return fmt.Errorf("url exceeds maximum length of %d characters", r.maxURLLength)
+	}
+
+	parsed, err := url.ParseRequestURI(raw)
+	if err != nil {
+		return fmt.Errorf("url is not a valid URI")
+	}
+	if parsed.Scheme == "" {
+		if !r.allowRelative {
+			return fmt.Errorf("url must be absolute")
+		}
+		return nil
+	}
+	if r.schemes != nil && !r.schemes[strings.ToLower(parsed.Scheme)] {
+		return fmt.Errorf("url scheme %q is not allowed", parsed.Scheme)
+	}
+	return nil
+}
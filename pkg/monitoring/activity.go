@@ -0,0 +1,133 @@
+package monitoring
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ActivityBucket counts ingest requests from one API key and source IP
+// during one minute.
+type ActivityBucket struct {
+	Minute   time.Time `json:"minute"`
+	APIKey   string    `json:"api_key,omitempty"`
+	IP       string    `json:"ip"`
+	Accepted int64     `json:"accepted"`
+	Rejected int64     `json:"rejected"`
+}
+
+type activitySource struct {
+	apiKey string
+	ip     string
+}
+
+type activityCounts struct {
+	accepted int64
+	rejected int64
+}
+
+// IngestActivity keeps per-minute ingest counters by API key and source IP
+// for a rolling window, as lightweight forensic data in place of raw events.
+type IngestActivity struct {
+	window  time.Duration
+	minutes map[int64]map[activitySource]*activityCounts
+	oldest  int64
+	mutex   sync.RWMutex
+}
+
+func NewIngestActivity(window time.Duration) *IngestActivity {
+	return &IngestActivity{
+		window:  window,
+		minutes: make(map[int64]map[activitySource]*activityCounts),
+	}
+}
+
+func (ia *IngestActivity) Window() time.Duration {
+	return ia.window
+}
+
+// Record counts one ingest request made at t.
+func (ia *IngestActivity) Record(apiKey, ip string, accepted bool, t time.Time) {
+	minute := t.Unix() / 60
+
+	ia.mutex.Lock()
+	defer ia.mutex.Unlock()
+
+	ia.prune(t)
+
+	sources := ia.minutes[minute]
+	if sources == nil {
+		sources = make(map[activitySource]*activityCounts)
+		ia.minutes[minute] = sources
+	}
+
+	source := activitySource{apiKey: apiKey, ip: ip}
+	counts := sources[source]
+	if counts == nil {
+		counts = &activityCounts{}
+		sources[source] = counts
+	}
+
+	if accepted {
+		counts.accepted++
+	} else {
+		counts.rejected++
+	}
+}
+
+// prune drops minutes that have left the window; the caller must hold the lock.
+func (ia *IngestActivity) prune(now time.Time) {
+	cutoff := now.Add(-ia.window).Unix() / 60
+	if cutoff <= ia.oldest {
+		return
+	}
+
+	for minute := range ia.minutes {
+		if minute < cutoff {
+			delete(ia.minutes, minute)
+		}
+	}
+	ia.oldest = cutoff
+}
+
+// Query returns buckets for minutes in [from, to), optionally restricted to an
+// API key, ordered by minute then key and IP.
+func (ia *IngestActivity) Query(from, to time.Time, apiKey string) []ActivityBucket {
+	fromMinute := from.Unix() / 60
+	toMinute := (to.Unix() + 59) / 60
+
+	ia.mutex.RLock()
+	defer ia.mutex.RUnlock()
+
+	buckets := []ActivityBucket{}
+	for minute, sources := range ia.minutes {
+		if minute < fromMinute || minute >= toMinute {
+			continue
+		}
+		for source, counts := range sources {
+			if apiKey != "" && source.apiKey != apiKey {
+				continue
+			}
+			buckets = append(buckets, ActivityBucket{
+				Minute:   time.Unix(minute*60, 0).UTC(),
+				APIKey:   source.apiKey,
+				IP:       source.ip,
+				Accepted: counts.accepted,
+				Rejected: counts.rejected,
+			})
+		}
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		a, b := buckets[i], buckets[j]
+		if !a.Minute.Equal(b.Minute) {
+			return a.Minute.Before(b.Minute)
+		}
+		if a.APIKey != b.APIKey {
+			return a.APIKey < b.APIKey
+		}
+		return a.IP < b.IP
+	})
+
+	return buckets
+}
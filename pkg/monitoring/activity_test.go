@@ -0,0 +1,47 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIngestActivity_RecordAndQuery(t *testing.T) {
+	activity := NewIngestActivity(time.Hour)
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	activity.Record("key1", "10.0.0.1", true, base)
+	activity.Record("key1", "10.0.0.1", true, base.Add(30*time.Second))
+	activity.Record("key1", "10.0.0.1", false, base.Add(40*time.Second))
+	activity.Record("", "10.0.0.2", true, base.Add(time.Minute))
+	activity.Record("key2", "10.0.0.3", true, base.Add(2*time.Minute))
+
+	buckets := activity.Query(base, base.Add(2*time.Minute), "")
+	if len(buckets) != 2 {
+		t.Fatalf("Expected 2 buckets, got %d", len(buckets))
+	}
+
+	if buckets[0].APIKey != "key1" || buckets[0].Accepted != 2 || buckets[0].Rejected != 1 {
+		t.Errorf("Unexpected first bucket: %+v", buckets[0])
+	}
+
+	if buckets[1].IP != "10.0.0.2" || !buckets[1].Minute.Equal(base.Add(time.Minute)) {
+		t.Errorf("Unexpected second bucket: %+v", buckets[1])
+	}
+
+	keyed := activity.Query(base, base.Add(time.Hour), "key2")
+	if len(keyed) != 1 || keyed[0].IP != "10.0.0.3" {
+		t.Errorf("Expected only key2 activity, got %+v", keyed)
+	}
+}
+
+func TestIngestActivity_PrunesOutsideWindow(t *testing.T) {
+	activity := NewIngestActivity(10 * time.Minute)
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	activity.Record("key1", "10.0.0.1", true, base)
+	activity.Record("key1", "10.0.0.1", true, base.Add(time.Hour))
+
+	if buckets := activity.Query(base, base.Add(2*time.Hour), ""); len(buckets) != 1 {
+		t.Errorf("Expected old minute to be pruned, got %d buckets", len(buckets))
+	}
+}
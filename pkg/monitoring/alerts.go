@@ -0,0 +1,210 @@
+package monitoring
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// Alert states
+const (
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// defaultAlertTemplate is used for rules without their own payload template.
+const defaultAlertTemplate = `{"rule":{{json .Rule}},"status":{{json .Status}},"metric":{{json .Metric}},` +
+	`"url":{{json .URL}},"value":{{json .Value}},"threshold":{{json .Threshold}},"time":{{json .Time}}}`
+
+// AlertEvent is the data available to payload templates.
+type AlertEvent struct {
+	Rule      string
+	Status    string
+	Metric    string
+	URL       string
+	Value     float64
+	Threshold float64
+	Time      time.Time
+}
+
+// URLVisitorCounter returns the number of distinct visitors of url seen since the given time.
+type URLVisitorCounter func(url string, since time.Time) int
+
+// Alerter evaluates alert rules on an interval and notifies each rule's
+// webhook when the rule starts or stops firing.
+type Alerter struct {
+	config      models.AlertingConfig
+	urlVisitors URLVisitorCounter
+	metrics     *MetricsCollector
+	client      *http.Client
+	templates   map[string]*template.Template
+	firing      map[string]bool
+
+	lastRequests int64
+	lastErrors   int64
+
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+	started  atomic.Bool
+}
+
+func NewAlerter(config models.AlertingConfig, urlVisitors URLVisitorCounter, metrics *MetricsCollector) *Alerter {
+	funcs := template.FuncMap{"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	}}
+
+	templates := make(map[string]*template.Template, len(config.Rules))
+	for _, rule := range config.Rules {
+		text := rule.Template
+		if text == "" {
+			text = defaultAlertTemplate
+		}
+		tmpl, err := template.New(rule.Name).Funcs(funcs).Parse(text)
+		if err != nil {
+			log.Printf("Alert rule %s: invalid template, using default: %v", rule.Name, err)
+			tmpl = template.Must(template.New(rule.Name).Funcs(funcs).Parse(defaultAlertTemplate))
+		}
+		templates[rule.Name] = tmpl
+	}
+
+	return &Alerter{
+		config:      config,
+		urlVisitors: urlVisitors,
+		metrics:     metrics,
+		client:      &http.Client{Timeout: config.WebhookTimeout},
+		templates:   templates,
+		firing:      make(map[string]bool),
+		stopCh:      make(chan struct{}),
+		doneCh:      make(chan struct{}),
+	}
+}
+
+func (a *Alerter) Start() {
+	if !a.started.CompareAndSwap(false, true) {
+		return
+	}
+
+	a.lastRequests, a.lastErrors = a.metrics.Totals()
+
+	go func() {
+		defer close(a.doneCh)
+
+		ticker := time.NewTicker(a.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				a.Evaluate(now)
+			case <-a.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the evaluation loop and waits for in-flight notifications.
+func (a *Alerter) Stop() {
+	a.stopOnce.Do(func() {
+		close(a.stopCh)
+	})
+	if a.started.Load() {
+		<-a.doneCh
+	}
+}
+
+// Evaluate checks every rule once and notifies webhooks of state changes.
+func (a *Alerter) Evaluate(now time.Time) {
+	requests, errors := a.metrics.Totals()
+	errorRate := 0.0
+	if delta := requests - a.lastRequests; delta > 0 {
+		errorRate = float64(errors-a.lastErrors) / float64(delta) * 100
+	}
+	a.lastRequests, a.lastErrors = requests, errors
+
+	for _, rule := range a.config.Rules {
+		var value float64
+		switch rule.Metric {
+		case models.AlertMetricURLVisitors:
+			value = float64(a.urlVisitors(rule.URL, now.Add(-rule.Window)))
+		case models.AlertMetricErrorRate:
+			value = errorRate
+		default:
+			continue
+		}
+
+		firing := value > rule.Threshold
+		if firing == a.firing[rule.Name] {
+			continue
+		}
+		a.firing[rule.Name] = firing
+
+		status := AlertResolved
+		if firing {
+			status = AlertFiring
+		}
+
+		event := AlertEvent{
+			Rule:      rule.Name,
+			Status:    status,
+			Metric:    rule.Metric,
+			URL:       rule.URL,
+			Value:     value,
+			Threshold: rule.Threshold,
+			Time:      now.UTC(),
+		}
+		if err := a.notify(rule, event); err != nil {
+			log.Printf("Alert %s (%s) not delivered: %v", rule.Name, status, err)
+		}
+	}
+}
+
+// notify POSTs the rendered payload, retrying with exponential backoff.
+func (a *Alerter) notify(rule models.AlertRule, event AlertEvent) error {
+	var payload bytes.Buffer
+	if err := a.templates[rule.Name].Execute(&payload, event); err != nil {
+		return fmt.Errorf("render payload: %w", err)
+	}
+
+	backoff := a.config.RetryBackoff
+	var err error
+	for attempt := 0; attempt <= a.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+				backoff *= 2
+			case <-a.stopCh:
+				return fmt.Errorf("stopped after %d attempts: %w", attempt, err)
+			}
+		}
+
+		if err = a.post(rule.Webhook, payload.Bytes()); err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+func (a *Alerter) post(url string, payload []byte) error {
+	resp, err := a.client.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+type webhookRecorder struct {
+	mutex    sync.Mutex
+	payloads []map[string]interface{}
+	failures atomic.Int64
+}
+
+func (wr *webhookRecorder) handler(w http.ResponseWriter, r *http.Request) {
+	if wr.failures.Add(-1) >= 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	var payload map[string]interface{}
+	json.Unmarshal(body, &payload)
+
+	wr.mutex.Lock()
+	wr.payloads = append(wr.payloads, payload)
+	wr.mutex.Unlock()
+}
+
+func TestAlerter_URLVisitorsFiresAndResolves(t *testing.T) {
+	recorder := &webhookRecorder{}
+	endpoint := httptest.NewServer(http.HandlerFunc(recorder.handler))
+	defer endpoint.Close()
+
+	visitors := 0
+	counter := func(url string, since time.Time) int { return visitors }
+
+	alerter := NewAlerter(models.AlertingConfig{
+		Interval:       time.Minute,
+		WebhookTimeout: time.Second,
+		Rules: []models.AlertRule{{
+			Name:      "home-spike",
+			Metric:    models.AlertMetricURLVisitors,
+			URL:       "https://example.com/",
+			Window:    time.Hour,
+			Threshold: 100,
+			Webhook:   endpoint.URL,
+		}},
+	}, counter, NewMetricsCollector())
+
+	now := time.Now()
+	alerter.Evaluate(now)
+	visitors = 150
+	alerter.Evaluate(now)
+	alerter.Evaluate(now)
+	visitors = 10
+	alerter.Evaluate(now)
+
+	if len(recorder.payloads) != 2 {
+		t.Fatalf("Expected firing and resolved notifications only, got %d", len(recorder.payloads))
+	}
+
+	if recorder.payloads[0]["status"] != AlertFiring || recorder.payloads[0]["value"] != 150.0 {
+		t.Errorf("Expected firing payload with value 150, got %v", recorder.payloads[0])
+	}
+	if recorder.payloads[1]["status"] != AlertResolved {
+		t.Errorf("Expected resolved payload, got %v", recorder.payloads[1])
+	}
+}
+
+func TestAlerter_ErrorRateWithRetriesAndTemplate(t *testing.T) {
+	recorder := &webhookRecorder{}
+	recorder.failures.Store(2)
+	endpoint := httptest.NewServer(http.HandlerFunc(recorder.handler))
+	defer endpoint.Close()
+
+	metrics := NewMetricsCollector()
+	alerter := NewAlerter(models.AlertingConfig{
+		Interval:       time.Minute,
+		WebhookTimeout: time.Second,
+		MaxRetries:     2,
+		RetryBackoff:   time.Millisecond,
+		Rules: []models.AlertRule{{
+			Name:      "errors",
+			Metric:    models.AlertMetricErrorRate,
+			Threshold: 5,
+			Webhook:   endpoint.URL,
+			Template:  `{"text":"{{.Rule}} is {{.Status}}","rate":{{printf "%.0f" .Value}}}`,
+		}},
+	}, func(string, time.Time) int { return 0 }, metrics)
+
+	for i := 0; i < 8; i++ {
+		metrics.RecordRequest("/stats", time.Millisecond, http.StatusOK)
+	}
+	for i := 0; i < 2; i++ {
+		metrics.RecordRequest("/stats", time.Millisecond, http.StatusInternalServerError)
+	}
+	alerter.Evaluate(time.Now())
+
+	if len(recorder.payloads) != 1 {
+		t.Fatalf("Expected delivery after retries, got %d payloads", len(recorder.payloads))
+	}
+	if recorder.payloads[0]["text"] != "errors is firing" || recorder.payloads[0]["rate"] != 20.0 {
+		t.Errorf("Expected templated payload, got %v", recorder.payloads[0])
+	}
+
+	// No new requests since the last evaluation: the rate drops to zero.
+	alerter.Evaluate(time.Now())
+	if len(recorder.payloads) != 2 || recorder.payloads[1]["text"] != "errors is resolved" {
+		t.Errorf("Expected resolved notification, got %v", recorder.payloads)
+	}
+}
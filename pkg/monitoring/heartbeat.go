@@ -0,0 +1,101 @@
+package monitoring
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// HealthCheck reports a problem with a component the heartbeat depends on.
+type HealthCheck func() error
+
+// Heartbeat pings an external monitoring URL (healthchecks.io style) on an
+// interval for as long as every health check passes. When a check fails the
+// ping is withheld, so the external service alerts on the missing heartbeat.
+type Heartbeat struct {
+	config models.HeartbeatConfig
+	checks []HealthCheck
+	client *http.Client
+
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+	started  atomic.Bool
+	healthy  bool
+}
+
+func NewHeartbeat(config models.HeartbeatConfig, checks ...HealthCheck) *Heartbeat {
+	return &Heartbeat{
+		config:  config,
+		checks:  checks,
+		client:  &http.Client{Timeout: config.Timeout},
+		stopCh:  make(chan struct{}),
+		doneCh:  make(chan struct{}),
+		healthy: true,
+	}
+}
+
+func (h *Heartbeat) Start() {
+	if !h.started.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer close(h.doneCh)
+
+		ticker := time.NewTicker(h.config.Interval)
+		defer ticker.Stop()
+
+		h.beat()
+		for {
+			select {
+			case <-ticker.C:
+				h.beat()
+			case <-h.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the heartbeat loop and waits for an in-flight ping to finish.
+func (h *Heartbeat) Stop() {
+	h.stopOnce.Do(func() {
+		close(h.stopCh)
+	})
+	if h.started.Load() {
+		<-h.doneCh
+	}
+}
+
+func (h *Heartbeat) beat() {
+	for _, check := range h.checks {
+		if err := check(); err != nil {
+			if h.healthy {
+				log.Printf("Heartbeat paused, health check failed: %v", err)
+				h.healthy = false
+			}
+			return
+		}
+	}
+
+	if !h.healthy {
+		log.Println("Heartbeat resumed, health checks passing")
+		h.healthy = true
+	}
+
+	resp, err := h.client.Get(h.config.URL)
+	if err != nil {
+		log.Printf("Heartbeat ping failed: %v", err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		log.Printf("Heartbeat ping returned status %d", resp.StatusCode)
+	}
+}
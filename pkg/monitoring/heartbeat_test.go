@@ -0,0 +1,69 @@
+package monitoring
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestHeartbeat_PingsWhileHealthy(t *testing.T) {
+	var pings int64
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&pings, 1)
+	}))
+	defer endpoint.Close()
+
+	var failing atomic.Bool
+	check := func() error {
+		if failing.Load() {
+			return errors.New("ingest degraded")
+		}
+		return nil
+	}
+
+	heartbeat := NewHeartbeat(models.HeartbeatConfig{
+		URL:      endpoint.URL,
+		Interval: 10 * time.Millisecond,
+		Timeout:  time.Second,
+	}, check)
+	heartbeat.Start()
+
+	time.Sleep(50 * time.Millisecond)
+	if atomic.LoadInt64(&pings) == 0 {
+		t.Fatal("Expected pings while healthy")
+	}
+
+	failing.Store(true)
+	time.Sleep(20 * time.Millisecond)
+	paused := atomic.LoadInt64(&pings)
+	time.Sleep(50 * time.Millisecond)
+	heartbeat.Stop()
+
+	if got := atomic.LoadInt64(&pings); got != paused {
+		t.Errorf("Expected no pings while unhealthy, got %d more", got-paused)
+	}
+
+	// Stop is idempotent
+	heartbeat.Stop()
+}
+
+func TestHeartbeat_StopWithoutStart(t *testing.T) {
+	heartbeat := NewHeartbeat(models.HeartbeatConfig{URL: "http://127.0.0.1:0", Interval: time.Second})
+
+	done := make(chan struct{})
+	go func() {
+		heartbeat.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop blocked on a heartbeat that was never started")
+	}
+}
@@ -3,9 +3,20 @@ package monitoring
 import (
 	"sync"
 	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/version"
 )
 
-const responseTimesBufferSize = 1000
+const (
+	responseTimesBufferSize = 1000
+
+	// DefaultMaxEndpoints caps the endpoints tracked individually.
+	DefaultMaxEndpoints = 100
+
+	// OtherEndpoint aggregates requests to endpoints beyond the cap.
+	OtherEndpoint = "other"
+)
 
 type MetricsCollector struct {
 	requestCount    int64
@@ -19,6 +30,9 @@ type MetricsCollector struct {
 
 	endpointMetrics map[string]*EndpointMetrics
 	statusCodes     map[int]int64
+	rejectedEvents  map[string]int64
+	deprecated      map[string]int64
+	maxEndpoints    int
 }
 
 type EndpointMetrics struct {
@@ -41,15 +55,33 @@ type PerformanceMetrics struct {
 	LastRequestTime     time.Time                   `json:"last_request_time"`
 	EndpointMetrics     map[string]*EndpointMetrics `json:"endpoint_metrics"`
 	StatusCodes         map[int]int64               `json:"status_codes"`
+	RejectedEvents      map[string]int64            `json:"rejected_events"`
+	DeprecatedRequests  map[string]int64            `json:"deprecated_requests"`
+	Build               models.BuildInfo            `json:"build"`
 }
 
 func NewMetricsCollector() *MetricsCollector {
+	return NewMetricsCollectorWithLimit(DefaultMaxEndpoints)
+}
+
+// NewMetricsCollectorWithLimit tracks at most maxEndpoints endpoints
+// individually; requests to any endpoint first seen after the cap is reached
+// are counted under OtherEndpoint, so memory stays bounded however many
+// distinct paths clients request.
+func NewMetricsCollectorWithLimit(maxEndpoints int) *MetricsCollector {
+	if maxEndpoints < 1 {
+		maxEndpoints = DefaultMaxEndpoints
+	}
+
 	return &MetricsCollector{
+		maxEndpoints:    maxEndpoints,
 		responseTimes:   make([]time.Duration, responseTimesBufferSize),
 		responseIndex:   0,
 		responseCount:   0,
 		endpointMetrics: make(map[string]*EndpointMetrics),
 		statusCodes:     make(map[int]int64),
+		rejectedEvents:  make(map[string]int64),
+		deprecated:      make(map[string]int64),
 		startTime:       time.Now(),
 	}
 }
@@ -75,6 +107,10 @@ func (mc *MetricsCollector) RecordRequest(endpoint string, responseTime time.Dur
 
 	mc.statusCodes[statusCode]++
 
+	if mc.endpointMetrics[endpoint] == nil && len(mc.endpointMetrics) >= mc.maxEndpoints {
+		endpoint = OtherEndpoint
+	}
+
 	if mc.endpointMetrics[endpoint] == nil {
 		mc.endpointMetrics[endpoint] = &EndpointMetrics{
 			MinTime: responseTime,
@@ -99,6 +135,24 @@ func (mc *MetricsCollector) RecordRequest(endpoint string, responseTime time.Dur
 	}
 }
 
+// RecordRejectedEvent counts an event rejected at ingest for reason, such as
+// the denylist that matched it.
+func (mc *MetricsCollector) RecordRejectedEvent(reason string) {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+
+	mc.rejectedEvents[reason]++
+}
+
+// RecordDeprecatedRequest counts a request to a deprecated legacy path, so
+// operators can tell when clients have moved off it.
+func (mc *MetricsCollector) RecordDeprecatedRequest(path string) {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+
+	mc.deprecated[path]++
+}
+
 func (mc *MetricsCollector) GetMetrics() *PerformanceMetrics {
 	mc.mutex.RLock()
 	defer mc.mutex.RUnlock()
@@ -164,6 +218,16 @@ func (mc *MetricsCollector) GetMetrics() *PerformanceMetrics {
 		statusCodes[code] = count
 	}
 
+	rejectedEvents := make(map[string]int64, len(mc.rejectedEvents))
+	for reason, count := range mc.rejectedEvents {
+		rejectedEvents[reason] = count
+	}
+
+	deprecated := make(map[string]int64, len(mc.deprecated))
+	for path, count := range mc.deprecated {
+		deprecated[path] = count
+	}
+
 	return &PerformanceMetrics{
 		TotalRequests:       mc.requestCount,
 		AverageResponseTime: avgResponseTime,
@@ -172,12 +236,23 @@ func (mc *MetricsCollector) GetMetrics() *PerformanceMetrics {
 		RequestsPerSecond:   requestsPerSecond,
 		ErrorRate:           errorRate,
 		Uptime:              uptime,
+		Build:               version.Info(),
 		LastRequestTime:     mc.lastRequestTime,
 		EndpointMetrics:     endpointMetrics,
 		StatusCodes:         statusCodes,
+		RejectedEvents:      rejectedEvents,
+		DeprecatedRequests:  deprecated,
 	}
 }
 
+// Totals returns the number of requests and error responses recorded.
+func (mc *MetricsCollector) Totals() (int64, int64) {
+	mc.mutex.RLock()
+	defer mc.mutex.RUnlock()
+
+	return mc.requestCount, mc.errorCount
+}
+
 func (mc *MetricsCollector) Reset() {
 	mc.mutex.Lock()
 	defer mc.mutex.Unlock()
@@ -195,6 +270,8 @@ func (mc *MetricsCollector) Reset() {
 	mc.startTime = time.Now()
 	mc.endpointMetrics = make(map[string]*EndpointMetrics)
 	mc.statusCodes = make(map[int]int64)
+	mc.rejectedEvents = make(map[string]int64)
+	mc.deprecated = make(map[string]int64)
 }
 
 func (mc *MetricsCollector) GetEndpointMetrics(endpoint string) *EndpointMetrics {
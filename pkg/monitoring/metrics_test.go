@@ -1,6 +1,7 @@
 package monitoring
 
 import (
+	"fmt"
 	"testing"
 	"time"
 )
@@ -216,3 +217,49 @@ func BenchmarkMetricsCollector_GetMetrics(b *testing.B) {
 		collector.GetMetrics()
 	}
 }
+
+func TestMetricsCollector_EndpointCap(t *testing.T) {
+	collector := NewMetricsCollectorWithLimit(2)
+
+	collector.RecordRequest("/a", time.Millisecond, 200)
+	collector.RecordRequest("/b", time.Millisecond, 200)
+	for i := 0; i < 100; i++ {
+		collector.RecordRequest(fmt.Sprintf("/visitors/%d", i), time.Millisecond, 404)
+	}
+	collector.RecordRequest("/a", time.Millisecond, 200)
+
+	metrics := collector.GetMetrics()
+
+	if len(metrics.EndpointMetrics) != 3 {
+		t.Fatalf("Expected 2 endpoints plus %s, got %d", OtherEndpoint, len(metrics.EndpointMetrics))
+	}
+
+	if other := metrics.EndpointMetrics[OtherEndpoint]; other == nil || other.RequestCount != 100 || other.ErrorCount != 100 {
+		t.Errorf("Expected 100 requests counted under %s, got %+v", OtherEndpoint, other)
+	}
+
+	if a := metrics.EndpointMetrics["/a"]; a == nil || a.RequestCount != 2 {
+		t.Errorf("Expected existing endpoints to keep counting, got %+v", a)
+	}
+}
+
+func TestMetricsCollector_RejectedEvents(t *testing.T) {
+	collector := NewMetricsCollector()
+
+	collector.RecordRejectedEvent("denylist_urls")
+	collector.RecordRejectedEvent("denylist_urls")
+	collector.RecordRejectedEvent("denylist_referrers")
+
+	metrics := collector.GetMetrics()
+	if metrics.RejectedEvents["denylist_urls"] != 2 || metrics.RejectedEvents["denylist_referrers"] != 1 {
+		t.Errorf("Expected rejected events by reason, got %v", metrics.RejectedEvents)
+	}
+	if metrics.TotalRequests != 0 {
+		t.Errorf("Expected rejected events not to count as requests, got %d", metrics.TotalRequests)
+	}
+
+	collector.Reset()
+	if len(collector.GetMetrics().RejectedEvents) != 0 {
+		t.Error("Expected Reset to clear rejected events")
+	}
+}
@@ -0,0 +1,101 @@
+package monitoring
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"runtime/debug"
+	"runtime/metrics"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// ReadinessCheck is one named dependency check behind the readiness probe.
+type ReadinessCheck struct {
+	Name  string
+	Check HealthCheck
+}
+
+// RunChecks runs checks concurrently and returns their results in order. A
+// check still running after timeout fails; it is left to finish in the
+// background.
+func RunChecks(checks []ReadinessCheck, timeout time.Duration) []models.CheckResult {
+	results := make([]models.CheckResult, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check ReadinessCheck) {
+			defer wg.Done()
+
+			start := time.Now()
+			done := make(chan error, 1)
+			go func() { done <- check.Check() }()
+
+			result := models.CheckResult{Name: check.Name, Status: models.CheckPass}
+			select {
+			case err := <-done:
+				if err != nil {
+					result.Status = models.CheckFail
+					result.Error = err.Error()
+				}
+			case <-time.After(timeout):
+				result.Status = models.CheckFail
+				result.Error = fmt.Sprintf("timed out after %v", timeout)
+			}
+			result.Duration = time.Since(start)
+			results[i] = result
+		}(i, check)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// WritableDirCheck fails unless a file can be created in dir.
+func WritableDirCheck(dir string) HealthCheck {
+	return func() error {
+		file, err := os.CreateTemp(dir, ".readyz-*")
+		if err != nil {
+			return fmt.Errorf("%s is not writable: %w", dir, err)
+		}
+		file.Close()
+		return os.Remove(file.Name())
+	}
+}
+
+// MemoryHeadroomCheck fails when the memory the runtime holds leaves less
+// than minHeadroom (a fraction) of the Go memory limit free. It always passes
+// when no limit is set, as there is nothing to measure headroom against.
+func MemoryHeadroomCheck(minHeadroom float64) HealthCheck {
+	return func() error {
+		limit := debug.SetMemoryLimit(-1)
+		if limit == math.MaxInt64 {
+			return nil
+		}
+
+		samples := []metrics.Sample{
+			{Name: "/memory/classes/total:bytes"},
+			{Name: "/memory/classes/heap/released:bytes"},
+		}
+		metrics.Read(samples)
+		used := int64(samples[0].Value.Uint64() - samples[1].Value.Uint64())
+
+		if headroom := 1 - float64(used)/float64(limit); headroom < minHeadroom {
+			return fmt.Errorf("%d of %d bytes in use, %.1f%% headroom is below %.1f%%", used, limit, headroom*100, minHeadroom*100)
+		}
+		return nil
+	}
+}
+
+// LagCheck fails when lag reports more than maxLag pending items.
+func LagCheck(lag func() int64, maxLag int64) HealthCheck {
+	return func() error {
+		if current := lag(); current > maxLag {
+			return fmt.Errorf("lag of %d exceeds %d", current, maxLag)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,73 @@
+package monitoring
+
+import (
+	"errors"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestRunChecks(t *testing.T) {
+	checks := []ReadinessCheck{
+		{Name: "ok", Check: func() error { return nil }},
+		{Name: "broken", Check: func() error { return errors.New("connection refused") }},
+		{Name: "slow", Check: func() error { time.Sleep(time.Second); return nil }},
+	}
+
+	start := time.Now()
+	results := RunChecks(checks, 50*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected checks to be bounded by the timeout, took %v", elapsed)
+	}
+
+	expected := []struct {
+		name   string
+		status string
+	}{{"ok", models.CheckPass}, {"broken", models.CheckFail}, {"slow", models.CheckFail}}
+	for i, want := range expected {
+		if results[i].Name != want.name || results[i].Status != want.status {
+			t.Errorf("Expected %s to %s, got %+v", want.name, want.status, results[i])
+		}
+	}
+	if results[1].Error != "connection refused" {
+		t.Errorf("Expected the check error to be reported, got %q", results[1].Error)
+	}
+}
+
+func TestWritableDirCheck(t *testing.T) {
+	dir := t.TempDir()
+	if err := WritableDirCheck(dir)(); err != nil {
+		t.Errorf("Expected %s to be writable, got %v", dir, err)
+	}
+	if err := WritableDirCheck(filepath.Join(dir, "missing"))(); err == nil {
+		t.Error("Expected a missing directory to fail")
+	}
+}
+
+func TestMemoryHeadroomCheck(t *testing.T) {
+	if err := MemoryHeadroomCheck(0.5)(); err != nil {
+		t.Errorf("Expected the check to pass without a memory limit, got %v", err)
+	}
+
+	// A limit far below what the runtime already holds leaves no headroom
+	previous := debug.SetMemoryLimit(1 << 20)
+	defer debug.SetMemoryLimit(previous)
+	if err := MemoryHeadroomCheck(0.1)(); err == nil {
+		t.Error("Expected the check to fail with a 1MiB memory limit")
+	}
+}
+
+func TestLagCheck(t *testing.T) {
+	lag := int64(5)
+	check := LagCheck(func() int64 { return lag }, 10)
+	if err := check(); err != nil {
+		t.Errorf("Expected lag below the limit to pass, got %v", err)
+	}
+	lag = 11
+	if err := check(); err == nil {
+		t.Error("Expected lag above the limit to fail")
+	}
+}
@@ -0,0 +1,76 @@
+package monitoring
+
+import (
+	"sync"
+	"time"
+)
+
+type SlowQueryEntry struct {
+	Timestamp   time.Time         `json:"timestamp"`
+	Endpoint    string            `json:"endpoint"`
+	Params      map[string]string `json:"params"`
+	StatusCode  int               `json:"status_code"`
+	Duration    time.Duration     `json:"duration"`
+	LockWait    time.Duration     `json:"lock_wait"`
+	MatchedURLs int               `json:"matched_urls"`
+}
+
+// SlowLog keeps the most recent queries that took at least threshold in a
+// fixed-size circular buffer.
+type SlowLog struct {
+	threshold time.Duration
+	entries   []SlowQueryEntry
+	index     int // next write position
+	count     int // actual count of items in buffer
+	total     int64
+	mutex     sync.RWMutex
+}
+
+func NewSlowLog(threshold time.Duration, size int) *SlowLog {
+	if size < 1 {
+		size = 1
+	}
+
+	return &SlowLog{
+		threshold: threshold,
+		entries:   make([]SlowQueryEntry, size),
+	}
+}
+
+func (sl *SlowLog) Threshold() time.Duration {
+	return sl.threshold
+}
+
+// Record stores entry if its duration meets the threshold and reports whether it did.
+func (sl *SlowLog) Record(entry SlowQueryEntry) bool {
+	if entry.Duration < sl.threshold {
+		return false
+	}
+
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+
+	sl.entries[sl.index] = entry
+	sl.index = (sl.index + 1) % len(sl.entries)
+	if sl.count < len(sl.entries) {
+		sl.count++
+	}
+	sl.total++
+
+	return true
+}
+
+// Entries returns the buffered slow queries, most recent first, and the total
+// number recorded since start.
+func (sl *SlowLog) Entries() ([]SlowQueryEntry, int64) {
+	sl.mutex.RLock()
+	defer sl.mutex.RUnlock()
+
+	result := make([]SlowQueryEntry, 0, sl.count)
+	for i := 1; i <= sl.count; i++ {
+		idx := (sl.index - i + len(sl.entries)) % len(sl.entries)
+		result = append(result, sl.entries[idx])
+	}
+
+	return result, sl.total
+}
@@ -0,0 +1,46 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlowLog_RecordsOnlyAboveThreshold(t *testing.T) {
+	slowLog := NewSlowLog(100*time.Millisecond, 10)
+
+	if slowLog.Record(SlowQueryEntry{Endpoint: "/stats", Duration: 50 * time.Millisecond}) {
+		t.Error("Expected fast query not to be recorded")
+	}
+
+	if !slowLog.Record(SlowQueryEntry{Endpoint: "/stats", Duration: 150 * time.Millisecond}) {
+		t.Error("Expected slow query to be recorded")
+	}
+
+	entries, total := slowLog.Entries()
+	if len(entries) != 1 || total != 1 {
+		t.Errorf("Expected 1 entry, got %d (total %d)", len(entries), total)
+	}
+}
+
+func TestSlowLog_CircularBuffer(t *testing.T) {
+	slowLog := NewSlowLog(0, 3)
+
+	for i := 1; i <= 5; i++ {
+		slowLog.Record(SlowQueryEntry{Duration: time.Duration(i) * time.Millisecond})
+	}
+
+	entries, total := slowLog.Entries()
+	if total != 5 {
+		t.Errorf("Expected 5 total entries, got %d", total)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 buffered entries, got %d", len(entries))
+	}
+
+	for i, want := range []time.Duration{5, 4, 3} {
+		if entries[i].Duration != want*time.Millisecond {
+			t.Errorf("Expected entry %d duration %dms, got %v", i, want, entries[i].Duration)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Package openapi builds the OpenAPI 3 description of the HTTP API from a
+// table of routes, reflecting request and response schemas from the model
+// types, and serves it together with a Swagger UI page.
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"nav-tracker/pkg/models"
+)
+
+// Version is the OpenAPI specification version documents are written in.
+const Version = "3.0.3"
+
+const (
+	contentJSON = "application/json"
+	// errorSchema is the component every error response refers to.
+	errorSchema = "ErrorResponse"
+)
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem maps a lowercase HTTP method to its operation.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Summary     string               `json:"summary"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Route documents one method on one path. Request and Response are sample
+// values whose types give the JSON schemas; an Object describes an ad-hoc
+// map response field by field. RequestTypes and ResponseType override the
+// JSON content types for bodies in other formats.
+type Route struct {
+	Method       string
+	Path         string
+	Summary      string
+	Tag          string
+	Params       []Parameter
+	Request      interface{}
+	RequestTypes []string
+	Status       int
+	Response     interface{}
+	ResponseType string
+	Errors       []int
+	// Condition names the configuration the route is only registered with.
+	Condition string
+}
+
+// Build returns the document describing routes.
+func Build(info Info, routes []Route) *Document {
+	doc := &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Paths:      make(map[string]PathItem),
+		Components: Components{Schemas: make(map[string]*Schema)},
+	}
+	schemas := newRegistry(doc.Components.Schemas)
+	schemas.schemaOf(models.ErrorResponse{})
+
+	for _, route := range routes {
+		op := &Operation{
+			Summary:    route.Summary,
+			Parameters: route.Params,
+			Responses:  make(map[string]*Response),
+		}
+		if route.Tag != "" {
+			op.Tags = []string{route.Tag}
+		}
+		if route.Condition != "" {
+			op.Description = "Only available when " + route.Condition + "."
+		}
+
+		if route.Request != nil {
+			contentTypes := route.RequestTypes
+			if len(contentTypes) == 0 {
+				contentTypes = []string{contentJSON}
+			}
+			op.RequestBody = &RequestBody{Required: true, Content: make(map[string]MediaType)}
+			for _, contentType := range contentTypes {
+				op.RequestBody.Content[contentType] = MediaType{Schema: schemas.schemaOf(route.Request)}
+			}
+		}
+
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := &Response{Description: http.StatusText(status)}
+		if route.Response != nil {
+			contentType := route.ResponseType
+			if contentType == "" {
+				contentType = contentJSON
+			}
+			success.Content = map[string]MediaType{contentType: {Schema: schemas.schemaOf(route.Response)}}
+		}
+		op.Responses[strconv.Itoa(status)] = success
+
+		for _, code := range route.Errors {
+			op.Responses[strconv.Itoa(code)] = &Response{
+				Description: http.StatusText(code),
+				Content:     map[string]MediaType{contentJSON: {Schema: ref(errorSchema)}},
+			}
+		}
+
+		item, exists := doc.Paths[route.Path]
+		if !exists {
+			item = make(PathItem)
+			doc.Paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = op
+	}
+
+	return doc
+}
+
+// QueryParam describes an optional string query parameter.
+func QueryParam(name, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// RequiredQueryParam describes a string query parameter that must be set.
+func RequiredQueryParam(name, description string) Parameter {
+	param := QueryParam(name, description)
+	param.Required = true
+	return param
+}
+
+// IntQueryParam describes an optional integer query parameter.
+func IntQueryParam(name, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "integer"}}
+}
+
+// PathParam describes a path segment written {name} in the route path.
+func PathParam(name, description string) Parameter {
+	return Parameter{Name: name, In: "path", Description: description, Required: true, Schema: &Schema{Type: "string"}}
+}
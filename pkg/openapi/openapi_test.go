@@ -0,0 +1,114 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func build() *Document {
+	return Build(Info{Title: "test", Version: "dev"}, Routes)
+}
+
+func TestBuild_Schemas(t *testing.T) {
+	doc := build()
+
+	for _, name := range []string{"NavigationEvent", "VisitorStats", "ErrorResponse"} {
+		if _, exists := doc.Components.Schemas[name]; !exists {
+			t.Errorf("Expected a %s schema", name)
+		}
+	}
+
+	event := doc.Components.Schemas["NavigationEvent"]
+	if event.Properties["timestamp"].Format != "date-time" {
+		t.Errorf("Expected timestamp to be a date-time, got %+v", event.Properties["timestamp"])
+	}
+	if event.Properties["properties"].AdditionalProperties.Type != "string" {
+		t.Errorf("Expected properties to map to strings, got %+v", event.Properties["properties"])
+	}
+	if strings.Join(event.Required, ",") != "url,visitor_id" {
+		t.Errorf("Expected url and visitor_id to be required, got %v", event.Required)
+	}
+}
+
+func TestBuild_Operations(t *testing.T) {
+	doc := build()
+
+	ingest := doc.Paths["/api/v1/ingest"]["post"]
+	if ingest == nil {
+		t.Fatal("Expected POST /ingest to be documented")
+	}
+	if ingest.RequestBody.Content[contentJSON].Schema.Ref != "#/components/schemas/NavigationEvent" {
+		t.Errorf("Expected the ingest body to refer to NavigationEvent, got %+v", ingest.RequestBody.Content)
+	}
+	if ingest.Responses["400"].Content[contentJSON].Schema.Ref != "#/components/schemas/ErrorResponse" {
+		t.Errorf("Expected a 400 ErrorResponse, got %+v", ingest.Responses["400"])
+	}
+	if _, exists := ingest.Responses["201"]; !exists {
+		t.Errorf("Expected a 201 response, got %v", ingest.Responses)
+	}
+
+	stats := doc.Paths["/api/v1/stats"]["get"].Responses["200"].Content[contentJSON].Schema
+	if stats.Type != "object" || stats.Properties["distinct_visitors"].Type != "integer" {
+		t.Errorf("Expected an inline stats object, got %+v", stats)
+	}
+
+	visitor := doc.Paths["/api/v1/visitors/{visitor_id}"]
+	if visitor["get"] == nil || visitor["delete"] == nil {
+		t.Errorf("Expected GET and DELETE on the visitor path, got %v", visitor)
+	}
+	if doc.Paths["/api/v1/tenants"]["get"].Description == "" {
+		t.Error("Expected conditional routes to say when they are available")
+	}
+}
+
+func TestBuild_RefsResolve(t *testing.T) {
+	doc := build()
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Failed to marshal document: %v", err)
+	}
+
+	for _, part := range strings.Split(string(data), `"$ref":"#/components/schemas/`)[1:] {
+		name := part[:strings.Index(part, `"`)]
+		if _, exists := doc.Components.Schemas[name]; !exists {
+			t.Errorf("Unresolved reference to %s", name)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	Handler(build())(w, httptest.NewRequest("GET", "/openapi.json", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var doc Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to unmarshal document: %v", err)
+	}
+	if doc.OpenAPI != Version || len(doc.Paths) == 0 {
+		t.Errorf("Expected an OpenAPI %s document with paths, got %s with %d", Version, doc.OpenAPI, len(doc.Paths))
+	}
+
+	w = httptest.NewRecorder()
+	Handler(build())(w, httptest.NewRequest("POST", "/openapi.json", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+func TestDocsHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	DocsHandler()(w, httptest.NewRequest("GET", "/docs", nil))
+
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		t.Errorf("Expected HTML content type, got %s", contentType)
+	}
+	if !strings.Contains(w.Body.String(), "SwaggerUIBundle") {
+		t.Error("Expected the page to start Swagger UI")
+	}
+}
@@ -0,0 +1,558 @@
+package openapi
+
+import (
+	"net/http"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/monitoring"
+)
+
+// Tags group operations the way the server groups routes for middleware.
+const (
+	TagIngest = "ingest"
+	TagQuery  = "query"
+	TagAdmin  = "admin"
+)
+
+const (
+	contentNDJSON = "application/x-ndjson"
+	contentCSV    = "text/csv"
+)
+
+var (
+	urlParam         = RequiredQueryParam("url", "Page URL, normalized as at ingestion")
+	limitParam       = IntQueryParam("limit", "Maximum number of results")
+	fromParam        = QueryParam("from", "Start of the time range, RFC3339")
+	toParam          = QueryParam("to", "End of the time range, RFC3339")
+	listParams       = []Parameter{limitParam, QueryParam("cursor", "Offset of the page returned by next_cursor"), QueryParam("sort", "Sort key"), QueryParam("order", "asc or desc")}
+	successResponse  = Object{"success": true, "message": ""}
+	denyRuleRequest  = Object{"list": "", "match": "", "pattern": ""}
+	denylistResponse = Object{"urls": []models.DenyRule{}, "referrers": []models.DenyRule{}, "stats": models.DenylistStats{}}
+	eventQueryParams = []Parameter{
+		RequiredQueryParam("visitor_id", "Visitor ID"),
+		RequiredQueryParam("url", "Page URL"),
+		QueryParam("event_id", "Client-generated ID used to drop duplicates"),
+		QueryParam("session_id", "Session ID"),
+		QueryParam("referrer", "Referring URL; defaults to the Referer header"),
+	}
+)
+
+// countedResponse describes a list response with its length in count.
+func countedResponse(name string, items interface{}) Object {
+	return Object{name: items, "count": 0}
+}
+
+// Routes documents every route the server registers. Conditional routes are
+// only registered with the configuration named in their Condition.
+var Routes = []Route{
+	{
+		Method: http.MethodPost, Path: "/api/v1/ingest", Tag: TagIngest,
+		Summary: "Record a navigation event",
+		Params:  []Parameter{QueryParam("dry_run", "With true, validate and normalize the event and return what would be stored without recording it")},
+		Request: models.NavigationEvent{}, Status: http.StatusCreated, Response: successResponse,
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/pixel", Tag: TagIngest,
+		Summary: "Record a navigation event from query parameters and return a 1x1 GIF",
+		Params:  eventQueryParams, Response: Text(""), ResponseType: "image/gif",
+		Errors: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/stats", Tag: TagQuery,
+		Summary: "Get the distinct visitor count for a URL or URL pattern",
+		Params: []Parameter{
+			QueryParam("url", "Page URL; required unless pattern is set"),
+			QueryParam("pattern", "Registered URL pattern such as /product/*"),
+			QueryParam("dimension", "Configured property dimension to count distinct values of"),
+			QueryParam("detailed", "true to include page views, devices and a page of visitors"),
+			QueryParam("segment", "Only count visitors whose latest properties match, e.g. plan:pro,country:DE"),
+			limitParam, QueryParam("cursor", "Offset of the visitor page returned by next_cursor"),
+		},
+		Response: Object{"url": "", "distinct_visitors": 0},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/export", Tag: TagQuery,
+		Summary: "Stream visitor records",
+		Params: []Parameter{
+			QueryParam("format", "ndjson (default) or csv"), QueryParam("url_prefix", "Only export URLs starting with this prefix"),
+			fromParam, toParam, QueryParam("resumable", "true to emit resume tokens"), QueryParam("resume_token", "Token to resume an interrupted export from"),
+		},
+		Response: models.ExportRecord{}, ResponseType: contentNDJSON,
+		Errors: []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/sessions", Tag: TagQuery,
+		Summary:  "List a visitor's sessions, or count a URL's sessions",
+		Params:   []Parameter{QueryParam("visitor_id", "Visitor ID"), QueryParam("url", "Page URL")},
+		Response: Object{"visitor_id": "", "sessions": []models.Session{}, "count": 0},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/system-stats", Tag: TagQuery,
+		Summary: "Get tracker-wide statistics", Response: models.SystemStats{},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/top-urls", Tag: TagQuery,
+		Summary: "Get URLs with the most distinct visitors", Params: listParams,
+		Response: Object{"urls": []models.VisitorStats{}, "total": 0, "limit": 0, "offset": 0, "next_cursor": ""},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/cohorts", Tag: TagQuery,
+		Summary:  "Get the weekly retention matrix",
+		Params:   []Parameter{IntQueryParam("weeks", "Number of weekly cohorts")},
+		Response: Object{"weeks": 0, "cohorts": []models.CohortRow{}},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/actives", Tag: TagQuery,
+		Summary:  "Get daily, weekly or monthly active visitors",
+		Params:   []Parameter{QueryParam("url", "Page URL; all URLs when unset"), QueryParam("window", "day, week or month")},
+		Response: models.ActiveVisitors{},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/realtime", Tag: TagQuery,
+		Summary:  "Get the visitors online now",
+		Params:   []Parameter{QueryParam("url", "Page URL; all URLs when unset")},
+		Response: models.RealtimeVisitors{},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/stats/compare", Tag: TagQuery,
+		Summary:  "Compare distinct visitors and page views with the previous period",
+		Params:   []Parameter{QueryParam("url", "Page URL; all URLs when unset"), QueryParam("period", "Whole UTC days ending today, 1d to 15d (default 7d)")},
+		Response: models.PeriodComparison{},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/stats/batch", Tag: TagQuery,
+		Summary:  "Get the stats of up to 100 URLs, optionally over whole UTC days from from to to",
+		Request:  models.BatchStatsRequest{},
+		Response: countedResponse("results", []models.BatchStatsResult{}),
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/overlap", Tag: TagQuery,
+		Summary:  "Count the visitors two URLs share",
+		Params:   []Parameter{RequiredQueryParam("url_a", "First page URL"), RequiredQueryParam("url_b", "Second page URL")},
+		Response: models.URLOverlap{},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/events/stats", Tag: TagQuery,
+		Summary: "Count a URL's events by type",
+		Params: []Parameter{
+			urlParam, QueryParam("type", "pageview, click or custom:<name>; every type when unset"),
+			QueryParam("property", "name:value of a property indexed in the schema of type; only events with that value are counted"),
+		},
+		Response: models.EventTypeStats{},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/data-quality", Tag: TagQuery,
+		Summary: "Get suspicious and excluded visitor IDs", Response: models.DataQualityReport{},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/categories", Tag: TagQuery,
+		Summary: "Get visitor rollups per URL category", Response: Object{"categories": []models.CategoryStats{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/referrers", Tag: TagQuery,
+		Summary: "Get the top referring domains for a URL", Params: []Parameter{urlParam, limitParam},
+		Response: Object{"url": "", "referrers": []models.ReferrerStats{}},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/paths", Tag: TagQuery,
+		Summary: "Get the most common next and previous pages of a URL", Params: []Parameter{urlParam, limitParam},
+		Response: models.PathReport{},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/entry-pages", Tag: TagQuery,
+		Summary: "Get the URLs that most often start sessions", Params: []Parameter{limitParam},
+		Response: models.SessionPagesReport{},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/exit-pages", Tag: TagQuery,
+		Summary: "Get the URLs that most often end sessions", Params: []Parameter{limitParam},
+		Response: models.SessionPagesReport{},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/segments", Tag: TagQuery,
+		Summary:  "Get a URL's distinct visitors per latest value of a custom property",
+		Params:   []Parameter{urlParam, RequiredQueryParam("key", "Property key, such as plan")},
+		Response: Object{"url": "", "key": "", "segments": []models.SegmentStats{}},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/devices", Tag: TagQuery,
+		Summary: "Get the browser, OS and device breakdown for a URL", Params: []Parameter{urlParam},
+		Response: Object{"url": "", "devices": models.DeviceBreakdown{}},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/geo", Tag: TagQuery,
+		Summary: "Get the visitor breakdown by country for a URL", Params: []Parameter{urlParam},
+		Response: Object{"url": "", "countries": []models.CountryStats{}},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/languages", Tag: TagQuery,
+		Summary:  "Get the visitor breakdown by preferred language for a URL, or across URLs",
+		Params:   []Parameter{QueryParam("url", "Page URL; every URL when omitted")},
+		Response: Object{"url": "", "languages": []models.LanguageStats{}, "count": 0},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/visitors/{visitor_id}", Tag: TagQuery,
+		Summary:  "Get a visitor's URLs, page views and sessions",
+		Params:   []Parameter{PathParam("visitor_id", "Visitor ID"), QueryParam("raw", "true if visitor_id is already pseudonymized")},
+		Response: models.VisitorProfile{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/visitors/{visitor_id}", Tag: TagAdmin,
+		Summary:  "Erase a visitor's data",
+		Params:   []Parameter{PathParam("visitor_id", "Visitor ID"), QueryParam("raw", "true if visitor_id is already pseudonymized")},
+		Response: models.DeletionAuditEntry{},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/funnels/{id}/report", Tag: TagQuery,
+		Summary: "Get per-step visitors and drop-off for a funnel", Params: []Parameter{PathParam("id", "Funnel ID")},
+		Response: models.FunnelReport{},
+		Errors:   []int{http.StatusNotFound},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/stream", Tag: TagQuery,
+		Summary: "Stream ingested events over a WebSocket",
+		Params: []Parameter{
+			QueryParam("url", "Only stream events for this URL"), QueryParam("url_prefix", "Only stream URLs starting with this prefix"),
+			QueryParam("snapshot_interval", "Duration between visitor count snapshots, at least 1s"),
+		},
+		Status: http.StatusSwitchingProtocols,
+		Errors: []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/events/tail", Tag: TagQuery,
+		Summary:  "Stream ingested events as Server-Sent Events",
+		Params:   []Parameter{QueryParam("url", "Only stream events for this URL")},
+		Response: Text(""), ResponseType: "text/event-stream",
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/funnels", Tag: TagAdmin,
+		Summary: "List funnels", Response: countedResponse("funnels", []models.Funnel{}),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/funnels", Tag: TagAdmin,
+		Summary: "Define a funnel of ordered URL steps",
+		Request: Object{"name": "", "steps": []string{}}, Status: http.StatusCreated, Response: models.Funnel{},
+		Errors: []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/schemas", Tag: TagAdmin,
+		Summary: "List property schemas", Response: countedResponse("schemas", []models.PropertySchema{}),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/schemas", Tag: TagAdmin,
+		Summary: "Register the property schema of an event type",
+		Request: models.PropertySchema{}, Status: http.StatusCreated, Response: models.PropertySchema{},
+		Errors: []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/schemas/{event_type}", Tag: TagAdmin,
+		Summary: "Get the property schema of an event type", Params: []Parameter{PathParam("event_type", "Event type")},
+		Response: models.PropertySchema{}, Errors: []int{http.StatusNotFound},
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/schemas/{event_type}", Tag: TagAdmin,
+		Summary: "Replace the property schema of an event type", Params: []Parameter{PathParam("event_type", "Event type")},
+		Request: models.PropertySchema{}, Response: models.PropertySchema{},
+		Errors: []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/schemas/{event_type}", Tag: TagAdmin,
+		Summary: "Delete the property schema of an event type", Params: []Parameter{PathParam("event_type", "Event type")},
+		Response: successResponse, Errors: []int{http.StatusNotFound},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/patterns", Tag: TagAdmin,
+		Summary: "List URL patterns with their stats", Response: countedResponse("patterns", []models.PatternStats{}),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/patterns", Tag: TagAdmin,
+		Summary: "Register a URL pattern such as /product/* for grouped stats",
+		Request: Object{"pattern": ""}, Status: http.StatusCreated, Response: models.PatternStats{},
+		Errors: []int{http.StatusBadRequest, http.StatusConflict},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/denylist", Tag: TagAdmin,
+		Summary:  "List the URL and referrer deny rules with rejection counts",
+		Response: denylistResponse,
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/denylist", Tag: TagAdmin,
+		Summary: "Replace every deny rule",
+		Request: models.DenylistConfig{}, Response: denylistResponse,
+		Errors: []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/denylist", Tag: TagAdmin,
+		Summary: "Add a deny rule to the urls or referrers list",
+		Request: denyRuleRequest, Status: http.StatusCreated, Response: denyRuleRequest,
+		Errors: []int{http.StatusBadRequest, http.StatusConflict},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/denylist", Tag: TagAdmin,
+		Summary: "Remove a deny rule from the urls or referrers list",
+		Request: denyRuleRequest, Response: denylistResponse,
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/urls", Tag: TagAdmin,
+		Summary: "List tracked URLs in order, filtered by prefix and substring",
+		Params: []Parameter{
+			QueryParam("prefix", "Only URLs starting with this prefix"),
+			QueryParam("q", "Only URLs containing this text, ignoring case"),
+			limitParam,
+			QueryParam("cursor", "The next_cursor of the previous page"),
+		},
+		Response: Object{"urls": []models.VisitorStats{}, "total": 0, "limit": 0, "next_cursor": ""},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/urls", Tag: TagAdmin,
+		Summary: "Erase a URL's data", Params: []Parameter{urlParam},
+		Response: models.DeletionAuditEntry{},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/deletions", Tag: TagAdmin,
+		Summary: "Get the data deletion audit log", Response: []models.DeletionAuditEntry{},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/import", Tag: TagAdmin,
+		Summary: "Import a file of historical events, reporting progress as NDJSON",
+		Params:  []Parameter{QueryParam("format", "ndjson or csv; defaults from Content-Type"), IntQueryParam("rate", "Maximum events per second")},
+		Request: Text(""), RequestTypes: []string{contentNDJSON, contentCSV},
+		Response: models.ImportProgress{}, ResponseType: contentNDJSON,
+		Errors: []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/urls/bulk", Tag: TagAdmin,
+		Summary: "List recent bulk URL jobs", Response: Object{"jobs": []models.BulkJob{}, "count": 0},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/urls/bulk", Tag: TagAdmin,
+		Summary: "Start a bulk delete, merge, categorize, freeze or retention job",
+		Request: models.BulkOperation{}, Status: http.StatusAccepted, Response: models.BulkJob{},
+		Errors: []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/urls/bulk/{id}", Tag: TagAdmin,
+		Summary: "Get a bulk URL job's status and result summary", Params: []Parameter{PathParam("id", "Job ID")},
+		Response: models.BulkJob{},
+		Errors:   []int{http.StatusNotFound},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/slowlog", Tag: TagAdmin,
+		Summary:  "Get recent slow queries",
+		Response: Object{"threshold": "", "total": int64(0), "entries": []monitoring.SlowQueryEntry{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/metrics", Tag: TagAdmin,
+		Summary: "Get request metrics per route", Response: monitoring.PerformanceMetrics{},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/ingest-activity", Tag: TagAdmin,
+		Summary:  "Get per-minute ingest counts by API key and IP",
+		Params:   []Parameter{fromParam, toParam, QueryParam("key", "Only count this API key"), QueryParam("format", "json (default) or csv")},
+		Response: Object{"from": time.Time{}, "to": time.Time{}, "window": "", "buckets": []monitoring.ActivityBucket{}},
+		Errors:   []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/accuracy", Tag: TagAdmin,
+		Summary: "Compare approximate and exact distinct visitor counts", Response: models.AccuracyReport{},
+		Errors: []int{http.StatusInternalServerError}, Condition: "Redis accuracy auditing is enabled",
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/cluster", Tag: TagAdmin,
+		Summary: "Get this node's replication status", Response: models.ClusterStatus{},
+		Condition: "clustering is enabled",
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/cluster/gossip", Tag: TagAdmin,
+		Summary: "Merge the counters pushed by a peer", Request: Object{"node": "", "counters": Object{}},
+		Response: Object{"origin": ""}, Errors: []int{http.StatusBadRequest, http.StatusUnauthorized},
+		Condition: "clustering is enabled",
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/config", Tag: TagAdmin,
+		Summary: "Get the configuration in effect", Response: models.Configuration{},
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/config", Tag: TagAdmin,
+		Summary: "Update the configuration; the body only needs the fields being changed",
+		Request: models.Configuration{}, Response: Object{"config": models.Configuration{}, "applied": []string{}, "restart_required": []string{}},
+		Errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/config/history", Tag: TagAdmin,
+		Summary:  "List configuration versions, or get one with its configuration",
+		Params:   []Parameter{IntQueryParam("version", "Version to return with its configuration")},
+		Response: countedResponse("versions", []models.ConfigVersion{}),
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/config/rollback", Tag: TagAdmin,
+		Summary:  "Apply the configuration of an earlier version as a new version",
+		Params:   []Parameter{IntQueryParam("version", "Version to restore")},
+		Response: Object{"version": models.ConfigVersion{}, "applied": []string{}, "restart_required": []string{}},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/config/canary", Tag: TagAdmin,
+		Summary:  "Get the running configuration canary with the stable and canary events counted side by side",
+		Response: models.ConfigCanary{}, Errors: []int{http.StatusNotFound},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/config/canary", Tag: TagAdmin,
+		Summary: "Try a configuration on a percentage of visitors; the body only needs the fields being changed",
+		Params:  []Parameter{IntQueryParam("percent", "Percentage of visitors, 1 to 100")},
+		Request: models.Configuration{}, Status: http.StatusCreated, Response: models.ConfigCanary{},
+		Errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/config/canary/promote", Tag: TagAdmin,
+		Summary:  "Apply the canary configuration to all traffic as a new version",
+		Response: Object{"version": models.ConfigVersion{}, "canary": models.ConfigCanary{}},
+		Errors:   []int{http.StatusNotFound, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/config/canary/abort", Tag: TagAdmin,
+		Summary:  "End the canary, keeping the configuration in effect",
+		Response: Object{"canary": models.ConfigCanary{}}, Errors: []int{http.StatusNotFound},
+	},
+	{
+		Method: http.MethodGet, Path: "/ready", Tag: TagAdmin,
+		Summary:  "Get per-component startup status; 503 until every component has started",
+		Response: Object{"ready": true, "components": []models.ComponentStatus{}, "build": models.BuildInfo{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/livez", Tag: TagAdmin,
+		Summary: "Liveness probe", Response: Object{"status": "", "started_at": time.Time{}, "uptime": time.Duration(0)},
+	},
+	{
+		Method: http.MethodGet, Path: "/readyz", Tag: TagAdmin,
+		Summary:  "Readiness probe with dependency checks; 503 when any check fails",
+		Response: Object{"ready": true, "checks": []models.CheckResult{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/version", Tag: TagAdmin,
+		Summary: "Get the build version, commit and date", Response: models.BuildInfo{},
+	},
+	{
+		Method: http.MethodGet, Path: "/dashboard", Tag: TagAdmin,
+		Summary: "Operator dashboard", Response: Text(""), ResponseType: "text/html",
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/snapshot", Tag: TagAdmin,
+		Summary:  "Save a persistence snapshot",
+		Response: Object{"success": true, "path": "", "duration": ""},
+		Errors:   []int{http.StatusInternalServerError}, Condition: "snapshots are enabled",
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/compact", Tag: TagAdmin,
+		Summary:   "List the last 50 compaction runs, newest first",
+		Response:  Object{"runs": []models.CompactionResult{}, "count": 0},
+		Condition: "snapshots or tiering are enabled",
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/admin/compact", Tag: TagAdmin,
+		Summary:  "Compact the snapshot and cold tier files, reporting the bytes reclaimed",
+		Response: models.CompactionResult{},
+		Errors:   []int{http.StatusInternalServerError}, Condition: "snapshots or tiering are enabled",
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/reports", Tag: TagAdmin,
+		Summary:   "List report schedules",
+		Response:  Object{"reports": []models.ReportSchedule{}, "count": 0},
+		Condition: "reports are enabled",
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/reports", Tag: TagAdmin,
+		Summary: "Create a report schedule",
+		Request: models.ReportSchedule{}, Status: http.StatusCreated, Response: models.ReportSchedule{},
+		Errors:    []int{http.StatusBadRequest},
+		Condition: "reports are enabled",
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/reports/{id}", Tag: TagAdmin,
+		Summary: "Get a report schedule", Params: []Parameter{PathParam("id", "Report schedule ID")},
+		Response: models.ReportSchedule{}, Errors: []int{http.StatusNotFound},
+		Condition: "reports are enabled",
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/reports/{id}", Tag: TagAdmin,
+		Summary: "Replace a report schedule's settings", Params: []Parameter{PathParam("id", "Report schedule ID")},
+		Request: models.ReportSchedule{}, Response: models.ReportSchedule{},
+		Errors:    []int{http.StatusBadRequest, http.StatusNotFound},
+		Condition: "reports are enabled",
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/reports/{id}", Tag: TagAdmin,
+		Summary: "Delete a report schedule", Params: []Parameter{PathParam("id", "Report schedule ID")},
+		Response: successResponse, Errors: []int{http.StatusNotFound},
+		Condition: "reports are enabled",
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/reports/{id}/run", Tag: TagAdmin,
+		Summary: "Generate and deliver a report now", Params: []Parameter{PathParam("id", "Report schedule ID")},
+		Response: models.Report{}, Errors: []int{http.StatusNotFound, http.StatusBadGateway},
+		Condition: "reports are enabled",
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/tenants", Tag: TagAdmin,
+		Summary: "List tenants with their state and stats", Response: []models.TenantInfo{},
+		Condition: "tenancy is enabled",
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/tenants", Tag: TagAdmin,
+		Summary: "Create, suspend or reactivate a tenant",
+		Request: Object{"id": "", "state": ""}, Response: models.TenantInfo{},
+		Errors:    []int{http.StatusBadRequest, http.StatusForbidden, http.StatusConflict},
+		Condition: "tenancy is enabled",
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/tenants/{id}", Tag: TagAdmin,
+		Summary: "Delete a tenant and purge its data", Params: []Parameter{PathParam("id", "Tenant ID")},
+		Status: http.StatusAccepted, Response: models.TenantInfo{},
+		Errors:    []int{http.StatusBadRequest, http.StatusNotFound},
+		Condition: "tenancy is enabled",
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/tenants/rebalance", Tag: TagAdmin,
+		Summary: "Replace the quotas of some tenants and list every tenant",
+		Request: Object{"quotas": map[string]models.TenantQuota{}}, Response: []models.TenantInfo{},
+		Errors:    []int{http.StatusBadRequest, http.StatusNotFound},
+		Condition: "tenancy is enabled",
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/tenants/audit", Tag: TagAdmin,
+		Summary: "Get the tenant lifecycle audit log", Response: []models.TenantAuditEntry{},
+		Condition: "tenancy is enabled",
+	},
+	{
+		Method: http.MethodGet, Path: "/openapi.json", Tag: TagAdmin,
+		Summary: "Get this OpenAPI document", Response: Object{},
+	},
+	{
+		Method: http.MethodGet, Path: "/docs", Tag: TagAdmin,
+		Summary: "Swagger UI for this API", Response: Text(""), ResponseType: "text/html",
+	},
+}
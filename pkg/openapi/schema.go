@@ -0,0 +1,162 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Object describes a JSON object built as a map in a handler: each key is a
+// field and its value a sample of the field's type. Nested Objects are
+// described inline.
+type Object map[string]interface{}
+
+// Text is a sample response or request body that is not JSON, such as a CSV
+// or NDJSON stream.
+type Text string
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	objectType   = reflect.TypeOf(Object{})
+	textType     = reflect.TypeOf(Text(""))
+	rawJSONType  = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// registry reflects Go types into schemas, adding each named struct to the
+// component schemas once and referring to it from then on.
+type registry struct {
+	components map[string]*Schema
+}
+
+func newRegistry(components map[string]*Schema) *registry {
+	return &registry{components: components}
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// schemaOf returns the schema of sample's type, or of its fields when
+// sample is an Object.
+func (r *registry) schemaOf(sample interface{}) *Schema {
+	if object, ok := sample.(Object); ok {
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema, len(object))}
+		for name, value := range object {
+			if value == nil {
+				schema.Properties[name] = &Schema{}
+				continue
+			}
+			schema.Properties[name] = r.schemaOf(value)
+		}
+		return schema
+	}
+	return r.schemaFor(reflect.TypeOf(sample))
+}
+
+func (r *registry) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "Duration in nanoseconds"}
+	case objectType:
+		return &Schema{Type: "object"}
+	case textType:
+		return &Schema{Type: "string"}
+	case rawJSONType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		if _, exists := r.components[t.Name()]; !exists {
+			// Register before filling so self-referencing types terminate
+			r.components[t.Name()] = &Schema{}
+			*r.components[t.Name()] = *r.structSchema(t)
+		}
+		return ref(t.Name())
+	}
+
+	// Interfaces and anything else may hold any JSON value
+	return &Schema{}
+}
+
+// structSchema describes the fields encoding/json writes for t. Fields
+// without omitempty are required.
+func (r *registry) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	r.addFields(schema, t)
+	sort.Strings(schema.Required)
+	return schema
+}
+
+func (r *registry) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				r.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = r.schemaFor(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
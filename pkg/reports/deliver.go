@@ -0,0 +1,113 @@
+package reports
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// Mailer sends an email message with the given headers already in msg.
+type Mailer func(to []string, msg []byte) error
+
+// SMTPMailer sends mail through the configured server, authenticating with
+// PLAIN auth when a username is set.
+func SMTPMailer(config models.SMTPConfig) Mailer {
+	return func(to []string, msg []byte) error {
+		var auth smtp.Auth
+		if config.Username != "" {
+			auth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
+		}
+		addr := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+		return smtp.SendMail(addr, auth, config.From, to, msg)
+	}
+}
+
+// deliver renders report in the schedule's format and sends it to every
+// target, returning the errors of those that failed.
+func (s *Scheduler) deliver(schedule models.ReportSchedule, report *models.Report) error {
+	body, err := Render(report, schedule.Format)
+	if err != nil {
+		return fmt.Errorf("render report: %w", err)
+	}
+	contentType := ContentType(schedule.Format)
+
+	var errs []error
+	if schedule.Delivery.Webhook != "" {
+		if err := s.retry(func() error { return s.post(schedule.Delivery.Webhook, contentType, body) }); err != nil {
+			errs = append(errs, fmt.Errorf("webhook: %w", err))
+		}
+	}
+	if len(schedule.Delivery.Email) > 0 {
+		msg := s.message(schedule, report, contentType, body)
+		if err := s.retry(func() error { return s.mailer(schedule.Delivery.Email, msg) }); err != nil {
+			errs = append(errs, fmt.Errorf("email: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// retry calls send until it succeeds, up to MaxRetries more times, doubling
+// the backoff after each attempt.
+func (s *Scheduler) retry(send func() error) error {
+	backoff := s.config.RetryBackoff
+	var err error
+	for attempt := 0; attempt <= s.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+				backoff *= 2
+			case <-s.stopCh:
+				return fmt.Errorf("stopped after %d attempts: %w", attempt, err)
+			}
+		}
+
+		if err = send(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (s *Scheduler) post(url, contentType string, body []byte) error {
+	resp, err := s.client.Post(url, contentType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// message builds the email carrying the rendered report as its body.
+func (s *Scheduler) message(schedule models.ReportSchedule, report *models.Report, contentType string, body []byte) []byte {
+	period := report.Summary.Current.Start.Format("2006-01-02")
+	if schedule.Frequency == models.ReportWeekly {
+		period += " to " + report.Summary.Current.End.Add(-day).Format("2006-01-02")
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.config.SMTP.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(schedule.Delivery.Email, ", "))
+	// Names come from API clients; keep them from adding headers
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace(schedule.Name)
+	fmt.Fprintf(&msg, "Subject: %s (%s)\r\n", subject, period)
+	fmt.Fprintf(&msg, "Date: %s\r\n", report.GeneratedAt.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: %s\r\n", contentType)
+	if schedule.Format == models.ReportFormatCSV {
+		fmt.Fprintf(&msg, "Content-Disposition: attachment; filename=\"%s-%s.csv\"\r\n", schedule.ID, report.Summary.Current.Start.Format("2006-01-02"))
+	}
+	msg.WriteString("\r\n")
+	msg.Write(body)
+	return msg.Bytes()
+}
@@ -0,0 +1,97 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strconv"
+
+	"nav-tracker/pkg/models"
+)
+
+// ContentType returns the MIME type of reports rendered in format.
+func ContentType(format string) string {
+	switch format {
+	case models.ReportFormatCSV:
+		return "text/csv; charset=utf-8"
+	case models.ReportFormatHTML:
+		return "text/html; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// Render formats report as json, csv or html.
+func Render(report *models.Report, format string) ([]byte, error) {
+	switch format {
+	case "", models.ReportFormatJSON:
+		return json.Marshal(report)
+	case models.ReportFormatCSV:
+		return renderCSV(report)
+	case models.ReportFormatHTML:
+		var buf bytes.Buffer
+		if err := htmlReport.Execute(&buf, report); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unknown report format %q", format)
+}
+
+// renderCSV writes the summary table, a blank line and the top URLs table.
+func renderCSV(report *models.Report) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	summary := report.Summary
+	records := [][]string{
+		{"metric", "current", "previous", "change", "change_percent"},
+		{"distinct_visitors", strconv.Itoa(summary.Current.DistinctVisitors), strconv.Itoa(summary.Previous.DistinctVisitors),
+			strconv.Itoa(summary.Change.DistinctVisitors), formatPercent(summary.Change.DistinctVisitorsPercent)},
+		{"page_views", strconv.FormatInt(summary.Current.PageViews, 10), strconv.FormatInt(summary.Previous.PageViews, 10),
+			strconv.FormatInt(summary.Change.PageViews, 10), formatPercent(summary.Change.PageViewsPercent)},
+		{},
+		{"rank", "url", "distinct_visitors", "total_page_views"},
+	}
+	for i, stats := range report.TopURLs {
+		records = append(records, []string{strconv.Itoa(i + 1), stats.URL, strconv.Itoa(stats.DistinctVisitors), strconv.Itoa(stats.TotalPageViews)})
+	}
+
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// formatPercent renders a percentage change, empty when there is none.
+func formatPercent(percent *float64) string {
+	if percent == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*percent, 'f', 2, 64)
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": formatPercent,
+	"inc":     func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Name}}</title></head>
+<body>
+<h1>{{.Name}}</h1>
+<p>{{.Frequency}} report for {{.Summary.Current.Start.Format "2006-01-02"}} to {{.Summary.Current.End.Format "2006-01-02"}}, generated {{.GeneratedAt.Format "2006-01-02 15:04 MST"}}</p>
+<table>
+<tr><th></th><th>Current</th><th>Previous</th><th>Change</th><th>Change %</th></tr>
+<tr><td>Distinct visitors</td><td>{{.Summary.Current.DistinctVisitors}}</td><td>{{.Summary.Previous.DistinctVisitors}}</td><td>{{.Summary.Change.DistinctVisitors}}</td><td>{{percent .Summary.Change.DistinctVisitorsPercent}}</td></tr>
+<tr><td>Page views</td><td>{{.Summary.Current.PageViews}}</td><td>{{.Summary.Previous.PageViews}}</td><td>{{.Summary.Change.PageViews}}</td><td>{{percent .Summary.Change.PageViewsPercent}}</td></tr>
+</table>
+<h2>Top URLs</h2>
+<table>
+<tr><th>#</th><th>URL</th><th>Distinct visitors</th><th>Page views</th></tr>
+{{range $i, $url := .TopURLs}}<tr><td>{{inc $i}}</td><td>{{$url.URL}}</td><td>{{$url.DistinctVisitors}}</td><td>{{$url.TotalPageViews}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,315 @@
+// Package reports generates scheduled traffic reports and delivers them to
+// webhooks and email addresses.
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// ErrScheduleNotFound is returned for an unknown schedule ID.
+var ErrScheduleNotFound = errors.New("report schedule not found")
+
+const day = 24 * time.Hour
+
+// Scheduler holds the report schedules and, once started, generates and
+// delivers each report when it falls due.
+type Scheduler struct {
+	config  models.ReportsConfig
+	tracker *storage.NavigationTracker
+	client  *http.Client
+	mailer  Mailer
+
+	schedules map[string]*models.ReportSchedule
+	// configured holds the IDs of the schedules from the configuration,
+	// which are not saved.
+	configured map[string]bool
+	nextID     int
+	mutex      sync.Mutex
+
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+	started  atomic.Bool
+}
+
+// NewScheduler returns a scheduler for the configured schedules, which must
+// already be valid; call ReportsConfig.Validate first, and for those created
+// through the API and saved to config.Path.
+func NewScheduler(config models.ReportsConfig, tracker *storage.NavigationTracker) *Scheduler {
+	s := &Scheduler{
+		config:     config,
+		tracker:    tracker,
+		client:     &http.Client{Timeout: config.WebhookTimeout},
+		mailer:     SMTPMailer(config.SMTP),
+		schedules:  make(map[string]*models.ReportSchedule),
+		configured: make(map[string]bool),
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+	}
+
+	now := time.Now().UTC()
+	for _, schedule := range config.Schedules {
+		schedule := schedule
+		if schedule.ID == "" {
+			schedule.ID = s.newID()
+		}
+		s.add(&schedule, now)
+		s.configured[schedule.ID] = true
+	}
+	if err := s.load(); err != nil {
+		log.Printf("Report schedules not restored: %v", err)
+	}
+	return s
+}
+
+// SetMailer replaces the SMTP mailer, e.g. to capture messages in tests. It
+// must be called before the scheduler starts.
+func (s *Scheduler) SetMailer(mailer Mailer) {
+	s.mailer = mailer
+}
+
+// newID returns an unused schedule ID; the caller must hold the mutex or
+// still be constructing the scheduler.
+func (s *Scheduler) newID() string {
+	for {
+		s.nextID++
+		id := fmt.Sprintf("report_%d", s.nextID)
+		if _, exists := s.schedules[id]; !exists {
+			return id
+		}
+	}
+}
+
+// add stores schedule with its defaults applied; the caller must hold the
+// mutex or still be constructing the scheduler.
+func (s *Scheduler) add(schedule *models.ReportSchedule, now time.Time) {
+	if schedule.Format == "" {
+		schedule.Format = models.ReportFormatJSON
+	}
+	if schedule.TopURLs == 0 {
+		schedule.TopURLs = models.DefaultReportTopURLs
+	}
+	if schedule.CreatedAt.IsZero() {
+		schedule.CreatedAt = now
+	}
+	schedule.NextRun = nextRun(*schedule, now)
+	s.schedules[schedule.ID] = schedule
+}
+
+func (s *Scheduler) validate(schedule models.ReportSchedule) error {
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	if len(schedule.Delivery.Email) > 0 && (s.config.SMTP.Host == "" || s.config.SMTP.From == "") {
+		return fmt.Errorf("email delivery requires reports.smtp.host and reports.smtp.from")
+	}
+	return nil
+}
+
+// nextRun returns the first time after now that schedule is due.
+func nextRun(schedule models.ReportSchedule, now time.Time) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), schedule.Hour, 0, 0, 0, time.UTC)
+	weekday, weekly := models.ParseWeekday(schedule.Weekday)
+	for !next.After(now) || (weekly && next.Weekday() != weekday) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Create validates and stores a new schedule, assigning its ID.
+func (s *Scheduler) Create(schedule models.ReportSchedule) (models.ReportSchedule, error) {
+	if err := s.validate(schedule); err != nil {
+		return models.ReportSchedule{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	schedule.ID = s.newID()
+	schedule.CreatedAt = time.Time{}
+	schedule.LastRun, schedule.LastError = nil, ""
+	s.add(&schedule, time.Now().UTC())
+	s.save()
+	return schedule, nil
+}
+
+// Update replaces the settings of schedule id, keeping its history.
+func (s *Scheduler) Update(id string, schedule models.ReportSchedule) (models.ReportSchedule, error) {
+	if err := s.validate(schedule); err != nil {
+		return models.ReportSchedule{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	existing, exists := s.schedules[id]
+	if !exists {
+		return models.ReportSchedule{}, ErrScheduleNotFound
+	}
+	schedule.ID = id
+	schedule.CreatedAt = existing.CreatedAt
+	schedule.LastRun, schedule.LastError = existing.LastRun, existing.LastError
+	s.add(&schedule, time.Now().UTC())
+	s.save()
+	return schedule, nil
+}
+
+// Delete removes schedule id and reports whether it existed.
+func (s *Scheduler) Delete(id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, exists := s.schedules[id]
+	delete(s.schedules, id)
+	s.save()
+	return exists
+}
+
+func (s *Scheduler) Get(id string) (models.ReportSchedule, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	schedule, exists := s.schedules[id]
+	if !exists {
+		return models.ReportSchedule{}, false
+	}
+	return *schedule, true
+}
+
+// List returns every schedule ordered by creation.
+func (s *Scheduler) List() []models.ReportSchedule {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	schedules := make([]models.ReportSchedule, 0, len(s.schedules))
+	for _, schedule := range s.schedules {
+		schedules = append(schedules, *schedule)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		if !schedules[i].CreatedAt.Equal(schedules[j].CreatedAt) {
+			return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+		}
+		return schedules[i].ID < schedules[j].ID
+	})
+	return schedules
+}
+
+func (s *Scheduler) Start() {
+	if !s.started.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer close(s.doneCh)
+
+		ticker := time.NewTicker(s.config.CheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				s.RunDue(now)
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the schedule loop and waits for in-flight deliveries.
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	if s.started.Load() {
+		<-s.doneCh
+	}
+}
+
+// RunDue generates and delivers every report due at now, one at a time, and
+// schedules each for its next run.
+func (s *Scheduler) RunDue(now time.Time) {
+	s.mutex.Lock()
+	var due []models.ReportSchedule
+	for _, schedule := range s.schedules {
+		if !schedule.NextRun.After(now) {
+			schedule.NextRun = nextRun(*schedule, now)
+			due = append(due, *schedule)
+		}
+	}
+	s.mutex.Unlock()
+
+	for _, schedule := range due {
+		if _, err := s.run(schedule, now); err != nil {
+			log.Printf("Report %s (%s) not delivered: %v", schedule.ID, schedule.Name, err)
+		}
+	}
+}
+
+// Run generates and delivers schedule id's report at once, without changing
+// when it next runs, and returns the report.
+func (s *Scheduler) Run(id string, now time.Time) (*models.Report, error) {
+	schedule, exists := s.Get(id)
+	if !exists {
+		return nil, ErrScheduleNotFound
+	}
+	return s.run(schedule, now)
+}
+
+func (s *Scheduler) run(schedule models.ReportSchedule, now time.Time) (*models.Report, error) {
+	report := s.Generate(schedule, now)
+	err := s.deliver(schedule, report)
+
+	s.mutex.Lock()
+	if current, exists := s.schedules[schedule.ID]; exists {
+		ran := now.UTC()
+		current.LastRun = &ran
+		current.LastError = ""
+		if err != nil {
+			current.LastError = err.Error()
+		}
+		s.save()
+	}
+	s.mutex.Unlock()
+
+	return report, err
+}
+
+// Generate builds schedule's report for the day, or the week, ending with
+// the last whole UTC day before now, ranking top URLs by their visitors
+// over that period.
+func (s *Scheduler) Generate(schedule models.ReportSchedule, now time.Time) *models.Report {
+	days := 1
+	if schedule.Frequency == models.ReportWeekly {
+		days = 7
+	}
+	summary, err := s.tracker.Actives().Compare("", days, now.Add(-day))
+	if err != nil {
+		// days is always within the periods the tracker keeps
+		summary = &models.PeriodComparison{}
+	}
+	topURLs, err := s.tracker.Actives().TopURLs(summary.Current.Start, summary.Current.End, schedule.TopURLs)
+	if err != nil {
+		topURLs = []*models.VisitorStats{}
+	}
+
+	return &models.Report{
+		Schedule:    schedule.ID,
+		Name:        schedule.Name,
+		Frequency:   schedule.Frequency,
+		GeneratedAt: now.UTC(),
+		Summary:     *summary,
+		TopURLs:     topURLs,
+	}
+}
@@ -0,0 +1,231 @@
+package reports
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func testConfig() models.ReportsConfig {
+	config := models.DefaultConfiguration().Reports
+	config.Enabled = true
+	config.RetryBackoff = time.Millisecond
+	config.SMTP.Host = "mail.example.com"
+	config.SMTP.From = "reports@example.com"
+	return config
+}
+
+func TestNextRun(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		schedule models.ReportSchedule
+		expected time.Time
+	}{
+		{models.ReportSchedule{Frequency: models.ReportDaily, Hour: 11}, time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)},
+		{models.ReportSchedule{Frequency: models.ReportDaily, Hour: 10}, time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)},
+		{models.ReportSchedule{Frequency: models.ReportWeekly, Weekday: "monday", Hour: 6}, time.Date(2024, 5, 6, 6, 0, 0, 0, time.UTC)},
+		{models.ReportSchedule{Frequency: models.ReportWeekly, Weekday: "Wednesday", Hour: 9}, time.Date(2024, 5, 8, 9, 0, 0, 0, time.UTC)},
+		{models.ReportSchedule{Frequency: models.ReportWeekly, Weekday: "wednesday", Hour: 12}, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if next := nextRun(tt.schedule, now); !next.Equal(tt.expected) {
+			t.Errorf("Expected %+v to run next at %v, got %v", tt.schedule, tt.expected, next)
+		}
+	}
+}
+
+func TestScheduler_CRUD(t *testing.T) {
+	config := testConfig()
+	config.SMTP.Host = ""
+	scheduler := NewScheduler(config, storage.NewNavigationTracker())
+
+	schedule := models.ReportSchedule{Name: "Daily", Frequency: models.ReportDaily, Hour: 6, Delivery: models.ReportDelivery{Webhook: "https://hooks.example.com/r"}}
+	created, err := scheduler.Create(schedule)
+	if err != nil {
+		t.Fatalf("Failed to create schedule: %v", err)
+	}
+	if created.ID == "" || created.Format != models.ReportFormatJSON || created.TopURLs != models.DefaultReportTopURLs || created.NextRun.IsZero() {
+		t.Errorf("Expected an ID, defaults and a next run, got %+v", created)
+	}
+
+	schedule.Delivery.Email = []string{"ops@example.com"}
+	if _, err := scheduler.Create(schedule); err == nil {
+		t.Error("Expected email delivery without an SMTP server to be rejected")
+	}
+	if _, err := scheduler.Create(models.ReportSchedule{Name: "Bad", Frequency: "hourly", Delivery: models.ReportDelivery{Webhook: "https://hooks.example.com/r"}}); err == nil {
+		t.Error("Expected an unknown frequency to be rejected")
+	}
+
+	schedule.Delivery.Email = nil
+	schedule.Frequency, schedule.Weekday, schedule.Format = models.ReportWeekly, "monday", models.ReportFormatCSV
+	updated, err := scheduler.Update(created.ID, schedule)
+	if err != nil || updated.Format != models.ReportFormatCSV || !updated.CreatedAt.Equal(created.CreatedAt) || updated.NextRun.Weekday() != time.Monday {
+		t.Errorf("Expected the weekly csv schedule, got %+v (%v)", updated, err)
+	}
+	if _, err := scheduler.Update("missing", schedule); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("Expected ErrScheduleNotFound, got %v", err)
+	}
+
+	if schedules := scheduler.List(); len(schedules) != 1 || schedules[0].Frequency != models.ReportWeekly {
+		t.Errorf("Expected the updated schedule listed, got %+v", schedules)
+	}
+	if !scheduler.Delete(created.ID) || scheduler.Delete(created.ID) {
+		t.Error("Expected the schedule to be deleted once")
+	}
+}
+
+func TestScheduler_RunDueDelivers(t *testing.T) {
+	tracker := storage.NewNavigationTracker()
+	// The daily report due next covers today; the day before has more
+	// visitors on /c, which must not be ranked
+	now := time.Now().UTC()
+	yesterday := now.Add(-day)
+	for _, event := range []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: now},
+		{VisitorID: "visitor2", URL: "https://example.com/a", Timestamp: now},
+		{VisitorID: "visitor1", URL: "https://example.com/b", Timestamp: now},
+		{VisitorID: "visitor1", URL: "https://example.com/c", Timestamp: yesterday},
+		{VisitorID: "visitor2", URL: "https://example.com/c", Timestamp: yesterday},
+		{VisitorID: "visitor3", URL: "https://example.com/c", Timestamp: yesterday},
+	} {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	var mutex sync.Mutex
+	var webhookBodies []string
+	attempts := 0
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		// The first delivery fails and is retried
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		webhookBodies = append(webhookBodies, r.Header.Get("Content-Type")+"\n"+string(body))
+	}))
+	defer webhook.Close()
+
+	config := testConfig()
+	config.Schedules = []models.ReportSchedule{{
+		Name: "Daily traffic", Frequency: models.ReportDaily, Format: models.ReportFormatCSV,
+		Delivery: models.ReportDelivery{Webhook: webhook.URL, Email: []string{"ops@example.com"}},
+	}}
+	scheduler := NewScheduler(config, tracker)
+
+	var mails []string
+	scheduler.SetMailer(func(to []string, msg []byte) error {
+		mails = append(mails, strings.Join(to, ",")+"\n"+string(msg))
+		return nil
+	})
+
+	schedule := scheduler.List()[0]
+	scheduler.RunDue(schedule.NextRun.Add(-time.Second))
+	if len(webhookBodies) != 0 || len(mails) != 0 {
+		t.Fatal("Expected nothing delivered before the schedule is due")
+	}
+
+	scheduler.RunDue(schedule.NextRun)
+	if len(webhookBodies) != 1 || len(mails) != 1 {
+		t.Fatalf("Expected one webhook and one email delivery, got %d and %d", len(webhookBodies), len(mails))
+	}
+	if !strings.HasPrefix(webhookBodies[0], "text/csv") || !strings.Contains(webhookBodies[0], "1,https://example.com/a,2,2") || strings.Contains(webhookBodies[0], "example.com/c") {
+		t.Errorf("Expected the csv report with the top URL, got %s", webhookBodies[0])
+	}
+	if !strings.HasPrefix(mails[0], "ops@example.com\nFrom: reports@example.com\r\n") || !strings.Contains(mails[0], "Subject: Daily traffic") {
+		t.Errorf("Expected the report emailed, got %s", mails[0])
+	}
+
+	after, _ := scheduler.Get(schedule.ID)
+	if after.LastRun == nil || after.LastError != "" || !after.NextRun.After(schedule.NextRun) {
+		t.Errorf("Expected the run recorded and the next one scheduled, got %+v", after)
+	}
+}
+
+func TestScheduler_RunRecordsDeliveryErrors(t *testing.T) {
+	config := testConfig()
+	config.MaxRetries = 1
+	config.Schedules = []models.ReportSchedule{{
+		ID: "weekly", Name: "Weekly", Frequency: models.ReportWeekly, Weekday: "monday",
+		Delivery: models.ReportDelivery{Email: []string{"ops@example.com"}},
+	}}
+	scheduler := NewScheduler(config, storage.NewNavigationTracker())
+
+	sent := 0
+	scheduler.SetMailer(func(to []string, msg []byte) error {
+		sent++
+		return errors.New("connection refused")
+	})
+
+	report, err := scheduler.Run("weekly", time.Now())
+	if err == nil || report == nil || report.Summary.Period != "7d" {
+		t.Fatalf("Expected a weekly report and a delivery error, got %+v (%v)", report, err)
+	}
+	if sent != 2 {
+		t.Errorf("Expected the email to be retried once, got %d attempts", sent)
+	}
+	if schedule, _ := scheduler.Get("weekly"); !strings.Contains(schedule.LastError, "connection refused") {
+		t.Errorf("Expected the delivery error recorded, got %q", schedule.LastError)
+	}
+	if _, err := scheduler.Run("missing", time.Now()); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("Expected ErrScheduleNotFound, got %v", err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	report := &models.Report{
+		Name:      "Daily <traffic>",
+		Frequency: models.ReportDaily,
+		TopURLs:   []*models.VisitorStats{{URL: "https://example.com/a?x=<b>", DistinctVisitors: 2, TotalPageViews: 3}},
+	}
+
+	html, err := Render(report, models.ReportFormatHTML)
+	if err != nil || !strings.Contains(string(html), "Daily &lt;traffic&gt;") || strings.Contains(string(html), "<b>") {
+		t.Errorf("Expected escaped html, got %s (%v)", html, err)
+	}
+	json, err := Render(report, models.ReportFormatJSON)
+	if err != nil || !strings.Contains(string(json), `"top_urls":[{"url":"https://example.com/a?x=\u003cb\u003e"`) {
+		t.Errorf("Expected the json report, got %s (%v)", json, err)
+	}
+	if _, err := Render(report, "pdf"); err == nil {
+		t.Error("Expected an unknown format to fail")
+	}
+}
+
+func TestScheduler_SavesCreatedSchedules(t *testing.T) {
+	config := testConfig()
+	config.Path = filepath.Join(t.TempDir(), "reports.json")
+	config.Schedules = []models.ReportSchedule{{Name: "Configured", Frequency: models.ReportDaily, Delivery: models.ReportDelivery{Webhook: "https://hooks.example.com/c"}}}
+	tracker := storage.NewNavigationTracker()
+	scheduler := NewScheduler(config, tracker)
+
+	created, err := scheduler.Create(models.ReportSchedule{Name: "Created", Frequency: models.ReportWeekly, Weekday: "monday", Delivery: models.ReportDelivery{Webhook: "https://hooks.example.com/r"}})
+	if err != nil {
+		t.Fatalf("Failed to create schedule: %v", err)
+	}
+	deleted, _ := scheduler.Create(models.ReportSchedule{Name: "Deleted", Frequency: models.ReportDaily, Delivery: models.ReportDelivery{Webhook: "https://hooks.example.com/d"}})
+	scheduler.Delete(deleted.ID)
+
+	restarted := NewScheduler(config, tracker)
+	if schedules := restarted.List(); len(schedules) != 2 {
+		t.Fatalf("Expected the configured and created schedules, got %+v", schedules)
+	}
+	if restored, _ := restarted.Get(created.ID); restored.Name != "Created" || !restored.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("Expected the created schedule restored as it was, got %+v", restored)
+	}
+}
@@ -0,0 +1,87 @@
+package reports
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// load adds the schedules saved at the configured path, keeping their IDs
+// unless a configured schedule already uses one; the caller must still be
+// constructing the scheduler. A missing file is not an error.
+func (s *Scheduler) load() error {
+	if s.config.Path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.config.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("load report schedules: %w", err)
+	}
+	var saved []models.ReportSchedule
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("decode report schedules: %w", err)
+	}
+
+	now := time.Now().UTC()
+	for _, schedule := range saved {
+		schedule := schedule
+		if _, exists := s.schedules[schedule.ID]; exists || schedule.ID == "" {
+			schedule.ID = s.newID()
+		}
+		s.add(&schedule, now)
+	}
+	return nil
+}
+
+// save writes the schedules created through the API to the configured path;
+// the caller must hold the mutex. Failures are logged, as the change is
+// already in effect.
+func (s *Scheduler) save() {
+	if s.config.Path == "" {
+		return
+	}
+	if err := s.write(); err != nil {
+		log.Printf("Failed to save report schedules: %v", err)
+	}
+}
+
+func (s *Scheduler) write() error {
+	saved := make([]models.ReportSchedule, 0, len(s.schedules))
+	for id, schedule := range s.schedules {
+		if !s.configured[id] {
+			saved = append(saved, *schedule)
+		}
+	}
+	sort.Slice(saved, func(i, j int) bool { return saved[i].ID < saved[j].ID })
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return fmt.Errorf("encode report schedules: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.config.Path), filepath.Base(s.config.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.config.Path)
+}
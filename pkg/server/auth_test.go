@@ -0,0 +1,219 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/auth"
+	"nav-tracker/pkg/models"
+)
+
+func hs256Token(t *testing.T, secret string, claims map[string]interface{}) string {
+	t.Helper()
+	segment := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("Failed to encode token: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	signed := segment(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + segment(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newJWTServer(t *testing.T, modify func(*models.Configuration)) *Server {
+	config := models.DefaultConfiguration()
+	config.Port = freePort(t)
+	config.Auth.Mode = models.AuthModeJWT
+	config.Auth.JWT.Secret = "secret"
+	if modify != nil {
+		modify(config)
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Invalid config: %v", err)
+	}
+	return NewServerWithConfig(config)
+}
+
+func TestAuth_JWTScopes(t *testing.T) {
+	s := newJWTServer(t, nil)
+	query := hs256Token(t, "secret", map[string]interface{}{"scope": "query"})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{"no token", "GET", "/api/v1/stats?url=/home", "", http.StatusUnauthorized},
+		{"invalid token", "GET", "/api/v1/stats?url=/home", hs256Token(t, "wrong", map[string]interface{}{"scope": "query"}), http.StatusUnauthorized},
+		{"query scope", "GET", "/api/v1/stats?url=/home", query, http.StatusOK},
+		{"legacy path", "GET", "/stats?url=/home", query, http.StatusOK},
+		{"query scope on ingest", "POST", "/api/v1/ingest", query, http.StatusForbidden},
+		{"query scope on admin", "GET", "/api/v1/metrics", query, http.StatusForbidden},
+		{"admin scope", "GET", "/api/v1/metrics", hs256Token(t, "secret", map[string]interface{}{"scope": "admin"}), http.StatusOK},
+		{"stream without token", "GET", "/api/v1/stream", "", http.StatusUnauthorized},
+		{"probe without token", "GET", "/livez", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		w := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.status, w.Code, w.Body.String())
+		}
+		if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") != "Bearer" {
+			t.Errorf("%s: expected a WWW-Authenticate challenge", tt.name)
+		}
+	}
+}
+
+func TestAuth_JWTSelectsTenant(t *testing.T) {
+	s := newJWTServer(t, func(config *models.Configuration) {
+		config.Tenancy.Enabled = true
+		config.Tenancy.APIKeys = map[string]string{"key-a": "acme"}
+	})
+	token := hs256Token(t, "secret", map[string]interface{}{"scope": "query admin", "tenant": "globex"})
+
+	req := httptest.NewRequest("GET", "/api/v1/system-stats", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, req)
+	var stats models.SystemStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil || stats.Tenant != "globex" {
+		t.Errorf("Expected the token's tenant, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/system-stats", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(TenantHeader, "acme")
+	w = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected another tenant's header to be refused, got %d", w.Code)
+	}
+
+	// The tenant API needs the admin scope
+	req = httptest.NewRequest("GET", tenantsPath, nil)
+	req.Header.Set("Authorization", "Bearer "+hs256Token(t, "secret", map[string]interface{}{"scope": "query"}))
+	w = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected the tenant API to require admin, got %d", w.Code)
+	}
+}
+
+func TestAuth_SignedIngest(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Port = freePort(t)
+	config.Auth.Signing.Enabled = true
+	config.Auth.Signing.Secrets = map[string]string{"shop": "secret"}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Invalid config: %v", err)
+	}
+	s := NewServerWithConfig(config)
+
+	body := `{"visitor_id": "visitor1", "url": "https://example.com/"}`
+	now := time.Now()
+	signed := auth.Sign("secret", auth.NewNonce(), now, []byte(body))
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		site      string
+		signature string
+		status    int
+	}{
+		{"signed", "POST", "/api/v1/ingest", "shop", signed, http.StatusCreated},
+		{"replayed", "POST", "/api/v1/ingest", "shop", signed, http.StatusUnauthorized},
+		{"unsigned", "POST", "/api/v1/ingest", "", "", http.StatusUnauthorized},
+		{"wrong secret", "POST", "/api/v1/ingest", "shop", auth.Sign("wrong", auth.NewNonce(), now, []byte(body)), http.StatusUnauthorized},
+		{"expired", "POST", "/api/v1/ingest", "shop", auth.Sign("secret", auth.NewNonce(), now.Add(-time.Hour), []byte(body)), http.StatusUnauthorized},
+		{"signed pixel", "GET", "/api/v1/pixel?visitor_id=visitor1&url=https://example.com/", "shop", auth.Sign("secret", auth.NewNonce(), now, []byte("visitor_id=visitor1&url=https://example.com/")), http.StatusOK},
+		{"unsigned pixel", "GET", "/api/v1/pixel?visitor_id=visitor1&url=https://example.com/", "", "", http.StatusUnauthorized},
+		{"query", "GET", "/api/v1/stats?url=https://example.com/", "", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		var req *http.Request
+		if tt.method == "POST" {
+			req = httptest.NewRequest(tt.method, tt.path, strings.NewReader(body))
+		} else {
+			req = httptest.NewRequest(tt.method, tt.path, nil)
+		}
+		req.Header.Set(auth.SiteHeader, tt.site)
+		req.Header.Set(auth.SignatureHeader, tt.signature)
+		w := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.status, w.Code, w.Body.String())
+		}
+	}
+
+	if rejected := s.metrics.GetMetrics().RejectedEvents[ErrorCodeInvalidSignature]; rejected != 5 {
+		t.Errorf("Expected 5 rejected signatures, got %d", rejected)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/system-stats", nil)
+	w := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, req)
+	var stats models.SystemStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("Failed to decode system stats: %v", err)
+	}
+	if stats.Signing == nil || stats.Signing.Replays != 1 || stats.Signing.TrackedNonces != 2 {
+		t.Errorf("Expected 1 replay and 2 tracked nonces, got %+v", stats.Signing)
+	}
+}
+
+func TestAuth_SignedIngestNoncesSurviveRestart(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Port = freePort(t)
+	config.Auth.Signing.Enabled = true
+	config.Auth.Signing.Secrets = map[string]string{"shop": "secret"}
+	config.Auth.Signing.NoncePath = filepath.Join(t.TempDir(), "nonces.json")
+
+	body := `{"visitor_id": "visitor1", "url": "https://example.com/"}`
+	signature := auth.Sign("secret", auth.NewNonce(), time.Now(), []byte(body))
+	ingest := func(s *Server) int {
+		req := httptest.NewRequest("POST", "/api/v1/ingest", strings.NewReader(body))
+		req.Header.Set(auth.SiteHeader, "shop")
+		req.Header.Set(auth.SignatureHeader, signature)
+		w := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	s := NewServerWithConfig(config)
+	if err := s.lifecycle.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	if status := ingest(s); status != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, status)
+	}
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Failed to stop server: %v", err)
+	}
+
+	config.Port = freePort(t)
+	restarted := NewServerWithConfig(config)
+	if status := ingest(restarted); status != http.StatusUnauthorized {
+		t.Errorf("Expected the replay to be refused after a restart, got %d", status)
+	}
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"nav-tracker/pkg/handlers"
+	"nav-tracker/pkg/models"
+)
+
+// canarySections are the configuration sections a canary may change: those
+// applied to each ingested event on its own, so the stable and canary
+// configurations can run side by side.
+var canarySections = map[string]bool{
+	"validation": true,
+}
+
+// configCanary is the configuration a running canary tries.
+type configCanary struct {
+	config    *models.Configuration
+	percent   int
+	sections  []string
+	startedAt time.Time
+	actor     string
+}
+
+// startCanary validates the events of percent of visitors, on every tenant,
+// against config until the canary is promoted or aborted. config may only
+// change canarySections from the configuration in effect.
+func (s *Server) startCanary(config *models.Configuration, percent int, actor string) (*models.ConfigCanary, error) {
+	s.configMutex.Lock()
+	defer s.configMutex.Unlock()
+
+	if s.canary != nil {
+		return nil, handlers.ErrConfigCanaryRunning
+	}
+	changed := changedSections(s.liveConfig, config)
+	if len(changed) == 0 {
+		return nil, fmt.Errorf("%w: no section differs from the configuration in effect", handlers.ErrInvalidConfigCanary)
+	}
+	for _, section := range changed {
+		if !canarySections[section] {
+			return nil, fmt.Errorf("%w: %s cannot be tried on part of the traffic; only validation can", handlers.ErrInvalidConfigCanary, section)
+		}
+	}
+	rules, err := models.CompileValidation(config.Validation)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", handlers.ErrInvalidConfigCanary, err)
+	}
+
+	for _, tracker := range s.trackers() {
+		tracker.StartCanary(rules, percent)
+	}
+	s.canary = &configCanary{
+		config:    config,
+		percent:   percent,
+		sections:  changed,
+		startedAt: time.Now().UTC(),
+		actor:     actor,
+	}
+	log.Printf("Configuration canary of %s started on %d%% of visitors by %s", strings.Join(changed, ", "), percent, actor)
+	return s.describeCanary()
+}
+
+// currentCanary returns the running canary with the events counted so far.
+func (s *Server) currentCanary() (*models.ConfigCanary, bool) {
+	s.configMutex.Lock()
+	defer s.configMutex.Unlock()
+
+	if s.canary == nil {
+		return nil, false
+	}
+	status, err := s.describeCanary()
+	if err != nil {
+		log.Printf("Failed to describe configuration canary: %v", err)
+		return nil, false
+	}
+	return status, true
+}
+
+// promoteCanary applies the canary configuration to all traffic, recording
+// it as a new version, and ends the canary.
+func (s *Server) promoteCanary(actor string) (*models.ConfigVersion, *models.ConfigCanary, error) {
+	s.configMutex.Lock()
+	defer s.configMutex.Unlock()
+
+	if s.canary == nil {
+		return nil, nil, handlers.ErrNoConfigCanary
+	}
+	status, err := s.describeCanary()
+	if err != nil {
+		return nil, nil, err
+	}
+	version, _, _, err := s.applyConfigLocked(s.canary.config, models.ConfigPromotion, actor, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.endCanary()
+	log.Printf("Configuration canary promoted by %s", actor)
+	return version, status, nil
+}
+
+// abortCanary ends the canary, leaving the configuration in effect as it is.
+func (s *Server) abortCanary(actor string) (*models.ConfigVersion, *models.ConfigCanary, error) {
+	s.configMutex.Lock()
+	defer s.configMutex.Unlock()
+
+	if s.canary == nil {
+		return nil, nil, handlers.ErrNoConfigCanary
+	}
+	status, err := s.describeCanary()
+	if err != nil {
+		return nil, nil, err
+	}
+	s.endCanary()
+	log.Printf("Configuration canary aborted by %s", actor)
+	return nil, status, nil
+}
+
+// endCanary stops the canary on every tenant; the caller must hold
+// configMutex.
+func (s *Server) endCanary() {
+	for _, tracker := range s.trackers() {
+		tracker.StopCanary()
+	}
+	s.canary = nil
+}
+
+// describeCanary returns the running canary with the events of each side
+// summed over the tenants; the caller must hold configMutex.
+func (s *Server) describeCanary() (*models.ConfigCanary, error) {
+	config, err := s.canary.config.Redacted()
+	if err != nil {
+		return nil, err
+	}
+	status := &models.ConfigCanary{
+		Percent:   s.canary.percent,
+		Sections:  s.canary.sections,
+		StartedAt: s.canary.startedAt,
+		Actor:     s.canary.actor,
+		Config:    config,
+	}
+	for _, tracker := range s.trackers() {
+		if stable, canary, ok := tracker.CanaryStats(); ok {
+			status.Stable = status.Stable.Add(stable)
+			status.Canary = status.Canary.Add(canary)
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+
+	"nav-tracker/pkg/handlers"
+	"nav-tracker/pkg/models"
+)
+
+// liveSections are the configuration sections applyConfig updates in place;
+// changes to any other section are stored but only take effect on restart.
+var liveSections = map[string]func(s *Server, config *models.Configuration) error{
+	"port": (*Server).reloadHTTP,
+	"http": (*Server).reloadHTTP,
+	"cors": func(s *Server, config *models.Configuration) error {
+		s.cors.Update(config.CORS)
+		return nil
+	},
+	"validation": func(s *Server, config *models.Configuration) error {
+		rules, err := models.CompileValidation(config.Validation)
+		if err != nil {
+			return err
+		}
+		for _, tracker := range s.trackers() {
+			tracker.SetValidation(rules)
+		}
+		return nil
+	},
+}
+
+// currentConfig returns the configuration most recently accepted through
+// the config API, which starts out as the startup configuration.
+func (s *Server) currentConfig() *models.Configuration {
+	s.configMutex.Lock()
+	defer s.configMutex.Unlock()
+	return s.liveConfig
+}
+
+// updateConfig applies config and records it in the configuration history
+// as changed by actor.
+func (s *Server) updateConfig(config *models.Configuration, actor string) (applied, restartRequired []string, err error) {
+	_, applied, restartRequired, err = s.applyConfig(config, models.ConfigUpdate, actor, 0)
+	return applied, restartRequired, err
+}
+
+// rollbackConfig applies the configuration of an earlier version again,
+// recording it as a new version. Versions are redacted, so the secrets in
+// effect are kept.
+func (s *Server) rollbackConfig(number int, actor string) (*models.ConfigVersion, []string, []string, error) {
+	previous, ok := s.configHistory.get(number)
+	if !ok {
+		return nil, nil, nil, handlers.ErrConfigVersionNotFound
+	}
+	config, err := previous.Config.Clone()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	config.KeepSecrets(s.currentConfig())
+	if err := config.Validate(); err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %v", handlers.ErrInvalidConfigVersion, err)
+	}
+	return s.applyConfig(config, models.ConfigRollback, actor, number)
+}
+
+// applyConfig stores config as the current configuration, applies the
+// sections that can change while running and records it in the history. It
+// returns the JSON names of the changed sections, split by whether they were
+// applied. Sections are applied in field order, so the port and HTTP server,
+// which can fail to bind, come first; if one fails nothing is applied and the
+// configuration is not stored. A history that fails to save is logged, as
+// the configuration is already in effect. While a canary runs the
+// configuration is refused; the canary must be promoted or aborted first.
+func (s *Server) applyConfig(config *models.Configuration, origin, actor string, rollbackOf int) (version *models.ConfigVersion, applied, restartRequired []string, err error) {
+	s.configMutex.Lock()
+	defer s.configMutex.Unlock()
+
+	if s.canary != nil {
+		return nil, nil, nil, handlers.ErrConfigCanaryRunning
+	}
+	return s.applyConfigLocked(config, origin, actor, rollbackOf)
+}
+
+// applyConfigLocked applies config as applyConfig does; the caller must hold
+// configMutex.
+func (s *Server) applyConfigLocked(config *models.Configuration, origin, actor string, rollbackOf int) (version *models.ConfigVersion, applied, restartRequired []string, err error) {
+	applied, restartRequired = []string{}, []string{}
+	changed := changedSections(s.liveConfig, config)
+	for _, section := range changed {
+		if apply, ok := liveSections[section]; ok {
+			if err := apply(s, config); err != nil {
+				return nil, nil, nil, fmt.Errorf("%s: %w", section, err)
+			}
+			applied = append(applied, section)
+		} else {
+			restartRequired = append(restartRequired, section)
+		}
+	}
+	s.liveConfig = config
+
+	version, err = s.configHistory.record(s.baseConfig, config, changed, origin, actor, rollbackOf)
+	if err != nil {
+		log.Printf("Failed to save configuration history: %v", err)
+	}
+	return version, applied, restartRequired, nil
+}
+
+// changedSections returns the JSON names of the top-level fields that differ
+// between old and new.
+func changedSections(old, new *models.Configuration) []string {
+	var changed []string
+	oldValue, newValue := reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem()
+	for i := 0; i < oldValue.NumField(); i++ {
+		if reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			continue
+		}
+		name, _, _ := strings.Cut(oldValue.Type().Field(i).Tag.Get("json"), ",")
+		changed = append(changed, name)
+	}
+	return changed
+}
+
+// copySection sets the top-level field of dst whose JSON name is section to
+// its value in src.
+func copySection(dst, src *models.Configuration, section string) {
+	dstValue, srcValue := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	for i := 0; i < dstValue.NumField(); i++ {
+		if name, _, _ := strings.Cut(dstValue.Type().Field(i).Tag.Get("json"), ","); name == section {
+			dstValue.Field(i).Set(srcValue.Field(i))
+			return
+		}
+	}
+}
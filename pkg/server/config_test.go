@@ -0,0 +1,753 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestServer_ConfigUpdatesCORS(t *testing.T) {
+	s := NewServer("0")
+
+	request := func() string {
+		req := httptest.NewRequest("GET", "/stats?url=https://example.com", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		w := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(w, req)
+		return w.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	if got := request(); got != "" {
+		t.Errorf("Expected no CORS headers by default, got %q", got)
+	}
+
+	body := `{"port": "9999", "cors": {"allowed_origins": ["https://*.example.com"]}}`
+	req := httptest.NewRequest("PUT", "/config", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"applied":["port","cors"]`) || !strings.Contains(w.Body.String(), `"restart_required":[]`) {
+		t.Errorf("Expected port and cors to be applied, got %s", w.Body.String())
+	}
+
+	if got := request(); got != "https://app.example.com" {
+		t.Errorf("Expected updated policy to allow the origin, got %q", got)
+	}
+	if s.currentConfig().Port != "9999" || s.config.Port != "0" || s.httpServer.Addr != ":9999" {
+		t.Error("Expected the new port to be stored and used by the HTTP server without changing the startup configuration")
+	}
+}
+
+func TestCORSConfig_Validate(t *testing.T) {
+	tests := []struct {
+		origins []string
+		valid   bool
+	}{
+		{[]string{"*"}, true},
+		{[]string{"https://app.example.com", "http://localhost:3000"}, true},
+		{[]string{"https://*.example.com"}, true},
+		{[]string{"app.example.com"}, false},
+		{[]string{"https://app.example.com/"}, false},
+		{[]string{"https://app.*.com"}, false},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		config.CORS.AllowedOrigins = tt.origins
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Expected valid=%v for %v, got %v", tt.valid, tt.origins, err)
+		}
+	}
+}
+
+func TestBodyLimitConfig_Validate(t *testing.T) {
+	tests := []struct {
+		config models.BodyLimitConfig
+		valid  bool
+	}{
+		{models.BodyLimitConfig{Default: 1024, Endpoints: map[string]int64{"/ingest": 512, "/api/v1/import": 0}}, true},
+		{models.BodyLimitConfig{Default: 0}, false},
+		{models.BodyLimitConfig{Default: 1024, Endpoints: map[string]int64{"ingest": 512}}, false},
+		{models.BodyLimitConfig{Default: 1024, Endpoints: map[string]int64{"/ingest": -1}}, false},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		config.BodyLimit = tt.config
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Expected valid=%v for %+v, got %v", tt.valid, tt.config, err)
+		}
+	}
+}
+
+func TestDimensionConfig_Validate(t *testing.T) {
+	tests := []struct {
+		dimensions []models.DimensionConfig
+		valid      bool
+	}{
+		{[]models.DimensionConfig{{Name: "account_id", Mode: models.DimensionModeExact}}, true},
+		{[]models.DimensionConfig{{Name: "org", Mode: models.DimensionModeHLL}}, true},
+		{[]models.DimensionConfig{{Name: "org", Mode: models.DimensionModeHLL, Precision: 18}}, false},
+		{[]models.DimensionConfig{{Name: "org", Mode: "sampled"}}, false},
+		{[]models.DimensionConfig{{Name: "", Mode: models.DimensionModeExact}}, false},
+		{[]models.DimensionConfig{{Name: "org", Mode: models.DimensionModeExact}, {Name: "org", Mode: models.DimensionModeHLL}}, false},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		config.Dimensions = tt.dimensions
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Expected valid=%v for %+v, got %v", tt.valid, tt.dimensions, err)
+		}
+	}
+}
+
+func TestPrivacyConfig_Validate(t *testing.T) {
+	tests := []struct {
+		privacy models.PrivacyConfig
+		valid   bool
+	}{
+		{models.PrivacyConfig{}, true},
+		{models.PrivacyConfig{HashVisitorIDs: true, Salt: "pepper"}, true},
+		{models.PrivacyConfig{HashVisitorIDs: true}, false},
+		{models.PrivacyConfig{AnonymizeIP: models.IPAnonymizeTruncate}, true},
+		{models.PrivacyConfig{AnonymizeIP: models.IPAnonymizeHash, Salt: "pepper"}, true},
+		{models.PrivacyConfig{AnonymizeIP: models.IPAnonymizeHash}, false},
+		{models.PrivacyConfig{AnonymizeIP: "drop"}, false},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		config.Privacy = tt.privacy
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Expected valid=%v for %+v, got %v", tt.valid, tt.privacy, err)
+		}
+	}
+}
+
+func TestClientIPConfig_Validate(t *testing.T) {
+	tests := []struct {
+		proxies []string
+		valid   bool
+	}{
+		{nil, true},
+		{[]string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32"}, true},
+		{[]string{"10.0.0.0/33"}, false},
+		{[]string{"proxy.internal"}, false},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		config.ClientIP.TrustedProxies = tt.proxies
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Expected valid=%v for %v, got %v", tt.valid, tt.proxies, err)
+		}
+	}
+}
+
+func TestProbesConfig_Validate(t *testing.T) {
+	tests := []struct {
+		probes models.ProbesConfig
+		valid  bool
+	}{
+		{models.ProbesConfig{CheckTimeout: time.Second}, true},
+		{models.ProbesConfig{CheckTimeout: time.Second, MinMemoryHeadroom: 0.2, MaxKafkaLag: 100}, true},
+		{models.ProbesConfig{}, false},
+		{models.ProbesConfig{CheckTimeout: time.Second, MinMemoryHeadroom: 1}, false},
+		{models.ProbesConfig{CheckTimeout: time.Second, MaxKafkaLag: -1}, false},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		config.Probes = tt.probes
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Expected valid=%v for %+v, got %v", tt.valid, tt.probes, err)
+		}
+	}
+}
+
+func TestAsyncIngestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		async models.AsyncIngestConfig
+		valid bool
+	}{
+		{models.AsyncIngestConfig{}, true},
+		{models.AsyncIngestConfig{Enabled: true, QueueSize: 100, Workers: 2}, true},
+		{models.AsyncIngestConfig{Enabled: true, Workers: 2}, false},
+		{models.AsyncIngestConfig{Enabled: true, QueueSize: 100}, false},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		config.AsyncIngest = tt.async
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Expected valid=%v for %+v, got %v", tt.valid, tt.async, err)
+		}
+	}
+}
+
+func TestDenylistConfig_Validate(t *testing.T) {
+	tests := []struct {
+		denylist models.DenylistConfig
+		valid    bool
+	}{
+		{models.DenylistConfig{}, true},
+		{models.DenylistConfig{URLs: []models.DenyRule{{Match: models.DenyPrefix, Pattern: "https://example.com/wp-"}}}, true},
+		{models.DenylistConfig{Referrers: []models.DenyRule{{Match: models.DenyRegex, Pattern: `spam\.net$`}}}, true},
+		{models.DenylistConfig{URLs: []models.DenyRule{{Match: "glob", Pattern: "*"}}}, false},
+		{models.DenylistConfig{URLs: []models.DenyRule{{Match: models.DenyExact}}}, false},
+		{models.DenylistConfig{Referrers: []models.DenyRule{{Match: models.DenyRegex, Pattern: "("}}}, false},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		config.Denylist = tt.denylist
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Expected valid=%v for %+v, got %v", tt.valid, tt.denylist, err)
+		}
+	}
+}
+
+func TestEvictionConfig_Validate(t *testing.T) {
+	tests := []struct {
+		eviction models.EvictionConfig
+		valid    bool
+	}{
+		{models.EvictionConfig{}, true},
+		{models.EvictionConfig{MaxBytes: 1 << 30, TargetRatio: 0.9}, true},
+		{models.EvictionConfig{MaxBytes: -1}, false},
+		{models.EvictionConfig{MaxBytes: 1 << 30}, false},
+		{models.EvictionConfig{MaxBytes: 1 << 30, TargetRatio: 1}, false},
+		{models.EvictionConfig{MaxBytes: 1 << 30, Mode: "drop"}, false},
+		{models.EvictionConfig{MaxBytes: 1 << 30, Mode: models.EvictionModeBackpressure, HighWatermark: 0.95, LowWatermark: 0.85, RetryAfter: time.Second}, true},
+		{models.EvictionConfig{MaxBytes: 1 << 30, Mode: models.EvictionModeBackpressure, HighWatermark: 0.8, LowWatermark: 0.85, RetryAfter: time.Second}, false},
+		{models.EvictionConfig{MaxBytes: 1 << 30, Mode: models.EvictionModeBackpressure, HighWatermark: 1.1, LowWatermark: 0.85, RetryAfter: time.Second}, false},
+		{models.EvictionConfig{MaxBytes: 1 << 30, Mode: models.EvictionModeBackpressure, HighWatermark: 0.95, LowWatermark: 0.85}, false},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		config.Eviction = tt.eviction
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Expected valid=%v for %+v, got %v", tt.valid, tt.eviction, err)
+		}
+	}
+}
+
+func TestImportConfig_Validate(t *testing.T) {
+	for rate, valid := range map[int]bool{0: true, 500: true, -1: false} {
+		config := models.DefaultConfiguration()
+		config.Import.MaxEventsPerSecond = rate
+		if err := config.Validate(); (err == nil) != valid {
+			t.Errorf("Expected valid=%v for max_events_per_second %d, got %v", valid, rate, err)
+		}
+	}
+}
+
+func TestKafkaConfig_Validate(t *testing.T) {
+	tests := []struct {
+		kafka models.KafkaConfig
+		valid bool
+	}{
+		{models.KafkaConfig{}, true},
+		{models.KafkaConfig{Enabled: true, Brokers: []string{"kafka:9092"}, Topics: []string{"navigation"}, GroupID: "nav-tracker"}, true},
+		{models.KafkaConfig{Enabled: true, Topics: []string{"navigation"}, GroupID: "nav-tracker"}, false},
+		{models.KafkaConfig{Enabled: true, Brokers: []string{"kafka:9092"}, GroupID: "nav-tracker"}, false},
+		{models.KafkaConfig{Enabled: true, Brokers: []string{"kafka:9092"}, Topics: []string{"navigation"}}, false},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		config.Kafka = tt.kafka
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Expected valid=%v for %+v, got %v", tt.valid, tt.kafka, err)
+		}
+	}
+}
+
+func postgresStorage(modify func(*models.StorageConfig)) models.StorageConfig {
+	config := models.DefaultConfiguration().Storage
+	config.Backend = models.StorageBackendPostgres
+	config.DSN = "postgres://localhost/nav_tracker"
+	if modify != nil {
+		modify(&config)
+	}
+	return config
+}
+
+func TestStorageConfig_Validate(t *testing.T) {
+	tests := []struct {
+		storage models.StorageConfig
+		redis   bool
+		valid   bool
+	}{
+		{models.StorageConfig{}, false, true},
+		{models.StorageConfig{Backend: models.StorageBackendMemory}, true, true},
+		{models.StorageConfig{Backend: models.StorageBackendSQLite, DSN: "file:nav.db", Timeout: time.Second}, false, true},
+		{models.StorageConfig{Backend: models.StorageBackendSQLite, Timeout: time.Second}, false, false},
+		{models.StorageConfig{Backend: models.StorageBackendSQLite, DSN: "file:nav.db"}, false, false},
+		{models.StorageConfig{Backend: models.StorageBackendSQLite, DSN: "file:nav.db", Timeout: time.Second}, true, false},
+		{postgresStorage(nil), false, true},
+		{postgresStorage(func(c *models.StorageConfig) { c.DSN = "" }), false, false},
+		{postgresStorage(func(c *models.StorageConfig) { c.MaxOpenConns = 0 }), false, false},
+		{postgresStorage(func(c *models.StorageConfig) { c.MaxIdleConns = 20 }), false, false},
+		{postgresStorage(func(c *models.StorageConfig) { c.BatchSize = 0 }), false, false},
+		{postgresStorage(func(c *models.StorageConfig) { c.FlushInterval = 0 }), false, false},
+		{postgresStorage(nil), true, false},
+		{models.StorageConfig{Backend: "mysql"}, false, false},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		config.Storage = tt.storage
+		config.Redis.Enabled = tt.redis
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Expected valid=%v for %+v (redis=%v), got %v", tt.valid, tt.storage, tt.redis, err)
+		}
+	}
+}
+
+func TestResponseCacheConfig_Validate(t *testing.T) {
+	tests := []struct {
+		cache models.ResponseCacheConfig
+		valid bool
+	}{
+		{models.ResponseCacheConfig{}, true},
+		{models.ResponseCacheConfig{Enabled: true, TTL: time.Second, MaxEntries: 100}, true},
+		{models.ResponseCacheConfig{Enabled: true, MaxEntries: 100}, false},
+		{models.ResponseCacheConfig{Enabled: true, TTL: time.Second}, false},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		config.ResponseCache = tt.cache
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Expected valid=%v for %+v, got %v", tt.valid, tt.cache, err)
+		}
+	}
+}
+
+func TestReportsConfig_Validate(t *testing.T) {
+	webhook := models.ReportSchedule{ID: "daily", Name: "Daily", Frequency: models.ReportDaily, Delivery: models.ReportDelivery{Webhook: "https://hooks.example.com/r"}}
+	email := models.ReportSchedule{ID: "weekly", Name: "Weekly", Frequency: models.ReportWeekly, Weekday: "monday", Delivery: models.ReportDelivery{Email: []string{"ops@example.com"}}}
+
+	tests := []struct {
+		modify func(*models.ReportsConfig)
+		valid  bool
+	}{
+		{func(c *models.ReportsConfig) {}, true},
+		{func(c *models.ReportsConfig) { c.Schedules = []models.ReportSchedule{webhook} }, true},
+		{func(c *models.ReportsConfig) { c.Schedules = []models.ReportSchedule{email} }, false},
+		{func(c *models.ReportsConfig) {
+			c.SMTP.Host, c.SMTP.From = "mail.example.com", "reports@example.com"
+			c.Schedules = []models.ReportSchedule{email}
+		}, true},
+		{func(c *models.ReportsConfig) { c.Schedules = []models.ReportSchedule{webhook, webhook} }, false},
+		{func(c *models.ReportsConfig) {
+			bad := webhook
+			bad.Hour = 24
+			c.Schedules = []models.ReportSchedule{bad}
+		}, false},
+		{func(c *models.ReportsConfig) { c.CheckInterval = 0 }, false},
+	}
+
+	for i, tt := range tests {
+		config := models.DefaultConfiguration()
+		config.Reports.Enabled = true
+		tt.modify(&config.Reports)
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Case %d: expected valid=%v, got %v", i, tt.valid, err)
+		}
+	}
+}
+
+func TestValidationConfig_Validate(t *testing.T) {
+	tests := []struct {
+		modify func(*models.ValidationConfig)
+		valid  bool
+	}{
+		{func(c *models.ValidationConfig) {}, true},
+		{func(c *models.ValidationConfig) { c.AllowedURLSchemes = []string{"https", "http"} }, true},
+		{func(c *models.ValidationConfig) { c.AllowedURLSchemes = []string{"https://"} }, false},
+		{func(c *models.ValidationConfig) { c.VisitorIDPattern = "^[a-z" }, false},
+		{func(c *models.ValidationConfig) { c.MaxVisitorIDLength = 0 }, false},
+		{func(c *models.ValidationConfig) { c.MaxURLLength = -1 }, false},
+	}
+
+	for i, tt := range tests {
+		config := models.DefaultConfiguration()
+		tt.modify(&config.Validation)
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Case %d: expected valid=%v, got %v", i, tt.valid, err)
+		}
+	}
+}
+
+func TestServer_ConfigUpdatesValidation(t *testing.T) {
+	s := NewServer("0")
+
+	accepted := func(visitorID, url string) bool {
+		body := `{"visitor_id": "` + visitorID + `", "url": "` + url + `"}`
+		w := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/ingest", strings.NewReader(body)))
+		return w.Code == http.StatusCreated
+	}
+
+	uuid := "3f1c.9a2e.44b0"
+	if accepted(uuid, "https://example.com") {
+		t.Error("Expected a dotted ID to be rejected by default")
+	}
+	if !accepted("visitor1", "/pricing") {
+		t.Error("Expected a relative URL to be accepted by default")
+	}
+
+	body := `{"validation": {"visitor_id_pattern": "^[a-z0-9.]+$", "max_visitor_id_length": 64, "max_url_length": 100, "allowed_url_schemes": ["https"], "allow_relative_urls": false}}`
+	w := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("PUT", "/api/v1/config", strings.NewReader(body)))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"applied":["validation"]`) {
+		t.Fatalf("Expected validation to be applied, got %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		visitorID string
+		url       string
+		accepted  bool
+	}{
+		{uuid, "https://example.com", true},
+		{"Visitor1", "https://example.com", false},
+		{uuid, "/pricing", false},
+		{uuid, "http://example.com", false},
+		{uuid, "https://example.com/" + strings.Repeat("a", 100), false},
+		{strings.Repeat("a", 65), "https://example.com", false},
+	}
+	for _, tt := range tests {
+		if accepted(tt.visitorID, tt.url) != tt.accepted {
+			t.Errorf("Expected accepted=%v for %s on %s", tt.accepted, tt.visitorID, tt.url)
+		}
+	}
+
+	// The rules are the server's tracker's, not the process's
+	if err := NewServer("0").tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "/pricing"}); err != nil {
+		t.Errorf("Expected another server to keep the default rules, got %v", err)
+	}
+}
+
+func TestVisitorCapConfig_Validate(t *testing.T) {
+	tests := []struct {
+		visitorCap models.VisitorCapConfig
+		valid      bool
+	}{
+		{models.VisitorCapConfig{Overflow: models.VisitorCapApproximate}, true},
+		{models.VisitorCapConfig{MaxVisitorsPerURL: 1000, Overflow: models.VisitorCapReject}, true},
+		{models.VisitorCapConfig{MaxVisitorsPerURL: 1000, Overflow: models.VisitorCapEvictOldest}, true},
+		{models.VisitorCapConfig{MaxVisitorsPerURL: 1000, Overflow: "drop"}, false},
+		{models.VisitorCapConfig{MaxVisitorsPerURL: -1, Overflow: models.VisitorCapReject}, false},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		config.VisitorCap = tt.visitorCap
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Expected valid=%v for %+v, got %v", tt.valid, tt.visitorCap, err)
+		}
+	}
+}
+
+func TestTimestampConfig_Validate(t *testing.T) {
+	tests := []struct {
+		timestamps models.TimestampConfig
+		valid      bool
+	}{
+		{models.TimestampConfig{Policy: models.TimestampClamp}, true},
+		{models.TimestampConfig{MaxPast: 24 * time.Hour, MaxFuture: 5 * time.Minute, Policy: models.TimestampReject}, true},
+		{models.TimestampConfig{ServerTime: true, Policy: models.TimestampServer}, true},
+		{models.TimestampConfig{MaxFuture: time.Minute, Policy: "drop"}, false},
+		{models.TimestampConfig{MaxPast: -time.Hour, Policy: models.TimestampClamp}, false},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		config.Timestamps = tt.timestamps
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Expected valid=%v for %+v, got %v", tt.valid, tt.timestamps, err)
+		}
+	}
+}
+
+func TestConfigHistoryConfig_Validate(t *testing.T) {
+	for maxVersions, valid := range map[int]bool{1: true, 100: true, 0: false, -1: false} {
+		config := models.DefaultConfiguration()
+		config.ConfigHistory.MaxVersions = maxVersions
+		if err := config.Validate(); (err == nil) != valid {
+			t.Errorf("Expected valid=%v for max_versions %d, got %v", valid, maxVersions, err)
+		}
+	}
+}
+
+func TestClusterConfig_Validate(t *testing.T) {
+	cluster := func(modify func(c *models.ClusterConfig)) func(*models.Configuration) {
+		return func(config *models.Configuration) {
+			config.Cluster.Enabled = true
+			config.Cluster.Secret = "secret"
+			config.Cluster.Peers = []string{"http://10.0.0.2:8080"}
+			modify(&config.Cluster)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*models.Configuration)
+		valid  bool
+	}{
+		{"disabled", func(config *models.Configuration) {}, true},
+		{"enabled", cluster(func(c *models.ClusterConfig) {}), true},
+		{"no secret", cluster(func(c *models.ClusterConfig) { c.Secret = "" }), false},
+		{"peer without scheme", cluster(func(c *models.ClusterConfig) { c.Peers = []string{"10.0.0.2:8080"} }), false},
+		{"zero interval", cluster(func(c *models.ClusterConfig) { c.Interval = 0 }), false},
+		{"precision too high", cluster(func(c *models.ClusterConfig) { c.Precision = 17 }), false},
+		{"with redis", func(config *models.Configuration) {
+			cluster(func(c *models.ClusterConfig) {})(config)
+			config.Redis.Enabled = true
+		}, false},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		tt.modify(config)
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid=%v, got %v", tt.name, tt.valid, err)
+		}
+	}
+}
+
+func TestHandlerTimeoutAndCircuitBreakerConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*models.Configuration)
+		valid  bool
+	}{
+		{"defaults", func(config *models.Configuration) {}, true},
+		{"no default timeout", func(config *models.Configuration) { config.HandlerTimeout.Default = 0 }, true},
+		{"negative timeout", func(config *models.Configuration) { config.HandlerTimeout.Default = -time.Second }, false},
+		{"relative endpoint", func(config *models.Configuration) {
+			config.HandlerTimeout.Endpoints = map[string]time.Duration{"stats": time.Second}
+		}, false},
+		{"zero threshold", func(config *models.Configuration) { config.CircuitBreaker.FailureThreshold = 0 }, false},
+		{"disabled breaker", func(config *models.Configuration) {
+			config.CircuitBreaker = models.CircuitBreakerConfig{}
+		}, true},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		tt.modify(config)
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid=%v, got %v", tt.name, tt.valid, err)
+		}
+	}
+}
+
+func TestAuthConfig_Validate(t *testing.T) {
+	jwt := func(modify func(c *models.JWTConfig)) func(*models.Configuration) {
+		return func(config *models.Configuration) {
+			config.Auth.Mode = models.AuthModeJWT
+			config.Auth.JWT.Secret = "secret"
+			modify(&config.Auth.JWT)
+		}
+	}
+	signing := func(secrets map[string]string) func(*models.Configuration) {
+		return func(config *models.Configuration) {
+			config.Auth.Signing.Enabled = true
+			config.Auth.Signing.Secrets = secrets
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*models.Configuration)
+		valid  bool
+	}{
+		{"api keys", func(config *models.Configuration) {}, true},
+		{"unknown mode", func(config *models.Configuration) { config.Auth.Mode = "basic" }, false},
+		{"hs256", jwt(func(c *models.JWTConfig) {}), true},
+		{"hs256 without secret", jwt(func(c *models.JWTConfig) { c.Secret = "" }), false},
+		{"unknown algorithm", jwt(func(c *models.JWTConfig) { c.Algorithm = "none" }), false},
+		{"rs256 with jwks", jwt(func(c *models.JWTConfig) {
+			c.Algorithm = models.JWTAlgorithmRS256
+			c.JWKSURL = "https://auth.example.com/jwks.json"
+		}), true},
+		{"rs256 without key", jwt(func(c *models.JWTConfig) { c.Algorithm = models.JWTAlgorithmRS256 }), false},
+		{"rs256 with invalid key", jwt(func(c *models.JWTConfig) {
+			c.Algorithm = models.JWTAlgorithmRS256
+			c.PublicKey = "not a key"
+		}), false},
+		{"unknown scope", jwt(func(c *models.JWTConfig) {
+			c.Scopes = map[string][]string{"analytics:read": {"read"}}
+		}), false},
+		{"no tenant claim", jwt(func(c *models.JWTConfig) { c.TenantClaim = "" }), false},
+		{"signing", signing(map[string]string{"shop": "secret"}), true},
+		{"signing without secrets", signing(nil), false},
+		{"signing with empty secret", signing(map[string]string{"shop": ""}), false},
+		{"signing without tolerance", func(config *models.Configuration) {
+			signing(map[string]string{"shop": "secret"})(config)
+			config.Auth.Signing.Tolerance = 0
+		}, false},
+		{"signing without nonce prune interval", func(config *models.Configuration) {
+			signing(map[string]string{"shop": "secret"})(config)
+			config.Auth.Signing.NoncePruneInterval = 0
+		}, false},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		tt.modify(config)
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid=%v, got %v", tt.name, tt.valid, err)
+		}
+	}
+}
+
+func TestSamplingConfig_Validate(t *testing.T) {
+	for rate, valid := range map[int]bool{0: true, 1: true, 100: true, -1: false} {
+		config := models.DefaultConfiguration()
+		config.Sampling.Rate = rate
+		if err := config.Validate(); (err == nil) != valid {
+			t.Errorf("Expected valid=%v for sampling rate %d, got %v", valid, rate, err)
+		}
+	}
+}
+
+func TestRealtimeConfig_Validate(t *testing.T) {
+	for window, valid := range map[time.Duration]bool{
+		time.Minute:      true,
+		15 * time.Minute: true,
+		time.Hour:        true,
+		0:                false,
+		90 * time.Second: false,
+		2 * time.Hour:    false,
+	} {
+		config := models.DefaultConfiguration()
+		config.Realtime.Window = window
+		if err := config.Validate(); (err == nil) != valid {
+			t.Errorf("Expected valid=%v for realtime window %v, got %v", valid, window, err)
+		}
+	}
+}
+
+func TestArchiveConfig_Validate(t *testing.T) {
+	archive := func(modify func(c *models.ArchiveConfig)) models.ArchiveConfig {
+		c := models.DefaultConfiguration().Archive
+		c.Enabled = true
+		c.Bucket = "nav-events"
+		c.AccessKeyID = "key"
+		c.SecretAccessKey = "secret"
+		if modify != nil {
+			modify(&c)
+		}
+		return c
+	}
+	tests := []struct {
+		name    string
+		archive models.ArchiveConfig
+		valid   bool
+	}{
+		{"valid", archive(nil), true},
+		{"disabled", models.ArchiveConfig{}, true},
+		{"invalid endpoint", archive(func(c *models.ArchiveConfig) { c.Endpoint = "s3.amazonaws.com" }), false},
+		{"no bucket", archive(func(c *models.ArchiveConfig) { c.Bucket = "" }), false},
+		{"no credentials", archive(func(c *models.ArchiveConfig) { c.SecretAccessKey = "" }), false},
+		{"no interval", archive(func(c *models.ArchiveConfig) { c.Interval = 0 }), false},
+		{"pending below batch", archive(func(c *models.ArchiveConfig) { c.MaxPendingEvents = c.BatchSize - 1 }), false},
+	}
+
+	for _, tt := range tests {
+		config := models.DefaultConfiguration()
+		config.Archive = tt.archive
+		if err := config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid=%v, got %v", tt.name, tt.valid, err)
+		}
+	}
+}
+
+func TestServer_ConfigCanary(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Port = "0"
+	config.RateLimit.Enabled = false
+	s := NewServerWithConfig(config)
+
+	request := func(method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return w
+	}
+
+	if w := request("POST", "/api/v1/config/canary?percent=50", `{"session_idle_timeout": 60000000000}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a canary of another section to be refused, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := request("POST", "/api/v1/config/canary?percent=0", `{"validation": {"allow_relative_urls": false}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected percent 0 to be refused, got %d", w.Code)
+	}
+
+	body := `{"validation": {"visitor_id_pattern": "^[a-zA-Z0-9_-]+$", "max_visitor_id_length": 100, "max_url_length": 2048, "allow_relative_urls": false}}`
+	if w := request("POST", "/api/v1/config/canary?percent=50", body); w.Code != http.StatusCreated {
+		t.Fatalf("Expected the canary to start, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := request("POST", "/api/v1/config/canary?percent=10", body); w.Code != http.StatusConflict {
+		t.Errorf("Expected a second canary to be refused, got %d", w.Code)
+	}
+	if w := request("PUT", "/api/v1/config", `{"session_idle_timeout": 60000000000}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected updates to be refused while the canary runs, got %d", w.Code)
+	}
+
+	// Relative URLs are only refused on the canary side
+	for i := 0; i < 100; i++ {
+		request("POST", "/ingest", fmt.Sprintf(`{"visitor_id": "visitor%d", "url": "/page%d"}`, i, i))
+	}
+	w := request("GET", "/api/v1/config/canary", "")
+	var canary models.ConfigCanary
+	if err := json.Unmarshal(w.Body.Bytes(), &canary); err != nil {
+		t.Fatalf("Failed to unmarshal canary: %v: %s", err, w.Body.String())
+	}
+	if canary.Percent != 50 || len(canary.Sections) != 1 || canary.Sections[0] != "validation" {
+		t.Errorf("Expected a 50%% validation canary, got %+v", canary)
+	}
+	if canary.Stable.Events+canary.Canary.Events != 100 || canary.Stable.Events == 0 || canary.Canary.Events == 0 {
+		t.Fatalf("Expected 100 events split between both sides, got %+v and %+v", canary.Stable, canary.Canary)
+	}
+	if canary.Stable.Rejected != 0 || canary.Stable.NewURLs != canary.Stable.Events || canary.Stable.URLsPerThousand != 1000 {
+		t.Errorf("Expected every stable event accepted with a new URL, got %+v", canary.Stable)
+	}
+	if canary.Canary.RejectionRate != 1 || canary.Canary.NewURLs != 0 {
+		t.Errorf("Expected every canary event refused, got %+v", canary.Canary)
+	}
+
+	w = request("POST", "/api/v1/config/canary/promote", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"origin":"canary_promotion"`) {
+		t.Fatalf("Expected the canary to be promoted, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := request("POST", "/ingest", `{"visitor_id": "visitor0", "url": "/pricing"}`); w.Code == http.StatusCreated {
+		t.Error("Expected relative URLs to be refused for every visitor once promoted")
+	}
+	if w := request("GET", "/api/v1/config/canary", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected no canary after promotion, got %d", w.Code)
+	}
+
+	request("POST", "/api/v1/config/canary?percent=100", `{"validation": {"allow_relative_urls": true}}`)
+	if w := request("POST", "/api/v1/config/canary/abort", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected the canary to be aborted, got %d: %s", w.Code, w.Body.String())
+	}
+	if s.currentConfig().Validation.AllowRelativeURLs {
+		t.Error("Expected the aborted canary not to change the configuration")
+	}
+	if w := request("POST", "/api/v1/config/canary/abort", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected no canary to abort, got %d", w.Code)
+	}
+}
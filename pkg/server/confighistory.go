@@ -0,0 +1,199 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// configHistory keeps the configurations accepted through the config API,
+// oldest first, and persists them with the sections that differ from the
+// file and environment configuration, which are laid over it on the next
+// startup. Versions are kept redacted, so secrets are neither served nor
+// written out; the secrets in effect are kept on restore and rollback.
+//
+// The history is a file rather than a storage.CounterBackend: backends hold
+// counters only, and the backend to connect to is itself configuration, so
+// the history has to be read before there is one.
+type configHistory struct {
+	path        string
+	maxVersions int
+
+	mutex     sync.Mutex
+	overrides []string
+	versions  []*models.ConfigVersion
+}
+
+// configHistoryFile is the persisted form of a configHistory.
+type configHistoryFile struct {
+	Overrides []string                `json:"overrides"`
+	Versions  []*models.ConfigVersion `json:"versions"`
+}
+
+// newConfigHistory loads the history at the configured path, if there is one.
+func newConfigHistory(config *models.Configuration) (*configHistory, error) {
+	h := &configHistory{path: config.ConfigHistory.Path, maxVersions: config.ConfigHistory.MaxVersions}
+	if h.path == "" && config.Snapshot.Enabled {
+		h.path = config.Snapshot.Path + ".config"
+	}
+	if h.path == "" {
+		return h, nil
+	}
+
+	data, err := os.ReadFile(h.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return h, fmt.Errorf("load configuration history: %w", err)
+	}
+	var file configHistoryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return h, fmt.Errorf("decode configuration history: %w", err)
+	}
+	// Histories saved before versions were redacted are redacted here and
+	// rewritten by the startup record
+	for _, version := range file.Versions {
+		if version.Config, err = version.Config.Redacted(); err != nil {
+			return h, fmt.Errorf("redact configuration history: %w", err)
+		}
+	}
+	h.overrides, h.versions = file.Overrides, file.Versions
+	return h, nil
+}
+
+// restore returns base with the sections last changed through the config API
+// laid over it, or base itself when there are none. The history's own
+// settings and every secret always come from base.
+func (h *configHistory) restore(base *models.Configuration) (*models.Configuration, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if len(h.overrides) == 0 || len(h.versions) == 0 {
+		return base, nil
+	}
+	config, err := base.Clone()
+	if err != nil {
+		return nil, err
+	}
+	latest := h.versions[len(h.versions)-1].Config
+	for _, section := range h.overrides {
+		if section != "config_history" {
+			copySection(config, latest, section)
+		}
+	}
+	config.KeepSecrets(base)
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// record appends config, redacted, as a new version and persists the
+// history. changed lists the sections that differ from the configuration it
+// replaces; at startup it is worked out against the latest version instead,
+// and nothing is appended when none differ. base is the file and environment
+// configuration the overrides are kept against.
+func (h *configHistory) record(base, config *models.Configuration, changed []string, origin, actor string, rollbackOf int) (*models.ConfigVersion, error) {
+	redacted, err := config.Redacted()
+	if err != nil {
+		return nil, err
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	number := 1
+	if len(h.versions) > 0 {
+		latest := h.versions[len(h.versions)-1]
+		if origin == models.ConfigStartup {
+			changed = changedSections(latest.Config, redacted)
+			if len(changed) == 0 {
+				h.overrides = changedSections(base, config)
+				return latest, h.save()
+			}
+		}
+		number = latest.Version + 1
+	}
+	if changed == nil {
+		changed = []string{}
+	}
+
+	version := &models.ConfigVersion{
+		Version:    number,
+		Time:       time.Now().UTC(),
+		Origin:     origin,
+		Actor:      actor,
+		Changed:    changed,
+		RollbackOf: rollbackOf,
+		Config:     redacted,
+	}
+	h.versions = append(h.versions, version)
+	if len(h.versions) > h.maxVersions {
+		h.versions = h.versions[len(h.versions)-h.maxVersions:]
+	}
+	h.overrides = changedSections(base, config)
+	return version, h.save()
+}
+
+// list returns every version, oldest first, without its configuration.
+func (h *configHistory) list() []*models.ConfigVersion {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	versions := make([]*models.ConfigVersion, len(h.versions))
+	for i, version := range h.versions {
+		summary := *version
+		summary.Config = nil
+		versions[i] = &summary
+	}
+	return versions
+}
+
+// get returns the version numbered number, if it is still kept.
+func (h *configHistory) get(number int) (*models.ConfigVersion, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for _, version := range h.versions {
+		if version.Version == number {
+			return version, true
+		}
+	}
+	return nil, false
+}
+
+// save writes the history to its path; the caller must hold h.mutex. It is
+// a no-op without a path.
+func (h *configHistory) save() error {
+	if h.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(configHistoryFile{Overrides: h.overrides, Versions: h.versions})
+	if err != nil {
+		return fmt.Errorf("encode configuration history: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("save configuration history: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("save configuration history: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("save configuration history: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), h.path)
+}
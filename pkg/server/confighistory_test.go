@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestServer_ConfigHistory(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.ConfigHistory.Path = filepath.Join(t.TempDir(), "config-history.json")
+	s := NewServerWithConfig(config)
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "admin-key")
+		w := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve("PUT", "/api/v1/config", `{"slow_query_log_size": 7}`); w.Code != http.StatusOK {
+		t.Fatalf("Failed to update configuration: %d %s", w.Code, w.Body.String())
+	}
+
+	w := serve("GET", "/api/v1/config/history", "")
+	var history struct {
+		Versions []models.ConfigVersion `json:"versions"`
+		Count    int                    `json:"count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &history); err != nil || history.Count != 2 {
+		t.Fatalf("Expected the startup and updated versions, got %d: %s", w.Code, w.Body.String())
+	}
+	update := history.Versions[1]
+	if history.Versions[0].Origin != models.ConfigStartup || update.Origin != models.ConfigUpdate || update.Config != nil {
+		t.Errorf("Expected a startup version then an update listed without its configuration, got %+v", history.Versions)
+	}
+	if len(update.Changed) != 1 || update.Changed[0] != "slow_query_log_size" || !strings.HasPrefix(update.Actor, "api_key:") || strings.Contains(update.Actor, "admin-key") {
+		t.Errorf("Expected the change attributed to a fingerprint of the API key, got %+v", update)
+	}
+
+	// The change survives a restart with the same startup configuration
+	restarted := NewServerWithConfig(config)
+	if size := restarted.currentConfig().SlowQueryLogSize; size != 7 {
+		t.Errorf("Expected the changed section restored, got %d", size)
+	}
+	if versions := restarted.configHistory.list(); len(versions) != 2 {
+		t.Errorf("Expected no new version for an unchanged startup, got %+v", versions)
+	}
+
+	w = serve("POST", "/api/v1/config/rollback?version=1", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"rollback_of":1`) {
+		t.Fatalf("Expected a rollback to version 1, got %d: %s", w.Code, w.Body.String())
+	}
+	if size := s.currentConfig().SlowQueryLogSize; size != config.SlowQueryLogSize {
+		t.Errorf("Expected the original value back, got %d", size)
+	}
+	if restarted := NewServerWithConfig(config); restarted.currentConfig().SlowQueryLogSize != config.SlowQueryLogSize {
+		t.Error("Expected no change restored after rolling back to the startup configuration")
+	}
+
+	w = serve("GET", "/api/v1/config/history?version=2", "")
+	var version models.ConfigVersion
+	if err := json.Unmarshal(w.Body.Bytes(), &version); err != nil || version.Config == nil || version.Config.SlowQueryLogSize != 7 {
+		t.Errorf("Expected version 2 with its configuration, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for target, expected := range map[string]int{
+		"/api/v1/config/rollback?version=99": http.StatusNotFound,
+		"/api/v1/config/rollback":            http.StatusBadRequest,
+	} {
+		if w := serve("POST", target, ""); w.Code != expected {
+			t.Errorf("Expected status %d for %s, got %d", expected, target, w.Code)
+		}
+	}
+}
+
+func TestServer_ConfigHistoryRedactsSecrets(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.ConfigHistory.Path = filepath.Join(t.TempDir(), "config-history.json")
+	config.Privacy.Salt = "salt-value"
+	config.Reports.SMTP.Password = "smtp-password"
+	config.Tenancy.APIKeys = map[string]string{"tenant-key": "acme"}
+	s := NewServerWithConfig(config)
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve("PUT", "/api/v1/config", `{"slow_query_log_size": 7}`); w.Code != http.StatusOK {
+		t.Fatalf("Failed to update configuration: %d %s", w.Code, w.Body.String())
+	}
+
+	secrets := []string{"salt-value", "smtp-password", "tenant-key"}
+	data, err := os.ReadFile(config.ConfigHistory.Path)
+	if err != nil {
+		t.Fatalf("Failed to read the history: %v", err)
+	}
+	w := serve("GET", "/api/v1/config/history?version=2", "")
+	for _, secret := range secrets {
+		if strings.Contains(string(data), secret) || strings.Contains(w.Body.String(), secret) {
+			t.Errorf("Expected %q redacted from the history", secret)
+		}
+	}
+	var version models.ConfigVersion
+	if err := json.Unmarshal(w.Body.Bytes(), &version); err != nil || version.Config.Reports.SMTP.Password != models.RedactedSecret {
+		t.Errorf("Expected the password shown as redacted, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Rollbacks and restarts keep the secrets in effect
+	if w := serve("POST", "/api/v1/config/rollback?version=1", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected a rollback to version 1, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, current := range []*models.Configuration{s.currentConfig(), NewServerWithConfig(config).currentConfig()} {
+		if current.Privacy.Salt != "salt-value" || current.Reports.SMTP.Password != "smtp-password" || current.Tenancy.APIKeys["tenant-key"] != "acme" {
+			t.Errorf("Expected the secrets kept, got %+v %+v %+v", current.Privacy, current.Reports.SMTP, current.Tenancy)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+const defaultStopTimeout = 10 * time.Second
+
+// Component is a subsystem with a managed start and stop. Start and Stop may
+// be nil. Components start after everything they depend on and stop before it.
+type Component struct {
+	Name        string
+	DependsOn   []string
+	Start       func() error
+	Stop        func(ctx context.Context) error
+	StopTimeout time.Duration
+}
+
+type managedComponent struct {
+	Component
+	state     string
+	err       error
+	startedAt time.Time
+	duration  time.Duration
+}
+
+// Lifecycle starts registered components in dependency order and stops the
+// started ones in reverse order, each within its stop timeout.
+type Lifecycle struct {
+	components []*managedComponent
+	started    []*managedComponent
+	mutex      sync.RWMutex
+}
+
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// Register adds a component. Components must be registered before Start.
+func (l *Lifecycle) Register(component Component) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.components = append(l.components, &managedComponent{Component: component, state: models.ComponentPending})
+}
+
+// Start brings components up in dependency order. If one fails, the ones
+// already started are stopped and the error is returned.
+func (l *Lifecycle) Start() error {
+	order, err := l.order()
+	if err != nil {
+		return err
+	}
+
+	for _, c := range order {
+		l.setState(c, models.ComponentStarting, nil)
+
+		start := time.Now()
+		if c.Start != nil {
+			err = c.Start()
+		}
+
+		l.mutex.Lock()
+		c.startedAt = start
+		c.duration = time.Since(start)
+		l.mutex.Unlock()
+
+		if err != nil {
+			l.setState(c, models.ComponentFailed, err)
+			stopErr := l.Stop()
+			return errors.Join(fmt.Errorf("start %s: %w", c.Name, err), stopErr)
+		}
+
+		l.mutex.Lock()
+		l.started = append(l.started, c)
+		l.mutex.Unlock()
+		l.setState(c, models.ComponentReady, nil)
+		log.Printf("Component %s ready in %v", c.Name, c.duration)
+	}
+
+	return nil
+}
+
+// Stop tears down started components in reverse start order. A component
+// that exceeds its stop timeout is reported and the next one is stopped.
+func (l *Lifecycle) Stop() error {
+	l.mutex.Lock()
+	started := l.started
+	l.started = nil
+	l.mutex.Unlock()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		c := started[i]
+		if c.Stop == nil {
+			l.setState(c, models.ComponentStopped, nil)
+			continue
+		}
+
+		timeout := c.StopTimeout
+		if timeout <= 0 {
+			timeout = defaultStopTimeout
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := stopWithContext(ctx, c.Stop)
+		cancel()
+
+		if err != nil {
+			log.Printf("Component %s stop error: %v", c.Name, err)
+			errs = append(errs, fmt.Errorf("stop %s: %w", c.Name, err))
+		}
+		l.setState(c, models.ComponentStopped, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// stopWithContext runs stop and gives up once ctx is done, even if stop does
+// not honour the context itself.
+func stopWithContext(ctx context.Context, stop func(ctx context.Context) error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Ready reports whether every component has started.
+func (l *Lifecycle) Ready() bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	for _, c := range l.components {
+		if c.state != models.ComponentReady {
+			return false
+		}
+	}
+	return true
+}
+
+// Status returns each component's state in registration order.
+func (l *Lifecycle) Status() []models.ComponentStatus {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	statuses := make([]models.ComponentStatus, 0, len(l.components))
+	for _, c := range l.components {
+		status := models.ComponentStatus{
+			Name:      c.Name,
+			State:     c.state,
+			DependsOn: c.DependsOn,
+			StartedAt: c.startedAt,
+			Duration:  c.duration,
+		}
+		if c.err != nil {
+			status.Error = c.err.Error()
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+func (l *Lifecycle) setState(c *managedComponent, state string, err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	c.state = state
+	c.err = err
+}
+
+// order sorts components so that dependencies come first, keeping
+// registration order among independent components.
+func (l *Lifecycle) order() ([]*managedComponent, error) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	byName := make(map[string]*managedComponent, len(l.components))
+	for _, c := range l.components {
+		if _, exists := byName[c.Name]; exists {
+			return nil, fmt.Errorf("duplicate component %q", c.Name)
+		}
+		byName[c.Name] = c
+	}
+
+	for _, c := range l.components {
+		for _, dep := range c.DependsOn {
+			if _, exists := byName[dep]; !exists {
+				return nil, fmt.Errorf("component %q depends on unknown component %q", c.Name, dep)
+			}
+		}
+	}
+
+	order := make([]*managedComponent, 0, len(l.components))
+	placed := make(map[string]bool, len(l.components))
+	for len(order) < len(l.components) {
+		progressed := false
+		for _, c := range l.components {
+			if placed[c.Name] {
+				continue
+			}
+
+			ready := true
+			for _, dep := range c.DependsOn {
+				if !placed[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				order = append(order, c)
+				placed[c.Name] = true
+				progressed = true
+			}
+		}
+
+		if !progressed {
+			return nil, fmt.Errorf("component dependency cycle detected")
+		}
+	}
+
+	return order, nil
+}
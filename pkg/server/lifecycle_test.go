@@ -0,0 +1,199 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestLifecycle_StartsInDependencyOrder(t *testing.T) {
+	lifecycle := NewLifecycle()
+	var events []string
+
+	register := func(name string, deps ...string) {
+		lifecycle.Register(Component{
+			Name:      name,
+			DependsOn: deps,
+			Start: func() error {
+				events = append(events, "start "+name)
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				events = append(events, "stop "+name)
+				return nil
+			},
+		})
+	}
+
+	register("http", "storage", "cache")
+	register("storage")
+	register("cache", "storage")
+
+	if err := lifecycle.Start(); err != nil {
+		t.Fatalf("Failed to start: %v", err)
+	}
+	if !lifecycle.Ready() {
+		t.Error("Expected lifecycle to be ready")
+	}
+
+	if err := lifecycle.Stop(); err != nil {
+		t.Fatalf("Failed to stop: %v", err)
+	}
+
+	expected := []string{"start storage", "start cache", "start http", "stop http", "stop cache", "stop storage"}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Errorf("Step %d: expected %q, got %q", i, expected[i], events[i])
+		}
+	}
+}
+
+func TestLifecycle_StartFailureStopsStartedComponents(t *testing.T) {
+	lifecycle := NewLifecycle()
+	stopped := false
+
+	lifecycle.Register(Component{
+		Name: "storage",
+		Stop: func(ctx context.Context) error {
+			stopped = true
+			return nil
+		},
+	})
+	lifecycle.Register(Component{
+		Name:      "http",
+		DependsOn: []string{"storage"},
+		Start:     func() error { return errors.New("address in use") },
+	})
+
+	if err := lifecycle.Start(); err == nil {
+		t.Fatal("Expected start error")
+	}
+	if !stopped {
+		t.Error("Expected started component to be stopped after failure")
+	}
+	if lifecycle.Ready() {
+		t.Error("Expected lifecycle not to be ready")
+	}
+
+	statuses := lifecycle.Status()
+	if statuses[1].State != models.ComponentFailed || statuses[1].Error == "" {
+		t.Errorf("Expected http to be failed with an error, got %+v", statuses[1])
+	}
+}
+
+func TestLifecycle_StopTimeout(t *testing.T) {
+	lifecycle := NewLifecycle()
+	lifecycle.Register(Component{
+		Name:        "stuck",
+		StopTimeout: 10 * time.Millisecond,
+		Stop: func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+	})
+
+	if err := lifecycle.Start(); err != nil {
+		t.Fatalf("Failed to start: %v", err)
+	}
+
+	start := time.Now()
+	err := lifecycle.Stop()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("Expected stop to give up after the timeout")
+	}
+}
+
+func TestLifecycle_InvalidDependencies(t *testing.T) {
+	tests := map[string][]Component{
+		"unknown": {{Name: "http", DependsOn: []string{"missing"}}},
+		"cycle":   {{Name: "a", DependsOn: []string{"b"}}, {Name: "b", DependsOn: []string{"a"}}},
+	}
+
+	for name, components := range tests {
+		lifecycle := NewLifecycle()
+		for _, c := range components {
+			lifecycle.Register(c)
+		}
+		if err := lifecycle.Start(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		for _, status := range lifecycle.Status() {
+			if status.State != models.ComponentPending {
+				t.Errorf("%s: expected %s to stay pending, got %s", name, status.Name, status.State)
+			}
+		}
+	}
+}
+
+func TestServer_AsyncIngestDrainsOnStop(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Port = freePort(t)
+	config.AsyncIngest = models.AsyncIngestConfig{Enabled: true, QueueSize: 100, Workers: 2}
+	s := NewServerWithConfig(config)
+	if err := s.lifecycle.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		body := fmt.Sprintf(`{"visitor_id":"visitor%d","url":"https://example.com/a"}`, i)
+		w := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/ingest", strings.NewReader(body)))
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status %d, got %d", http.StatusAccepted, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/system-stats", nil))
+	if !strings.Contains(w.Body.String(), `"ingest_queue"`) {
+		t.Errorf("Expected queue stats in system stats, got %s", w.Body.String())
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Failed to stop server: %v", err)
+	}
+	if visitors := s.tracker.GetDistinctVisitors("https://example.com/a"); visitors != 10 {
+		t.Errorf("Expected every accepted event to be recorded by shutdown, got %d visitors", visitors)
+	}
+}
+
+func TestServer_StopEndsStreams(t *testing.T) {
+	s := startTestServer(t)
+
+	resp, err := http.Get("http://127.0.0.1:" + s.port + "/api/v1/events/tail")
+	if err != nil {
+		t.Fatalf("Failed to open event stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	start := time.Now()
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Failed to stop server: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= s.config.HTTP.DrainTimeout {
+		t.Errorf("Expected open streams not to hold up the drain, took %v", elapsed)
+	}
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Errorf("Expected the stream to end cleanly, got %v", err)
+	}
+	if err := s.Stop(); err != nil {
+		t.Errorf("Expected a second stop to be a no-op, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+
+	"nav-tracker/pkg/middleware"
+	"nav-tracker/pkg/models"
+)
+
+// chain returns a function wrapping handlers in the named middleware,
+// outermost first. Request metrics are labelled by mux's route patterns.
+func (s *Server) chain(names []string, mux *http.ServeMux) func(http.Handler) http.Handler {
+	registry := map[string]func(http.Handler) http.Handler{
+		models.MiddlewareCORS:           middleware.CORS(s.cors),
+		models.MiddlewareRateLimit:      s.rateLimit,
+		models.MiddlewareMetrics:        middleware.RequestMetrics(s.metrics, mux),
+		models.MiddlewareResponseFormat: middleware.ResponseFormat(s.config.ResponseFormat),
+		models.MiddlewareSlowLog:        middleware.SlowQueryLog(s.slowLog),
+		models.MiddlewareIngestActivity: middleware.IngestActivity(s.ingestActivity, s.ipResolver),
+		models.MiddlewareBodyLimit:      middleware.BodyLimit(s.config.BodyLimit),
+		models.MiddlewareTimeout:        middleware.Timeout(s.config.HandlerTimeout),
+	}
+
+	return func(handler http.Handler) http.Handler {
+		for i := len(names) - 1; i >= 0; i-- {
+			handler = registry[names[i]](handler)
+		}
+		return handler
+	}
+}
+
+// group returns the middleware of a route group: the chain named by names,
+// behind a check that the request's token grants scope in jwt auth mode.
+func (s *Server) group(scope string, names []string, mux *http.ServeMux) func(http.Handler) http.Handler {
+	chain := s.chain(names, mux)
+	scoped := s.requireScope(scope)
+	return func(handler http.Handler) http.Handler {
+		return scoped(chain(handler))
+	}
+}
+
+// passthrough is the rate_limit middleware when rate limiting is disabled.
+func passthrough(next http.Handler) http.Handler {
+	return next
+}
+
+// byMethod routes requests whose method has an entry in methods to it and all
+// others to fallback, so one path can be served by handlers in different
+// route groups.
+func byMethod(fallback http.Handler, methods map[string]http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handler, ok := methods[r.Method]; ok {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		fallback.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/clientip"
+	"nav-tracker/pkg/middleware"
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/monitoring"
+)
+
+func newTestChainServer(config *models.Configuration) *Server {
+	s := &Server{
+		config:         config,
+		slowLog:        monitoring.NewSlowLog(time.Hour, 10),
+		metrics:        monitoring.NewMetricsCollector(),
+		ingestActivity: monitoring.NewIngestActivity(time.Hour),
+		rateLimit:      passthrough,
+		cors:           middleware.NewCORSPolicy(config.CORS),
+		ipResolver:     clientip.NewResolver(config.ClientIP.TrustedProxies),
+	}
+	if config.RateLimit.Enabled {
+		s.rateLimit = middleware.RateLimit(config.RateLimit, config.Tenancy.APIKeys, s.ipResolver)
+	}
+	return s
+}
+
+func TestServer_ChainOrder(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.RateLimit.Enabled = true
+	config.RateLimit.IPBurst = 1
+	config.RateLimit.IPRequestsPerSecond = 0.001
+	s := newTestChainServer(config)
+
+	mux := http.NewServeMux()
+	called := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called++
+		w.WriteHeader(http.StatusNoContent)
+	})
+	// Metrics outside the rate limiter see rejected requests too
+	mux.Handle("/limited", s.chain([]string{models.MiddlewareMetrics, models.MiddlewareRateLimit}, mux)(handler))
+	mux.Handle("/open", s.chain(nil, mux)(handler))
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/limited", nil))
+		if i == 1 && w.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected second request to be rate limited, got %d", w.Code)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/open", nil))
+		if w.Code != http.StatusNoContent {
+			t.Errorf("Expected route without rate limiting to succeed, got %d", w.Code)
+		}
+	}
+
+	if called != 3 {
+		t.Errorf("Expected handler to be called 3 times, got %d", called)
+	}
+	if metrics := s.metrics.GetEndpointMetrics("/limited"); metrics == nil || metrics.RequestCount != 2 {
+		t.Errorf("Expected 2 requests recorded for /limited, got %+v", metrics)
+	}
+	if metrics := s.metrics.GetEndpointMetrics("/open"); metrics != nil {
+		t.Errorf("Expected no metrics for /open, got %+v", metrics)
+	}
+}
+
+func TestServer_ChainRateLimitDisabled(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.RateLimit.Enabled = false
+	config.RateLimit.IPBurst = 1
+	s := newTestChainServer(config)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", s.chain([]string{models.MiddlewareRateLimit}, mux)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected rate_limit to be a no-op when disabled, got %d", w.Code)
+		}
+	}
+}
+
+func TestMiddlewareConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config models.MiddlewareConfig
+		valid  bool
+	}{
+		{"default", models.DefaultConfiguration().Middleware, true},
+		{"per group", models.MiddlewareConfig{Ingest: []string{"rate_limit"}, Admin: []string{"metrics"}}, true},
+		{"unknown", models.MiddlewareConfig{Query: []string{"gzip"}}, false},
+		{"duplicate", models.MiddlewareConfig{Query: []string{"slow_log", "slow_log"}}, false},
+		{"global and group", models.MiddlewareConfig{Global: []string{"metrics"}, Admin: []string{"metrics"}}, false},
+		{"timeout on ingest", models.MiddlewareConfig{Ingest: []string{"timeout"}}, false},
+		{"global timeout", models.MiddlewareConfig{Global: []string{"timeout"}}, false},
+	}
+
+	for _, tt := range tests {
+		if err := tt.config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid=%v, got %v", tt.name, tt.valid, err)
+		}
+	}
+}
+
+func TestByMethod(t *testing.T) {
+	respond := func(code int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(code) })
+	}
+	handler := byMethod(respond(http.StatusOK), map[string]http.Handler{http.MethodDelete: respond(http.StatusAccepted)})
+
+	for method, expected := range map[string]int{"GET": http.StatusOK, "DELETE": http.StatusAccepted} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, "/api/v1/visitors/visitor1", nil))
+		if w.Code != expected {
+			t.Errorf("Expected status %d for %s, got %d", expected, method, w.Code)
+		}
+	}
+}
+
+func TestServer_ResponseCacheInvalidatedByEvents(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Port = "0"
+	config.ResponseCache.Enabled = true
+	s := NewServerWithConfig(config)
+	handler := s.httpServer.Handler
+
+	stats := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/stats?url=https://example.com", nil))
+		return w
+	}
+	ingest := func(visitorID string) {
+		body := `{"visitor_id": "` + visitorID + `", "url": "https://example.com"}`
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/ingest", strings.NewReader(body)))
+		if w.Code != http.StatusOK && w.Code != http.StatusCreated && w.Code != http.StatusAccepted {
+			t.Fatalf("Failed to ingest: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	ingest("visitor1")
+	first := stats()
+	if cached := stats(); cached.Header().Get("X-Cache") != "HIT" || cached.Header().Get("ETag") != first.Header().Get("ETag") {
+		t.Errorf("Expected a cache hit with the same ETag, got %q", cached.Header().Get("X-Cache"))
+	}
+
+	ingest("visitor2")
+	fresh := stats()
+	if fresh.Header().Get("X-Cache") != "MISS" || !strings.Contains(fresh.Body.String(), `"distinct_visitors":2`) {
+		t.Errorf("Expected the event to invalidate the cached stats, got %s", fresh.Body.String())
+	}
+}
+
+func TestServer_DenylistRejectionsInMetrics(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Port = "0"
+	config.Denylist.URLs = []models.DenyRule{{Match: models.DenyPrefix, Pattern: "https://example.com/wp-"}}
+	s := NewServerWithConfig(config)
+
+	w := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/ingest", strings.NewReader(`{"visitor_id": "visitor1", "url": "https://example.com/wp-login.php"}`)))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected a denylisted event to be rejected with 403, got %d", w.Code)
+	}
+	if rejected := s.metrics.GetMetrics().RejectedEvents["denylist_urls"]; rejected != 1 {
+		t.Errorf("Expected 1 rejection in metrics, got %d", rejected)
+	}
+}
+
+func TestServer_BodyLimit(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Port = "0"
+	config.BodyLimit = models.BodyLimitConfig{Default: 256, Endpoints: map[string]int64{"/ingest": 128}}
+	s := NewServerWithConfig(config)
+
+	event := `{"visitor_id": "visitor1", "url": "https://example.com/` + strings.Repeat("a", 128) + `"}`
+	update := `{"port": "` + strings.Repeat(" ", 256) + `"}`
+	for _, req := range []*http.Request{
+		httptest.NewRequest("POST", "/ingest", strings.NewReader(event)),
+		httptest.NewRequest("PUT", "/api/v1/config", strings.NewReader(update)),
+	} {
+		// Without a Content-Length, as in a chunked request
+		req.ContentLength = -1
+		w := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusRequestEntityTooLarge || !strings.Contains(w.Body.String(), middleware.ErrorCodeRequestTooLarge) {
+			t.Errorf("Expected %s %s to be rejected as too large, got %d %s", req.Method, req.URL.Path, w.Code, w.Body.String())
+		}
+	}
+}
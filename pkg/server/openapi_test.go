@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nav-tracker/pkg/openapi"
+)
+
+func TestServer_OpenAPIDocumentsRegisteredRoutes(t *testing.T) {
+	s := NewServer(freePort(t))
+
+	for _, route := range openapi.Routes {
+		if route.Condition != "" {
+			continue
+		}
+		path := strings.NewReplacer("{visitor_id}", "visitor1", "{id}", "missing").Replace(route.Path)
+
+		req := httptest.NewRequest(route.Method, path, nil)
+		if route.ResponseType == "text/event-stream" {
+			// Event streams run until the client goes away
+			ctx, cancel := context.WithCancel(req.Context())
+			cancel()
+			req = req.WithContext(ctx)
+		}
+
+		w := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(w, req)
+
+		if w.Code == http.StatusNotFound && strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+			t.Errorf("%s %s is documented but not registered", route.Method, route.Path)
+		}
+		if w.Code == http.StatusMethodNotAllowed {
+			t.Errorf("%s %s is documented but the method is not allowed", route.Method, route.Path)
+		}
+	}
+}
+
+func TestServer_ServesOpenAPIDocument(t *testing.T) {
+	s := NewServer(freePort(t))
+
+	w := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"openapi":"`+openapi.Version+`"`) {
+		t.Errorf("Expected an OpenAPI %s document, got %s", openapi.Version, w.Body.String()[:100])
+	}
+
+	w = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/docs", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "/openapi.json") {
+		t.Errorf("Expected the docs page to load /openapi.json, got %d", w.Code)
+	}
+}
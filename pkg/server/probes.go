@@ -0,0 +1,78 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/monitoring"
+)
+
+// readinessChecks lists the dependency checks behind /readyz: every
+// component started, the configured storage backend reachable, the snapshot
+// and tiering directories writable, memory headroom, room in the async
+// ingestion queue and Kafka consumer lag.
+func (s *Server) readinessChecks() []monitoring.ReadinessCheck {
+	checks := []monitoring.ReadinessCheck{
+		{Name: "components", Check: s.componentsReady},
+		{Name: "memory", Check: monitoring.MemoryHeadroomCheck(s.config.Probes.MinMemoryHeadroom)},
+	}
+
+	if s.redisBackend != nil {
+		checks = append(checks, monitoring.ReadinessCheck{Name: "redis", Check: s.redisBackend.Ping})
+	}
+	if s.sqliteBackend != nil {
+		checks = append(checks, monitoring.ReadinessCheck{Name: "sqlite", Check: s.sqliteBackend.Ping})
+	}
+	if s.postgresBackend != nil {
+		checks = append(checks, monitoring.ReadinessCheck{Name: "postgres", Check: s.postgresBackend.Ping})
+	}
+	if s.config.Snapshot.Enabled {
+		checks = append(checks, monitoring.ReadinessCheck{
+			Name:  "snapshot_dir",
+			Check: monitoring.WritableDirCheck(filepath.Dir(s.config.Snapshot.Path)),
+		})
+	}
+	if s.config.Tiering.Enabled {
+		checks = append(checks, monitoring.ReadinessCheck{
+			Name:  "tiering_dir",
+			Check: monitoring.WritableDirCheck(s.config.Tiering.Dir),
+		})
+	}
+	if s.ingestQueue != nil {
+		// Not ready once full, as further events are being refused
+		depth := func() int64 { return int64(s.ingestQueue.Stats().Depth) }
+		checks = append(checks, monitoring.ReadinessCheck{
+			Name:  "ingest_queue",
+			Check: monitoring.LagCheck(depth, int64(s.config.AsyncIngest.QueueSize)-1),
+		})
+	}
+	if s.kafkaConsumer != nil && s.config.Probes.MaxKafkaLag > 0 {
+		lag := func() int64 { return s.kafkaConsumer.Stats().Lag }
+		checks = append(checks, monitoring.ReadinessCheck{
+			Name:  "kafka_lag",
+			Check: monitoring.LagCheck(lag, s.config.Probes.MaxKafkaLag),
+		})
+	}
+
+	return checks
+}
+
+// componentsReady fails while any lifecycle component has not started.
+func (s *Server) componentsReady() error {
+	var pending []string
+	for _, component := range s.lifecycle.Status() {
+		if component.State != models.ComponentReady {
+			pending = append(pending, component.Name+" "+component.State)
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("not ready: %s", strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+func (s *Server) runReadinessChecks() []models.CheckResult {
+	return monitoring.RunChecks(s.readinessChecks(), s.config.Probes.CheckTimeout)
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func getProbe(t *testing.T, s *Server, path string) (int, map[string]json.RawMessage) {
+	w := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to unmarshal %s response: %v", path, err)
+	}
+	return w.Code, body
+}
+
+func checkResults(t *testing.T, body map[string]json.RawMessage) map[string]models.CheckResult {
+	var results []models.CheckResult
+	if err := json.Unmarshal(body["checks"], &results); err != nil {
+		t.Fatalf("Failed to unmarshal checks: %v", err)
+	}
+	byName := make(map[string]models.CheckResult, len(results))
+	for _, result := range results {
+		byName[result.Name] = result
+	}
+	return byName
+}
+
+func TestServer_Probes(t *testing.T) {
+	s := NewServer(freePort(t))
+
+	if code, _ := getProbe(t, s, "/livez"); code != http.StatusOK {
+		t.Errorf("Expected liveness to pass before startup, got %d", code)
+	}
+
+	code, body := getProbe(t, s, "/readyz")
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected readiness to fail before startup, got %d", code)
+	}
+	if results := checkResults(t, body); results["components"].Status != models.CheckFail || results["memory"].Status != models.CheckPass {
+		t.Errorf("Expected only the components check to fail, got %+v", results)
+	}
+
+	if err := s.lifecycle.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Stop() })
+
+	if code, body := getProbe(t, s, "/readyz"); code != http.StatusOK {
+		t.Errorf("Expected readiness to pass after startup, got %d: %s", code, body["checks"])
+	}
+}
+
+func TestServer_ReadinessChecksDirectories(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Port = freePort(t)
+	config.Snapshot.Enabled = true
+	config.Snapshot.Path = filepath.Join(t.TempDir(), "missing", "snapshot.json")
+	s := NewServerWithConfig(config)
+
+	results := make(map[string]models.CheckResult)
+	for _, result := range s.runReadinessChecks() {
+		results[result.Name] = result
+	}
+	if result, ok := results["snapshot_dir"]; !ok || result.Status != models.CheckFail || result.Error == "" {
+		t.Errorf("Expected the snapshot directory check to fail, got %+v", result)
+	}
+}
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+const drainPollInterval = 10 * time.Millisecond
+
+// newHTTPServer returns a server with config's timeouts and the count of its
+// open connections.
+func newHTTPServer(port string, config models.HTTPConfig, handler http.Handler) (*http.Server, *atomic.Int64) {
+	conns := &atomic.Int64{}
+	return &http.Server{
+		Addr:              ":" + port,
+		Handler:           handler,
+		ReadTimeout:       config.ReadTimeout,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+		ConnState: func(conn net.Conn, state http.ConnState) {
+			switch state {
+			case http.StateNew:
+				conns.Add(1)
+			case http.StateClosed, http.StateHijacked:
+				conns.Add(-1)
+			}
+		},
+	}, conns
+}
+
+// sharedListener accepts connections on one socket and hands each to
+// whichever server takes it first, so that a reload can start a new
+// http.Server on the port before the old one stops accepting.
+type sharedListener struct {
+	net.Listener
+	conns chan net.Conn
+	done  chan struct{}
+	err   error
+}
+
+func newSharedListener(listener net.Listener) *sharedListener {
+	sl := &sharedListener{
+		Listener: listener,
+		conns:    make(chan net.Conn),
+		done:     make(chan struct{}),
+	}
+	go sl.acceptLoop()
+	return sl
+}
+
+func (sl *sharedListener) acceptLoop() {
+	defer close(sl.done)
+	for {
+		conn, err := sl.Listener.Accept()
+		if err != nil {
+			sl.err = err
+			return
+		}
+		sl.conns <- conn
+	}
+}
+
+// view returns a listener for one server; closing it stops that server
+// accepting without closing the socket.
+func (sl *sharedListener) view() net.Listener {
+	return &listenerView{shared: sl, closed: make(chan struct{})}
+}
+
+type listenerView struct {
+	shared    *sharedListener
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (v *listenerView) Accept() (net.Conn, error) {
+	select {
+	case <-v.closed:
+		return nil, net.ErrClosed
+	default:
+	}
+
+	select {
+	case conn := <-v.shared.conns:
+		return conn, nil
+	case <-v.closed:
+		return nil, net.ErrClosed
+	case <-v.shared.done:
+		return nil, v.shared.err
+	}
+}
+
+func (v *listenerView) Close() error {
+	v.closeOnce.Do(func() { close(v.closed) })
+	return nil
+}
+
+func (v *listenerView) Addr() net.Addr {
+	return v.shared.Addr()
+}
+
+// startHTTP binds the listener synchronously so that address errors fail
+// startup, then serves in the background.
+func (s *Server) startHTTP() error {
+	s.httpMutex.Lock()
+	defer s.httpMutex.Unlock()
+
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+	s.listener = newSharedListener(listener)
+	s.httpView = s.serve(s.httpServer, s.listener)
+	return nil
+}
+
+// serve runs server on a new view of listener and returns the view.
+func (s *Server) serve(server *http.Server, listener *sharedListener) net.Listener {
+	view := listener.view()
+	go func() {
+		err := server.Serve(view)
+		if err != nil && err != http.ErrServerClosed && !errors.Is(err, net.ErrClosed) {
+			log.Printf("Server failed: %v", err)
+			_ = s.Stop()
+		}
+	}()
+	return view
+}
+
+// drain stops server accepting on view and lets the connections it has
+// already accepted finish their current request, closing any still open
+// after timeout. Unlike http.Server.Shutdown, it does not drop requests on
+// connections accepted just before it was called.
+func drain(server *http.Server, view net.Listener, conns *atomic.Int64, timeout time.Duration) {
+	_ = view.Close()
+	server.SetKeepAlivesEnabled(false)
+
+	deadline := time.Now().Add(timeout)
+	for conns.Load() > 0 {
+		if time.Now().After(deadline) {
+			log.Printf("HTTP server on %s did not drain within %s", server.Addr, timeout)
+			_ = server.Close()
+			return
+		}
+		time.Sleep(drainPollInterval)
+	}
+}
+
+// stopHTTP drains the current server, waits for servers replaced by reloads
+// to finish draining, and closes the socket.
+func (s *Server) stopHTTP(ctx context.Context) error {
+	s.httpMutex.Lock()
+	server, listener := s.httpServer, s.listener
+	s.httpMutex.Unlock()
+
+	err := server.Shutdown(ctx)
+	s.draining.Wait()
+	if listener != nil {
+		_ = listener.Close()
+	}
+	return err
+}
+
+// reloadHTTP replaces the HTTP server if config's port or timeouts differ
+// from the running server's. The new server starts before the old one stops
+// accepting: on the same socket, or on a new one bound first for a new port,
+// so a port that cannot be bound leaves the old server running. The old
+// server then drains in the background for up to DrainTimeout.
+func (s *Server) reloadHTTP(config *models.Configuration) error {
+	s.httpMutex.Lock()
+	defer s.httpMutex.Unlock()
+
+	old := s.httpServer
+	if old.Addr == ":"+config.Port && s.httpConfig == config.HTTP {
+		return nil
+	}
+	next, nextConns := newHTTPServer(config.Port, config.HTTP, old.Handler)
+
+	if s.listener == nil {
+		// Not serving yet: startHTTP will use the new settings
+		s.httpServer, s.httpConns, s.port, s.httpConfig = next, nextConns, config.Port, config.HTTP
+		return nil
+	}
+
+	oldListener, listener := s.listener, s.listener
+	if next.Addr != old.Addr {
+		bound, err := net.Listen("tcp", next.Addr)
+		if err != nil {
+			return fmt.Errorf("listen on port %s: %w", config.Port, err)
+		}
+		listener = newSharedListener(bound)
+	}
+
+	oldView, oldConns := s.httpView, s.httpConns
+	s.httpView = s.serve(next, listener)
+	s.httpServer, s.httpConns, s.listener, s.port, s.httpConfig = next, nextConns, listener, config.Port, config.HTTP
+
+	drainTimeout := config.HTTP.DrainTimeout
+	s.draining.Add(1)
+	go func() {
+		defer s.draining.Done()
+
+		if oldListener != listener {
+			_ = oldListener.Close()
+		}
+		drain(old, oldView, oldConns, drainTimeout)
+	}()
+
+	log.Printf("HTTP server reloaded on port %s", config.Port)
+	return nil
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// freePort returns a port that was free a moment ago.
+func freePort(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	defer listener.Close()
+	return fmt.Sprint(listener.Addr().(*net.TCPAddr).Port)
+}
+
+func startTestServer(t *testing.T) *Server {
+	s := NewServer(freePort(t))
+	if err := s.lifecycle.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Stop() })
+	return s
+}
+
+func putConfig(t *testing.T, s *Server, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("PUT", "/api/v1/config", strings.NewReader(body)))
+	return w
+}
+
+func healthy(port string) bool {
+	resp, err := http.Get("http://127.0.0.1:" + port + "/api/v1/version")
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+func TestServer_ReloadPort(t *testing.T) {
+	s := startTestServer(t)
+	oldPort, newPort := s.port, freePort(t)
+
+	w := putConfig(t, s, `{"port": "`+newPort+`"}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"applied":["port"]`) {
+		t.Fatalf("Expected port to be applied, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if !healthy(newPort) {
+		t.Error("Expected the server to answer on the new port")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for healthy(oldPort) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if healthy(oldPort) {
+		t.Error("Expected the old port to be released after draining")
+	}
+}
+
+func TestServer_ReloadTimeouts(t *testing.T) {
+	s := startTestServer(t)
+
+	w := putConfig(t, s, `{"http": {"read_timeout": 5000000000, "write_timeout": 60000000000, "drain_timeout": 10000000000}}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"applied":["http"]`) {
+		t.Fatalf("Expected http to be applied, got %d: %s", w.Code, w.Body.String())
+	}
+
+	s.httpMutex.Lock()
+	server := s.httpServer
+	s.httpMutex.Unlock()
+	if server.ReadTimeout != 5*time.Second || server.WriteTimeout != time.Minute {
+		t.Errorf("Expected new timeouts, got read %s and write %s", server.ReadTimeout, server.WriteTimeout)
+	}
+	if !healthy(s.port) {
+		t.Error("Expected the server to keep answering on the same port")
+	}
+}
+
+func TestServer_ReloadPortInUse(t *testing.T) {
+	s := startTestServer(t)
+
+	taken, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer taken.Close()
+	port := fmt.Sprint(taken.Addr().(*net.TCPAddr).Port)
+
+	w := putConfig(t, s, `{"port": "`+port+`", "cors": {"allowed_origins": ["*"]}}`)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusInternalServerError, w.Code, w.Body.String())
+	}
+	if s.currentConfig().Port == port || len(s.cors.Config().AllowedOrigins) != 0 {
+		t.Error("Expected a failed reload to leave the configuration unchanged")
+	}
+	if !healthy(s.port) {
+		t.Error("Expected the server to keep answering on its port")
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// legacyPaths maps each endpoint that is still served at its original
+// unversioned path to that path.
+var legacyPaths = map[string]string{
+	"/api/v1/ingest": "/ingest",
+	"/api/v1/stats":  "/stats",
+	"/api/v1/config": "/config",
+}
+
+// legacyDeprecatedAt is when the unversioned paths were deprecated in favour
+// of /api/v1, as sent in the Deprecation header.
+var legacyDeprecatedAt = time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC)
+
+// routes registers each endpoint once. Endpoints with a legacy path are also
+// mounted there, with the same handler and middleware, so the two can't
+// drift apart.
+type routes struct {
+	mux    *http.ServeMux
+	legacy models.LegacyRoutesConfig
+	onUse  func(path string)
+}
+
+func (s *Server) newRoutes(mux *http.ServeMux) *routes {
+	return &routes{mux: mux, legacy: s.config.LegacyRoutes, onUse: s.metrics.RecordDeprecatedRequest}
+}
+
+func (rt *routes) Handle(path string, handler http.Handler) {
+	rt.mux.Handle(path, handler)
+	if legacy, ok := legacyPaths[path]; ok && rt.legacy.Enabled {
+		rt.mux.Handle(legacy, rt.deprecated(legacy, path, handler))
+	}
+}
+
+func (rt *routes) HandleFunc(path string, handler http.HandlerFunc) {
+	rt.Handle(path, handler)
+}
+
+// deprecated serves legacy with handler, announcing successor as its
+// replacement and counting the request.
+func (rt *routes) deprecated(legacy, successor string, handler http.Handler) http.Handler {
+	deprecation := fmt.Sprintf("@%d", legacyDeprecatedAt.Unix())
+	link := fmt.Sprintf("<%s>; rel=\"successor-version\"", successor)
+	sunset := ""
+	if !rt.legacy.Sunset.IsZero() {
+		sunset = rt.legacy.Sunset.UTC().Format(http.TimeFormat)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", deprecation)
+		w.Header().Add("Link", link)
+		if sunset != "" {
+			w.Header().Set("Sunset", sunset)
+		}
+		rt.onUse(legacy)
+		handler.ServeHTTP(w, r)
+	})
+}
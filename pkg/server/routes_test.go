@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestRoutes_LegacyPathsAreDeprecated(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Port = freePort(t)
+	config.LegacyRoutes.Sunset = time.Date(2027, time.June, 1, 0, 0, 0, 0, time.UTC)
+	s := NewServerWithConfig(config)
+
+	w := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/stats?url=/home", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if deprecation := w.Header().Get("Deprecation"); deprecation != "" {
+		t.Errorf("Expected no Deprecation header on /api/v1/stats, got %q", deprecation)
+	}
+
+	w = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/stats?url=/home", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d from the legacy path, got %d", http.StatusOK, w.Code)
+	}
+	if deprecation := w.Header().Get("Deprecation"); deprecation == "" || deprecation[0] != '@' {
+		t.Errorf("Expected a Deprecation date, got %q", deprecation)
+	}
+	if link := w.Header().Get("Link"); link != `</api/v1/stats>; rel="successor-version"` {
+		t.Errorf("Expected a successor Link, got %q", link)
+	}
+	if sunset := w.Header().Get("Sunset"); sunset != "Tue, 01 Jun 2027 00:00:00 GMT" {
+		t.Errorf("Expected a Sunset header, got %q", sunset)
+	}
+
+	if count := s.metrics.GetMetrics().DeprecatedRequests["/stats"]; count != 1 {
+		t.Errorf("Expected 1 deprecated request to /stats, got %d", count)
+	}
+}
+
+func TestRoutes_LegacyPathsCanBeDisabled(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Port = freePort(t)
+	config.LegacyRoutes.Enabled = false
+	s := NewServerWithConfig(config)
+
+	for _, path := range []string{"/ingest", "/stats", "/config"} {
+		w := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected %s to be gone, got %d", path, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/config", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected /api/v1/config to be served, got %d", w.Code)
+	}
+}
@@ -2,56 +2,449 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"nav-tracker/pkg/archive"
+	"nav-tracker/pkg/auth"
+	"nav-tracker/pkg/clientip"
+	"nav-tracker/pkg/dashboard"
+	"nav-tracker/pkg/fixtures"
+	"nav-tracker/pkg/geo"
 	"nav-tracker/pkg/handlers"
+	"nav-tracker/pkg/ingest/kafka"
+	"nav-tracker/pkg/ingest/queue"
+	"nav-tracker/pkg/ingest/syslog"
+	"nav-tracker/pkg/middleware"
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/monitoring"
+	"nav-tracker/pkg/openapi"
+	"nav-tracker/pkg/reports"
 	"nav-tracker/pkg/storage"
+	"nav-tracker/pkg/storage/gossip"
+	"nav-tracker/pkg/storage/postgres"
+	"nav-tracker/pkg/storage/redis"
+	"nav-tracker/pkg/storage/sqlite"
+	"nav-tracker/pkg/stream"
+	"nav-tracker/pkg/version"
 )
 
 type Server struct {
+	config     *models.Configuration
 	tracker    *storage.NavigationTracker
 	httpServer *http.Server
+	httpConfig models.HTTPConfig
+	httpView   net.Listener
+	httpConns  *atomic.Int64
+	listener   *sharedListener
+	httpMutex  sync.Mutex
+	draining   sync.WaitGroup
 	port       string
 	shutdownCh chan struct{}
 	stopOnce   sync.Once
+	started    time.Time
+
+	slowLog         *monitoring.SlowLog
+	metrics         *monitoring.MetricsCollector
+	ingestActivity  *monitoring.IngestActivity
+	rateLimit       func(http.Handler) http.Handler
+	cors            *middleware.CORSPolicy
+	ipResolver      *clientip.Resolver
+	syslogListener  *syslog.Listener
+	kafkaConsumer   *kafka.Consumer
+	ingestQueue     *queue.Queue
+	geoResolver     *geo.MaxMindResolver
+	redisBackend    *redis.Backend
+	sqliteBackend   *sqlite.Backend
+	postgresBackend *postgres.Backend
+	clusterBackend  *gossip.Backend
+	breaker         *storage.CircuitBreaker
+	enrichers       []handlers.EventEnricher
+	apiDoc          *openapi.Document
+	tenants         *tenantRouter
+	jwt             *auth.JWTVerifier
+	signatures      *auth.SignatureVerifier
+	nonces          *auth.NonceStore
+	heartbeat       *monitoring.Heartbeat
+	alerter         *monitoring.Alerter
+	reportScheduler *reports.Scheduler
+	flusher         *storage.SnapshotFlusher
+	archiver        *archive.Archiver
+	compactor       *storage.Compactor
+	lifecycle       *Lifecycle
+	retentionStop   chan struct{}
+	tieringStop     chan struct{}
+
+	// hubs are the event streams of every mux built, one per tenant.
+	hubs      []*stream.Hub
+	hubsMutex sync.Mutex
+
+	// baseConfig is the startup configuration before the changes kept in
+	// configHistory were laid over it.
+	baseConfig    *models.Configuration
+	liveConfig    *models.Configuration
+	configHistory *configHistory
+	canary        *configCanary
+	configMutex   sync.Mutex
 }
 
+// NewServer returns a server with the default configuration listening on port.
 func NewServer(port string) *Server {
-	tracker := storage.NewNavigationTracker()
-	mux := http.NewServeMux()
+	config := models.DefaultConfiguration()
+	config.Port = port
+	return NewServerWithConfig(config)
+}
+
+// NewServerWithConfig returns a server for config, which must be valid.
+func NewServerWithConfig(config *models.Configuration) *Server {
+	baseConfig := config
+	history, err := newConfigHistory(config)
+	if err != nil {
+		log.Printf("Starting a new configuration history: %v", err)
+	}
+	if restored, err := history.restore(config); err != nil {
+		log.Printf("Failed to restore configuration changes, using the startup configuration: %v", err)
+	} else if restored != config {
+		log.Printf("Restored configuration changes from %s", history.path)
+		config = restored
+	}
+	if _, err := history.record(baseConfig, config, nil, models.ConfigStartup, "", 0); err != nil {
+		log.Printf("Failed to save configuration history: %v", err)
+	}
+
+	port := config.Port
+	tracker := storage.NewNavigationTrackerWithConfig(config)
 
 	server := &Server{
-		tracker:    tracker,
-		port:       port,
-		shutdownCh: make(chan struct{}),
+		config:         config,
+		tracker:        tracker,
+		slowLog:        monitoring.NewSlowLog(config.SlowQueryThreshold, config.SlowQueryLogSize),
+		metrics:        monitoring.NewMetricsCollectorWithLimit(config.MetricsMaxEndpoints),
+		ingestActivity: monitoring.NewIngestActivity(config.IngestActivityTTL),
+		cors:           middleware.NewCORSPolicy(config.CORS),
+		lifecycle:      NewLifecycle(),
+		baseConfig:     baseConfig,
+		liveConfig:     config,
+		configHistory:  history,
+		port:           port,
+		shutdownCh:     make(chan struct{}),
+		started:        time.Now(),
+	}
+
+	var counters storage.CounterBackend
+	if config.Redis.Enabled {
+		server.redisBackend = redis.NewBackend(config.Redis)
+		counters = server.redisBackend
+	}
+	if config.Storage.Backend == models.StorageBackendSQLite {
+		server.sqliteBackend = sqlite.NewBackend(config.Storage)
+		counters = server.sqliteBackend
+	}
+	if config.Storage.Backend == models.StorageBackendPostgres {
+		server.postgresBackend = postgres.NewBackend(config.Storage)
+		counters = server.postgresBackend
+	}
+	if config.Cluster.Enabled {
+		server.clusterBackend = gossip.NewBackend(config.Cluster)
+		counters = server.clusterBackend
+	}
+	if counters != nil {
+		if config.CircuitBreaker.Enabled {
+			server.breaker = storage.NewCircuitBreaker(counters, config.CircuitBreaker)
+			counters = server.breaker
+		}
+		tracker.SetCounterBackend(counters)
+	}
+
+	if config.Heartbeat.Enabled {
+		var checks []monitoring.HealthCheck
+		if server.redisBackend != nil {
+			checks = append(checks, server.redisBackend.Ping)
+		}
+		if server.sqliteBackend != nil {
+			checks = append(checks, server.sqliteBackend.Ping)
+		}
+		if server.postgresBackend != nil {
+			checks = append(checks, server.postgresBackend.Ping)
+		}
+		server.heartbeat = monitoring.NewHeartbeat(config.Heartbeat, checks...)
+	}
+
+	if config.Alerting.Enabled {
+		server.alerter = monitoring.NewAlerter(config.Alerting, tracker.CountActiveVisitors, server.metrics)
+	}
+	if config.Reports.Enabled {
+		reportsConfig := config.Reports
+		if reportsConfig.Path == "" && config.Snapshot.Enabled {
+			reportsConfig.Path = config.Snapshot.Path + ".reports"
+		}
+		server.reportScheduler = reports.NewScheduler(reportsConfig, tracker)
+	}
+
+	server.ipResolver = clientip.NewResolver(config.ClientIP.TrustedProxies)
+	server.enrichers = append(server.enrichers, clientip.Enricher(server.ipResolver))
+
+	if config.GeoIP.Enabled {
+		resolver, err := geo.OpenMaxMind(config.GeoIP.DatabasePath)
+		if err != nil {
+			log.Printf("GeoIP enrichment disabled: %v", err)
+		} else {
+			server.geoResolver = resolver
+			if config.GeoIP.TrustForwardedFor {
+				log.Printf("geoip.trust_forwarded_for is deprecated and ignored; list the proxies in client_ip.trusted_proxies instead")
+			}
+			server.enrichers = append(server.enrichers, geo.Enricher(resolver, server.ipResolver.ClientIP))
+		}
+	}
+
+	var statsSources []handlers.SystemStatsSource
+	if config.Snapshot.Enabled {
+		server.flusher = storage.NewSnapshotFlusher(tracker, config.Snapshot)
+		statsSources = append(statsSources, func(stats *models.SystemStats) {
+			stats.Persistence = server.flusher.Stats()
+		})
+	}
+	if config.Snapshot.Enabled || config.Tiering.Enabled {
+		server.compactor = storage.NewCompactor(tracker, server.flusher, config.Compaction)
+		statsSources = append(statsSources, func(stats *models.SystemStats) {
+			stats.Compaction = server.compactor.Stats()
+		})
+	}
+	if config.Archive.Enabled {
+		server.archiver = archive.NewArchiver(config.Archive, archive.NewS3Client(config.Archive))
+		tracker.AddListener(server.archiver.Record)
+		tracker.AddExternalCopy(models.DeletionCopyArchive)
+		statsSources = append(statsSources, func(stats *models.SystemStats) {
+			stats.Archive = server.archiver.Stats()
+		})
+	}
+	if config.Syslog.Enabled {
+		server.syslogListener = syslog.NewListener(config.Syslog, tracker)
+		tracker.AddExternalCopy(models.DeletionCopySyslog)
+		statsSources = append(statsSources, func(stats *models.SystemStats) {
+			stats.Syslog = server.syslogListener.Stats()
+		})
+	}
+	if config.Kafka.Enabled {
+		server.kafkaConsumer = kafka.NewConsumer(config.Kafka, tracker)
+		tracker.AddExternalCopy(models.DeletionCopyKafka)
+		statsSources = append(statsSources, func(stats *models.SystemStats) {
+			stats.Kafka = server.kafkaConsumer.Stats()
+		})
+	}
+	if config.AsyncIngest.Enabled {
+		server.ingestQueue = queue.New(config.AsyncIngest, tracker)
+		statsSources = append(statsSources, func(stats *models.SystemStats) {
+			stats.IngestQueue = server.ingestQueue.Stats()
+		})
 	}
 
-	mux.HandleFunc("/ingest", handlers.IngestHandler(tracker))
-	mux.HandleFunc("/stats", handlers.StatsHandler(tracker))
+	if server.breaker != nil {
+		statsSources = append(statsSources, func(stats *models.SystemStats) {
+			stats.CircuitBreaker = server.breaker.Stats()
+		})
+	}
+
+	if config.Tenancy.Enabled {
+		statsSources = append(statsSources, func(stats *models.SystemStats) {
+			stats.Tenant = models.DefaultTenant
+		})
+	}
+
+	server.apiDoc = openapi.Build(openapi.Info{
+		Title:       "nav-tracker API",
+		Description: "Records page navigation events and reports distinct visitors per URL.",
+		Version:     version.Version,
+	}, openapi.Routes)
+
+	server.rateLimit = passthrough
+	if config.RateLimit.Enabled {
+		server.rateLimit = middleware.RateLimit(config.RateLimit, config.Tenancy.APIKeys, server.ipResolver)
+	}
 
-	server.httpServer = &http.Server{
-		Addr:    ":" + port,
-		Handler: mux,
+	if config.Auth.Mode == models.AuthModeJWT {
+		server.jwt = auth.NewJWTVerifier(config.Auth.JWT)
+	}
+	if config.Auth.Signing.Enabled {
+		noncePath := config.Auth.Signing.NoncePath
+		if noncePath == "" && config.Snapshot.Enabled {
+			noncePath = config.Snapshot.Path + ".nonces"
+		}
+		// Nonces are kept for as long as their signatures are accepted
+		nonces, err := auth.NewNonceStore(config.Auth.Signing.Tolerance, noncePath)
+		if err != nil {
+			log.Printf("Starting without the signed ingest nonces used before: %v", err)
+		}
+		server.nonces = nonces
+		server.signatures = auth.NewSignatureVerifier(config.Auth.Signing, nonces)
+		statsSources = append(statsSources, func(stats *models.SystemStats) {
+			nonceStats := nonces.Stats()
+			stats.Signing = &models.SigningStats{
+				TrackedNonces: nonceStats.Tracked,
+				Replays:       nonceStats.Replays,
+				PrunedNonces:  nonceStats.Pruned,
+			}
+		})
 	}
 
+	var handler http.Handler
+	mux := server.newMux(tracker, statsSources...)
+	if config.Tenancy.Enabled {
+		server.tenants = newTenantRouter(config, tracker, mux, server.newMux)
+		handler = server.tenants
+	} else {
+		handler = mux
+	}
+	if server.jwt != nil {
+		handler = server.authenticate(handler)
+	}
+
+	handler = server.chain(config.Middleware.Global, mux)(handler)
+	if config.Fixtures.Record {
+		if err := os.MkdirAll(config.Fixtures.Dir, 0o755); err != nil {
+			log.Printf("Fixture recording disabled: %v", err)
+		} else {
+			handler = fixtures.NewRecorder(config.Fixtures.Dir).Middleware(handler)
+		}
+	}
+
+	server.httpServer, server.httpConns = newHTTPServer(port, config.HTTP, handler)
+	server.httpConfig = config.HTTP
+
+	server.registerComponents()
+
 	return server
 }
 
+// newMux registers every route against tracker. With tenancy enabled it is
+// called once per tenant, so each tenant gets its own event stream and only
+// sees its own data; monitoring routes share server-wide state.
+func (s *Server) newMux(tracker *storage.NavigationTracker, statsSources ...handlers.SystemStatsSource) *http.ServeMux {
+	hub := stream.NewHub()
+	tracker.AddListener(hub.Publish)
+	s.hubsMutex.Lock()
+	s.hubs = append(s.hubs, hub)
+	s.hubsMutex.Unlock()
+	mux := http.NewServeMux()
+	routes := s.newRoutes(mux)
+	config := s.config
+	ingest := s.group(models.ScopeIngest, config.Middleware.Ingest, mux)
+	if s.signatures != nil {
+		group := ingest
+		ingest = func(handler http.Handler) http.Handler { return group(s.verifySignature(handler)) }
+	}
+	query := s.group(models.ScopeQuery, config.Middleware.Query, mux)
+	admin := s.group(models.ScopeAdmin, config.Middleware.Admin, mux)
+	// Probes must answer without a token, and gossip checks the cluster secret
+	public := s.chain(config.Middleware.Admin, mux)
+
+	tracker.AddDenyListener(func(list string) { s.metrics.RecordRejectedEvent("denylist_" + list) })
+
+	cache := middleware.NewResponseCache(config.ResponseCache)
+	tracker.AddListener(func(event models.NavigationEvent) { cache.Invalidate(event.URL) })
+	cachedForURL := middleware.Cached(cache, func(r *http.Request) string { return r.URL.Query().Get("url") })
+	cachedForAll := middleware.Cached(cache, func(r *http.Request) string { return "" })
+
+	if s.ingestQueue != nil {
+		routes.Handle("/api/v1/ingest", ingest(handlers.DryRunIngestHandler(tracker, handlers.BackpressureHandler(tracker, handlers.AsyncIngestHandler(tracker, s.ingestQueue, s.enrichers...)), s.enrichers...)))
+		routes.Handle("/api/v1/pixel", ingest(handlers.BackpressureHandler(tracker, handlers.AsyncPixelHandler(tracker, s.ingestQueue, s.enrichers...))))
+	} else {
+		routes.Handle("/api/v1/ingest", ingest(handlers.DryRunIngestHandler(tracker, handlers.IngestHandler(tracker, s.enrichers...), s.enrichers...)))
+		routes.Handle("/api/v1/pixel", ingest(handlers.PixelHandler(tracker, s.enrichers...)))
+	}
+	routes.Handle("/api/v1/stats", query(cachedForURL(handlers.StatsHandler(tracker))))
+	routes.Handle("/api/v1/export", query(handlers.ExportHandler(tracker)))
+	routes.Handle("/api/v1/sessions", query(handlers.SessionsHandler(tracker)))
+	routes.Handle("/api/v1/system-stats", query(handlers.SystemStatsHandler(tracker, statsSources...)))
+	routes.Handle("/api/v1/top-urls", query(cachedForAll(handlers.TopURLsHandler(tracker))))
+	routes.Handle("/api/v1/cohorts", query(handlers.CohortsHandler(tracker)))
+	routes.Handle("/api/v1/actives", query(handlers.ActivesHandler(tracker)))
+	routes.Handle("/api/v1/realtime", query(handlers.RealtimeHandler(tracker)))
+	routes.Handle("/api/v1/stats/compare", query(handlers.CompareHandler(tracker)))
+	routes.Handle("/api/v1/stats/batch", query(handlers.BatchStatsHandler(tracker)))
+	routes.Handle("/api/v1/overlap", query(handlers.OverlapHandler(tracker)))
+	routes.Handle("/api/v1/events/stats", query(handlers.EventTypeStatsHandler(tracker)))
+	routes.Handle("/api/v1/data-quality", query(handlers.DataQualityHandler(tracker)))
+	routes.Handle("/api/v1/categories", query(handlers.CategoriesHandler(tracker)))
+	routes.Handle("/api/v1/referrers", query(handlers.ReferrersHandler(tracker)))
+	routes.Handle("/api/v1/paths", query(handlers.PathsHandler(tracker)))
+	routes.Handle("/api/v1/entry-pages", query(handlers.EntryPagesHandler(tracker)))
+	routes.Handle("/api/v1/exit-pages", query(handlers.ExitPagesHandler(tracker)))
+	routes.Handle("/api/v1/segments", query(handlers.SegmentsHandler(tracker)))
+	routes.Handle("/api/v1/devices", query(handlers.DevicesHandler(tracker)))
+	routes.Handle("/api/v1/geo", query(handlers.GeoHandler(tracker)))
+	routes.Handle("/api/v1/languages", query(handlers.LanguagesHandler(tracker)))
+	routes.Handle("/api/v1/visitors/", byMethod(query(handlers.VisitorProfileHandler(tracker)), map[string]http.Handler{
+		http.MethodDelete: admin(handlers.DeleteVisitorHandler(tracker)),
+	}))
+	routes.Handle("/api/v1/funnels/", query(handlers.FunnelReportHandler(tracker)))
+	// The stream is only covered by the global chain: per-request middleware
+	// such as the slow log would see each connection as one long request.
+	routes.Handle("/api/v1/stream", s.requireScope(models.ScopeQuery)(handlers.StreamHandler(hub, tracker, s.cors)))
+	routes.Handle("/api/v1/events/tail", s.requireScope(models.ScopeQuery)(handlers.TailHandler(hub)))
+	routes.Handle("/api/v1/funnels", admin(handlers.FunnelsHandler(tracker)))
+	routes.Handle("/api/v1/schemas", admin(handlers.SchemasHandler(tracker)))
+	routes.Handle("/api/v1/schemas/", admin(handlers.SchemaHandler(tracker)))
+	routes.Handle("/api/v1/patterns", admin(handlers.PatternsHandler(tracker)))
+	routes.Handle("/api/v1/denylist", admin(handlers.DenylistHandler(tracker)))
+	routes.Handle("/api/v1/urls", admin(handlers.URLsHandler(tracker)))
+	routes.Handle("/api/v1/deletions", admin(handlers.DeletionLogHandler(tracker)))
+	routes.Handle("/api/v1/import", admin(handlers.BackpressureHandler(tracker, handlers.ImportHandler(tracker, config.Import.MaxEventsPerSecond))))
+	routes.Handle("/api/v1/urls/bulk", admin(handlers.BulkURLsHandler(tracker)))
+	routes.Handle("/api/v1/urls/bulk/", admin(handlers.BulkJobHandler(tracker)))
+	routes.Handle("/api/v1/slowlog", admin(handlers.SlowLogHandler(s.slowLog)))
+	routes.Handle("/api/v1/metrics", admin(handlers.MetricsHandler(s.metrics)))
+	routes.Handle("/api/v1/ingest-activity", admin(handlers.IngestActivityHandler(s.ingestActivity)))
+	if s.clusterBackend != nil {
+		s.clusterBackend.AddListener(cache.Invalidate)
+		routes.Handle("/api/v1/cluster", admin(handlers.ClusterHandler(s.clusterBackend)))
+		routes.Handle(gossip.GossipPath, public(handlers.ClusterGossipHandler(s.clusterBackend)))
+	}
+	if config.Redis.Enabled && config.Redis.HyperLogLog && config.Redis.AuditSampleRate > 0 {
+		routes.Handle("/api/v1/accuracy", admin(handlers.AccuracyHandler(s.redisBackend.Accuracy)))
+	}
+	routes.Handle("/api/v1/config", admin(handlers.ConfigHandler(s.currentConfig, s.updateConfig)))
+	routes.Handle("/api/v1/config/history", admin(handlers.ConfigHistoryHandler(s.configHistory.list, s.configHistory.get)))
+	routes.Handle("/api/v1/config/rollback", admin(handlers.ConfigRollbackHandler(s.rollbackConfig)))
+	routes.Handle("/api/v1/config/canary", admin(handlers.ConfigCanaryHandler(s.currentConfig, s.currentCanary, s.startCanary)))
+	routes.Handle("/api/v1/config/canary/promote", admin(handlers.ConfigCanaryEndHandler(s.promoteCanary)))
+	routes.Handle("/api/v1/config/canary/abort", admin(handlers.ConfigCanaryEndHandler(s.abortCanary)))
+	routes.Handle("/ready", public(handlers.ReadyHandler(s.lifecycle.Status)))
+	routes.Handle("/livez", public(handlers.LivezHandler(s.started)))
+	routes.Handle("/readyz", public(handlers.ReadyzHandler(s.runReadinessChecks)))
+	routes.Handle("/api/v1/version", admin(handlers.VersionHandler()))
+	routes.Handle("/dashboard", admin(dashboard.Handler()))
+	routes.Handle("/openapi.json", admin(openapi.Handler(s.apiDoc)))
+	routes.Handle("/docs", admin(openapi.DocsHandler()))
+	if s.reportScheduler != nil {
+		routes.Handle("/api/v1/reports", admin(handlers.ReportsHandler(s.reportScheduler)))
+		routes.Handle("/api/v1/reports/", admin(handlers.ReportHandler(s.reportScheduler)))
+	}
+	if config.Snapshot.Enabled {
+		routes.Handle("/api/v1/snapshot", admin(handlers.SnapshotHandler(tracker, config.Snapshot.Path)))
+	}
+	if s.compactor != nil {
+		routes.Handle("/api/v1/admin/compact", admin(handlers.CompactHandler(s.compactor)))
+	}
+
+	return mux
+}
+
 func (s *Server) Start() error {
-	go func() {
-		log.Printf("Server starting on port %s", s.port)
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("Server failed to start: %v", err)
-			_ = s.Stop()
-		}
-	}()
+	if err := s.lifecycle.Start(); err != nil {
+		return err
+	}
 
+	log.Printf("Server started on port %s", s.port)
 	s.waitForShutdown()
 	return nil
 }
@@ -60,18 +453,377 @@ func (s *Server) Stop() error {
 	var retErr error
 	s.stopOnce.Do(func() {
 		log.Println("Shutting down server...")
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-		if err := s.httpServer.Shutdown(ctx); err != nil && err != http.ErrServerClosed {
-			log.Printf("Server shutdown error: %v", err)
-			retErr = err
-		}
+		retErr = s.lifecycle.Stop()
 		close(s.shutdownCh)
 		log.Println("Server stopped gracefully")
 	})
 	return retErr
 }
 
+// registerComponents declares the server's subsystems and their start order:
+// storage backends first, then ingestion and the HTTP listener, then anything
+// that should only run once the server is serving.
+func (s *Server) registerComponents() {
+	var storageDeps []string
+
+	// The snapshot depends on tiering so that it is saved while cold URLs
+	// can still be read.
+	if s.config.Tiering.Enabled {
+		s.lifecycle.Register(Component{
+			Name:  "tiering",
+			Start: s.startTiering,
+			Stop: func(ctx context.Context) error {
+				close(s.tieringStop)
+				return s.tracker.CloseTiering()
+			},
+		})
+		storageDeps = append(storageDeps, "tiering")
+	}
+
+	if s.config.Snapshot.Enabled {
+		s.lifecycle.Register(Component{
+			Name:      "snapshot",
+			DependsOn: storageDeps,
+			Start: func() error {
+				if err := s.loadSnapshot(); err != nil {
+					return err
+				}
+				s.flusher.Start()
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				s.flusher.Stop()
+				return s.saveSnapshot()
+			},
+		})
+		storageDeps = append(storageDeps, "snapshot")
+	}
+
+	// Compaction stops before the snapshot's final save and the cold files
+	// are removed.
+	if s.compactor != nil {
+		s.lifecycle.Register(Component{
+			Name:      "compaction",
+			DependsOn: storageDeps,
+			Start: func() error {
+				s.compactor.Start()
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				s.compactor.Stop()
+				return nil
+			},
+		})
+	}
+
+	if s.redisBackend != nil {
+		s.lifecycle.Register(Component{
+			Name:  "redis",
+			Start: s.redisBackend.Ping,
+			Stop: func(ctx context.Context) error {
+				return s.redisBackend.Close()
+			},
+		})
+		storageDeps = append(storageDeps, "redis")
+	}
+
+	if s.sqliteBackend != nil {
+		s.lifecycle.Register(Component{
+			Name:  "sqlite",
+			Start: s.sqliteBackend.Open,
+			Stop: func(ctx context.Context) error {
+				return s.sqliteBackend.Close()
+			},
+		})
+		storageDeps = append(storageDeps, "sqlite")
+	}
+
+	if s.postgresBackend != nil {
+		s.lifecycle.Register(Component{
+			Name:  "postgres",
+			Start: s.postgresBackend.Open,
+			Stop: func(ctx context.Context) error {
+				return s.postgresBackend.Close()
+			},
+		})
+		storageDeps = append(storageDeps, "postgres")
+	}
+
+	if s.clusterBackend != nil {
+		s.lifecycle.Register(Component{
+			Name:  "cluster",
+			Start: s.clusterBackend.Start,
+			Stop:  s.clusterBackend.Stop,
+		})
+		storageDeps = append(storageDeps, "cluster")
+	}
+
+	// Archival stops after every ingestion source, uploading the events
+	// recorded up to shutdown.
+	if s.archiver != nil {
+		s.lifecycle.Register(Component{
+			Name:      "archive",
+			DependsOn: storageDeps,
+			Start: func() error {
+				s.archiver.Start()
+				return nil
+			},
+			Stop:        s.archiver.Stop,
+			StopTimeout: s.config.Archive.Timeout,
+		})
+		storageDeps = append(storageDeps, "archive")
+	}
+
+	ingestDeps := storageDeps
+	if s.geoResolver != nil {
+		s.lifecycle.Register(Component{
+			Name: "geoip",
+			Stop: func(ctx context.Context) error {
+				return s.geoResolver.Close()
+			},
+		})
+		ingestDeps = append(append([]string{}, storageDeps...), "geoip")
+	}
+
+	if s.config.Retention.Enabled() {
+		s.lifecycle.Register(Component{
+			Name:      "retention",
+			DependsOn: storageDeps,
+			Start:     s.startRetention,
+			Stop: func(ctx context.Context) error {
+				close(s.retentionStop)
+				return nil
+			},
+		})
+	}
+
+	if s.syslogListener != nil {
+		s.lifecycle.Register(Component{
+			Name:      "syslog",
+			DependsOn: ingestDeps,
+			Start:     s.syslogListener.Start,
+			Stop: func(ctx context.Context) error {
+				s.syslogListener.Stop()
+				return nil
+			},
+		})
+	}
+
+	if s.kafkaConsumer != nil {
+		s.lifecycle.Register(Component{
+			Name:      "kafka",
+			DependsOn: ingestDeps,
+			Start:     s.kafkaConsumer.Start,
+			Stop: func(ctx context.Context) error {
+				s.kafkaConsumer.Stop()
+				return nil
+			},
+		})
+	}
+
+	httpDeps := ingestDeps
+	if s.nonces != nil {
+		// The nonces are saved once the HTTP server can no longer accept
+		// signed requests
+		s.lifecycle.Register(Component{
+			Name: "nonces",
+			Start: func() error {
+				s.nonces.Start(s.config.Auth.Signing.NoncePruneInterval)
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				return s.nonces.Stop()
+			},
+		})
+		httpDeps = append(append([]string{}, httpDeps...), "nonces")
+	}
+	if s.ingestQueue != nil {
+		// The queue stops after the HTTP server, recording every event
+		// accepted before shutdown
+		s.lifecycle.Register(Component{
+			Name:      "ingest_queue",
+			DependsOn: storageDeps,
+			Start:     s.ingestQueue.Start,
+			Stop: func(ctx context.Context) error {
+				s.ingestQueue.Stop()
+				return nil
+			},
+		})
+		httpDeps = append(append([]string{}, httpDeps...), "ingest_queue")
+	}
+
+	s.lifecycle.Register(Component{
+		Name:        "http",
+		DependsOn:   httpDeps,
+		Start:       s.startHTTP,
+		Stop:        s.stopHTTP,
+		StopTimeout: s.config.HTTP.DrainTimeout,
+	})
+
+	// Streams end before the HTTP server drains, which would otherwise wait
+	// out the drain timeout on every WebSocket and event stream still open.
+	s.lifecycle.Register(Component{
+		Name:      "streams",
+		DependsOn: []string{"http"},
+		Stop: func(ctx context.Context) error {
+			s.closeStreams()
+			return nil
+		},
+	})
+
+	if s.alerter != nil {
+		s.lifecycle.Register(Component{
+			Name:      "alerting",
+			DependsOn: []string{"http"},
+			Start: func() error {
+				s.alerter.Start()
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				s.alerter.Stop()
+				return nil
+			},
+		})
+	}
+
+	if s.tenants != nil {
+		s.lifecycle.Register(Component{
+			Name:      "tenant_quotas",
+			DependsOn: []string{"http"},
+			Start: func() error {
+				s.tenants.startQuotas()
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				s.tenants.stopQuotas()
+				return nil
+			},
+		})
+	}
+
+	if s.reportScheduler != nil {
+		s.lifecycle.Register(Component{
+			Name:      "reports",
+			DependsOn: []string{"http"},
+			Start: func() error {
+				s.reportScheduler.Start()
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				s.reportScheduler.Stop()
+				return nil
+			},
+		})
+	}
+
+	if s.heartbeat != nil {
+		s.lifecycle.Register(Component{
+			Name:      "heartbeat",
+			DependsOn: []string{"http"},
+			Start: func() error {
+				s.heartbeat.Start()
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				s.heartbeat.Stop()
+				return nil
+			},
+		})
+	}
+}
+
+// closeStreams ends every live event stream.
+func (s *Server) closeStreams() {
+	s.hubsMutex.Lock()
+	defer s.hubsMutex.Unlock()
+
+	for _, hub := range s.hubs {
+		hub.Close()
+	}
+}
+
+func (s *Server) loadSnapshot() error {
+	err := s.tracker.LoadSnapshot(s.config.Snapshot.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("restore snapshot from %s: %w", s.config.Snapshot.Path, err)
+	}
+
+	log.Printf("Restored snapshot from %s", s.config.Snapshot.Path)
+	return nil
+}
+
+func (s *Server) saveSnapshot() error {
+	if err := s.flusher.Flush(); err != nil {
+		return err
+	}
+
+	log.Printf("Saved snapshot to %s", s.config.Snapshot.Path)
+	return nil
+}
+
+func (s *Server) startRetention() error {
+	s.retentionStop = make(chan struct{})
+	go s.runRetention(s.retentionStop)
+	return nil
+}
+
+// runRetention expires URLs past their TTL until stop is closed.
+func (s *Server) runRetention(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.config.Retention.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			for _, tracker := range s.trackers() {
+				if urls, visitors := tracker.ExpireURLs(now); urls > 0 {
+					log.Printf("Retention expired %d URLs (%d visitor records)", urls, visitors)
+				}
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (s *Server) startTiering() error {
+	if err := s.tracker.EnableTiering(s.config.Tiering); err != nil {
+		return err
+	}
+	s.tieringStop = make(chan struct{})
+	go s.runTiering(s.tieringStop)
+	return nil
+}
+
+// runTiering demotes idle URLs to the cold tier until stop is closed.
+func (s *Server) runTiering(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.config.Tiering.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			if urls := s.tracker.DemoteIdleURLs(now); urls > 0 {
+				log.Printf("Tiering demoted %d idle URLs", urls)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// trackers returns every tenant's tracker, or just the server's tracker when
+// tenancy is disabled.
+func (s *Server) trackers() []*storage.NavigationTracker {
+	if s.tenants == nil {
+		return []*storage.NavigationTracker{s.tracker}
+	}
+	return s.tenants.trackers()
+}
+
 func (s *Server) waitForShutdown() {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
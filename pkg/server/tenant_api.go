@@ -0,0 +1,180 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"nav-tracker/pkg/models"
+)
+
+const tenantsPath = "/api/v1/tenants"
+
+// tenantUpsertRequest is the body of POST /api/v1/tenants. State defaults to active.
+type tenantUpsertRequest struct {
+	ID    string `json:"id"`
+	State string `json:"state"`
+}
+
+// serveAdmin handles the tenant lifecycle API and reports whether r was one
+// of its requests. It is only reachable from the default tenant, so tenants
+// cannot manage each other.
+func (tr *tenantRouter) serveAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if r.URL.Path != tenantsPath && !strings.HasPrefix(r.URL.Path, tenantsPath+"/") {
+		return false
+	}
+	if tr.config.Auth.Mode == models.AuthModeJWT && !checkScope(w, r, models.ScopeAdmin) {
+		return true
+	}
+
+	switch {
+	case r.URL.Path == tenantsPath:
+		switch r.Method {
+		case http.MethodGet:
+			tr.listTenants(w)
+		case http.MethodPost:
+			tr.upsertTenant(w, r)
+		default:
+			writeTenantError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		}
+	case r.URL.Path == tenantsPath+"/rebalance":
+		if r.Method != http.MethodPost {
+			writeTenantError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+			return true
+		}
+		tr.rebalance(w, r)
+	case r.URL.Path == tenantsPath+"/audit":
+		if r.Method != http.MethodGet {
+			writeTenantError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+			return true
+		}
+		tr.mutex.RLock()
+		entries := append([]*models.TenantAuditEntry{}, tr.audit...)
+		tr.mutex.RUnlock()
+		writeTenantJSON(w, http.StatusOK, entries)
+	case strings.HasPrefix(r.URL.Path, tenantsPath+"/"):
+		if r.Method != http.MethodDelete {
+			writeTenantError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+			return true
+		}
+		tr.deleteTenant(w, r, strings.TrimPrefix(r.URL.Path, tenantsPath+"/"))
+	default:
+		return false
+	}
+	return true
+}
+
+func (tr *tenantRouter) listTenants(w http.ResponseWriter) {
+	tr.mutex.RLock()
+	infos := make([]*models.TenantInfo, 0, len(tr.tenants))
+	trackers := make(map[*models.TenantInfo]*tenant, len(tr.tenants))
+	for id, t := range tr.tenants {
+		info := tr.info(id, t)
+		infos = append(infos, info)
+		trackers[info] = t
+	}
+	tr.mutex.RUnlock()
+
+	for _, info := range infos {
+		tracker := trackers[info].tracker
+		usage := tracker.Usage()
+		info.Usage = &usage
+		info.QuotaUsed = info.Quota.Percent(usage)
+		info.Stats = tracker.GetSystemStats()
+		info.Stats.Tenant = info.ID
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+
+	writeTenantJSON(w, http.StatusOK, infos)
+}
+
+// upsertTenant creates a tenant or moves it between active and suspended.
+func (tr *tenantRouter) upsertTenant(w http.ResponseWriter, r *http.Request) {
+	var req tenantUpsertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeTenantError(w, http.StatusBadRequest, "Invalid JSON payload", "")
+		return
+	}
+	if !tenantIDPattern.MatchString(req.ID) {
+		writeTenantError(w, http.StatusBadRequest, "Invalid tenant ID", "")
+		return
+	}
+	if req.State == "" {
+		req.State = models.TenantActive
+	}
+	if req.State != models.TenantActive && req.State != models.TenantSuspended {
+		writeTenantError(w, http.StatusBadRequest, "State must be active or suspended", "")
+		return
+	}
+	if req.ID == models.DefaultTenant && req.State != models.TenantActive {
+		writeTenantError(w, http.StatusBadRequest, "The default tenant cannot be suspended", "")
+		return
+	}
+
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+
+	status := http.StatusOK
+	t, exists := tr.tenants[req.ID]
+	if !exists {
+		created, ok := tr.create(req.ID, req.State, r)
+		if !ok {
+			writeTenantError(w, http.StatusForbidden, "Tenant limit reached", "")
+			return
+		}
+		t, status = created, http.StatusCreated
+	}
+
+	if t.state == models.TenantPendingDeletion {
+		writeTenantError(w, http.StatusConflict, "Tenant is being deleted", ErrorCodeTenantDeleted)
+		return
+	}
+	tr.transition(req.ID, t, req.State, r)
+
+	writeTenantJSON(w, status, tr.info(req.ID, t))
+}
+
+// deleteTenant marks a tenant pending deletion, which rejects its requests
+// immediately, and schedules a purge of its data.
+func (tr *tenantRouter) deleteTenant(w http.ResponseWriter, r *http.Request, id string) {
+	if id == models.DefaultTenant {
+		writeTenantError(w, http.StatusBadRequest, "The default tenant cannot be deleted", "")
+		return
+	}
+
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+
+	t, exists := tr.tenants[id]
+	if !exists {
+		writeTenantError(w, http.StatusNotFound, "Tenant not found", "")
+		return
+	}
+
+	if t.state != models.TenantPendingDeletion {
+		tr.transition(id, t, models.TenantPendingDeletion, r)
+		tr.purges.Add(1)
+		go tr.purge(id)
+	}
+
+	writeTenantJSON(w, http.StatusAccepted, tr.info(id, t))
+}
+
+// info describes t; the caller must hold tr.mutex.
+func (tr *tenantRouter) info(id string, t *tenant) *models.TenantInfo {
+	return &models.TenantInfo{
+		ID:        id,
+		State:     t.state,
+		CreatedAt: t.createdAt,
+		UpdatedAt: t.updatedAt,
+		Quota:     t.quota,
+		QuotaUsed: t.quota.Percent(t.usage),
+	}
+}
+
+func writeTenantJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// Quota alert levels, as percentages of a tenant's quota.
+const (
+	quotaWarning  = 80
+	quotaExceeded = 100
+
+	tenantQuotaWebhookTimeout = 10 * time.Second
+)
+
+// tenantQuotaAlert is POSTed to tenancy.quota_webhook when a tenant's quota
+// level changes. Level is 80 or 100, or 0 once it falls back below 80.
+type tenantQuotaAlert struct {
+	Tenant    string             `json:"tenant"`
+	Level     int                `json:"level"`
+	QuotaUsed float64            `json:"quota_used"`
+	Quota     models.TenantQuota `json:"quota"`
+	Usage     models.TenantUsage `json:"usage"`
+	Time      time.Time          `json:"time"`
+}
+
+// tenantRebalanceRequest is the body of POST /api/v1/tenants/rebalance:
+// the new quota of each tenant listed.
+type tenantRebalanceRequest struct {
+	Quotas map[string]models.TenantQuota `json:"quotas"`
+}
+
+// quotaLevel returns the alert level percent of a quota has reached.
+func quotaLevel(percent float64) int {
+	switch {
+	case percent >= quotaExceeded:
+		return quotaExceeded
+	case percent >= quotaWarning:
+		return quotaWarning
+	}
+	return 0
+}
+
+// overQuota reports whether t's events are refused for using up its quota.
+func (tr *tenantRouter) overQuota(t *tenant) bool {
+	tr.mutex.RLock()
+	defer tr.mutex.RUnlock()
+
+	return t.level == quotaExceeded
+}
+
+// startQuotas checks every tenant's usage each quota_check_interval until
+// stopQuotas is called.
+func (tr *tenantRouter) startQuotas() {
+	go func() {
+		defer close(tr.quotaDone)
+
+		ticker := time.NewTicker(tr.config.Tenancy.QuotaCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				tr.checkQuotas(now)
+			case <-tr.quotaStop:
+				return
+			}
+		}
+	}()
+}
+
+// stopQuotas ends the quota checks and waits for in-flight alerts.
+func (tr *tenantRouter) stopQuotas() {
+	close(tr.quotaStop)
+	<-tr.quotaDone
+}
+
+// checkQuotas measures every tenant's usage against its quota, and alerts
+// the quota webhook of each tenant whose level changed.
+func (tr *tenantRouter) checkQuotas(now time.Time) {
+	tr.mutex.RLock()
+	ids := make([]string, 0, len(tr.tenants))
+	tenants := make([]*tenant, 0, len(tr.tenants))
+	for id, t := range tr.tenants {
+		ids = append(ids, id)
+		tenants = append(tenants, t)
+	}
+	tr.mutex.RUnlock()
+
+	var alerts []tenantQuotaAlert
+	for i, t := range tenants {
+		usage := t.tracker.Usage()
+
+		tr.mutex.Lock()
+		t.usage = usage
+		percent := t.quota.Percent(usage)
+		if level := quotaLevel(percent); level != t.level {
+			t.level = level
+			alerts = append(alerts, tenantQuotaAlert{
+				Tenant:    ids[i],
+				Level:     level,
+				QuotaUsed: percent,
+				Quota:     t.quota,
+				Usage:     usage,
+				Time:      now.UTC(),
+			})
+		}
+		tr.mutex.Unlock()
+	}
+
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Tenant < alerts[j].Tenant })
+	for _, alert := range alerts {
+		if err := tr.notifyQuota(alert); err != nil {
+			log.Printf("Tenant %s quota alert (%d%%) not delivered: %v", alert.Tenant, alert.Level, err)
+		}
+	}
+}
+
+func (tr *tenantRouter) notifyQuota(alert tenantQuotaAlert) error {
+	if tr.config.Tenancy.QuotaWebhook == "" {
+		return nil
+	}
+
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	resp, err := tr.client.Post(tr.config.Tenancy.QuotaWebhook, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// rebalance replaces the quotas of the tenants listed, all or none, then
+// checks every tenant again so those back under their quota are accepted
+// at once.
+func (tr *tenantRouter) rebalance(w http.ResponseWriter, r *http.Request) {
+	var req tenantRebalanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Quotas) == 0 {
+		writeTenantError(w, http.StatusBadRequest, "Body must list quotas by tenant ID", "")
+		return
+	}
+	for id, quota := range req.Quotas {
+		if err := quota.Validate(); err != nil {
+			writeTenantError(w, http.StatusBadRequest, id+": "+err.Error(), "")
+			return
+		}
+	}
+
+	tr.mutex.Lock()
+	for id := range req.Quotas {
+		if _, exists := tr.tenants[id]; !exists {
+			tr.mutex.Unlock()
+			writeTenantError(w, http.StatusNotFound, "Tenant not found: "+id, "")
+			return
+		}
+	}
+	for id, quota := range req.Quotas {
+		tr.tenants[id].quota = quota
+	}
+	tr.mutex.Unlock()
+
+	tr.checkQuotas(time.Now())
+	tr.listTenants(w)
+}
@@ -0,0 +1,284 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/auth"
+	"nav-tracker/pkg/handlers"
+	"nav-tracker/pkg/middleware"
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+const (
+	// TenantHeader names the tenant a request acts on when no API key maps to one.
+	TenantHeader = "X-Tenant-ID"
+
+	// ErrorCodeTenantSuspended is returned when a suspended tenant ingests events.
+	ErrorCodeTenantSuspended = "tenant_suspended"
+
+	// ErrorCodeTenantDeleted is returned for requests to a tenant pending deletion.
+	ErrorCodeTenantDeleted = "tenant_deleted"
+
+	// ErrorCodeTenantQuotaExceeded is returned when a tenant at its quota
+	// ingests events.
+	ErrorCodeTenantQuotaExceeded = "tenant_quota_exceeded"
+
+	tenantAuditLogSize = 1000
+)
+
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// tenantRouter dispatches each request to its tenant's routes, creating the
+// tenant's tracker and routes on first use.
+type tenantRouter struct {
+	config  *models.Configuration
+	newMux  func(*storage.NavigationTracker, ...handlers.SystemStatsSource) *http.ServeMux
+	tenants map[string]*tenant
+	audit   []*models.TenantAuditEntry
+	purges  sync.WaitGroup
+	mutex   sync.RWMutex
+
+	client    *http.Client
+	quotaStop chan struct{}
+	quotaDone chan struct{}
+}
+
+// tenant fields other than tracker and mux are guarded by tenantRouter.mutex.
+// usage and level are as of the last quota check.
+type tenant struct {
+	tracker   *storage.NavigationTracker
+	mux       *http.ServeMux
+	state     string
+	createdAt time.Time
+	updatedAt time.Time
+	quota     models.TenantQuota
+	usage     models.TenantUsage
+	level     int
+}
+
+// newTenantRouter serves the default tenant from the existing tracker and
+// mux, and builds other tenants with newMux.
+func newTenantRouter(config *models.Configuration, defaultTracker *storage.NavigationTracker, defaultMux *http.ServeMux, newMux func(*storage.NavigationTracker, ...handlers.SystemStatsSource) *http.ServeMux) *tenantRouter {
+	now := time.Now().UTC()
+	return &tenantRouter{
+		config: config,
+		newMux: newMux,
+		tenants: map[string]*tenant{
+			models.DefaultTenant: {
+				tracker:   defaultTracker,
+				mux:       defaultMux,
+				state:     models.TenantActive,
+				createdAt: now,
+				updatedAt: now,
+				quota:     config.Tenancy.Quota,
+			},
+		},
+		client:    &http.Client{Timeout: tenantQuotaWebhookTimeout},
+		quotaStop: make(chan struct{}),
+		quotaDone: make(chan struct{}),
+	}
+}
+
+func (tr *tenantRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, status, message := tr.resolve(r)
+	if status != 0 {
+		writeTenantError(w, status, message, "")
+		return
+	}
+
+	if id == models.DefaultTenant && tr.serveAdmin(w, r) {
+		return
+	}
+
+	t, ok := tr.tenant(id, r)
+	if !ok {
+		writeTenantError(w, http.StatusForbidden, "Tenant limit reached", "")
+		return
+	}
+
+	switch tr.state(t) {
+	case models.TenantPendingDeletion, models.TenantPurged:
+		writeTenantError(w, http.StatusGone, "Tenant is being deleted", ErrorCodeTenantDeleted)
+		return
+	case models.TenantSuspended:
+		if isIngestPath(r.URL.Path) {
+			writeTenantError(w, http.StatusForbidden, "Tenant is suspended", ErrorCodeTenantSuspended)
+			return
+		}
+	}
+	if isIngestPath(r.URL.Path) && tr.overQuota(t) {
+		writeTenantError(w, http.StatusTooManyRequests, "Tenant quota exceeded", ErrorCodeTenantQuotaExceeded)
+		return
+	}
+
+	t.mux.ServeHTTP(w, r)
+}
+
+func isIngestPath(path string) bool {
+	switch path {
+	case "/api/v1/ingest", "/ingest", "/api/v1/pixel", "/api/v1/import":
+		return true
+	}
+	return false
+}
+
+// resolve returns the request's tenant ID, or a non-zero status and message
+// when the request must be rejected.
+func (tr *tenantRouter) resolve(r *http.Request) (string, int, string) {
+	header := r.Header.Get(TenantHeader)
+
+	if tr.config.Auth.Mode == models.AuthModeJWT {
+		// The token takes the place of the API key
+		if claims, ok := auth.ClaimsFrom(r.Context()); ok && claims.Tenant != "" {
+			if header != "" && header != claims.Tenant {
+				return "", http.StatusForbidden, "Token does not belong to tenant"
+			}
+			if !tenantIDPattern.MatchString(claims.Tenant) {
+				return "", http.StatusBadRequest, "Invalid tenant ID"
+			}
+			return claims.Tenant, 0, ""
+		}
+	} else if id, ok := tr.config.Tenancy.APIKeys[r.Header.Get(middleware.APIKeyHeader)]; ok {
+		if header != "" && header != id {
+			return "", http.StatusForbidden, "API key does not belong to tenant"
+		}
+		return id, 0, ""
+	}
+
+	if header == "" {
+		return models.DefaultTenant, 0, ""
+	}
+	if !tr.config.Tenancy.AllowHeader {
+		return "", http.StatusForbidden, "Tenant must be selected by API key or token"
+	}
+	if !tenantIDPattern.MatchString(header) {
+		return "", http.StatusBadRequest, "Invalid tenant ID"
+	}
+	return header, 0, ""
+}
+
+// tenant returns the tenant with id, creating it unless MaxTenants already exist.
+func (tr *tenantRouter) tenant(id string, r *http.Request) (*tenant, bool) {
+	tr.mutex.RLock()
+	t, exists := tr.tenants[id]
+	tr.mutex.RUnlock()
+	if exists {
+		return t, true
+	}
+
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+
+	return tr.create(id, models.TenantActive, r)
+}
+
+// create adds a tenant in state; the caller must hold tr.mutex. It returns
+// the existing tenant unchanged if there is one.
+func (tr *tenantRouter) create(id, state string, r *http.Request) (*tenant, bool) {
+	if t, exists := tr.tenants[id]; exists {
+		return t, true
+	}
+	if len(tr.tenants) >= tr.config.Tenancy.MaxTenants {
+		return nil, false
+	}
+
+	tracker := storage.NewNavigationTrackerWithConfig(tr.config)
+	// Validation rules may have changed since startup, and a canary may run
+	defaultTracker := tr.tenants[models.DefaultTenant].tracker
+	tracker.SetValidation(defaultTracker.Validation())
+	if rules, percent, ok := defaultTracker.Canary(); ok {
+		tracker.StartCanary(rules, percent)
+	}
+	now := time.Now().UTC()
+	t := &tenant{
+		tracker: tracker,
+		mux: tr.newMux(tracker, func(stats *models.SystemStats) {
+			stats.Tenant = id
+		}),
+		state:     state,
+		createdAt: now,
+		updatedAt: now,
+		quota:     tr.config.Tenancy.Quota,
+	}
+	tr.tenants[id] = t
+	tr.record(id, "", state, r)
+
+	return t, true
+}
+
+func (tr *tenantRouter) state(t *tenant) string {
+	tr.mutex.RLock()
+	defer tr.mutex.RUnlock()
+
+	return t.state
+}
+
+// transition moves t to state; the caller must hold tr.mutex.
+func (tr *tenantRouter) transition(id string, t *tenant, state string, r *http.Request) {
+	if t.state == state {
+		return
+	}
+	tr.record(id, t.state, state, r)
+	t.state = state
+	t.updatedAt = time.Now().UTC()
+}
+
+// record appends an audit entry; the caller must hold tr.mutex.
+func (tr *tenantRouter) record(id, from, to string, r *http.Request) {
+	entry := &models.TenantAuditEntry{
+		Time:   time.Now().UTC(),
+		Tenant: id,
+		From:   from,
+		To:     to,
+	}
+	if r != nil {
+		entry.Actor = r.RemoteAddr
+	}
+
+	tr.audit = append(tr.audit, entry)
+	if len(tr.audit) > tenantAuditLogSize {
+		tr.audit = tr.audit[len(tr.audit)-tenantAuditLogSize:]
+	}
+}
+
+// purge drops every reference to a tenant's data so it can be reclaimed.
+// The tenant ID becomes available again afterwards.
+func (tr *tenantRouter) purge(id string) {
+	defer tr.purges.Done()
+
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+
+	t, exists := tr.tenants[id]
+	if !exists || t.state != models.TenantPendingDeletion {
+		return
+	}
+
+	t.tracker.Purge()
+	tr.transition(id, t, models.TenantPurged, nil)
+	delete(tr.tenants, id)
+}
+
+func (tr *tenantRouter) trackers() []*storage.NavigationTracker {
+	tr.mutex.RLock()
+	defer tr.mutex.RUnlock()
+
+	trackers := make([]*storage.NavigationTracker, 0, len(tr.tenants))
+	for _, t := range tr.tenants {
+		trackers = append(trackers, t.tracker)
+	}
+	return trackers
+}
+
+func writeTenantError(w http.ResponseWriter, status int, message, code string) {
+	response := models.ErrorResponse{Error: message, Code: code}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,276 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/handlers"
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func newTestTenantRouter(tenancy models.TenancyConfig) *tenantRouter {
+	config := models.DefaultConfiguration()
+	config.Tenancy = tenancy
+	config.Tenancy.Enabled = true
+
+	newMux := func(tracker *storage.NavigationTracker, sources ...handlers.SystemStatsSource) *http.ServeMux {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/ingest", handlers.IngestHandler(tracker))
+		mux.HandleFunc("/api/v1/import", handlers.ImportHandler(tracker, 0))
+		mux.HandleFunc("/stats", handlers.StatsHandler(tracker))
+		mux.HandleFunc("/api/v1/system-stats", handlers.SystemStatsHandler(tracker, sources...))
+		return mux
+	}
+
+	tracker := storage.NewNavigationTrackerWithConfig(config)
+	return newTenantRouter(config, tracker, newMux(tracker), newMux)
+}
+
+func tenantRequest(router http.Handler, method, target, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestTenantRouter_IsolatesTenants(t *testing.T) {
+	router := newTestTenantRouter(models.TenancyConfig{
+		APIKeys:    map[string]string{"key-a": "site-a"},
+		MaxTenants: 10,
+	})
+
+	event := `{"visitor_id":"v1","url":"https://example.com/"}`
+	if w := tenantRequest(router, "POST", "/ingest", event, map[string]string{"X-API-Key": "key-a"}); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	var stats struct {
+		DistinctVisitors int `json:"distinct_visitors"`
+	}
+
+	w := tenantRequest(router, "GET", "/stats?url=https://example.com/", "", map[string]string{"X-API-Key": "key-a"})
+	json.Unmarshal(w.Body.Bytes(), &stats)
+	if stats.DistinctVisitors != 1 {
+		t.Errorf("Expected 1 visitor for site-a, got %d", stats.DistinctVisitors)
+	}
+
+	w = tenantRequest(router, "GET", "/stats?url=https://example.com/", "", nil)
+	json.Unmarshal(w.Body.Bytes(), &stats)
+	if stats.DistinctVisitors != 0 {
+		t.Errorf("Expected the default tenant to see no visitors, got %d", stats.DistinctVisitors)
+	}
+
+	var system models.SystemStats
+	w = tenantRequest(router, "GET", "/api/v1/system-stats", "", map[string]string{"X-API-Key": "key-a"})
+	json.Unmarshal(w.Body.Bytes(), &system)
+	if system.Tenant != "site-a" || system.TotalPageViews != 1 {
+		t.Errorf("Expected site-a stats with 1 page view, got %+v", system)
+	}
+}
+
+func TestTenantRouter_Resolution(t *testing.T) {
+	router := newTestTenantRouter(models.TenancyConfig{
+		APIKeys:    map[string]string{"key-a": "site-a"},
+		MaxTenants: 2,
+	})
+
+	if w := tenantRequest(router, "GET", "/stats?url=x", "", map[string]string{TenantHeader: "site-b"}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected header selection to be refused without allow_header, got %d", w.Code)
+	}
+
+	if w := tenantRequest(router, "GET", "/stats?url=x", "", map[string]string{"X-API-Key": "key-a", TenantHeader: "site-b"}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected mismatched key and header to be refused, got %d", w.Code)
+	}
+
+	router.config.Tenancy.AllowHeader = true
+	if w := tenantRequest(router, "GET", "/stats?url=x", "", map[string]string{TenantHeader: "bad tenant!"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected invalid tenant ID to be rejected, got %d", w.Code)
+	}
+
+	if w := tenantRequest(router, "GET", "/stats?url=x", "", map[string]string{TenantHeader: "site-b"}); w.Code != http.StatusOK {
+		t.Errorf("Expected site-b to be created, got %d", w.Code)
+	}
+
+	if w := tenantRequest(router, "GET", "/stats?url=x", "", map[string]string{TenantHeader: "site-c"}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected the tenant limit to be enforced, got %d", w.Code)
+	}
+
+	if len(router.trackers()) != 2 {
+		t.Errorf("Expected 2 tenants, got %d", len(router.trackers()))
+	}
+}
+
+func TestTenantRouter_Lifecycle(t *testing.T) {
+	router := newTestTenantRouter(models.TenancyConfig{
+		APIKeys:    map[string]string{"key-a": "site-a"},
+		MaxTenants: 10,
+	})
+	asSiteA := map[string]string{"X-API-Key": "key-a"}
+	event := `{"visitor_id":"v1","url":"https://example.com/"}`
+
+	if w := tenantRequest(router, "POST", "/api/v1/tenants", `{"id":"site-a"}`, nil); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	if w := tenantRequest(router, "POST", "/ingest", event, asSiteA); w.Code != http.StatusCreated {
+		t.Fatalf("Expected ingest to succeed, got %d", w.Code)
+	}
+
+	if w := tenantRequest(router, "GET", "/api/v1/tenants", "", asSiteA); w.Code != http.StatusNotFound {
+		t.Errorf("Expected tenants to be unable to reach the admin API, got %d", w.Code)
+	}
+
+	if w := tenantRequest(router, "POST", "/api/v1/tenants", `{"id":"site-a","state":"suspended"}`, nil); w.Code != http.StatusOK {
+		t.Fatalf("Expected suspend to succeed, got %d", w.Code)
+	}
+	w := tenantRequest(router, "POST", "/ingest", event, asSiteA)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), ErrorCodeTenantSuspended) {
+		t.Errorf("Expected suspended ingest to fail with %s, got %d %s", ErrorCodeTenantSuspended, w.Code, w.Body.String())
+	}
+	w = tenantRequest(router, "POST", "/api/v1/import", event, asSiteA)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), ErrorCodeTenantSuspended) {
+		t.Errorf("Expected suspended import to fail with %s, got %d %s", ErrorCodeTenantSuspended, w.Code, w.Body.String())
+	}
+	if w := tenantRequest(router, "GET", "/stats?url=https://example.com/", "", asSiteA); w.Code != http.StatusOK {
+		t.Errorf("Expected a suspended tenant to still query, got %d", w.Code)
+	}
+
+	var tenants []*models.TenantInfo
+	w = tenantRequest(router, "GET", "/api/v1/tenants", "", nil)
+	json.Unmarshal(w.Body.Bytes(), &tenants)
+	if len(tenants) != 2 || tenants[1].ID != "site-a" || tenants[1].State != models.TenantSuspended || tenants[1].Stats.TotalPageViews != 1 {
+		t.Errorf("Expected default and suspended site-a, got %+v", tenants)
+	}
+
+	siteA := router.tenants["site-a"].tracker
+	if w := tenantRequest(router, "DELETE", "/api/v1/tenants/site-a", "", nil); w.Code != http.StatusAccepted {
+		t.Fatalf("Expected delete to be accepted, got %d", w.Code)
+	}
+	router.purges.Wait()
+
+	if stats := siteA.GetSystemStats(); stats.TotalPageViews != 0 || stats.TotalUniqueURLs != 0 {
+		t.Errorf("Expected site-a data to be purged, got %+v", stats)
+	}
+	if len(router.trackers()) != 1 {
+		t.Errorf("Expected only the default tenant to remain, got %d", len(router.trackers()))
+	}
+
+	var audit []*models.TenantAuditEntry
+	w = tenantRequest(router, "GET", "/api/v1/tenants/audit", "", nil)
+	json.Unmarshal(w.Body.Bytes(), &audit)
+	expected := []string{models.TenantActive, models.TenantSuspended, models.TenantPendingDeletion, models.TenantPurged}
+	if len(audit) != len(expected) {
+		t.Fatalf("Expected %d audit entries, got %d", len(expected), len(audit))
+	}
+	for i, state := range expected {
+		if audit[i].Tenant != "site-a" || audit[i].To != state {
+			t.Errorf("Expected audit entry %d to move site-a to %s, got %+v", i, state, audit[i])
+		}
+	}
+}
+
+func TestTenantRouter_DefaultTenantIsProtected(t *testing.T) {
+	router := newTestTenantRouter(models.TenancyConfig{MaxTenants: 10})
+
+	if w := tenantRequest(router, "POST", "/api/v1/tenants", `{"id":"default","state":"suspended"}`, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected suspending the default tenant to fail, got %d", w.Code)
+	}
+	if w := tenantRequest(router, "DELETE", "/api/v1/tenants/default", "", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected deleting the default tenant to fail, got %d", w.Code)
+	}
+	if w := tenantRequest(router, "DELETE", "/api/v1/tenants/missing", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected deleting an unknown tenant to return 404, got %d", w.Code)
+	}
+}
+
+func TestTenantRouter_Quotas(t *testing.T) {
+	var mutex sync.Mutex
+	var alerts []tenantQuotaAlert
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert tenantQuotaAlert
+		json.NewDecoder(r.Body).Decode(&alert)
+		mutex.Lock()
+		alerts = append(alerts, alert)
+		mutex.Unlock()
+	}))
+	defer webhook.Close()
+
+	router := newTestTenantRouter(models.TenancyConfig{
+		AllowHeader:  true,
+		MaxTenants:   10,
+		Quota:        models.TenantQuota{MaxURLs: 5},
+		QuotaWebhook: webhook.URL,
+	})
+	siteA := map[string]string{TenantHeader: "site-a"}
+	ingest := func(url string) int {
+		event := fmt.Sprintf(`{"visitor_id":"v1","url":%q}`, url)
+		return tenantRequest(router, "POST", "/ingest", event, siteA).Code
+	}
+	levels := func() []int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		var levels []int
+		for _, alert := range alerts {
+			if alert.Tenant != "site-a" {
+				t.Errorf("Expected only site-a alerted, got %+v", alert)
+			}
+			levels = append(levels, alert.Level)
+		}
+		return levels
+	}
+
+	for i := 0; i < 4; i++ {
+		ingest(fmt.Sprintf("https://example.com/%d", i))
+	}
+	router.checkQuotas(time.Now())
+	ingest("https://example.com/4")
+	router.checkQuotas(time.Now())
+	if got := levels(); len(got) != 2 || got[0] != quotaWarning || got[1] != quotaExceeded {
+		t.Fatalf("Expected alerts at 80%% then 100%%, got %v", got)
+	}
+
+	if code := ingest("https://example.com/5"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected a tenant at its quota refused, got %d", code)
+	}
+	importEvent := `{"visitor_id":"v1","url":"https://example.com/5"}`
+	if w := tenantRequest(router, "POST", "/api/v1/import", importEvent, siteA); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected a tenant at its quota refused imports, got %d", w.Code)
+	}
+	if w := tenantRequest(router, "POST", "/ingest", `{"visitor_id":"v1","url":"https://example.com/"}`, nil); w.Code != http.StatusCreated {
+		t.Errorf("Expected other tenants unaffected, got %d", w.Code)
+	}
+
+	w := tenantRequest(router, "POST", "/api/v1/tenants/rebalance", `{"quotas":{"site-a":{"max_urls":10}}}`, nil)
+	var infos []models.TenantInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &infos); err != nil || w.Code != http.StatusOK || len(infos) != 2 {
+		t.Fatalf("Expected every tenant listed after rebalancing, got %d: %s", w.Code, w.Body.String())
+	}
+	if info := infos[1]; info.ID != "site-a" || info.Quota.MaxURLs != 10 || info.Usage == nil || info.Usage.URLs != 5 || info.QuotaUsed != 50 {
+		t.Errorf("Expected site-a at 50%% of its new quota, got %+v", info)
+	}
+	if got := levels(); len(got) != 3 || got[2] != 0 {
+		t.Errorf("Expected a resolved alert after rebalancing, got %v", got)
+	}
+	if code := ingest("https://example.com/5"); code != http.StatusCreated {
+		t.Errorf("Expected ingestion resumed after rebalancing, got %d", code)
+	}
+
+	for body, expected := range map[string]int{
+		`{"quotas":{"site-b":{"max_urls":10}}}`: http.StatusNotFound,
+		`{"quotas":{"site-a":{"max_urls":-1}}}`: http.StatusBadRequest,
+		`{}`:                                    http.StatusBadRequest,
+	} {
+		if w := tenantRequest(router, "POST", "/api/v1/tenants/rebalance", body, nil); w.Code != expected {
+			t.Errorf("Expected status %d for %s, got %d", expected, body, w.Code)
+		}
+	}
+}
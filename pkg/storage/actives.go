@@ -0,0 +1,325 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+const day = 24 * time.Hour
+
+// activeWindowDays maps each actives window to the number of UTC days it spans.
+var activeWindowDays = map[string]int{
+	models.ActiveWindowDay:   1,
+	models.ActiveWindowWeek:  7,
+	models.ActiveWindowMonth: 30,
+}
+
+// ActiveStore keeps the set of visitors seen on each UTC day, overall and per
+// URL with their page views, for the last month so that daily, weekly and
+// monthly actives can be counted over rolling windows and periods compared.
+type ActiveStore struct {
+	days   map[int64]*activeDay
+	latest int64
+	mutex  sync.RWMutex
+}
+
+type activeDay struct {
+	visitors map[string]bool
+	// urls maps each URL to its visitors' page views that day.
+	urls map[string]map[string]int64
+}
+
+func NewActiveStore() *ActiveStore {
+	return &ActiveStore{days: make(map[int64]*activeDay)}
+}
+
+// dayStart returns 00:00 UTC of the day containing t, as Unix seconds.
+func dayStart(t time.Time) int64 {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix()
+}
+
+func newActiveDay() *activeDay {
+	return &activeDay{
+		visitors: make(map[string]bool),
+		urls:     make(map[string]map[string]int64),
+	}
+}
+
+// Record marks visitorID active on url on the day of timestamp. Events more
+// than a month older than the newest day recorded are ignored.
+func (as *ActiveStore) Record(visitorID, url string, timestamp time.Time) {
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+
+	eventDay := dayStart(timestamp)
+	if eventDay > as.latest {
+		as.latest = eventDay
+		as.prune()
+	}
+	if eventDay <= as.oldest() {
+		return
+	}
+
+	d := as.days[eventDay]
+	if d == nil {
+		d = newActiveDay()
+		as.days[eventDay] = d
+	}
+	d.visitors[visitorID] = true
+
+	visitors := d.urls[url]
+	if visitors == nil {
+		visitors = make(map[string]int64)
+		d.urls[url] = visitors
+	}
+	visitors[visitorID]++
+}
+
+// oldest returns the day before the longest window ending on the latest day.
+func (as *ActiveStore) oldest() int64 {
+	return as.latest - int64(activeWindowDays[models.ActiveWindowMonth])*int64(day/time.Second)
+}
+
+// prune drops days that have fallen out of every window; the caller must hold the lock.
+func (as *ActiveStore) prune() {
+	oldest := as.oldest()
+	for start := range as.days {
+		if start <= oldest {
+			delete(as.days, start)
+		}
+	}
+}
+
+// Count returns the distinct visitors seen during the window of whole UTC
+// days ending with the day containing asOf, on url or on any URL when url is
+// empty. It returns false if the window is unknown.
+func (as *ActiveStore) Count(url, window string, asOf time.Time) (*models.ActiveVisitors, bool) {
+	days, ok := activeWindowDays[window]
+	if !ok {
+		return nil, false
+	}
+
+	end := dayStart(asOf)
+	start := end - int64(days-1)*int64(day/time.Second)
+
+	as.mutex.RLock()
+	defer as.mutex.RUnlock()
+
+	visitors, _ := as.period(url, start, end)
+
+	return &models.ActiveVisitors{
+		URL:      url,
+		Window:   window,
+		Start:    time.Unix(start, 0).UTC(),
+		End:      time.Unix(end, 0).UTC().Add(day),
+		Visitors: visitors,
+	}, true
+}
+
+// period returns the distinct visitors and page views on url, or on any URL
+// when url is empty, from the day starting at start to the day starting at
+// end inclusive; the caller must hold the lock.
+func (as *ActiveStore) period(url string, start, end int64) (int, int64) {
+	visitors := make(map[string]bool)
+	var pageViews int64
+	for d := start; d <= end; d += int64(day / time.Second) {
+		active := as.days[d]
+		if active == nil {
+			continue
+		}
+		if url == "" {
+			for visitorID := range active.visitors {
+				visitors[visitorID] = true
+			}
+			for _, views := range active.urls {
+				for _, count := range views {
+					pageViews += count
+				}
+			}
+			continue
+		}
+		for visitorID, count := range active.urls[url] {
+			visitors[visitorID] = true
+			pageViews += count
+		}
+	}
+	return len(visitors), pageViews
+}
+
+// Compare returns the distinct visitors and page views on url, or on any URL
+// when url is empty, over the days whole UTC days ending with the day
+// containing asOf, against the days before them. Both periods must fall
+// within the month kept, so days is at most MaxComparePeriodDays.
+func (as *ActiveStore) Compare(url string, days int, asOf time.Time) (*models.PeriodComparison, error) {
+	if days < 1 || days > models.MaxComparePeriodDays {
+		return nil, fmt.Errorf("period must be between 1 and %d days", models.MaxComparePeriodDays)
+	}
+
+	daySeconds := int64(day / time.Second)
+	end := dayStart(asOf)
+	start := end - int64(days-1)*daySeconds
+	previousEnd := start - daySeconds
+	previousStart := previousEnd - int64(days-1)*daySeconds
+
+	as.mutex.RLock()
+	currentVisitors, currentViews := as.period(url, start, end)
+	previousVisitors, previousViews := as.period(url, previousStart, previousEnd)
+	as.mutex.RUnlock()
+
+	comparison := &models.PeriodComparison{
+		URL:    url,
+		Period: fmt.Sprintf("%dd", days),
+		Current: models.PeriodStats{
+			Start:            time.Unix(start, 0).UTC(),
+			End:              time.Unix(end, 0).UTC().Add(day),
+			DistinctVisitors: currentVisitors,
+			PageViews:        currentViews,
+		},
+		Previous: models.PeriodStats{
+			Start:            time.Unix(previousStart, 0).UTC(),
+			End:              time.Unix(start, 0).UTC(),
+			DistinctVisitors: previousVisitors,
+			PageViews:        previousViews,
+		},
+	}
+	comparison.Change = models.PeriodDelta{
+		DistinctVisitors:        currentVisitors - previousVisitors,
+		DistinctVisitorsPercent: percentChange(int64(currentVisitors), int64(previousVisitors)),
+		PageViews:               currentViews - previousViews,
+		PageViewsPercent:        percentChange(currentViews, previousViews),
+	}
+	return comparison, nil
+}
+
+// Range returns the distinct visitors and page views on url, or on any URL
+// when url is empty, over the whole UTC days from the day containing from to
+// the one containing the instant before to. The range must fall within the
+// month kept.
+func (as *ActiveStore) Range(url string, from, to time.Time) (*models.PeriodStats, error) {
+	start, end, err := rangeDays(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	as.mutex.RLock()
+	visitors, pageViews := as.period(url, start, end)
+	as.mutex.RUnlock()
+
+	return &models.PeriodStats{
+		Start:            time.Unix(start, 0).UTC(),
+		End:              time.Unix(end, 0).UTC().Add(day),
+		DistinctVisitors: visitors,
+		PageViews:        pageViews,
+	}, nil
+}
+
+// rangeDays returns the first and last whole UTC days of the range from
+// from to to, as Unix seconds, checking it falls within the month kept.
+func rangeDays(from, to time.Time) (int64, int64, error) {
+	if !from.Before(to) {
+		return 0, 0, fmt.Errorf("from must be before to")
+	}
+	start := dayStart(from)
+	end := dayStart(to.Add(-time.Nanosecond))
+	if days := (end-start)/int64(day/time.Second) + 1; days > int64(activeWindowDays[models.ActiveWindowMonth]) {
+		return 0, 0, fmt.Errorf("range must span at most %d days", activeWindowDays[models.ActiveWindowMonth])
+	}
+	return start, end, nil
+}
+
+// TopURLs returns the limit URLs with the most distinct visitors over the
+// same days as Range, with their page views over those days, ordered by
+// visitors then page views descending and then by URL.
+func (as *ActiveStore) TopURLs(from, to time.Time, limit int) ([]*models.VisitorStats, error) {
+	start, end, err := rangeDays(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	visitors := make(map[string]map[string]bool)
+	pageViews := make(map[string]int64)
+	as.mutex.RLock()
+	for d := start; d <= end; d += int64(day / time.Second) {
+		active := as.days[d]
+		if active == nil {
+			continue
+		}
+		for url, views := range active.urls {
+			seen := visitors[url]
+			if seen == nil {
+				seen = make(map[string]bool)
+				visitors[url] = seen
+			}
+			for visitorID, count := range views {
+				seen[visitorID] = true
+				pageViews[url] += count
+			}
+		}
+	}
+	as.mutex.RUnlock()
+
+	top := make([]*models.VisitorStats, 0, len(visitors))
+	for url, seen := range visitors {
+		top = append(top, &models.VisitorStats{
+			URL:              url,
+			DistinctVisitors: len(seen),
+			TotalPageViews:   int(pageViews[url]),
+		})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].DistinctVisitors != top[j].DistinctVisitors {
+			return top[i].DistinctVisitors > top[j].DistinctVisitors
+		}
+		if top[i].TotalPageViews != top[j].TotalPageViews {
+			return top[i].TotalPageViews > top[j].TotalPageViews
+		}
+		return top[i].URL < top[j].URL
+	})
+	if limit > 0 && len(top) > limit {
+		top = top[:limit]
+	}
+	return top, nil
+}
+
+// percentChange returns the change from previous to current as a percentage
+// of previous, or nil when previous is zero.
+func percentChange(current, previous int64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	percent := math.Round(float64(current-previous)/float64(previous)*10000) / 100
+	return &percent
+}
+
+// ForgetVisitor removes visitorID from every day.
+func (as *ActiveStore) ForgetVisitor(visitorID string) {
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+
+	for _, d := range as.days {
+		delete(d.visitors, visitorID)
+		for url, visitors := range d.urls {
+			delete(visitors, visitorID)
+			if len(visitors) == 0 {
+				delete(d.urls, url)
+			}
+		}
+	}
+}
+
+// ForgetURL removes url's visitors from every day. Its visitors still count
+// as active overall.
+func (as *ActiveStore) ForgetURL(url string) {
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+
+	for _, d := range as.days {
+		delete(d.urls, url)
+	}
+}
@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestActiveStore_Count(t *testing.T) {
+	store := NewActiveStore()
+	today := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	store.Record("visitor1", "/a", today)
+	store.Record("visitor1", "/b", today.Add(-time.Hour))
+	store.Record("visitor2", "/a", today.AddDate(0, 0, -1))
+	store.Record("visitor3", "/b", today.AddDate(0, 0, -10))
+	store.Record("visitor4", "/a", today.AddDate(0, 0, -40))
+
+	tests := []struct {
+		url      string
+		window   string
+		visitors int
+	}{
+		{"", models.ActiveWindowDay, 1},
+		{"", models.ActiveWindowWeek, 2},
+		{"", models.ActiveWindowMonth, 3},
+		{"/a", models.ActiveWindowWeek, 2},
+		{"/b", models.ActiveWindowMonth, 2},
+		{"/c", models.ActiveWindowMonth, 0},
+	}
+	for _, tt := range tests {
+		actives, ok := store.Count(tt.url, tt.window, today)
+		if !ok {
+			t.Fatalf("Expected window %s to be valid", tt.window)
+		}
+		if actives.Visitors != tt.visitors {
+			t.Errorf("Expected %d visitors for %q over a %s, got %d", tt.visitors, tt.url, tt.window, actives.Visitors)
+		}
+	}
+
+	actives, _ := store.Count("", models.ActiveWindowWeek, today)
+	if !actives.Start.Equal(time.Date(2024, 3, 25, 0, 0, 0, 0, time.UTC)) || !actives.End.Equal(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected week bounds: %v to %v", actives.Start, actives.End)
+	}
+
+	if _, ok := store.Count("", "year", today); ok {
+		t.Error("Expected unknown window to be rejected")
+	}
+}
+
+func TestActiveStore_Compare(t *testing.T) {
+	store := NewActiveStore()
+	today := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	store.Record("visitor1", "/a", today)
+	store.Record("visitor1", "/a", today)
+	store.Record("visitor2", "/a", today.AddDate(0, 0, -6))
+	store.Record("visitor3", "/b", today.AddDate(0, 0, -2))
+	store.Record("visitor1", "/a", today.AddDate(0, 0, -7))
+	store.Record("visitor4", "/a", today.AddDate(0, 0, -13))
+	store.Record("visitor5", "/a", today.AddDate(0, 0, -14))
+
+	comparison, err := store.Compare("/a", 7, today)
+	if err != nil {
+		t.Fatalf("Failed to compare: %v", err)
+	}
+	if comparison.Current.DistinctVisitors != 2 || comparison.Current.PageViews != 3 {
+		t.Errorf("Expected 2 visitors and 3 views this week, got %+v", comparison.Current)
+	}
+	if comparison.Previous.DistinctVisitors != 2 || comparison.Previous.PageViews != 2 {
+		t.Errorf("Expected 2 visitors and 2 views the week before, got %+v", comparison.Previous)
+	}
+	if comparison.Change.DistinctVisitors != 0 || comparison.Change.PageViews != 1 || *comparison.Change.PageViewsPercent != 50 {
+		t.Errorf("Expected +1 view (+50%%), got %+v", comparison.Change)
+	}
+	if !comparison.Previous.End.Equal(comparison.Current.Start) || !comparison.Previous.Start.Equal(time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected period bounds: %+v", comparison)
+	}
+
+	comparison, _ = store.Compare("", 1, today)
+	if comparison.Change.DistinctVisitorsPercent != nil {
+		t.Errorf("Expected no percentage without a previous period, got %v", *comparison.Change.DistinctVisitorsPercent)
+	}
+
+	store.ForgetVisitor("visitor1")
+	if comparison, _ = store.Compare("/a", 7, today); comparison.Current.PageViews != 1 {
+		t.Errorf("Expected a forgotten visitor's views to be dropped, got %+v", comparison.Current)
+	}
+
+	if _, err := store.Compare("/a", models.MaxComparePeriodDays+1, today); err == nil {
+		t.Error("Expected a period beyond the month kept to be rejected")
+	}
+}
+
+func TestActiveStore_Range(t *testing.T) {
+	store := NewActiveStore()
+	today := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	store.Record("visitor1", "/a", today)
+	store.Record("visitor1", "/a", today.AddDate(0, 0, -1))
+	store.Record("visitor2", "/a", today.AddDate(0, 0, -1))
+	store.Record("visitor3", "/a", today.AddDate(0, 0, -5))
+
+	// A range ending at midnight leaves out the day it ends on
+	period, err := store.Range("/a", today.AddDate(0, 0, -2), time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to count range: %v", err)
+	}
+	if period.DistinctVisitors != 2 || period.PageViews != 2 {
+		t.Errorf("Expected 2 visitors and 2 views, got %+v", period)
+	}
+	if !period.Start.Equal(time.Date(2024, 3, 29, 0, 0, 0, 0, time.UTC)) || !period.End.Equal(time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected range bounds: %+v", period)
+	}
+
+	if period, _ := store.Range("/a", today.AddDate(0, 0, -7), today); period.DistinctVisitors != 3 || period.PageViews != 4 {
+		t.Errorf("Expected 3 visitors and 4 views over the week, got %+v", period)
+	}
+
+	if _, err := store.Range("/a", today, today); err == nil {
+		t.Error("Expected an empty range to be refused")
+	}
+	if _, err := store.Range("/a", today.AddDate(0, 0, -31), today); err == nil {
+		t.Error("Expected a range longer than the month kept to be refused")
+	}
+}
+
+func TestActiveStore_PrunesOldDays(t *testing.T) {
+	store := NewActiveStore()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store.Record("visitor1", "/a", start)
+	store.Record("visitor2", "/a", start.AddDate(0, 0, 31))
+
+	if len(store.days) != 1 {
+		t.Errorf("Expected days outside the month window to be pruned, got %d days", len(store.days))
+	}
+
+	// Late events older than every window are ignored
+	store.Record("visitor3", "/a", start)
+	if len(store.days) != 1 {
+		t.Errorf("Expected late event to be ignored, got %d days", len(store.days))
+	}
+}
+
+func TestActiveStore_Snapshot(t *testing.T) {
+	tracker := NewNavigationTracker()
+	now := time.Now()
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: now}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	path := t.TempDir() + "/snapshot.json"
+	if err := tracker.SaveSnapshot(path); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	restored := NewNavigationTracker()
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+
+	actives, _ := restored.Actives().Count("https://example.com/a", models.ActiveWindowDay, now)
+	if actives.Visitors != 1 {
+		t.Errorf("Expected 1 restored active visitor, got %d", actives.Visitors)
+	}
+}
+
+func TestActiveStore_TopURLs(t *testing.T) {
+	store := NewActiveStore()
+	today := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	store.Record("visitor1", "/a", today.AddDate(0, 0, -1))
+	store.Record("visitor1", "/a", today.AddDate(0, 0, -1))
+	store.Record("visitor1", "/b", today.AddDate(0, 0, -1))
+	store.Record("visitor2", "/b", today.AddDate(0, 0, -1))
+	// Outside the range: /c has the most visitors overall
+	for _, visitorID := range []string{"visitor1", "visitor2", "visitor3"} {
+		store.Record(visitorID, "/c", today)
+		store.Record(visitorID, "/c", today.AddDate(0, 0, -3))
+	}
+
+	top, err := store.TopURLs(today.AddDate(0, 0, -1), time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), 10)
+	if err != nil {
+		t.Fatalf("Failed to rank range: %v", err)
+	}
+	if len(top) != 2 || top[0].URL != "/b" || top[0].DistinctVisitors != 2 || top[1].URL != "/a" || top[1].TotalPageViews != 2 {
+		t.Errorf("Expected /b then /a ranked over the range, got %+v %+v", top[0], top[len(top)-1])
+	}
+
+	if top, _ := store.TopURLs(today.AddDate(0, 0, -7), today.Add(day), 1); len(top) != 1 || top[0].URL != "/c" {
+		t.Errorf("Expected /c ranked first over the week, got %+v", top)
+	}
+	if _, err := store.TopURLs(today, today, 10); err == nil {
+		t.Error("Expected an empty range to be refused")
+	}
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"errors"
+	"log"
+
+	"nav-tracker/pkg/models"
+)
+
+// CounterBackend holds the per-URL distinct visitor and page view counters so
+// that replicas behind a load balancer report the same numbers. Every other
+// statistic is still computed from the tracker's in-memory state.
+//
+// ForgetVisitor, DeleteURL and MergeURL mirror the tracker's deletions,
+// retention expiry and bulk merges, so the shared counters stop counting
+// what was removed locally.
+type CounterBackend interface {
+	Record(event *models.NavigationEvent) error
+	DistinctVisitors(url string) (int, error)
+	PageViews(url string) (int64, error)
+	ForgetVisitor(visitorID string) error
+	DeleteURL(url string) error
+	MergeURL(source, target string) error
+}
+
+// SetCounterBackend routes the per-URL counters through backend. It must be
+// called before the tracker starts receiving events.
+func (nt *NavigationTracker) SetCounterBackend(backend CounterBackend) {
+	nt.counters = backend
+}
+
+// sharedCounts reads url's counters from the backend, reporting false when
+// there is no backend or it failed so callers fall back to local counts.
+func (nt *NavigationTracker) sharedCounts(url string) (int, int64, bool) {
+	if nt.counters == nil {
+		return 0, 0, false
+	}
+
+	visitors, err := nt.counters.DistinctVisitors(url)
+	if err != nil {
+		logReadFailure(err)
+		return 0, 0, false
+	}
+
+	pageViews, err := nt.counters.PageViews(url)
+	if err != nil {
+		logReadFailure(err)
+		return 0, 0, false
+	}
+
+	return visitors, pageViews, true
+}
+
+// logWriteFailure logs an event the backend failed to record. Events refused
+// by an open circuit breaker are not logged, as the failures that opened it
+// were.
+func logWriteFailure(err error) {
+	if !errors.Is(err, ErrCircuitOpen) {
+		log.Printf("Counter backend write failed, event counted in memory only: %v", err)
+	}
+}
+
+// logReadFailure logs a failed backend read. Reads refused by an open
+// circuit breaker are not logged, as the failures that opened it were.
+func logReadFailure(err error) {
+	if !errors.Is(err, ErrCircuitOpen) {
+		log.Printf("Counter backend read failed, using local counts: %v", err)
+	}
+}
+
+// forgetShared removes visitorID from the backend, if there is one.
+func (nt *NavigationTracker) forgetShared(visitorID string) error {
+	if nt.counters == nil {
+		return nil
+	}
+	return nt.counters.ForgetVisitor(visitorID)
+}
+
+// deleteShared removes url from the backend, if there is one.
+func (nt *NavigationTracker) deleteShared(url string) error {
+	if nt.counters == nil {
+		return nil
+	}
+	return nt.counters.DeleteURL(url)
+}
+
+// mergeShared folds source into target in the backend, if there is one.
+func (nt *NavigationTracker) mergeShared(source, target string) error {
+	if nt.counters == nil {
+		return nil
+	}
+	return nt.counters.MergeURL(source, target)
+}
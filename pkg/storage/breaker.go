@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// ErrCircuitOpen is returned instead of calling a counter backend whose
+// circuit breaker is open.
+var ErrCircuitOpen = errors.New("counter backend circuit breaker is open")
+
+// CircuitBreaker is a CounterBackend that stops calling a failing backend.
+// After FailureThreshold consecutive failures it opens and fails every call
+// with ErrCircuitOpen for Cooldown, so requests don't each wait out the
+// backend's timeout. Then it half-opens and lets one call through: success
+// closes it, failure opens it for another Cooldown.
+type CircuitBreaker struct {
+	backend CounterBackend
+	config  models.CircuitBreakerConfig
+	now     func() time.Time
+
+	mutex    sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	trips    int64
+	rejected int64
+}
+
+func NewCircuitBreaker(backend CounterBackend, config models.CircuitBreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		backend: backend,
+		config:  config,
+		now:     time.Now,
+		state:   models.CircuitClosed,
+	}
+}
+
+// allow reports whether a call may go to the backend, half-opening the
+// breaker once the cooldown has passed. Only one trial call is let through
+// while half-open.
+func (cb *CircuitBreaker) allow() error {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if cb.state == models.CircuitOpen && cb.now().Sub(cb.openedAt) >= cb.config.Cooldown {
+		cb.state = models.CircuitHalfOpen
+		return nil
+	}
+	if cb.state != models.CircuitClosed {
+		cb.rejected++
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// done records the outcome of a call that allow let through.
+func (cb *CircuitBreaker) done(err error) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if err == nil {
+		cb.state = models.CircuitClosed
+		cb.failures = 0
+		return
+	}
+
+	cb.failures++
+	if cb.state == models.CircuitHalfOpen || cb.failures >= cb.config.FailureThreshold {
+		cb.state = models.CircuitOpen
+		cb.openedAt = cb.now()
+		cb.trips++
+	}
+}
+
+func (cb *CircuitBreaker) Record(event *models.NavigationEvent) error {
+	return cb.call(func() error { return cb.backend.Record(event) })
+}
+
+func (cb *CircuitBreaker) DistinctVisitors(url string) (int, error) {
+	if err := cb.allow(); err != nil {
+		return 0, err
+	}
+	visitors, err := cb.backend.DistinctVisitors(url)
+	cb.done(err)
+	return visitors, err
+}
+
+func (cb *CircuitBreaker) PageViews(url string) (int64, error) {
+	if err := cb.allow(); err != nil {
+		return 0, err
+	}
+	pageViews, err := cb.backend.PageViews(url)
+	cb.done(err)
+	return pageViews, err
+}
+
+func (cb *CircuitBreaker) ForgetVisitor(visitorID string) error {
+	return cb.call(func() error { return cb.backend.ForgetVisitor(visitorID) })
+}
+
+func (cb *CircuitBreaker) DeleteURL(url string) error {
+	return cb.call(func() error { return cb.backend.DeleteURL(url) })
+}
+
+func (cb *CircuitBreaker) MergeURL(source, target string) error {
+	return cb.call(func() error { return cb.backend.MergeURL(source, target) })
+}
+
+// call runs fn if the breaker allows it and records the outcome.
+func (cb *CircuitBreaker) call(fn func() error) error {
+	if err := cb.allow(); err != nil {
+		return err
+	}
+	err := fn()
+	cb.done(err)
+	return err
+}
+
+// Stats reports the breaker's state and counters.
+func (cb *CircuitBreaker) Stats() *models.CircuitBreakerStats {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	stats := &models.CircuitBreakerStats{
+		State:               cb.state,
+		ConsecutiveFailures: cb.failures,
+		Trips:               cb.trips,
+		Rejected:            cb.rejected,
+	}
+	if cb.state != models.CircuitClosed {
+		stats.OpenedAt = cb.openedAt
+	}
+	return stats
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// flakyBackend fails every call while failing is set.
+type flakyBackend struct {
+	failing bool
+	calls   int
+}
+
+func (b *flakyBackend) result() error {
+	b.calls++
+	if b.failing {
+		return errors.New("backend down")
+	}
+	return nil
+}
+
+func (b *flakyBackend) Record(event *models.NavigationEvent) error { return b.result() }
+func (b *flakyBackend) DistinctVisitors(url string) (int, error)   { return 1, b.result() }
+func (b *flakyBackend) PageViews(url string) (int64, error)        { return 1, b.result() }
+func (b *flakyBackend) ForgetVisitor(visitorID string) error       { return b.result() }
+func (b *flakyBackend) DeleteURL(url string) error                 { return b.result() }
+func (b *flakyBackend) MergeURL(source, target string) error       { return b.result() }
+
+func TestCircuitBreaker(t *testing.T) {
+	backend := &flakyBackend{failing: true}
+	breaker := NewCircuitBreaker(backend, models.CircuitBreakerConfig{Enabled: true, FailureThreshold: 3, Cooldown: time.Minute})
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+	event := &models.NavigationEvent{VisitorID: "visitor1", URL: "/home"}
+
+	for i := 0; i < 3; i++ {
+		if err := breaker.Record(event); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Call %d: expected the backend's error, got %v", i, err)
+		}
+	}
+	if state := breaker.Stats().State; state != models.CircuitOpen {
+		t.Fatalf("Expected the breaker to open after 3 failures, got %s", state)
+	}
+
+	if _, err := breaker.DistinctVisitors("/home"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen while open, got %v", err)
+	}
+	if backend.calls != 3 {
+		t.Errorf("Expected the backend not to be called while open, got %d calls", backend.calls)
+	}
+
+	// A failed trial reopens the breaker for another cooldown
+	now = now.Add(time.Minute)
+	if err := breaker.Record(event); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected the trial call to reach the backend, got %v", err)
+	}
+	if _, err := breaker.PageViews("/home"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected the breaker to reopen after a failed trial, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	backend.failing = false
+	if err := breaker.Record(event); err != nil {
+		t.Errorf("Expected the trial call to succeed, got %v", err)
+	}
+
+	stats := breaker.Stats()
+	if stats.State != models.CircuitClosed || stats.ConsecutiveFailures != 0 || stats.Trips != 2 || stats.Rejected != 2 {
+		t.Errorf("Unexpected stats after recovery: %+v", stats)
+	}
+}
+
+func TestCircuitBreaker_ReadsFallBackToLocalCounts(t *testing.T) {
+	tracker := NewNavigationTracker()
+	backend := &flakyBackend{}
+	breaker := NewCircuitBreaker(backend, models.CircuitBreakerConfig{Enabled: true, FailureThreshold: 1, Cooldown: time.Minute})
+	tracker.SetCounterBackend(breaker)
+
+	for _, visitor := range []string{"visitor1", "visitor2"} {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: visitor, URL: "/home"}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	notified := 0
+	tracker.AddListener(func(event models.NavigationEvent) { notified++ })
+
+	// The first failure opens the breaker, which refuses the second event;
+	// both are counted locally and are not errors, so they aren't retried
+	backend.failing = true
+	for _, visitor := range []string{"visitor3", "visitor4"} {
+		degraded, err := tracker.RecordEventStatus(&models.NavigationEvent{VisitorID: visitor, URL: "/home"})
+		if err != nil || !degraded {
+			t.Fatalf("Expected %s to be recorded degraded, got %v (%v)", visitor, degraded, err)
+		}
+	}
+	if breaker.Stats().State != models.CircuitOpen || backend.calls != 3 {
+		t.Errorf("Expected the breaker open after 3 backend calls, got %s after %d", breaker.Stats().State, backend.calls)
+	}
+	if notified != 2 {
+		t.Errorf("Expected listeners notified of both degraded events, got %d", notified)
+	}
+
+	if visitors := tracker.GetDistinctVisitors("/home"); visitors != 4 {
+		t.Errorf("Expected the 4 local visitors while the breaker is open, got %d", visitors)
+	}
+}
@@ -0,0 +1,500 @@
+package storage
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+const (
+	// MaxBulkURLs bounds the URLs one bulk job may list explicitly.
+	MaxBulkURLs = 10000
+
+	// maxRetainedJobs bounds how many jobs BulkJobStore remembers.
+	maxRetainedJobs = 100
+)
+
+// ErrURLFrozen is returned when recording an event for a frozen URL.
+var ErrURLFrozen = errors.New("url is frozen")
+
+// BulkJobStore runs bulk URL operations in the background and keeps the
+// most recent jobs so their results can be polled.
+type BulkJobStore struct {
+	tracker *NavigationTracker
+	jobs    map[string]*models.BulkJob
+	order   []string
+	nextID  int
+	mutex   sync.RWMutex
+}
+
+func NewBulkJobStore(tracker *NavigationTracker) *BulkJobStore {
+	return &BulkJobStore{
+		tracker: tracker,
+		jobs:    make(map[string]*models.BulkJob),
+	}
+}
+
+// bulkPlan is a validated operation ready to run.
+type bulkPlan struct {
+	op      models.BulkOperation
+	urls    []string
+	pattern *regexp.Regexp
+	target  string
+	ttl     time.Duration
+}
+
+// Submit validates op and starts it in the background, returning the queued job.
+func (bs *BulkJobStore) Submit(op models.BulkOperation) (*models.BulkJob, error) {
+	plan, err := bs.tracker.planBulk(op)
+	if err != nil {
+		return nil, err
+	}
+
+	bs.mutex.Lock()
+	bs.nextID++
+	job := &models.BulkJob{
+		ID:          fmt.Sprintf("job_%d", bs.nextID),
+		Status:      models.JobQueued,
+		Operation:   op,
+		SubmittedAt: time.Now().UTC(),
+	}
+	bs.jobs[job.ID] = job
+	bs.order = append(bs.order, job.ID)
+	if len(bs.order) > maxRetainedJobs {
+		delete(bs.jobs, bs.order[0])
+		bs.order = bs.order[1:]
+	}
+	queued := *job
+	bs.mutex.Unlock()
+
+	go bs.run(job, plan)
+
+	return &queued, nil
+}
+
+func (bs *BulkJobStore) run(job *models.BulkJob, plan *bulkPlan) {
+	bs.update(job, func(j *models.BulkJob) {
+		j.Status = models.JobRunning
+		j.StartedAt = time.Now().UTC()
+	})
+
+	urls := plan.urls
+	if plan.pattern != nil {
+		urls = mergeURLLists(urls, bs.tracker.matchURLs(plan.pattern))
+	}
+
+	applied := 0
+	for _, url := range urls {
+		if bs.tracker.applyBulk(plan, url) {
+			applied++
+		}
+	}
+
+	bs.update(job, func(j *models.BulkJob) {
+		j.Status = models.JobCompleted
+		j.FinishedAt = time.Now().UTC()
+		j.Matched = len(urls)
+		j.Applied = applied
+		j.Skipped = len(urls) - applied
+	})
+}
+
+func (bs *BulkJobStore) update(job *models.BulkJob, fn func(*models.BulkJob)) {
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+
+	fn(job)
+}
+
+// Get returns a copy of the job with id.
+func (bs *BulkJobStore) Get(id string) (*models.BulkJob, bool) {
+	bs.mutex.RLock()
+	defer bs.mutex.RUnlock()
+
+	job, exists := bs.jobs[id]
+	if !exists {
+		return nil, false
+	}
+	copied := *job
+	return &copied, true
+}
+
+// List returns copies of the retained jobs, most recent first.
+func (bs *BulkJobStore) List() []*models.BulkJob {
+	bs.mutex.RLock()
+	defer bs.mutex.RUnlock()
+
+	jobs := make([]*models.BulkJob, 0, len(bs.order))
+	for i := len(bs.order) - 1; i >= 0; i-- {
+		copied := *bs.jobs[bs.order[i]]
+		jobs = append(jobs, &copied)
+	}
+	return jobs
+}
+
+func (nt *NavigationTracker) planBulk(op models.BulkOperation) (*bulkPlan, error) {
+	if len(op.URLs) == 0 && op.Pattern == "" {
+		return nil, fmt.Errorf("urls or pattern is required")
+	}
+	if len(op.URLs) > MaxBulkURLs {
+		return nil, fmt.Errorf("at most %d urls may be listed", MaxBulkURLs)
+	}
+
+	plan := &bulkPlan{op: op}
+	for i, url := range op.URLs {
+		normalized, err := nt.normalizeBulkURL(url)
+		if err != nil {
+			return nil, fmt.Errorf("urls[%d]: %w", i, err)
+		}
+		plan.urls = append(plan.urls, normalized)
+	}
+
+	if op.Pattern != "" {
+		pattern, err := regexp.Compile(op.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		plan.pattern = pattern
+	}
+
+	switch op.Operation {
+	case models.BulkDelete, models.BulkFreeze, models.BulkUnfreeze:
+	case models.BulkMerge:
+		target, err := nt.normalizeBulkURL(op.Target)
+		if err != nil {
+			return nil, fmt.Errorf("target: %w", err)
+		}
+		plan.target = target
+	case models.BulkCategorize:
+		if op.Category == "" {
+			return nil, fmt.Errorf("category is required")
+		}
+	case models.BulkRetention:
+		if !nt.retention.enabled() {
+			return nil, fmt.Errorf("retention is not enabled")
+		}
+		ttl, err := time.ParseDuration(op.TTL)
+		if err != nil || ttl < 0 {
+			return nil, fmt.Errorf("ttl must be a non-negative duration")
+		}
+		plan.ttl = ttl
+		if ttl == 0 {
+			plan.ttl = -1
+		}
+	default:
+		return nil, fmt.Errorf("unknown operation %q", op.Operation)
+	}
+
+	return plan, nil
+}
+
+func (nt *NavigationTracker) normalizeBulkURL(url string) (string, error) {
+	if err := nt.Validation().ValidateURL(url); err != nil {
+		return "", err
+	}
+	return models.NormalizeURL(url), nil
+}
+
+// mergeURLLists returns the URLs in either list, without duplicates, in order.
+func mergeURLLists(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	merged := make([]string, 0, len(a)+len(b))
+	for _, url := range append(a, b...) {
+		if !seen[url] {
+			seen[url] = true
+			merged = append(merged, url)
+		}
+	}
+	return merged
+}
+
+// matchURLs returns the tracked URLs matching pattern in sorted order.
+func (nt *NavigationTracker) matchURLs(pattern *regexp.Regexp) []string {
+	var urls []string
+	for _, shard := range nt.shards {
+		shard.mutex.RLock()
+		shard.eachURL(func(url string) {
+			if pattern.MatchString(url) {
+				urls = append(urls, url)
+			}
+		})
+		shard.mutex.RUnlock()
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// applyBulk runs the plan's operation on one URL and reports whether it changed anything.
+func (nt *NavigationTracker) applyBulk(plan *bulkPlan, url string) bool {
+	switch plan.op.Operation {
+	case models.BulkDelete:
+		if !nt.deleteURL(url) {
+			return false
+		}
+		if err := nt.deleteShared(url); err != nil {
+			log.Printf("Failed to delete URL %s from the counter backend: %v", url, err)
+		}
+		return true
+	case models.BulkMerge:
+		if !nt.mergeURL(url, plan.target) {
+			return false
+		}
+		if err := nt.mergeShared(url, plan.target); err != nil {
+			log.Printf("Failed to merge URL %s into %s in the counter backend: %v", url, plan.target, err)
+		}
+		return true
+	case models.BulkCategorize:
+		return nt.categorizeURL(url, plan.op.Category)
+	case models.BulkFreeze:
+		return nt.setFrozen(url, true)
+	case models.BulkUnfreeze:
+		return nt.setFrozen(url, false)
+	case models.BulkRetention:
+		return nt.setRetention(url, plan.ttl)
+	}
+	return false
+}
+
+// deleteURL removes url's statistics. As with retention expiry, session,
+// cohort and funnel history and the running page view total are kept.
+func (nt *NavigationTracker) deleteURL(url string) bool {
+	shard := nt.urlShard(url)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	stats, exists := shard.hot(url)
+	if !exists {
+		return false
+	}
+	nt.removeURL(shard, url, stats)
+	return true
+}
+
+// mergeURL folds source's statistics into target and removes source. Visitors
+// seen on both are counted once; per-country and per-language visitor counts
+// are summed, so a visitor attributed to a country on both URLs is counted
+// there twice.
+func (nt *NavigationTracker) mergeURL(source, target string) bool {
+	if source == target {
+		return false
+	}
+
+	srcShard, dstShard := nt.urlShard(source), nt.urlShard(target)
+	if shardIndex(source) < shardIndex(target) {
+		srcShard.mutex.Lock()
+		dstShard.mutex.Lock()
+	} else {
+		dstShard.mutex.Lock()
+		if srcShard != dstShard {
+			srcShard.mutex.Lock()
+		}
+	}
+	defer func() {
+		if srcShard != dstShard {
+			srcShard.mutex.Unlock()
+		}
+		dstShard.mutex.Unlock()
+	}()
+
+	src, exists := srcShard.hot(source)
+	if !exists {
+		return false
+	}
+
+	dst, _ := dstShard.hot(target)
+	newURL := dst == nil
+	if newURL {
+		dst = &urlStats{
+			visitors:  make(map[string]*visitorRecord),
+			category:  nt.classifier.Classify(target),
+			referrers: make(map[string]*referrerStats),
+			devices:   newDeviceStats(),
+			countries: make(map[string]*countryStats),
+			languages: make(map[string]*languageStats),
+		}
+		dstShard.urls[target] = dst
+		dstShard.indexURL(target)
+	}
+
+	category := dstShard.categories[dst.category]
+	if category == nil {
+		category = &categoryStats{visitors: make(map[string]bool)}
+		dstShard.categories[dst.category] = category
+	}
+	if newURL {
+		category.urls++
+	}
+	category.pageViews += src.pageViews
+
+	var added []string
+	for visitorID, record := range src.visitors {
+		category.visitors[visitorID] = true
+
+		existing := dst.visitors[visitorID]
+		if existing == nil {
+			copied := *record
+			dst.visitors[visitorID] = &copied
+			nt.indexVisitor(visitorID, target)
+			added = append(added, visitorID)
+			continue
+		}
+		if record.firstSeen.Before(existing.firstSeen) {
+			existing.firstSeen = record.firstSeen
+		}
+		if record.lastSeen.After(existing.lastSeen) {
+			existing.lastSeen = record.lastSeen
+		}
+		existing.pageViews += record.pageViews
+	}
+	dst.pageViews += src.pageViews
+
+	for domain, ref := range src.referrers {
+		existing := dst.referrers[domain]
+		if existing == nil {
+			existing = &referrerStats{visitors: make(map[string]bool), firstSeen: ref.firstSeen, lastSeen: ref.lastSeen}
+			dst.referrers[domain] = existing
+		}
+		for visitorID := range ref.visitors {
+			existing.visitors[visitorID] = true
+		}
+		if ref.firstSeen.Before(existing.firstSeen) {
+			existing.firstSeen = ref.firstSeen
+		}
+		if ref.lastSeen.After(existing.lastSeen) {
+			existing.lastSeen = ref.lastSeen
+		}
+	}
+
+	addCounts(dst.devices.browsers, src.devices.browsers)
+	addCounts(dst.devices.operatingSystems, src.devices.operatingSystems)
+	addCounts(dst.devices.deviceTypes, src.devices.deviceTypes)
+
+	for country, cs := range src.countries {
+		existing := dst.countries[country]
+		if existing == nil {
+			existing = &countryStats{}
+			dst.countries[country] = existing
+		}
+		existing.visitors += cs.visitors
+		existing.pageViews += cs.pageViews
+	}
+
+	for language, ls := range src.languages {
+		existing := dst.languages[language]
+		if existing == nil {
+			existing = &languageStats{}
+			dst.languages[language] = existing
+		}
+		existing.visitors += ls.visitors
+		existing.pageViews += ls.pageViews
+	}
+
+	dst.mergeDimensions(src)
+	if src.overflow != nil {
+		if dst.overflow == nil {
+			dst.overflow = src.overflow.snapshot().counter().(*hyperLogLog)
+		} else {
+			dst.overflow = mergeCounters(dst.overflow, src.overflow).(*hyperLogLog)
+		}
+	}
+	nt.patterns.merge(target, newURL, src.pageViews, added)
+	if src.lastRecorded > dst.lastRecorded {
+		dst.lastRecorded = src.lastRecorded
+	}
+	dstShard.resize(target, dst)
+	nt.distribution.update(dst)
+
+	nt.removeURL(srcShard, source, src)
+
+	if nt.topURLs != nil && len(added) > 0 {
+		nt.topURLs.add(target, len(added))
+	}
+	dstShard.trackExpiry(nt.retention, target, dst, dst.lastSeen())
+
+	return true
+}
+
+// categorizeURL moves url and its aggregates to category.
+func (nt *NavigationTracker) categorizeURL(url, category string) bool {
+	shard := nt.urlShard(url)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	stats, exists := shard.hot(url)
+	if !exists {
+		return false
+	}
+	if stats.category == category {
+		return true
+	}
+
+	if previous := shard.categories[stats.category]; previous != nil {
+		previous.urls--
+		previous.pageViews -= stats.pageViews
+	}
+
+	next := shard.categories[category]
+	if next == nil {
+		next = &categoryStats{visitors: make(map[string]bool)}
+		shard.categories[category] = next
+	}
+	next.urls++
+	next.pageViews += stats.pageViews
+	for visitorID := range stats.visitors {
+		next.visitors[visitorID] = true
+	}
+
+	stats.category = category
+	return true
+}
+
+// setFrozen freezes or unfreezes url. A frozen URL rejects new events but
+// keeps its statistics; URLs can be frozen before they are first seen.
+func (nt *NavigationTracker) setFrozen(url string, frozen bool) bool {
+	shard := nt.urlShard(url)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	if shard.frozen[url] == frozen {
+		return false
+	}
+	if frozen {
+		shard.frozen[url] = true
+	} else {
+		delete(shard.frozen, url)
+	}
+	return true
+}
+
+// setRetention overrides the retention policy for url; a negative ttl keeps it forever.
+func (nt *NavigationTracker) setRetention(url string, ttl time.Duration) bool {
+	shard := nt.urlShard(url)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	stats, exists := shard.hot(url)
+	if !exists {
+		return false
+	}
+
+	stats.ttl = ttl
+	if stats.expiry != nil {
+		heap.Remove(&shard.expiries, stats.expiry.index)
+		stats.expiry = nil
+	}
+	shard.trackExpiry(nt.retention, url, stats, stats.lastSeen())
+	return true
+}
+
+func addCounts(dst, src map[string]int64) {
+	for key, count := range src {
+		dst[key] += count
+	}
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func runBulk(t *testing.T, tracker *NavigationTracker, op models.BulkOperation) *models.BulkJob {
+	t.Helper()
+
+	job, err := tracker.BulkJobs().Submit(op)
+	if err != nil {
+		t.Fatalf("Failed to submit job: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		job, _ = tracker.BulkJobs().Get(job.ID)
+		if job.Status == models.JobCompleted {
+			return job
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Job %s did not complete", job.ID)
+	return nil
+}
+
+func TestBulkJobs_Merge(t *testing.T) {
+	tracker := NewNavigationTracker()
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a?utm=1", Referrer: "https://news.example.org/"},
+		{VisitorID: "visitor2", URL: "https://example.com/a?utm=1"},
+		{VisitorID: "visitor1", URL: "https://example.com/a"},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	job := runBulk(t, tracker, models.BulkOperation{
+		Operation: models.BulkMerge,
+		URLs:      []string{"https://example.com/a?utm=1", "https://example.com/missing"},
+		Target:    "https://example.com/a",
+	})
+	if job.Applied != 1 || job.Skipped != 1 {
+		t.Errorf("Expected 1 applied and 1 skipped, got %+v", job)
+	}
+
+	stats := tracker.GetVisitorStats("https://example.com/a")
+	if stats.DistinctVisitors != 2 || stats.TotalPageViews != 3 {
+		t.Errorf("Expected 2 visitors and 3 page views after merge, got %+v", stats)
+	}
+	if tracker.GetDistinctVisitors("https://example.com/a?utm=1") != 0 {
+		t.Errorf("Expected the source URL to be removed")
+	}
+	if referrers := tracker.GetTopReferrers("https://example.com/a", 10); len(referrers) != 1 {
+		t.Errorf("Expected referrers to be merged, got %+v", referrers)
+	}
+
+	profile, _ := tracker.GetVisitorProfile("visitor2")
+	if len(profile.URLs) != 1 || profile.URLs[0].URL != "https://example.com/a" {
+		t.Errorf("Expected visitor2 to be re-indexed on the target, got %+v", profile.URLs)
+	}
+}
+
+func TestBulkJobs_CategorizeAndFreeze(t *testing.T) {
+	tracker := NewNavigationTracker()
+	tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/promo"})
+
+	runBulk(t, tracker, models.BulkOperation{Operation: models.BulkCategorize, Pattern: "promo", Category: "campaign"})
+
+	categories := tracker.GetCategoryStats()
+	for _, category := range categories {
+		if category.Category == "campaign" && (category.URLCount != 1 || category.TotalPageViews != 1) {
+			t.Errorf("Expected campaign to hold the URL, got %+v", category)
+		}
+		if category.Category != "campaign" && category.URLCount != 0 {
+			t.Errorf("Expected %s to be empty, got %+v", category.Category, category)
+		}
+	}
+
+	runBulk(t, tracker, models.BulkOperation{Operation: models.BulkFreeze, URLs: []string{"https://example.com/promo", "https://example.com/next"}})
+
+	for _, url := range []string{"https://example.com/promo", "https://example.com/next"} {
+		err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor2", URL: url})
+		if !errors.Is(err, ErrURLFrozen) {
+			t.Errorf("Expected %s to be frozen, got %v", url, err)
+		}
+	}
+
+	runBulk(t, tracker, models.BulkOperation{Operation: models.BulkUnfreeze, URLs: []string{"https://example.com/promo"}})
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor2", URL: "https://example.com/promo"}); err != nil {
+		t.Errorf("Expected the URL to accept events after unfreezing, got %v", err)
+	}
+}
+
+func TestBulkJobs_Retention(t *testing.T) {
+	tracker := newRetentionTracker()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: base})
+	tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/b", Timestamp: base})
+
+	runBulk(t, tracker, models.BulkOperation{Operation: models.BulkRetention, URLs: []string{"https://example.com/a"}, TTL: "1h"})
+	runBulk(t, tracker, models.BulkOperation{Operation: models.BulkRetention, URLs: []string{"https://example.com/b"}, TTL: "0s"})
+
+	if urls, _ := tracker.ExpireURLs(base.Add(2 * time.Hour)); urls != 1 {
+		t.Errorf("Expected only /a to expire early, got %d URLs", urls)
+	}
+	if urls, _ := tracker.ExpireURLs(base.Add(48 * time.Hour)); urls != 0 {
+		t.Errorf("Expected /b to be kept forever, got %d URLs expired", urls)
+	}
+	if tracker.GetDistinctVisitors("https://example.com/b") != 1 {
+		t.Errorf("Expected /b to remain")
+	}
+}
+
+func TestBulkJobs_Validation(t *testing.T) {
+	tracker := NewNavigationTracker()
+
+	invalid := []models.BulkOperation{
+		{Operation: models.BulkDelete},
+		{Operation: models.BulkMerge, URLs: []string{"https://example.com/a"}},
+		{Operation: models.BulkCategorize, Pattern: "("},
+		{Operation: models.BulkRetention, URLs: []string{"https://example.com/a"}, TTL: "1h"},
+	}
+	for _, op := range invalid {
+		if _, err := tracker.BulkJobs().Submit(op); err == nil {
+			t.Errorf("Expected %+v to be rejected", op)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"hash/fnv"
+	"sync/atomic"
+
+	"nav-tracker/pkg/models"
+)
+
+// validationCanary validates the events of percent of visitors against
+// candidate rules, and counts the events of both sides. Visitors are chosen
+// by hashing their ID, so each stays on one side for the whole canary.
+type validationCanary struct {
+	rules   *models.ValidationRules
+	percent uint32
+	stable  canaryArm
+	canary  canaryArm
+}
+
+// canaryArm counts the events validated against one side of a canary, those
+// refused, and the URLs first recorded from them.
+type canaryArm struct {
+	events   atomic.Int64
+	rejected atomic.Int64
+	newURLs  atomic.Int64
+}
+
+// count records an event validated against the side; a nil arm, for events
+// validated while no canary runs, counts nothing.
+func (a *canaryArm) count(valid bool) {
+	if a == nil {
+		return
+	}
+	a.events.Add(1)
+	if !valid {
+		a.rejected.Add(1)
+	}
+}
+
+func (a *canaryArm) countURL() {
+	if a != nil {
+		a.newURLs.Add(1)
+	}
+}
+
+func (a *canaryArm) stats() models.CanaryArmStats {
+	return models.NewCanaryArmStats(a.events.Load(), a.rejected.Load(), a.newURLs.Load())
+}
+
+// validationFor returns the rules event is validated against and the canary
+// side it is counted on, nil while no canary runs. It must be called before
+// the visitor ID is pseudonymized.
+func (nt *NavigationTracker) validationFor(event *models.NavigationEvent) (*models.ValidationRules, *canaryArm) {
+	canary := nt.canary.Load()
+	if canary == nil {
+		return nt.Validation(), nil
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(event.VisitorID))
+	if hash.Sum32()%100 < canary.percent {
+		return canary.rules, &canary.canary
+	}
+	return nt.Validation(), &canary.stable
+}
+
+// ValidateEvent validates event against the rules of its visitor's canary
+// side, or the tracker's rules while no canary runs. A refused event is
+// counted on its side, so events checked before they are queued are counted
+// once: when refused here, or else when recorded.
+func (nt *NavigationTracker) ValidateEvent(event *models.NavigationEvent) error {
+	rules, arm := nt.validationFor(event)
+	err := event.Validate(rules)
+	if err != nil {
+		arm.count(false)
+	}
+	return err
+}
+
+// StartCanary validates the events of percent of visitors against rules
+// until StopCanary, replacing any canary already running.
+func (nt *NavigationTracker) StartCanary(rules *models.ValidationRules, percent int) {
+	nt.canary.Store(&validationCanary{rules: rules, percent: uint32(percent)})
+}
+
+// Canary returns the rules and percent of the running canary, if any.
+func (nt *NavigationTracker) Canary() (*models.ValidationRules, int, bool) {
+	canary := nt.canary.Load()
+	if canary == nil {
+		return nil, 0, false
+	}
+	return canary.rules, int(canary.percent), true
+}
+
+// CanaryStats returns the events counted on the stable and canary sides of
+// the running canary.
+func (nt *NavigationTracker) CanaryStats() (stable, canary models.CanaryArmStats, ok bool) {
+	running := nt.canary.Load()
+	if running == nil {
+		return stable, canary, false
+	}
+	return running.stable.stats(), running.canary.stats(), true
+}
+
+// StopCanary ends the running canary, validating every event against the
+// tracker's rules again.
+func (nt *NavigationTracker) StopCanary() {
+	nt.canary.Store(nil)
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"regexp"
+	"sort"
+
+	"nav-tracker/pkg/models"
+)
+
+// URLClassifier assigns a category to a URL using ordered pattern rules.
+type URLClassifier struct {
+	rules []compiledCategoryRule
+}
+
+type compiledCategoryRule struct {
+	category string
+	pattern  *regexp.Regexp
+}
+
+type categoryStats struct {
+	visitors  map[string]bool
+	pageViews int64
+	urls      int
+}
+
+// NewURLClassifier compiles rules, skipping any whose pattern does not
+// compile; call Configuration.Validate first to surface those errors.
+func NewURLClassifier(rules []models.CategoryRule) *URLClassifier {
+	classifier := &URLClassifier{}
+	for _, rule := range rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			continue
+		}
+		classifier.rules = append(classifier.rules, compiledCategoryRule{
+			category: rule.Category,
+			pattern:  pattern,
+		})
+	}
+	return classifier
+}
+
+func (c *URLClassifier) Classify(url string) string {
+	for _, rule := range c.rules {
+		if rule.pattern.MatchString(url) {
+			return rule.category
+		}
+	}
+	return models.DefaultURLCategory
+}
+
+// recordCategory updates the shard's category aggregates with views page
+// views; an empty visitorID counts them without a visitor. The caller must
+// hold the shard lock.
+func (s *urlShard) recordCategory(category, visitorID string, newURL bool, views int64) {
+	stats := s.categories[category]
+	if stats == nil {
+		stats = &categoryStats{visitors: make(map[string]bool)}
+		s.categories[category] = stats
+	}
+
+	if visitorID != "" {
+		stats.visitors[visitorID] = true
+	}
+	stats.pageViews += views
+	if newURL {
+		stats.urls++
+	}
+}
+
+// GetCategoryStats returns per-category aggregates sorted by distinct visitors.
+func (nt *NavigationTracker) GetCategoryStats() []*models.CategoryStats {
+	merged := nt.mergedCategories()
+
+	result := make([]*models.CategoryStats, 0, len(merged))
+	for category, stats := range merged {
+		result = append(result, &models.CategoryStats{
+			Category:         category,
+			DistinctVisitors: len(stats.visitors),
+			TotalPageViews:   stats.pageViews,
+			URLCount:         stats.urls,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].DistinctVisitors != result[j].DistinctVisitors {
+			return result[i].DistinctVisitors > result[j].DistinctVisitors
+		}
+		return result[i].Category < result[j].Category
+	})
+
+	return result
+}
+
+// mergedCategories combines every shard's category aggregates.
+func (nt *NavigationTracker) mergedCategories() map[string]*categoryStats {
+	merged := make(map[string]*categoryStats)
+	for _, shard := range nt.shards {
+		shard.mutex.RLock()
+		shard.mergeCategories(merged)
+		shard.mutex.RUnlock()
+	}
+	return merged
+}
+
+// mergeCategories adds the shard's category aggregates to merged, counting a
+// visitor seen in several shards once; the caller must hold the shard lock.
+func (s *urlShard) mergeCategories(merged map[string]*categoryStats) {
+	for category, stats := range s.categories {
+		total := merged[category]
+		if total == nil {
+			total = &categoryStats{visitors: make(map[string]bool)}
+			merged[category] = total
+		}
+		for visitorID := range stats.visitors {
+			total.visitors[visitorID] = true
+		}
+		total.pageViews += stats.pageViews
+		total.urls += stats.urls
+	}
+}
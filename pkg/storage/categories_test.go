@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestURLClassifier_DefaultRules(t *testing.T) {
+	classifier := NewURLClassifier(models.DefaultConfiguration().URLCategories)
+
+	tests := map[string]string{
+		"https://example.com/blog/my-post":     "article",
+		"https://example.com/product/123":      "product",
+		"https://example.com/search?q=shoes":   "search",
+		"https://example.com/shop?query=shoes": "search",
+		"https://example.com/checkout":         "checkout",
+		"https://example.com/cart/items":       "checkout",
+		"https://example.com/api/v1/users":     "api",
+		"https://example.com/about":            models.DefaultURLCategory,
+	}
+
+	for url, want := range tests {
+		if got := classifier.Classify(url); got != want {
+			t.Errorf("Classify(%s): expected %s, got %s", url, want, got)
+		}
+	}
+}
+
+func TestURLClassifier_CustomRulesFirstMatchWins(t *testing.T) {
+	classifier := NewURLClassifier([]models.CategoryRule{
+		{Category: "docs", Pattern: `/docs/`},
+		{Category: "article", Pattern: `/docs/blog/`},
+		{Category: "broken", Pattern: `(`},
+	})
+
+	if got := classifier.Classify("https://example.com/docs/blog/x"); got != "docs" {
+		t.Errorf("Expected docs, got %s", got)
+	}
+}
+
+func TestNavigationTracker_GetCategoryStats(t *testing.T) {
+	tracker := NewNavigationTracker()
+
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/blog/a"},
+		{VisitorID: "visitor1", URL: "https://example.com/blog/b"},
+		{VisitorID: "visitor2", URL: "https://example.com/blog/a"},
+		{VisitorID: "visitor1", URL: "https://example.com/checkout"},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	stats := tracker.GetCategoryStats()
+	if len(stats) != 2 {
+		t.Fatalf("Expected 2 categories, got %d", len(stats))
+	}
+
+	article := stats[0]
+	if article.Category != "article" || article.DistinctVisitors != 2 || article.TotalPageViews != 3 || article.URLCount != 2 {
+		t.Errorf("Unexpected article stats: %+v", article)
+	}
+}
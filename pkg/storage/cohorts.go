@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+const week = 7 * 24 * time.Hour
+
+// CohortStore groups visitors by the week they were first seen and keeps,
+// per cohort, how many of them returned in each subsequent week.
+type CohortStore struct {
+	visitors map[string]*cohortVisitor
+	cohorts  map[int64]*cohort
+	mutex    sync.RWMutex
+}
+
+type cohortVisitor struct {
+	firstWeek   int64
+	activeWeeks map[int]bool
+}
+
+type cohort struct {
+	size     int
+	returned map[int]int
+}
+
+func NewCohortStore() *CohortStore {
+	return &CohortStore{
+		visitors: make(map[string]*cohortVisitor),
+		cohorts:  make(map[int64]*cohort),
+	}
+}
+
+// weekStart returns the Monday 00:00 UTC of the week containing t, as Unix seconds.
+func weekStart(t time.Time) int64 {
+	t = t.UTC()
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -daysSinceMonday).Unix()
+}
+
+func (cs *CohortStore) Record(visitorID string, timestamp time.Time) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	eventWeek := weekStart(timestamp)
+
+	visitor := cs.visitors[visitorID]
+	if visitor == nil {
+		visitor = &cohortVisitor{firstWeek: eventWeek, activeWeeks: map[int]bool{0: true}}
+		cs.visitors[visitorID] = visitor
+
+		c := cs.cohorts[eventWeek]
+		if c == nil {
+			c = &cohort{returned: make(map[int]int)}
+			cs.cohorts[eventWeek] = c
+		}
+		c.size++
+		c.returned[0]++
+		return
+	}
+
+	// Events older than the visitor's cohort week are not re-bucketed.
+	offset := int((eventWeek - visitor.firstWeek) / int64(week/time.Second))
+	if offset <= 0 || visitor.activeWeeks[offset] {
+		return
+	}
+
+	visitor.activeWeeks[offset] = true
+	cs.cohorts[visitor.firstWeek].returned[offset]++
+}
+
+// Matrix returns the retention matrix for the given number of weekly cohorts
+// ending with the week containing asOf. Each row's Retention[i] is the fraction
+// of the cohort seen i weeks after its first week.
+func (cs *CohortStore) Matrix(weeks int, asOf time.Time) []*models.CohortRow {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+
+	currentWeek := weekStart(asOf)
+	weekSeconds := int64(week / time.Second)
+
+	rows := make([]*models.CohortRow, 0, weeks)
+	for i := weeks - 1; i >= 0; i-- {
+		start := currentWeek - int64(i)*weekSeconds
+		row := &models.CohortRow{
+			CohortStart: time.Unix(start, 0).UTC(),
+			Retention:   make([]float64, i+1),
+		}
+
+		if c := cs.cohorts[start]; c != nil {
+			row.Visitors = c.size
+			for offset := 0; offset <= i; offset++ {
+				row.Retention[offset] = float64(c.returned[offset]) / float64(c.size)
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// Forget removes visitorID from its cohort and the weeks it returned in.
+func (cs *CohortStore) Forget(visitorID string) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	visitor := cs.visitors[visitorID]
+	if visitor == nil {
+		return
+	}
+	c := cs.cohorts[visitor.firstWeek]
+	c.size--
+	for offset := range visitor.activeWeeks {
+		c.returned[offset]--
+	}
+	if c.size == 0 {
+		delete(cs.cohorts, visitor.firstWeek)
+	}
+	delete(cs.visitors, visitorID)
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCohortStore_Matrix(t *testing.T) {
+	store := NewCohortStore()
+
+	// Monday 2024-01-01 starts week 0
+	week0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	week1 := week0.AddDate(0, 0, 7)
+	week2 := week0.AddDate(0, 0, 14)
+
+	store.Record("visitor1", week0)
+	store.Record("visitor2", week0.Add(48*time.Hour))
+	store.Record("visitor1", week1)
+	store.Record("visitor1", week1.Add(time.Hour))
+	store.Record("visitor2", week2)
+	store.Record("visitor3", week1)
+
+	rows := store.Matrix(3, week2)
+	if len(rows) != 3 {
+		t.Fatalf("Expected 3 rows, got %d", len(rows))
+	}
+
+	first := rows[0]
+	if !first.CohortStart.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected first cohort to start 2024-01-01, got %v", first.CohortStart)
+	}
+
+	if first.Visitors != 2 {
+		t.Errorf("Expected 2 visitors in first cohort, got %d", first.Visitors)
+	}
+
+	expected := []float64{1, 0.5, 0.5}
+	for i, want := range expected {
+		if first.Retention[i] != want {
+			t.Errorf("Expected retention[%d]=%.2f, got %.2f", i, want, first.Retention[i])
+		}
+	}
+
+	if rows[1].Visitors != 1 || len(rows[1].Retention) != 2 {
+		t.Errorf("Unexpected second cohort: %+v", rows[1])
+	}
+
+	if rows[2].Visitors != 0 {
+		t.Errorf("Expected empty third cohort, got %d visitors", rows[2].Visitors)
+	}
+}
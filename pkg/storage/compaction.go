@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// staleTempAge is how old a temporary snapshot file must be before
+// compaction treats it as left behind by an interrupted write.
+const staleTempAge = time.Hour
+
+// compactionHistorySize caps the runs kept for History; the oldest are
+// dropped first.
+const compactionHistorySize = 50
+
+// Compactor reclaims the disk space held by persisted state. It rewrites
+// cold tier files without the copies promotions left behind, rewrites the
+// snapshot so deleted and expired data no longer lingers in it, and removes
+// temporary snapshot files left by interrupted writes. It runs on demand and,
+// once started, every interval.
+type Compactor struct {
+	tracker  *NavigationTracker
+	flusher  *SnapshotFlusher
+	interval time.Duration
+
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+	started  atomic.Bool
+
+	mutex           sync.Mutex // serialises compactions and guards the fields below
+	runs            int64
+	failedRuns      int64
+	reclaimedBytes  int64
+	droppedURLs     int64
+	droppedVisitors int64
+	last            *models.CompactionResult
+	lastError       string
+	history         []*models.CompactionResult
+}
+
+// NewCompactor returns a compactor for tracker's cold tier and, when flusher
+// is not nil, its snapshot.
+func NewCompactor(tracker *NavigationTracker, flusher *SnapshotFlusher, config models.CompactionConfig) *Compactor {
+	return &Compactor{
+		tracker:  tracker,
+		flusher:  flusher,
+		interval: config.Interval,
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+}
+
+func (c *Compactor) Start() {
+	if c.interval <= 0 || !c.started.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer close(c.doneCh)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-c.stopCh:
+				return
+			}
+
+			result, err := c.compact(models.CompactionScheduled)
+			if err != nil {
+				log.Printf("Compaction failed: %v", err)
+			} else if result.ReclaimedBytes > 0 {
+				log.Printf("Compaction reclaimed %d bytes in %v", result.ReclaimedBytes, result.Duration)
+			}
+		}
+	}()
+}
+
+// Stop ends scheduled compaction and waits for an in-flight run.
+func (c *Compactor) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stopCh)
+	})
+	if c.started.Load() {
+		<-c.doneCh
+	}
+}
+
+// Compact runs a compaction now, waiting for any in-flight run first.
+func (c *Compactor) Compact() (*models.CompactionResult, error) {
+	return c.compact(models.CompactionManual)
+}
+
+func (c *Compactor) compact(trigger string) (*models.CompactionResult, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	result := &models.CompactionResult{Trigger: trigger, StartedAt: time.Now().UTC()}
+	err := c.run(result)
+	result.Duration = time.Since(result.StartedAt)
+
+	c.runs++
+	c.reclaimedBytes += result.ReclaimedBytes
+	c.droppedURLs += int64(result.DroppedURLs)
+	c.droppedVisitors += result.DroppedVisitorRecords
+	c.history = append(c.history, result)
+	if len(c.history) > compactionHistorySize {
+		c.history = c.history[1:]
+	}
+	if err != nil {
+		result.Error = err.Error()
+		c.failedRuns++
+		c.lastError = err.Error()
+		return nil, err
+	}
+	c.last = result
+	c.lastError = ""
+	return result, nil
+}
+
+func (c *Compactor) run(result *models.CompactionResult) error {
+	files, reclaimed, err := c.tracker.compactTiers()
+	result.ColdFiles = files
+	result.ReclaimedBytes += reclaimed
+	if err != nil || c.flusher == nil {
+		return err
+	}
+
+	path := c.flusher.path
+	before := fileSize(path)
+	// A snapshot that cannot be read, such as the first, drops nothing
+	previous, _ := readSnapshotRecords(path)
+	if err := c.flusher.Flush(); err != nil {
+		return err
+	}
+	result.SnapshotBytes = fileSize(path)
+	if before > result.SnapshotBytes {
+		result.ReclaimedBytes += before - result.SnapshotBytes
+	}
+	written, err := readSnapshotRecords(path)
+	if err != nil {
+		return err
+	}
+	result.URLs = len(written)
+	for _, visitors := range written {
+		result.VisitorRecords += int64(len(visitors))
+	}
+	for url, visitors := range previous {
+		kept, exists := written[url]
+		if !exists {
+			result.DroppedURLs++
+		}
+		for visitor := range visitors {
+			if _, exists := kept[visitor]; !exists {
+				result.DroppedVisitorRecords++
+			}
+		}
+	}
+
+	temps, err := filepath.Glob(filepath.Join(filepath.Dir(path), filepath.Base(path)+".tmp*"))
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-staleTempAge)
+	for _, temp := range temps {
+		info, err := os.Stat(temp)
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(temp); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		result.TempFiles++
+		result.ReclaimedBytes += info.Size()
+	}
+	return nil
+}
+
+func (c *Compactor) Stats() *models.CompactionStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return &models.CompactionStats{
+		Interval:              c.interval,
+		Runs:                  c.runs,
+		FailedRuns:            c.failedRuns,
+		ReclaimedBytes:        c.reclaimedBytes,
+		DroppedURLs:           c.droppedURLs,
+		DroppedVisitorRecords: c.droppedVisitors,
+		Last:                  c.last,
+		LastError:             c.lastError,
+	}
+}
+
+// History returns the last runs, failed ones included, newest first.
+func (c *Compactor) History() []*models.CompactionResult {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	history := make([]*models.CompactionResult, len(c.history))
+	for i, result := range c.history {
+		history[len(history)-1-i] = result
+	}
+	return history
+}
+
+// readSnapshotRecords returns the visitor IDs of each URL in the snapshot at
+// path, without decoding the rest.
+func readSnapshotRecords(path string) (map[string]map[string]struct{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snap struct {
+		URLs map[string]struct {
+			Visitors map[string]struct{} `json:"visitors"`
+		} `json:"urls"`
+	}
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, err
+	}
+	records := make(map[string]map[string]struct{}, len(snap.URLs))
+	for url, us := range snap.URLs {
+		records[url] = us.Visitors
+	}
+	return records, nil
+}
+
+// fileSize returns the size of the file at path, or 0 if it cannot be read.
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestCompactor_Compact(t *testing.T) {
+	tracker := newTieringTracker(t, 1000)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/", Timestamp: base}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+	if demoted := tracker.DemoteIdleURLs(base.Add(2 * time.Hour)); demoted != 1 {
+		t.Fatalf("Expected 1 URL demoted, got %d", demoted)
+	}
+	// Promoting the URL leaves its copy in the cold file
+	tracker.GetDistinctVisitors("https://example.com/")
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	flusher := NewSnapshotFlusher(tracker, models.SnapshotConfig{Path: path})
+	stale := path + ".tmp123"
+	if err := os.WriteFile(stale, []byte("partial"), 0o644); err != nil {
+		t.Fatalf("Failed to write temp file: %v", err)
+	}
+	old := time.Now().Add(-2 * staleTempAge)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatalf("Failed to age temp file: %v", err)
+	}
+
+	compactor := NewCompactor(tracker, flusher, models.CompactionConfig{})
+	result, err := compactor.Compact()
+	if err != nil {
+		t.Fatalf("Failed to compact: %v", err)
+	}
+	if result.Trigger != models.CompactionManual || result.ColdFiles != 1 || result.TempFiles != 1 {
+		t.Errorf("Expected 1 cold file and 1 temp file compacted, got %+v", result)
+	}
+	if result.SnapshotBytes == 0 || result.ReclaimedBytes <= int64(len("partial")) {
+		t.Errorf("Expected a snapshot written and cold space reclaimed, got %+v", result)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("Expected stale temp file removed, got %v", err)
+	}
+
+	// Nothing is left to reclaim the second time. The snapshot is rewritten
+	// with fresh timestamps, so its size may still shift by a few bytes.
+	if result, err = compactor.Compact(); err != nil || result.ColdFiles != 0 || result.TempFiles != 0 || result.ReclaimedBytes > 16 {
+		t.Errorf("Expected nothing reclaimed, got %+v: %v", result, err)
+	}
+
+	stats := compactor.Stats()
+	if stats.Runs != 2 || stats.FailedRuns != 0 || stats.Last == nil || stats.ReclaimedBytes == 0 {
+		t.Errorf("Expected 2 runs recorded, got %+v", stats)
+	}
+}
+
+func TestCompactor_Scheduled(t *testing.T) {
+	compactor := NewCompactor(NewNavigationTracker(), nil, models.CompactionConfig{Interval: 10 * time.Millisecond})
+	compactor.Start()
+
+	deadline := time.Now().Add(time.Second)
+	for compactor.Stats().Runs == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	compactor.Stop()
+	compactor.Stop()
+
+	stats := compactor.Stats()
+	if stats.Runs == 0 || stats.Last == nil || stats.Last.Trigger != models.CompactionScheduled {
+		t.Errorf("Expected a scheduled compaction, got %+v", stats)
+	}
+}
+
+func TestCompactor_CountsDroppedRecords(t *testing.T) {
+	tracker := NewNavigationTracker()
+	for _, event := range []models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a"},
+		{VisitorID: "visitor2", URL: "https://example.com/a"},
+		{VisitorID: "visitor1", URL: "https://example.com/b"},
+	} {
+		if err := tracker.RecordEvent(&event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	flusher := NewSnapshotFlusher(tracker, models.SnapshotConfig{Path: filepath.Join(t.TempDir(), "snapshot.json")})
+	compactor := NewCompactor(tracker, flusher, models.CompactionConfig{})
+	result, err := compactor.Compact()
+	if err != nil {
+		t.Fatalf("Failed to compact: %v", err)
+	}
+	if result.URLs != 2 || result.VisitorRecords != 3 || result.DroppedURLs != 0 || result.DroppedVisitorRecords != 0 {
+		t.Errorf("Expected 2 URLs and 3 visitor records, none dropped, got %+v", result)
+	}
+
+	tracker.DeleteURL("https://example.com/b", "test")
+	tracker.DeleteVisitor("visitor2", "test")
+	if result, err = compactor.Compact(); err != nil {
+		t.Fatalf("Failed to compact: %v", err)
+	}
+	if result.URLs != 1 || result.VisitorRecords != 1 || result.DroppedURLs != 1 || result.DroppedVisitorRecords != 2 {
+		t.Errorf("Expected 1 URL and 2 visitor records dropped, got %+v", result)
+	}
+
+	stats := compactor.Stats()
+	if stats.DroppedURLs != 1 || stats.DroppedVisitorRecords != 2 {
+		t.Errorf("Expected the dropped records totalled, got %+v", stats)
+	}
+	if history := compactor.History(); len(history) != 2 || history[0] != result {
+		t.Errorf("Expected both runs listed, newest first, got %+v", history)
+	}
+}
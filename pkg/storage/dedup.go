@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// dedupCache remembers recently recorded EventIDs so that events resent by
+// retrying clients are only counted once. It holds at most size IDs, each
+// for ttl after it was first seen, evicting the oldest first.
+type dedupCache struct {
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List // of *dedupEntry, oldest at the back
+	hits    int64
+	mutex   sync.Mutex
+}
+
+type dedupEntry struct {
+	id     string
+	seenAt time.Time
+}
+
+// newDedupCache returns nil when deduplication is disabled.
+func newDedupCache(config models.DedupConfig) *dedupCache {
+	if config.Size <= 0 {
+		return nil
+	}
+	return &dedupCache{
+		size:    config.Size,
+		ttl:     config.TTL,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// seen reports whether id was recorded within the TTL, and records it if not.
+func (c *dedupCache) seen(id string, now time.Time) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.evictExpired(now)
+
+	if _, exists := c.entries[id]; exists {
+		c.hits++
+		return true
+	}
+
+	c.entries[id] = c.order.PushFront(&dedupEntry{id: id, seenAt: now})
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	return false
+}
+
+// contains reports whether id was recorded within the TTL without recording it.
+func (c *dedupCache) contains(id string, now time.Time) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, exists := c.entries[id]
+	return exists && now.Sub(element.Value.(*dedupEntry).seenAt) < c.ttl
+}
+
+// forget drops id, so that an event whose recording failed can be retried.
+func (c *dedupCache) forget(id string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, exists := c.entries[id]; exists {
+		c.remove(element)
+	}
+}
+
+func (c *dedupCache) evictExpired(now time.Time) {
+	for element := c.order.Back(); element != nil; element = c.order.Back() {
+		if now.Sub(element.Value.(*dedupEntry).seenAt) < c.ttl {
+			return
+		}
+		c.remove(element)
+	}
+}
+
+func (c *dedupCache) remove(element *list.Element) {
+	delete(c.entries, element.Value.(*dedupEntry).id)
+	c.order.Remove(element)
+}
+
+func (c *dedupCache) stats() *models.DedupStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return &models.DedupStats{Hits: c.hits, TrackedIDs: c.order.Len()}
+}
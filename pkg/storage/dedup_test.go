@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestNavigationTracker_DeduplicatesEventIDs(t *testing.T) {
+	tracker := NewNavigationTracker()
+	url := "https://example.com/page"
+
+	var notified int
+	tracker.AddListener(func(models.NavigationEvent) { notified++ })
+
+	for i := 0; i < 3; i++ {
+		if err := tracker.RecordEvent(&models.NavigationEvent{EventID: "event-1", VisitorID: "visitor1", URL: url}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+	// Events without an EventID are never deduplicated
+	for i := 0; i < 2; i++ {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	if stats := tracker.GetVisitorStats(url); stats.TotalPageViews != 3 {
+		t.Errorf("Expected 3 page views, got %d", stats.TotalPageViews)
+	}
+	if notified != 3 {
+		t.Errorf("Expected listeners to be notified of 3 events, got %d", notified)
+	}
+
+	stats := tracker.GetSystemStats()
+	if stats.Dedup == nil || stats.Dedup.Hits != 2 || stats.Dedup.TrackedIDs != 1 {
+		t.Errorf("Expected 2 dedup hits and 1 tracked ID, got %+v", stats.Dedup)
+	}
+}
+
+func TestNavigationTracker_DedupForgetsFailedEvents(t *testing.T) {
+	tracker := NewNavigationTracker()
+	url := "https://example.com/page"
+
+	tracker.setFrozen(url, true)
+	if err := tracker.RecordEvent(&models.NavigationEvent{EventID: "event-1", VisitorID: "visitor1", URL: url}); err == nil {
+		t.Fatal("Expected event for frozen URL to fail")
+	}
+
+	tracker.setFrozen(url, false)
+	if err := tracker.RecordEvent(&models.NavigationEvent{EventID: "event-1", VisitorID: "visitor1", URL: url}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+	if stats := tracker.GetVisitorStats(url); stats.TotalPageViews != 1 {
+		t.Errorf("Expected retried event to be counted, got %d page views", stats.TotalPageViews)
+	}
+}
+
+func TestDedupCache_Eviction(t *testing.T) {
+	cache := newDedupCache(models.DedupConfig{Size: 2, TTL: time.Minute})
+	now := time.Now()
+
+	cache.seen("a", now)
+	cache.seen("b", now)
+	cache.seen("c", now)
+	if cache.seen("a", now) {
+		t.Error("Expected oldest ID to be evicted once the cache is full")
+	}
+
+	if !cache.seen("c", now.Add(30*time.Second)) {
+		t.Error("Expected ID within the TTL to be a duplicate")
+	}
+	if cache.seen("c", now.Add(2*time.Minute)) {
+		t.Error("Expected ID past the TTL to be recorded again")
+	}
+
+	if newDedupCache(models.DedupConfig{Size: 0}) != nil {
+		t.Error("Expected zero size to disable deduplication")
+	}
+}
@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// deletionLogSize caps the deletion audit log; the oldest entries are dropped first.
+const deletionLogSize = 1000
+
+// deletionLog is the audit trail of DeleteVisitor and DeleteURL calls.
+// unreached lists the copies of events outside the tracker that deletions
+// cannot purge.
+type deletionLog struct {
+	entries   []*models.DeletionAuditEntry
+	unreached []string
+	mutex     sync.RWMutex
+}
+
+// AddExternalCopy records that the tracker's events are also kept by name,
+// one of the models.DeletionCopy kinds, which deletions cannot purge. It
+// must be called before the tracker starts receiving events.
+func (nt *NavigationTracker) AddExternalCopy(name string) {
+	nt.deletions.unreached = append(nt.deletions.unreached, name)
+}
+
+func (dl *deletionLog) record(entry *models.DeletionAuditEntry) {
+	entry.Unreached = dl.unreached
+
+	dl.mutex.Lock()
+	defer dl.mutex.Unlock()
+
+	dl.entries = append(dl.entries, entry)
+	if len(dl.entries) > deletionLogSize {
+		dl.entries = dl.entries[len(dl.entries)-deletionLogSize:]
+	}
+}
+
+// DeletionLog returns the deletion audit log, oldest first.
+func (nt *NavigationTracker) DeletionLog() []*models.DeletionAuditEntry {
+	nt.deletions.mutex.RLock()
+	defer nt.deletions.mutex.RUnlock()
+
+	return append([]*models.DeletionAuditEntry{}, nt.deletions.entries...)
+}
+
+// DeleteVisitor removes every trace of visitorID: its visitor records and
+// page views on each URL, its membership of category and referrer visitor
+// sets, the visitor index, properties and language, sessions, cohorts,
+// active visitor history and the counter backend's records. URLs left
+// without visitors or page views are removed. Aggregate device, country and
+// per-URL language counts are kept, as they do not identify the visitor.
+// Copies of the visitor's events outside the tracker, in the archive or the
+// Kafka topics and syslog senders they came from, are not reached. The
+// deletion is appended to the audit log with actor, and the entry returned.
+func (nt *NavigationTracker) DeleteVisitor(visitorID, actor string) *models.DeletionAuditEntry {
+	entry := &models.DeletionAuditEntry{
+		Time:   time.Now().UTC(),
+		Kind:   models.DeletionVisitor,
+		Target: visitorID,
+		Actor:  actor,
+	}
+
+	vs := nt.visitorShard(visitorID)
+	vs.mutex.RLock()
+	urls := make([]string, 0, len(vs.urls[visitorID]))
+	for url := range vs.urls[visitorID] {
+		urls = append(urls, url)
+	}
+	vs.mutex.RUnlock()
+
+	for _, url := range urls {
+		if pageViews, ok := nt.deleteVisitorRecord(url, visitorID); ok {
+			entry.URLs++
+			entry.PageViews += pageViews
+		}
+	}
+	if entry.URLs > 0 {
+		entry.Visitors = 1
+	}
+
+	vs.mutex.Lock()
+	delete(vs.urls, visitorID)
+	delete(vs.properties, visitorID)
+	delete(vs.languages, visitorID)
+	vs.mutex.Unlock()
+
+	entry.Sessions = nt.sessions.ForgetVisitor(visitorID)
+	nt.cohorts.Forget(visitorID)
+	nt.actives.ForgetVisitor(visitorID)
+	nt.realtime.forgetVisitor(visitorID)
+
+	if err := nt.forgetShared(visitorID); err != nil {
+		log.Printf("Failed to delete visitor from the counter backend: %v", err)
+		entry.BackendError = err.Error()
+	}
+
+	nt.deletions.record(entry)
+	return entry
+}
+
+// deleteVisitorRecord removes visitorID from url and returns the page views
+// removed with it.
+func (nt *NavigationTracker) deleteVisitorRecord(url, visitorID string) (int64, bool) {
+	shard := nt.urlShard(url)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	defer shard.compactTier()
+
+	stats, exists := shard.hot(url)
+	if !exists {
+		return 0, false
+	}
+	record := stats.visitors[visitorID]
+	if record == nil {
+		return 0, false
+	}
+
+	delete(stats.visitors, visitorID)
+	stats.pageViews -= record.pageViews
+	for domain, ref := range stats.referrers {
+		delete(ref.visitors, visitorID)
+		if len(ref.visitors) == 0 {
+			delete(stats.referrers, domain)
+		}
+	}
+	if category := shard.categories[stats.category]; category != nil {
+		delete(category.visitors, visitorID)
+		category.pageViews -= record.pageViews
+	}
+	nt.patterns.removeVisitor(url, visitorID, record.pageViews)
+
+	if len(stats.visitors) == 0 && stats.pageViews <= 0 {
+		nt.removeURL(shard, url, stats)
+	} else {
+		shard.resize(url, stats)
+		nt.distribution.update(stats)
+	}
+	return record.pageViews, true
+}
+
+// DeleteURL removes url and everything recorded for it, including its
+// visitors' links to it, its page views in sessions and active visitor
+// history, and its counters in the counter backend. The deletion is
+// appended to the audit log with actor, and the entry returned.
+func (nt *NavigationTracker) DeleteURL(url, actor string) *models.DeletionAuditEntry {
+	entry := &models.DeletionAuditEntry{
+		Time:   time.Now().UTC(),
+		Kind:   models.DeletionURL,
+		Target: url,
+		Actor:  actor,
+	}
+
+	shard := nt.urlShard(url)
+	shard.mutex.Lock()
+	if stats, exists := shard.hot(url); exists {
+		entry.URLs = 1
+		entry.Visitors = len(stats.visitors)
+		entry.PageViews = stats.pageViews
+		shard.forgetCategoryVisitors(url, stats)
+		nt.removeURL(shard, url, stats)
+		shard.compactTier()
+	}
+	shard.mutex.Unlock()
+
+	nt.sessions.ForgetURL(url)
+	nt.actives.ForgetURL(url)
+	nt.realtime.forgetURL(url)
+	// A URL with events other than page views only is not in its shard
+	nt.events.removeURL(url)
+	nt.schemas.removeURL(url)
+
+	if err := nt.deleteShared(url); err != nil {
+		log.Printf("Failed to delete URL %s from the counter backend: %v", url, err)
+		entry.BackendError = err.Error()
+	}
+
+	nt.deletions.record(entry)
+	return entry
+}
+
+// forgetCategoryVisitors removes from url's category the visitors who viewed
+// no other URL of that category in the shard; the caller must hold the shard
+// write lock.
+func (s *urlShard) forgetCategoryVisitors(url string, stats *urlStats) {
+	category := s.categories[stats.category]
+	if category == nil {
+		return
+	}
+
+	only := make(map[string]bool, len(stats.visitors))
+	for visitorID := range stats.visitors {
+		only[visitorID] = true
+	}
+	s.eachURL(func(other string) {
+		if other == url || len(only) == 0 {
+			return
+		}
+		if otherStats, ok := s.lookup(other); ok && otherStats.category == stats.category {
+			for visitorID := range otherStats.visitors {
+				delete(only, visitorID)
+			}
+		}
+	})
+
+	for visitorID := range only {
+		delete(category.visitors, visitorID)
+	}
+}
+
+// compactTier rewrites the shard's cold file if promotions left stale copies
+// in it, so deleted data does not linger on disk; the caller must hold the
+// shard write lock.
+func (s *urlShard) compactTier() {
+	if s.tier == nil || s.tier.garbage == 0 {
+		return
+	}
+	if err := s.tier.compact(); err != nil {
+		log.Printf("Failed to compact cold file %s: %v", s.tier.path, err)
+	}
+}
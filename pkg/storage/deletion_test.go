@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func recordDeletionEvents(t *testing.T, tracker *NavigationTracker) time.Time {
+	now := time.Now().UTC()
+	events := []*models.NavigationEvent{
+		{VisitorID: "alice", URL: "https://example.com/a", Referrer: "https://google.com/", Timestamp: now},
+		{VisitorID: "alice", URL: "https://example.com/a", Timestamp: now},
+		{VisitorID: "alice", URL: "https://example.com/b", Timestamp: now},
+		{VisitorID: "bob", URL: "https://example.com/a", Timestamp: now},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+	return now
+}
+
+func TestNavigationTracker_DeleteVisitor(t *testing.T) {
+	tracker := NewNavigationTracker()
+	now := recordDeletionEvents(t, tracker)
+
+	entry := tracker.DeleteVisitor("alice", "127.0.0.1:1234")
+	if entry.URLs != 2 || entry.Visitors != 1 || entry.PageViews != 3 || entry.Sessions != 1 {
+		t.Errorf("Unexpected deletion entry %+v", entry)
+	}
+
+	if _, exists := tracker.GetVisitorProfile("alice"); exists {
+		t.Error("Expected visitor profile to be gone")
+	}
+	stats := tracker.GetVisitorStats("https://example.com/a")
+	if stats.DistinctVisitors != 1 || stats.TotalPageViews != 1 {
+		t.Errorf("Expected only bob's page view to remain, got %d visitors and %d views", stats.DistinctVisitors, stats.TotalPageViews)
+	}
+	if tracker.GetSystemStats().TotalUniqueURLs != 1 {
+		t.Error("Expected URL viewed only by the deleted visitor to be removed")
+	}
+	if referrers := tracker.GetTopReferrers("https://example.com/a", 10); len(referrers) != 0 {
+		t.Errorf("Expected the visitor's referrer to be removed, got %d", len(referrers))
+	}
+	for _, category := range tracker.GetCategoryStats() {
+		if category.DistinctVisitors != 1 || category.TotalPageViews != 1 {
+			t.Errorf("Expected category to count only bob, got %+v", category)
+		}
+	}
+	if sessions := tracker.Sessions().GetVisitorSessions("alice"); len(sessions) != 0 {
+		t.Errorf("Expected sessions to be removed, got %d", len(sessions))
+	}
+	if actives, _ := tracker.Actives().Count("", models.ActiveWindowDay, now); actives.Visitors != 1 {
+		t.Errorf("Expected 1 active visitor, got %d", actives.Visitors)
+	}
+	if rows := tracker.Cohorts().Matrix(1, now); len(rows) != 1 || rows[0].Visitors != 1 {
+		t.Errorf("Expected cohort of 1 visitor, got %+v", rows)
+	}
+
+	log := tracker.DeletionLog()
+	if len(log) != 1 || log[0].Target != "alice" || log[0].Actor != "127.0.0.1:1234" {
+		t.Errorf("Expected deletion to be audited, got %+v", log)
+	}
+}
+
+func TestNavigationTracker_DeletionListsUnreachedCopies(t *testing.T) {
+	tracker := NewNavigationTracker()
+	tracker.AddExternalCopy(models.DeletionCopyArchive)
+	tracker.AddExternalCopy(models.DeletionCopyKafka)
+	recordDeletionEvents(t, tracker)
+
+	entry := tracker.DeleteVisitor("alice", "test")
+	if len(entry.Unreached) != 2 || entry.Unreached[0] != models.DeletionCopyArchive || entry.Unreached[1] != models.DeletionCopyKafka {
+		t.Errorf("Expected the archive and Kafka listed as unreached, got %v", entry.Unreached)
+	}
+	if entry = NewNavigationTracker().DeleteURL("https://example.com/a", "test"); entry.Unreached != nil {
+		t.Errorf("Expected nothing unreached without external copies, got %v", entry.Unreached)
+	}
+}
+
+func TestNavigationTracker_DeleteURL(t *testing.T) {
+	tracker := NewNavigationTracker()
+	now := recordDeletionEvents(t, tracker)
+
+	entry := tracker.DeleteURL("https://example.com/a", "admin")
+	if entry.URLs != 1 || entry.Visitors != 2 || entry.PageViews != 3 {
+		t.Errorf("Unexpected deletion entry %+v", entry)
+	}
+
+	if tracker.GetDistinctVisitors("https://example.com/a") != 0 {
+		t.Error("Expected URL to be gone")
+	}
+	profile, exists := tracker.GetVisitorProfile("alice")
+	if !exists || len(profile.URLs) != 1 {
+		t.Errorf("Expected alice to keep only the other URL, got %+v", profile)
+	}
+	if _, exists := tracker.GetVisitorProfile("bob"); exists {
+		t.Error("Expected bob's profile to be gone with his only URL")
+	}
+	for _, category := range tracker.GetCategoryStats() {
+		if category.DistinctVisitors != 1 || category.URLCount != 1 {
+			t.Errorf("Expected category to keep alice only, got %+v", category)
+		}
+	}
+	if tracker.Sessions().GetURLSessionCount("https://example.com/a") != 0 {
+		t.Error("Expected URL to be removed from sessions")
+	}
+	if actives, _ := tracker.Actives().Count("https://example.com/a", models.ActiveWindowDay, now); actives.Visitors != 0 {
+		t.Errorf("Expected no active visitors on the URL, got %d", actives.Visitors)
+	}
+}
+
+func TestNavigationTracker_DeleteColdURL(t *testing.T) {
+	tracker := newTieringTracker(t, 1000)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	url := "https://example.com/page"
+
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "alice", URL: url, Timestamp: base}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+	tracker.DemoteIdleURLs(base.Add(2 * time.Hour))
+
+	tracker.DeleteVisitor("alice", "admin")
+
+	shard := tracker.urlShard(url)
+	if shard.coldEntry(url) != nil || shard.tier.size != 0 {
+		t.Errorf("Expected cold copy to be removed from disk, got %d bytes", shard.tier.size)
+	}
+}
+
+func TestNavigationTracker_DeletionLogSnapshot(t *testing.T) {
+	tracker := NewNavigationTracker()
+	tracker.DeleteVisitor("alice", "admin")
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := tracker.SaveSnapshot(path); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	restored := NewNavigationTracker()
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if len(restored.DeletionLog()) != 1 {
+		t.Error("Expected deletion log to be restored")
+	}
+
+	restored.Purge()
+	if len(restored.DeletionLog()) != 1 {
+		t.Error("Expected purge to keep the deletion log")
+	}
+}
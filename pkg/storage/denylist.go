@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"nav-tracker/pkg/models"
+)
+
+// ErrDenied is returned when recording an event whose URL or referrer is denylisted.
+var ErrDenied = errors.New("event is denylisted")
+
+// ErrDenyRuleExists is returned when adding a rule already on its list.
+var ErrDenyRuleExists = errors.New("deny rule already exists")
+
+// DenyListener is called with the name of the list that rejected an event.
+type DenyListener func(list string)
+
+// denylist rejects events by URL or referrer domain. Its rules can be
+// replaced while events are being recorded.
+type denylist struct {
+	mutex     sync.RWMutex
+	urls      []compiledDenyRule
+	referrers []compiledDenyRule
+	listeners []DenyListener
+
+	rejectedURLs      atomic.Int64
+	rejectedReferrers atomic.Int64
+}
+
+type compiledDenyRule struct {
+	rule    models.DenyRule
+	value   string
+	pattern *regexp.Regexp
+}
+
+// compileDenyRule prepares rule for matching values of list. Exact URL rules
+// are normalized like event URLs so that they match them.
+func compileDenyRule(list string, rule models.DenyRule) (compiledDenyRule, error) {
+	if err := rule.Validate(); err != nil {
+		return compiledDenyRule{}, err
+	}
+
+	compiled := compiledDenyRule{rule: rule, value: strings.ToLower(rule.Pattern)}
+	switch rule.Match {
+	case models.DenyExact:
+		if list == models.DenylistURLs {
+			compiled.value = models.NormalizeURL(rule.Pattern)
+		}
+	case models.DenyRegex:
+		compiled.pattern = regexp.MustCompile(rule.Pattern)
+	}
+	return compiled, nil
+}
+
+func (r compiledDenyRule) matches(value string) bool {
+	switch r.rule.Match {
+	case models.DenyExact:
+		return value == r.value
+	case models.DenyPrefix:
+		return strings.HasPrefix(value, r.value)
+	default:
+		return r.pattern.MatchString(value)
+	}
+}
+
+func compileDenyRules(list string, rules []models.DenyRule) ([]compiledDenyRule, error) {
+	compiled := make([]compiledDenyRule, 0, len(rules))
+	for i, rule := range rules {
+		c, err := compileDenyRule(list, rule)
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", list, i, err)
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// newDenylist compiles config, skipping invalid rules; call
+// Configuration.Validate first to surface those errors.
+func newDenylist(config models.DenylistConfig) *denylist {
+	d := &denylist{}
+	for _, rule := range config.URLs {
+		if c, err := compileDenyRule(models.DenylistURLs, rule); err == nil {
+			d.urls = append(d.urls, c)
+		}
+	}
+	for _, rule := range config.Referrers {
+		if c, err := compileDenyRule(models.DenylistReferrers, rule); err == nil {
+			d.referrers = append(d.referrers, c)
+		}
+	}
+	return d
+}
+
+// list returns the rules of the named list; the caller must hold the mutex.
+func (d *denylist) list(name string) (*[]compiledDenyRule, error) {
+	switch name {
+	case models.DenylistURLs:
+		return &d.urls, nil
+	case models.DenylistReferrers:
+		return &d.referrers, nil
+	}
+	return nil, fmt.Errorf("list must be %s or %s", models.DenylistURLs, models.DenylistReferrers)
+}
+
+// check returns the name of the list that denies event, or "" if none does.
+// The event's URL must already be normalized.
+func (d *denylist) check(event *models.NavigationEvent) string {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	for _, rule := range d.urls {
+		if rule.matches(event.URL) {
+			return models.DenylistURLs
+		}
+	}
+	if len(d.referrers) > 0 {
+		if domain := event.ReferrerDomain(); domain != "" {
+			for _, rule := range d.referrers {
+				if rule.matches(domain) {
+					return models.DenylistReferrers
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// deny counts an event rejected by list and notifies the listeners.
+func (d *denylist) deny(list string) {
+	if list == models.DenylistURLs {
+		d.rejectedURLs.Add(1)
+	} else {
+		d.rejectedReferrers.Add(1)
+	}
+
+	d.mutex.RLock()
+	listeners := d.listeners
+	d.mutex.RUnlock()
+	for _, listener := range listeners {
+		listener(list)
+	}
+}
+
+// AddDenyListener registers fn to be notified of events rejected by the denylist.
+func (nt *NavigationTracker) AddDenyListener(fn DenyListener) {
+	nt.denylist.mutex.Lock()
+	defer nt.denylist.mutex.Unlock()
+	nt.denylist.listeners = append(nt.denylist.listeners, fn)
+}
+
+// Denylist returns the current deny rules.
+func (nt *NavigationTracker) Denylist() models.DenylistConfig {
+	d := nt.denylist
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	config := models.DenylistConfig{
+		URLs:      make([]models.DenyRule, len(d.urls)),
+		Referrers: make([]models.DenyRule, len(d.referrers)),
+	}
+	for i, rule := range d.urls {
+		config.URLs[i] = rule.rule
+	}
+	for i, rule := range d.referrers {
+		config.Referrers[i] = rule.rule
+	}
+	return config
+}
+
+// SetDenylist replaces every deny rule. Nothing changes if a rule is invalid.
+func (nt *NavigationTracker) SetDenylist(config models.DenylistConfig) error {
+	urls, err := compileDenyRules(models.DenylistURLs, config.URLs)
+	if err != nil {
+		return err
+	}
+	referrers, err := compileDenyRules(models.DenylistReferrers, config.Referrers)
+	if err != nil {
+		return err
+	}
+
+	d := nt.denylist
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.urls, d.referrers = urls, referrers
+	return nil
+}
+
+// AddDenyRule appends rule to the named list.
+func (nt *NavigationTracker) AddDenyRule(list string, rule models.DenyRule) error {
+	compiled, err := compileDenyRule(list, rule)
+	if err != nil {
+		return err
+	}
+
+	d := nt.denylist
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	rules, err := d.list(list)
+	if err != nil {
+		return err
+	}
+	for _, existing := range *rules {
+		if existing.rule == rule {
+			return ErrDenyRuleExists
+		}
+	}
+	*rules = append(*rules, compiled)
+	return nil
+}
+
+// RemoveDenyRule removes rule from the named list and reports whether it was there.
+func (nt *NavigationTracker) RemoveDenyRule(list string, rule models.DenyRule) (bool, error) {
+	d := nt.denylist
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	rules, err := d.list(list)
+	if err != nil {
+		return false, err
+	}
+	for i, existing := range *rules {
+		if existing.rule == rule {
+			*rules = append((*rules)[:i:i], (*rules)[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DenylistStats reports the number of rules and of events each list rejected.
+func (nt *NavigationTracker) DenylistStats() *models.DenylistStats {
+	d := nt.denylist
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return &models.DenylistStats{
+		URLRules:          len(d.urls),
+		ReferrerRules:     len(d.referrers),
+		RejectedURLs:      d.rejectedURLs.Load(),
+		RejectedReferrers: d.rejectedReferrers.Load(),
+	}
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestNavigationTracker_Denylist(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Denylist = models.DenylistConfig{
+		URLs: []models.DenyRule{
+			{Match: models.DenyExact, Pattern: "https://Example.com/Blocked/"},
+			{Match: models.DenyPrefix, Pattern: "https://example.com/wp-"},
+		},
+		Referrers: []models.DenyRule{
+			{Match: models.DenyRegex, Pattern: `(^|\.)spam\.net$`},
+		},
+	}
+	tracker := NewNavigationTrackerWithConfig(config)
+
+	var notified []string
+	tracker.AddDenyListener(func(list string) { notified = append(notified, list) })
+
+	tests := []struct {
+		event *models.NavigationEvent
+		list  string
+	}{
+		{&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/blocked"}, models.DenylistURLs},
+		{&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/wp-login.php"}, models.DenylistURLs},
+		{&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/", Referrer: "https://www.seo.spam.net/x"}, models.DenylistReferrers},
+		{&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/", Referrer: "https://notspam.net/"}, ""},
+		{&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/blocked/page"}, ""},
+	}
+	for _, tt := range tests {
+		err := tracker.RecordEvent(tt.event)
+		if denied := errors.Is(err, ErrDenied); denied != (tt.list != "") {
+			t.Errorf("Expected denied=%v for %s, got %v", tt.list != "", tt.event.URL, err)
+		}
+	}
+
+	if tracker.GetDistinctVisitors("https://example.com/blocked") != 0 {
+		t.Error("Expected the denylisted URL not to be recorded")
+	}
+	stats := tracker.DenylistStats()
+	if stats.RejectedURLs != 2 || stats.RejectedReferrers != 1 {
+		t.Errorf("Expected 2 URL and 1 referrer rejections, got %+v", stats)
+	}
+	if len(notified) != 3 || notified[2] != models.DenylistReferrers {
+		t.Errorf("Expected listeners to be notified per rejection, got %v", notified)
+	}
+	if system := tracker.GetSystemStats(); system.Denylist == nil || system.Denylist.URLRules != 2 {
+		t.Errorf("Expected denylist in system stats, got %+v", system.Denylist)
+	}
+}
+
+func TestNavigationTracker_ManageDenyRules(t *testing.T) {
+	tracker := NewNavigationTracker()
+	rule := models.DenyRule{Match: models.DenyExact, Pattern: "spam.example"}
+	event := func() *models.NavigationEvent {
+		return &models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/", Referrer: "https://spam.example/"}
+	}
+
+	if err := tracker.AddDenyRule(models.DenylistReferrers, rule); err != nil {
+		t.Fatalf("Failed to add rule: %v", err)
+	}
+	if err := tracker.AddDenyRule(models.DenylistReferrers, rule); !errors.Is(err, ErrDenyRuleExists) {
+		t.Errorf("Expected ErrDenyRuleExists, got %v", err)
+	}
+	if err := tracker.AddDenyRule("visitors", rule); err == nil {
+		t.Error("Expected an unknown list to be rejected")
+	}
+	if err := tracker.AddDenyRule(models.DenylistURLs, models.DenyRule{Match: models.DenyRegex, Pattern: "("}); err == nil {
+		t.Error("Expected an invalid regex to be rejected")
+	}
+	if err := tracker.RecordEvent(event()); !errors.Is(err, ErrDenied) {
+		t.Errorf("Expected the referrer to be denied, got %v", err)
+	}
+
+	if removed, err := tracker.RemoveDenyRule(models.DenylistReferrers, rule); !removed || err != nil {
+		t.Errorf("Expected the rule to be removed, got %v, %v", removed, err)
+	}
+	if err := tracker.RecordEvent(event()); err != nil {
+		t.Errorf("Expected the event to be recorded once the rule is removed, got %v", err)
+	}
+
+	err := tracker.SetDenylist(models.DenylistConfig{
+		URLs: []models.DenyRule{{Match: models.DenyPrefix, Pattern: "https://example.com/"}, {Match: "glob", Pattern: "*"}},
+	})
+	if err == nil || len(tracker.Denylist().URLs) != 0 {
+		t.Errorf("Expected an invalid replacement to change nothing, got %v and %+v", err, tracker.Denylist())
+	}
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/useragent"
+)
+
+type deviceStats struct {
+	browsers         map[string]int64
+	operatingSystems map[string]int64
+	deviceTypes      map[string]int64
+}
+
+func newDeviceStats() *deviceStats {
+	return &deviceStats{
+		browsers:         make(map[string]int64),
+		operatingSystems: make(map[string]int64),
+		deviceTypes:      make(map[string]int64),
+	}
+}
+
+func (d *deviceStats) record(info useragent.Info) {
+	d.browsers[info.Browser]++
+	d.operatingSystems[info.OS]++
+	d.deviceTypes[info.Device]++
+}
+
+// GetDeviceBreakdown returns page views per browser, OS and device type for url.
+func (nt *NavigationTracker) GetDeviceBreakdown(url string) *models.DeviceBreakdown {
+	nt.promote(url)
+	shard := nt.urlShard(url)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	breakdown := &models.DeviceBreakdown{
+		Browsers:         make(map[string]int64),
+		OperatingSystems: make(map[string]int64),
+		DeviceTypes:      make(map[string]int64),
+	}
+
+	stats, exists := shard.lookup(url)
+	if !exists {
+		return breakdown
+	}
+
+	for name, count := range stats.devices.browsers {
+		breakdown.Browsers[name] = count
+	}
+	for name, count := range stats.devices.operatingSystems {
+		breakdown.OperatingSystems[name] = count
+	}
+	for name, count := range stats.devices.deviceTypes {
+		breakdown.DeviceTypes[name] = count
+	}
+
+	return breakdown
+}
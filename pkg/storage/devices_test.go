@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestNavigationTracker_GetDeviceBreakdown(t *testing.T) {
+	tracker := NewNavigationTracker()
+
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"},
+		{VisitorID: "visitor2", URL: "https://example.com/a", UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1"},
+		{VisitorID: "visitor3", URL: "https://example.com/a"},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	breakdown := tracker.GetDeviceBreakdown("https://example.com/a")
+
+	if breakdown.Browsers["Chrome"] != 1 || breakdown.Browsers["Safari"] != 1 || breakdown.Browsers["unknown"] != 1 {
+		t.Errorf("Unexpected browsers: %v", breakdown.Browsers)
+	}
+
+	if breakdown.OperatingSystems["Windows"] != 1 || breakdown.OperatingSystems["iOS"] != 1 {
+		t.Errorf("Unexpected operating systems: %v", breakdown.OperatingSystems)
+	}
+
+	if breakdown.DeviceTypes["desktop"] != 1 || breakdown.DeviceTypes["mobile"] != 1 {
+		t.Errorf("Unexpected device types: %v", breakdown.DeviceTypes)
+	}
+
+	empty := tracker.GetDeviceBreakdown("https://example.com/missing")
+	if len(empty.Browsers) != 0 {
+		t.Errorf("Expected empty breakdown for unknown URL, got %v", empty.Browsers)
+	}
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage/internal/hll"
+)
+
+// distinctCounter counts the distinct values of one dimension on a URL.
+type distinctCounter interface {
+	add(value string)
+	count() int
+	snapshot() dimensionSnapshot
+}
+
+type dimensionSnapshot struct {
+	Values    []string `json:"values,omitempty"`
+	Registers []byte   `json:"registers,omitempty"`
+}
+
+func (ds dimensionSnapshot) counter() distinctCounter {
+	if ds.Registers != nil {
+		return &hyperLogLog{registers: append([]byte(nil), ds.Registers...)}
+	}
+	return exactCounter(keySet(ds.Values))
+}
+
+// exactCounter keeps every distinct value.
+type exactCounter map[string]bool
+
+func (c exactCounter) add(value string) { c[value] = true }
+
+func (c exactCounter) count() int { return len(c) }
+
+func (c exactCounter) snapshot() dimensionSnapshot {
+	return dimensionSnapshot{Values: setKeys(c)}
+}
+
+// hyperLogLog estimates distinct values with a HyperLogLog sketch.
+type hyperLogLog struct {
+	registers hll.Sketch
+}
+
+func newHyperLogLog(precision int) *hyperLogLog {
+	return &hyperLogLog{registers: hll.New(precision)}
+}
+
+func (h *hyperLogLog) add(value string) { h.registers.Add(value) }
+
+func (h *hyperLogLog) count() int { return h.registers.Count() }
+
+func (h *hyperLogLog) snapshot() dimensionSnapshot {
+	return dimensionSnapshot{Registers: append([]byte(nil), h.registers...)}
+}
+
+// dimensionSet holds the configured dimensions by name.
+type dimensionSet map[string]models.DimensionConfig
+
+func newDimensionSet(configs []models.DimensionConfig) dimensionSet {
+	set := make(dimensionSet, len(configs))
+	for _, config := range configs {
+		set[config.Name] = config
+	}
+	return set
+}
+
+func (ds dimensionSet) newCounter(name string) distinctCounter {
+	config := ds[name]
+	if config.Mode == models.DimensionModeHLL {
+		precision := config.Precision
+		if precision == 0 {
+			precision = models.DefaultHLLPrecision
+		}
+		return newHyperLogLog(precision)
+	}
+	return make(exactCounter)
+}
+
+// recordDimensions adds the event's values for each configured dimension;
+// the caller must hold the shard write lock.
+func (s *urlStats) recordDimensions(dimensions dimensionSet, properties map[string]string) {
+	for name := range dimensions {
+		value := properties[name]
+		if value == "" {
+			continue
+		}
+		if s.dimensions == nil {
+			s.dimensions = make(map[string]distinctCounter)
+		}
+		counter := s.dimensions[name]
+		if counter == nil {
+			counter = dimensions.newCounter(name)
+			s.dimensions[name] = counter
+		}
+		counter.add(value)
+	}
+}
+
+// mergeDimensions adds src's dimension values to s. Exact values merged into
+// a sketch are added to it; a sketch merged into exact values replaces them
+// with a copy of the sketch holding both. Sketches of different precision
+// cannot be combined, so the larger one is kept. The caller must hold the
+// write locks of both URLs' shards.
+func (s *urlStats) mergeDimensions(src *urlStats) {
+	for name, counter := range src.dimensions {
+		if s.dimensions == nil {
+			s.dimensions = make(map[string]distinctCounter)
+		}
+		s.dimensions[name] = mergeCounters(s.dimensions[name], counter)
+	}
+}
+
+func mergeCounters(dst, src distinctCounter) distinctCounter {
+	if dst == nil {
+		return src.snapshot().counter()
+	}
+
+	switch d := dst.(type) {
+	case exactCounter:
+		if s, ok := src.(exactCounter); ok {
+			for value := range s {
+				d.add(value)
+			}
+			return d
+		}
+		merged := src.snapshot().counter()
+		for value := range d {
+			merged.add(value)
+		}
+		return merged
+	case *hyperLogLog:
+		switch s := src.(type) {
+		case exactCounter:
+			for value := range s {
+				d.add(value)
+			}
+		case *hyperLogLog:
+			if len(s.registers) != len(d.registers) {
+				if s.count() > d.count() {
+					return s.snapshot().counter()
+				}
+				return d
+			}
+			d.registers.Merge(s.registers)
+		}
+	}
+	return dst
+}
+
+// GetDistinctDimension returns the number of distinct values of dimension
+// seen on url, and whether the count is an estimate. It returns false if
+// dimension is not configured.
+func (nt *NavigationTracker) GetDistinctDimension(url, dimension string) (count int, approximate bool, ok bool) {
+	config, ok := nt.dimensions[dimension]
+	if !ok {
+		return 0, false, false
+	}
+	approximate = config.Mode == models.DimensionModeHLL
+
+	nt.promote(url)
+	shard := nt.urlShard(url)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	if stats, exists := shard.lookup(url); exists {
+		if counter := stats.dimensions[dimension]; counter != nil {
+			count = counter.count()
+		}
+	}
+	return count, approximate, true
+}
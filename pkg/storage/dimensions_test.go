@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func newDimensionTracker() *NavigationTracker {
+	config := models.DefaultConfiguration()
+	config.Dimensions = []models.DimensionConfig{
+		{Name: "account_id", Mode: models.DimensionModeExact},
+		{Name: "org", Mode: models.DimensionModeHLL, Precision: 14},
+	}
+	return NewNavigationTrackerWithConfig(config)
+}
+
+func TestNavigationTracker_GetDistinctDimension(t *testing.T) {
+	tracker := newDimensionTracker()
+	url := "https://example.com/pricing"
+
+	events := []struct {
+		visitorID string
+		account   string
+	}{
+		{"visitor1", "acme"},
+		{"visitor2", "acme"},
+		{"visitor3", "globex"},
+		{"visitor4", ""},
+	}
+	for _, e := range events {
+		event := &models.NavigationEvent{VisitorID: e.visitorID, URL: url}
+		if e.account != "" {
+			event.Properties = map[string]string{"account_id": e.account}
+		}
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	count, approximate, ok := tracker.GetDistinctDimension(url, "account_id")
+	if !ok || approximate || count != 2 {
+		t.Errorf("Expected exactly 2 accounts, got %d (approximate=%v, ok=%v)", count, approximate, ok)
+	}
+	if count, _, _ := tracker.GetDistinctDimension("https://example.com/other", "account_id"); count != 0 {
+		t.Errorf("Expected 0 accounts for unknown URL, got %d", count)
+	}
+	if _, _, ok := tracker.GetDistinctDimension(url, "plan"); ok {
+		t.Error("Expected unconfigured dimension to be rejected")
+	}
+}
+
+func TestNavigationTracker_DimensionHLLAccuracy(t *testing.T) {
+	tracker := newDimensionTracker()
+	url := "https://example.com/pricing"
+
+	const orgs = 20000
+	for i := 0; i < orgs*2; i++ {
+		event := &models.NavigationEvent{
+			VisitorID:  fmt.Sprintf("visitor%d", i),
+			URL:        url,
+			Properties: map[string]string{"org": fmt.Sprintf("org-%d", i%orgs)},
+		}
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	count, approximate, _ := tracker.GetDistinctDimension(url, "org")
+	if !approximate {
+		t.Error("Expected hll dimension to be reported as approximate")
+	}
+	// Precision 14 has a standard error below 1%; allow three of them
+	if relErr := math.Abs(float64(count-orgs)) / orgs; relErr > 0.03 {
+		t.Errorf("Expected about %d orgs, got %d (error %.3f)", orgs, count, relErr)
+	}
+}
+
+func TestHyperLogLog_SmallCounts(t *testing.T) {
+	h := newHyperLogLog(models.DefaultHLLPrecision)
+	for i := 0; i < 10; i++ {
+		h.add(fmt.Sprintf("value%d", i%5))
+	}
+	if h.count() != 5 {
+		t.Errorf("Expected 5 distinct values, got %d", h.count())
+	}
+}
+
+func TestNavigationTracker_DimensionSnapshotAndMerge(t *testing.T) {
+	tracker := newDimensionTracker()
+	a, b := "https://example.com/a", "https://example.com/b"
+
+	for i, url := range []string{a, a, b} {
+		event := &models.NavigationEvent{
+			VisitorID:  fmt.Sprintf("visitor%d", i),
+			URL:        url,
+			Properties: map[string]string{"account_id": fmt.Sprintf("account%d", i), "org": fmt.Sprintf("org%d", i)},
+		}
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := tracker.SaveSnapshot(path); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	restored := newDimensionTracker()
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+
+	if !restored.mergeURL(a, b) {
+		t.Fatal("Expected merge to succeed")
+	}
+	for _, dimension := range []string{"account_id", "org"} {
+		if count, _, _ := restored.GetDistinctDimension(b, dimension); count != 3 {
+			t.Errorf("Expected 3 distinct %s values after restore and merge, got %d", dimension, count)
+		}
+	}
+}
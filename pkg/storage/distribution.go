@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"math/bits"
+	"sync/atomic"
+
+	"nav-tracker/pkg/models"
+)
+
+// Visitor count buckets of urlDistribution: 1, 2-10, 11-100 and over 100.
+var visitorBucketBounds = [...]int{1, 10, 100}
+
+// viewSubBuckets is the number of buckets each power of two of page views is
+// split into, so that a bucket is at most an eighth as wide as its values.
+const (
+	viewSubBucketBits = 3
+	viewSubBuckets    = 1 << viewSubBucketBits
+	viewBucketCount   = viewSubBuckets * (64 - viewSubBucketBits + 1)
+)
+
+// urlDistribution counts URLs by distinct visitors and by page views. Each
+// URL remembers the buckets it is counted in, and is moved between them as
+// its counts change, so reading the distribution never walks the URLs.
+type urlDistribution struct {
+	visitors [len(visitorBucketBounds) + 1]atomic.Int64
+	views    [viewBucketCount]atomic.Int64
+}
+
+// distributionSlot is where a URL is counted. Zero means nowhere yet.
+type distributionSlot struct {
+	counted bool
+	visitor int8 // -1 for URLs without visitors
+	view    int16
+}
+
+func visitorBucket(visitors int) int8 {
+	if visitors < 1 {
+		return -1
+	}
+	for i, bound := range visitorBucketBounds {
+		if visitors <= bound {
+			return int8(i)
+		}
+	}
+	return int8(len(visitorBucketBounds))
+}
+
+// viewBucket returns the bucket of a page view count. Counts below
+// viewSubBuckets have a bucket each; above that, each power of two is split
+// into viewSubBuckets equal ranges.
+func viewBucket(views int64) int16 {
+	if views < viewSubBuckets {
+		return int16(max(views, 0))
+	}
+	exponent := bits.Len64(uint64(views)) - 1
+	sub := (uint64(views) >> (exponent - viewSubBucketBits)) & (viewSubBuckets - 1)
+	return int16(viewSubBuckets*(exponent-viewSubBucketBits+1) + int(sub))
+}
+
+// viewBucketRange returns the smallest and largest page view counts in bucket.
+func viewBucketRange(bucket int) (int64, int64) {
+	if bucket < viewSubBuckets {
+		return int64(bucket), int64(bucket)
+	}
+	exponent := bucket/viewSubBuckets + viewSubBucketBits - 1
+	sub := int64(bucket % viewSubBuckets)
+	width := int64(1) << (exponent - viewSubBucketBits)
+	lower := (viewSubBuckets + sub) * width
+	return lower, lower + width - 1
+}
+
+// slotOf returns where stats belongs. Visitors beyond the visitor cap are
+// only estimated when they could change the bucket.
+func slotOf(stats *urlStats) distributionSlot {
+	visitors := len(stats.visitors)
+	if stats.overflow != nil && visitors <= visitorBucketBounds[len(visitorBucketBounds)-1] {
+		visitors = stats.distinctVisitors()
+	}
+	return distributionSlot{counted: true, visitor: visitorBucket(visitors), view: viewBucket(stats.pageViews)}
+}
+
+func (d *urlDistribution) add(slot distributionSlot, delta int64) {
+	if slot.visitor >= 0 {
+		d.visitors[slot.visitor].Add(delta)
+	}
+	d.views[slot.view].Add(delta)
+}
+
+// update moves stats to the buckets of its current counts; the caller must
+// hold its shard's write lock.
+func (d *urlDistribution) update(stats *urlStats) {
+	slot := slotOf(stats)
+	if slot == stats.slot {
+		return
+	}
+	if stats.slot.counted {
+		d.add(stats.slot, -1)
+	}
+	d.add(slot, 1)
+	stats.slot = slot
+}
+
+// remove stops counting stats; the caller must hold its shard's write lock.
+func (d *urlDistribution) remove(stats *urlStats) {
+	if !stats.slot.counted {
+		return
+	}
+	d.add(stats.slot, -1)
+	stats.slot = distributionSlot{}
+}
+
+func (d *urlDistribution) reset() {
+	for i := range d.visitors {
+		d.visitors[i].Store(0)
+	}
+	for i := range d.views {
+		d.views[i].Store(0)
+	}
+}
+
+// viewPercentile estimates the page views of the URL at quantile q from the
+// per-bucket counts, interpolating within its bucket.
+func viewPercentile(counts []int64, total int64, q float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	rank := q * float64(total)
+	var seen int64
+	for bucket, count := range counts {
+		if count == 0 {
+			continue
+		}
+		if float64(seen+count) >= rank {
+			lower, upper := viewBucketRange(bucket)
+			within := (rank - float64(seen)) / float64(count)
+			return float64(lower) + within*float64(upper-lower)
+		}
+		seen += count
+	}
+	lower, _ := viewBucketRange(len(counts) - 1)
+	return float64(lower)
+}
+
+func (d *urlDistribution) stats() *models.URLDistribution {
+	counts := make([]int64, len(d.views))
+	var total int64
+	for i := range d.views {
+		counts[i] = d.views[i].Load()
+		total += counts[i]
+	}
+
+	return &models.URLDistribution{
+		Visitors: models.VisitorHistogram{
+			One:             int(d.visitors[0].Load()),
+			TwoToTen:        int(d.visitors[1].Load()),
+			ElevenToHundred: int(d.visitors[2].Load()),
+			OverHundred:     int(d.visitors[3].Load()),
+		},
+		MedianPageViews: viewPercentile(counts, total, 0.5),
+		P90PageViews:    viewPercentile(counts, total, 0.9),
+		P99PageViews:    viewPercentile(counts, total, 0.99),
+	}
+}
+
+// rebuildDistribution recounts every URL; the caller must hold every shard
+// lock, and every URL must be hot.
+func (nt *NavigationTracker) rebuildDistribution() {
+	nt.distribution.reset()
+	for _, shard := range nt.shards {
+		for _, stats := range shard.urls {
+			stats.slot = distributionSlot{}
+			nt.distribution.update(stats)
+		}
+	}
+}
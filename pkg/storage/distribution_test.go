@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestViewBucket_Range(t *testing.T) {
+	for _, views := range []int64{0, 1, 7, 8, 9, 15, 16, 17, 100, 1000, 123456789} {
+		lower, upper := viewBucketRange(int(viewBucket(views)))
+		if views < lower || views > upper {
+			t.Errorf("Expected %d within its bucket, got [%d, %d]", views, lower, upper)
+		}
+		if width := upper - lower + 1; width*8 > max(views, 8) {
+			t.Errorf("Expected the bucket of %d to be at most an eighth as wide, got %d", views, width)
+		}
+	}
+}
+
+func TestNavigationTracker_URLDistribution(t *testing.T) {
+	tracker := NewNavigationTracker()
+	recordVisitors(t, tracker, "https://example.com/a", 1)
+	recordVisitors(t, tracker, "https://example.com/b", 2)
+	recordVisitors(t, tracker, "https://example.com/c", 10)
+	recordVisitors(t, tracker, "https://example.com/d", 11)
+	recordVisitors(t, tracker, "https://example.com/e", 101)
+
+	got := tracker.GetSystemStats().URLDistribution
+	want := models.VisitorHistogram{One: 1, TwoToTen: 2, ElevenToHundred: 1, OverHundred: 1}
+	if got == nil || got.Visitors != want {
+		t.Fatalf("Expected visitor histogram %+v, got %+v", want, got)
+	}
+	if got.MedianPageViews != 10 {
+		t.Errorf("Expected a median of 10 page views, got %v", got.MedianPageViews)
+	}
+	if got.P99PageViews < 96 || got.P99PageViews > 111 {
+		t.Errorf("Expected p99 within the bucket of 101 page views, got %v", got.P99PageViews)
+	}
+
+	// A repeat visit moves the URL between page view buckets only
+	recordVisitors(t, tracker, "https://example.com/a", 1)
+	got = tracker.GetSystemStats().URLDistribution
+	if got.Visitors != want {
+		t.Errorf("Expected visitor histogram %+v after a repeat visit, got %+v", want, got.Visitors)
+	}
+}
+
+func TestNavigationTracker_URLDistribution_Deletion(t *testing.T) {
+	tracker := NewNavigationTracker()
+	recordVisitors(t, tracker, "https://example.com/a", 2)
+	recordVisitors(t, tracker, "https://example.com/b", 1)
+
+	tracker.DeleteVisitor("visitor0", "test")
+	got := tracker.GetSystemStats().URLDistribution.Visitors
+	if want := (models.VisitorHistogram{One: 1}); got != want {
+		t.Errorf("Expected %+v after deleting a visitor, got %+v", want, got)
+	}
+
+	tracker.DeleteURL("https://example.com/a", "test")
+	tracker.DeleteURL("https://example.com/b", "test")
+	dist := tracker.GetSystemStats().URLDistribution
+	if dist.Visitors != (models.VisitorHistogram{}) || dist.MedianPageViews != 0 {
+		t.Errorf("Expected an empty distribution after deleting every URL, got %+v", dist)
+	}
+}
+
+func TestNavigationTracker_URLDistribution_Snapshot(t *testing.T) {
+	tracker := NewNavigationTracker()
+	for i := 0; i < 3; i++ {
+		recordVisitors(t, tracker, fmt.Sprintf("https://example.com/%d", i), 5)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := tracker.SaveSnapshot(path); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	restored := NewNavigationTracker()
+	recordVisitors(t, restored, "https://example.com/gone", 1)
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+
+	got := restored.GetSystemStats().URLDistribution
+	if want := (models.VisitorHistogram{TwoToTen: 3}); got.Visitors != want || got.MedianPageViews != 5 {
+		t.Errorf("Expected 3 URLs with 5 visitors after restore, got %+v", got)
+	}
+}
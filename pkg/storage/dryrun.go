@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// DryRun validates and prepares event exactly as RecordEvent would and
+// reports how it would be counted, without changing any state: a denylisted
+// event is not counted as rejected, and its EventID is not remembered.
+// Errors match RecordEvent's.
+func (nt *NavigationTracker) DryRun(event *models.NavigationEvent) (*models.DryRunResult, error) {
+	rules, _ := nt.validationFor(event)
+	if err := event.Validate(rules); err != nil {
+		return nil, fmt.Errorf("invalid event: %w", err)
+	}
+	if err := nt.schemas.check(event); err != nil {
+		return nil, err
+	}
+
+	event.NormalizeURL()
+	event.NormalizeLanguage()
+	if list := nt.denylist.check(event); list != "" {
+		return nil, fmt.Errorf("%w by %s", ErrDenied, list)
+	}
+	timestamp, _, err := nt.timestamps.adjust(event, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	event.Timestamp = timestamp
+	event.SetDefaults()
+	event.VisitorID = nt.pseudonymizer.hash(event.VisitorID)
+	event.IP = nt.ipAnonymizer.AnonymizeString(event.IP)
+
+	result := &models.DryRunResult{
+		DryRun:    true,
+		Event:     *event,
+		Duplicate: nt.dedup != nil && event.EventID != "" && nt.dedup.contains(event.EventID, time.Now()),
+		Excluded:  nt.quality.isExcluded(event.VisitorID),
+	}
+	if !event.IsPageView() {
+		return result, nil
+	}
+
+	shard := nt.urlShard(event.URL)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	if shard.frozen[event.URL] {
+		return nil, ErrURLFrozen
+	}
+	stats, exists := shard.lookup(event.URL)
+	result.NewURL = !exists
+	result.NewVisitor = !result.Excluded && (!exists || stats.visitors[event.VisitorID] == nil)
+	if result.NewVisitor && exists && nt.visitorCap.reached(stats) {
+		if nt.visitorCap.overflow == models.VisitorCapReject {
+			return nil, ErrVisitorCapReached
+		}
+		result.OverVisitorCap = true
+	}
+	return result, nil
+}
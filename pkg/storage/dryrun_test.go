@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestNavigationTracker_DryRun(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Denylist.URLs = []models.DenyRule{{Match: models.DenyPrefix, Pattern: "https://example.com/admin"}}
+	tracker := NewNavigationTrackerWithConfig(config)
+	url := "https://example.com/page"
+
+	if err := tracker.RecordEvent(&models.NavigationEvent{EventID: "event-1", VisitorID: "visitor1", URL: url}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	result, err := tracker.DryRun(&models.NavigationEvent{EventID: "event-1", VisitorID: "visitor1", URL: "HTTPS://Example.com/page"})
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if result.Event.URL != url || result.Event.Timestamp.IsZero() {
+		t.Errorf("Expected the normalized event with a timestamp, got %+v", result.Event)
+	}
+	if !result.Duplicate || result.NewURL || result.NewVisitor {
+		t.Errorf("Expected a duplicate of a known visitor and URL, got %+v", result)
+	}
+
+	result, err = tracker.DryRun(&models.NavigationEvent{VisitorID: "visitor2", URL: "https://example.com/other"})
+	if err != nil || !result.NewURL || !result.NewVisitor || result.Duplicate {
+		t.Errorf("Expected a new URL and visitor, got %+v (%v)", result, err)
+	}
+
+	if _, err := tracker.DryRun(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/admin/users"}); !errors.Is(err, ErrDenied) {
+		t.Errorf("Expected the denylisted event to fail, got %v", err)
+	}
+	tracker.setFrozen(url, true)
+	if _, err := tracker.DryRun(&models.NavigationEvent{VisitorID: "visitor1", URL: url}); !errors.Is(err, ErrURLFrozen) {
+		t.Errorf("Expected the frozen URL to fail, got %v", err)
+	}
+
+	// Nothing above changed the tracker
+	if stats := tracker.GetVisitorStats(url); stats.DistinctVisitors != 1 || stats.TotalPageViews != 1 {
+		t.Errorf("Expected 1 visitor and page view, got %+v", stats)
+	}
+	if visitors := tracker.GetDistinctVisitors("https://example.com/other"); visitors != 0 {
+		t.Errorf("Expected the dry-run URL to be unrecorded, got %d visitors", visitors)
+	}
+	if rejected := tracker.DenylistStats().RejectedURLs; rejected != 0 {
+		t.Errorf("Expected no denylist rejections counted, got %d", rejected)
+	}
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"math"
+	"sort"
+
+	"nav-tracker/pkg/models"
+)
+
+// EdgePages counts, across every session with page views, the pages that
+// started and ended it, in the order its events arrived. A single-page
+// session counts as both. It also returns the number of sessions counted.
+func (ss *SessionStore) EdgePages() (entries, exits map[string]int, total int) {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	entries = make(map[string]int)
+	exits = make(map[string]int)
+	for _, visitorSessions := range ss.sessions {
+		for _, s := range visitorSessions {
+			if len(s.urls) == 0 {
+				continue
+			}
+			entries[s.urls[0]]++
+			exits[s.urls[len(s.urls)-1]]++
+			total++
+		}
+	}
+	return entries, exits, total
+}
+
+// GetEntryPages returns the up to limit URLs that most often start sessions.
+func (nt *NavigationTracker) GetEntryPages(limit int) *models.SessionPagesReport {
+	entries, _, total := nt.sessions.EdgePages()
+	return rankSessionPages(entries, total, limit)
+}
+
+// GetExitPages returns the up to limit URLs that most often end sessions.
+func (nt *NavigationTracker) GetExitPages(limit int) *models.SessionPagesReport {
+	_, exits, total := nt.sessions.EdgePages()
+	return rankSessionPages(exits, total, limit)
+}
+
+// rankSessionPages orders counts by count, then URL, keeping the first limit,
+// with each count's share of total rounded to two decimal places.
+func rankSessionPages(counts map[string]int, total, limit int) *models.SessionPagesReport {
+	pages := make([]models.SessionPage, 0, len(counts))
+	for url, count := range counts {
+		pages = append(pages, models.SessionPage{URL: url, Sessions: count})
+	}
+
+	sort.Slice(pages, func(i, j int) bool {
+		if pages[i].Sessions != pages[j].Sessions {
+			return pages[i].Sessions > pages[j].Sessions
+		}
+		return pages[i].URL < pages[j].URL
+	})
+	if len(pages) > limit {
+		pages = pages[:limit]
+	}
+
+	for i := range pages {
+		pages[i].Share = math.Round(float64(pages[i].Sessions)/float64(total)*10000) / 100
+	}
+	return &models.SessionPagesReport{TotalSessions: total, Pages: pages}
+}
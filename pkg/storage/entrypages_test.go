@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestNavigationTracker_EntryAndExitPages(t *testing.T) {
+	tracker := NewNavigationTracker()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	sessions := map[string][]string{
+		"visitor1": {"/home", "/pricing", "/signup"},
+		"visitor2": {"/home", "/blog"},
+		"visitor3": {"/blog", "/pricing"},
+		"visitor4": {"/home"},
+	}
+	for visitorID, paths := range sessions {
+		for i, path := range paths {
+			event := &models.NavigationEvent{VisitorID: visitorID, URL: "https://example.com" + path, Timestamp: base.Add(time.Duration(i) * time.Minute)}
+			if err := tracker.RecordEvent(event); err != nil {
+				t.Fatalf("Failed to record event: %v", err)
+			}
+		}
+	}
+
+	entries := tracker.GetEntryPages(2)
+	if entries.TotalSessions != 4 || len(entries.Pages) != 2 {
+		t.Fatalf("Expected 2 of the entry pages of 4 sessions, got %+v", entries)
+	}
+	if top := entries.Pages[0]; top.URL != "https://example.com/home" || top.Sessions != 3 || top.Share != 75 {
+		t.Errorf("Expected /home to start 3 sessions (75%%), got %+v", top)
+	}
+	if second := entries.Pages[1]; second.URL != "https://example.com/blog" || second.Share != 25 {
+		t.Errorf("Expected /blog second, got %+v", second)
+	}
+
+	exits := tracker.GetExitPages(10)
+	want := map[string]int{"https://example.com/signup": 1, "https://example.com/blog": 1, "https://example.com/pricing": 1, "https://example.com/home": 1}
+	if len(exits.Pages) != len(want) {
+		t.Fatalf("Expected %d exit pages, got %+v", len(want), exits.Pages)
+	}
+	for _, page := range exits.Pages {
+		if want[page.URL] != page.Sessions || page.Share != 25 {
+			t.Errorf("Unexpected exit page %+v", page)
+		}
+	}
+
+	if empty := NewNavigationTracker().GetEntryPages(10); empty.TotalSessions != 0 || len(empty.Pages) != 0 {
+		t.Errorf("Expected no entry pages without sessions, got %+v", empty)
+	}
+}
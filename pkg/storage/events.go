@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// eventTypeStore counts the events other than page views, such as clicks,
+// per URL and type. Only counts and times are kept, so there is nothing to
+// erase when a visitor is deleted.
+type eventTypeStore struct {
+	mutex sync.RWMutex
+	urls  map[string]map[string]*eventTypeCount
+}
+
+type eventTypeCount struct {
+	count    int64
+	lastSeen time.Time
+}
+
+func newEventTypeStore() *eventTypeStore {
+	return &eventTypeStore{urls: make(map[string]map[string]*eventTypeCount)}
+}
+
+func (s *eventTypeStore) record(event *models.NavigationEvent) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	types := s.urls[event.URL]
+	if types == nil {
+		types = make(map[string]*eventTypeCount)
+		s.urls[event.URL] = types
+	}
+	count := types[event.EventType]
+	if count == nil {
+		count = &eventTypeCount{}
+		types[event.EventType] = count
+	}
+	count.count++
+	if event.Timestamp.After(count.lastSeen) {
+		count.lastSeen = event.Timestamp
+	}
+}
+
+func (s *eventTypeStore) removeURL(url string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.urls, url)
+}
+
+// counts returns url's counts of eventType, or of every type when it is
+// empty, by type name.
+func (s *eventTypeStore) counts(url, eventType string) []models.EventTypeCount {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	counts := []models.EventTypeCount{}
+	for name, count := range s.urls[url] {
+		if eventType == "" || name == eventType {
+			counts = append(counts, models.EventTypeCount{Type: name, Count: count.count, LastSeen: count.lastSeen})
+		}
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i].Type < counts[j].Type })
+	return counts
+}
+
+// EventTypeStats returns the events recorded for url by type, page views
+// first, or only those of eventType when it is set.
+func (nt *NavigationTracker) EventTypeStats(url, eventType string) *models.EventTypeStats {
+	stats := &models.EventTypeStats{URL: url, Events: []models.EventTypeCount{}}
+	if eventType == "" || eventType == models.EventTypePageView {
+		shard := nt.urlShard(url)
+		shard.mutex.RLock()
+		if views, exists := shard.lookup(url); exists && views.pageViews > 0 {
+			stats.Events = append(stats.Events, models.EventTypeCount{
+				Type:     models.EventTypePageView,
+				Count:    views.pageViews,
+				LastSeen: views.lastSeen(),
+			})
+		}
+		shard.mutex.RUnlock()
+		if eventType != "" {
+			return stats
+		}
+	}
+	stats.Events = append(stats.Events, nt.events.counts(url, eventType)...)
+	return stats
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestNavigationTracker_EventTypes(t *testing.T) {
+	tracker := NewNavigationTracker()
+	now := time.Now().UTC()
+	url := "https://example.com/pricing"
+
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: url, Timestamp: now},
+		{VisitorID: "visitor1", URL: url, EventType: models.EventTypeClick, Timestamp: now},
+		{VisitorID: "visitor2", URL: url, EventType: models.EventTypeClick, Timestamp: now.Add(time.Second)},
+		{VisitorID: "visitor2", URL: url, EventType: "custom:signup", Payload: []byte(`{"plan":"pro"}`), Timestamp: now},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	// Only the page view is a visit
+	if stats := tracker.GetVisitorStats(url); stats.DistinctVisitors != 1 || stats.TotalPageViews != 1 {
+		t.Errorf("Expected 1 visitor and 1 page view, got %+v", stats)
+	}
+	if sessions := tracker.GetSystemStats().TotalSessions; sessions != 1 {
+		t.Errorf("Expected clicks not to start sessions, got %d sessions", sessions)
+	}
+
+	stats := tracker.EventTypeStats(url, "")
+	expected := []models.EventTypeCount{
+		{Type: models.EventTypePageView, Count: 1, LastSeen: now},
+		{Type: models.EventTypeClick, Count: 2, LastSeen: now.Add(time.Second)},
+		{Type: "custom:signup", Count: 1, LastSeen: now},
+	}
+	if len(stats.Events) != len(expected) {
+		t.Fatalf("Expected %d event types, got %+v", len(expected), stats.Events)
+	}
+	for i, count := range stats.Events {
+		if count.Type != expected[i].Type || count.Count != expected[i].Count || !count.LastSeen.Equal(expected[i].LastSeen) {
+			t.Errorf("Expected %+v, got %+v", expected[i], count)
+		}
+	}
+	if clicks := tracker.EventTypeStats(url, models.EventTypeClick).Events; len(clicks) != 1 || clicks[0].Count != 2 {
+		t.Errorf("Expected only the 2 clicks, got %+v", clicks)
+	}
+
+	path := t.TempDir() + "/snapshot.json"
+	if err := tracker.SaveSnapshot(path); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	restored := NewNavigationTracker()
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if events := restored.EventTypeStats(url, "").Events; len(events) != 3 {
+		t.Errorf("Expected 3 restored event types, got %+v", events)
+	}
+
+	tracker.DeleteURL(url, "test")
+	if events := tracker.EventTypeStats(url, "").Events; len(events) != 0 {
+		t.Errorf("Expected no events after deleting the URL, got %+v", events)
+	}
+}
@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// Approximate in-memory sizes of tracked entries, including map overhead.
+// A visitor's size covers its entry in the visitor index, and a URL's its
+// device, country and category aggregates, which stay small per URL.
+const (
+	urlEntryBytes        = 640
+	visitorEntryBytes    = 160
+	referrerEntryBytes   = 160
+	referrerVisitorBytes = 48
+)
+
+// ErrMemoryPressure is returned instead of recording an event while memory
+// backpressure is on.
+var ErrMemoryPressure = errors.New("tracked data is over its memory limit")
+
+// evictionPolicy evicts the least recently recorded URLs once the tracked
+// size of the URLs in memory exceeds maxBytes, down to targetBytes. In
+// backpressure mode it evicts nothing and instead refuses events from when
+// the size reaches highBytes until it is back under lowBytes.
+type evictionPolicy struct {
+	maxBytes     int64
+	targetBytes  int64
+	backpressure bool
+	highBytes    int64
+	lowBytes     int64
+	retryAfter   time.Duration
+	running      atomic.Bool
+
+	evictedURLs     atomic.Int64
+	evictedVisitors atomic.Int64
+	lastEviction    atomic.Int64 // unix nanoseconds
+
+	degradedSince  atomic.Int64 // unix nanoseconds, 0 when events are accepted
+	rejectedEvents atomic.Int64
+}
+
+func newEvictionPolicy(config models.EvictionConfig) *evictionPolicy {
+	if config.MaxBytes <= 0 {
+		return nil
+	}
+	return &evictionPolicy{
+		maxBytes:     config.MaxBytes,
+		targetBytes:  int64(float64(config.MaxBytes) * config.TargetRatio),
+		backpressure: config.Mode == models.EvictionModeBackpressure,
+		highBytes:    int64(float64(config.MaxBytes) * config.HighWatermark),
+		lowBytes:     int64(float64(config.MaxBytes) * config.LowWatermark),
+		retryAfter:   config.RetryAfter,
+	}
+}
+
+// degraded reports whether events are refused at the tracked size, turning
+// backpressure on at highBytes and off again under lowBytes.
+func (p *evictionPolicy) degraded(tracked int64) bool {
+	since := p.degradedSince.Load()
+	switch {
+	case since == 0 && tracked >= p.highBytes:
+		if p.degradedSince.CompareAndSwap(0, time.Now().UnixNano()) {
+			log.Printf("Memory backpressure on: tracked data is %d bytes, refusing events until under %d", tracked, p.lowBytes)
+		}
+		return true
+	case since != 0 && tracked < p.lowBytes:
+		if p.degradedSince.CompareAndSwap(since, 0) {
+			log.Printf("Memory backpressure off: tracked data is %d bytes", tracked)
+		}
+		return false
+	}
+	return since != 0
+}
+
+// admit returns ErrMemoryPressure, and counts the event as rejected, while
+// backpressure is on.
+func (nt *NavigationTracker) admit() error {
+	if nt.eviction == nil || !nt.eviction.backpressure || !nt.eviction.degraded(nt.TrackedBytes()) {
+		return nil
+	}
+	nt.eviction.rejectedEvents.Add(1)
+	return ErrMemoryPressure
+}
+
+// Backpressure returns how long clients should wait before resending events
+// refused with ErrMemoryPressure, or 0 while events are accepted.
+func (nt *NavigationTracker) Backpressure() time.Duration {
+	if nt.eviction == nil || !nt.eviction.backpressure || !nt.eviction.degraded(nt.TrackedBytes()) {
+		return 0
+	}
+	return nt.eviction.retryAfter
+}
+
+// estimateSize returns the approximate bytes url's statistics hold in memory.
+func (s *urlStats) estimateSize(url string) int64 {
+	size := urlEntryBytes + int64(len(url))
+	for visitorID := range s.visitors {
+		size += visitorEntryBytes + int64(len(visitorID))
+	}
+	if s.overflow != nil {
+		size += int64(len(s.overflow.registers))
+	}
+	for domain, ref := range s.referrers {
+		size += referrerEntryBytes + int64(len(domain))
+		for visitorID := range ref.visitors {
+			size += referrerVisitorBytes + int64(len(visitorID))
+		}
+	}
+	return size
+}
+
+// grow adds delta to the tracked size of stats and its shard; the caller
+// must hold the shard write lock.
+func (s *urlShard) grow(stats *urlStats, delta int64) {
+	stats.size += delta
+	s.bytes.Add(delta)
+}
+
+// resize re-estimates the tracked size of stats after entries were removed
+// from it; the caller must hold the shard write lock.
+func (s *urlShard) resize(url string, stats *urlStats) {
+	s.grow(stats, stats.estimateSize(url)-stats.size)
+}
+
+// untrack drops stats from its shard's tracked size when it leaves memory;
+// the caller must hold the shard write lock.
+func (s *urlShard) untrack(stats *urlStats) {
+	s.grow(stats, -stats.size)
+}
+
+// TrackedBytes returns the approximate size of the URL statistics held in
+// memory. Cold URLs are not counted.
+func (nt *NavigationTracker) TrackedBytes() int64 {
+	var total int64
+	for _, shard := range nt.shards {
+		total += shard.bytes.Load()
+	}
+	return total
+}
+
+// Usage returns the tracked size, the URLs held, cold ones included, and the
+// visitors seen on any URL, as reported for tenant quotas.
+func (nt *NavigationTracker) Usage() models.TenantUsage {
+	usage := models.TenantUsage{Bytes: nt.TrackedBytes()}
+	for _, shard := range nt.shards {
+		shard.mutex.RLock()
+		usage.URLs += len(shard.urls) + shard.coldURLs()
+		shard.mutex.RUnlock()
+	}
+	for _, shard := range nt.visitorShards {
+		shard.mutex.RLock()
+		usage.Visitors += len(shard.urls)
+		shard.mutex.RUnlock()
+	}
+	return usage
+}
+
+// evictIfFull evicts URLs when the tracked size exceeds the configured
+// maximum. Only one caller evicts at a time; the others carry on ingesting.
+func (nt *NavigationTracker) evictIfFull() {
+	if nt.eviction == nil || nt.eviction.backpressure || nt.TrackedBytes() <= nt.eviction.maxBytes {
+		return
+	}
+	if !nt.eviction.running.CompareAndSwap(false, true) {
+		return
+	}
+	defer nt.eviction.running.Store(false)
+
+	nt.EvictURLs(nt.eviction.targetBytes)
+}
+
+// EvictURLs removes the least recently recorded URLs held in memory until
+// their tracked size is at most target, and returns the number of URLs and
+// visitor records evicted. Frozen URLs are skipped, and, as with retention,
+// session, cohort and funnel history is kept. Shards are locked one at a time
+// so ingestion into the others continues.
+func (nt *NavigationTracker) EvictURLs(target int64) (int, int) {
+	type candidate struct {
+		shard        *urlShard
+		url          string
+		lastRecorded int64
+	}
+	var candidates []candidate
+	for _, shard := range nt.shards {
+		shard.mutex.RLock()
+		for url, stats := range shard.urls {
+			if !shard.frozen[url] {
+				candidates = append(candidates, candidate{shard, url, stats.lastRecorded})
+			}
+		}
+		shard.mutex.RUnlock()
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].lastRecorded < candidates[j].lastRecorded })
+
+	urls, visitors := 0, 0
+	for _, c := range candidates {
+		if nt.TrackedBytes() <= target {
+			break
+		}
+
+		c.shard.mutex.Lock()
+		// A URL recorded since the scan is no longer among the least recent
+		if stats, exists := c.shard.urls[c.url]; exists && stats.lastRecorded == c.lastRecorded && !c.shard.frozen[c.url] {
+			visitors += len(stats.visitors)
+			urls++
+			nt.removeURL(c.shard, c.url, stats)
+		}
+		c.shard.mutex.Unlock()
+	}
+
+	if nt.eviction != nil && urls > 0 {
+		nt.eviction.evictedURLs.Add(int64(urls))
+		nt.eviction.evictedVisitors.Add(int64(visitors))
+		nt.eviction.lastEviction.Store(time.Now().UnixNano())
+	}
+	return urls, visitors
+}
+
+// evictionStats reports the tracked size and eviction counters.
+func (nt *NavigationTracker) evictionStats() *models.EvictionStats {
+	stats := &models.EvictionStats{
+		TrackedBytes:    nt.TrackedBytes(),
+		MaxBytes:        nt.eviction.maxBytes,
+		Mode:            models.EvictionModeEvict,
+		EvictedURLs:     nt.eviction.evictedURLs.Load(),
+		EvictedVisitors: nt.eviction.evictedVisitors.Load(),
+	}
+	if last := nt.eviction.lastEviction.Load(); last != 0 {
+		stats.LastEviction = time.Unix(0, last).UTC()
+	}
+	if nt.eviction.backpressure {
+		stats.Mode = models.EvictionModeBackpressure
+		stats.Backpressure = nt.eviction.degraded(stats.TrackedBytes)
+		stats.RejectedEvents = nt.eviction.rejectedEvents.Load()
+		if since := nt.eviction.degradedSince.Load(); since != 0 {
+			stats.DegradedSince = time.Unix(0, since).UTC()
+		}
+	}
+	return stats
+}
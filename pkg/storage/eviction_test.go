@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestNavigationTracker_TrackedBytes(t *testing.T) {
+	tracker := NewNavigationTracker()
+	now := time.Now()
+
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", Referrer: "https://google.com/", Timestamp: now},
+		{VisitorID: "visitor2", URL: "https://example.com/a", Referrer: "https://google.com/", Timestamp: now},
+		{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: now},
+		{VisitorID: "visitor1", URL: "https://example.com/b", Timestamp: now},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	estimate := func() int64 {
+		var total int64
+		for _, shard := range tracker.shards {
+			for url, stats := range shard.urls {
+				total += stats.estimateSize(url)
+			}
+		}
+		return total
+	}
+	if tracked := tracker.TrackedBytes(); tracked == 0 || tracked != estimate() {
+		t.Errorf("Expected tracked bytes to match the estimate %d, got %d", estimate(), tracked)
+	}
+
+	tracker.DeleteVisitor("visitor2", "test")
+	if tracked := tracker.TrackedBytes(); tracked != estimate() {
+		t.Errorf("Expected tracked bytes %d after deleting a visitor, got %d", estimate(), tracked)
+	}
+
+	tracker.DeleteURL("https://example.com/a", "test")
+	tracker.DeleteURL("https://example.com/b", "test")
+	if tracked := tracker.TrackedBytes(); tracked != 0 {
+		t.Errorf("Expected no tracked bytes after deleting every URL, got %d", tracked)
+	}
+}
+
+func TestNavigationTracker_EvictsLeastRecentlyRecorded(t *testing.T) {
+	urls := []string{"https://example.com/a", "https://example.com/b", "https://example.com/c", "https://example.com/d"}
+	perURL := int64(urlEntryBytes + len(urls[0]) + visitorEntryBytes + len("visitor1"))
+
+	config := models.DefaultConfiguration()
+	config.Eviction = models.EvictionConfig{MaxBytes: 3*perURL + perURL/2, TargetRatio: 0.9}
+	tracker := NewNavigationTrackerWithConfig(config)
+
+	record := func(url string) {
+		t.Helper()
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	record(urls[0])
+	record(urls[1])
+	record(urls[2])
+	// Recording /a again makes /b the least recently recorded URL
+	record(urls[0])
+	record(urls[3])
+
+	if tracker.GetDistinctVisitors(urls[1]) != 0 {
+		t.Error("Expected /b to be evicted")
+	}
+	for _, url := range []string{urls[0], urls[2], urls[3]} {
+		if tracker.GetDistinctVisitors(url) != 1 {
+			t.Errorf("Expected %s to be kept", url)
+		}
+	}
+
+	stats := tracker.GetSystemStats()
+	if stats.Eviction == nil || stats.Eviction.EvictedURLs != 1 || stats.Eviction.EvictedVisitors != 1 {
+		t.Errorf("Expected 1 URL and 1 visitor evicted, got %+v", stats.Eviction)
+	}
+	if stats.Eviction.TrackedBytes != 3*perURL {
+		t.Errorf("Expected %d tracked bytes, got %d", 3*perURL, stats.Eviction.TrackedBytes)
+	}
+	if profile, _ := tracker.GetVisitorProfile("visitor1"); profile == nil || len(profile.URLs) != 3 {
+		t.Errorf("Expected the evicted URL to be unindexed from its visitor, got %+v", profile)
+	}
+}
+
+func TestNavigationTracker_EvictionDisabled(t *testing.T) {
+	tracker := NewNavigationTracker()
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/a"}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+	if stats := tracker.GetSystemStats(); stats.Eviction != nil {
+		t.Errorf("Expected no eviction stats without max_bytes, got %+v", stats.Eviction)
+	}
+	if urls, _ := tracker.EvictURLs(1 << 30); urls != 0 {
+		t.Errorf("Expected nothing evicted under the target, got %d", urls)
+	}
+}
+
+func TestNavigationTracker_MemoryBackpressure(t *testing.T) {
+	urls := []string{"https://example.com/a", "https://example.com/b", "https://example.com/c", "https://example.com/d"}
+	perURL := int64(urlEntryBytes + len(urls[0]) + visitorEntryBytes + len("visitor1"))
+
+	config := models.DefaultConfiguration()
+	config.Eviction = models.EvictionConfig{
+		MaxBytes:      4 * perURL,
+		Mode:          models.EvictionModeBackpressure,
+		HighWatermark: 0.75,
+		LowWatermark:  0.5,
+		RetryAfter:    5 * time.Second,
+	}
+	tracker := NewNavigationTrackerWithConfig(config)
+
+	record := func(url string) error {
+		return tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url})
+	}
+	for _, url := range urls[:3] {
+		if err := record(url); err != nil {
+			t.Fatalf("Failed to record %s: %v", url, err)
+		}
+	}
+
+	if err := record(urls[3]); !errors.Is(err, ErrMemoryPressure) {
+		t.Fatalf("Expected ErrMemoryPressure at the high watermark, got %v", err)
+	}
+	if retryAfter := tracker.Backpressure(); retryAfter != 5*time.Second {
+		t.Errorf("Expected a 5s retry, got %v", retryAfter)
+	}
+	stats := tracker.GetSystemStats()
+	if len(stats.Degraded) != 1 || stats.Degraded[0] != models.DegradedMemory {
+		t.Errorf("Expected a degraded memory state, got %v", stats.Degraded)
+	}
+	if stats.TotalUniqueURLs != 3 || stats.Eviction.EvictedURLs != 0 || stats.Eviction.RejectedEvents != 1 {
+		t.Errorf("Expected 3 URLs kept and 1 event rejected, got %d URLs and %+v", stats.TotalUniqueURLs, stats.Eviction)
+	}
+
+	// Between the watermarks, events stay refused
+	tracker.DeleteURL(urls[0], "test")
+	if err := record(urls[3]); !errors.Is(err, ErrMemoryPressure) {
+		t.Errorf("Expected events refused above the low watermark, got %v", err)
+	}
+
+	tracker.DeleteURL(urls[1], "test")
+	if err := record(urls[3]); err != nil {
+		t.Errorf("Expected events accepted under the low watermark, got %v", err)
+	}
+	if stats := tracker.GetSystemStats(); stats.Degraded != nil || stats.Eviction.Backpressure {
+		t.Errorf("Expected backpressure off, got %v and %+v", stats.Degraded, stats.Eviction)
+	}
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// SnapshotFlusher coalesces changes into periodic snapshot writes instead of
+// persisting each event. A flush happens every interval while events are
+// pending, or sooner once maxEvents have accumulated, so at most one interval
+// of events is lost on a crash.
+type SnapshotFlusher struct {
+	tracker   *NavigationTracker
+	path      string
+	interval  time.Duration
+	maxEvents int64
+
+	pending  atomic.Int64
+	triggerC chan struct{}
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+	started  atomic.Bool
+
+	mutex             sync.Mutex // serialises flushes and guards the fields below
+	flushes           int64
+	failedFlushes     int64
+	lastFlush         time.Time
+	lastFlushDuration time.Duration
+}
+
+// NewSnapshotFlusher registers a listener on tracker to count pending events,
+// so it must be created before the tracker receives events.
+func NewSnapshotFlusher(tracker *NavigationTracker, config models.SnapshotConfig) *SnapshotFlusher {
+	f := &SnapshotFlusher{
+		tracker:   tracker,
+		path:      config.Path,
+		interval:  config.FlushInterval,
+		maxEvents: int64(config.FlushMaxEvents),
+		triggerC:  make(chan struct{}, 1),
+		stopCh:    make(chan struct{}),
+		doneCh:    make(chan struct{}),
+	}
+	tracker.AddListener(f.onEvent)
+	return f
+}
+
+func (f *SnapshotFlusher) onEvent(models.NavigationEvent) {
+	if pending := f.pending.Add(1); f.maxEvents > 0 && pending >= f.maxEvents {
+		select {
+		case f.triggerC <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (f *SnapshotFlusher) Start() {
+	if f.interval <= 0 || !f.started.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer close(f.doneCh)
+
+		ticker := time.NewTicker(f.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-f.triggerC:
+			case <-f.stopCh:
+				return
+			}
+
+			if f.pending.Load() > 0 {
+				if err := f.Flush(); err != nil {
+					log.Printf("Snapshot flush failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop ends background flushing and waits for an in-flight flush. It does not
+// flush; call Flush afterwards to persist the remaining events.
+func (f *SnapshotFlusher) Stop() {
+	f.stopOnce.Do(func() {
+		close(f.stopCh)
+	})
+	if f.started.Load() {
+		<-f.doneCh
+	}
+}
+
+// Flush writes a snapshot now. Events recorded while it is being taken stay
+// pending for the next flush.
+func (f *SnapshotFlusher) Flush() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	pending := f.pending.Load()
+	start := time.Now()
+	err := f.tracker.SaveSnapshot(f.path)
+	f.lastFlushDuration = time.Since(start)
+
+	if err != nil {
+		f.failedFlushes++
+		return err
+	}
+
+	f.pending.Add(-pending)
+	f.flushes++
+	f.lastFlush = start.UTC()
+	return nil
+}
+
+func (f *SnapshotFlusher) Stats() *models.FlushStats {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return &models.FlushStats{
+		PendingEvents:     f.pending.Load(),
+		Flushes:           f.flushes,
+		FailedFlushes:     f.failedFlushes,
+		LastFlush:         f.lastFlush,
+		LastFlushDuration: f.lastFlushDuration,
+		MaxLossWindow:     f.interval,
+	}
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestSnapshotFlusher_FlushesOnEventThreshold(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	tracker := NewNavigationTracker()
+	flusher := NewSnapshotFlusher(tracker, models.SnapshotConfig{
+		Path:           path,
+		FlushInterval:  time.Hour,
+		FlushMaxEvents: 3,
+	})
+	flusher.Start()
+	defer flusher.Stop()
+
+	for _, visitor := range []string{"v1", "v2", "v3"} {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: visitor, URL: "https://example.com/"}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for flusher.Stats().Flushes == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	stats := flusher.Stats()
+	if stats.Flushes != 1 || stats.PendingEvents != 0 {
+		t.Fatalf("Expected one flush with nothing pending, got %+v", stats)
+	}
+	if stats.MaxLossWindow != time.Hour {
+		t.Errorf("Expected max loss window of 1h, got %v", stats.MaxLossWindow)
+	}
+
+	restored := NewNavigationTracker()
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if visitors := restored.GetDistinctVisitors("https://example.com/"); visitors != 3 {
+		t.Errorf("Expected 3 visitors persisted, got %d", visitors)
+	}
+}
+
+func TestSnapshotFlusher_FlushesOnInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	tracker := NewNavigationTracker()
+	flusher := NewSnapshotFlusher(tracker, models.SnapshotConfig{
+		Path:          path,
+		FlushInterval: 10 * time.Millisecond,
+	})
+	flusher.Start()
+
+	time.Sleep(30 * time.Millisecond)
+	if flushes := flusher.Stats().Flushes; flushes != 0 {
+		t.Errorf("Expected no flushes without pending events, got %d", flushes)
+	}
+
+	tracker.RecordEvent(&models.NavigationEvent{VisitorID: "v1", URL: "https://example.com/"})
+	time.Sleep(50 * time.Millisecond)
+	flusher.Stop()
+
+	if flushes := flusher.Stats().Flushes; flushes != 1 {
+		t.Errorf("Expected the pending event to be flushed once, got %d", flushes)
+	}
+}
+
+func TestSnapshotFlusher_StopWithoutStart(t *testing.T) {
+	flusher := NewSnapshotFlusher(NewNavigationTracker(), models.SnapshotConfig{Path: "unused"})
+	flusher.Start()
+	flusher.Stop()
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+const (
+	MinFunnelSteps = 2
+	MaxFunnelSteps = 20
+)
+
+// FunnelStore holds funnel definitions. Reports are computed on demand from
+// the visitor navigation paths kept by the SessionStore.
+type FunnelStore struct {
+	funnels map[string]*models.Funnel
+	nextID  int
+	mutex   sync.RWMutex
+	// validation returns the rules steps are validated against, the
+	// defaults when nil.
+	validation func() *models.ValidationRules
+}
+
+func NewFunnelStore() *FunnelStore {
+	return &FunnelStore{
+		funnels: make(map[string]*models.Funnel),
+	}
+}
+
+// Create validates and normalizes steps and stores a new funnel.
+func (fs *FunnelStore) Create(name string, steps []string) (*models.Funnel, error) {
+	if len(steps) < MinFunnelSteps || len(steps) > MaxFunnelSteps {
+		return nil, fmt.Errorf("funnel must have between %d and %d steps", MinFunnelSteps, MaxFunnelSteps)
+	}
+
+	rules := models.DefaultValidationRules()
+	if fs.validation != nil {
+		rules = fs.validation()
+	}
+	normalized := make([]string, len(steps))
+	for i, step := range steps {
+		if err := rules.ValidateURL(step); err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+		normalized[i] = models.NormalizeURL(step)
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	fs.nextID++
+	funnel := &models.Funnel{
+		ID:        fmt.Sprintf("funnel_%d", fs.nextID),
+		Name:      name,
+		Steps:     normalized,
+		CreatedAt: time.Now().UTC(),
+	}
+	fs.funnels[funnel.ID] = funnel
+
+	return funnel, nil
+}
+
+func (fs *FunnelStore) Get(id string) (*models.Funnel, bool) {
+	fs.mutex.RLock()
+	defer fs.mutex.RUnlock()
+
+	funnel, exists := fs.funnels[id]
+	return funnel, exists
+}
+
+// List returns all funnels ordered by creation.
+func (fs *FunnelStore) List() []*models.Funnel {
+	fs.mutex.RLock()
+	defer fs.mutex.RUnlock()
+
+	funnels := make([]*models.Funnel, 0, len(fs.funnels))
+	for _, funnel := range fs.funnels {
+		funnels = append(funnels, funnel)
+	}
+
+	sort.Slice(funnels, func(i, j int) bool {
+		return funnels[i].CreatedAt.Before(funnels[j].CreatedAt) ||
+			(funnels[i].CreatedAt.Equal(funnels[j].CreatedAt) && funnels[i].ID < funnels[j].ID)
+	})
+
+	return funnels
+}
+
+// FunnelProgress returns, for each step, how many visitors reached it having
+// visited every previous step earlier in their navigation path. Steps need
+// not be consecutive and may span sessions.
+func (ss *SessionStore) FunnelProgress(steps []string) []int {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	counts := make([]int, len(steps))
+	for _, visitorSessions := range ss.sessions {
+		reached := 0
+		for _, s := range visitorSessions {
+			for _, url := range s.urls {
+				if reached < len(steps) && url == steps[reached] {
+					reached++
+				}
+			}
+		}
+
+		for i := 0; i < reached; i++ {
+			counts[i]++
+		}
+	}
+
+	return counts
+}
+
+// FunnelReport computes per-step visitor counts, conversion from the first
+// step and drop-off from the previous step.
+func (nt *NavigationTracker) FunnelReport(funnel *models.Funnel) *models.FunnelReport {
+	counts := nt.sessions.FunnelProgress(funnel.Steps)
+
+	report := &models.FunnelReport{
+		FunnelID:    funnel.ID,
+		Name:        funnel.Name,
+		Steps:       make([]models.FunnelStep, len(funnel.Steps)),
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	for i, url := range funnel.Steps {
+		step := models.FunnelStep{URL: url, Visitors: counts[i]}
+		if counts[0] > 0 {
+			step.ConversionRate = float64(counts[i]) / float64(counts[0])
+		}
+		if i > 0 && counts[i-1] > 0 {
+			step.DropOffRate = 1 - float64(counts[i])/float64(counts[i-1])
+		}
+		report.Steps[i] = step
+	}
+
+	return report
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestFunnelStore_Create(t *testing.T) {
+	store := NewFunnelStore()
+
+	funnel, err := store.Create("signup", []string{"https://Example.com/Landing/", "https://example.com/signup"})
+	if err != nil {
+		t.Fatalf("Failed to create funnel: %v", err)
+	}
+
+	if funnel.Steps[0] != "https://example.com/landing" {
+		t.Errorf("Expected normalized step, got %s", funnel.Steps[0])
+	}
+
+	if got, ok := store.Get(funnel.ID); !ok || got.Name != "signup" {
+		t.Errorf("Expected to find funnel %s", funnel.ID)
+	}
+
+	if _, err := store.Create("short", []string{"https://example.com"}); err == nil {
+		t.Error("Expected error for single-step funnel")
+	}
+
+	if _, err := store.Create("bad", []string{"https://example.com", "not a url"}); err == nil {
+		t.Error("Expected error for invalid step URL")
+	}
+}
+
+func TestNavigationTracker_FunnelReport(t *testing.T) {
+	tracker := NewNavigationTracker()
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	paths := map[string][]string{
+		"visitor1": {"/landing", "/pricing", "/signup"},
+		"visitor2": {"/landing", "/about", "/pricing"},
+		"visitor3": {"/pricing", "/landing"},
+		"visitor4": {"/signup"},
+	}
+	for visitorID, path := range paths {
+		for i, p := range path {
+			event := &models.NavigationEvent{
+				VisitorID: visitorID,
+				URL:       "https://example.com" + p,
+				Timestamp: base.Add(time.Duration(i) * time.Minute),
+			}
+			if err := tracker.RecordEvent(event); err != nil {
+				t.Fatalf("Failed to record event: %v", err)
+			}
+		}
+	}
+
+	funnel, err := tracker.Funnels().Create("signup", []string{
+		"https://example.com/landing",
+		"https://example.com/pricing",
+		"https://example.com/signup",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create funnel: %v", err)
+	}
+
+	report := tracker.FunnelReport(funnel)
+
+	expected := []int{3, 2, 1}
+	for i, step := range report.Steps {
+		if step.Visitors != expected[i] {
+			t.Errorf("Step %d: expected %d visitors, got %d", i, expected[i], step.Visitors)
+		}
+	}
+
+	if report.Steps[1].DropOffRate < 0.33 || report.Steps[1].DropOffRate > 0.34 {
+		t.Errorf("Expected step 2 drop-off of 1/3, got %f", report.Steps[1].DropOffRate)
+	}
+
+	if report.Steps[2].ConversionRate < 0.33 || report.Steps[2].ConversionRate > 0.34 {
+		t.Errorf("Expected overall conversion of 1/3, got %f", report.Steps[2].ConversionRate)
+	}
+}
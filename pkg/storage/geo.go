@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"sort"
+
+	"nav-tracker/pkg/models"
+)
+
+// UnknownCountry buckets events that carry no resolved country.
+const UnknownCountry = "unknown"
+
+type countryStats struct {
+	visitors  int
+	pageViews int64
+}
+
+// recordCountry attributes a visitor to the country of their first event on
+// the URL, so each visitor is counted in exactly one country.
+func (s *urlStats) recordCountry(country string, newVisitor bool) {
+	if country == "" {
+		country = UnknownCountry
+	}
+
+	stats := s.countries[country]
+	if stats == nil {
+		stats = &countryStats{}
+		s.countries[country] = stats
+	}
+
+	if newVisitor {
+		stats.visitors++
+	}
+	stats.pageViews++
+}
+
+// GetGeoBreakdown returns distinct visitors and page views per country for
+// url, ordered by visitors descending.
+func (nt *NavigationTracker) GetGeoBreakdown(url string) []*models.CountryStats {
+	nt.promote(url)
+	shard := nt.urlShard(url)
+	shard.mutex.RLock()
+	stats, exists := shard.lookup(url)
+	if !exists {
+		shard.mutex.RUnlock()
+		return []*models.CountryStats{}
+	}
+
+	breakdown := make([]*models.CountryStats, 0, len(stats.countries))
+	for country, cs := range stats.countries {
+		breakdown = append(breakdown, &models.CountryStats{
+			Country:          country,
+			DistinctVisitors: cs.visitors,
+			PageViews:        cs.pageViews,
+		})
+	}
+	shard.mutex.RUnlock()
+
+	sort.Slice(breakdown, func(i, j int) bool {
+		if breakdown[i].DistinctVisitors != breakdown[j].DistinctVisitors {
+			return breakdown[i].DistinctVisitors > breakdown[j].DistinctVisitors
+		}
+		return breakdown[i].Country < breakdown[j].Country
+	})
+
+	return breakdown
+}
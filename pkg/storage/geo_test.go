@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestNavigationTracker_GetGeoBreakdown(t *testing.T) {
+	tracker := NewNavigationTracker()
+
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", Country: "KE"},
+		{VisitorID: "visitor1", URL: "https://example.com/a", Country: "US"},
+		{VisitorID: "visitor2", URL: "https://example.com/a", Country: "KE"},
+		{VisitorID: "visitor3", URL: "https://example.com/a"},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	breakdown := tracker.GetGeoBreakdown("https://example.com/a")
+	if len(breakdown) != 3 {
+		t.Fatalf("Expected 3 countries, got %d", len(breakdown))
+	}
+
+	if breakdown[0].Country != "KE" || breakdown[0].DistinctVisitors != 2 || breakdown[0].PageViews != 2 {
+		t.Errorf("Expected KE with 2 visitors and 2 page views first, got %+v", breakdown[0])
+	}
+
+	counts := make(map[string]int)
+	for _, country := range breakdown {
+		counts[country.Country] = country.DistinctVisitors
+	}
+	if counts["US"] != 0 || counts[UnknownCountry] != 1 {
+		t.Errorf("Expected visitor1 attributed only to first country, got %v", counts)
+	}
+
+	if empty := tracker.GetGeoBreakdown("https://example.com/missing"); len(empty) != 0 {
+		t.Errorf("Expected empty breakdown for unknown URL, got %d", len(empty))
+	}
+}
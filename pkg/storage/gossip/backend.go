@@ -0,0 +1,465 @@
+package gossip
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage/internal/hll"
+)
+
+// GossipPath is the endpoint on every node that accepts pushed counters.
+const GossipPath = "/api/v1/cluster/gossip"
+
+// SecretHeader carries the shared cluster secret on gossip requests.
+const SecretHeader = "X-Cluster-Secret"
+
+// Message is what a node pushes to a peer: the counters, keyed by origin and
+// then URL, that changed since its last successful push to that peer.
+// Counters from other origins are relayed too, so nodes converge even when
+// some of them cannot reach each other directly.
+type Message struct {
+	Node     string                        `json:"node"`
+	Counters map[string]map[string]Counter `json:"counters"`
+}
+
+// Counter is one origin's state for a URL. Registers only grow and page
+// views only increase, so merging keeps the larger of each and applying the
+// same counter twice changes nothing.
+type Counter struct {
+	Registers []byte `json:"registers"`
+	PageViews int64  `json:"page_views"`
+}
+
+// Ack is a node's reply to a push. Origin lets the sender notice that the
+// node restarted and lost what it had acknowledged.
+type Ack struct {
+	Origin string `json:"origin"`
+}
+
+type entry struct {
+	registers hll.Sketch
+	pageViews int64
+	version   uint64
+}
+
+// total is the union of every origin's counter for a URL, kept up to date
+// as counters change so reads don't have to merge.
+type total struct {
+	registers hll.Sketch
+	pageViews int64
+}
+
+type peerState struct {
+	origin    string
+	acked     uint64
+	pushes    int64
+	failures  int64
+	lastPush  time.Time
+	lastError string
+}
+
+// Backend implements storage.CounterBackend for replicated mode. Each node
+// records its own events under its origin, a name unique to this run of the
+// process, and merges the counters of every other origin it hears about.
+// Reads return the union, so every node converges on the global counts.
+type Backend struct {
+	config models.ClusterConfig
+	node   string
+	origin string
+	client *http.Client
+
+	mutex     sync.RWMutex
+	origins   map[string]map[string]*entry
+	totals    map[string]*total
+	version   uint64
+	peers     map[string]*peerState
+	merges    int64
+	listeners []func(url string)
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewBackend(config models.ClusterConfig) *Backend {
+	node := config.NodeID
+	if node == "" {
+		node, _ = os.Hostname()
+	}
+
+	peers := make(map[string]*peerState, len(config.Peers))
+	for _, peer := range config.Peers {
+		peers[strings.TrimRight(peer, "/")] = &peerState{}
+	}
+
+	return &Backend{
+		config: config,
+		node:   node,
+		// A restarted node starts a fresh origin, and its peers hand back
+		// the counters of the previous one.
+		origin:  node + "@" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		client:  &http.Client{Timeout: config.Timeout},
+		origins: make(map[string]map[string]*entry),
+		totals:  make(map[string]*total),
+		peers:   peers,
+	}
+}
+
+// AddListener registers fn to be called with each URL whose counts changed
+// because of a merge. It must be called before Start.
+func (b *Backend) AddListener(fn func(url string)) {
+	b.listeners = append(b.listeners, fn)
+}
+
+// Origin names this run of the node.
+func (b *Backend) Origin() string {
+	return b.origin
+}
+
+// Authorized reports whether secret matches the cluster secret.
+func (b *Backend) Authorized(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(b.config.Secret)) == 1
+}
+
+func (b *Backend) Record(event *models.NavigationEvent) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	e := b.entry(b.origin, event.URL)
+	e.registers.Add(event.VisitorID)
+	e.pageViews++
+
+	t := b.total(event.URL)
+	t.registers.Add(event.VisitorID)
+	t.pageViews++
+
+	b.version++
+	e.version = b.version
+	return nil
+}
+
+func (b *Backend) DistinctVisitors(url string) (int, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if t, ok := b.totals[url]; ok {
+		return t.registers.Count(), nil
+	}
+	return 0, nil
+}
+
+func (b *Backend) PageViews(url string) (int64, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if t, ok := b.totals[url]; ok {
+		return t.pageViews, nil
+	}
+	return 0, nil
+}
+
+// ForgetVisitor does nothing: sketches keep no visitor IDs and cannot drop
+// one, so a forgotten visitor stays counted.
+func (b *Backend) ForgetVisitor(visitorID string) error {
+	return nil
+}
+
+// DeleteURL drops every origin's counter for url on this node. Peers still
+// holding counters for url push them again, so in a cluster the URL has to
+// be deleted on every node, as with the rest of its statistics.
+func (b *Backend) DeleteURL(url string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, urls := range b.origins {
+		delete(urls, url)
+	}
+	delete(b.totals, url)
+	return nil
+}
+
+// MergeURL folds each origin's counter for source into its counter for
+// target and drops source, as DeleteURL does. The merged counters are
+// pushed to peers like any other change.
+func (b *Backend) MergeURL(source, target string) error {
+	if source == target {
+		return nil
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for origin, urls := range b.origins {
+		src, ok := urls[source]
+		if !ok {
+			continue
+		}
+		dst := b.entry(origin, target)
+		dst.registers.Merge(src.registers)
+		dst.pageViews += src.pageViews
+		b.version++
+		dst.version = b.version
+		delete(urls, source)
+	}
+	if src, ok := b.totals[source]; ok {
+		t := b.total(target)
+		t.registers.Merge(src.registers)
+		t.pageViews += src.pageViews
+		delete(b.totals, source)
+	}
+	return nil
+}
+
+// entry returns origin's counter for url, creating it. The caller must hold
+// the write lock.
+func (b *Backend) entry(origin, url string) *entry {
+	urls, ok := b.origins[origin]
+	if !ok {
+		urls = make(map[string]*entry)
+		b.origins[origin] = urls
+	}
+	e, ok := urls[url]
+	if !ok {
+		e = &entry{registers: hll.New(b.config.Precision)}
+		urls[url] = e
+	}
+	return e
+}
+
+func (b *Backend) total(url string) *total {
+	t, ok := b.totals[url]
+	if !ok {
+		t = &total{registers: hll.New(b.config.Precision)}
+		b.totals[url] = t
+	}
+	return t
+}
+
+// Merge applies message's counters. It fails without merging anything if a
+// sketch was built with a different precision, since those cannot be
+// combined.
+func (b *Backend) Merge(message Message) error {
+	size := 1 << b.config.Precision
+	for _, urls := range message.Counters {
+		for url, counter := range urls {
+			if len(counter.Registers) != size {
+				return fmt.Errorf("counter for %q has %d registers, want %d: every node must use the same precision", url, len(counter.Registers), size)
+			}
+		}
+	}
+
+	var changed []string
+	b.mutex.Lock()
+	for origin, urls := range message.Counters {
+		for url, counter := range urls {
+			e := b.entry(origin, url)
+			grew := e.registers.Merge(counter.Registers)
+			t := b.total(url)
+			if counter.PageViews > e.pageViews {
+				t.pageViews += counter.PageViews - e.pageViews
+				e.pageViews = counter.PageViews
+				grew = true
+			}
+			if !grew {
+				continue
+			}
+			t.registers.Merge(counter.Registers)
+			b.version++
+			e.version = b.version
+			changed = append(changed, url)
+		}
+	}
+	b.merges++
+	b.mutex.Unlock()
+
+	for _, url := range changed {
+		for _, fn := range b.listeners {
+			fn(url)
+		}
+	}
+	return nil
+}
+
+// pending collects the counters changed since version, along with the
+// version they are current as of.
+func (b *Backend) pending(since uint64) (map[string]map[string]Counter, uint64) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	counters := make(map[string]map[string]Counter)
+	for origin, urls := range b.origins {
+		for url, e := range urls {
+			if e.version <= since {
+				continue
+			}
+			if counters[origin] == nil {
+				counters[origin] = make(map[string]Counter)
+			}
+			counters[origin][url] = Counter{
+				Registers: append([]byte(nil), e.registers...),
+				PageViews: e.pageViews,
+			}
+		}
+	}
+	return counters, b.version
+}
+
+// push sends peer everything it hasn't acknowledged yet.
+func (b *Backend) push(ctx context.Context, peer string) error {
+	b.mutex.RLock()
+	acked := b.peers[peer].acked
+	b.mutex.RUnlock()
+
+	counters, version := b.pending(acked)
+	if len(counters) == 0 {
+		return nil
+	}
+
+	ack, err := b.send(ctx, peer, Message{Node: b.node, Counters: counters})
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	state := b.peers[peer]
+	state.pushes++
+	state.lastPush = time.Now()
+	if err != nil {
+		state.failures++
+		state.lastError = err.Error()
+		return err
+	}
+	state.lastError = ""
+	if state.origin != "" && ack.Origin != state.origin {
+		// The peer restarted, so send it everything on the next push
+		state.origin = ack.Origin
+		state.acked = 0
+		return nil
+	}
+	state.origin = ack.Origin
+	if version > state.acked {
+		state.acked = version
+	}
+	return nil
+}
+
+func (b *Backend) send(ctx context.Context, peer string, message Message) (Ack, error) {
+	var ack Ack
+	body, err := json.Marshal(message)
+	if err != nil {
+		return ack, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, peer+GossipPath, bytes.NewReader(body))
+	if err != nil {
+		return ack, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SecretHeader, b.config.Secret)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return ack, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return ack, fmt.Errorf("peer %s responded with status %d", peer, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&ack); err != nil {
+		return ack, fmt.Errorf("peer %s sent an invalid acknowledgement: %v", peer, err)
+	}
+	return ack, nil
+}
+
+// Gossip pushes pending counters to every peer concurrently.
+func (b *Backend) Gossip(ctx context.Context) {
+	var wg sync.WaitGroup
+	for peer := range b.peers {
+		wg.Add(1)
+		go func(peer string) {
+			defer wg.Done()
+			b.push(ctx, peer)
+		}(peer)
+	}
+	wg.Wait()
+}
+
+// Start gossips every Interval until Stop is called.
+func (b *Backend) Start() error {
+	b.stop = make(chan struct{})
+	b.done = make(chan struct{})
+
+	go func() {
+		defer close(b.done)
+		ticker := time.NewTicker(b.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				b.Gossip(context.Background())
+			case <-b.stop:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop ends the gossip loop and makes a final push, so peers keep this
+// node's latest counts after it leaves.
+func (b *Backend) Stop(ctx context.Context) error {
+	if b.stop == nil {
+		return nil
+	}
+	close(b.stop)
+	<-b.done
+	b.stop = nil
+
+	b.Gossip(ctx)
+	return nil
+}
+
+// Status describes this node, the counters it holds and its peers.
+func (b *Backend) Status() models.ClusterStatus {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	status := models.ClusterStatus{
+		Node:    b.node,
+		Origin:  b.origin,
+		Origins: len(b.origins),
+		URLs:    len(b.totals),
+		Merges:  b.merges,
+		Peers:   make([]models.ClusterPeer, 0, len(b.peers)),
+	}
+	for _, peer := range b.config.Peers {
+		url := strings.TrimRight(peer, "/")
+		state := b.peers[url]
+		pending := 0
+		for _, urls := range b.origins {
+			for _, e := range urls {
+				if e.version > state.acked {
+					pending++
+				}
+			}
+		}
+		status.Peers = append(status.Peers, models.ClusterPeer{
+			URL:       url,
+			Pushes:    state.pushes,
+			Failures:  state.failures,
+			Pending:   pending,
+			LastPush:  state.lastPush,
+			LastError: state.lastError,
+		})
+	}
+	return status
+}
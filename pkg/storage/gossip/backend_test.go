@@ -0,0 +1,256 @@
+package gossip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func testConfig(peers ...string) models.ClusterConfig {
+	return models.ClusterConfig{
+		Enabled:   true,
+		Peers:     peers,
+		Secret:    "secret",
+		Interval:  time.Hour,
+		Timeout:   time.Second,
+		Precision: 10,
+	}
+}
+
+// serve exposes backend's gossip endpoint the way the server does.
+func serve(backend **Backend) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !(*backend).Authorized(r.Header.Get(SecretHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var message Message
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := (*backend).Merge(message); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(Ack{Origin: (*backend).Origin()})
+	}))
+}
+
+func record(b *Backend, url string, visitors ...string) {
+	for _, visitor := range visitors {
+		b.Record(&models.NavigationEvent{URL: url, VisitorID: visitor})
+	}
+}
+
+func TestBackend_Converges(t *testing.T) {
+	var a, b, c *Backend
+	serverA, serverB, serverC := serve(&a), serve(&b), serve(&c)
+	defer serverA.Close()
+	defer serverB.Close()
+	defer serverC.Close()
+
+	// A line: a and c only reach each other through b
+	a = NewBackend(testConfig(serverB.URL))
+	b = NewBackend(testConfig(serverA.URL, serverC.URL))
+	c = NewBackend(testConfig(serverB.URL))
+
+	record(a, "/home", "v1", "v2", "v3")
+	record(b, "/home", "v2", "v4")
+	record(c, "/home", "v5", "v1")
+	record(c, "/about", "v1")
+
+	for round := 0; round < 2; round++ {
+		for _, backend := range []*Backend{a, b, c} {
+			backend.Gossip(context.Background())
+		}
+	}
+
+	for name, backend := range map[string]*Backend{"a": a, "b": b, "c": c} {
+		if visitors, _ := backend.DistinctVisitors("/home"); visitors != 5 {
+			t.Errorf("%s: expected 5 distinct visitors on /home, got %d", name, visitors)
+		}
+		if views, _ := backend.PageViews("/home"); views != 7 {
+			t.Errorf("%s: expected 7 page views on /home, got %d", name, views)
+		}
+		if visitors, _ := backend.DistinctVisitors("/about"); visitors != 1 {
+			t.Errorf("%s: expected 1 distinct visitor on /about, got %d", name, visitors)
+		}
+	}
+}
+
+func TestBackend_MergeIsIdempotent(t *testing.T) {
+	source := NewBackend(testConfig())
+	record(source, "/home", "v1", "v2")
+	counters, _ := source.pending(0)
+	message := Message{Node: "source", Counters: counters}
+
+	target := NewBackend(testConfig())
+	record(target, "/home", "v1")
+
+	notified := 0
+	target.AddListener(func(url string) { notified++ })
+
+	for i := 0; i < 3; i++ {
+		if err := target.Merge(message); err != nil {
+			t.Fatalf("Merge failed: %v", err)
+		}
+	}
+
+	if views, _ := target.PageViews("/home"); views != 3 {
+		t.Errorf("Expected 3 page views after repeated merges, got %d", views)
+	}
+	if visitors, _ := target.DistinctVisitors("/home"); visitors != 2 {
+		t.Errorf("Expected 2 distinct visitors, got %d", visitors)
+	}
+	if notified != 1 {
+		t.Errorf("Expected listeners to be notified once, got %d", notified)
+	}
+}
+
+func TestBackend_MergeAndDeleteURL(t *testing.T) {
+	backend := NewBackend(testConfig())
+	record(backend, "/old", "v1", "v2")
+	record(backend, "/new", "v2", "v3")
+
+	if err := backend.MergeURL("/old", "/new"); err != nil {
+		t.Fatalf("MergeURL failed: %v", err)
+	}
+	if visitors, _ := backend.DistinctVisitors("/new"); visitors != 3 {
+		t.Errorf("Expected 3 distinct visitors after the merge, got %d", visitors)
+	}
+	if views, _ := backend.PageViews("/new"); views != 4 {
+		t.Errorf("Expected 4 page views after the merge, got %d", views)
+	}
+	counters, _ := backend.pending(0)
+	if _, ok := counters[backend.Origin()]["/old"]; ok {
+		t.Error("Expected the merged URL not to be pushed")
+	}
+
+	if err := backend.DeleteURL("/new"); err != nil {
+		t.Fatalf("DeleteURL failed: %v", err)
+	}
+	if views, _ := backend.PageViews("/new"); views != 0 {
+		t.Errorf("Expected the URL deleted, got %d page views", views)
+	}
+}
+
+func TestBackend_MergeRejectsOtherPrecision(t *testing.T) {
+	backend := NewBackend(testConfig())
+	message := Message{Counters: map[string]map[string]Counter{
+		"other": {"/home": {Registers: make([]byte, 16), PageViews: 1}},
+	}}
+
+	if err := backend.Merge(message); err == nil {
+		t.Fatal("Expected an error for a sketch of another precision")
+	}
+	if views, _ := backend.PageViews("/home"); views != 0 {
+		t.Errorf("Expected nothing merged, got %d page views", views)
+	}
+}
+
+func TestBackend_PushOnlySendsChanges(t *testing.T) {
+	var target *Backend
+	server := serve(&target)
+	defer server.Close()
+	target = NewBackend(testConfig())
+
+	backend := NewBackend(testConfig(server.URL))
+	record(backend, "/home", "v1")
+	backend.Gossip(context.Background())
+
+	peer := backend.Status().Peers[0]
+	if peer.Pushes != 1 || peer.Pending != 0 || peer.LastError != "" {
+		t.Fatalf("Unexpected peer status after push: %+v", peer)
+	}
+
+	backend.Gossip(context.Background())
+	if pushes := backend.Status().Peers[0].Pushes; pushes != 1 {
+		t.Errorf("Expected no push without changes, got %d pushes", pushes)
+	}
+
+	record(backend, "/about", "v2")
+	if pending := backend.Status().Peers[0].Pending; pending != 1 {
+		t.Errorf("Expected 1 pending counter, got %d", pending)
+	}
+}
+
+func TestBackend_RejectedPushIsRetried(t *testing.T) {
+	var target *Backend
+	server := serve(&target)
+	defer server.Close()
+	target = NewBackend(testConfig())
+
+	config := testConfig(server.URL)
+	config.Secret = "wrong"
+	backend := NewBackend(config)
+	record(backend, "/home", "v1")
+	backend.Gossip(context.Background())
+
+	peer := backend.Status().Peers[0]
+	if peer.Failures != 1 || peer.Pending != 1 || peer.LastError == "" {
+		t.Fatalf("Expected a failed push with the counter still pending, got %+v", peer)
+	}
+	if views, _ := target.PageViews("/home"); views != 0 {
+		t.Errorf("Expected the push to be rejected, got %d page views", views)
+	}
+}
+
+func TestBackend_RestartRecoversCounts(t *testing.T) {
+	var a, b *Backend
+	serverA, serverB := serve(&a), serve(&b)
+	defer serverA.Close()
+	defer serverB.Close()
+
+	a = NewBackend(testConfig(serverB.URL))
+	b = NewBackend(testConfig(serverA.URL))
+	for i := 0; i < 20; i++ {
+		record(a, "/home", fmt.Sprintf("visitor-%d", i))
+	}
+	a.Gossip(context.Background())
+	b.Gossip(context.Background())
+
+	// a restarts with empty state under a new origin. b's first push finds
+	// out and the next one resends everything.
+	a = NewBackend(testConfig(serverB.URL))
+	record(a, "/home", "visitor-0")
+	record(b, "/about", "visitor-0")
+	b.Gossip(context.Background())
+	if views, _ := a.PageViews("/home"); views != 1 {
+		t.Fatalf("Expected only a's own page view before the resend, got %d", views)
+	}
+	b.Gossip(context.Background())
+
+	if views, _ := a.PageViews("/home"); views != 21 {
+		t.Errorf("Expected 21 page views after recovery, got %d", views)
+	}
+	if origins := a.Status().Origins; origins != 3 {
+		t.Errorf("Expected counters from both of a's origins and b's, got %d", origins)
+	}
+}
+
+func TestBackend_StopMakesFinalPush(t *testing.T) {
+	var target *Backend
+	server := serve(&target)
+	defer server.Close()
+	target = NewBackend(testConfig())
+
+	backend := NewBackend(testConfig(server.URL))
+	if err := backend.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	record(backend, "/home", "v1")
+	if err := backend.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	if views, _ := target.PageViews("/home"); views != 1 {
+		t.Errorf("Expected the final push to deliver 1 page view, got %d", views)
+	}
+}
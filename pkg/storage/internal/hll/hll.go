@@ -0,0 +1,93 @@
+// Package hll implements the HyperLogLog sketch shared by the tracker's
+// approximate counters and the replicated backend. Every sketch hashes
+// alike, so those built apart, such as on different nodes, can be merged.
+package hll
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// Sketch estimates distinct values in 2^p one-byte registers, each holding
+// the longest run of leading zeros seen among the hashes routed to it.
+// Merging keeps the larger of each register, so merges commute and repeating
+// one changes nothing.
+type Sketch []byte
+
+// New returns an empty sketch of 2^precision registers.
+func New(precision int) Sketch {
+	return make(Sketch, 1<<precision)
+}
+
+// Add records value, reporting whether a register grew.
+func (s Sketch) Add(value string) bool {
+	hash := hashValue(value)
+	p := bits.TrailingZeros(uint(len(s)))
+	index := hash >> (64 - p)
+	rank := byte(bits.LeadingZeros64(hash<<p|1<<(p-1)) + 1)
+	if rank > s[index] {
+		s[index] = rank
+		return true
+	}
+	return false
+}
+
+// Merge raises s to other register by register, reporting whether any grew.
+// Both must have the same precision.
+func (s Sketch) Merge(other Sketch) bool {
+	grew := false
+	for i, r := range other {
+		if r > s[i] {
+			s[i] = r
+			grew = true
+		}
+	}
+	return grew
+}
+
+// Count returns the estimated number of distinct values added.
+func (s Sketch) Count() int {
+	m := float64(len(s))
+	sum := 0.0
+	zeros := 0
+	for _, r := range s {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	var alpha float64
+	switch len(s) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// Linear counting is more accurate while many registers are empty
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int(math.Round(estimate))
+}
+
+// hashValue is FNV-1a followed by a 64-bit finalizer, so that the high bits
+// used to pick a register are well mixed.
+func hashValue(value string) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(value))
+	h := hasher.Sum64()
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
@@ -0,0 +1,35 @@
+package hll
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSketch_Count(t *testing.T) {
+	s := New(12)
+	for i := 0; i < 10000; i++ {
+		s.Add(fmt.Sprintf("visitor-%d", i))
+	}
+
+	if count := s.Count(); count < 9500 || count > 10500 {
+		t.Errorf("Expected about 10000, got %d", count)
+	}
+}
+
+func TestSketch_Merge(t *testing.T) {
+	a, b := New(12), New(12)
+	for i := 0; i < 1000; i++ {
+		a.Add(fmt.Sprintf("visitor-%d", i))
+		b.Add(fmt.Sprintf("visitor-%d", i+500))
+	}
+
+	if !a.Merge(b) {
+		t.Error("Expected the merge to grow a register")
+	}
+	if a.Merge(b) {
+		t.Error("Expected merging again to change nothing")
+	}
+	if count := a.Count(); count < 1425 || count > 1575 {
+		t.Errorf("Expected about 1500, got %d", count)
+	}
+}
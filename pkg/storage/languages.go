@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"sort"
+
+	"nav-tracker/pkg/models"
+)
+
+// UnknownLanguage buckets events that carry no language.
+const UnknownLanguage = "unknown"
+
+type languageStats struct {
+	visitors  int
+	pageViews int64
+}
+
+// visitorLanguage is the language a visitor is counted in across every URL,
+// that of their first event, and their page views since.
+type visitorLanguage struct {
+	language  string
+	pageViews int64
+}
+
+// recordLanguage attributes a visitor to the language of their first event
+// on the URL, so each visitor is counted in exactly one language.
+func (s *urlStats) recordLanguage(language string, newVisitor bool) {
+	if language == "" {
+		language = UnknownLanguage
+	}
+
+	stats := s.languages[language]
+	if stats == nil {
+		stats = &languageStats{}
+		s.languages[language] = stats
+	}
+
+	if newVisitor {
+		stats.visitors++
+	}
+	stats.pageViews++
+}
+
+// recordLanguage counts a page view by visitorID towards the language of
+// their first event on any URL.
+func (nt *NavigationTracker) recordLanguage(visitorID, language string) {
+	if language == "" {
+		language = UnknownLanguage
+	}
+
+	shard := nt.visitorShard(visitorID)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	vl := shard.languages[visitorID]
+	if vl == nil {
+		vl = &visitorLanguage{language: language}
+		shard.languages[visitorID] = vl
+	}
+	vl.pageViews++
+}
+
+// GetLanguageBreakdown returns distinct visitors and page views per language
+// for url, or across every URL when url is empty, ordered by visitors
+// descending. Across URLs, a visitor is counted once, in the language of
+// their first event, and page views by excluded visitors are left out.
+func (nt *NavigationTracker) GetLanguageBreakdown(url string) []*models.LanguageStats {
+	if url == "" {
+		return nt.globalLanguageBreakdown()
+	}
+
+	nt.promote(url)
+	shard := nt.urlShard(url)
+	shard.mutex.RLock()
+	stats, exists := shard.lookup(url)
+	if !exists {
+		shard.mutex.RUnlock()
+		return []*models.LanguageStats{}
+	}
+
+	breakdown := make([]*models.LanguageStats, 0, len(stats.languages))
+	for language, ls := range stats.languages {
+		breakdown = append(breakdown, &models.LanguageStats{
+			Language:         language,
+			DistinctVisitors: ls.visitors,
+			PageViews:        ls.pageViews,
+		})
+	}
+	shard.mutex.RUnlock()
+
+	sortLanguageBreakdown(breakdown)
+	return breakdown
+}
+
+func (nt *NavigationTracker) globalLanguageBreakdown() []*models.LanguageStats {
+	totals := make(map[string]*models.LanguageStats)
+	for _, shard := range nt.visitorShards {
+		shard.mutex.RLock()
+		for _, vl := range shard.languages {
+			ls := totals[vl.language]
+			if ls == nil {
+				ls = &models.LanguageStats{Language: vl.language}
+				totals[vl.language] = ls
+			}
+			ls.DistinctVisitors++
+			ls.PageViews += vl.pageViews
+		}
+		shard.mutex.RUnlock()
+	}
+
+	breakdown := make([]*models.LanguageStats, 0, len(totals))
+	for _, ls := range totals {
+		breakdown = append(breakdown, ls)
+	}
+	sortLanguageBreakdown(breakdown)
+	return breakdown
+}
+
+func sortLanguageBreakdown(breakdown []*models.LanguageStats) {
+	sort.Slice(breakdown, func(i, j int) bool {
+		if breakdown[i].DistinctVisitors != breakdown[j].DistinctVisitors {
+			return breakdown[i].DistinctVisitors > breakdown[j].DistinctVisitors
+		}
+		return breakdown[i].Language < breakdown[j].Language
+	})
+}
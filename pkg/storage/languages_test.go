@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestPrimaryLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"en-US,en;q=0.9,fr;q=0.8", "en-US"},
+		{"fr;q=0.5, de-ch;q=0.9, en;q=0.7", "de-CH"},
+		{"zh-hant-tw", "zh-Hant-TW"},
+		{"en_gb", "en-GB"},
+		{"*;q=1, es;q=0.4", "es"},
+		{"en;q=0, pt-BR;q=0.1", "pt-BR"},
+		{"nl, en", "nl"},
+		{"en-x-priv", "en-x-priv"},
+		{"", ""},
+		{"*", ""},
+		{"e1", ""},
+		{"en;q=abc", ""},
+		{"toolongtag-US", ""},
+	}
+
+	for _, tt := range tests {
+		if got := models.PrimaryLanguage(tt.header); got != tt.want {
+			t.Errorf("PrimaryLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestNavigationTracker_GetLanguageBreakdown(t *testing.T) {
+	tracker := NewNavigationTracker()
+
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", Language: "en-us,en;q=0.9"},
+		{VisitorID: "visitor1", URL: "https://example.com/a", Language: "fr"},
+		{VisitorID: "visitor2", URL: "https://example.com/a", Language: "en-US"},
+		{VisitorID: "visitor3", URL: "https://example.com/a"},
+		{VisitorID: "visitor1", URL: "https://example.com/b", Language: "fr"},
+		{VisitorID: "visitor4", URL: "https://example.com/b", Language: "fr"},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	breakdown := tracker.GetLanguageBreakdown("https://example.com/a")
+	if len(breakdown) != 3 {
+		t.Fatalf("Expected 3 languages, got %+v", breakdown)
+	}
+	if breakdown[0].Language != "en-US" || breakdown[0].DistinctVisitors != 2 || breakdown[0].PageViews != 2 {
+		t.Errorf("Expected en-US with 2 visitors and 2 page views first, got %+v", breakdown[0])
+	}
+	counts := make(map[string]int)
+	for _, language := range breakdown {
+		counts[language.Language] = language.DistinctVisitors
+	}
+	if counts["fr"] != 0 || counts[UnknownLanguage] != 1 {
+		t.Errorf("Expected visitor1 attributed only to first language, got %v", counts)
+	}
+
+	// Across URLs each visitor counts once, in the language of their first event
+	global := make(map[string]models.LanguageStats)
+	for _, language := range tracker.GetLanguageBreakdown("") {
+		global[language.Language] = *language
+	}
+	if en := global["en-US"]; en.DistinctVisitors != 2 || en.PageViews != 4 {
+		t.Errorf("Expected 2 en-US visitors with 4 page views, got %+v", en)
+	}
+	if fr := global["fr"]; fr.DistinctVisitors != 1 || fr.PageViews != 1 {
+		t.Errorf("Expected 1 fr visitor with 1 page view, got %+v", fr)
+	}
+
+	tracker.DeleteVisitor("visitor4", "test")
+	for _, language := range tracker.GetLanguageBreakdown("") {
+		if language.Language == "fr" {
+			t.Errorf("Expected fr to be gone after deleting its only visitor, got %+v", language)
+		}
+	}
+
+	if empty := tracker.GetLanguageBreakdown("https://example.com/missing"); len(empty) != 0 {
+		t.Errorf("Expected empty breakdown for unknown URL, got %d", len(empty))
+	}
+}
+
+func TestNavigationTracker_LanguagesSnapshotRoundTrip(t *testing.T) {
+	tracker := NewNavigationTracker()
+	for _, event := range []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", Language: "de"},
+		{VisitorID: "visitor1", URL: "https://example.com/b", Language: "de"},
+		{VisitorID: "visitor2", URL: "https://example.com/a", Language: "ja"},
+	} {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := tracker.SaveSnapshot(path); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	restored := NewNavigationTracker()
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+
+	if languages := restored.GetLanguageBreakdown("https://example.com/a"); len(languages) != 2 {
+		t.Errorf("Expected 2 languages for the URL after restore, got %+v", languages)
+	}
+	global := restored.GetLanguageBreakdown("")
+	if len(global) != 2 || global[0].Language != "de" || global[0].PageViews != 2 {
+		t.Errorf("Expected de with 2 page views first after restore, got %+v", global)
+	}
+}
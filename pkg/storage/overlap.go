@@ -0,0 +1,57 @@
+package storage
+
+import "nav-tracker/pkg/models"
+
+// Overlap counts the visitors of urlA and urlB who visited both or only one
+// of them. It walks the smaller visitor set and looks each visitor up in the
+// other, so it takes time in proportion to the smaller URL's visitors.
+// Visitors without records, such as those counted beyond the visitor cap,
+// are left out.
+func (nt *NavigationTracker) Overlap(urlA, urlB string) *models.URLOverlap {
+	nt.promote(urlA)
+	nt.promote(urlB)
+
+	shardA, shardB := nt.urlShard(urlA), nt.urlShard(urlB)
+	// Lock in shard order so that concurrent overlaps can't deadlock
+	first, second := shardA, shardB
+	if shardIndex(urlB) < shardIndex(urlA) {
+		first, second = shardB, shardA
+	}
+	first.mutex.RLock()
+	defer first.mutex.RUnlock()
+	if second != first {
+		second.mutex.RLock()
+		defer second.mutex.RUnlock()
+	}
+
+	var visitorsA, visitorsB map[string]*visitorRecord
+	if stats, exists := shardA.lookup(urlA); exists {
+		visitorsA = stats.visitors
+	}
+	if stats, exists := shardB.lookup(urlB); exists {
+		visitorsB = stats.visitors
+	}
+
+	smaller, larger := visitorsA, visitorsB
+	if len(smaller) > len(larger) {
+		smaller, larger = larger, smaller
+	}
+	both := 0
+	for visitorID := range smaller {
+		if _, exists := larger[visitorID]; exists {
+			both++
+		}
+	}
+
+	overlap := &models.URLOverlap{
+		URLA:  urlA,
+		URLB:  urlB,
+		Both:  both,
+		OnlyA: len(visitorsA) - both,
+		OnlyB: len(visitorsB) - both,
+	}
+	if either := both + overlap.OnlyA + overlap.OnlyB; either > 0 {
+		overlap.Jaccard = float64(both) / float64(either)
+	}
+	return overlap
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestNavigationTracker_Overlap(t *testing.T) {
+	tracker := NewNavigationTracker()
+	for i := 0; i < 10; i++ {
+		// visitor0-5 visit A and visitor4-9 visit B
+		url := "https://example.com/a"
+		if i >= 6 {
+			url = "https://example.com/b"
+		}
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: fmt.Sprintf("visitor%d", i), URL: url}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+		if i == 4 || i == 5 {
+			if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: fmt.Sprintf("visitor%d", i), URL: "https://example.com/b"}); err != nil {
+				t.Fatalf("Failed to record event: %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		a, b               string
+		both, onlyA, onlyB int
+		jaccard            float64
+	}{
+		{"https://example.com/a", "https://example.com/b", 2, 4, 4, 0.2},
+		{"https://example.com/b", "https://example.com/a", 2, 4, 4, 0.2},
+		{"https://example.com/a", "https://example.com/a", 6, 0, 0, 1},
+		{"https://example.com/a", "https://example.com/missing", 0, 6, 0, 0},
+		{"https://example.com/missing", "https://example.com/none", 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		overlap := tracker.Overlap(tt.a, tt.b)
+		if overlap.Both != tt.both || overlap.OnlyA != tt.onlyA || overlap.OnlyB != tt.onlyB || overlap.Jaccard != tt.jaccard {
+			t.Errorf("%s and %s: expected %d/%d/%d and %v, got %+v", tt.a, tt.b, tt.both, tt.onlyA, tt.onlyB, tt.jaccard, overlap)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"sort"
+
+	"nav-tracker/pkg/models"
+)
+
+// Transitions counts, across every session, the pages visited directly after
+// and directly before url. Consecutive views of the same page are treated as
+// reloads and not counted.
+func (ss *SessionStore) Transitions(url string) (next, previous map[string]int) {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	next = make(map[string]int)
+	previous = make(map[string]int)
+	if ss.urlSessions[url] == 0 {
+		return next, previous
+	}
+
+	for _, visitorSessions := range ss.sessions {
+		for _, s := range visitorSessions {
+			if !s.seenURLs[url] {
+				continue
+			}
+			for i := 1; i < len(s.urls); i++ {
+				from, to := s.urls[i-1], s.urls[i]
+				if from == to {
+					continue
+				}
+				if from == url {
+					next[to]++
+				}
+				if to == url {
+					previous[from]++
+				}
+			}
+		}
+	}
+
+	return next, previous
+}
+
+// GetPaths returns the up to limit most common pages visited next after url
+// and previously before it, with the probability of each transition.
+func (nt *NavigationTracker) GetPaths(url string, limit int) *models.PathReport {
+	next, previous := nt.sessions.Transitions(url)
+
+	report := &models.PathReport{URL: url}
+	report.Next, report.TotalNext = rankTransitions(next, limit)
+	report.Previous, report.TotalPrevious = rankTransitions(previous, limit)
+	return report
+}
+
+// rankTransitions orders counts by count, then URL, keeping the first limit,
+// and returns them with the total over all of counts.
+func rankTransitions(counts map[string]int, limit int) ([]models.PathTransition, int) {
+	total := 0
+	transitions := make([]models.PathTransition, 0, len(counts))
+	for url, count := range counts {
+		total += count
+		transitions = append(transitions, models.PathTransition{URL: url, Count: count})
+	}
+
+	sort.Slice(transitions, func(i, j int) bool {
+		if transitions[i].Count != transitions[j].Count {
+			return transitions[i].Count > transitions[j].Count
+		}
+		return transitions[i].URL < transitions[j].URL
+	})
+	if len(transitions) > limit {
+		transitions = transitions[:limit]
+	}
+
+	for i := range transitions {
+		transitions[i].Probability = float64(transitions[i].Count) / float64(total)
+	}
+	return transitions, total
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestNavigationTracker_GetPaths(t *testing.T) {
+	tracker := NewNavigationTracker()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	journeys := map[string][]string{
+		"visitor1": {"/home", "/pricing", "/signup"},
+		"visitor2": {"/home", "/pricing", "/pricing", "/docs"},
+		"visitor3": {"/blog", "/pricing", "/signup"},
+	}
+	for visitorID, pages := range journeys {
+		for i, page := range pages {
+			event := &models.NavigationEvent{VisitorID: visitorID, URL: "https://example.com" + page, Timestamp: base.Add(time.Duration(i) * time.Minute)}
+			if err := tracker.RecordEvent(event); err != nil {
+				t.Fatalf("Failed to record event: %v", err)
+			}
+		}
+	}
+
+	report := tracker.GetPaths("https://example.com/pricing", 10)
+	if report.TotalNext != 3 || report.TotalPrevious != 3 {
+		t.Fatalf("Expected 3 transitions each way, got %d next and %d previous", report.TotalNext, report.TotalPrevious)
+	}
+	if len(report.Next) != 2 || report.Next[0].URL != "https://example.com/signup" || report.Next[0].Count != 2 {
+		t.Fatalf("Expected /signup to be the most common next page, got %+v", report.Next)
+	}
+	if p := report.Next[0].Probability; p < 0.66 || p > 0.67 {
+		t.Errorf("Expected probability 2/3, got %f", p)
+	}
+	if report.Previous[0].URL != "https://example.com/home" || report.Previous[0].Count != 2 {
+		t.Errorf("Expected /home to be the most common previous page, got %+v", report.Previous)
+	}
+
+	// Limiting the transitions keeps probabilities relative to the total
+	limited := tracker.GetPaths("https://example.com/pricing", 1)
+	if len(limited.Next) != 1 || limited.TotalNext != 3 || limited.Next[0].Probability != report.Next[0].Probability {
+		t.Errorf("Expected one next page out of 3 transitions, got %+v", limited)
+	}
+}
+
+func TestNavigationTracker_GetPathsWithinSessions(t *testing.T) {
+	tracker := NewNavigationTracker()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// The second page starts a new session, so it is not a transition
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: base},
+		{VisitorID: "visitor1", URL: "https://example.com/b", Timestamp: base.Add(2 * time.Hour)},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	report := tracker.GetPaths("https://example.com/a", 10)
+	if report.TotalNext != 0 || len(report.Next) != 0 {
+		t.Errorf("Expected no transitions across sessions, got %+v", report.Next)
+	}
+}
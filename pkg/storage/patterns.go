@@ -0,0 +1,286 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+const (
+	MaxURLPatterns      = 100
+	maxURLPatternLength = 256
+)
+
+var (
+	ErrPatternExists   = errors.New("pattern already registered")
+	ErrTooManyPatterns = fmt.Errorf("at most %d patterns can be registered", MaxURLPatterns)
+)
+
+// patternSet keeps the aggregates of the registered URL patterns, updated as
+// URLs gain and lose visitors. It is locked after the URL shard locks.
+type patternSet struct {
+	patterns map[string]*urlPattern
+	mutex    sync.RWMutex
+}
+
+type urlPattern struct {
+	definition *models.URLPattern
+	regexp     *regexp.Regexp
+	pathOnly   bool
+
+	// visitors counts the matching URLs each visitor has viewed.
+	visitors  map[string]int
+	pageViews int64
+	urls      int
+}
+
+func newPatternSet() *patternSet {
+	return &patternSet{patterns: make(map[string]*urlPattern)}
+}
+
+// compileURLPattern translates a glob into an anchored regexp.
+func compileURLPattern(pattern string) (*urlPattern, error) {
+	if pattern == "" || len(pattern) > maxURLPatternLength || strings.ContainsAny(pattern, " \t\r\n") {
+		return nil, fmt.Errorf("pattern must be 1-%d characters without whitespace", maxURLPatternLength)
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expr.WriteString("[^/]*")
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	expr.WriteString("$")
+
+	return &urlPattern{
+		definition: &models.URLPattern{Pattern: pattern, CreatedAt: time.Now().UTC()},
+		regexp:     regexp.MustCompile(expr.String()),
+		pathOnly:   strings.HasPrefix(pattern, "/"),
+		visitors:   make(map[string]int),
+	}, nil
+}
+
+func (p *urlPattern) matches(rawURL string) bool {
+	if !p.pathOnly {
+		return p.regexp.MatchString(rawURL)
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	path := parsed.Path
+	if path == "" {
+		path = "/"
+	}
+	return p.regexp.MatchString(path)
+}
+
+// add counts url's stats, the URL itself only if it is new to the pattern.
+func (p *urlPattern) add(stats *urlStats, newURL bool) {
+	if newURL {
+		p.urls++
+	}
+	p.pageViews += stats.pageViews
+	for visitorID := range stats.visitors {
+		p.visitors[visitorID]++
+	}
+}
+
+func (p *urlPattern) forget(visitorID string) {
+	if p.visitors[visitorID] <= 1 {
+		delete(p.visitors, visitorID)
+	} else {
+		p.visitors[visitorID]--
+	}
+}
+
+// each calls fn for every pattern matching url.
+func (ps *patternSet) each(url string, fn func(p *urlPattern)) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	for _, p := range ps.patterns {
+		if p.matches(url) {
+			fn(p)
+		}
+	}
+}
+
+// record counts views page views of url, by visitorID if it is new to the
+// URL and not empty; the caller must hold url's shard lock.
+func (ps *patternSet) record(url, visitorID string, newURL, newVisitor bool, views int64) {
+	ps.each(url, func(p *urlPattern) {
+		if newURL {
+			p.urls++
+		}
+		p.pageViews += views
+		if newVisitor && visitorID != "" {
+			p.visitors[visitorID]++
+		}
+	})
+}
+
+// removeURL drops url's statistics from the patterns it matches; the caller
+// must hold url's shard lock.
+func (ps *patternSet) removeURL(url string, stats *urlStats) {
+	ps.each(url, func(p *urlPattern) {
+		p.urls--
+		p.pageViews -= stats.pageViews
+		for visitorID := range stats.visitors {
+			p.forget(visitorID)
+		}
+	})
+}
+
+// merge counts pageViews folded into target, with the visitors new to it;
+// the caller must hold target's shard lock.
+func (ps *patternSet) merge(target string, newURL bool, pageViews int64, added []string) {
+	ps.each(target, func(p *urlPattern) {
+		if newURL {
+			p.urls++
+		}
+		p.pageViews += pageViews
+		for _, visitorID := range added {
+			p.visitors[visitorID]++
+		}
+	})
+}
+
+// removeVisitor drops visitorID's page views of url from the patterns it
+// matches; the caller must hold url's shard lock.
+func (ps *patternSet) removeVisitor(url, visitorID string, pageViews int64) {
+	ps.each(url, func(p *urlPattern) {
+		p.pageViews -= pageViews
+		p.forget(visitorID)
+	})
+}
+
+// RegisterPattern starts aggregating the URLs matching pattern, counting
+// those already tracked, and returns its current stats.
+func (nt *NavigationTracker) RegisterPattern(pattern string) (*models.PatternStats, error) {
+	p, err := compileURLPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	// Holding every shard lock keeps events from being counted twice or
+	// missed while existing URLs are added.
+	nt.lockAll()
+	defer nt.unlockAll()
+
+	nt.patterns.mutex.Lock()
+	defer nt.patterns.mutex.Unlock()
+
+	if nt.patterns.patterns[pattern] != nil {
+		return nil, ErrPatternExists
+	}
+	if len(nt.patterns.patterns) >= MaxURLPatterns {
+		return nil, ErrTooManyPatterns
+	}
+
+	nt.backfillPattern(p)
+	nt.patterns.patterns[pattern] = p
+	return p.stats(), nil
+}
+
+// backfillPattern adds every tracked URL matching p to its aggregates; the
+// caller must hold every shard lock.
+func (nt *NavigationTracker) backfillPattern(p *urlPattern) {
+	for _, shard := range nt.shards {
+		shard.eachURL(func(url string) {
+			if !p.matches(url) {
+				return
+			}
+			if stats, ok := shard.lookup(url); ok {
+				p.add(stats, true)
+			}
+		})
+	}
+}
+
+// rebuildPatterns registers definitions afresh against the tracked URLs;
+// the caller must hold every shard lock.
+func (nt *NavigationTracker) rebuildPatterns(definitions []*models.URLPattern) {
+	patterns := make(map[string]*urlPattern, len(definitions))
+	for _, definition := range definitions {
+		p, err := compileURLPattern(definition.Pattern)
+		if err != nil {
+			continue
+		}
+		p.definition = definition
+		nt.backfillPattern(p)
+		patterns[definition.Pattern] = p
+	}
+
+	nt.patterns.mutex.Lock()
+	nt.patterns.patterns = patterns
+	nt.patterns.mutex.Unlock()
+}
+
+func (p *urlPattern) stats() *models.PatternStats {
+	return &models.PatternStats{
+		Pattern:          p.definition.Pattern,
+		DistinctVisitors: len(p.visitors),
+		TotalPageViews:   p.pageViews,
+		URLCount:         p.urls,
+	}
+}
+
+// GetPatternStats returns the aggregates of a registered pattern.
+func (nt *NavigationTracker) GetPatternStats(pattern string) (*models.PatternStats, bool) {
+	nt.patterns.mutex.RLock()
+	defer nt.patterns.mutex.RUnlock()
+
+	p, exists := nt.patterns.patterns[pattern]
+	if !exists {
+		return nil, false
+	}
+	return p.stats(), true
+}
+
+// ListPatterns returns every registered pattern's aggregates in
+// registration order.
+func (nt *NavigationTracker) ListPatterns() []*models.PatternStats {
+	nt.patterns.mutex.RLock()
+	defer nt.patterns.mutex.RUnlock()
+
+	patterns := make([]*urlPattern, 0, len(nt.patterns.patterns))
+	for _, p := range nt.patterns.patterns {
+		patterns = append(patterns, p)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		a, b := patterns[i].definition, patterns[j].definition
+		return a.CreatedAt.Before(b.CreatedAt) || (a.CreatedAt.Equal(b.CreatedAt) && a.Pattern < b.Pattern)
+	})
+
+	result := make([]*models.PatternStats, len(patterns))
+	for i, p := range patterns {
+		result[i] = p.stats()
+	}
+	return result
+}
+
+func (ps *patternSet) definitions() []*models.URLPattern {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	definitions := make([]*models.URLPattern, 0, len(ps.patterns))
+	for _, p := range ps.patterns {
+		definitions = append(definitions, p.definition)
+	}
+	return definitions
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestCompileURLPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		url     string
+		matches bool
+	}{
+		{"/product/*", "https://shop.example.com/product/123", true},
+		{"/product/*", "https://shop.example.com/product/123?ref=home", true},
+		{"/product/*", "https://shop.example.com/product/123/reviews", false},
+		{"/product/**", "https://shop.example.com/product/123/reviews", true},
+		{"/product/*/reviews", "https://shop.example.com/product/9/reviews", true},
+		{"https://*.example.com/blog/*", "https://news.example.com/blog/post", true},
+		{"https://*.example.com/blog/*", "https://example.org/blog/post", false},
+		{"/a.b", "https://example.com/aXb", false},
+	}
+
+	for _, tt := range tests {
+		p, err := compileURLPattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("Failed to compile %q: %v", tt.pattern, err)
+		}
+		if got := p.matches(tt.url); got != tt.matches {
+			t.Errorf("Expected %q matching %s to be %v, got %v", tt.pattern, tt.url, tt.matches, got)
+		}
+	}
+
+	for _, invalid := range []string{"", "/has space"} {
+		if _, err := compileURLPattern(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestNavigationTracker_PatternStats(t *testing.T) {
+	tracker := NewNavigationTracker()
+	record := func(visitorID, url string) {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: visitorID, URL: url}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	// Registering counts URLs already tracked
+	record("visitor1", "https://example.com/product/1")
+	record("visitor1", "https://example.com/product/1")
+	if _, err := tracker.RegisterPattern("/product/*"); err != nil {
+		t.Fatalf("Failed to register pattern: %v", err)
+	}
+	if _, err := tracker.RegisterPattern("/product/*"); !errors.Is(err, ErrPatternExists) {
+		t.Errorf("Expected ErrPatternExists, got %v", err)
+	}
+
+	record("visitor1", "https://example.com/product/2")
+	record("visitor2", "https://example.com/product/2")
+	record("visitor3", "https://example.com/about")
+
+	stats, exists := tracker.GetPatternStats("/product/*")
+	if !exists || stats.DistinctVisitors != 2 || stats.TotalPageViews != 4 || stats.URLCount != 2 {
+		t.Fatalf("Unexpected pattern stats: %+v", stats)
+	}
+
+	// visitor1 viewed both products, so removing one URL keeps them counted
+	tracker.DeleteURL("https://example.com/product/1", "test")
+	stats, _ = tracker.GetPatternStats("/product/*")
+	if stats.DistinctVisitors != 2 || stats.TotalPageViews != 2 || stats.URLCount != 1 {
+		t.Errorf("Unexpected pattern stats after URL deletion: %+v", stats)
+	}
+
+	tracker.DeleteVisitor("visitor2", "test")
+	stats, _ = tracker.GetPatternStats("/product/*")
+	if stats.DistinctVisitors != 1 || stats.TotalPageViews != 1 || stats.URLCount != 1 {
+		t.Errorf("Unexpected pattern stats after visitor deletion: %+v", stats)
+	}
+
+	if _, exists := tracker.GetPatternStats("/missing/*"); exists {
+		t.Error("Expected unregistered pattern to be missing")
+	}
+}
+
+func TestNavigationTracker_PatternsSnapshot(t *testing.T) {
+	tracker := NewNavigationTracker()
+	if _, err := tracker.RegisterPattern("/product/*"); err != nil {
+		t.Fatalf("Failed to register pattern: %v", err)
+	}
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/product/1"}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	path := t.TempDir() + "/snapshot.json"
+	if err := tracker.SaveSnapshot(path); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	restored := NewNavigationTracker()
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+
+	patterns := restored.ListPatterns()
+	if len(patterns) != 1 || patterns[0].DistinctVisitors != 1 || patterns[0].TotalPageViews != 1 {
+		t.Errorf("Expected restored pattern with its stats, got %+v", patterns)
+	}
+}
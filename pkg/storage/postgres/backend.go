@@ -0,0 +1,514 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// DriverName is the database/sql driver the backend opens. It is registered
+// by the pgx driver, which is linked into builds made with the postgres
+// build tag.
+const DriverName = "pgx"
+
+// maxPendingBatches bounds the events buffered while the database is
+// unreachable, in multiples of the batch size. Events beyond it are refused.
+const maxPendingBatches = 10
+
+// maxParams is the number of bind parameters Postgres accepts per statement.
+const maxParams = 65535
+
+// Backend implements storage.CounterBackend on PostgreSQL. Events are
+// buffered and written in batches, each batch in one transaction that
+// appends the events to the events table and folds them into the
+// url_visitors and url_stats aggregates the counters are read from.
+// url_stats keeps the distinct visitor count materialized so reads never
+// count url_visitors rows.
+type Backend struct {
+	db     *sql.DB
+	config models.StorageConfig
+
+	countVisitors *sql.Stmt
+	pageViews     *sql.Stmt
+
+	mutex   sync.Mutex
+	pending []models.NavigationEvent
+	lastErr error
+
+	flushMutex sync.Mutex
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+func NewBackend(config models.StorageConfig) *Backend {
+	return &Backend{config: config}
+}
+
+func (b *Backend) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), b.config.Timeout)
+}
+
+// Open connects to the database, configures the connection pool, applies
+// pending migrations and starts the periodic flush. It must succeed before
+// the backend records events.
+func (b *Backend) Open() error {
+	if !driverRegistered() {
+		return fmt.Errorf("postgres: no %q database driver is linked into this build (build with -tags postgres)", DriverName)
+	}
+
+	db, err := sql.Open(DriverName, b.config.DSN)
+	if err != nil {
+		return fmt.Errorf("postgres: open: %w", err)
+	}
+	db.SetMaxOpenConns(b.config.MaxOpenConns)
+	db.SetMaxIdleConns(b.config.MaxIdleConns)
+	db.SetConnMaxLifetime(b.config.ConnMaxLifetime)
+
+	ctx, cancel := b.context()
+	defer cancel()
+
+	if _, err := migrate(ctx, db); err != nil {
+		db.Close()
+		return fmt.Errorf("postgres: %w", err)
+	}
+
+	statements := []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&b.countVisitors, `SELECT visitors FROM url_stats WHERE url = $1`},
+		{&b.pageViews, `SELECT page_views FROM url_stats WHERE url = $1`},
+	}
+	for _, s := range statements {
+		if *s.stmt, err = db.PrepareContext(ctx, s.query); err != nil {
+			db.Close()
+			return fmt.Errorf("postgres: prepare: %w", err)
+		}
+	}
+
+	b.db = db
+	b.stop = make(chan struct{})
+	b.done = make(chan struct{})
+	go b.flushLoop(b.config.FlushInterval)
+	return nil
+}
+
+func driverRegistered() bool {
+	for _, name := range sql.Drivers() {
+		if name == DriverName {
+			return true
+		}
+	}
+	return false
+}
+
+// Ping checks that the database is reachable.
+func (b *Backend) Ping() error {
+	if b.db == nil {
+		return errors.New("postgres: not open")
+	}
+
+	ctx, cancel := b.context()
+	defer cancel()
+
+	return b.db.PingContext(ctx)
+}
+
+// Record buffers event, writing the buffer once it holds a full batch. An
+// event is only refused when the buffer is full because earlier writes
+// failed; failed batches stay buffered and are retried.
+func (b *Backend) Record(event *models.NavigationEvent) error {
+	b.mutex.Lock()
+	if len(b.pending) >= b.config.BatchSize*maxPendingBatches {
+		err := b.lastErr
+		b.mutex.Unlock()
+		return fmt.Errorf("postgres: write buffer full: %w", err)
+	}
+	b.pending = append(b.pending, *event)
+	full := len(b.pending) >= b.config.BatchSize
+	b.mutex.Unlock()
+
+	if full {
+		// A failed write is retried by the next flush
+		_ = b.Flush()
+	}
+	return nil
+}
+
+func (b *Backend) flushLoop(interval time.Duration) {
+	defer close(b.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = b.Flush()
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// Flush writes the buffered events, one batch per transaction.
+func (b *Backend) Flush() error {
+	b.flushMutex.Lock()
+	defer b.flushMutex.Unlock()
+
+	for {
+		b.mutex.Lock()
+		n := len(b.pending)
+		if n > b.config.BatchSize {
+			n = b.config.BatchSize
+		}
+		batch := b.pending[:n:n]
+		b.mutex.Unlock()
+
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := b.writeBatch(batch)
+
+		b.mutex.Lock()
+		b.lastErr = err
+		if err == nil {
+			b.pending = b.pending[n:]
+			if len(b.pending) == 0 {
+				b.pending = nil
+			}
+		}
+		b.mutex.Unlock()
+
+		if err != nil {
+			return fmt.Errorf("postgres: write batch: %w", err)
+		}
+	}
+}
+
+// visitorRow is one url_visitors row folded from a batch.
+type visitorRow struct {
+	url, visitorID      string
+	pageViews           int64
+	firstSeen, lastSeen int64
+}
+
+// aggregate folds batch into url_visitors rows, in first-seen order.
+func aggregate(batch []models.NavigationEvent) []visitorRow {
+	type key struct{ url, visitorID string }
+	index := make(map[key]int)
+	var rows []visitorRow
+
+	for _, event := range batch {
+		timestamp := event.Timestamp.UnixNano()
+		k := key{event.URL, event.VisitorID}
+		i, exists := index[k]
+		if !exists {
+			index[k] = len(rows)
+			rows = append(rows, visitorRow{url: event.URL, visitorID: event.VisitorID, firstSeen: timestamp, lastSeen: timestamp})
+			i = len(rows) - 1
+		}
+		row := &rows[i]
+		row.pageViews++
+		if timestamp < row.firstSeen {
+			row.firstSeen = timestamp
+		}
+		if timestamp > row.lastSeen {
+			row.lastSeen = timestamp
+		}
+	}
+	return rows
+}
+
+// placeholders returns a VALUES list of rows tuples of columns numbered
+// bind parameters, e.g. ($1, $2), ($3, $4).
+func placeholders(rows, columns int) string {
+	var sb strings.Builder
+	for r := 0; r < rows; r++ {
+		if r > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteByte('(')
+		for c := 0; c < columns; c++ {
+			if c > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteByte('$')
+			sb.WriteString(strconv.Itoa(r*columns + c + 1))
+		}
+		sb.WriteByte(')')
+	}
+	return sb.String()
+}
+
+// chunks calls fn with consecutive ranges of n rows small enough that each
+// statement stays under the bind parameter limit.
+func chunks(n, columns int, fn func(from, to int) error) error {
+	size := maxParams / columns
+	for from := 0; from < n; from += size {
+		to := from + size
+		if to > n {
+			to = n
+		}
+		if err := fn(from, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Backend) writeBatch(batch []models.NavigationEvent) error {
+	ctx, cancel := b.context()
+	defer cancel()
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = chunks(len(batch), 6, func(from, to int) error {
+		args := make([]interface{}, 0, (to-from)*6)
+		for _, event := range batch[from:to] {
+			args = append(args, nullable(event.EventID), event.VisitorID, event.URL, nullable(event.SessionID), nullable(event.Referrer), event.Timestamp.UnixNano())
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO events (event_id, visitor_id, url, session_id, referrer, timestamp) VALUES `+placeholders(to-from, 6), args...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	rows := aggregate(batch)
+	newVisitors := make(map[string]int64)
+	pageViews := make(map[string]int64)
+	var urls []string
+	for _, row := range rows {
+		if _, exists := pageViews[row.url]; !exists {
+			urls = append(urls, row.url)
+		}
+		pageViews[row.url] += row.pageViews
+	}
+
+	err = chunks(len(rows), 5, func(from, to int) error {
+		args := make([]interface{}, 0, (to-from)*5)
+		for _, row := range rows[from:to] {
+			args = append(args, row.url, row.visitorID, row.pageViews, row.firstSeen, row.lastSeen)
+		}
+		// xmax is zero on rows the statement inserted rather than updated,
+		// which are the visitors new to their URL
+		inserted, err := tx.QueryContext(ctx, `INSERT INTO url_visitors (url, visitor_id, page_views, first_seen, last_seen) VALUES `+placeholders(to-from, 5)+`
+			ON CONFLICT (url, visitor_id) DO UPDATE SET
+				page_views = url_visitors.page_views + excluded.page_views,
+				first_seen = LEAST(url_visitors.first_seen, excluded.first_seen),
+				last_seen = GREATEST(url_visitors.last_seen, excluded.last_seen)
+			RETURNING url, xmax = 0`, args...)
+		if err != nil {
+			return err
+		}
+		defer inserted.Close()
+
+		for inserted.Next() {
+			var url string
+			var isNew bool
+			if err := inserted.Scan(&url, &isNew); err != nil {
+				return err
+			}
+			if isNew {
+				newVisitors[url]++
+			}
+		}
+		return inserted.Err()
+	})
+	if err != nil {
+		return err
+	}
+
+	err = chunks(len(urls), 3, func(from, to int) error {
+		args := make([]interface{}, 0, (to-from)*3)
+		for _, url := range urls[from:to] {
+			args = append(args, url, newVisitors[url], pageViews[url])
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO url_stats (url, visitors, page_views) VALUES `+placeholders(to-from, 3)+`
+			ON CONFLICT (url) DO UPDATE SET
+				visitors = url_stats.visitors + excluded.visitors,
+				page_views = url_stats.page_views + excluded.page_views`, args...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func nullable(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// DistinctVisitors reads url's materialized visitor count. Buffered events
+// are written first so a replica reads its own events.
+func (b *Backend) DistinctVisitors(url string) (int, error) {
+	if err := b.flushPending(); err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := b.context()
+	defer cancel()
+
+	var count int
+	err := b.countVisitors.QueryRowContext(ctx, url).Scan(&count)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+
+	return count, err
+}
+
+func (b *Backend) PageViews(url string) (int64, error) {
+	if err := b.flushPending(); err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := b.context()
+	defer cancel()
+
+	var count int64
+	err := b.pageViews.QueryRowContext(ctx, url).Scan(&count)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+
+	return count, err
+}
+
+// flushPending flushes only when events are buffered, keeping reads cheap.
+func (b *Backend) flushPending() error {
+	b.mutex.Lock()
+	pending := len(b.pending)
+	b.mutex.Unlock()
+
+	if pending == 0 {
+		return nil
+	}
+	return b.Flush()
+}
+
+// ForgetVisitor deletes visitorID's events and url_visitors rows, including
+// buffered ones, and takes them off url_stats, dropping URLs left without
+// visitors.
+func (b *Backend) ForgetVisitor(visitorID string) error {
+	return b.exec(func(event *models.NavigationEvent) bool { return event.VisitorID == visitorID },
+		statement{`UPDATE url_stats SET visitors = url_stats.visitors - 1, page_views = url_stats.page_views - v.page_views
+			FROM url_visitors v WHERE v.url = url_stats.url AND v.visitor_id = $1`, []interface{}{visitorID}},
+		statement{`DELETE FROM url_stats WHERE visitors <= 0`, nil},
+		statement{`DELETE FROM url_visitors WHERE visitor_id = $1`, []interface{}{visitorID}},
+		statement{`DELETE FROM events WHERE visitor_id = $1`, []interface{}{visitorID}},
+	)
+}
+
+// DeleteURL deletes url's events, including buffered ones, and aggregates.
+func (b *Backend) DeleteURL(url string) error {
+	return b.exec(func(event *models.NavigationEvent) bool { return event.URL == url },
+		statement{`DELETE FROM url_stats WHERE url = $1`, []interface{}{url}},
+		statement{`DELETE FROM url_visitors WHERE url = $1`, []interface{}{url}},
+		statement{`DELETE FROM events WHERE url = $1`, []interface{}{url}},
+	)
+}
+
+// MergeURL moves source's events, including buffered ones, to target and
+// folds its aggregates into target's, counting visitors seen on both once.
+func (b *Backend) MergeURL(source, target string) error {
+	if source == target {
+		return nil
+	}
+	retarget := func(event *models.NavigationEvent) bool {
+		if event.URL == source {
+			event.URL = target
+		}
+		return false
+	}
+	return b.exec(retarget,
+		statement{`UPDATE events SET url = $1 WHERE url = $2`, []interface{}{target, source}},
+		statement{`INSERT INTO url_visitors (url, visitor_id, page_views, first_seen, last_seen)
+			SELECT $1, visitor_id, page_views, first_seen, last_seen FROM url_visitors WHERE url = $2
+			ON CONFLICT (url, visitor_id) DO UPDATE SET
+				page_views = url_visitors.page_views + excluded.page_views,
+				first_seen = LEAST(url_visitors.first_seen, excluded.first_seen),
+				last_seen = GREATEST(url_visitors.last_seen, excluded.last_seen)`, []interface{}{target, source}},
+		statement{`DELETE FROM url_visitors WHERE url = $1`, []interface{}{source}},
+		statement{`INSERT INTO url_stats (url, visitors, page_views)
+			SELECT $1, (SELECT COUNT(*) FROM url_visitors WHERE url = $1), page_views FROM url_stats WHERE url = $2
+			ON CONFLICT (url) DO UPDATE SET
+				visitors = excluded.visitors,
+				page_views = url_stats.page_views + excluded.page_views`, []interface{}{target, source}},
+		statement{`DELETE FROM url_stats WHERE url = $1`, []interface{}{source}},
+	)
+}
+
+type statement struct {
+	query string
+	args  []interface{}
+}
+
+// exec runs statements in one transaction with flushes held off, after
+// dropping the buffered events drop reports true for. drop may also rewrite
+// the events it keeps.
+func (b *Backend) exec(drop func(event *models.NavigationEvent) bool, statements ...statement) error {
+	b.flushMutex.Lock()
+	defer b.flushMutex.Unlock()
+
+	b.mutex.Lock()
+	kept := b.pending[:0]
+	for i := range b.pending {
+		if !drop(&b.pending[i]) {
+			kept = append(kept, b.pending[i])
+		}
+	}
+	b.pending = kept
+	b.mutex.Unlock()
+
+	ctx, cancel := b.context()
+	defer cancel()
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, s := range statements {
+		if _, err := tx.ExecContext(ctx, s.query, s.args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Close stops the periodic flush, writes the buffered events and releases
+// the prepared statements and the connection pool.
+func (b *Backend) Close() error {
+	if b.db == nil {
+		return nil
+	}
+	close(b.stop)
+	<-b.done
+
+	err := b.Flush()
+	for _, stmt := range []*sql.Stmt{b.countVisitors, b.pageViews} {
+		stmt.Close()
+	}
+	if closeErr := b.db.Close(); err == nil {
+		err = closeErr
+	}
+	b.db = nil
+	return err
+}
@@ -0,0 +1,219 @@
+package postgres
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+// testDSNEnv names the database the integration tests run against. They
+// empty the backend's tables, so point it at a scratch database.
+const testDSNEnv = "NAV_TRACKER_TEST_POSTGRES_DSN"
+
+func testConfig() models.StorageConfig {
+	config := models.DefaultConfiguration().Storage
+	config.Backend = models.StorageBackendPostgres
+	config.DSN = os.Getenv(testDSNEnv)
+	if config.DSN == "" {
+		config.DSN = "postgres://localhost/nav_tracker_test"
+	}
+	return config
+}
+
+func newTestBackend(t *testing.T) *Backend {
+	if !driverRegistered() {
+		t.Skip("no postgres driver linked; run with -tags postgres")
+	}
+	if os.Getenv(testDSNEnv) == "" {
+		t.Skip("no test database; set " + testDSNEnv)
+	}
+
+	backend := NewBackend(testConfig())
+	if err := backend.Open(); err != nil {
+		t.Fatalf("Failed to open backend: %v", err)
+	}
+	if _, err := backend.db.Exec(`TRUNCATE events, url_visitors, url_stats`); err != nil {
+		t.Fatalf("Failed to clear tables: %v", err)
+	}
+	t.Cleanup(func() { _ = backend.Close() })
+
+	return backend
+}
+
+func TestBackend_OpenWithoutDriver(t *testing.T) {
+	if driverRegistered() {
+		t.Skip("postgres driver is linked")
+	}
+
+	err := NewBackend(testConfig()).Open()
+	if err == nil || !strings.Contains(err.Error(), "-tags postgres") {
+		t.Errorf("Expected missing driver error, got %v", err)
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	if got := placeholders(2, 3); got != "($1, $2, $3), ($4, $5, $6)" {
+		t.Errorf("Unexpected placeholders %q", got)
+	}
+
+	var ranges [][2]int
+	_ = chunks(maxParams/5*2+1, 5, func(from, to int) error {
+		ranges = append(ranges, [2]int{from, to})
+		return nil
+	})
+	if len(ranges) != 3 || ranges[2][1]-ranges[2][0] != 1 {
+		t.Errorf("Expected 3 chunks with a last one of 1 row, got %v", ranges)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	now := time.Now()
+	rows := aggregate([]models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: now},
+		{VisitorID: "visitor2", URL: "https://example.com/a", Timestamp: now},
+		{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: now.Add(-time.Minute)},
+		{VisitorID: "visitor1", URL: "https://example.com/b", Timestamp: now},
+	})
+
+	if len(rows) != 3 {
+		t.Fatalf("Expected 3 url_visitors rows, got %+v", rows)
+	}
+	if first := rows[0]; first.pageViews != 2 || first.firstSeen != now.Add(-time.Minute).UnixNano() || first.lastSeen != now.UnixNano() {
+		t.Errorf("Expected visitor1's /a views and visits to be folded, got %+v", first)
+	}
+}
+
+func TestBackend_SharedAcrossTrackers(t *testing.T) {
+	backend := newTestBackend(t)
+	url := "https://example.com/page"
+
+	replicaA := storage.NewNavigationTracker()
+	replicaA.SetCounterBackend(backend)
+	replicaB := storage.NewNavigationTracker()
+	replicaB.SetCounterBackend(backend)
+
+	for i, tracker := range []*storage.NavigationTracker{replicaA, replicaB, replicaB} {
+		visitorID := "visitor1"
+		if i == 1 {
+			visitorID = "visitor2"
+		}
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: visitorID, URL: url, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	for _, tracker := range []*storage.NavigationTracker{replicaA, replicaB} {
+		stats := tracker.GetVisitorStats(url)
+		if stats.DistinctVisitors != 2 || stats.TotalPageViews != 3 {
+			t.Errorf("Expected 2 visitors and 3 page views, got %d and %d", stats.DistinctVisitors, stats.TotalPageViews)
+		}
+	}
+
+	var events int
+	if err := backend.db.QueryRow(`SELECT COUNT(*) FROM events WHERE url = $1`, url).Scan(&events); err != nil || events != 3 {
+		t.Errorf("Expected 3 stored events, got %d (%v)", events, err)
+	}
+}
+
+func TestBackend_BatchedWrites(t *testing.T) {
+	backend := newTestBackend(t)
+	backend.config.BatchSize = 2
+	url := "https://example.com/page"
+
+	for _, visitorID := range []string{"visitor1", "visitor2", "visitor1"} {
+		if err := backend.Record(&models.NavigationEvent{VisitorID: visitorID, URL: url, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	// The first two events filled a batch; the third is still buffered
+	var visitors int
+	if err := backend.db.QueryRow(`SELECT visitors FROM url_stats WHERE url = $1`, url).Scan(&visitors); err != nil || visitors != 2 {
+		t.Errorf("Expected the full batch written, got %d visitors (%v)", visitors, err)
+	}
+	if pageViews, err := backend.PageViews(url); err != nil || pageViews != 3 {
+		t.Errorf("Expected reads to flush the buffer, got %d page views (%v)", pageViews, err)
+	}
+}
+
+func TestBackend_ReopenKeepsData(t *testing.T) {
+	backend := newTestBackend(t)
+	url := "https://example.com/page"
+
+	if err := backend.Record(&models.NavigationEvent{VisitorID: "visitor1", URL: url, Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+	// Close writes the buffered event
+	backend.Close()
+
+	reopened := NewBackend(backend.config)
+	if err := reopened.Open(); err != nil {
+		t.Fatalf("Failed to reopen backend: %v", err)
+	}
+	defer reopened.Close()
+
+	var version int
+	if err := reopened.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil || version != len(migrations) {
+		t.Errorf("Expected schema version %d, got %d (%v)", len(migrations), version, err)
+	}
+	if visitors, _ := reopened.DistinctVisitors(url); visitors != 1 {
+		t.Errorf("Expected 1 visitor after reopening, got %d", visitors)
+	}
+}
+
+func TestBackend_DeletionsReachTheDatabase(t *testing.T) {
+	backend := newTestBackend(t)
+	tracker := storage.NewNavigationTracker()
+	tracker.SetCounterBackend(backend)
+	a, b := "https://example.com/a", "https://example.com/b"
+
+	for _, event := range []models.NavigationEvent{
+		{VisitorID: "alice", URL: a},
+		{VisitorID: "alice", URL: a},
+		{VisitorID: "alice", URL: b},
+		{VisitorID: "bob", URL: a},
+		{VisitorID: "carol", URL: b},
+	} {
+		event.Timestamp = time.Now()
+		if err := tracker.RecordEvent(&event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	// Deleting while the events are still buffered keeps them from being
+	// written later
+	if entry := tracker.DeleteVisitor("alice", "test"); entry.BackendError != "" {
+		t.Fatalf("Expected the backend to forget the visitor, got %s", entry.BackendError)
+	}
+	if err := backend.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	var events int
+	if err := backend.db.QueryRow(`SELECT COUNT(*) FROM events WHERE visitor_id = 'alice'`).Scan(&events); err != nil || events != 0 {
+		t.Errorf("Expected no events left for the visitor, got %d (%v)", events, err)
+	}
+	if visitors, _ := backend.DistinctVisitors(a); visitors != 1 {
+		t.Errorf("Expected 1 visitor on a, got %d", visitors)
+	}
+
+	if err := backend.MergeURL(a, b); err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+	if visitors, _ := backend.DistinctVisitors(b); visitors != 2 {
+		t.Errorf("Expected 2 visitors on b after the merge, got %d", visitors)
+	}
+	if pageViews, _ := backend.PageViews(b); pageViews != 2 {
+		t.Errorf("Expected 2 page views on b after the merge, got %d", pageViews)
+	}
+
+	if entry := tracker.DeleteURL(b, "test"); entry.BackendError != "" {
+		t.Fatalf("Expected the backend to delete the URL, got %s", entry.BackendError)
+	}
+	if err := backend.db.QueryRow(`SELECT COUNT(*) FROM events`).Scan(&events); err != nil || events != 0 {
+		t.Errorf("Expected no events left, got %d (%v)", events, err)
+	}
+}
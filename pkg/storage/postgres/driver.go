@@ -0,0 +1,8 @@
+//go:build postgres
+
+package postgres
+
+// The pgx driver registers itself with database/sql as "pgx". It is only
+// linked into builds made with the postgres build tag, so the default build
+// needs no database dependencies.
+import _ "github.com/jackc/pgx/v5/stdlib"
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrations are applied in order on startup, pending ones together in one
+// transaction, and each is recorded in schema_migrations by its index plus one.
+// Append new migrations rather than editing applied ones.
+var migrations = []string{
+	`CREATE TABLE events (
+		id BIGSERIAL PRIMARY KEY,
+		event_id TEXT,
+		visitor_id TEXT NOT NULL,
+		url TEXT NOT NULL,
+		session_id TEXT,
+		referrer TEXT,
+		timestamp BIGINT NOT NULL
+	);
+	CREATE INDEX events_url_timestamp ON events (url, timestamp);
+	CREATE INDEX events_visitor ON events (visitor_id);
+	CREATE TABLE url_visitors (
+		url TEXT NOT NULL,
+		visitor_id TEXT NOT NULL,
+		page_views BIGINT NOT NULL,
+		first_seen BIGINT NOT NULL,
+		last_seen BIGINT NOT NULL,
+		PRIMARY KEY (url, visitor_id)
+	);
+	CREATE TABLE url_stats (
+		url TEXT PRIMARY KEY,
+		visitors BIGINT NOT NULL,
+		page_views BIGINT NOT NULL
+	);`,
+}
+
+// migrate brings the schema up to date, returning the resulting version. A
+// transaction-scoped advisory lock keeps replicas starting together from
+// applying the same migration twice.
+func migrate(ctx context.Context, db *sql.DB) (int, error) {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return 0, fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return 0, fmt.Errorf("lock schema_migrations: %w", err)
+	}
+
+	var version int
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	if version > len(migrations) {
+		return version, fmt.Errorf("schema version %d is newer than this build supports (%d)", version, len(migrations))
+	}
+
+	for applied := version; applied < len(migrations); applied++ {
+		if _, err := tx.ExecContext(ctx, migrations[applied]); err != nil {
+			return version, fmt.Errorf("apply migration %d: %w", applied+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, applied+1); err != nil {
+			return version, fmt.Errorf("apply migration %d: %w", applied+1, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return version, err
+	}
+	return len(migrations), nil
+}
+
+// migrationLockID identifies the advisory lock held while migrating.
+const migrationLockID = 0x6e6176747261636b // "navtrack"
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"nav-tracker/pkg/models"
+)
+
+// pseudonymizer replaces visitor IDs with salted SHA-256 digests. A nil
+// pseudonymizer leaves IDs unchanged.
+type pseudonymizer struct {
+	salt string
+}
+
+func newPseudonymizer(config models.PrivacyConfig) *pseudonymizer {
+	if !config.HashVisitorIDs {
+		return nil
+	}
+	return &pseudonymizer{salt: config.Salt}
+}
+
+func (p *pseudonymizer) hash(visitorID string) string {
+	if p == nil {
+		return visitorID
+	}
+	sum := sha256.Sum256([]byte(p.salt + visitorID))
+	return hex.EncodeToString(sum[:])
+}
+
+// VisitorKey returns the ID visitorID is stored under: its digest when
+// visitor IDs are hashed, otherwise visitorID itself. Use it to look up a
+// visitor by the raw ID a client sent.
+func (nt *NavigationTracker) VisitorKey(visitorID string) string {
+	return nt.pseudonymizer.hash(visitorID)
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func newHashingTracker() *NavigationTracker {
+	config := models.DefaultConfiguration()
+	config.Privacy = models.PrivacyConfig{HashVisitorIDs: true, Salt: "pepper"}
+	config.DataQuality.ExcludedVisitorIDs = []string{"bot"}
+	return NewNavigationTrackerWithConfig(config)
+}
+
+func TestNavigationTracker_HashVisitorIDs(t *testing.T) {
+	tracker := newHashingTracker()
+	url := "https://example.com/page"
+
+	for _, visitorID := range []string{"visitor1", "visitor1", "visitor2", "bot"} {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: visitorID, URL: url}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	if visitors := tracker.GetDistinctVisitors(url); visitors != 2 {
+		t.Errorf("Expected 2 distinct visitors, got %d", visitors)
+	}
+	if _, exists := tracker.GetVisitorProfile("visitor1"); exists {
+		t.Error("Expected raw visitor ID not to be stored")
+	}
+
+	key := tracker.VisitorKey("visitor1")
+	if len(key) != 64 || key == tracker.VisitorKey("visitor2") {
+		t.Errorf("Expected distinct 64 character digests, got %q", key)
+	}
+	profile, exists := tracker.GetVisitorProfile(key)
+	if !exists || profile.TotalPageViews != 2 {
+		t.Errorf("Expected profile under hashed ID with 2 views, got %+v", profile)
+	}
+	if sessions := tracker.Sessions().GetVisitorSessions(key); len(sessions) != 1 {
+		t.Errorf("Expected 1 session under hashed ID, got %d", len(sessions))
+	}
+}
+
+func TestNavigationTracker_HashVisitorIDsPlaceholders(t *testing.T) {
+	tracker := newHashingTracker()
+
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "null", URL: "https://example.com"}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	report := tracker.DataQualityReport()
+	if len(report.Suspicious) != 1 || report.Suspicious[0].VisitorID != tracker.VisitorKey("null") {
+		t.Errorf("Expected hashed placeholder to be reported, got %+v", report.Suspicious)
+	}
+}
+
+func TestNavigationTracker_VisitorKeyWithoutHashing(t *testing.T) {
+	tracker := NewNavigationTracker()
+	if key := tracker.VisitorKey("visitor1"); key != "visitor1" {
+		t.Errorf("Expected visitor ID unchanged, got %q", key)
+	}
+}
+
+func TestNavigationTracker_AnonymizesIPs(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Privacy.AnonymizeIP = models.IPAnonymizeTruncate
+	tracker := NewNavigationTrackerWithConfig(config)
+
+	var recorded []string
+	tracker.AddListener(func(event models.NavigationEvent) {
+		recorded = append(recorded, event.IP)
+	})
+
+	// Events from imports and consumers carry whatever IP their source set
+	for _, ip := range []string{"203.0.113.7", "not-an-ip", ""} {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com", IP: ip}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	if len(recorded) != 3 || recorded[0] != "203.0.113.0" || recorded[1] != "" || recorded[2] != "" {
+		t.Errorf("Expected the IPs anonymized or dropped, got %q", recorded)
+	}
+}
@@ -0,0 +1,213 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// SchemaViolationError is returned for an event whose properties violate the
+// schema registered for its type.
+type SchemaViolationError struct {
+	EventType string
+	Fields    []models.FieldError
+}
+
+func (e *SchemaViolationError) Error() string {
+	violations := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		violations[i] = field.Field + " " + field.Message
+	}
+	return fmt.Sprintf("properties violate the %s schema: %s", e.EventType, strings.Join(violations, "; "))
+}
+
+// PropertySchemaStore holds the property schemas registered per event type
+// and, for their indexed properties, counts each URL's events per value.
+// Events of types without a schema are not checked.
+type PropertySchemaStore struct {
+	mutex   sync.RWMutex
+	schemas map[string]*models.PropertySchema
+	// index maps URL, event type and property to the events counted per value.
+	index map[string]map[string]map[string]map[string]*eventTypeCount
+}
+
+func NewPropertySchemaStore() *PropertySchemaStore {
+	return &PropertySchemaStore{
+		schemas: make(map[string]*models.PropertySchema),
+		index:   make(map[string]map[string]map[string]map[string]*eventTypeCount),
+	}
+}
+
+// Put validates and registers schema, replacing any for its event type.
+// Counts of properties no longer indexed are dropped; newly indexed ones
+// count from now on.
+func (ps *PropertySchemaStore) Put(schema models.PropertySchema) (models.PropertySchema, error) {
+	if err := schema.Validate(); err != nil {
+		return models.PropertySchema{}, err
+	}
+	schema.UpdatedAt = time.Now().UTC()
+
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	ps.schemas[schema.EventType] = &schema
+	for _, types := range ps.index {
+		for property := range types[schema.EventType] {
+			if !schema.Indexed(property) {
+				delete(types[schema.EventType], property)
+			}
+		}
+	}
+	return schema, nil
+}
+
+func (ps *PropertySchemaStore) Get(eventType string) (models.PropertySchema, bool) {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	schema, exists := ps.schemas[eventType]
+	if !exists {
+		return models.PropertySchema{}, false
+	}
+	return *schema, true
+}
+
+// List returns every schema ordered by event type.
+func (ps *PropertySchemaStore) List() []models.PropertySchema {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	schemas := make([]models.PropertySchema, 0, len(ps.schemas))
+	for _, schema := range ps.schemas {
+		schemas = append(schemas, *schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].EventType < schemas[j].EventType })
+	return schemas
+}
+
+// Delete removes the schema of eventType with its counts, and reports
+// whether there was one.
+func (ps *PropertySchemaStore) Delete(eventType string) bool {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	_, exists := ps.schemas[eventType]
+	delete(ps.schemas, eventType)
+	for _, types := range ps.index {
+		delete(types, eventType)
+	}
+	return exists
+}
+
+// check returns a SchemaViolationError if event's properties violate the
+// schema of its type.
+func (ps *PropertySchemaStore) check(event *models.NavigationEvent) error {
+	eventType := eventTypeOf(event)
+
+	ps.mutex.RLock()
+	schema, exists := ps.schemas[eventType]
+	ps.mutex.RUnlock()
+	if !exists {
+		return nil
+	}
+
+	if violations := schema.Check(event.Properties); len(violations) > 0 {
+		return &SchemaViolationError{EventType: eventType, Fields: violations}
+	}
+	return nil
+}
+
+// record counts event under each of its indexed properties.
+func (ps *PropertySchemaStore) record(event *models.NavigationEvent) {
+	eventType := eventTypeOf(event)
+
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	schema, exists := ps.schemas[eventType]
+	if !exists {
+		return
+	}
+	for _, property := range schema.Properties {
+		value, set := event.Properties[property.Name]
+		if !property.Indexed || !set {
+			continue
+		}
+
+		types := ps.index[event.URL]
+		if types == nil {
+			types = make(map[string]map[string]map[string]*eventTypeCount)
+			ps.index[event.URL] = types
+		}
+		properties := types[eventType]
+		if properties == nil {
+			properties = make(map[string]map[string]*eventTypeCount)
+			types[eventType] = properties
+		}
+		values := properties[property.Name]
+		if values == nil {
+			values = make(map[string]*eventTypeCount)
+			properties[property.Name] = values
+		}
+		count := values[value]
+		if count == nil {
+			count = &eventTypeCount{}
+			values[value] = count
+		}
+		count.count++
+		if event.Timestamp.After(count.lastSeen) {
+			count.lastSeen = event.Timestamp
+		}
+	}
+}
+
+func (ps *PropertySchemaStore) removeURL(url string) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	delete(ps.index, url)
+}
+
+// count returns url's events of eventType whose property is value. The
+// property must be indexed in the schema of eventType.
+func (ps *PropertySchemaStore) count(url, eventType, property, value string) (models.EventTypeCount, error) {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	schema, exists := ps.schemas[eventType]
+	if !exists || !schema.Indexed(property) {
+		return models.EventTypeCount{}, fmt.Errorf("property %s is not indexed for %s events", property, eventType)
+	}
+
+	counted := models.EventTypeCount{Type: eventType}
+	if count := ps.index[url][eventType][property][value]; count != nil {
+		counted.Count, counted.LastSeen = count.count, count.lastSeen
+	}
+	return counted, nil
+}
+
+// eventTypeOf returns event's type, pageview when it has none.
+func eventTypeOf(event *models.NavigationEvent) string {
+	if event.EventType == "" {
+		return models.EventTypePageView
+	}
+	return event.EventType
+}
+
+// FilteredEventTypeStats returns the events of eventType recorded for url
+// whose property is value. The property must be indexed.
+func (nt *NavigationTracker) FilteredEventTypeStats(url, eventType, property, value string) (*models.EventTypeStats, error) {
+	count, err := nt.schemas.count(url, eventType, property, value)
+	if err != nil {
+		return nil, err
+	}
+	stats := &models.EventTypeStats{URL: url, Events: []models.EventTypeCount{}}
+	if count.Count > 0 {
+		stats.Events = append(stats.Events, count)
+	}
+	return stats, nil
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestNavigationTracker_PropertySchemas(t *testing.T) {
+	tracker := NewNavigationTracker()
+	now := time.Now().UTC()
+	url := "https://example.com/pricing"
+
+	_, err := tracker.PropertySchemas().Put(models.PropertySchema{
+		EventType: "custom:signup",
+		Properties: []models.PropertyDefinition{
+			{Name: "plan", Type: models.PropertyTypeString, Required: true, Indexed: true},
+			{Name: "seats", Type: models.PropertyTypeNumber},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to register schema: %v", err)
+	}
+	if _, err := tracker.PropertySchemas().Put(models.PropertySchema{EventType: "custom:x", Properties: []models.PropertyDefinition{{Name: "a", Type: "date"}}}); err == nil {
+		t.Error("Expected an unknown property type to be refused")
+	}
+
+	for _, plan := range []string{"pro", "pro", "free"} {
+		event := &models.NavigationEvent{VisitorID: "visitor1", URL: url, EventType: "custom:signup", Properties: map[string]string{"plan": plan, "seats": "3"}, Timestamp: now}
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	err = tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url, EventType: "custom:signup", Properties: map[string]string{"seats": "many", "coupon": "x"}, Timestamp: now})
+	var violation *SchemaViolationError
+	if !errors.As(err, &violation) {
+		t.Fatalf("Expected a schema violation, got %v", err)
+	}
+	expected := []models.FieldError{
+		{Field: "properties.plan", Message: "is required"},
+		{Field: "properties.seats", Message: "must be a number"},
+		{Field: "properties.coupon", Message: "is not declared in the schema"},
+	}
+	if len(violation.Fields) != len(expected) {
+		t.Fatalf("Expected %d violations, got %+v", len(expected), violation.Fields)
+	}
+	for i, field := range violation.Fields {
+		if field != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], field)
+		}
+	}
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url, EventType: models.EventTypeClick, Properties: map[string]string{"any": "thing"}, Timestamp: now}); err != nil {
+		t.Errorf("Expected types without a schema not to be checked, got %v", err)
+	}
+
+	stats, err := tracker.FilteredEventTypeStats(url, "custom:signup", "plan", "pro")
+	if err != nil {
+		t.Fatalf("Failed to filter event stats: %v", err)
+	}
+	if len(stats.Events) != 1 || stats.Events[0].Count != 2 {
+		t.Errorf("Expected 2 pro signups, got %+v", stats.Events)
+	}
+	if _, err := tracker.FilteredEventTypeStats(url, "custom:signup", "seats", "3"); err == nil {
+		t.Error("Expected filtering on a property that is not indexed to fail")
+	}
+
+	path := t.TempDir() + "/snapshot.json"
+	if err := tracker.SaveSnapshot(path); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	restored := NewNavigationTracker()
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if _, exists := restored.PropertySchemas().Get("custom:signup"); !exists {
+		t.Error("Expected the schema to be restored")
+	}
+	if stats, err := restored.FilteredEventTypeStats(url, "custom:signup", "plan", "free"); err != nil || len(stats.Events) != 1 || stats.Events[0].Count != 1 {
+		t.Errorf("Expected 1 restored free signup, got %+v, %v", stats, err)
+	}
+
+	tracker.DeleteURL(url, "test")
+	if stats, _ := tracker.FilteredEventTypeStats(url, "custom:signup", "plan", "pro"); len(stats.Events) != 0 {
+		t.Errorf("Expected no counts after deleting the URL, got %+v", stats.Events)
+	}
+	if !tracker.PropertySchemas().Delete("custom:signup") || tracker.PropertySchemas().Delete("custom:signup") {
+		t.Error("Expected the schema to be deleted once")
+	}
+}
@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// placeholderVisitorIDs are values clients send when the real visitor ID is
+// missing, compared case-insensitively.
+var placeholderVisitorIDs = map[string]bool{
+	"undefined":                            true,
+	"null":                                 true,
+	"nil":                                  true,
+	"none":                                 true,
+	"unknown":                              true,
+	"anonymous":                            true,
+	"guest":                                true,
+	"default":                              true,
+	"test":                                 true,
+	"0":                                    true,
+	"00000000-0000-0000-0000-000000000000": true,
+}
+
+// dataQuality flags visitor IDs that look synthetic and drops excluded ones
+// from distinct counts.
+type dataQuality struct {
+	excluded map[string]bool
+	// placeholders holds the digests of placeholderVisitorIDs when visitor
+	// IDs are hashed; hashed IDs can only match the lower-case placeholders.
+	placeholders  map[string]bool
+	share         float64
+	minPageViews  int64
+	excludedViews atomic.Int64
+}
+
+// newDataQuality hashes the excluded IDs and placeholders with p so they
+// match stored visitor IDs.
+func newDataQuality(config models.DataQualityConfig, p *pseudonymizer) *dataQuality {
+	q := &dataQuality{
+		excluded:     make(map[string]bool, len(config.ExcludedVisitorIDs)),
+		share:        config.SuspiciousShare,
+		minPageViews: config.MinPageViews,
+	}
+	for _, visitorID := range config.ExcludedVisitorIDs {
+		q.excluded[p.hash(visitorID)] = true
+	}
+	if p != nil {
+		q.placeholders = make(map[string]bool, len(placeholderVisitorIDs))
+		for visitorID := range placeholderVisitorIDs {
+			q.placeholders[p.hash(visitorID)] = true
+		}
+	}
+	return q
+}
+
+// isPlaceholder reports whether the stored visitorID is a placeholder value.
+func (q *dataQuality) isPlaceholder(visitorID string) bool {
+	if q.placeholders != nil {
+		return q.placeholders[visitorID]
+	}
+	return placeholderVisitorIDs[strings.ToLower(visitorID)]
+}
+
+// isExcluded reports whether visitorID's events count as page views but not as visitors.
+func (q *dataQuality) isExcluded(visitorID string) bool {
+	return q.excluded[visitorID]
+}
+
+// DataQualityReport lists visitor IDs that are placeholders or account for at
+// least the configured share of a URL's page views, once the URL has enough
+// of them, most page views first. Shards are scanned one at a time; URLs in
+// the cold tier are not checked.
+func (nt *NavigationTracker) DataQualityReport() *models.DataQualityReport {
+	q := nt.quality
+	suspicious := make(map[string]*models.SuspiciousVisitorID)
+	flag := func(visitorID, url string, reasons []string, record *visitorRecord, share float64) {
+		s := suspicious[visitorID]
+		if s == nil {
+			s = &models.SuspiciousVisitorID{VisitorID: visitorID}
+			suspicious[visitorID] = s
+		}
+		for _, reason := range reasons {
+			if !hasReason(s.Reasons, reason) {
+				s.Reasons = append(s.Reasons, reason)
+			}
+		}
+		s.URLs++
+		s.PageViews += record.pageViews
+		if share > s.MaxShare {
+			s.MaxShare = share
+			s.MaxShareURL = url
+		}
+	}
+
+	for _, shard := range nt.shards {
+		shard.mutex.RLock()
+		for url, stats := range shard.urls {
+			for visitorID, record := range stats.visitors {
+				share := float64(record.pageViews) / float64(stats.pageViews)
+
+				var reasons []string
+				if q.isPlaceholder(visitorID) {
+					reasons = append(reasons, models.QualityReasonPlaceholder)
+				}
+				if stats.pageViews >= q.minPageViews && share >= q.share {
+					reasons = append(reasons, models.QualityReasonOverRepresented)
+				}
+				if len(reasons) > 0 {
+					flag(visitorID, url, reasons, record, share)
+				}
+			}
+		}
+		shard.mutex.RUnlock()
+	}
+
+	report := &models.DataQualityReport{
+		GeneratedAt:        time.Now().UTC(),
+		ExcludedVisitorIDs: make([]string, 0, len(q.excluded)),
+		ExcludedPageViews:  q.excludedViews.Load(),
+		Suspicious:         make([]*models.SuspiciousVisitorID, 0, len(suspicious)),
+	}
+	for visitorID := range q.excluded {
+		report.ExcludedVisitorIDs = append(report.ExcludedVisitorIDs, visitorID)
+	}
+	sort.Strings(report.ExcludedVisitorIDs)
+	for _, s := range suspicious {
+		sort.Strings(s.Reasons)
+		report.Suspicious = append(report.Suspicious, s)
+	}
+	sort.Slice(report.Suspicious, func(i, j int) bool {
+		a, b := report.Suspicious[i], report.Suspicious[j]
+		if a.PageViews != b.PageViews {
+			return a.PageViews > b.PageViews
+		}
+		return a.VisitorID < b.VisitorID
+	})
+	return report
+}
+
+func hasReason(reasons []string, reason string) bool {
+	for _, r := range reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
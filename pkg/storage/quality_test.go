@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestNavigationTracker_DataQualityReport(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.DataQuality.MinPageViews = 10
+	tracker := NewNavigationTrackerWithConfig(config)
+
+	record := func(visitorID, url string, times int) {
+		for i := 0; i < times; i++ {
+			if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: visitorID, URL: url}); err != nil {
+				t.Fatalf("Failed to record event: %v", err)
+			}
+		}
+	}
+
+	// iPhone14 accounts for most of /a's views; /b has too few views to judge
+	record("iPhone14", "https://example.com/a", 8)
+	for i := 0; i < 4; i++ {
+		record(fmt.Sprintf("visitor%d", i), "https://example.com/a", 1)
+	}
+	record("lonely", "https://example.com/b", 3)
+	record("NULL", "https://example.com/b", 1)
+	record("NULL", "https://example.com/c", 1)
+
+	report := tracker.DataQualityReport()
+	if len(report.Suspicious) != 2 {
+		t.Fatalf("Expected 2 suspicious visitor IDs, got %+v", report.Suspicious)
+	}
+
+	first := report.Suspicious[0]
+	if first.VisitorID != "iPhone14" || first.PageViews != 8 || first.MaxShareURL != "https://example.com/a" ||
+		len(first.Reasons) != 1 || first.Reasons[0] != models.QualityReasonOverRepresented {
+		t.Errorf("Unexpected over-represented entry: %+v", first)
+	}
+
+	second := report.Suspicious[1]
+	if second.VisitorID != "NULL" || second.URLs != 2 || second.PageViews != 2 ||
+		len(second.Reasons) != 1 || second.Reasons[0] != models.QualityReasonPlaceholder {
+		t.Errorf("Unexpected placeholder entry: %+v", second)
+	}
+}
+
+func TestNavigationTracker_ExcludedVisitorIDs(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.DataQuality.ExcludedVisitorIDs = []string{"undefined"}
+	tracker := NewNavigationTrackerWithConfig(config)
+	url := "https://example.com/page"
+
+	for _, visitorID := range []string{"undefined", "undefined", "visitor1"} {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: visitorID, URL: url}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	stats := tracker.GetVisitorStats(url)
+	if stats.DistinctVisitors != 1 || stats.TotalPageViews != 3 {
+		t.Errorf("Expected 1 visitor and 3 page views, got %d and %d", stats.DistinctVisitors, stats.TotalPageViews)
+	}
+	if _, exists := tracker.GetVisitorProfile("undefined"); exists {
+		t.Error("Expected excluded visitor ID to have no profile")
+	}
+
+	report := tracker.DataQualityReport()
+	if report.ExcludedPageViews != 2 || len(report.ExcludedVisitorIDs) != 1 {
+		t.Errorf("Expected 2 excluded page views, got %+v", report)
+	}
+}
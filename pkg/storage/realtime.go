@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// realtimeGauge counts the visitors online now: those with an event in the
+// last window of minutes, overall and per URL. Visitors are kept in a ring of
+// one-minute buckets, each reused once its minute has left the window, so
+// memory is bounded by the visitors seen during the window.
+type realtimeGauge struct {
+	window  time.Duration
+	buckets []realtimeBucket
+	mutex   sync.Mutex
+}
+
+type realtimeBucket struct {
+	// minute is the bucket's minute as Unix minutes; 0 while unused.
+	minute   int64
+	visitors map[string]struct{}
+	urls     map[string]map[string]struct{}
+}
+
+func newRealtimeGauge(config models.RealtimeConfig) *realtimeGauge {
+	minutes := int(config.Window / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+	return &realtimeGauge{
+		window:  time.Duration(minutes) * time.Minute,
+		buckets: make([]realtimeBucket, minutes),
+	}
+}
+
+func unixMinute(t time.Time) int64 {
+	return t.Unix() / 60
+}
+
+// record marks visitorID online on url in the minute of timestamp. Events
+// dated before the window ending now are ignored, and those dated after it
+// count as now.
+func (g *realtimeGauge) record(visitorID, url string, timestamp, now time.Time) {
+	minute, current := unixMinute(timestamp), unixMinute(now)
+	if minute > current {
+		minute = current
+	}
+	if minute <= current-int64(len(g.buckets)) {
+		return
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	bucket := &g.buckets[minute%int64(len(g.buckets))]
+	if bucket.minute != minute {
+		if bucket.minute > minute {
+			// A newer minute has already reused the bucket
+			return
+		}
+		*bucket = realtimeBucket{
+			minute:   minute,
+			visitors: make(map[string]struct{}),
+			urls:     make(map[string]map[string]struct{}),
+		}
+	}
+	bucket.visitors[visitorID] = struct{}{}
+	visitors := bucket.urls[url]
+	if visitors == nil {
+		visitors = make(map[string]struct{})
+		bucket.urls[url] = visitors
+	}
+	visitors[visitorID] = struct{}{}
+}
+
+// count returns the distinct visitors on url, or on any URL when url is
+// empty, during the window ending now.
+func (g *realtimeGauge) count(url string, now time.Time) int {
+	current := unixMinute(now)
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	online := make(map[string]struct{})
+	for i := range g.buckets {
+		bucket := &g.buckets[i]
+		if bucket.minute <= current-int64(len(g.buckets)) || bucket.minute > current {
+			continue
+		}
+		visitors := bucket.visitors
+		if url != "" {
+			visitors = bucket.urls[url]
+		}
+		for visitorID := range visitors {
+			online[visitorID] = struct{}{}
+		}
+	}
+	return len(online)
+}
+
+func (g *realtimeGauge) reset() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.buckets = make([]realtimeBucket, len(g.buckets))
+}
+
+func (g *realtimeGauge) forgetVisitor(visitorID string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for i := range g.buckets {
+		bucket := &g.buckets[i]
+		if _, exists := bucket.visitors[visitorID]; !exists {
+			continue
+		}
+		delete(bucket.visitors, visitorID)
+		for url, visitors := range bucket.urls {
+			delete(visitors, visitorID)
+			if len(visitors) == 0 {
+				delete(bucket.urls, url)
+			}
+		}
+	}
+}
+
+// forgetURL removes url's visitors from the URL. They still count as online
+// overall.
+func (g *realtimeGauge) forgetURL(url string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for i := range g.buckets {
+		delete(g.buckets[i].urls, url)
+	}
+}
+
+// Realtime returns the visitors online now on url, or on any URL when url is
+// empty: those with an event during the configured window.
+func (nt *NavigationTracker) Realtime(url string, now time.Time) *models.RealtimeVisitors {
+	return &models.RealtimeVisitors{
+		URL:      url,
+		Window:   nt.realtime.window,
+		AsOf:     now.UTC(),
+		Visitors: nt.realtime.count(url, now),
+	}
+}
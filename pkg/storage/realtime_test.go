@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestRealtimeGauge(t *testing.T) {
+	gauge := newRealtimeGauge(models.RealtimeConfig{Window: 5 * time.Minute})
+	now := time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)
+
+	gauge.record("visitor1", "https://example.com/a", now.Add(-4*time.Minute), now)
+	gauge.record("visitor1", "https://example.com/b", now, now)
+	gauge.record("visitor2", "https://example.com/a", now.Add(-2*time.Minute), now)
+	gauge.record("visitor3", "https://example.com/a", now.Add(-10*time.Minute), now)
+	gauge.record("visitor4", "https://example.com/b", now.Add(time.Hour), now)
+
+	if online := gauge.count("", now); online != 3 {
+		t.Errorf("Expected 3 visitors online, got %d", online)
+	}
+	if online := gauge.count("https://example.com/a", now); online != 2 {
+		t.Errorf("Expected 2 visitors online on /a, got %d", online)
+	}
+
+	// visitor1's /a event leaves the window after its minute has passed
+	later := now.Add(90 * time.Second)
+	if online := gauge.count("https://example.com/a", later); online != 1 {
+		t.Errorf("Expected 1 visitor online on /a later, got %d", online)
+	}
+	// A new minute starts afresh in a bucket whose minute has expired
+	gauge.record("visitor5", "https://example.com/c", later, later)
+	if online := gauge.count("", later); online != 4 {
+		t.Errorf("Expected 4 visitors online later, got %d", online)
+	}
+	if online := gauge.count("", now.Add(time.Hour)); online != 0 {
+		t.Errorf("Expected nobody online an hour later, got %d", online)
+	}
+
+	gauge.forgetURL("https://example.com/b")
+	if online := gauge.count("https://example.com/b", later); online != 0 {
+		t.Errorf("Expected no visitors on a forgotten URL, got %d", online)
+	}
+	gauge.forgetVisitor("visitor2")
+	if online := gauge.count("", later); online != 3 {
+		t.Errorf("Expected 3 visitors online after forgetting one, got %d", online)
+	}
+}
+
+func TestNavigationTracker_Realtime(t *testing.T) {
+	tracker := NewNavigationTracker()
+	for _, event := range []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a"},
+		{VisitorID: "visitor2", URL: "https://example.com/b"},
+		{VisitorID: "visitor3", URL: "https://example.com/a", Timestamp: time.Now().Add(-time.Hour)},
+	} {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	realtime := tracker.Realtime("", time.Now())
+	if realtime.Visitors != 2 || realtime.Window != 5*time.Minute {
+		t.Errorf("Expected 2 visitors online over 5m, got %+v", realtime)
+	}
+	if realtime := tracker.Realtime("https://example.com/a", time.Now()); realtime.Visitors != 1 {
+		t.Errorf("Expected 1 visitor online on /a, got %d", realtime.Visitors)
+	}
+
+	tracker.DeleteVisitor("visitor1", "test")
+	if realtime := tracker.Realtime("", time.Now()); realtime.Visitors != 1 {
+		t.Errorf("Expected 1 visitor online after deletion, got %d", realtime.Visitors)
+	}
+}
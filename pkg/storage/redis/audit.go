@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// auditBuckets is the resolution of the URL sampling decision.
+const auditBuckets = 10000
+
+// audited reports whether url is in the exact-count audit sample. The
+// decision is a hash of the URL so every replica samples the same URLs.
+func (b *Backend) audited(url string) bool {
+	if !b.config.HyperLogLog || b.config.AuditSampleRate <= 0 {
+		return false
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(url))
+	return float64(h.Sum32()%auditBuckets) < b.config.AuditSampleRate*auditBuckets
+}
+
+func (b *Backend) auditKey(url string) string {
+	return b.config.KeyPrefix + "audit:visitors:" + url
+}
+
+func (b *Backend) auditURLsKey() string {
+	return b.config.KeyPrefix + "audit:urls"
+}
+
+// Accuracy compares the HyperLogLog distinct visitor counts of the audited
+// URLs against their exact counts, largest error first.
+func (b *Backend) Accuracy() (*models.AccuracyReport, error) {
+	ctx, cancel := b.context()
+	defer cancel()
+
+	urls, err := b.client.SMembers(ctx, b.auditURLsKey()).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.AccuracyReport{
+		URLs:        make([]*models.URLAccuracy, 0, len(urls)),
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	var totalError float64
+	for _, url := range urls {
+		exact, err := b.client.SCard(ctx, b.auditKey(url)).Result()
+		if err != nil {
+			return nil, err
+		}
+		approximate, err := b.client.PFCount(ctx, b.visitorsKey(url)).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		accuracy := &models.URLAccuracy{URL: url, Exact: exact, Approximate: approximate}
+		if exact > 0 {
+			accuracy.RelativeError = math.Abs(float64(approximate-exact)) / float64(exact)
+		}
+
+		totalError += accuracy.RelativeError
+		report.MaxRelativeError = math.Max(report.MaxRelativeError, accuracy.RelativeError)
+		report.URLs = append(report.URLs, accuracy)
+	}
+
+	report.SampledURLs = len(report.URLs)
+	if report.SampledURLs > 0 {
+		report.MeanRelativeError = totalError / float64(report.SampledURLs)
+	}
+
+	sort.Slice(report.URLs, func(i, j int) bool {
+		if report.URLs[i].RelativeError != report.URLs[j].RelativeError {
+			return report.URLs[i].RelativeError > report.URLs[j].RelativeError
+		}
+		return report.URLs[i].URL < report.URLs[j].URL
+	})
+
+	return report, nil
+}
@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"fmt"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestBackend_Accuracy(t *testing.T) {
+	backend := newTestBackend(t, true)
+	backend.config.AuditSampleRate = 1
+
+	for i := 0; i < 50; i++ {
+		event := &models.NavigationEvent{VisitorID: fmt.Sprintf("visitor%d", i), URL: "https://example.com/a"}
+		if err := backend.Record(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	report, err := backend.Accuracy()
+	if err != nil {
+		t.Fatalf("Failed to build report: %v", err)
+	}
+
+	if report.SampledURLs != 1 || report.URLs[0].Exact != 50 {
+		t.Fatalf("Expected one audited URL with 50 exact visitors, got %+v", report.URLs)
+	}
+
+	if report.MaxRelativeError > 0.05 {
+		t.Errorf("Expected small HyperLogLog error, got %f", report.MaxRelativeError)
+	}
+}
+
+func TestBackend_AuditSampling(t *testing.T) {
+	backend := newTestBackend(t, true)
+
+	if backend.audited("https://example.com/a") {
+		t.Error("Expected no auditing with a zero sample rate")
+	}
+
+	backend.config.AuditSampleRate = 0.5
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		url := fmt.Sprintf("https://example.com/%d", i)
+		if backend.audited(url) {
+			sampled++
+		}
+		if backend.audited(url) != backend.audited(url) {
+			t.Fatal("Expected sampling to be deterministic per URL")
+		}
+	}
+
+	if sampled < 400 || sampled > 600 {
+		t.Errorf("Expected about half of URLs sampled, got %d", sampled)
+	}
+}
@@ -0,0 +1,232 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"nav-tracker/pkg/models"
+)
+
+// Backend implements storage.CounterBackend on Redis. Distinct visitors are
+// kept in one set (or HyperLogLog) per URL and page views in a single hash
+// keyed by URL. With sets, a hash per visitor indexes their page views by
+// URL so ForgetVisitor can take them back out.
+type Backend struct {
+	client *goredis.Client
+	config models.RedisConfig
+}
+
+func NewBackend(config models.RedisConfig) *Backend {
+	return &Backend{
+		client: goredis.NewClient(&goredis.Options{
+			Addr:     config.Address,
+			Password: config.Password,
+			DB:       config.DB,
+		}),
+		config: config,
+	}
+}
+
+func (b *Backend) visitorsKey(url string) string {
+	return b.config.KeyPrefix + "visitors:" + url
+}
+
+func (b *Backend) pageViewsKey() string {
+	return b.config.KeyPrefix + "pageviews"
+}
+
+func (b *Backend) visitorKey(visitorID string) string {
+	return b.config.KeyPrefix + "visitor:" + visitorID
+}
+
+func (b *Backend) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), b.config.Timeout)
+}
+
+// Ping checks that Redis is reachable.
+func (b *Backend) Ping() error {
+	ctx, cancel := b.context()
+	defer cancel()
+
+	return b.client.Ping(ctx).Err()
+}
+
+func (b *Backend) Record(event *models.NavigationEvent) error {
+	ctx, cancel := b.context()
+	defer cancel()
+
+	_, err := b.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		if b.config.HyperLogLog {
+			pipe.PFAdd(ctx, b.visitorsKey(event.URL), event.VisitorID)
+		} else {
+			pipe.SAdd(ctx, b.visitorsKey(event.URL), event.VisitorID)
+			pipe.HIncrBy(ctx, b.visitorKey(event.VisitorID), event.URL, 1)
+		}
+		pipe.HIncrBy(ctx, b.pageViewsKey(), event.URL, 1)
+		if b.audited(event.URL) {
+			pipe.SAdd(ctx, b.auditKey(event.URL), event.VisitorID)
+			pipe.SAdd(ctx, b.auditURLsKey(), event.URL)
+		}
+		return nil
+	})
+
+	return err
+}
+
+func (b *Backend) DistinctVisitors(url string) (int, error) {
+	ctx, cancel := b.context()
+	defer cancel()
+
+	var count int64
+	var err error
+	if b.config.HyperLogLog {
+		count, err = b.client.PFCount(ctx, b.visitorsKey(url)).Result()
+	} else {
+		count, err = b.client.SCard(ctx, b.visitorsKey(url)).Result()
+	}
+
+	return int(count), err
+}
+
+func (b *Backend) PageViews(url string) (int64, error) {
+	ctx, cancel := b.context()
+	defer cancel()
+
+	count, err := b.client.HGet(ctx, b.pageViewsKey(), url).Int64()
+	if errors.Is(err, goredis.Nil) {
+		return 0, nil
+	}
+
+	return count, err
+}
+
+// ForgetVisitor removes visitorID from each URL's visitor set and takes
+// their page views off, then from the audit samples. A HyperLogLog keeps
+// no visitor IDs and cannot drop one, so with HyperLogLog only the audit
+// samples are purged and the visitor stays counted.
+func (b *Backend) ForgetVisitor(visitorID string) error {
+	ctx, cancel := b.context()
+	defer cancel()
+
+	var urls map[string]string
+	if !b.config.HyperLogLog {
+		var err error
+		if urls, err = b.client.HGetAll(ctx, b.visitorKey(visitorID)).Result(); err != nil {
+			return err
+		}
+	}
+	audited, err := b.client.SMembers(ctx, b.auditURLsKey()).Result()
+	if err != nil {
+		return err
+	}
+
+	_, err = b.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for url, views := range urls {
+			pageViews, err := strconv.ParseInt(views, 10, 64)
+			if err != nil {
+				return fmt.Errorf("visitor page views for %s: %w", url, err)
+			}
+			pipe.SRem(ctx, b.visitorsKey(url), visitorID)
+			pipe.HIncrBy(ctx, b.pageViewsKey(), url, -pageViews)
+		}
+		pipe.Del(ctx, b.visitorKey(visitorID))
+		for _, url := range audited {
+			pipe.SRem(ctx, b.auditKey(url), visitorID)
+		}
+		return nil
+	})
+	return err
+}
+
+// DeleteURL removes url's visitors, page views and audit sample.
+func (b *Backend) DeleteURL(url string) error {
+	ctx, cancel := b.context()
+	defer cancel()
+
+	var visitors []string
+	if !b.config.HyperLogLog {
+		var err error
+		if visitors, err = b.client.SMembers(ctx, b.visitorsKey(url)).Result(); err != nil {
+			return err
+		}
+	}
+
+	_, err := b.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for _, visitorID := range visitors {
+			pipe.HDel(ctx, b.visitorKey(visitorID), url)
+		}
+		pipe.Del(ctx, b.visitorsKey(url), b.auditKey(url))
+		pipe.HDel(ctx, b.pageViewsKey(), url)
+		pipe.SRem(ctx, b.auditURLsKey(), url)
+		return nil
+	})
+	return err
+}
+
+// MergeURL folds source's visitors and page views into target's, counting
+// visitors seen on both once, and removes source.
+func (b *Backend) MergeURL(source, target string) error {
+	if source == target {
+		return nil
+	}
+
+	ctx, cancel := b.context()
+	defer cancel()
+
+	pageViews, err := b.client.HGet(ctx, b.pageViewsKey(), source).Int64()
+	if err != nil && !errors.Is(err, goredis.Nil) {
+		return err
+	}
+
+	// Each visitor's page views on source move to target in their index
+	views := make(map[string]*goredis.StringCmd)
+	if !b.config.HyperLogLog {
+		visitors, err := b.client.SMembers(ctx, b.visitorsKey(source)).Result()
+		if err != nil {
+			return err
+		}
+		_, err = b.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+			for _, visitorID := range visitors {
+				views[visitorID] = pipe.HGet(ctx, b.visitorKey(visitorID), source)
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, goredis.Nil) {
+			return err
+		}
+	}
+
+	_, err = b.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		if b.config.HyperLogLog {
+			pipe.PFMerge(ctx, b.visitorsKey(target), b.visitorsKey(target), b.visitorsKey(source))
+		} else {
+			pipe.SUnionStore(ctx, b.visitorsKey(target), b.visitorsKey(target), b.visitorsKey(source))
+		}
+		for visitorID, cmd := range views {
+			if n, err := cmd.Int64(); err == nil {
+				pipe.HIncrBy(ctx, b.visitorKey(visitorID), target, n)
+			}
+			pipe.HDel(ctx, b.visitorKey(visitorID), source)
+		}
+		if pageViews > 0 {
+			pipe.HIncrBy(ctx, b.pageViewsKey(), target, pageViews)
+		}
+		if b.audited(target) {
+			pipe.SUnionStore(ctx, b.auditKey(target), b.auditKey(target), b.auditKey(source))
+			pipe.SAdd(ctx, b.auditURLsKey(), target)
+		}
+		pipe.Del(ctx, b.visitorsKey(source), b.auditKey(source))
+		pipe.HDel(ctx, b.pageViewsKey(), source)
+		pipe.SRem(ctx, b.auditURLsKey(), source)
+		return nil
+	})
+	return err
+}
+
+func (b *Backend) Close() error {
+	return b.client.Close()
+}
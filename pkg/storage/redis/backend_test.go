@@ -0,0 +1,137 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func newTestBackend(t *testing.T, hyperLogLog bool) *Backend {
+	mr := miniredis.RunT(t)
+
+	config := models.DefaultConfiguration().Redis
+	config.Address = mr.Addr()
+	config.HyperLogLog = hyperLogLog
+
+	backend := NewBackend(config)
+	t.Cleanup(func() { _ = backend.Close() })
+
+	if err := backend.Ping(); err != nil {
+		t.Fatalf("Failed to ping redis: %v", err)
+	}
+
+	return backend
+}
+
+func TestBackend_SharedAcrossTrackers(t *testing.T) {
+	backend := newTestBackend(t, false)
+
+	replicaA := storage.NewNavigationTracker()
+	replicaA.SetCounterBackend(backend)
+	replicaB := storage.NewNavigationTracker()
+	replicaB.SetCounterBackend(backend)
+
+	events := []struct {
+		tracker   *storage.NavigationTracker
+		visitorID string
+	}{
+		{replicaA, "visitor1"},
+		{replicaB, "visitor2"},
+		{replicaB, "visitor1"},
+	}
+	for _, e := range events {
+		if err := e.tracker.RecordEvent(&models.NavigationEvent{VisitorID: e.visitorID, URL: "https://example.com/a"}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	if got := replicaA.GetDistinctVisitors("https://example.com/a"); got != 2 {
+		t.Errorf("Expected 2 distinct visitors on replica A, got %d", got)
+	}
+
+	stats := replicaB.GetVisitorStats("https://example.com/a")
+	if stats.DistinctVisitors != 2 || stats.TotalPageViews != 3 {
+		t.Errorf("Expected 2 visitors and 3 page views on replica B, got %+v", stats)
+	}
+}
+
+func TestBackend_HyperLogLog(t *testing.T) {
+	backend := newTestBackend(t, true)
+
+	for _, visitorID := range []string{"visitor1", "visitor2", "visitor1"} {
+		if err := backend.Record(&models.NavigationEvent{VisitorID: visitorID, URL: "https://example.com/a"}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	visitors, err := backend.DistinctVisitors("https://example.com/a")
+	if err != nil || visitors != 2 {
+		t.Errorf("Expected 2 distinct visitors, got %d (%v)", visitors, err)
+	}
+
+	pageViews, err := backend.PageViews("https://example.com/missing")
+	if err != nil || pageViews != 0 {
+		t.Errorf("Expected 0 page views for unknown URL, got %d (%v)", pageViews, err)
+	}
+}
+
+func TestBackend_Deletions(t *testing.T) {
+	backend := newTestBackend(t, false)
+	tracker := storage.NewNavigationTracker()
+	tracker.SetCounterBackend(backend)
+	a, b := "https://example.com/a", "https://example.com/b"
+
+	for _, event := range []models.NavigationEvent{
+		{VisitorID: "alice", URL: a},
+		{VisitorID: "alice", URL: a},
+		{VisitorID: "alice", URL: b},
+		{VisitorID: "bob", URL: a},
+		{VisitorID: "carol", URL: b},
+	} {
+		if err := tracker.RecordEvent(&event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	if entry := tracker.DeleteVisitor("alice", "test"); entry.BackendError != "" {
+		t.Fatalf("Expected the backend to forget the visitor, got %s", entry.BackendError)
+	}
+	if visitors, _ := backend.DistinctVisitors(a); visitors != 1 {
+		t.Errorf("Expected 1 visitor on a, got %d", visitors)
+	}
+	if pageViews, _ := backend.PageViews(a); pageViews != 1 {
+		t.Errorf("Expected 1 page view on a, got %d", pageViews)
+	}
+
+	if err := backend.MergeURL(a, b); err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+	if visitors, _ := backend.DistinctVisitors(b); visitors != 2 {
+		t.Errorf("Expected 2 visitors on b after the merge, got %d", visitors)
+	}
+	if pageViews, _ := backend.PageViews(b); pageViews != 2 {
+		t.Errorf("Expected 2 page views on b after the merge, got %d", pageViews)
+	}
+
+	// bob's page views moved with the merge, so forgetting him empties b
+	if err := backend.ForgetVisitor("bob"); err != nil {
+		t.Fatalf("Failed to forget visitor: %v", err)
+	}
+	if pageViews, _ := backend.PageViews(b); pageViews != 1 {
+		t.Errorf("Expected 1 page view on b, got %d", pageViews)
+	}
+
+	if entry := tracker.DeleteURL(b, "test"); entry.BackendError != "" {
+		t.Fatalf("Expected the backend to delete the URL, got %s", entry.BackendError)
+	}
+	if visitors, _ := backend.DistinctVisitors(b); visitors != 0 {
+		t.Errorf("Expected no visitors on b, got %d", visitors)
+	}
+	if fields, _ := backend.client.HLen(context.Background(), backend.visitorKey("carol")).Result(); fields != 0 {
+		t.Errorf("Expected carol's index emptied, got %d URLs", fields)
+	}
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"sort"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+type referrerStats struct {
+	visitors  map[string]bool
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// recordReferrer aggregates the event's referrer domain and returns the
+// approximate bytes it added; the caller must hold the URL's shard lock.
+func (s *urlStats) recordReferrer(event *models.NavigationEvent) int64 {
+	domain := event.ReferrerDomain()
+	if domain == "" {
+		return 0
+	}
+
+	var added int64
+	stats := s.referrers[domain]
+	if stats == nil {
+		stats = &referrerStats{
+			visitors:  make(map[string]bool),
+			firstSeen: event.Timestamp,
+			lastSeen:  event.Timestamp,
+		}
+		s.referrers[domain] = stats
+		added += referrerEntryBytes + int64(len(domain))
+	}
+
+	if !stats.visitors[event.VisitorID] {
+		stats.visitors[event.VisitorID] = true
+		added += referrerVisitorBytes + int64(len(event.VisitorID))
+	}
+	if event.Timestamp.Before(stats.firstSeen) {
+		stats.firstSeen = event.Timestamp
+	}
+	if event.Timestamp.After(stats.lastSeen) {
+		stats.lastSeen = event.Timestamp
+	}
+	return added
+}
+
+// GetTopReferrers returns up to limit referring domains for url, ordered by distinct visitors.
+func (nt *NavigationTracker) GetTopReferrers(url string, limit int) []*models.ReferrerStats {
+	nt.promote(url)
+	shard := nt.urlShard(url)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	stats, exists := shard.lookup(url)
+	if !exists {
+		return []*models.ReferrerStats{}
+	}
+
+	result := make([]*models.ReferrerStats, 0, len(stats.referrers))
+	for domain, referrer := range stats.referrers {
+		result = append(result, &models.ReferrerStats{
+			Domain:           domain,
+			DistinctVisitors: len(referrer.visitors),
+			FirstSeen:        referrer.firstSeen,
+			LastSeen:         referrer.lastSeen,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].DistinctVisitors != result[j].DistinctVisitors {
+			return result[i].DistinctVisitors > result[j].DistinctVisitors
+		}
+		return result[i].Domain < result[j].Domain
+	})
+
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
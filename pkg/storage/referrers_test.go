@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestNavigationTracker_GetTopReferrers(t *testing.T) {
+	tracker := NewNavigationTracker()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", Referrer: "https://Twitter.com/post", Timestamp: base.Add(time.Hour)},
+		{VisitorID: "visitor1", URL: "https://example.com/a", Referrer: "https://twitter.com/other", Timestamp: base},
+		{VisitorID: "visitor2", URL: "https://example.com/a", Referrer: "https://bing.com/", Timestamp: base},
+		{VisitorID: "visitor3", URL: "https://example.com/a", Referrer: "not a url at all", Timestamp: base},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	referrers := tracker.GetTopReferrers("https://example.com/a", 10)
+	if len(referrers) != 2 {
+		t.Fatalf("Expected 2 referrer domains, got %d", len(referrers))
+	}
+
+	twitter := referrers[1]
+	if twitter.Domain != "twitter.com" || twitter.DistinctVisitors != 1 {
+		t.Errorf("Unexpected twitter stats: %+v", twitter)
+	}
+
+	if !twitter.FirstSeen.Equal(base) || !twitter.LastSeen.Equal(base.Add(time.Hour)) {
+		t.Errorf("Unexpected first/last seen: %v - %v", twitter.FirstSeen, twitter.LastSeen)
+	}
+
+	if len(tracker.GetTopReferrers("https://example.com/missing", 10)) != 0 {
+		t.Error("Expected no referrers for unknown URL")
+	}
+}
@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"container/heap"
+	"log"
+	"regexp"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// retentionPolicy resolves the TTL for a URL from the configured rules.
+type retentionPolicy struct {
+	defaultTTL time.Duration
+	rules      []compiledRetentionRule
+}
+
+type compiledRetentionRule struct {
+	pattern *regexp.Regexp
+	ttl     time.Duration
+}
+
+// newRetentionPolicy compiles rules, skipping any whose pattern does not
+// compile; call Configuration.Validate first to surface those errors.
+func newRetentionPolicy(config models.RetentionConfig) *retentionPolicy {
+	policy := &retentionPolicy{defaultTTL: config.MaxEventAge}
+	for _, rule := range config.Rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			continue
+		}
+		policy.rules = append(policy.rules, compiledRetentionRule{pattern: pattern, ttl: rule.TTL})
+	}
+	return policy
+}
+
+func (p *retentionPolicy) enabled() bool {
+	return p.defaultTTL > 0 || len(p.rules) > 0
+}
+
+func (p *retentionPolicy) ttl(url string) time.Duration {
+	for _, rule := range p.rules {
+		if rule.pattern.MatchString(url) {
+			return rule.ttl
+		}
+	}
+	return p.defaultTTL
+}
+
+// expiryEntry is a URL's position in the expiry index.
+type expiryEntry struct {
+	url       string
+	ttl       time.Duration
+	expiresAt time.Time
+	index     int
+}
+
+// expiryIndex is a min-heap of URLs ordered by expiry time.
+type expiryIndex []*expiryEntry
+
+func (ei expiryIndex) Len() int           { return len(ei) }
+func (ei expiryIndex) Less(i, j int) bool { return ei[i].expiresAt.Before(ei[j].expiresAt) }
+
+func (ei expiryIndex) Swap(i, j int) {
+	ei[i], ei[j] = ei[j], ei[i]
+	ei[i].index = i
+	ei[j].index = j
+}
+
+func (ei *expiryIndex) Push(x interface{}) {
+	entry := x.(*expiryEntry)
+	entry.index = len(*ei)
+	*ei = append(*ei, entry)
+}
+
+func (ei *expiryIndex) Pop() interface{} {
+	old := *ei
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	*ei = old[:n-1]
+	return entry
+}
+
+// trackExpiry moves url's expiry to lastSeen plus its TTL; the caller must
+// hold the shard lock. URLs with no TTL are never indexed.
+func (s *urlShard) trackExpiry(policy *retentionPolicy, url string, stats *urlStats, lastSeen time.Time) {
+	if stats.expiry == nil {
+		ttl := stats.ttl
+		if ttl == 0 {
+			ttl = policy.ttl(url)
+		}
+		if ttl <= 0 {
+			return
+		}
+		stats.expiry = &expiryEntry{url: url, ttl: ttl, expiresAt: lastSeen.Add(ttl)}
+		heap.Push(&s.expiries, stats.expiry)
+		return
+	}
+
+	if expiresAt := lastSeen.Add(stats.expiry.ttl); expiresAt.After(stats.expiry.expiresAt) {
+		stats.expiry.expiresAt = expiresAt
+		heap.Fix(&s.expiries, stats.expiry.index)
+	}
+}
+
+// ExpireURLs removes every URL whose expiry is at or before now, here and in
+// the counter backend, and returns the number of URLs and visitor records
+// reclaimed. Session, cohort and funnel history is kept. Shards are swept
+// one at a time so ingestion into the others continues.
+func (nt *NavigationTracker) ExpireURLs(now time.Time) (int, int) {
+	urls, visitors := 0, 0
+	for _, shard := range nt.shards {
+		expired, v := nt.expireShard(shard, now)
+		for _, url := range expired {
+			if err := nt.deleteShared(url); err != nil {
+				log.Printf("Failed to expire URL %s in the counter backend: %v", url, err)
+			}
+		}
+		urls += len(expired)
+		visitors += v
+	}
+
+	nt.mutex.Lock()
+	defer nt.mutex.Unlock()
+
+	nt.expiredURLs += int64(urls)
+	nt.expiredVisitors += int64(visitors)
+	nt.lastSweep = now.UTC()
+
+	return urls, visitors
+}
+
+// expireShard removes the shard's expired URLs and returns them along with
+// the number of visitor records reclaimed.
+func (nt *NavigationTracker) expireShard(shard *urlShard, now time.Time) ([]string, int) {
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	var urls []string
+	visitors := 0
+	for shard.expiries.Len() > 0 && !shard.expiries[0].expiresAt.After(now) {
+		entry := heap.Pop(&shard.expiries).(*expiryEntry)
+
+		stats, exists := shard.hot(entry.url)
+		if !exists {
+			continue
+		}
+		stats.expiry = nil
+
+		visitors += len(stats.visitors)
+		urls = append(urls, entry.url)
+		nt.removeURL(shard, entry.url, stats)
+	}
+
+	return urls, visitors
+}
+
+// removeURL drops url's statistics and its entries in the shard's indexes;
+// the caller must hold the shard lock.
+func (nt *NavigationTracker) removeURL(shard *urlShard, url string, stats *urlStats) {
+	if category := shard.categories[stats.category]; category != nil {
+		category.urls--
+		category.pageViews -= stats.pageViews
+	}
+	nt.patterns.removeURL(url, stats)
+
+	for visitorID := range stats.visitors {
+		nt.unindexVisitor(visitorID, url)
+	}
+
+	if nt.topURLs != nil {
+		nt.topURLs.remove(url)
+	}
+
+	if stats.expiry != nil {
+		heap.Remove(&shard.expiries, stats.expiry.index)
+		stats.expiry = nil
+	}
+
+	nt.events.removeURL(url)
+	nt.schemas.removeURL(url)
+	nt.distribution.remove(stats)
+	shard.untrack(stats)
+	shard.unindexURL(url)
+	delete(shard.urls, url)
+}
+
+// lastSeen returns the most recent time any visitor viewed the URL.
+func (s *urlStats) lastSeen() time.Time {
+	var lastSeen time.Time
+	for _, record := range s.visitors {
+		if record.lastSeen.After(lastSeen) {
+			lastSeen = record.lastSeen
+		}
+	}
+	return lastSeen
+}
+
+// retentionStats reports expiry counters across all shards.
+func (nt *NavigationTracker) retentionStats() *models.RetentionStats {
+	expiring := 0
+	for _, shard := range nt.shards {
+		shard.mutex.RLock()
+		expiring += shard.expiries.Len()
+		shard.mutex.RUnlock()
+	}
+
+	nt.mutex.RLock()
+	defer nt.mutex.RUnlock()
+
+	return &models.RetentionStats{
+		ExpiringURLs:    expiring,
+		ExpiredURLs:     nt.expiredURLs,
+		ExpiredVisitors: nt.expiredVisitors,
+		LastSweep:       nt.lastSweep,
+	}
+}
+
+// rebuildExpiries re-indexes every URL from its visitors' last seen times;
+// the caller must hold every shard lock.
+func (nt *NavigationTracker) rebuildExpiries() {
+	for _, shard := range nt.shards {
+		shard.expiries = nil
+		for url, stats := range shard.urls {
+			stats.expiry = nil
+			shard.trackExpiry(nt.retention, url, stats, stats.lastSeen())
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func newRetentionTracker() *NavigationTracker {
+	config := models.DefaultConfiguration()
+	config.Retention.MaxEventAge = 24 * time.Hour
+	config.Retention.Rules = []models.RetentionRule{
+		{Pattern: `/tmp/`, TTL: time.Hour},
+		{Pattern: `/keep/`, TTL: 0},
+	}
+	return NewNavigationTrackerWithConfig(config)
+}
+
+func TestNavigationTracker_ExpireURLs(t *testing.T) {
+	tracker := newRetentionTracker()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/tmp/a", Timestamp: base},
+		{VisitorID: "visitor2", URL: "https://example.com/tmp/a", Timestamp: base},
+		{VisitorID: "visitor1", URL: "https://example.com/page", Timestamp: base},
+		{VisitorID: "visitor1", URL: "https://example.com/keep/a", Timestamp: base},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	urls, visitors := tracker.ExpireURLs(base.Add(2 * time.Hour))
+	if urls != 1 || visitors != 2 {
+		t.Errorf("Expected 1 URL and 2 visitors expired, got %d and %d", urls, visitors)
+	}
+	if tracker.GetDistinctVisitors("https://example.com/tmp/a") != 0 {
+		t.Error("Expected /tmp/a to be expired")
+	}
+
+	// A newer event extends the URL's expiry
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor2", URL: "https://example.com/page", Timestamp: base.Add(20 * time.Hour)}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+	if urls, _ := tracker.ExpireURLs(base.Add(30 * time.Hour)); urls != 0 {
+		t.Errorf("Expected refreshed URL to survive, got %d expired", urls)
+	}
+
+	urls, _ = tracker.ExpireURLs(base.Add(365 * 24 * time.Hour))
+	if urls != 1 {
+		t.Errorf("Expected only /page to expire, got %d", urls)
+	}
+	if tracker.GetDistinctVisitors("https://example.com/keep/a") != 1 {
+		t.Error("Expected URL with zero TTL to be kept")
+	}
+
+	stats := tracker.GetSystemStats()
+	if stats.Retention == nil || stats.Retention.ExpiredURLs != 2 || stats.Retention.ExpiredVisitors != 4 {
+		t.Errorf("Unexpected retention stats: %+v", stats.Retention)
+	}
+	if stats.TotalUniqueURLs != 1 {
+		t.Errorf("Expected 1 remaining URL, got %d", stats.TotalUniqueURLs)
+	}
+}
+
+func TestNavigationTracker_RetentionDisabled(t *testing.T) {
+	tracker := NewNavigationTracker()
+
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com"}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	if urls, _ := tracker.ExpireURLs(time.Now().Add(365 * 24 * time.Hour)); urls != 0 {
+		t.Errorf("Expected no expiry without retention config, got %d", urls)
+	}
+	if stats := tracker.GetSystemStats(); stats.Retention != nil {
+		t.Errorf("Expected no retention stats when disabled, got %+v", stats.Retention)
+	}
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"sync/atomic"
+
+	"nav-tracker/pkg/models"
+)
+
+// viewSampler records every rate-th repeat view of a URL as rate page views
+// and drops the others before their referrer, device and other breakdowns
+// are parsed. Sessions, cohorts, active visitors and counter backends still
+// see every event.
+type viewSampler struct {
+	rate int64
+
+	repeatViews   atomic.Int64
+	recordedViews atomic.Int64
+}
+
+func newViewSampler(config models.SamplingConfig) *viewSampler {
+	if config.Rate <= 1 {
+		return nil
+	}
+	return &viewSampler{rate: int64(config.Rate)}
+}
+
+// weight returns the page views a repeat view of stats counts as: the rate
+// for every rate-th, and 0 for the rest. The caller must hold the shard
+// write lock.
+func (s *viewSampler) weight(stats *urlStats) int64 {
+	if s == nil {
+		return 1
+	}
+	s.repeatViews.Add(1)
+	stats.repeatViews++
+	if stats.repeatViews%s.rate != 0 {
+		return 0
+	}
+	s.recordedViews.Add(1)
+	return s.rate
+}
+
+func (s *viewSampler) stats() *models.SamplingStats {
+	stats := &models.SamplingStats{
+		Rate:          int(s.rate),
+		RepeatViews:   s.repeatViews.Load(),
+		RecordedViews: s.recordedViews.Load(),
+	}
+	if stats.RepeatViews > 0 {
+		stats.EffectiveRate = float64(stats.RecordedViews) / float64(stats.RepeatViews)
+	}
+	return stats
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestNavigationTracker_Sampling(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Sampling.Rate = 10
+	tracker := NewNavigationTrackerWithConfig(config)
+	url := "https://example.com/a"
+
+	recordVisitors(t, tracker, url, 5)
+	for i := 0; i < 25; i++ {
+		event := &models.NavigationEvent{VisitorID: "visitor0", URL: url, Referrer: "https://google.com/"}
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	// First views are always recorded; 2 of the 25 repeat views are
+	// recorded as 10 views each
+	stats := tracker.GetVisitorStats(url)
+	if stats.DistinctVisitors != 5 || stats.TotalPageViews != 25 || stats.SamplingRate != 10 {
+		t.Errorf("Expected 5 visitors and 25 scaled views at rate 10, got %+v", stats)
+	}
+	if total := tracker.GetSystemStats().TotalPageViews; total != 25 {
+		t.Errorf("Expected 25 total page views, got %d", total)
+	}
+
+	sampling := tracker.GetSystemStats().Sampling
+	if sampling == nil || sampling.RepeatViews != 25 || sampling.RecordedViews != 2 || sampling.EffectiveRate != 0.08 {
+		t.Errorf("Unexpected sampling stats: %+v", sampling)
+	}
+
+	// Only recorded views reach the referrer breakdown
+	if referrers := tracker.GetTopReferrers(url, 10); len(referrers) != 1 {
+		t.Errorf("Expected the sampled referrer, got %+v", referrers)
+	}
+}
+
+func TestNavigationTracker_SamplingDisabled(t *testing.T) {
+	tracker := NewNavigationTracker()
+	recordVisitors(t, tracker, "https://example.com/a", 1)
+	recordVisitors(t, tracker, "https://example.com/a", 1)
+
+	if stats := tracker.GetVisitorStats("https://example.com/a"); stats.TotalPageViews != 2 || stats.SamplingRate != 0 {
+		t.Errorf("Expected every view recorded without a sampling rate, got %+v", stats)
+	}
+	if sampling := tracker.GetSystemStats().Sampling; sampling != nil {
+		t.Errorf("Expected no sampling stats, got %+v", sampling)
+	}
+}
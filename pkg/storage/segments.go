@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"nav-tracker/pkg/models"
+)
+
+// SegmentFilter matches visitors whose latest value of Key is Value.
+type SegmentFilter struct {
+	Key   string
+	Value string
+}
+
+// Segment selects the visitors matching every one of its filters.
+type Segment []SegmentFilter
+
+// ParseSegment reads a segment written as comma-separated key:value filters,
+// such as plan:pro,country:DE. A key may only appear once.
+func ParseSegment(raw string) (Segment, error) {
+	var segment Segment
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), ":")
+		if !found || key == "" {
+			return nil, fmt.Errorf("segment filter %q must be key:value", part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("segment key %q appears more than once", key)
+		}
+		seen[key] = true
+		segment = append(segment, SegmentFilter{Key: key, Value: value})
+	}
+	if len(segment) > models.MaxProperties {
+		return nil, fmt.Errorf("segment must have at most %d filters", models.MaxProperties)
+	}
+	return segment, nil
+}
+
+func (s Segment) String() string {
+	parts := make([]string, len(s))
+	for i, filter := range s {
+		parts[i] = filter.Key + ":" + filter.Value
+	}
+	return strings.Join(parts, ",")
+}
+
+func (s Segment) matches(properties map[string]string) bool {
+	for _, filter := range s {
+		if value, exists := properties[filter.Key]; !exists || value != filter.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// recordProperties merges an event's properties into its visitor's latest
+// properties. New keys beyond MaxProperties are ignored so that a visitor's
+// properties stay bounded.
+func (nt *NavigationTracker) recordProperties(visitorID string, properties map[string]string) {
+	if len(properties) == 0 {
+		return
+	}
+
+	shard := nt.visitorShard(visitorID)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	latest := shard.properties[visitorID]
+	if latest == nil {
+		latest = make(map[string]string, len(properties))
+		shard.properties[visitorID] = latest
+	}
+	for key, value := range properties {
+		if _, exists := latest[key]; exists || len(latest) < models.MaxProperties {
+			latest[key] = value
+		}
+	}
+}
+
+// GetVisitorProperties returns a copy of a visitor's latest properties.
+func (nt *NavigationTracker) GetVisitorProperties(visitorID string) map[string]string {
+	shard := nt.visitorShard(visitorID)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	latest := shard.properties[visitorID]
+	if len(latest) == 0 {
+		return nil
+	}
+	properties := make(map[string]string, len(latest))
+	for key, value := range latest {
+		properties[key] = value
+	}
+	return properties
+}
+
+// urlVisitorIDs returns the IDs of url's visitors.
+func (nt *NavigationTracker) urlVisitorIDs(url string) []string {
+	nt.promote(url)
+	shard := nt.urlShard(url)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	stats, exists := shard.lookup(url)
+	if !exists {
+		return nil
+	}
+	visitorIDs := make([]string, 0, len(stats.visitors))
+	for visitorID := range stats.visitors {
+		visitorIDs = append(visitorIDs, visitorID)
+	}
+	return visitorIDs
+}
+
+// GetSegmentVisitors returns the number of url's distinct visitors whose
+// latest properties match segment. It always counts this instance's visitors,
+// even with a shared counter backend.
+func (nt *NavigationTracker) GetSegmentVisitors(url string, segment Segment) int {
+	count := 0
+	for _, visitorID := range nt.urlVisitorIDs(url) {
+		shard := nt.visitorShard(visitorID)
+		shard.mutex.RLock()
+		if segment.matches(shard.properties[visitorID]) {
+			count++
+		}
+		shard.mutex.RUnlock()
+	}
+	return count
+}
+
+// GetSegmentBreakdown returns url's distinct visitors per latest value of
+// the property key, most visitors first. Visitors without the property are
+// counted under an empty value.
+func (nt *NavigationTracker) GetSegmentBreakdown(url, key string) []*models.SegmentStats {
+	counts := make(map[string]int)
+	for _, visitorID := range nt.urlVisitorIDs(url) {
+		shard := nt.visitorShard(visitorID)
+		shard.mutex.RLock()
+		counts[shard.properties[visitorID][key]]++
+		shard.mutex.RUnlock()
+	}
+
+	breakdown := make([]*models.SegmentStats, 0, len(counts))
+	for value, visitors := range counts {
+		breakdown = append(breakdown, &models.SegmentStats{Value: value, DistinctVisitors: visitors})
+	}
+	sort.Slice(breakdown, func(i, j int) bool {
+		if breakdown[i].DistinctVisitors != breakdown[j].DistinctVisitors {
+			return breakdown[i].DistinctVisitors > breakdown[j].DistinctVisitors
+		}
+		return breakdown[i].Value < breakdown[j].Value
+	})
+	return breakdown
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestParseSegment(t *testing.T) {
+	tests := []struct {
+		raw   string
+		valid bool
+	}{
+		{"plan:pro", true},
+		{"plan:pro,country:DE", true},
+		{"plan:", true},
+		{"plan", false},
+		{":pro", false},
+		{"plan:pro,plan:free", false},
+	}
+
+	for _, tt := range tests {
+		segment, err := ParseSegment(tt.raw)
+		if (err == nil) != tt.valid {
+			t.Errorf("Expected valid=%v for %q, got %v", tt.valid, tt.raw, err)
+		}
+		if err == nil && segment.String() != tt.raw {
+			t.Errorf("Expected %q to round-trip, got %q", tt.raw, segment.String())
+		}
+	}
+}
+
+func recordWithProperties(t *testing.T, tracker *NavigationTracker, visitorID, url string, properties map[string]string) {
+	event := &models.NavigationEvent{VisitorID: visitorID, URL: url, Properties: properties}
+	if err := tracker.RecordEvent(event); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+}
+
+func TestNavigationTracker_GetSegmentVisitors(t *testing.T) {
+	tracker := NewNavigationTracker()
+	url := "https://example.com/pricing"
+
+	recordWithProperties(t, tracker, "visitor1", url, map[string]string{"plan": "free", "country": "DE"})
+	recordWithProperties(t, tracker, "visitor2", url, map[string]string{"plan": "pro", "country": "DE"})
+	recordWithProperties(t, tracker, "visitor3", url, map[string]string{"plan": "pro", "country": "US"})
+	recordWithProperties(t, tracker, "visitor4", url, nil)
+	// The latest value wins, and an event without properties keeps them
+	recordWithProperties(t, tracker, "visitor1", "https://example.com/upgrade", map[string]string{"plan": "pro"})
+	recordWithProperties(t, tracker, "visitor2", url, nil)
+
+	tests := []struct {
+		segment  string
+		expected int
+	}{
+		{"plan:pro", 3},
+		{"plan:pro,country:DE", 2},
+		{"plan:free", 0},
+		{"country:FR", 0},
+	}
+	for _, tt := range tests {
+		segment, _ := ParseSegment(tt.segment)
+		if count := tracker.GetSegmentVisitors(url, segment); count != tt.expected {
+			t.Errorf("Expected %d visitors in %s, got %d", tt.expected, tt.segment, count)
+		}
+	}
+
+	breakdown := tracker.GetSegmentBreakdown(url, "plan")
+	if len(breakdown) != 2 || breakdown[0].Value != "pro" || breakdown[0].DistinctVisitors != 3 || breakdown[1].Value != "" {
+		t.Errorf("Expected 3 pro visitors and 1 without a plan, got %+v", breakdown)
+	}
+}
+
+func TestNavigationTracker_VisitorPropertiesBounded(t *testing.T) {
+	tracker := NewNavigationTracker()
+	url := "https://example.com/"
+
+	for i := 0; i < models.MaxProperties+5; i++ {
+		recordWithProperties(t, tracker, "visitor1", url, map[string]string{fmt.Sprintf("key%d", i): "value"})
+	}
+	if properties := tracker.GetVisitorProperties("visitor1"); len(properties) != models.MaxProperties {
+		t.Errorf("Expected %d properties, got %d", models.MaxProperties, len(properties))
+	}
+}
+
+func TestNavigationTracker_VisitorPropertiesLifecycle(t *testing.T) {
+	tracker := NewNavigationTracker()
+	url := "https://example.com/"
+	recordWithProperties(t, tracker, "visitor1", url, map[string]string{"plan": "pro"})
+	recordWithProperties(t, tracker, "visitor2", url, map[string]string{"plan": "pro"})
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := tracker.SaveSnapshot(path); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	restored := NewNavigationTracker()
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if profile, _ := restored.GetVisitorProfile("visitor1"); profile.Properties["plan"] != "pro" {
+		t.Errorf("Expected properties to be restored, got %v", profile.Properties)
+	}
+
+	restored.DeleteVisitor("visitor1", "test")
+	if properties := restored.GetVisitorProperties("visitor1"); properties != nil {
+		t.Errorf("Expected deleted visitor's properties to be erased, got %v", properties)
+	}
+
+	restored.DeleteURL(url, "test")
+	if properties := restored.GetVisitorProperties("visitor2"); properties != nil {
+		t.Errorf("Expected properties to be dropped with the visitor's last URL, got %v", properties)
+	}
+}
@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// SessionStore groups a visitor's events into sessions. An event continues the
+// visitor's current session when its SessionID matches and it arrives within
+// the idle timeout of the session's last event; otherwise a new session starts.
+type SessionStore struct {
+	idleTimeout time.Duration
+	sessions    map[string][]*session
+	urlSessions map[string]int
+	mutex       sync.RWMutex
+
+	totalSessions  int
+	totalPageViews int64
+	totalDuration  time.Duration
+}
+
+type session struct {
+	id        string
+	explicit  bool
+	startTime time.Time
+	endTime   time.Time
+	pageViews int
+	urls      []string
+	seenURLs  map[string]bool
+}
+
+func NewSessionStore(idleTimeout time.Duration) *SessionStore {
+	if idleTimeout <= 0 {
+		idleTimeout = models.DefaultSessionIdleTimeout
+	}
+
+	return &SessionStore{
+		idleTimeout: idleTimeout,
+		sessions:    make(map[string][]*session),
+		urlSessions: make(map[string]int),
+	}
+}
+
+// Record assigns a validated, normalized event to a session.
+func (ss *SessionStore) Record(event *models.NavigationEvent) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	visitorSessions := ss.sessions[event.VisitorID]
+
+	var current *session
+	if n := len(visitorSessions); n > 0 {
+		last := visitorSessions[n-1]
+		sameID := (event.SessionID == "" && !last.explicit) || event.SessionID == last.id
+		if sameID && event.Timestamp.Sub(last.endTime) <= ss.idleTimeout {
+			current = last
+		}
+	}
+
+	if current == nil {
+		current = &session{
+			id:        event.SessionID,
+			explicit:  event.SessionID != "",
+			startTime: event.Timestamp,
+			endTime:   event.Timestamp,
+			seenURLs:  make(map[string]bool),
+		}
+		if current.id == "" {
+			current.id = fmt.Sprintf("%s-%d", event.VisitorID, event.Timestamp.UnixNano())
+		}
+		ss.sessions[event.VisitorID] = append(visitorSessions, current)
+		ss.totalSessions++
+	}
+
+	oldDuration := current.endTime.Sub(current.startTime)
+	if event.Timestamp.Before(current.startTime) {
+		current.startTime = event.Timestamp
+	}
+	if event.Timestamp.After(current.endTime) {
+		current.endTime = event.Timestamp
+	}
+	ss.totalDuration += current.endTime.Sub(current.startTime) - oldDuration
+
+	current.pageViews++
+	current.urls = append(current.urls, event.URL)
+	ss.totalPageViews++
+
+	if !current.seenURLs[event.URL] {
+		current.seenURLs[event.URL] = true
+		ss.urlSessions[event.URL]++
+	}
+}
+
+func (ss *SessionStore) GetVisitorSessions(visitorID string) []*models.Session {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	visitorSessions := ss.sessions[visitorID]
+	result := make([]*models.Session, 0, len(visitorSessions))
+	for _, s := range visitorSessions {
+		urls := make([]string, len(s.urls))
+		copy(urls, s.urls)
+
+		result = append(result, &models.Session{
+			SessionID: s.id,
+			VisitorID: visitorID,
+			StartTime: s.startTime,
+			EndTime:   s.endTime,
+			Duration:  s.endTime.Sub(s.startTime),
+			PageViews: s.pageViews,
+			URLs:      urls,
+		})
+	}
+
+	return result
+}
+
+func (ss *SessionStore) GetVisitorSessionCount(visitorID string) int {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	return len(ss.sessions[visitorID])
+}
+
+func (ss *SessionStore) GetURLSessionCount(url string) int {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	return ss.urlSessions[url]
+}
+
+// Summary returns the total session count, average session length and
+// average pages per session.
+func (ss *SessionStore) Summary() (int, time.Duration, float64) {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	if ss.totalSessions == 0 {
+		return 0, 0, 0
+	}
+
+	avgLength := ss.totalDuration / time.Duration(ss.totalSessions)
+	pagesPerSession := float64(ss.totalPageViews) / float64(ss.totalSessions)
+
+	return ss.totalSessions, avgLength, pagesPerSession
+}
+
+// ForgetVisitor removes a visitor's sessions and their share of the totals.
+func (ss *SessionStore) ForgetVisitor(visitorID string) int {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	visitorSessions := ss.sessions[visitorID]
+	for _, s := range visitorSessions {
+		ss.totalSessions--
+		ss.totalPageViews -= int64(s.pageViews)
+		ss.totalDuration -= s.endTime.Sub(s.startTime)
+		for url := range s.seenURLs {
+			ss.decrementURLSessions(url)
+		}
+	}
+	delete(ss.sessions, visitorID)
+	return len(visitorSessions)
+}
+
+// ForgetURL removes url from every session's history. Sessions keep their
+// timing; their page views on url are no longer counted.
+func (ss *SessionStore) ForgetURL(url string) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	if ss.urlSessions[url] == 0 {
+		return
+	}
+	for _, visitorSessions := range ss.sessions {
+		for _, s := range visitorSessions {
+			if !s.seenURLs[url] {
+				continue
+			}
+			delete(s.seenURLs, url)
+			kept := s.urls[:0]
+			for _, u := range s.urls {
+				if u != url {
+					kept = append(kept, u)
+				}
+			}
+			removed := len(s.urls) - len(kept)
+			s.urls = kept
+			s.pageViews -= removed
+			ss.totalPageViews -= int64(removed)
+		}
+	}
+	delete(ss.urlSessions, url)
+}
+
+// decrementURLSessions drops one session from url's count; the caller must hold the lock.
+func (ss *SessionStore) decrementURLSessions(url string) {
+	ss.urlSessions[url]--
+	if ss.urlSessions[url] <= 0 {
+		delete(ss.urlSessions, url)
+	}
+}
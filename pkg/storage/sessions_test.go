@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestSessionStore_IdleTimeoutSplitsSessions(t *testing.T) {
+	store := NewSessionStore(30 * time.Minute)
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	store.Record(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: base})
+	store.Record(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/b", Timestamp: base.Add(10 * time.Minute)})
+	store.Record(&models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: base.Add(2 * time.Hour)})
+
+	sessions := store.GetVisitorSessions("visitor1")
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+	}
+
+	if sessions[0].PageViews != 2 {
+		t.Errorf("Expected 2 page views in first session, got %d", sessions[0].PageViews)
+	}
+
+	if sessions[0].Duration != 10*time.Minute {
+		t.Errorf("Expected first session duration 10m, got %v", sessions[0].Duration)
+	}
+
+	if count := store.GetURLSessionCount("https://example.com/a"); count != 2 {
+		t.Errorf("Expected 2 sessions for /a, got %d", count)
+	}
+
+	if count := store.GetURLSessionCount("https://example.com/b"); count != 1 {
+		t.Errorf("Expected 1 session for /b, got %d", count)
+	}
+}
+
+func TestSessionStore_ExplicitSessionID(t *testing.T) {
+	store := NewSessionStore(30 * time.Minute)
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	store.Record(&models.NavigationEvent{VisitorID: "visitor1", SessionID: "s1", URL: "https://example.com/a", Timestamp: base})
+	store.Record(&models.NavigationEvent{VisitorID: "visitor1", SessionID: "s1", URL: "https://example.com/b", Timestamp: base.Add(time.Minute)})
+	store.Record(&models.NavigationEvent{VisitorID: "visitor1", SessionID: "s2", URL: "https://example.com/c", Timestamp: base.Add(2 * time.Minute)})
+
+	sessions := store.GetVisitorSessions("visitor1")
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+	}
+
+	if sessions[0].SessionID != "s1" || sessions[1].SessionID != "s2" {
+		t.Errorf("Expected sessions s1 and s2, got %s and %s", sessions[0].SessionID, sessions[1].SessionID)
+	}
+
+	total, avgLength, pagesPerSession := store.Summary()
+	if total != 2 {
+		t.Errorf("Expected 2 total sessions, got %d", total)
+	}
+
+	if avgLength != 30*time.Second {
+		t.Errorf("Expected average session length 30s, got %v", avgLength)
+	}
+
+	if pagesPerSession != 1.5 {
+		t.Errorf("Expected 1.5 pages per session, got %.2f", pagesPerSession)
+	}
+}
+
+func TestNavigationTracker_GetSystemStats(t *testing.T) {
+	tracker := NewNavigationTracker()
+
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a"},
+		{VisitorID: "visitor1", URL: "https://example.com/b"},
+		{VisitorID: "visitor2", URL: "https://example.com/a"},
+	}
+
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	stats := tracker.GetSystemStats()
+	if stats.TotalUniqueURLs != 2 {
+		t.Errorf("Expected 2 unique URLs, got %d", stats.TotalUniqueURLs)
+	}
+
+	if stats.TotalPageViews != 3 {
+		t.Errorf("Expected 3 page views, got %d", stats.TotalPageViews)
+	}
+
+	if stats.TotalSessions != 2 {
+		t.Errorf("Expected 2 sessions, got %d", stats.TotalSessions)
+	}
+}
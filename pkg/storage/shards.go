@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// shardCount is the number of independently locked partitions of the tracker.
+const shardCount = 64
+
+// urlShard owns the statistics of the URLs that hash to it, along with that
+// subset's category aggregates and expiry index, so ingesting events for URLs
+// in different shards never contends on a lock.
+//
+// When more than one lock is needed they are taken in this order: URL shards
+// by index, then visitor shards by index, then nt.mutex.
+type urlShard struct {
+	mutex      sync.RWMutex
+	urls       map[string]*urlStats
+	categories map[string]*categoryStats
+	expiries   expiryIndex
+	frozen     map[string]bool
+	// sorted lists every URL in the shard, hot or cold, in order.
+	sorted []string
+	tier   *shardTier
+	// bytes is the approximate size of the shard's URLs in memory, updated
+	// under the write lock and read without it.
+	bytes atomic.Int64
+}
+
+// visitorShard indexes, for the visitors that hash to it, the URLs each has
+// viewed, their latest custom properties and their language.
+type visitorShard struct {
+	mutex      sync.RWMutex
+	urls       map[string]map[string]bool
+	properties map[string]map[string]string
+	languages  map[string]*visitorLanguage
+}
+
+func newURLShard() *urlShard {
+	return &urlShard{
+		urls:       make(map[string]*urlStats),
+		categories: make(map[string]*categoryStats),
+		frozen:     make(map[string]bool),
+	}
+}
+
+func newVisitorShard() *visitorShard {
+	return &visitorShard{
+		urls:       make(map[string]map[string]bool),
+		properties: make(map[string]map[string]string),
+		languages:  make(map[string]*visitorLanguage),
+	}
+}
+
+func shardIndex(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % shardCount)
+}
+
+func (nt *NavigationTracker) urlShard(url string) *urlShard {
+	return nt.shards[shardIndex(url)]
+}
+
+func (nt *NavigationTracker) visitorShard(visitorID string) *visitorShard {
+	return nt.visitorShards[shardIndex(visitorID)]
+}
+
+// lockAll write-locks every shard in lock order.
+func (nt *NavigationTracker) lockAll() {
+	for _, shard := range nt.shards {
+		shard.mutex.Lock()
+	}
+	for _, shard := range nt.visitorShards {
+		shard.mutex.Lock()
+	}
+}
+
+func (nt *NavigationTracker) unlockAll() {
+	for _, shard := range nt.visitorShards {
+		shard.mutex.Unlock()
+	}
+	for _, shard := range nt.shards {
+		shard.mutex.Unlock()
+	}
+}
+
+// rlock acquires the shard's read lock, charging the wait to trace if set.
+func (s *urlShard) rlock(trace *QueryTrace) {
+	if trace == nil {
+		s.mutex.RLock()
+		return
+	}
+
+	start := time.Now()
+	s.mutex.RLock()
+	trace.LockWait += time.Since(start)
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestNavigationTracker_ShardedConcurrentIngest(t *testing.T) {
+	tracker := NewNavigationTracker()
+
+	const workers = 16
+	const urlsPerWorker = 50
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < urlsPerWorker; i++ {
+				event := &models.NavigationEvent{
+					VisitorID: "shared-visitor",
+					URL:       fmt.Sprintf("https://example.com/w%d/p%d", w, i),
+				}
+				if err := tracker.RecordEvent(event); err != nil {
+					t.Errorf("Failed to record event: %v", err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	stats := tracker.GetSystemStats()
+	if stats.TotalUniqueURLs != workers*urlsPerWorker {
+		t.Errorf("Expected %d URLs, got %d", workers*urlsPerWorker, stats.TotalUniqueURLs)
+	}
+
+	profile, ok := tracker.GetVisitorProfile("shared-visitor")
+	if !ok || len(profile.URLs) != workers*urlsPerWorker {
+		t.Fatalf("Expected the visitor indexed on every URL")
+	}
+
+	categories := tracker.GetCategoryStats()
+	if len(categories) != 1 || categories[0].DistinctVisitors != 1 {
+		t.Errorf("Expected one visitor counted once across shards, got %+v", categories)
+	}
+
+	used := 0
+	for _, shard := range tracker.shards {
+		if len(shard.urls) > 0 {
+			used++
+		}
+	}
+	if used < shardCount/2 {
+		t.Errorf("Expected URLs spread across shards, only %d of %d used", used, shardCount)
+	}
+}
+
+// BenchmarkRecordEvent_DistinctURLs ingests in parallel across many URLs, so
+// goroutines mostly land on different shards.
+func BenchmarkRecordEvent_DistinctURLs(b *testing.B) {
+	tracker := NewNavigationTracker()
+	urls := make([]string, 1024)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://example.com/page/%d", i)
+	}
+
+	var counter atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			n := counter.Add(1)
+			tracker.RecordEvent(&models.NavigationEvent{
+				VisitorID: fmt.Sprintf("visitor%d", n%4096),
+				URL:       urls[n%int64(len(urls))],
+			})
+		}
+	})
+}
+
+// BenchmarkRecordEvent_SingleURL is the fully contended baseline: every
+// goroutine writes to the same shard, as every write did before sharding.
+func BenchmarkRecordEvent_SingleURL(b *testing.B) {
+	tracker := NewNavigationTracker()
+
+	var counter atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			n := counter.Add(1)
+			tracker.RecordEvent(&models.NavigationEvent{
+				VisitorID: fmt.Sprintf("visitor%d", n%4096),
+				URL:       "https://example.com/page",
+			})
+		}
+	})
+}
@@ -0,0 +1,749 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/version"
+)
+
+const snapshotVersion = 1
+
+// trackerSnapshot is the on-disk form of the tracker's in-memory state.
+type trackerSnapshot struct {
+	Version        int                          `json:"version"`
+	Build          models.BuildInfo             `json:"build"`
+	CreatedAt      time.Time                    `json:"created_at"`
+	Sequence       uint64                       `json:"sequence"`
+	TotalPageViews int64                        `json:"total_page_views"`
+	URLs           map[string]urlSnapshot       `json:"urls"`
+	Categories     map[string]categorySnapshot  `json:"categories"`
+	Sessions       sessionStoreSnapshot         `json:"sessions"`
+	Cohorts        cohortStoreSnapshot          `json:"cohorts"`
+	Actives        map[int64]activeDaySnapshot  `json:"actives,omitempty"`
+	Funnels        funnelStoreSnapshot          `json:"funnels"`
+	Patterns       []*models.URLPattern         `json:"patterns,omitempty"`
+	Frozen         []string                     `json:"frozen,omitempty"`
+	Deletions      []*models.DeletionAuditEntry `json:"deletions,omitempty"`
+	// VisitorProperties holds each visitor's latest custom properties.
+	VisitorProperties map[string]map[string]string `json:"visitor_properties,omitempty"`
+	// VisitorLanguages holds the language each visitor is counted in across
+	// URLs.
+	VisitorLanguages map[string]visitorLanguageSnapshot `json:"visitor_languages,omitempty"`
+	// EventTypes counts the events other than page views per URL and type.
+	EventTypes map[string]map[string]eventTypeSnapshot `json:"event_types,omitempty"`
+	// PropertySchemas holds the registered property schemas, and
+	// PropertyIndex their indexed properties' counts per URL, event type,
+	// property and value.
+	PropertySchemas []models.PropertySchema                                       `json:"property_schemas,omitempty"`
+	PropertyIndex   map[string]map[string]map[string]map[string]eventTypeSnapshot `json:"property_index,omitempty"`
+}
+
+type urlSnapshot struct {
+	PageViews        int64                        `json:"page_views"`
+	Category         string                       `json:"category"`
+	Visitors         map[string]visitorSnapshot   `json:"visitors"`
+	Referrers        map[string]referrerSnapshot  `json:"referrers"`
+	Browsers         map[string]int64             `json:"browsers"`
+	OperatingSystems map[string]int64             `json:"operating_systems"`
+	DeviceTypes      map[string]int64             `json:"device_types"`
+	Countries        map[string]countrySnapshot   `json:"countries"`
+	Languages        map[string]countrySnapshot   `json:"languages,omitempty"`
+	Dimensions       map[string]dimensionSnapshot `json:"dimensions,omitempty"`
+	Overflow         []byte                       `json:"overflow,omitempty"`
+	TTL              time.Duration                `json:"ttl,omitempty"`
+}
+
+type visitorSnapshot struct {
+	Sequence  uint64    `json:"sequence"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	PageViews int64     `json:"page_views"`
+}
+
+type referrerSnapshot struct {
+	Visitors  []string  `json:"visitors"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+type countrySnapshot struct {
+	Visitors  int   `json:"visitors"`
+	PageViews int64 `json:"page_views"`
+}
+
+type visitorLanguageSnapshot struct {
+	Language  string `json:"language"`
+	PageViews int64  `json:"page_views"`
+}
+
+type categorySnapshot struct {
+	Visitors  []string `json:"visitors"`
+	PageViews int64    `json:"page_views"`
+	URLs      int      `json:"urls"`
+}
+
+type sessionStoreSnapshot struct {
+	Sessions       map[string][]sessionSnapshot `json:"sessions"`
+	URLSessions    map[string]int               `json:"url_sessions"`
+	TotalSessions  int                          `json:"total_sessions"`
+	TotalPageViews int64                        `json:"total_page_views"`
+	TotalDuration  time.Duration                `json:"total_duration"`
+}
+
+type sessionSnapshot struct {
+	ID        string    `json:"id"`
+	Explicit  bool      `json:"explicit"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	PageViews int       `json:"page_views"`
+	URLs      []string  `json:"urls"`
+}
+
+type cohortStoreSnapshot struct {
+	Visitors map[string]cohortVisitorSnapshot `json:"visitors"`
+	Cohorts  map[int64]cohortSnapshot         `json:"cohorts"`
+}
+
+type cohortVisitorSnapshot struct {
+	FirstWeek   int64 `json:"first_week"`
+	ActiveWeeks []int `json:"active_weeks"`
+}
+
+type cohortSnapshot struct {
+	Size     int         `json:"size"`
+	Returned map[int]int `json:"returned"`
+}
+
+// activeDaySnapshot lists a day's visitors and each URL's visitors with their
+// page views. URLs is only read, from snapshots written before page views
+// were kept, and each visitor listed in it is counted as one page view.
+type activeDaySnapshot struct {
+	Visitors []string                    `json:"visitors"`
+	URLs     map[string][]string         `json:"urls,omitempty"`
+	URLViews map[string]map[string]int64 `json:"url_views,omitempty"`
+}
+
+type eventTypeSnapshot struct {
+	Count    int64     `json:"count"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+type funnelStoreSnapshot struct {
+	Funnels []*models.Funnel `json:"funnels"`
+	NextID  int              `json:"next_id"`
+}
+
+// SaveSnapshot writes the tracker's state to path as JSON. Ingestion is
+// blocked only while the state is copied; the file is written afterwards and
+// atomically replaces any previous snapshot.
+func (nt *NavigationTracker) SaveSnapshot(path string) error {
+	snap := nt.snapshot()
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadSnapshot replaces the tracker's state with the snapshot at path. A
+// missing file is reported as an error satisfying errors.Is(err, os.ErrNotExist).
+func (nt *NavigationTracker) LoadSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var snap trackerSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+	if running := version.Info(); snap.Build.Version != "" && (snap.Build.Version != running.Version || snap.Build.Commit != running.Commit) {
+		log.Printf("Snapshot %s was written by %s (commit %s); running %s (commit %s)",
+			path, snap.Build.Version, snap.Build.Commit, running.Version, running.Commit)
+	}
+
+	nt.restore(&snap)
+	return nil
+}
+
+// Purge discards everything the tracker has recorded, including sessions,
+// cohorts and funnel definitions, as if it had just been created.
+func (nt *NavigationTracker) Purge() {
+	nt.restore(&trackerSnapshot{})
+}
+
+func (nt *NavigationTracker) snapshot() *trackerSnapshot {
+	for _, shard := range nt.shards {
+		shard.mutex.RLock()
+		defer shard.mutex.RUnlock()
+	}
+
+	snap := &trackerSnapshot{
+		Version:        snapshotVersion,
+		Build:          version.Info(),
+		CreatedAt:      time.Now().UTC(),
+		Sequence:       nt.sequence.Load(),
+		TotalPageViews: nt.totalPageViews.Load(),
+		URLs:           make(map[string]urlSnapshot),
+		Categories:     make(map[string]categorySnapshot),
+		Sessions:       nt.sessions.snapshot(),
+		Cohorts:        nt.cohorts.snapshot(),
+		Actives:        nt.actives.snapshot(),
+		EventTypes:     nt.events.snapshot(),
+		Funnels:        nt.funnels.snapshot(),
+		Patterns:       nt.patterns.definitions(),
+	}
+	snap.PropertySchemas, snap.PropertyIndex = nt.schemas.snapshot()
+
+	for _, shard := range nt.shards {
+		for url, stats := range shard.urls {
+			snap.URLs[url] = stats.snapshot()
+		}
+		if shard.tier != nil {
+			for url, entry := range shard.tier.entries {
+				us, err := shard.tier.read(entry)
+				if err != nil {
+					log.Printf("Failed to read cold URL %s: %v", url, err)
+					continue
+				}
+				snap.URLs[url] = us
+			}
+		}
+		for url := range shard.frozen {
+			snap.Frozen = append(snap.Frozen, url)
+		}
+	}
+
+	snap.Deletions = nt.DeletionLog()
+
+	for _, shard := range nt.visitorShards {
+		shard.mutex.RLock()
+		for visitorID, properties := range shard.properties {
+			if snap.VisitorProperties == nil {
+				snap.VisitorProperties = make(map[string]map[string]string)
+			}
+			copied := make(map[string]string, len(properties))
+			for key, value := range properties {
+				copied[key] = value
+			}
+			snap.VisitorProperties[visitorID] = copied
+		}
+		for visitorID, vl := range shard.languages {
+			if snap.VisitorLanguages == nil {
+				snap.VisitorLanguages = make(map[string]visitorLanguageSnapshot)
+			}
+			snap.VisitorLanguages[visitorID] = visitorLanguageSnapshot{Language: vl.language, PageViews: vl.pageViews}
+		}
+		shard.mutex.RUnlock()
+	}
+
+	merged := make(map[string]*categoryStats)
+	for _, shard := range nt.shards {
+		shard.mergeCategories(merged)
+	}
+	for category, stats := range merged {
+		snap.Categories[category] = categorySnapshot{
+			Visitors:  setKeys(stats.visitors),
+			PageViews: stats.pageViews,
+			URLs:      stats.urls,
+		}
+	}
+
+	return snap
+}
+
+func (s *urlStats) snapshot() urlSnapshot {
+	us := urlSnapshot{
+		PageViews:        s.pageViews,
+		Category:         s.category,
+		Visitors:         make(map[string]visitorSnapshot, len(s.visitors)),
+		Referrers:        make(map[string]referrerSnapshot, len(s.referrers)),
+		Browsers:         copyCounts(s.devices.browsers),
+		OperatingSystems: copyCounts(s.devices.operatingSystems),
+		DeviceTypes:      copyCounts(s.devices.deviceTypes),
+		Countries:        make(map[string]countrySnapshot, len(s.countries)),
+		Languages:        make(map[string]countrySnapshot, len(s.languages)),
+		TTL:              s.ttl,
+	}
+	for visitorID, record := range s.visitors {
+		us.Visitors[visitorID] = visitorSnapshot{
+			Sequence:  record.sequence,
+			FirstSeen: record.firstSeen,
+			LastSeen:  record.lastSeen,
+			PageViews: record.pageViews,
+		}
+	}
+	for domain, ref := range s.referrers {
+		us.Referrers[domain] = referrerSnapshot{
+			Visitors:  setKeys(ref.visitors),
+			FirstSeen: ref.firstSeen,
+			LastSeen:  ref.lastSeen,
+		}
+	}
+	for country, cs := range s.countries {
+		us.Countries[country] = countrySnapshot{Visitors: cs.visitors, PageViews: cs.pageViews}
+	}
+	for language, ls := range s.languages {
+		us.Languages[language] = countrySnapshot{Visitors: ls.visitors, PageViews: ls.pageViews}
+	}
+	if s.dimensions != nil {
+		us.Dimensions = make(map[string]dimensionSnapshot, len(s.dimensions))
+		for name, counter := range s.dimensions {
+			us.Dimensions[name] = counter.snapshot()
+		}
+	}
+	if s.overflow != nil {
+		us.Overflow = append([]byte(nil), s.overflow.registers...)
+	}
+	return us
+}
+
+// stats rebuilds the in-memory statistics; the expiry index is not linked.
+func (us urlSnapshot) stats() *urlStats {
+	stats := &urlStats{
+		visitors:  make(map[string]*visitorRecord, len(us.Visitors)),
+		pageViews: us.PageViews,
+		category:  us.Category,
+		referrers: make(map[string]*referrerStats, len(us.Referrers)),
+		devices: &deviceStats{
+			browsers:         copyCounts(us.Browsers),
+			operatingSystems: copyCounts(us.OperatingSystems),
+			deviceTypes:      copyCounts(us.DeviceTypes),
+		},
+		countries: make(map[string]*countryStats, len(us.Countries)),
+		languages: make(map[string]*languageStats, len(us.Languages)),
+		ttl:       us.TTL,
+	}
+	for visitorID, v := range us.Visitors {
+		stats.visitors[visitorID] = &visitorRecord{
+			sequence:  v.Sequence,
+			firstSeen: v.FirstSeen,
+			lastSeen:  v.LastSeen,
+			pageViews: v.PageViews,
+		}
+	}
+	for domain, ref := range us.Referrers {
+		stats.referrers[domain] = &referrerStats{
+			visitors:  keySet(ref.Visitors),
+			firstSeen: ref.FirstSeen,
+			lastSeen:  ref.LastSeen,
+		}
+	}
+	for country, cs := range us.Countries {
+		stats.countries[country] = &countryStats{visitors: cs.Visitors, pageViews: cs.PageViews}
+	}
+	for language, ls := range us.Languages {
+		stats.languages[language] = &languageStats{visitors: ls.Visitors, pageViews: ls.PageViews}
+	}
+	if us.Dimensions != nil {
+		stats.dimensions = make(map[string]distinctCounter, len(us.Dimensions))
+		for name, ds := range us.Dimensions {
+			stats.dimensions[name] = ds.counter()
+		}
+	}
+	if us.Overflow != nil {
+		stats.overflow = &hyperLogLog{registers: append([]byte(nil), us.Overflow...)}
+	}
+	// Until an event is recorded, order loaded URLs for eviction by their last visit
+	if lastSeen := stats.lastSeen(); !lastSeen.IsZero() {
+		stats.lastRecorded = lastSeen.UnixNano()
+	}
+	return stats
+}
+
+func (nt *NavigationTracker) restore(snap *trackerSnapshot) {
+	var shards [shardCount]*urlShard
+	for i := range shards {
+		shards[i] = newURLShard()
+	}
+
+	for url, us := range snap.URLs {
+		shards[shardIndex(url)].urls[url] = us.stats()
+	}
+	for _, url := range snap.Frozen {
+		shards[shardIndex(url)].frozen[url] = true
+	}
+
+	// Category aggregates are summed across shards when read, so the
+	// restored totals can all live in the first shard.
+	for category, cs := range snap.Categories {
+		shards[0].categories[category] = &categoryStats{
+			visitors:  keySet(cs.Visitors),
+			pageViews: cs.PageViews,
+			urls:      cs.URLs,
+		}
+	}
+
+	nt.lockAll()
+	defer nt.unlockAll()
+
+	for i, shard := range shards {
+		nt.shards[i].urls = shard.urls
+		nt.shards[i].categories = shard.categories
+		nt.shards[i].frozen = shard.frozen
+		nt.shards[i].bytes.Store(0)
+		for url, stats := range shard.urls {
+			nt.shards[i].resize(url, stats)
+		}
+		if tier := nt.shards[i].tier; tier != nil {
+			if err := tier.reset(); err != nil {
+				log.Printf("Failed to reset cold file %s: %v", tier.path, err)
+			}
+		}
+		nt.shards[i].rebuildIndex()
+	}
+	nt.rebuildVisitorIndex()
+	for visitorID, properties := range snap.VisitorProperties {
+		shard := nt.visitorShard(visitorID)
+		if _, indexed := shard.urls[visitorID]; indexed {
+			shard.properties[visitorID] = properties
+		}
+	}
+	for visitorID, vl := range snap.VisitorLanguages {
+		shard := nt.visitorShard(visitorID)
+		if _, indexed := shard.urls[visitorID]; indexed {
+			shard.languages[visitorID] = &visitorLanguage{language: vl.Language, pageViews: vl.PageViews}
+		}
+	}
+	nt.totalPageViews.Store(snap.TotalPageViews)
+	nt.sequence.Store(snap.Sequence)
+	nt.rebuildExpiries()
+	nt.rebuildTopURLs()
+	nt.rebuildDistribution()
+	nt.rebuildPatterns(snap.Patterns)
+	nt.sessions.restore(snap.Sessions)
+	nt.cohorts.restore(snap.Cohorts)
+	nt.actives.restore(snap.Actives)
+	nt.events.restore(snap.EventTypes)
+	nt.schemas.restore(snap.PropertySchemas, snap.PropertyIndex)
+	// Visitors online are not snapshotted; they are back within a window
+	nt.realtime.reset()
+	nt.funnels.restore(snap.Funnels)
+	// Purging keeps the audit log; loading a snapshot that has one replaces it
+	if snap.Deletions != nil {
+		nt.deletions.mutex.Lock()
+		nt.deletions.entries = snap.Deletions
+		nt.deletions.mutex.Unlock()
+	}
+}
+
+func (ss *SessionStore) snapshot() sessionStoreSnapshot {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	snap := sessionStoreSnapshot{
+		Sessions:       make(map[string][]sessionSnapshot, len(ss.sessions)),
+		URLSessions:    copyCounts(ss.urlSessions),
+		TotalSessions:  ss.totalSessions,
+		TotalPageViews: ss.totalPageViews,
+		TotalDuration:  ss.totalDuration,
+	}
+
+	for visitorID, visitorSessions := range ss.sessions {
+		sessions := make([]sessionSnapshot, len(visitorSessions))
+		for i, s := range visitorSessions {
+			sessions[i] = sessionSnapshot{
+				ID:        s.id,
+				Explicit:  s.explicit,
+				StartTime: s.startTime,
+				EndTime:   s.endTime,
+				PageViews: s.pageViews,
+				URLs:      append([]string(nil), s.urls...),
+			}
+		}
+		snap.Sessions[visitorID] = sessions
+	}
+
+	return snap
+}
+
+func (ss *SessionStore) restore(snap sessionStoreSnapshot) {
+	sessions := make(map[string][]*session, len(snap.Sessions))
+	for visitorID, visitorSessions := range snap.Sessions {
+		restored := make([]*session, len(visitorSessions))
+		for i, s := range visitorSessions {
+			restored[i] = &session{
+				id:        s.ID,
+				explicit:  s.Explicit,
+				startTime: s.StartTime,
+				endTime:   s.EndTime,
+				pageViews: s.PageViews,
+				urls:      s.URLs,
+				seenURLs:  keySet(s.URLs),
+			}
+		}
+		sessions[visitorID] = restored
+	}
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	ss.sessions = sessions
+	ss.urlSessions = copyCounts(snap.URLSessions)
+	ss.totalSessions = snap.TotalSessions
+	ss.totalPageViews = snap.TotalPageViews
+	ss.totalDuration = snap.TotalDuration
+}
+
+func (cs *CohortStore) snapshot() cohortStoreSnapshot {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+
+	snap := cohortStoreSnapshot{
+		Visitors: make(map[string]cohortVisitorSnapshot, len(cs.visitors)),
+		Cohorts:  make(map[int64]cohortSnapshot, len(cs.cohorts)),
+	}
+
+	for visitorID, visitor := range cs.visitors {
+		weeks := make([]int, 0, len(visitor.activeWeeks))
+		for offset := range visitor.activeWeeks {
+			weeks = append(weeks, offset)
+		}
+		snap.Visitors[visitorID] = cohortVisitorSnapshot{FirstWeek: visitor.firstWeek, ActiveWeeks: weeks}
+	}
+
+	for start, c := range cs.cohorts {
+		returned := make(map[int]int, len(c.returned))
+		for offset, count := range c.returned {
+			returned[offset] = count
+		}
+		snap.Cohorts[start] = cohortSnapshot{Size: c.size, Returned: returned}
+	}
+
+	return snap
+}
+
+func (cs *CohortStore) restore(snap cohortStoreSnapshot) {
+	visitors := make(map[string]*cohortVisitor, len(snap.Visitors))
+	for visitorID, v := range snap.Visitors {
+		weeks := make(map[int]bool, len(v.ActiveWeeks))
+		for _, offset := range v.ActiveWeeks {
+			weeks[offset] = true
+		}
+		visitors[visitorID] = &cohortVisitor{firstWeek: v.FirstWeek, activeWeeks: weeks}
+	}
+
+	cohorts := make(map[int64]*cohort, len(snap.Cohorts))
+	for start, c := range snap.Cohorts {
+		returned := c.Returned
+		if returned == nil {
+			returned = make(map[int]int)
+		}
+		cohorts[start] = &cohort{size: c.Size, returned: returned}
+	}
+
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	cs.visitors = visitors
+	cs.cohorts = cohorts
+}
+
+func (s *eventTypeStore) snapshot() map[string]map[string]eventTypeSnapshot {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	snap := make(map[string]map[string]eventTypeSnapshot, len(s.urls))
+	for url, types := range s.urls {
+		counts := make(map[string]eventTypeSnapshot, len(types))
+		for name, count := range types {
+			counts[name] = eventTypeSnapshot{Count: count.count, LastSeen: count.lastSeen}
+		}
+		snap[url] = counts
+	}
+	return snap
+}
+
+func (s *eventTypeStore) restore(snap map[string]map[string]eventTypeSnapshot) {
+	urls := make(map[string]map[string]*eventTypeCount, len(snap))
+	for url, counts := range snap {
+		types := make(map[string]*eventTypeCount, len(counts))
+		for name, count := range counts {
+			types[name] = &eventTypeCount{count: count.Count, lastSeen: count.LastSeen}
+		}
+		urls[url] = types
+	}
+
+	s.mutex.Lock()
+	s.urls = urls
+	s.mutex.Unlock()
+}
+
+func (ps *PropertySchemaStore) snapshot() ([]models.PropertySchema, map[string]map[string]map[string]map[string]eventTypeSnapshot) {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	schemas := make([]models.PropertySchema, 0, len(ps.schemas))
+	for _, schema := range ps.schemas {
+		schemas = append(schemas, *schema)
+	}
+	index := make(map[string]map[string]map[string]map[string]eventTypeSnapshot, len(ps.index))
+	for url, types := range ps.index {
+		typesSnap := make(map[string]map[string]map[string]eventTypeSnapshot, len(types))
+		for eventType, properties := range types {
+			propertiesSnap := make(map[string]map[string]eventTypeSnapshot, len(properties))
+			for property, values := range properties {
+				valuesSnap := make(map[string]eventTypeSnapshot, len(values))
+				for value, count := range values {
+					valuesSnap[value] = eventTypeSnapshot{Count: count.count, LastSeen: count.lastSeen}
+				}
+				propertiesSnap[property] = valuesSnap
+			}
+			typesSnap[eventType] = propertiesSnap
+		}
+		index[url] = typesSnap
+	}
+	return schemas, index
+}
+
+func (ps *PropertySchemaStore) restore(schemas []models.PropertySchema, snap map[string]map[string]map[string]map[string]eventTypeSnapshot) {
+	restored := make(map[string]*models.PropertySchema, len(schemas))
+	for _, schema := range schemas {
+		schema := schema
+		restored[schema.EventType] = &schema
+	}
+	index := make(map[string]map[string]map[string]map[string]*eventTypeCount, len(snap))
+	for url, typesSnap := range snap {
+		types := make(map[string]map[string]map[string]*eventTypeCount, len(typesSnap))
+		for eventType, propertiesSnap := range typesSnap {
+			properties := make(map[string]map[string]*eventTypeCount, len(propertiesSnap))
+			for property, valuesSnap := range propertiesSnap {
+				values := make(map[string]*eventTypeCount, len(valuesSnap))
+				for value, count := range valuesSnap {
+					values[value] = &eventTypeCount{count: count.Count, lastSeen: count.LastSeen}
+				}
+				properties[property] = values
+			}
+			types[eventType] = properties
+		}
+		index[url] = types
+	}
+
+	ps.mutex.Lock()
+	ps.schemas, ps.index = restored, index
+	ps.mutex.Unlock()
+}
+
+func (as *ActiveStore) snapshot() map[int64]activeDaySnapshot {
+	as.mutex.RLock()
+	defer as.mutex.RUnlock()
+
+	snap := make(map[int64]activeDaySnapshot, len(as.days))
+	for start, d := range as.days {
+		urls := make(map[string]map[string]int64, len(d.urls))
+		for url, visitors := range d.urls {
+			views := make(map[string]int64, len(visitors))
+			for visitorID, count := range visitors {
+				views[visitorID] = count
+			}
+			urls[url] = views
+		}
+		snap[start] = activeDaySnapshot{Visitors: setKeys(d.visitors), URLViews: urls}
+	}
+	return snap
+}
+
+func (as *ActiveStore) restore(snap map[int64]activeDaySnapshot) {
+	days := make(map[int64]*activeDay, len(snap))
+	var latest int64
+	for start, ds := range snap {
+		d := &activeDay{visitors: keySet(ds.Visitors), urls: make(map[string]map[string]int64, len(ds.URLs)+len(ds.URLViews))}
+		for url, visitors := range ds.URLs {
+			views := make(map[string]int64, len(visitors))
+			for _, visitorID := range visitors {
+				views[visitorID] = 1
+			}
+			d.urls[url] = views
+		}
+		for url, views := range ds.URLViews {
+			d.urls[url] = views
+		}
+		days[start] = d
+		if start > latest {
+			latest = start
+		}
+	}
+
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+
+	as.days = days
+	as.latest = latest
+}
+
+func (fs *FunnelStore) snapshot() funnelStoreSnapshot {
+	fs.mutex.RLock()
+	defer fs.mutex.RUnlock()
+
+	snap := funnelStoreSnapshot{
+		Funnels: make([]*models.Funnel, 0, len(fs.funnels)),
+		NextID:  fs.nextID,
+	}
+	for _, funnel := range fs.funnels {
+		snap.Funnels = append(snap.Funnels, funnel)
+	}
+
+	return snap
+}
+
+func (fs *FunnelStore) restore(snap funnelStoreSnapshot) {
+	funnels := make(map[string]*models.Funnel, len(snap.Funnels))
+	for _, funnel := range snap.Funnels {
+		funnels[funnel.ID] = funnel
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	fs.funnels = funnels
+	fs.nextID = snap.NextID
+}
+
+func copyCounts[V int | int64](counts map[string]V) map[string]V {
+	copied := make(map[string]V, len(counts))
+	for key, count := range counts {
+		copied[key] = count
+	}
+	return copied
+}
+
+func setKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func keySet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/version"
+)
+
+func TestNavigationTracker_SnapshotRoundTrip(t *testing.T) {
+	tracker := NewNavigationTracker()
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/blog/a", Referrer: "https://google.com/", Country: "KE", Timestamp: base},
+		{VisitorID: "visitor1", URL: "https://example.com/checkout", Timestamp: base.Add(time.Minute)},
+		{VisitorID: "visitor2", URL: "https://example.com/blog/a", Timestamp: base.Add(8 * 24 * time.Hour)},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+	funnel, err := tracker.Funnels().Create("checkout", []string{"https://example.com/blog/a", "https://example.com/checkout"})
+	if err != nil {
+		t.Fatalf("Failed to create funnel: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := tracker.SaveSnapshot(path); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+
+	restored := NewNavigationTracker()
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+
+	stats := restored.GetVisitorStats("https://example.com/blog/a")
+	if stats.DistinctVisitors != 2 || stats.TotalPageViews != 2 {
+		t.Errorf("Expected 2 visitors and 2 page views, got %+v", stats)
+	}
+
+	if got, want := restored.GetSystemStats().TotalSessions, tracker.GetSystemStats().TotalSessions; got != want {
+		t.Errorf("Expected %d sessions after restore, got %d", want, got)
+	}
+
+	if referrers := restored.GetTopReferrers("https://example.com/blog/a", 10); len(referrers) != 1 || referrers[0].Domain != "google.com" {
+		t.Errorf("Expected google.com referrer after restore, got %+v", referrers)
+	}
+
+	if countries := restored.GetGeoBreakdown("https://example.com/blog/a"); len(countries) != 2 {
+		t.Errorf("Expected 2 countries after restore, got %+v", countries)
+	}
+
+	if categories := restored.GetCategoryStats(); len(categories) != 2 {
+		t.Errorf("Expected 2 categories after restore, got %d", len(categories))
+	}
+
+	restoredFunnel, ok := restored.Funnels().Get(funnel.ID)
+	if !ok {
+		t.Fatalf("Expected funnel %s after restore", funnel.ID)
+	}
+	if report := restored.FunnelReport(restoredFunnel); report.Steps[1].Visitors != 1 {
+		t.Errorf("Expected 1 visitor to reach checkout after restore, got %d", report.Steps[1].Visitors)
+	}
+
+	rows := restored.Cohorts().Matrix(2, base.Add(8*24*time.Hour))
+	if rows[0].Visitors != 1 || rows[1].Visitors != 1 {
+		t.Errorf("Unexpected cohorts after restore: %+v, %+v", rows[0], rows[1])
+	}
+
+	// Restored trackers keep recording on top of the snapshot
+	if err := restored.RecordEvent(&models.NavigationEvent{VisitorID: "visitor3", URL: "https://example.com/blog/a"}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+	if got := restored.GetDistinctVisitors("https://example.com/blog/a"); got != 3 {
+		t.Errorf("Expected 3 visitors after new event, got %d", got)
+	}
+	if next, _ := restored.Funnels().Create("next", []string{"https://example.com/a", "https://example.com/b"}); next.ID == funnel.ID {
+		t.Errorf("Expected new funnel ID after restore, got duplicate %s", next.ID)
+	}
+}
+
+func TestNavigationTracker_Purge(t *testing.T) {
+	tracker := NewNavigationTracker()
+	tracker.RecordEvent(&models.NavigationEvent{VisitorID: "v1", URL: "https://example.com/"})
+
+	tracker.Purge()
+
+	stats := tracker.GetSystemStats()
+	if stats.TotalPageViews != 0 || stats.TotalUniqueURLs != 0 || stats.TotalSessions != 0 {
+		t.Errorf("Expected an empty tracker after purge, got %+v", stats)
+	}
+	if _, ok := tracker.GetVisitorProfile("v1"); ok {
+		t.Errorf("Expected the visitor index to be purged")
+	}
+}
+
+func TestNavigationTracker_LoadSnapshotMissing(t *testing.T) {
+	tracker := NewNavigationTracker()
+
+	err := tracker.LoadSnapshot(filepath.Join(t.TempDir(), "missing.json"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected not-exist error, got %v", err)
+	}
+}
+
+func TestNavigationTracker_SnapshotRecordsBuild(t *testing.T) {
+	defer func(v string) { version.Version = v }(version.Version)
+	version.Version = "v1.4.0"
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := NewNavigationTracker().SaveSnapshot(path); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+	var snap trackerSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatalf("Failed to decode snapshot: %v", err)
+	}
+	if snap.Build.Version != "v1.4.0" {
+		t.Errorf("Expected snapshot to record version v1.4.0, got %q", snap.Build.Version)
+	}
+}
@@ -0,0 +1,237 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"nav-tracker/pkg/models"
+)
+
+// DriverName is the database/sql driver the backend opens. It is registered
+// by the pure-Go modernc.org/sqlite driver, which is linked into builds made
+// with the sqlite build tag.
+const DriverName = "sqlite"
+
+// Backend implements storage.CounterBackend on SQLite. Every event is
+// appended to the events table, and the url_visitors and url_stats tables
+// hold the per-URL aggregates the counters are read from.
+type Backend struct {
+	db     *sql.DB
+	config models.StorageConfig
+
+	insertEvent   *sql.Stmt
+	upsertVisitor *sql.Stmt
+	upsertURL     *sql.Stmt
+	countVisitors *sql.Stmt
+	pageViews     *sql.Stmt
+}
+
+func NewBackend(config models.StorageConfig) *Backend {
+	return &Backend{config: config}
+}
+
+func (b *Backend) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), b.config.Timeout)
+}
+
+// Open connects to the database, applies pending migrations and prepares the
+// backend's statements. It must succeed before the backend records events.
+func (b *Backend) Open() error {
+	if !driverRegistered() {
+		return fmt.Errorf("sqlite: no %q database driver is linked into this build (build with -tags sqlite)", DriverName)
+	}
+
+	db, err := sql.Open(DriverName, b.config.DSN)
+	if err != nil {
+		return fmt.Errorf("sqlite: open: %w", err)
+	}
+	// SQLite allows a single writer; one connection avoids SQLITE_BUSY errors
+	// between the backend's own transactions.
+	db.SetMaxOpenConns(1)
+
+	ctx, cancel := b.context()
+	defer cancel()
+
+	if _, err := migrate(ctx, db); err != nil {
+		db.Close()
+		return fmt.Errorf("sqlite: %w", err)
+	}
+
+	statements := []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&b.insertEvent, `INSERT INTO events (event_id, visitor_id, url, session_id, referrer, timestamp) VALUES (?, ?, ?, ?, ?, ?)`},
+		{&b.upsertVisitor, `INSERT INTO url_visitors (url, visitor_id, page_views, first_seen, last_seen) VALUES (?, ?, 1, ?, ?)
+			ON CONFLICT (url, visitor_id) DO UPDATE SET
+				page_views = page_views + 1,
+				first_seen = min(first_seen, excluded.first_seen),
+				last_seen = max(last_seen, excluded.last_seen)`},
+		{&b.upsertURL, `INSERT INTO url_stats (url, page_views) VALUES (?, 1)
+			ON CONFLICT (url) DO UPDATE SET page_views = page_views + 1`},
+		{&b.countVisitors, `SELECT COUNT(*) FROM url_visitors WHERE url = ?`},
+		{&b.pageViews, `SELECT page_views FROM url_stats WHERE url = ?`},
+	}
+	for _, s := range statements {
+		if *s.stmt, err = db.PrepareContext(ctx, s.query); err != nil {
+			db.Close()
+			return fmt.Errorf("sqlite: prepare: %w", err)
+		}
+	}
+
+	b.db = db
+	return nil
+}
+
+func driverRegistered() bool {
+	for _, name := range sql.Drivers() {
+		if name == DriverName {
+			return true
+		}
+	}
+	return false
+}
+
+// Ping checks that the database is reachable.
+func (b *Backend) Ping() error {
+	if b.db == nil {
+		return errors.New("sqlite: not open")
+	}
+
+	ctx, cancel := b.context()
+	defer cancel()
+
+	return b.db.PingContext(ctx)
+}
+
+func (b *Backend) Record(event *models.NavigationEvent) error {
+	ctx, cancel := b.context()
+	defer cancel()
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	timestamp := event.Timestamp.UnixNano()
+	if _, err := tx.StmtContext(ctx, b.insertEvent).ExecContext(ctx,
+		nullable(event.EventID), event.VisitorID, event.URL, nullable(event.SessionID), nullable(event.Referrer), timestamp); err != nil {
+		return err
+	}
+	if _, err := tx.StmtContext(ctx, b.upsertVisitor).ExecContext(ctx, event.URL, event.VisitorID, timestamp, timestamp); err != nil {
+		return err
+	}
+	if _, err := tx.StmtContext(ctx, b.upsertURL).ExecContext(ctx, event.URL); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func nullable(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func (b *Backend) DistinctVisitors(url string) (int, error) {
+	ctx, cancel := b.context()
+	defer cancel()
+
+	var count int
+	err := b.countVisitors.QueryRowContext(ctx, url).Scan(&count)
+	return count, err
+}
+
+func (b *Backend) PageViews(url string) (int64, error) {
+	ctx, cancel := b.context()
+	defer cancel()
+
+	var count int64
+	err := b.pageViews.QueryRowContext(ctx, url).Scan(&count)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+
+	return count, err
+}
+
+// ForgetVisitor deletes visitorID's events and url_visitors rows and takes
+// their page views off url_stats, dropping URLs left without page views.
+func (b *Backend) ForgetVisitor(visitorID string) error {
+	return b.exec(
+		statement{`UPDATE url_stats SET page_views = page_views -
+			(SELECT page_views FROM url_visitors WHERE url_visitors.url = url_stats.url AND visitor_id = ?)
+			WHERE url IN (SELECT url FROM url_visitors WHERE visitor_id = ?)`, []interface{}{visitorID, visitorID}},
+		statement{`DELETE FROM url_stats WHERE page_views <= 0`, nil},
+		statement{`DELETE FROM url_visitors WHERE visitor_id = ?`, []interface{}{visitorID}},
+		statement{`DELETE FROM events WHERE visitor_id = ?`, []interface{}{visitorID}},
+	)
+}
+
+// DeleteURL deletes url's events and aggregates.
+func (b *Backend) DeleteURL(url string) error {
+	return b.exec(
+		statement{`DELETE FROM url_stats WHERE url = ?`, []interface{}{url}},
+		statement{`DELETE FROM url_visitors WHERE url = ?`, []interface{}{url}},
+		statement{`DELETE FROM events WHERE url = ?`, []interface{}{url}},
+	)
+}
+
+// MergeURL moves source's events to target and folds its aggregates into
+// target's, counting visitors seen on both once.
+func (b *Backend) MergeURL(source, target string) error {
+	if source == target {
+		return nil
+	}
+	return b.exec(
+		statement{`UPDATE events SET url = ? WHERE url = ?`, []interface{}{target, source}},
+		statement{`INSERT INTO url_visitors (url, visitor_id, page_views, first_seen, last_seen)
+			SELECT ?, visitor_id, page_views, first_seen, last_seen FROM url_visitors WHERE url = ?
+			ON CONFLICT (url, visitor_id) DO UPDATE SET
+				page_views = page_views + excluded.page_views,
+				first_seen = min(first_seen, excluded.first_seen),
+				last_seen = max(last_seen, excluded.last_seen)`, []interface{}{target, source}},
+		statement{`DELETE FROM url_visitors WHERE url = ?`, []interface{}{source}},
+		statement{`INSERT INTO url_stats (url, page_views)
+			SELECT ?, page_views FROM url_stats WHERE url = ?
+			ON CONFLICT (url) DO UPDATE SET page_views = page_views + excluded.page_views`, []interface{}{target, source}},
+		statement{`DELETE FROM url_stats WHERE url = ?`, []interface{}{source}},
+	)
+}
+
+type statement struct {
+	query string
+	args  []interface{}
+}
+
+// exec runs statements in one transaction.
+func (b *Backend) exec(statements ...statement) error {
+	ctx, cancel := b.context()
+	defer cancel()
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, s := range statements {
+		if _, err := tx.ExecContext(ctx, s.query, s.args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Close releases the prepared statements and the database.
+func (b *Backend) Close() error {
+	if b.db == nil {
+		return nil
+	}
+	for _, stmt := range []*sql.Stmt{b.insertEvent, b.upsertVisitor, b.upsertURL, b.countVisitors, b.pageViews} {
+		stmt.Close()
+	}
+	return b.db.Close()
+}
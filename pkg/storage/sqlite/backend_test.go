@@ -0,0 +1,167 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/storage"
+)
+
+func testConfig(t *testing.T) models.StorageConfig {
+	config := models.DefaultConfiguration().Storage
+	config.Backend = models.StorageBackendSQLite
+	config.DSN = filepath.Join(t.TempDir(), "nav-tracker.db")
+	return config
+}
+
+func newTestBackend(t *testing.T) *Backend {
+	if !driverRegistered() {
+		t.Skip("no sqlite driver linked; run with -tags sqlite")
+	}
+
+	backend := NewBackend(testConfig(t))
+	if err := backend.Open(); err != nil {
+		t.Fatalf("Failed to open backend: %v", err)
+	}
+	t.Cleanup(func() { _ = backend.Close() })
+
+	return backend
+}
+
+func TestBackend_OpenWithoutDriver(t *testing.T) {
+	if driverRegistered() {
+		t.Skip("sqlite driver is linked")
+	}
+
+	err := NewBackend(testConfig(t)).Open()
+	if err == nil || !strings.Contains(err.Error(), "-tags sqlite") {
+		t.Errorf("Expected missing driver error, got %v", err)
+	}
+}
+
+func TestBackend_SharedAcrossTrackers(t *testing.T) {
+	backend := newTestBackend(t)
+	url := "https://example.com/page"
+
+	replicaA := storage.NewNavigationTracker()
+	replicaA.SetCounterBackend(backend)
+	replicaB := storage.NewNavigationTracker()
+	replicaB.SetCounterBackend(backend)
+
+	for i, tracker := range []*storage.NavigationTracker{replicaA, replicaB, replicaB} {
+		visitorID := "visitor1"
+		if i == 1 {
+			visitorID = "visitor2"
+		}
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: visitorID, URL: url, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	for _, tracker := range []*storage.NavigationTracker{replicaA, replicaB} {
+		stats := tracker.GetVisitorStats(url)
+		if stats.DistinctVisitors != 2 || stats.TotalPageViews != 3 {
+			t.Errorf("Expected 2 visitors and 3 page views, got %d and %d", stats.DistinctVisitors, stats.TotalPageViews)
+		}
+	}
+
+	var events int
+	if err := backend.db.QueryRow(`SELECT COUNT(*) FROM events WHERE url = ?`, url).Scan(&events); err != nil || events != 3 {
+		t.Errorf("Expected 3 stored events, got %d (%v)", events, err)
+	}
+}
+
+func TestBackend_UnknownURL(t *testing.T) {
+	backend := newTestBackend(t)
+
+	visitors, err := backend.DistinctVisitors("https://example.com/missing")
+	if err != nil || visitors != 0 {
+		t.Errorf("Expected 0 visitors, got %d (%v)", visitors, err)
+	}
+	pageViews, err := backend.PageViews("https://example.com/missing")
+	if err != nil || pageViews != 0 {
+		t.Errorf("Expected 0 page views, got %d (%v)", pageViews, err)
+	}
+}
+
+func TestBackend_ReopenKeepsData(t *testing.T) {
+	backend := newTestBackend(t)
+	url := "https://example.com/page"
+
+	if err := backend.Record(&models.NavigationEvent{VisitorID: "visitor1", URL: url, Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+	backend.Close()
+
+	// Migrations already applied are skipped on the second open
+	reopened := NewBackend(backend.config)
+	if err := reopened.Open(); err != nil {
+		t.Fatalf("Failed to reopen backend: %v", err)
+	}
+	defer reopened.Close()
+
+	var version int
+	if err := reopened.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil || version != len(migrations) {
+		t.Errorf("Expected schema version %d, got %d (%v)", len(migrations), version, err)
+	}
+	if visitors, _ := reopened.DistinctVisitors(url); visitors != 1 {
+		t.Errorf("Expected 1 visitor after reopening, got %d", visitors)
+	}
+}
+
+func TestBackend_DeletionsReachTheDatabase(t *testing.T) {
+	backend := newTestBackend(t)
+	tracker := storage.NewNavigationTracker()
+	tracker.SetCounterBackend(backend)
+	a, b := "https://example.com/a", "https://example.com/b"
+
+	for _, event := range []models.NavigationEvent{
+		{VisitorID: "alice", URL: a},
+		{VisitorID: "alice", URL: a},
+		{VisitorID: "alice", URL: b},
+		{VisitorID: "bob", URL: a},
+		{VisitorID: "carol", URL: b},
+	} {
+		event.Timestamp = time.Now()
+		if err := tracker.RecordEvent(&event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	if entry := tracker.DeleteVisitor("alice", "test"); entry.BackendError != "" {
+		t.Fatalf("Expected the backend to forget the visitor, got %s", entry.BackendError)
+	}
+	var events int
+	if err := backend.db.QueryRow(`SELECT COUNT(*) FROM events WHERE visitor_id = 'alice'`).Scan(&events); err != nil || events != 0 {
+		t.Errorf("Expected no events left for the visitor, got %d (%v)", events, err)
+	}
+	if visitors, _ := backend.DistinctVisitors(a); visitors != 1 {
+		t.Errorf("Expected 1 visitor on a, got %d", visitors)
+	}
+	if pageViews, _ := backend.PageViews(a); pageViews != 1 {
+		t.Errorf("Expected 1 page view on a, got %d", pageViews)
+	}
+
+	if err := backend.MergeURL(a, b); err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+	if visitors, _ := backend.DistinctVisitors(b); visitors != 2 {
+		t.Errorf("Expected 2 visitors on b after the merge, got %d", visitors)
+	}
+	if pageViews, _ := backend.PageViews(a); pageViews != 0 {
+		t.Errorf("Expected a to be gone after the merge, got %d page views", pageViews)
+	}
+
+	if entry := tracker.DeleteURL(b, "test"); entry.BackendError != "" {
+		t.Fatalf("Expected the backend to delete the URL, got %s", entry.BackendError)
+	}
+	if err := backend.db.QueryRow(`SELECT COUNT(*) FROM events`).Scan(&events); err != nil || events != 0 {
+		t.Errorf("Expected no events left, got %d (%v)", events, err)
+	}
+	if visitors, _ := backend.DistinctVisitors(b); visitors != 0 {
+		t.Errorf("Expected no visitors on b, got %d", visitors)
+	}
+}
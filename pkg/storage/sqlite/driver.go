@@ -0,0 +1,6 @@
+//go:build sqlite
+
+package sqlite
+
+// Registers the pure-Go SQLite driver under DriverName.
+import _ "modernc.org/sqlite"
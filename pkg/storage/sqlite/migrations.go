@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrations are applied in order on startup; each runs once, in its own
+// transaction, and is recorded in schema_migrations by its index plus one.
+// Append new migrations rather than editing applied ones.
+var migrations = []string{
+	`CREATE TABLE events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id TEXT,
+		visitor_id TEXT NOT NULL,
+		url TEXT NOT NULL,
+		session_id TEXT,
+		referrer TEXT,
+		timestamp INTEGER NOT NULL
+	);
+	CREATE INDEX events_url_timestamp ON events (url, timestamp);
+	CREATE INDEX events_visitor ON events (visitor_id);
+	CREATE TABLE url_visitors (
+		url TEXT NOT NULL,
+		visitor_id TEXT NOT NULL,
+		page_views INTEGER NOT NULL,
+		first_seen INTEGER NOT NULL,
+		last_seen INTEGER NOT NULL,
+		PRIMARY KEY (url, visitor_id)
+	);
+	CREATE TABLE url_stats (
+		url TEXT PRIMARY KEY,
+		page_views INTEGER NOT NULL
+	);`,
+}
+
+// migrate brings the schema up to date, returning the resulting version.
+func migrate(ctx context.Context, db *sql.DB) (int, error) {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	)`); err != nil {
+		return 0, fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var version int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	if version > len(migrations) {
+		return version, fmt.Errorf("schema version %d is newer than this build supports (%d)", version, len(migrations))
+	}
+
+	for ; version < len(migrations); version++ {
+		if err := apply(ctx, db, version+1, migrations[version]); err != nil {
+			return version, fmt.Errorf("apply migration %d: %w", version+1, err)
+		}
+	}
+	return version, nil
+}
+
+func apply(ctx context.Context, db *sql.DB, version int, statements string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, statements); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, strftime('%s', 'now'))`, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,401 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// tieringPolicy decides when hot URLs are demoted to their shard's cold file.
+type tieringPolicy struct {
+	idleAfter time.Duration
+	// shardCache is the number of promoted URLs each shard keeps in memory
+	// while they stay idle.
+	shardCache int
+}
+
+// shardTier is a shard's cold file and the index of the URLs stored in it.
+// It is guarded by the shard lock.
+type shardTier struct {
+	path       string
+	file       *os.File
+	size       int64
+	garbage    int64
+	entries    map[string]*coldEntry
+	demotions  int64
+	promotions int64
+}
+
+// coldEntry locates a demoted URL's statistics in the cold file and keeps
+// the summary needed to count and rank it without loading it. The URL keeps
+// its place in the expiry index while it is cold.
+type coldEntry struct {
+	offset    int64
+	length    int64
+	visitors  int
+	pageViews int64
+	lastSeen  time.Time
+	expiry    *expiryEntry
+}
+
+// EnableTiering creates a cold file per shard under config.Dir, replacing any
+// left by a previous run. It must be called before the tracker serves requests.
+func (nt *NavigationTracker) EnableTiering(config models.TieringConfig) error {
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return fmt.Errorf("create tiering dir: %w", err)
+	}
+
+	nt.lockAll()
+	defer nt.unlockAll()
+
+	for i, shard := range nt.shards {
+		path := filepath.Join(config.Dir, fmt.Sprintf("shard-%02d.cold", i))
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+		if err != nil {
+			nt.closeTiers()
+			return fmt.Errorf("open cold file: %w", err)
+		}
+		shard.tier = &shardTier{path: path, file: file, entries: make(map[string]*coldEntry)}
+	}
+
+	nt.tiering = &tieringPolicy{
+		idleAfter:  config.IdleAfter,
+		shardCache: (config.HotCacheSize + shardCount - 1) / shardCount,
+	}
+	return nil
+}
+
+// CloseTiering removes the cold files. Cold URLs are no longer visible
+// afterwards, so call it only after the final snapshot has been saved.
+func (nt *NavigationTracker) CloseTiering() error {
+	nt.lockAll()
+	defer nt.unlockAll()
+
+	return nt.closeTiers()
+}
+
+// closeTiers closes and removes every cold file; the caller must hold every shard lock.
+func (nt *NavigationTracker) closeTiers() error {
+	var firstErr error
+	for _, shard := range nt.shards {
+		if shard.tier == nil {
+			continue
+		}
+		if err := shard.tier.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err := os.Remove(shard.tier.path); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		shard.tier = nil
+	}
+	return firstErr
+}
+
+// lookup returns url's statistics, decoding a cold URL's without promoting
+// it; the caller must hold the shard lock.
+func (s *urlShard) lookup(url string) (*urlStats, bool) {
+	if stats, exists := s.urls[url]; exists {
+		return stats, true
+	}
+	if s.tier == nil {
+		return nil, false
+	}
+	entry, exists := s.tier.entries[url]
+	if !exists {
+		return nil, false
+	}
+
+	us, err := s.tier.read(entry)
+	if err != nil {
+		log.Printf("Failed to read cold URL %s: %v", url, err)
+		return nil, false
+	}
+	return us.stats(), true
+}
+
+// hot returns url's statistics, first moving them back into memory if the
+// URL is cold; the caller must hold the shard write lock.
+func (s *urlShard) hot(url string) (*urlStats, bool) {
+	if stats, exists := s.urls[url]; exists {
+		return stats, true
+	}
+	if s.tier == nil {
+		return nil, false
+	}
+	entry, exists := s.tier.entries[url]
+	if !exists {
+		return nil, false
+	}
+
+	us, err := s.tier.read(entry)
+	if err != nil {
+		log.Printf("Failed to promote cold URL %s: %v", url, err)
+		return nil, false
+	}
+
+	stats := us.stats()
+	stats.expiry = entry.expiry
+	stats.promotedAt = time.Now()
+	// The URL stayed counted while cold, under the counts it had then
+	stats.slot = slotOf(stats)
+	s.urls[url] = stats
+	s.resize(url, stats)
+
+	delete(s.tier.entries, url)
+	s.tier.garbage += entry.length
+	s.tier.promotions++
+	return stats, true
+}
+
+// promote moves url into memory if it is cold, ahead of a read that may
+// benefit from it staying there.
+func (nt *NavigationTracker) promote(url string) {
+	shard := nt.urlShard(url)
+	shard.mutex.RLock()
+	cold := shard.tier != nil && shard.tier.entries[url] != nil
+	shard.mutex.RUnlock()
+
+	if cold {
+		shard.mutex.Lock()
+		shard.hot(url)
+		shard.mutex.Unlock()
+	}
+}
+
+// demote writes stats to the cold file and drops them from memory; the
+// caller must hold the shard write lock.
+func (s *urlShard) demote(url string, stats *urlStats) error {
+	data, err := json.Marshal(stats.snapshot())
+	if err != nil {
+		return err
+	}
+	if _, err := s.tier.file.WriteAt(data, s.tier.size); err != nil {
+		return err
+	}
+
+	s.tier.entries[url] = &coldEntry{
+		offset:    s.tier.size,
+		length:    int64(len(data)),
+		visitors:  stats.distinctVisitors(),
+		pageViews: stats.pageViews,
+		lastSeen:  stats.lastSeen(),
+		expiry:    stats.expiry,
+	}
+	s.tier.size += int64(len(data))
+	s.tier.demotions++
+	s.untrack(stats)
+	delete(s.urls, url)
+	return nil
+}
+
+func (t *shardTier) read(entry *coldEntry) (urlSnapshot, error) {
+	data := make([]byte, entry.length)
+	if _, err := t.file.ReadAt(data, entry.offset); err != nil {
+		return urlSnapshot{}, err
+	}
+
+	var us urlSnapshot
+	if err := json.Unmarshal(data, &us); err != nil {
+		return urlSnapshot{}, err
+	}
+	return us, nil
+}
+
+// reset empties the cold file.
+func (t *shardTier) reset() error {
+	t.entries = make(map[string]*coldEntry)
+	t.size = 0
+	t.garbage = 0
+	return t.file.Truncate(0)
+}
+
+// compact rewrites the cold file without the space left by promoted URLs.
+func (t *shardTier) compact() error {
+	tmpPath := t.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	offsets := make(map[*coldEntry]int64, len(t.entries))
+	var size int64
+	for _, entry := range t.entries {
+		data := make([]byte, entry.length)
+		if _, err := t.file.ReadAt(data, entry.offset); err == nil {
+			_, err = tmp.WriteAt(data, size)
+		}
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+			return err
+		}
+		offsets[entry] = size
+		size += entry.length
+	}
+
+	if err := os.Rename(tmpPath, t.path); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	t.file.Close()
+	t.file = tmp
+	t.size = size
+	t.garbage = 0
+	for entry, offset := range offsets {
+		entry.offset = offset
+	}
+	return nil
+}
+
+// DemoteIdleURLs moves URLs that have had no events since IdleAfter before
+// now to the cold tier. A URL promoted by a read stays in memory until it has
+// been idle that long again, unless its shard holds more promoted URLs than
+// its share of HotCacheSize, in which case the least recently promoted go
+// first. Cold files that are mostly unused space are compacted. It returns
+// the number of URLs demoted.
+func (nt *NavigationTracker) DemoteIdleURLs(now time.Time) int {
+	if nt.tiering == nil {
+		return 0
+	}
+
+	cutoff := now.Add(-nt.tiering.idleAfter)
+	demoted := 0
+	for _, shard := range nt.shards {
+		demoted += nt.demoteShard(shard, cutoff)
+	}
+	return demoted
+}
+
+func (nt *NavigationTracker) demoteShard(shard *urlShard, cutoff time.Time) int {
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	if shard.tier == nil {
+		return 0
+	}
+
+	type cached struct {
+		url        string
+		stats      *urlStats
+		promotedAt time.Time
+	}
+	var idle []string
+	var cache []cached
+	for url, stats := range shard.urls {
+		if !stats.lastSeen().Before(cutoff) {
+			continue
+		}
+		if stats.promotedAt.Before(cutoff) {
+			idle = append(idle, url)
+		} else {
+			cache = append(cache, cached{url, stats, stats.promotedAt})
+		}
+	}
+
+	if len(cache) > nt.tiering.shardCache {
+		sort.Slice(cache, func(i, j int) bool { return cache[i].promotedAt.Before(cache[j].promotedAt) })
+		for _, c := range cache[:len(cache)-nt.tiering.shardCache] {
+			idle = append(idle, c.url)
+		}
+	}
+
+	demoted := 0
+	for _, url := range idle {
+		if err := shard.demote(url, shard.urls[url]); err != nil {
+			log.Printf("Failed to demote URL %s: %v", url, err)
+			break
+		}
+		demoted++
+	}
+
+	if shard.tier.garbage > 0 && shard.tier.garbage*2 > shard.tier.size {
+		if err := shard.tier.compact(); err != nil {
+			log.Printf("Failed to compact cold file %s: %v", shard.tier.path, err)
+		}
+	}
+
+	return demoted
+}
+
+// tieringStats reports hot and cold URL counts across all shards.
+func (nt *NavigationTracker) tieringStats() *models.TieringStats {
+	stats := &models.TieringStats{}
+	for _, shard := range nt.shards {
+		shard.mutex.RLock()
+		stats.HotURLs += len(shard.urls)
+		if shard.tier != nil {
+			stats.ColdURLs += len(shard.tier.entries)
+			stats.ColdBytes += shard.tier.size
+			stats.Demotions += shard.tier.demotions
+			stats.Promotions += shard.tier.promotions
+		}
+		shard.mutex.RUnlock()
+	}
+	return stats
+}
+
+// eachURL calls fn with every URL in the shard, hot or cold; the caller
+// must hold the shard lock.
+func (s *urlShard) eachURL(fn func(url string)) {
+	for url := range s.urls {
+		fn(url)
+	}
+	if s.tier != nil {
+		for url := range s.tier.entries {
+			fn(url)
+		}
+	}
+}
+
+// coldEntry returns url's cold tier entry, or nil if it is not cold; the
+// caller must hold the shard lock.
+func (s *urlShard) coldEntry(url string) *coldEntry {
+	if s.tier == nil {
+		return nil
+	}
+	return s.tier.entries[url]
+}
+
+// coldURLs returns the number of URLs in the shard's cold tier; the caller
+// must hold the shard lock.
+func (s *urlShard) coldURLs() int {
+	if s.tier == nil {
+		return 0
+	}
+	return len(s.tier.entries)
+}
+
+// compactTiers rewrites every cold file holding space left by promoted URLs,
+// returning the files rewritten and the bytes freed. It stops at the first
+// file that fails to compact.
+func (nt *NavigationTracker) compactTiers() (int, int64, error) {
+	files := 0
+	var reclaimed int64
+	for _, shard := range nt.shards {
+		shard.mutex.Lock()
+		if shard.tier == nil || shard.tier.garbage == 0 {
+			shard.mutex.Unlock()
+			continue
+		}
+		path, size := shard.tier.path, shard.tier.size
+		err := shard.tier.compact()
+		if err == nil {
+			files++
+			reclaimed += size - shard.tier.size
+		}
+		shard.mutex.Unlock()
+		if err != nil {
+			return files, reclaimed, fmt.Errorf("compact %s: %w", path, err)
+		}
+	}
+	return files, reclaimed, nil
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func newTieringTracker(t *testing.T, hotCache int) *NavigationTracker {
+	tracker := NewNavigationTracker()
+	config := models.DefaultConfiguration().Tiering
+	config.Enabled = true
+	config.Dir = t.TempDir()
+	config.IdleAfter = time.Hour
+	config.HotCacheSize = hotCache
+	if err := tracker.EnableTiering(config); err != nil {
+		t.Fatalf("Failed to enable tiering: %v", err)
+	}
+	t.Cleanup(func() { tracker.CloseTiering() })
+	return tracker
+}
+
+func TestNavigationTracker_DemoteIdleURLs(t *testing.T) {
+	tracker := newTieringTracker(t, 1000)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/old", Timestamp: base},
+		{VisitorID: "visitor2", URL: "https://example.com/old", Timestamp: base},
+		{VisitorID: "visitor1", URL: "https://example.com/new", Timestamp: base.Add(2 * time.Hour)},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	if demoted := tracker.DemoteIdleURLs(base.Add(2 * time.Hour)); demoted != 1 {
+		t.Fatalf("Expected 1 URL demoted, got %d", demoted)
+	}
+
+	stats := tracker.GetSystemStats()
+	if stats.TotalUniqueURLs != 2 {
+		t.Errorf("Expected 2 unique URLs, got %d", stats.TotalUniqueURLs)
+	}
+	if stats.Tiering == nil || stats.Tiering.HotURLs != 1 || stats.Tiering.ColdURLs != 1 {
+		t.Fatalf("Expected 1 hot and 1 cold URL, got %+v", stats.Tiering)
+	}
+
+	// Listings read cold URLs without promoting them
+	top, total := tracker.GetTopURLs(ListOptions{Limit: 10})
+	if total != 2 || top[0].URL != "https://example.com/old" || top[0].DistinctVisitors != 2 {
+		t.Errorf("Expected cold URL to lead the ranking, got %d URLs", total)
+	}
+	if profile, _ := tracker.GetVisitorProfile("visitor1"); profile == nil || len(profile.URLs) != 2 {
+		t.Errorf("Expected visitor profile to include the cold URL, got %+v", profile)
+	}
+	if stats := tracker.GetSystemStats(); stats.Tiering.Promotions != 0 {
+		t.Errorf("Expected no promotions, got %d", stats.Tiering.Promotions)
+	}
+
+	// A point lookup promotes the URL
+	if visitors := tracker.GetDistinctVisitors("https://example.com/old"); visitors != 2 {
+		t.Errorf("Expected 2 visitors for promoted URL, got %d", visitors)
+	}
+	stats = tracker.GetSystemStats()
+	if stats.Tiering.HotURLs != 2 || stats.Tiering.ColdURLs != 0 || stats.Tiering.Promotions != 1 {
+		t.Errorf("Expected URL to be promoted, got %+v", stats.Tiering)
+	}
+
+	// A promoted URL stays hot until it has been idle for IdleAfter again
+	if demoted := tracker.DemoteIdleURLs(base.Add(2 * time.Hour)); demoted != 0 {
+		t.Errorf("Expected promoted URL to stay hot, got %d demoted", demoted)
+	}
+}
+
+func TestNavigationTracker_RecordEventPromotesColdURL(t *testing.T) {
+	tracker := newTieringTracker(t, 1000)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	url := "https://example.com/page"
+
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url, Timestamp: base}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+	tracker.DemoteIdleURLs(base.Add(2 * time.Hour))
+
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor2", URL: url, Timestamp: base.Add(3 * time.Hour)}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	stats := tracker.GetVisitorStats(url)
+	if stats.DistinctVisitors != 2 || stats.TotalPageViews != 2 {
+		t.Errorf("Expected 2 visitors and 2 views, got %d and %d", stats.DistinctVisitors, stats.TotalPageViews)
+	}
+}
+
+func TestNavigationTracker_TieringHotCacheSize(t *testing.T) {
+	tracker := newTieringTracker(t, 1)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Both URLs land in the same shard, whose share of the cache is one URL
+	var urls []string
+	for i := 0; len(urls) < 2; i++ {
+		url := fmt.Sprintf("https://example.com/page%d", i)
+		if len(urls) == 0 || shardIndex(url) == shardIndex(urls[0]) {
+			urls = append(urls, url)
+		}
+	}
+	for _, url := range urls {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url, Timestamp: base}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+	tracker.DemoteIdleURLs(base.Add(2 * time.Hour))
+
+	for _, url := range urls {
+		tracker.GetDistinctVisitors(url)
+		time.Sleep(time.Millisecond)
+	}
+
+	if demoted := tracker.DemoteIdleURLs(base.Add(2 * time.Hour)); demoted != 1 {
+		t.Fatalf("Expected the oldest promoted URL to be demoted, got %d", demoted)
+	}
+	shard := tracker.urlShard(urls[0])
+	if shard.coldEntry(urls[0]) == nil || shard.coldEntry(urls[1]) != nil {
+		t.Error("Expected the least recently promoted URL to be cold")
+	}
+}
+
+func TestNavigationTracker_TieringCompaction(t *testing.T) {
+	tracker := newTieringTracker(t, 1000)
+	url := "https://example.com/page"
+
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url, Timestamp: time.Now().Add(-2 * time.Hour)}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	// Each promotion leaves its copy behind; the third demotion finds the
+	// file two thirds garbage and compacts it
+	for i := 0; i < 2; i++ {
+		tracker.DemoteIdleURLs(time.Now().Add(2 * time.Hour))
+		tracker.GetDistinctVisitors(url)
+	}
+	tracker.DemoteIdleURLs(time.Now().Add(2 * time.Hour))
+
+	shard := tracker.urlShard(url)
+	entry := shard.coldEntry(url)
+	if entry == nil {
+		t.Fatal("Expected URL to be cold")
+	}
+	if entry.offset != 0 || shard.tier.size != entry.length {
+		t.Errorf("Expected cold file to be compacted to one entry, got offset %d and size %d", entry.offset, shard.tier.size)
+	}
+	if tracker.GetDistinctVisitors(url) != 1 {
+		t.Error("Expected compacted URL to be readable")
+	}
+}
+
+func TestNavigationTracker_TieringSnapshot(t *testing.T) {
+	tracker := newTieringTracker(t, 1000)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	url := "https://example.com/page"
+
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url, Timestamp: base}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+	tracker.DemoteIdleURLs(base.Add(2 * time.Hour))
+
+	path := t.TempDir() + "/snapshot.json"
+	if err := tracker.SaveSnapshot(path); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+
+	restored := newTieringTracker(t, 1000)
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if restored.GetDistinctVisitors(url) != 1 {
+		t.Error("Expected cold URL to be included in the snapshot")
+	}
+}
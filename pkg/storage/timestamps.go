@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// ErrTimestampOutOfWindow is returned for an event whose timestamp is outside
+// the acceptance window under the reject policy.
+var ErrTimestampOutOfWindow = errors.New("event timestamp is outside the acceptance window")
+
+// timestampPolicy keeps client-supplied event timestamps within a window
+// around the server's clock, so that a device with a skewed clock cannot
+// count its events in days, cohorts and sessions far from when they
+// happened. Events without a timestamp are dated on arrival as before.
+type timestampPolicy struct {
+	maxPast    time.Duration
+	maxFuture  time.Duration
+	policy     string
+	serverTime bool
+
+	clamped    atomic.Int64
+	overridden atomic.Int64
+	rejected   atomic.Int64
+}
+
+func newTimestampPolicy(config models.TimestampConfig) *timestampPolicy {
+	if config.MaxPast <= 0 && config.MaxFuture <= 0 && !config.ServerTime {
+		return nil
+	}
+	return &timestampPolicy{
+		maxPast:    config.MaxPast,
+		maxFuture:  config.MaxFuture,
+		policy:     config.Policy,
+		serverTime: config.ServerTime,
+	}
+}
+
+// adjust returns the timestamp event is to be counted at, and what policy
+// decided it when that is not its own. It reports ErrTimestampOutOfWindow
+// instead under the reject policy.
+func (p *timestampPolicy) adjust(event *models.NavigationEvent, now time.Time) (time.Time, string, error) {
+	t := event.Timestamp
+	if p == nil || t.IsZero() {
+		return t, "", nil
+	}
+	if p.serverTime {
+		return now, models.TimestampServer, nil
+	}
+
+	edge := t
+	if p.maxPast > 0 && t.Before(now.Add(-p.maxPast)) {
+		edge = now.Add(-p.maxPast)
+	} else if p.maxFuture > 0 && t.After(now.Add(p.maxFuture)) {
+		edge = now.Add(p.maxFuture)
+	}
+	if edge.Equal(t) {
+		return t, "", nil
+	}
+
+	switch p.policy {
+	case models.TimestampReject:
+		return t, models.TimestampReject, fmt.Errorf("%w: %s is %v from server time",
+			ErrTimestampOutOfWindow, t.UTC().Format(time.RFC3339), t.Sub(now).Round(time.Second))
+	case models.TimestampServer:
+		return now, models.TimestampServer, nil
+	default:
+		return edge, models.TimestampClamp, nil
+	}
+}
+
+// apply sets event's timestamp as adjust decides and counts the outcome.
+func (p *timestampPolicy) apply(event *models.NavigationEvent, now time.Time) error {
+	t, decision, err := p.adjust(event, now)
+	switch decision {
+	case models.TimestampReject:
+		p.rejected.Add(1)
+	case models.TimestampClamp:
+		p.clamped.Add(1)
+	case models.TimestampServer:
+		p.overridden.Add(1)
+	}
+	if err != nil {
+		return err
+	}
+	event.Timestamp = t
+	return nil
+}
+
+func (p *timestampPolicy) stats() *models.TimestampStats {
+	return &models.TimestampStats{
+		MaxPast:    p.maxPast,
+		MaxFuture:  p.maxFuture,
+		Policy:     p.policy,
+		ServerTime: p.serverTime,
+		Clamped:    p.clamped.Load(),
+		Overridden: p.overridden.Load(),
+		Rejected:   p.rejected.Load(),
+	}
+}
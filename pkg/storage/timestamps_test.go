@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestTimestampPolicy_Adjust(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	window := models.TimestampConfig{MaxPast: 24 * time.Hour, MaxFuture: 5 * time.Minute}
+
+	tests := []struct {
+		name      string
+		policy    string
+		timestamp time.Time
+		expected  time.Time
+		decision  string
+	}{
+		{"missing", models.TimestampClamp, time.Time{}, time.Time{}, ""},
+		{"in window", models.TimestampClamp, now.Add(-time.Hour), now.Add(-time.Hour), ""},
+		{"clamped past", models.TimestampClamp, now.Add(-72 * time.Hour), now.Add(-24 * time.Hour), models.TimestampClamp},
+		{"clamped future", models.TimestampClamp, now.Add(time.Hour), now.Add(5 * time.Minute), models.TimestampClamp},
+		{"server time", models.TimestampServer, now.Add(time.Hour), now, models.TimestampServer},
+	}
+
+	for _, tt := range tests {
+		config := window
+		config.Policy = tt.policy
+		event := &models.NavigationEvent{Timestamp: tt.timestamp}
+		timestamp, decision, err := newTimestampPolicy(config).adjust(event, now)
+		if err != nil || !timestamp.Equal(tt.expected) || decision != tt.decision {
+			t.Errorf("%s: expected %v by %q, got %v by %q: %v", tt.name, tt.expected, tt.decision, timestamp, decision, err)
+		}
+	}
+
+	config := window
+	config.Policy = models.TimestampReject
+	event := &models.NavigationEvent{Timestamp: now.Add(-48 * time.Hour)}
+	if _, _, err := newTimestampPolicy(config).adjust(event, now); !errors.Is(err, ErrTimestampOutOfWindow) {
+		t.Errorf("Expected ErrTimestampOutOfWindow, got %v", err)
+	}
+
+	config = models.TimestampConfig{ServerTime: true, Policy: models.TimestampReject}
+	event = &models.NavigationEvent{Timestamp: now.Add(-time.Minute)}
+	if timestamp, _, err := newTimestampPolicy(config).adjust(event, now); err != nil || !timestamp.Equal(now) {
+		t.Errorf("Expected server time to replace every client timestamp, got %v: %v", timestamp, err)
+	}
+
+	if newTimestampPolicy(models.TimestampConfig{Policy: models.TimestampClamp}) != nil {
+		t.Error("Expected no policy without a window")
+	}
+}
+
+func TestNavigationTracker_Timestamps(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.Timestamps = models.TimestampConfig{MaxPast: 24 * time.Hour, MaxFuture: 5 * time.Minute, Policy: models.TimestampReject}
+	tracker := NewNavigationTrackerWithConfig(config)
+	url := "https://example.com/a"
+
+	old := &models.NavigationEvent{VisitorID: "visitor1", URL: url, Timestamp: time.Now().Add(-48 * time.Hour)}
+	if err := tracker.RecordEvent(old); !errors.Is(err, ErrTimestampOutOfWindow) {
+		t.Fatalf("Expected the event refused, got %v", err)
+	}
+	if _, err := tracker.DryRun(&models.NavigationEvent{VisitorID: "visitor1", URL: url, Timestamp: old.Timestamp}); !errors.Is(err, ErrTimestampOutOfWindow) {
+		t.Errorf("Expected the dry run refused, got %v", err)
+	}
+	if stats := tracker.GetVisitorStats(url); stats.TotalPageViews != 0 {
+		t.Errorf("Expected nothing counted, got %+v", stats)
+	}
+
+	config.Timestamps.Policy = models.TimestampClamp
+	tracker = NewNavigationTrackerWithConfig(config)
+	future := &models.NavigationEvent{VisitorID: "visitor1", URL: url, Timestamp: time.Now().Add(30 * 24 * time.Hour)}
+	if err := tracker.RecordEvent(future); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+	if latest := time.Now().Add(5 * time.Minute); future.Timestamp.After(latest) {
+		t.Errorf("Expected the timestamp clamped to %v, got %v", latest, future.Timestamp)
+	}
+	if stats := tracker.GetVisitorStats(url); stats.LastVisit.After(time.Now().Add(5 * time.Minute)) {
+		t.Errorf("Expected the visit counted at the clamped time, got %v", stats.LastVisit)
+	}
+
+	timestamps := tracker.GetSystemStats().Timestamps
+	if timestamps == nil || timestamps.Clamped != 1 || timestamps.Rejected != 0 || timestamps.Policy != models.TimestampClamp {
+		t.Errorf("Unexpected timestamp stats: %+v", timestamps)
+	}
+}
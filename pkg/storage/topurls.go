@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"container/heap"
+	"hash/fnv"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// countMinSketch estimates per-key counts in fixed memory. Estimates never
+// undercount; collisions can only inflate them.
+type countMinSketch struct {
+	width  uint64
+	counts [][]uint32
+}
+
+func newCountMinSketch(width, depth int) *countMinSketch {
+	counts := make([][]uint32, depth)
+	for i := range counts {
+		counts[i] = make([]uint32, width)
+	}
+	return &countMinSketch{width: uint64(width), counts: counts}
+}
+
+// add increments key by n and returns its new estimate.
+func (s *countMinSketch) add(key string, n uint32) uint32 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	sum := h.Sum64()
+	h1, h2 := sum&math.MaxUint32, sum>>32
+
+	estimate := uint32(math.MaxUint32)
+	for i, row := range s.counts {
+		idx := (h1 + uint64(i)*h2) % s.width
+		row[idx] += n
+		if row[idx] < estimate {
+			estimate = row[idx]
+		}
+	}
+	return estimate
+}
+
+// topURLs tracks the URLs with the most distinct visitors without sorting
+// every URL: a sketch estimates each URL's count and a bounded min-heap keeps
+// the current leaders.
+type topURLs struct {
+	size   int
+	sketch *countMinSketch
+	width  int
+	depth  int
+	heap   topHeap
+	index  map[string]*topEntry
+	mutex  sync.Mutex
+}
+
+type topEntry struct {
+	url      string
+	estimate uint32
+	index    int
+}
+
+// topHeap is a min-heap of entries ordered by estimate.
+type topHeap []*topEntry
+
+func (h topHeap) Len() int           { return len(h) }
+func (h topHeap) Less(i, j int) bool { return h[i].estimate < h[j].estimate }
+
+func (h topHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *topHeap) Push(x interface{}) {
+	entry := x.(*topEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *topHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return entry
+}
+
+// newTopURLs returns nil unless config selects sketch mode.
+func newTopURLs(config models.TopURLsConfig) *topURLs {
+	if config.Mode != models.TopURLsSketch {
+		return nil
+	}
+
+	t := &topURLs{size: config.Size, width: config.SketchWidth, depth: config.SketchDepth}
+	t.reset()
+	return t
+}
+
+func (t *topURLs) reset() {
+	t.sketch = newCountMinSketch(t.width, t.depth)
+	t.heap = nil
+	t.index = make(map[string]*topEntry)
+}
+
+// add counts n new visitors for url and updates the leaders.
+func (t *topURLs) add(url string, n int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	estimate := t.sketch.add(url, uint32(n))
+
+	if entry, exists := t.index[url]; exists {
+		entry.estimate = estimate
+		heap.Fix(&t.heap, entry.index)
+		return
+	}
+
+	if t.heap.Len() >= t.size {
+		if estimate <= t.heap[0].estimate {
+			return
+		}
+		evicted := heap.Pop(&t.heap).(*topEntry)
+		delete(t.index, evicted.url)
+	}
+
+	entry := &topEntry{url: url, estimate: estimate}
+	heap.Push(&t.heap, entry)
+	t.index[url] = entry
+}
+
+// remove drops url from the leaders. Its sketch counters are left in place.
+func (t *topURLs) remove(url string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if entry, exists := t.index[url]; exists {
+		heap.Remove(&t.heap, entry.index)
+		delete(t.index, url)
+	}
+}
+
+func (t *topURLs) urls() []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	urls := make([]string, 0, len(t.heap))
+	for _, entry := range t.heap {
+		urls = append(urls, entry.url)
+	}
+	return urls
+}
+
+// rebuildTopURLs re-seeds the sketch from exact visitor counts; the caller
+// must hold every shard lock.
+func (nt *NavigationTracker) rebuildTopURLs() {
+	if nt.topURLs == nil {
+		return
+	}
+
+	nt.topURLs.reset()
+	for _, shard := range nt.shards {
+		for url, stats := range shard.urls {
+			nt.topURLs.add(url, stats.distinctVisitors())
+		}
+	}
+}
+
+// GetTopURLs returns one page of URLs sorted by visitors, views or
+// last_visit, along with the number of URLs ranked. In sketch mode only the
+// tracked leaders are ranked, so the total is at most the configured size;
+// the counts returned are always exact.
+func (nt *NavigationTracker) GetTopURLs(opts ListOptions) ([]*models.VisitorStats, int) {
+	now := time.Now().UTC()
+
+	var result []*models.VisitorStats
+	collect := func(shard *urlShard, url string) {
+		if summary := shard.summary(url, now); summary != nil {
+			result = append(result, summary)
+		}
+	}
+
+	if nt.topURLs != nil {
+		for _, url := range nt.topURLs.urls() {
+			shard := nt.urlShard(url)
+			shard.mutex.RLock()
+			collect(shard, url)
+			shard.mutex.RUnlock()
+		}
+	} else {
+		for _, shard := range nt.shards {
+			shard.mutex.RLock()
+			shard.eachURL(func(url string) {
+				collect(shard, url)
+			})
+			shard.mutex.RUnlock()
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		switch opts.Sort {
+		case SortByViews:
+			if a.TotalPageViews != b.TotalPageViews {
+				return (a.TotalPageViews > b.TotalPageViews) != opts.Ascending
+			}
+		case SortByLastVisit:
+			if !a.LastVisit.Equal(b.LastVisit) {
+				return a.LastVisit.After(b.LastVisit) != opts.Ascending
+			}
+		default:
+			if a.DistinctVisitors != b.DistinctVisitors {
+				return (a.DistinctVisitors > b.DistinctVisitors) != opts.Ascending
+			}
+		}
+		return a.URL < b.URL
+	})
+
+	start, end := opts.page(len(result))
+	page := append([]*models.VisitorStats{}, result[start:end]...)
+	return page, len(result)
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func newSketchTracker(size int) *NavigationTracker {
+	config := models.DefaultConfiguration()
+	config.TopURLs.Mode = models.TopURLsSketch
+	config.TopURLs.Size = size
+	return NewNavigationTrackerWithConfig(config)
+}
+
+func recordVisitors(t *testing.T, tracker *NavigationTracker, url string, visitors int) {
+	t.Helper()
+	for i := 0; i < visitors; i++ {
+		event := &models.NavigationEvent{VisitorID: fmt.Sprintf("visitor%d", i), URL: url}
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+}
+
+func TestNavigationTracker_GetTopURLs_Exact(t *testing.T) {
+	tracker := NewNavigationTracker()
+	recordVisitors(t, tracker, "https://example.com/a", 3)
+	recordVisitors(t, tracker, "https://example.com/b", 5)
+	recordVisitors(t, tracker, "https://example.com/c", 1)
+
+	top, total := tracker.GetTopURLs(ListOptions{Limit: 2})
+	if len(top) != 2 || total != 3 {
+		t.Fatalf("Expected 2 of 3 URLs, got %d of %d", len(top), total)
+	}
+	if top[0].URL != "https://example.com/b" || top[1].URL != "https://example.com/a" {
+		t.Errorf("Expected b then a, got %s then %s", top[0].URL, top[1].URL)
+	}
+}
+
+func TestNavigationTracker_GetTopURLs_SortAndPage(t *testing.T) {
+	tracker := NewNavigationTracker()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: base},
+		{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: base},
+		{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: base},
+		{VisitorID: "visitor1", URL: "https://example.com/b", Timestamp: base.Add(time.Hour)},
+		{VisitorID: "visitor2", URL: "https://example.com/b", Timestamp: base},
+		{VisitorID: "visitor1", URL: "https://example.com/c", Timestamp: base.Add(2 * time.Hour)},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	cases := []struct {
+		opts     ListOptions
+		expected []string
+	}{
+		{ListOptions{Sort: SortByViews, Limit: 1}, []string{"https://example.com/a"}},
+		{ListOptions{Sort: SortByLastVisit, Limit: 3}, []string{"https://example.com/c", "https://example.com/b", "https://example.com/a"}},
+		{ListOptions{Sort: SortByVisitors, Ascending: true, Offset: 1, Limit: 1}, []string{"https://example.com/c"}},
+		{ListOptions{Offset: 5, Limit: 1}, []string{}},
+	}
+	for _, tc := range cases {
+		top, total := tracker.GetTopURLs(tc.opts)
+		if total != 3 {
+			t.Errorf("Expected total 3, got %d", total)
+		}
+		if len(top) != len(tc.expected) {
+			t.Errorf("Expected %d URLs for %+v, got %d", len(tc.expected), tc.opts, len(top))
+			continue
+		}
+		for i, url := range tc.expected {
+			if top[i].URL != url {
+				t.Errorf("Expected %s at %d for %+v, got %s", url, i, tc.opts, top[i].URL)
+			}
+		}
+	}
+}
+
+func TestNavigationTracker_GetTopURLs_Sketch(t *testing.T) {
+	tracker := newSketchTracker(3)
+	for i := 0; i < 50; i++ {
+		recordVisitors(t, tracker, fmt.Sprintf("https://example.com/tail/%d", i), 1)
+	}
+	recordVisitors(t, tracker, "https://example.com/a", 10)
+	recordVisitors(t, tracker, "https://example.com/b", 20)
+	recordVisitors(t, tracker, "https://example.com/c", 30)
+
+	top, _ := tracker.GetTopURLs(ListOptions{Limit: 10})
+	if len(top) != 3 {
+		t.Fatalf("Expected the 3 tracked leaders, got %d", len(top))
+	}
+
+	expected := []string{"https://example.com/c", "https://example.com/b", "https://example.com/a"}
+	for i, url := range expected {
+		if top[i].URL != url {
+			t.Errorf("Expected %s at %d, got %s", url, i, top[i].URL)
+		}
+	}
+	if top[0].DistinctVisitors != 30 {
+		t.Errorf("Expected exact count 30, got %d", top[0].DistinctVisitors)
+	}
+}
+
+func TestNavigationTracker_GetTopURLs_SketchRetention(t *testing.T) {
+	config := models.DefaultConfiguration()
+	config.TopURLs.Mode = models.TopURLsSketch
+	config.Retention.MaxEventAge = time.Hour
+	tracker := NewNavigationTrackerWithConfig(config)
+
+	event := &models.NavigationEvent{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: time.Now().Add(-2 * time.Hour)}
+	if err := tracker.RecordEvent(event); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	tracker.ExpireURLs(time.Now())
+
+	if top, _ := tracker.GetTopURLs(ListOptions{Limit: 10}); len(top) != 0 {
+		t.Errorf("Expected expired URL dropped from the leaders, got %+v", top)
+	}
+}
+
+func TestCountMinSketch_NeverUndercounts(t *testing.T) {
+	sketch := newCountMinSketch(16, 4)
+	for i := 0; i < 100; i++ {
+		sketch.add(fmt.Sprintf("key%d", i), 1)
+	}
+
+	if estimate := sketch.add("key7", 1); estimate < 2 {
+		t.Errorf("Expected estimate of at least 2, got %d", estimate)
+	}
+}
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// QueryTrace collects per-request cost details for the slow query log. A trace
+// is only written by the goroutine serving its request.
+type QueryTrace struct {
+	LockWait    time.Duration
+	MatchedURLs int
+}
+
+type queryTraceKey struct{}
+
+func WithQueryTrace(ctx context.Context, trace *QueryTrace) context.Context {
+	return context.WithValue(ctx, queryTraceKey{}, trace)
+}
+
+// QueryTraceFromContext returns the request's trace, or nil if it is not being traced.
+func QueryTraceFromContext(ctx context.Context) *QueryTrace {
+	trace, _ := ctx.Value(queryTraceKey{}).(*QueryTrace)
+	return trace
+}
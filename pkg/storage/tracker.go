@@ -2,67 +2,694 @@ package storage
 
 import (
 	"fmt"
+	"log"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"nav-tracker/pkg/clientip"
 	"nav-tracker/pkg/models"
+	"nav-tracker/pkg/useragent"
 )
 
 type NavigationTracker struct {
-	urlVisitors map[string]map[string]bool
-	mutex       sync.RWMutex
+	shards         [shardCount]*urlShard
+	visitorShards  [shardCount]*visitorShard
+	totalPageViews atomic.Int64
+	sequence       atomic.Uint64
+	validation     atomic.Pointer[models.ValidationRules]
+	canary         atomic.Pointer[validationCanary]
+	sessions       *SessionStore
+	cohorts        *CohortStore
+	actives        *ActiveStore
+	realtime       *realtimeGauge
+	events         *eventTypeStore
+	funnels        *FunnelStore
+	schemas        *PropertySchemaStore
+	patterns       *patternSet
+	bulkJobs       *BulkJobStore
+	classifier     *URLClassifier
+	retention      *retentionPolicy
+	topURLs        *topURLs
+	tiering        *tieringPolicy
+	dedup          *dedupCache
+	eviction       *evictionPolicy
+	visitorCap     *visitorCap
+	sampler        *viewSampler
+	timestamps     *timestampPolicy
+	denylist       *denylist
+	quality        *dataQuality
+	pseudonymizer  *pseudonymizer
+	ipAnonymizer   *clientip.Anonymizer
+	dimensions     dimensionSet
+	distribution   urlDistribution
+	deletions      deletionLog
+	listeners      []EventListener
+	counters       CounterBackend
+
+	// mutex guards the retention sweep counters below; per-URL state is
+	// guarded by its shard.
+	expiredURLs     int64
+	expiredVisitors int64
+	lastSweep       time.Time
+	mutex           sync.RWMutex
+}
+
+// EventListener is called with a copy of every successfully recorded event,
+// after the tracker lock has been released.
+type EventListener func(event models.NavigationEvent)
+
+type urlStats struct {
+	visitors  map[string]*visitorRecord
+	pageViews int64
+	category  string
+	referrers map[string]*referrerStats
+	devices   *deviceStats
+	countries map[string]*countryStats
+	languages map[string]*languageStats
+	// dimensions is nil until an event carries a configured dimension.
+	dimensions map[string]distinctCounter
+	// overflow is nil until a visitor beyond the visitor cap is counted
+	// without a record.
+	overflow *hyperLogLog
+	expiry   *expiryEntry
+	ttl      time.Duration // overrides the retention policy when non-zero; negative never expires
+	// promotedAt is when the URL was last loaded back from the cold tier.
+	promotedAt time.Time
+	// size is the approximate bytes the statistics hold in memory, and
+	// lastRecorded when an event was last recorded for the URL, in unix
+	// nanoseconds, ordering URLs for eviction.
+	size         int64
+	lastRecorded int64
+	// slot is where the URL is counted in the tracker's distribution.
+	slot distributionSlot
+	// repeatViews counts the views by known visitors in sampling mode.
+	repeatViews int64
+}
+
+type visitorRecord struct {
+	sequence  uint64
+	firstSeen time.Time
+	lastSeen  time.Time
+	pageViews int64
+}
+
+const (
+	SortByLastVisit  = "last_visit"
+	SortByVisitCount = "visit_count"
+	SortByVisitors   = "visitors"
+	SortByViews      = "views"
+)
+
+// ListOptions selects one page of a sorted listing. Results are in
+// descending order of Sort unless Ascending is set; ties are broken by key.
+type ListOptions struct {
+	Sort      string
+	Ascending bool
+	Offset    int
+	Limit     int
+}
+
+// page returns the window of n sorted items selected by the options as
+// slice bounds.
+func (o ListOptions) page(n int) (int, int) {
+	if o.Offset >= n {
+		return n, n
+	}
+	end := o.Offset + o.Limit
+	if end > n {
+		end = n
+	}
+	return o.Offset, end
+}
+
+// ExportFilter narrows the records returned by Export. Zero values match everything.
+// Epoch, when set, excludes visitor records created after ExportEpoch returned
+// it; After resumes the export past the given position.
+type ExportFilter struct {
+	URLPrefix string
+	From      time.Time
+	To        time.Time
+	Epoch     uint64
+	After     ExportPosition
+	Trace     *QueryTrace
+}
+
+// ExportPosition identifies a record in Export's URL, visitor ID ordering.
+type ExportPosition struct {
+	URL       string
+	VisitorID string
 }
 
 func NewNavigationTracker() *NavigationTracker {
-	return &NavigationTracker{
-		urlVisitors: make(map[string]map[string]bool),
+	return NewNavigationTrackerWithConfig(models.DefaultConfiguration())
+}
+
+func NewNavigationTrackerWithConfig(config *models.Configuration) *NavigationTracker {
+	nt := &NavigationTracker{
+		sessions:   NewSessionStore(config.SessionIdleTimeout),
+		cohorts:    NewCohortStore(),
+		actives:    NewActiveStore(),
+		realtime:   newRealtimeGauge(config.Realtime),
+		events:     newEventTypeStore(),
+		funnels:    NewFunnelStore(),
+		schemas:    NewPropertySchemaStore(),
+		patterns:   newPatternSet(),
+		classifier: NewURLClassifier(config.URLCategories),
+		retention:  newRetentionPolicy(config.Retention),
+		topURLs:    newTopURLs(config.TopURLs),
+		dedup:      newDedupCache(config.Dedup),
+		eviction:   newEvictionPolicy(config.Eviction),
+		visitorCap: newVisitorCap(config.VisitorCap),
+		sampler:    newViewSampler(config.Sampling),
+		timestamps: newTimestampPolicy(config.Timestamps),
+		denylist:   newDenylist(config.Denylist),
+		dimensions: newDimensionSet(config.Dimensions),
+	}
+	nt.pseudonymizer = newPseudonymizer(config.Privacy)
+	nt.ipAnonymizer = clientip.NewAnonymizer(config.Privacy)
+	nt.quality = newDataQuality(config.DataQuality, nt.pseudonymizer)
+	nt.bulkJobs = NewBulkJobStore(nt)
+	nt.funnels.validation = nt.Validation
+	rules, err := models.CompileValidation(config.Validation)
+	if err != nil {
+		log.Printf("Invalid validation rules, keeping the defaults: %v", err)
+		rules = models.DefaultValidationRules()
 	}
+	nt.validation.Store(rules)
+	for i := range nt.shards {
+		nt.shards[i] = newURLShard()
+		nt.visitorShards[i] = newVisitorShard()
+	}
+	return nt
 }
 
+// AddListener registers fn to be notified of recorded events. Listeners must
+// be added before the tracker starts receiving events.
+func (nt *NavigationTracker) AddListener(fn EventListener) {
+	nt.listeners = append(nt.listeners, fn)
+}
+
+// RecordEvent counts event. An event whose EventID was already recorded
+// within the dedup TTL is acknowledged without being counted again. Once
+// counted in memory an event is recorded: if the counter backend then fails,
+// the event is still passed to listeners and no error is returned, since a
+// retry would count it twice.
 func (nt *NavigationTracker) RecordEvent(event *models.NavigationEvent) error {
-	nt.mutex.Lock()
-	defer nt.mutex.Unlock()
+	_, err := nt.RecordEventStatus(event)
+	return err
+}
+
+// RecordEventStatus records event like RecordEvent and also reports whether
+// it is degraded: counted in memory but not by the counter backend.
+func (nt *NavigationTracker) RecordEventStatus(event *models.NavigationEvent) (bool, error) {
+	counted, err := nt.recordEvent(event)
+	if err != nil || !counted {
+		return false, err
+	}
 
-	if err := event.Validate(); err != nil {
-		return fmt.Errorf("invalid event: %w", err)
+	degraded := false
+	if nt.counters != nil && event.IsPageView() && !nt.quality.isExcluded(event.VisitorID) {
+		if err := nt.counters.Record(event); err != nil {
+			logWriteFailure(err)
+			degraded = true
+		}
+	}
+
+	for _, listener := range nt.listeners {
+		listener(*event)
+	}
+
+	return degraded, nil
+}
+
+// recordEvent reports whether event was counted, which it is not if it is a duplicate.
+func (nt *NavigationTracker) recordEvent(event *models.NavigationEvent) (bool, error) {
+	rules, arm := nt.validationFor(event)
+	if err := event.Validate(rules); err != nil {
+		arm.count(false)
+		return false, fmt.Errorf("invalid event: %w", err)
+	}
+	arm.count(true)
+	if err := nt.schemas.check(event); err != nil {
+		return false, err
 	}
 
 	event.NormalizeURL()
+	event.NormalizeLanguage()
+	if list := nt.denylist.check(event); list != "" {
+		nt.denylist.deny(list)
+		return false, fmt.Errorf("%w by %s", ErrDenied, list)
+	}
+	if err := nt.admit(); err != nil {
+		return false, err
+	}
+	if err := nt.timestamps.apply(event, time.Now().UTC()); err != nil {
+		return false, err
+	}
 	event.SetDefaults()
+	event.VisitorID = nt.pseudonymizer.hash(event.VisitorID)
+	// Whatever the source, no raw IP is recorded or passed to listeners
+	event.IP = nt.ipAnonymizer.AnonymizeString(event.IP)
+
+	deduplicated := nt.dedup != nil && event.EventID != ""
+	if deduplicated && nt.dedup.seen(event.EventID, time.Now()) {
+		return false, nil
+	}
+
+	// Clicks and custom events are counted apart from visits
+	if !event.IsPageView() {
+		nt.events.record(event)
+		nt.schemas.record(event)
+		return true, nil
+	}
+
+	excluded := nt.quality.isExcluded(event.VisitorID)
+	if err := nt.recordURL(event, excluded, arm); err != nil {
+		if deduplicated {
+			nt.dedup.forget(event.EventID)
+		}
+		return false, err
+	}
+	nt.schemas.record(event)
+	nt.evictIfFull()
+	if excluded {
+		nt.quality.excludedViews.Add(1)
+		return true, nil
+	}
+	nt.recordProperties(event.VisitorID, event.Properties)
+	nt.recordLanguage(event.VisitorID, event.Language)
+	nt.sessions.Record(event)
+	nt.cohorts.Record(event.VisitorID, event.Timestamp)
+	nt.actives.Record(event.VisitorID, event.URL, event.Timestamp)
+	nt.realtime.record(event.VisitorID, event.URL, event.Timestamp, time.Now())
 
-	if nt.urlVisitors[event.URL] == nil {
-		nt.urlVisitors[event.URL] = make(map[string]bool)
+	return true, nil
+}
+
+// recordURL updates the statistics of the event's URL under its shard lock.
+// An excluded visitor's event counts as a page view without a visitor, and
+// a new URL on arm when a canary runs.
+func (nt *NavigationTracker) recordURL(event *models.NavigationEvent, excluded bool, arm *canaryArm) error {
+	shard := nt.urlShard(event.URL)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	if shard.frozen[event.URL] {
+		return ErrURLFrozen
 	}
 
-	nt.urlVisitors[event.URL][event.VisitorID] = true
+	stats, _ := shard.hot(event.URL)
+	newURL := stats == nil
+	if newURL {
+		stats = &urlStats{
+			visitors:  make(map[string]*visitorRecord),
+			category:  nt.classifier.Classify(event.URL),
+			referrers: make(map[string]*referrerStats),
+			devices:   newDeviceStats(),
+			countries: make(map[string]*countryStats),
+			languages: make(map[string]*languageStats),
+		}
+		shard.urls[event.URL] = stats
+		shard.indexURL(event.URL)
+		shard.grow(stats, urlEntryBytes+int64(len(event.URL)))
+		arm.countURL()
+	}
+	stats.lastRecorded = time.Now().UnixNano()
+	defer nt.distribution.update(stats)
 
+	record := stats.visitors[event.VisitorID]
+	newVisitor := record == nil
+	overflow := !excluded && newVisitor && nt.visitorCap.reached(stats)
+	if overflow {
+		nt.visitorCap.overflowEvents.Add(1)
+		switch nt.visitorCap.overflow {
+		case models.VisitorCapReject:
+			return ErrVisitorCapReached
+		case models.VisitorCapApproximate:
+			shard.countOverflow(stats, event.VisitorID)
+		case models.VisitorCapEvictOldest:
+			nt.evictOldestVisitors(shard, event.URL, stats)
+			overflow = false
+		}
+	}
+
+	// Excluded visitors and those counted beyond the cap add a page view
+	// without a visitor record
+	if excluded || overflow {
+		stats.pageViews++
+		nt.totalPageViews.Add(1)
+		shard.trackExpiry(nt.retention, event.URL, stats, event.Timestamp)
+		shard.recordCategory(stats.category, "", newURL, 1)
+		nt.patterns.record(event.URL, "", newURL, false, 1)
+		stats.devices.record(useragent.Parse(event.UserAgent))
+		stats.recordCountry(event.Country, false)
+		stats.recordLanguage(event.Language, false)
+		return nil
+	}
+
+	if newVisitor {
+		record = &visitorRecord{sequence: nt.sequence.Add(1), firstSeen: event.Timestamp, lastSeen: event.Timestamp}
+		stats.visitors[event.VisitorID] = record
+		shard.grow(stats, visitorEntryBytes+int64(len(event.VisitorID)))
+		nt.indexVisitor(event.VisitorID, event.URL)
+		if nt.topURLs != nil {
+			nt.topURLs.add(event.URL, 1)
+		}
+	}
+
+	if event.Timestamp.Before(record.firstSeen) {
+		record.firstSeen = event.Timestamp
+	}
+	if event.Timestamp.After(record.lastSeen) {
+		record.lastSeen = event.Timestamp
+	}
+	shard.trackExpiry(nt.retention, event.URL, stats, record.lastSeen)
+
+	views := int64(1)
+	if !newVisitor {
+		views = nt.sampler.weight(stats)
+	}
+	if views == 0 {
+		return nil
+	}
+	record.pageViews += views
+	stats.pageViews += views
+	nt.totalPageViews.Add(views)
+
+	shard.recordCategory(stats.category, event.VisitorID, newURL, views)
+	nt.patterns.record(event.URL, event.VisitorID, newURL, newVisitor, views)
+	shard.grow(stats, stats.recordReferrer(event))
+	stats.devices.record(useragent.Parse(event.UserAgent))
+	stats.recordCountry(event.Country, newVisitor)
+	stats.recordLanguage(event.Language, newVisitor)
+	stats.recordDimensions(nt.dimensions, event.Properties)
 	return nil
 }
 
 func (nt *NavigationTracker) GetDistinctVisitors(url string) int {
-	nt.mutex.RLock()
-	defer nt.mutex.RUnlock()
+	if visitors, _, ok := nt.sharedCounts(url); ok {
+		return visitors
+	}
+
+	nt.promote(url)
+	shard := nt.urlShard(url)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
 
-	if visitors, exists := nt.urlVisitors[url]; exists {
-		return len(visitors)
+	if stats, exists := shard.lookup(url); exists {
+		return stats.distinctVisitors()
 	}
 
 	return 0
 }
 
-func (nt *NavigationTracker) GetVisitorStats(url string) *models.VisitorStats {
-	nt.mutex.RLock()
-	defer nt.mutex.RUnlock()
+// CountActiveVisitors returns the number of url's visitors last seen at or after since.
+func (nt *NavigationTracker) CountActiveVisitors(url string, since time.Time) int {
+	nt.promote(url)
+	shard := nt.urlShard(url)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	stats, exists := shard.lookup(url)
+	if !exists {
+		return 0
+	}
+
+	count := 0
+	for _, record := range stats.visitors {
+		if !record.lastSeen.Before(since) {
+			count++
+		}
+	}
+	return count
+}
 
+func (nt *NavigationTracker) GetVisitorStats(url string) *models.VisitorStats {
 	distinctVisitors := 0
-	if visitors, exists := nt.urlVisitors[url]; exists {
-		distinctVisitors = len(visitors)
+	totalPageViews := 0
+	samplingRate := 0
+	if visitors, pageViews, ok := nt.sharedCounts(url); ok {
+		distinctVisitors = visitors
+		totalPageViews = int(pageViews)
+	} else {
+		nt.promote(url)
+		shard := nt.urlShard(url)
+		shard.mutex.RLock()
+		if stats, exists := shard.lookup(url); exists {
+			distinctVisitors = stats.distinctVisitors()
+			totalPageViews = int(stats.pageViews)
+		}
+		shard.mutex.RUnlock()
+		if nt.sampler != nil {
+			samplingRate = int(nt.sampler.rate)
+		}
 	}
 
 	return &models.VisitorStats{
 		URL:              url,
 		DistinctVisitors: distinctVisitors,
-		TotalPageViews:   0,
+		TotalPageViews:   totalPageViews,
+		SamplingRate:     samplingRate,
 		LastUpdated:      time.Now().UTC(),
 	}
 }
+
+// GetVisitors returns one page of a URL's visitors sorted by last_visit or
+// visit_count, along with the total number of visitors for the URL.
+func (nt *NavigationTracker) GetVisitors(url string, opts ListOptions, trace *QueryTrace) ([]*models.VisitorDetail, int) {
+	nt.promote(url)
+	shard := nt.urlShard(url)
+	shard.rlock(trace)
+	stats, exists := shard.lookup(url)
+	if !exists {
+		shard.mutex.RUnlock()
+		return []*models.VisitorDetail{}, 0
+	}
+
+	if trace != nil {
+		trace.MatchedURLs++
+	}
+
+	visitors := make([]*models.VisitorDetail, 0, len(stats.visitors))
+	for visitorID, record := range stats.visitors {
+		visitors = append(visitors, &models.VisitorDetail{
+			VisitorID: visitorID,
+			FirstSeen: record.firstSeen,
+			LastSeen:  record.lastSeen,
+			PageViews: record.pageViews,
+		})
+	}
+	shard.mutex.RUnlock()
+
+	sort.Slice(visitors, func(i, j int) bool {
+		a, b := visitors[i], visitors[j]
+		switch opts.Sort {
+		case SortByVisitCount:
+			if a.PageViews != b.PageViews {
+				return (a.PageViews > b.PageViews) != opts.Ascending
+			}
+		default:
+			if !a.LastSeen.Equal(b.LastSeen) {
+				return a.LastSeen.After(b.LastSeen) != opts.Ascending
+			}
+		}
+		return a.VisitorID < b.VisitorID
+	})
+
+	start, end := opts.page(len(visitors))
+	return visitors[start:end], len(visitors)
+}
+
+func (nt *NavigationTracker) Sessions() *SessionStore {
+	return nt.sessions
+}
+
+func (nt *NavigationTracker) Cohorts() *CohortStore {
+	return nt.cohorts
+}
+
+func (nt *NavigationTracker) Actives() *ActiveStore {
+	return nt.actives
+}
+
+func (nt *NavigationTracker) Funnels() *FunnelStore {
+	return nt.funnels
+}
+
+func (nt *NavigationTracker) PropertySchemas() *PropertySchemaStore {
+	return nt.schemas
+}
+
+// Validation returns the rules events are validated against.
+func (nt *NavigationTracker) Validation() *models.ValidationRules {
+	return nt.validation.Load()
+}
+
+// SetValidation replaces the rules events are validated against.
+func (nt *NavigationTracker) SetValidation(rules *models.ValidationRules) {
+	nt.validation.Store(rules)
+}
+
+func (nt *NavigationTracker) BulkJobs() *BulkJobStore {
+	return nt.bulkJobs
+}
+
+func (nt *NavigationTracker) GetSystemStats() *models.SystemStats {
+	uniqueURLs := 0
+	for _, shard := range nt.shards {
+		shard.mutex.RLock()
+		uniqueURLs += len(shard.urls) + shard.coldURLs()
+		shard.mutex.RUnlock()
+	}
+
+	var retention *models.RetentionStats
+	if nt.retention.enabled() {
+		retention = nt.retentionStats()
+	}
+
+	var tiering *models.TieringStats
+	if nt.tiering != nil {
+		tiering = nt.tieringStats()
+	}
+
+	var eviction *models.EvictionStats
+	if nt.eviction != nil {
+		eviction = nt.evictionStats()
+	}
+
+	var denylist *models.DenylistStats
+	if stats := nt.DenylistStats(); stats.URLRules+stats.ReferrerRules > 0 || stats.RejectedURLs+stats.RejectedReferrers > 0 {
+		denylist = stats
+	}
+
+	var dedup *models.DedupStats
+	if nt.dedup != nil {
+		dedup = nt.dedup.stats()
+	}
+
+	var visitorCap *models.VisitorCapStats
+	if nt.visitorCap != nil {
+		visitorCap = nt.visitorCapStats()
+	}
+
+	var sampling *models.SamplingStats
+	if nt.sampler != nil {
+		sampling = nt.sampler.stats()
+	}
+
+	var timestamps *models.TimestampStats
+	if nt.timestamps != nil {
+		timestamps = nt.timestamps.stats()
+	}
+
+	var degraded []string
+	if eviction != nil && eviction.Backpressure {
+		degraded = append(degraded, models.DegradedMemory)
+	}
+
+	totalSessions, avgSessionLength, pagesPerSession := nt.sessions.Summary()
+
+	return &models.SystemStats{
+		TotalUniqueURLs:      uniqueURLs,
+		TotalPageViews:       nt.totalPageViews.Load(),
+		TotalSessions:        totalSessions,
+		AverageSessionLength: avgSessionLength,
+		PagesPerSession:      pagesPerSession,
+		Retention:            retention,
+		Tiering:              tiering,
+		Dedup:                dedup,
+		Eviction:             eviction,
+		VisitorCap:           visitorCap,
+		Sampling:             sampling,
+		Timestamps:           timestamps,
+		Denylist:             denylist,
+		URLDistribution:      nt.distribution.stats(),
+		Degraded:             degraded,
+		Timestamp:            time.Now().UTC(),
+	}
+}
+
+// ExportEpoch returns a marker for the current point in time. Exports filtered
+// by it skip visitor records created afterwards, so a resumed export sees the
+// same set of records as the one it continues. Counters and timestamps of
+// included records still reflect their current values.
+func (nt *NavigationTracker) ExportEpoch() uint64 {
+	return nt.sequence.Load() + 1
+}
+
+// Export calls fn for every visitor record matching filter, ordered by URL.
+// A shard lock is only held while copying a single URL's records, so fn may
+// do slow I/O without blocking ingestion.
+func (nt *NavigationTracker) Export(filter ExportFilter, fn func(*models.ExportRecord) error) error {
+	var urls []string
+	for _, shard := range nt.shards {
+		shard.rlock(filter.Trace)
+		shard.eachURL(func(url string) {
+			if strings.HasPrefix(url, filter.URLPrefix) && url >= filter.After.URL {
+				urls = append(urls, url)
+			}
+		})
+		shard.mutex.RUnlock()
+	}
+
+	if filter.Trace != nil {
+		filter.Trace.MatchedURLs = len(urls)
+	}
+
+	sort.Strings(urls)
+
+	for _, url := range urls {
+		records := nt.exportURL(url, filter)
+		for _, record := range records {
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (nt *NavigationTracker) exportURL(url string, filter ExportFilter) []*models.ExportRecord {
+	shard := nt.urlShard(url)
+	shard.rlock(filter.Trace)
+	defer shard.mutex.RUnlock()
+
+	stats, exists := shard.lookup(url)
+	if !exists {
+		return nil
+	}
+
+	records := make([]*models.ExportRecord, 0, len(stats.visitors))
+	for visitorID, record := range stats.visitors {
+		if filter.Epoch > 0 && record.sequence >= filter.Epoch {
+			continue
+		}
+		if url == filter.After.URL && visitorID <= filter.After.VisitorID {
+			continue
+		}
+		if !filter.From.IsZero() && record.lastSeen.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && !record.firstSeen.Before(filter.To) {
+			continue
+		}
+
+		records = append(records, &models.ExportRecord{
+			URL:       url,
+			VisitorID: visitorID,
+			FirstSeen: record.firstSeen,
+			LastSeen:  record.lastSeen,
+			PageViews: record.pageViews,
+		})
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].VisitorID < records[j].VisitorID
+	})
+
+	return records
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"sync"
 	"testing"
+	"time"
 
 	"nav-tracker/pkg/models"
 )
@@ -238,3 +239,181 @@ func TestNavigationTracker_NonExistentURL(t *testing.T) {
 		t.Errorf("Expected 0 visitors for non-existent URL, got %d", count)
 	}
 }
+
+func TestNavigationTracker_Export(t *testing.T) {
+	tracker := NewNavigationTracker()
+
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{VisitorID: "visitor2", URL: "https://example.com/b", Timestamp: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{VisitorID: "visitor3", URL: "https://other.com/a", Timestamp: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	var records []*models.ExportRecord
+	err := tracker.Export(ExportFilter{URLPrefix: "https://example.com"}, func(record *models.ExportRecord) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+
+	if records[0].PageViews != 2 {
+		t.Errorf("Expected 2 page views for visitor1, got %d", records[0].PageViews)
+	}
+
+	if !records[0].FirstSeen.Equal(events[0].Timestamp) || !records[0].LastSeen.Equal(events[1].Timestamp) {
+		t.Errorf("Unexpected first/last seen: %v - %v", records[0].FirstSeen, records[0].LastSeen)
+	}
+
+	records = nil
+	err = tracker.Export(ExportFilter{To: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}, func(record *models.ExportRecord) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	if len(records) != 1 || records[0].VisitorID != "visitor1" {
+		t.Errorf("Expected only visitor1 before 2024-01-02, got %+v", records)
+	}
+}
+
+func TestNavigationTracker_ExportResume(t *testing.T) {
+	tracker := NewNavigationTracker()
+
+	for _, event := range []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a"},
+		{VisitorID: "visitor2", URL: "https://example.com/a"},
+		{VisitorID: "visitor1", URL: "https://example.com/b"},
+	} {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	epoch := tracker.ExportEpoch()
+
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor3", URL: "https://example.com/a"}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	var records []*models.ExportRecord
+	filter := ExportFilter{
+		Epoch: epoch,
+		After: ExportPosition{URL: "https://example.com/a", VisitorID: "visitor1"},
+	}
+	err := tracker.Export(filter, func(record *models.ExportRecord) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records after resume position, got %d", len(records))
+	}
+
+	if records[0].VisitorID != "visitor2" || records[1].URL != "https://example.com/b" {
+		t.Errorf("Unexpected resumed records: %+v, %+v", records[0], records[1])
+	}
+}
+
+func TestNavigationTracker_GetVisitors(t *testing.T) {
+	tracker := NewNavigationTracker()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: base},
+		{VisitorID: "visitor2", URL: "https://example.com/a", Timestamp: base.Add(time.Hour)},
+		{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: base.Add(30 * time.Minute)},
+		{VisitorID: "visitor3", URL: "https://example.com/a", Timestamp: base.Add(2 * time.Hour)},
+	}
+
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	visitors, total := tracker.GetVisitors("https://example.com/a", ListOptions{Sort: SortByLastVisit, Limit: 2}, nil)
+	if total != 3 {
+		t.Errorf("Expected 3 total visitors, got %d", total)
+	}
+
+	if len(visitors) != 2 || visitors[0].VisitorID != "visitor3" || visitors[1].VisitorID != "visitor2" {
+		t.Errorf("Unexpected last_visit order: %+v", visitors)
+	}
+
+	visitors, _ = tracker.GetVisitors("https://example.com/a", ListOptions{Sort: SortByVisitCount, Limit: 1}, nil)
+	if len(visitors) != 1 || visitors[0].VisitorID != "visitor1" || visitors[0].PageViews != 2 {
+		t.Errorf("Expected visitor1 with 2 views first, got %+v", visitors)
+	}
+
+	visitors, _ = tracker.GetVisitors("https://example.com/a", ListOptions{Sort: SortByLastVisit, Ascending: true, Offset: 1, Limit: 1}, nil)
+	if len(visitors) != 1 || visitors[0].VisitorID != "visitor2" {
+		t.Errorf("Expected visitor2 second in ascending order, got %+v", visitors)
+	}
+
+	visitors, _ = tracker.GetVisitors("https://example.com/a", ListOptions{Sort: SortByLastVisit, Offset: 10, Limit: 2}, nil)
+	if len(visitors) != 0 {
+		t.Errorf("Expected empty page past the end, got %d visitors", len(visitors))
+	}
+}
+
+func TestNavigationTracker_ExportTrace(t *testing.T) {
+	tracker := NewNavigationTracker()
+
+	for _, url := range []string{"https://example.com/a", "https://example.com/b", "https://other.com/a"} {
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	trace := &QueryTrace{}
+	err := tracker.Export(ExportFilter{URLPrefix: "https://example.com", Trace: trace}, func(*models.ExportRecord) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	if trace.MatchedURLs != 2 {
+		t.Errorf("Expected 2 matched URLs in trace, got %d", trace.MatchedURLs)
+	}
+}
+
+func TestNavigationTracker_CountActiveVisitors(t *testing.T) {
+	tracker := NewNavigationTracker()
+	now := time.Now()
+
+	events := []*models.NavigationEvent{
+		{VisitorID: "old", URL: "https://example.com/", Timestamp: now.Add(-2 * time.Hour)},
+		{VisitorID: "recent", URL: "https://example.com/", Timestamp: now.Add(-10 * time.Minute)},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	if count := tracker.CountActiveVisitors("https://example.com/", now.Add(-time.Hour)); count != 1 {
+		t.Errorf("Expected 1 visitor in the last hour, got %d", count)
+	}
+	if count := tracker.CountActiveVisitors("https://example.com/missing", now.Add(-time.Hour)); count != 0 {
+		t.Errorf("Expected 0 for unknown URL, got %d", count)
+	}
+}
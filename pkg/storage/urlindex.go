@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+// URLQuery selects tracked URLs for ListURLs: those starting with Prefix
+// and containing Contains, which is compared case-insensitively, listed in order
+// starting after the URL After.
+type URLQuery struct {
+	Prefix   string
+	Contains string
+	After    string
+	Limit    int
+}
+
+// indexURL adds url to the shard's sorted index; the caller must hold the
+// shard write lock.
+func (s *urlShard) indexURL(url string) {
+	i := sort.SearchStrings(s.sorted, url)
+	if i < len(s.sorted) && s.sorted[i] == url {
+		return
+	}
+	s.sorted = append(s.sorted, "")
+	copy(s.sorted[i+1:], s.sorted[i:])
+	s.sorted[i] = url
+}
+
+// unindexURL removes url from the shard's sorted index; the caller must
+// hold the shard write lock.
+func (s *urlShard) unindexURL(url string) {
+	i := sort.SearchStrings(s.sorted, url)
+	if i < len(s.sorted) && s.sorted[i] == url {
+		s.sorted = append(s.sorted[:i], s.sorted[i+1:]...)
+	}
+}
+
+// rebuildIndex sorts every URL in the shard, hot or cold; the caller must
+// hold the shard write lock.
+func (s *urlShard) rebuildIndex() {
+	s.sorted = s.sorted[:0]
+	s.eachURL(func(url string) {
+		s.sorted = append(s.sorted, url)
+	})
+	sort.Strings(s.sorted)
+}
+
+// summary returns url's counts without loading it if it is cold, or nil if
+// the shard does not track it; the caller must hold the shard lock.
+func (s *urlShard) summary(url string, now time.Time) *models.VisitorStats {
+	if stats, exists := s.urls[url]; exists {
+		return &models.VisitorStats{
+			URL:              url,
+			DistinctVisitors: stats.distinctVisitors(),
+			TotalPageViews:   int(stats.pageViews),
+			LastVisit:        stats.lastSeen(),
+			LastUpdated:      now,
+		}
+	}
+	if entry := s.coldEntry(url); entry != nil {
+		return &models.VisitorStats{
+			URL:              url,
+			DistinctVisitors: entry.visitors,
+			TotalPageViews:   int(entry.pageViews),
+			LastVisit:        entry.lastSeen,
+			LastUpdated:      now,
+		}
+	}
+	return nil
+}
+
+// ListURLs returns the summaries of up to query.Limit tracked URLs matching
+// query in sorted order, the number of URLs matching it in total, and
+// whether more remain after the page. Each shard's index is searched for
+// the prefix, so only URLs sharing it are scanned for the substring.
+func (nt *NavigationTracker) ListURLs(query URLQuery) ([]*models.VisitorStats, int, bool) {
+	now := time.Now().UTC()
+	contains := strings.ToLower(query.Contains)
+
+	var page []*models.VisitorStats
+	total, remaining := 0, 0
+	for _, shard := range nt.shards {
+		shard.mutex.RLock()
+		urls := shard.sorted
+		start := sort.SearchStrings(urls, query.Prefix)
+		end := start + sort.Search(len(urls)-start, func(i int) bool {
+			return !strings.HasPrefix(urls[start+i], query.Prefix)
+		})
+		from := start + sort.Search(end-start, func(i int) bool {
+			return urls[start+i] > query.After
+		})
+
+		if contains == "" {
+			total += end - start
+			remaining += end - from
+			for _, url := range urls[from:min(end, from+query.Limit)] {
+				page = append(page, shard.summary(url, now))
+			}
+		} else {
+			added := 0
+			for i := start; i < end; i++ {
+				if !strings.Contains(strings.ToLower(urls[i]), contains) {
+					continue
+				}
+				total++
+				if i < from {
+					continue
+				}
+				remaining++
+				if added < query.Limit {
+					page = append(page, shard.summary(urls[i], now))
+					added++
+				}
+			}
+		}
+		shard.mutex.RUnlock()
+	}
+
+	sort.Slice(page, func(i, j int) bool { return page[i].URL < page[j].URL })
+	if len(page) > query.Limit {
+		page = page[:query.Limit]
+	}
+	return page, total, remaining > len(page)
+}
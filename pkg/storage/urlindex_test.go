@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func listedURLs(page []*models.VisitorStats) []string {
+	urls := make([]string, len(page))
+	for i, stats := range page {
+		urls[i] = stats.URL
+	}
+	return urls
+}
+
+func TestNavigationTracker_ListURLs(t *testing.T) {
+	tracker := NewNavigationTracker()
+	for i := 0; i < 30; i++ {
+		url := fmt.Sprintf("https://example.com/blog/post-%02d", i)
+		if i%3 == 0 {
+			url = fmt.Sprintf("https://example.com/shop/item-%02d?ref=Promo", i)
+		}
+		if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor1", URL: url}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor2", URL: "https://example.com/blog/post-01"}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	// Paging through every URL returns each once, in order
+	var all []string
+	cursor := ""
+	for {
+		page, total, more := tracker.ListURLs(URLQuery{After: cursor, Limit: 7})
+		if total != 30 {
+			t.Fatalf("Expected 30 URLs in total, got %d", total)
+		}
+		all = append(all, listedURLs(page)...)
+		if !more {
+			break
+		}
+		cursor = page[len(page)-1].URL
+	}
+	if len(all) != 30 || all[0] != "https://example.com/blog/post-01" || all[29] != "https://example.com/shop/item-27?ref=Promo" {
+		t.Fatalf("Expected every URL in order, got %v", all)
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i-1] >= all[i] {
+			t.Fatalf("Expected sorted URLs, got %s before %s", all[i-1], all[i])
+		}
+	}
+
+	page, total, more := tracker.ListURLs(URLQuery{Prefix: "https://example.com/shop/", Limit: 3})
+	if total != 10 || !more || len(page) != 3 || page[0].URL != "https://example.com/shop/item-00?ref=Promo" {
+		t.Errorf("Expected the first 3 of 10 shop URLs, got %v of %d", listedURLs(page), total)
+	}
+
+	page, total, more = tracker.ListURLs(URLQuery{Prefix: "https://example.com/blog/", Contains: "POST-0", Limit: 10})
+	if total != 6 || more || len(page) != 6 {
+		t.Errorf("Expected 6 blog posts matching post-0, got %v of %d", listedURLs(page), total)
+	}
+	if page[0].URL != "https://example.com/blog/post-01" || page[0].DistinctVisitors != 2 || page[0].TotalPageViews != 2 || page[0].LastVisit.IsZero() {
+		t.Errorf("Expected the summary of post-01, got %+v", page[0])
+	}
+
+	if _, total, _ := tracker.ListURLs(URLQuery{Contains: "promo", Limit: 10}); total != 10 {
+		t.Errorf("Expected a case-insensitive substring match, got %d", total)
+	}
+
+	tracker.DeleteURL("https://example.com/blog/post-01", "test")
+	if page, total, _ := tracker.ListURLs(URLQuery{Prefix: "https://example.com/blog/post-0", Limit: 10}); total != 5 || page[0].URL != "https://example.com/blog/post-02" {
+		t.Errorf("Expected the deleted URL to be unlisted, got %v", listedURLs(page))
+	}
+}
+
+func TestNavigationTracker_ListURLsAfterRestoreAndDemotion(t *testing.T) {
+	tracker := newTieringTracker(t, 1000)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, url := range []string{"https://example.com/b", "https://example.com/a"} {
+		event := &models.NavigationEvent{VisitorID: "visitor1", URL: url, Timestamp: base.Add(time.Duration(i) * 2 * time.Hour)}
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	if demoted := tracker.DemoteIdleURLs(base.Add(2 * time.Hour)); demoted != 1 {
+		t.Fatalf("Expected 1 URL demoted, got %d", demoted)
+	}
+	page, total, _ := tracker.ListURLs(URLQuery{Limit: 10})
+	if total != 2 || page[1].URL != "https://example.com/b" || page[1].DistinctVisitors != 1 {
+		t.Errorf("Expected the cold URL listed with its summary, got %v", listedURLs(page))
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := tracker.SaveSnapshot(path); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	restored := NewNavigationTracker()
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if page, _, _ := restored.ListURLs(URLQuery{Limit: 10}); len(page) != 2 || page[0].URL != "https://example.com/a" {
+		t.Errorf("Expected the index rebuilt on restore, got %v", listedURLs(page))
+	}
+
+	restored.Purge()
+	if _, total, _ := restored.ListURLs(URLQuery{Limit: 10}); total != 0 {
+		t.Errorf("Expected no URLs after a purge, got %d", total)
+	}
+}
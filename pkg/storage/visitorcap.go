@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+	"sync/atomic"
+
+	"nav-tracker/pkg/models"
+)
+
+// ErrVisitorCapReached is returned when recording an event for a new visitor
+// of a URL that has reached its visitor cap with the reject strategy.
+var ErrVisitorCapReached = errors.New("url has reached its visitor cap")
+
+// visitorCap enforces the maximum number of visitor records kept per URL.
+type visitorCap struct {
+	max      int
+	overflow string
+
+	overflowEvents  atomic.Int64
+	evictedVisitors atomic.Int64
+}
+
+func newVisitorCap(config models.VisitorCapConfig) *visitorCap {
+	if config.MaxVisitorsPerURL <= 0 {
+		return nil
+	}
+	return &visitorCap{max: config.MaxVisitorsPerURL, overflow: config.Overflow}
+}
+
+// reached reports whether stats holds as many visitors as the cap allows.
+func (c *visitorCap) reached(stats *urlStats) bool {
+	return c != nil && len(stats.visitors) >= c.max
+}
+
+// distinctVisitors returns the URL's visitor records plus the estimated
+// visitors beyond the cap that were counted without a record.
+func (s *urlStats) distinctVisitors() int {
+	if s.overflow == nil {
+		return len(s.visitors)
+	}
+	return len(s.visitors) + s.overflow.count()
+}
+
+// countOverflow counts a visitor beyond the cap in the URL's sketch; the
+// caller must hold the shard write lock.
+func (s *urlShard) countOverflow(stats *urlStats, visitorID string) {
+	if stats.overflow == nil {
+		stats.overflow = newHyperLogLog(models.DefaultHLLPrecision)
+		s.grow(stats, int64(len(stats.overflow.registers)))
+	}
+	stats.overflow.add(visitorID)
+}
+
+// evictOldestVisitors drops the least recently seen tenth of url's visitors,
+// at least one, so that the cap is not hit again on every new visitor; the
+// caller must hold the shard write lock.
+func (nt *NavigationTracker) evictOldestVisitors(shard *urlShard, url string, stats *urlStats) {
+	type candidate struct {
+		visitorID string
+		record    *visitorRecord
+	}
+	candidates := make([]candidate, 0, len(stats.visitors))
+	for visitorID, record := range stats.visitors {
+		candidates = append(candidates, candidate{visitorID, record})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i].record, candidates[j].record
+		if !a.lastSeen.Equal(b.lastSeen) {
+			return a.lastSeen.Before(b.lastSeen)
+		}
+		return a.sequence < b.sequence
+	})
+
+	count := max(1, len(candidates)/10)
+	for _, c := range candidates[:count] {
+		delete(stats.visitors, c.visitorID)
+		shard.grow(stats, -(visitorEntryBytes + int64(len(c.visitorID))))
+		nt.unindexVisitor(c.visitorID, url)
+	}
+	nt.visitorCap.evictedVisitors.Add(int64(count))
+}
+
+// VisitorCapStatus reports whether url has reached the visitor cap, or nil
+// when no cap is configured.
+func (nt *NavigationTracker) VisitorCapStatus(url string) *models.VisitorCapStatus {
+	if nt.visitorCap == nil {
+		return nil
+	}
+
+	status := &models.VisitorCapStatus{MaxVisitors: nt.visitorCap.max, Overflow: nt.visitorCap.overflow}
+	nt.promote(url)
+	shard := nt.urlShard(url)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	if stats, exists := shard.lookup(url); exists {
+		status.Reached = nt.visitorCap.reached(stats)
+		status.Approximate = stats.overflow != nil
+	}
+	return status
+}
+
+func (nt *NavigationTracker) visitorCapStats() *models.VisitorCapStats {
+	return &models.VisitorCapStats{
+		MaxVisitorsPerURL: nt.visitorCap.max,
+		Overflow:          nt.visitorCap.overflow,
+		OverflowEvents:    nt.visitorCap.overflowEvents.Load(),
+		EvictedVisitors:   nt.visitorCap.evictedVisitors.Load(),
+	}
+}
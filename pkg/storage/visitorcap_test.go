@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func newCappedTracker(max int, overflow string) *NavigationTracker {
+	config := models.DefaultConfiguration()
+	config.VisitorCap = models.VisitorCapConfig{MaxVisitorsPerURL: max, Overflow: overflow}
+	return NewNavigationTrackerWithConfig(config)
+}
+
+func recordCappedVisitors(t *testing.T, tracker *NavigationTracker, url string, from, to int, base time.Time) []error {
+	t.Helper()
+	var errs []error
+	for i := from; i < to; i++ {
+		event := &models.NavigationEvent{VisitorID: fmt.Sprintf("visitor%d", i), URL: url, Timestamp: base.Add(time.Duration(i) * time.Minute)}
+		errs = append(errs, tracker.RecordEvent(event))
+	}
+	return errs
+}
+
+func TestVisitorCap_Reject(t *testing.T) {
+	tracker := newCappedTracker(3, models.VisitorCapReject)
+	base := time.Now().Add(-time.Hour)
+
+	errs := recordCappedVisitors(t, tracker, "https://example.com", 0, 5, base)
+	for i, err := range errs {
+		if rejected := errors.Is(err, ErrVisitorCapReached); rejected != (i >= 3) {
+			t.Errorf("Visitor %d: unexpected error %v", i, err)
+		}
+	}
+	// Existing visitors are still counted
+	if err := tracker.RecordEvent(&models.NavigationEvent{VisitorID: "visitor0", URL: "https://example.com"}); err != nil {
+		t.Errorf("Expected an existing visitor to be recorded, got %v", err)
+	}
+
+	stats := tracker.GetVisitorStats("https://example.com")
+	if stats.DistinctVisitors != 3 || stats.TotalPageViews != 4 {
+		t.Errorf("Expected 3 visitors and 4 page views, got %+v", stats)
+	}
+	status := tracker.VisitorCapStatus("https://example.com")
+	if !status.Reached || status.Approximate {
+		t.Errorf("Expected the exact cap reached, got %+v", status)
+	}
+	if capStats := tracker.GetSystemStats().VisitorCap; capStats == nil || capStats.OverflowEvents != 2 {
+		t.Errorf("Expected 2 overflow events, got %+v", capStats)
+	}
+	if _, err := tracker.DryRun(&models.NavigationEvent{VisitorID: "visitor9", URL: "https://example.com"}); !errors.Is(err, ErrVisitorCapReached) {
+		t.Errorf("Expected the dry run to be rejected, got %v", err)
+	}
+}
+
+func TestVisitorCap_Approximate(t *testing.T) {
+	tracker := newCappedTracker(10, models.VisitorCapApproximate)
+	base := time.Now().Add(-24 * time.Hour)
+
+	for _, err := range recordCappedVisitors(t, tracker, "https://example.com", 0, 110, base) {
+		if err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+	recordCappedVisitors(t, tracker, "https://example.com", 50, 60, base)
+
+	stats := tracker.GetVisitorStats("https://example.com")
+	if stats.DistinctVisitors < 105 || stats.DistinctVisitors > 115 || stats.TotalPageViews != 120 {
+		t.Errorf("Expected about 110 visitors and 120 page views, got %+v", stats)
+	}
+	if _, total := tracker.GetVisitors("https://example.com", ListOptions{Limit: 100}, nil); total != 10 {
+		t.Errorf("Expected 10 visitor records, got %d", total)
+	}
+	if status := tracker.VisitorCapStatus("https://example.com"); !status.Reached || !status.Approximate {
+		t.Errorf("Expected an approximate count, got %+v", status)
+	}
+	if result, err := tracker.DryRun(&models.NavigationEvent{VisitorID: "visitor500", URL: "https://example.com"}); err != nil || !result.OverVisitorCap {
+		t.Errorf("Expected the dry run to report the cap, got %+v (%v)", result, err)
+	}
+
+	// The sketch survives snapshots and merges
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := tracker.SaveSnapshot(path); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	restored := newCappedTracker(10, models.VisitorCapApproximate)
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if visitors := restored.GetDistinctVisitors("https://example.com"); visitors != stats.DistinctVisitors {
+		t.Errorf("Expected %d visitors after restore, got %d", stats.DistinctVisitors, visitors)
+	}
+
+	if !restored.mergeURL("https://example.com", "https://example.com/home") {
+		t.Fatal("Expected the URL to be merged")
+	}
+	if visitors := restored.GetDistinctVisitors("https://example.com/home"); visitors != stats.DistinctVisitors {
+		t.Errorf("Expected %d visitors after the merge, got %d", stats.DistinctVisitors, visitors)
+	}
+}
+
+func TestVisitorCap_EvictOldest(t *testing.T) {
+	tracker := newCappedTracker(20, models.VisitorCapEvictOldest)
+	base := time.Now().Add(-24 * time.Hour)
+
+	for _, err := range recordCappedVisitors(t, tracker, "https://example.com", 0, 21, base) {
+		if err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	visitors, total := tracker.GetVisitors("https://example.com", ListOptions{Sort: SortByLastVisit, Ascending: true, Limit: 100}, nil)
+	if total != 19 || visitors[0].VisitorID != "visitor2" {
+		t.Errorf("Expected the 2 least recently seen visitors evicted, got %d starting with %s", total, visitors[0].VisitorID)
+	}
+	if profile, _ := tracker.GetVisitorProfile("visitor0"); profile != nil && len(profile.URLs) != 0 {
+		t.Errorf("Expected the evicted visitor unindexed, got %+v", profile)
+	}
+	if capStats := tracker.GetSystemStats().VisitorCap; capStats.OverflowEvents != 1 || capStats.EvictedVisitors != 2 {
+		t.Errorf("Expected 1 overflow event and 2 evicted visitors, got %+v", capStats)
+	}
+	if tracker.GetVisitorStats("https://example.com").TotalPageViews != 21 {
+		t.Error("Expected evicted visitors' page views to be kept")
+	}
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"sort"
+
+	"nav-tracker/pkg/models"
+)
+
+// indexVisitor records that a visitor has viewed url.
+func (nt *NavigationTracker) indexVisitor(visitorID, url string) {
+	shard := nt.visitorShard(visitorID)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	shard.index(visitorID, url)
+}
+
+// unindexVisitor removes a visitor's link to url, and the visitor's
+// properties and language once no URL is left.
+func (nt *NavigationTracker) unindexVisitor(visitorID, url string) {
+	shard := nt.visitorShard(visitorID)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	urls := shard.urls[visitorID]
+	delete(urls, url)
+	if len(urls) == 0 {
+		delete(shard.urls, visitorID)
+		delete(shard.properties, visitorID)
+		delete(shard.languages, visitorID)
+	}
+}
+
+// index links visitorID to url; the caller must hold the shard lock.
+func (s *visitorShard) index(visitorID, url string) {
+	urls := s.urls[visitorID]
+	if urls == nil {
+		urls = make(map[string]bool)
+		s.urls[visitorID] = urls
+	}
+	urls[url] = true
+}
+
+// rebuildVisitorIndex re-creates the visitor index from the URL shards and
+// clears visitor properties and languages; the caller must hold every shard lock.
+func (nt *NavigationTracker) rebuildVisitorIndex() {
+	for _, shard := range nt.visitorShards {
+		shard.urls = make(map[string]map[string]bool)
+		shard.properties = make(map[string]map[string]string)
+		shard.languages = make(map[string]*visitorLanguage)
+	}
+	for _, shard := range nt.shards {
+		for url, stats := range shard.urls {
+			for visitorID := range stats.visitors {
+				nt.visitorShard(visitorID).index(visitorID, url)
+			}
+		}
+	}
+}
+
+// GetVisitorProfile returns every URL a visitor has viewed, most recent first,
+// with their overall first/last seen times, page views and session count.
+func (nt *NavigationTracker) GetVisitorProfile(visitorID string) (*models.VisitorProfile, bool) {
+	vs := nt.visitorShard(visitorID)
+	vs.mutex.RLock()
+	indexed, exists := vs.urls[visitorID]
+	urls := make([]string, 0, len(indexed))
+	for url := range indexed {
+		urls = append(urls, url)
+	}
+	vs.mutex.RUnlock()
+
+	if !exists {
+		return nil, false
+	}
+
+	profile := &models.VisitorProfile{
+		VisitorID: visitorID,
+		URLs:      make([]*models.VisitorURL, 0, len(urls)),
+	}
+	for _, url := range urls {
+		record, found := nt.visitorRecord(url, visitorID)
+		// The URL expired between reading the index and its shard.
+		if !found {
+			continue
+		}
+
+		profile.URLs = append(profile.URLs, &models.VisitorURL{
+			URL:       url,
+			FirstSeen: record.firstSeen,
+			LastSeen:  record.lastSeen,
+			PageViews: record.pageViews,
+		})
+
+		if profile.FirstSeen.IsZero() || record.firstSeen.Before(profile.FirstSeen) {
+			profile.FirstSeen = record.firstSeen
+		}
+		if record.lastSeen.After(profile.LastSeen) {
+			profile.LastSeen = record.lastSeen
+		}
+		profile.TotalPageViews += record.pageViews
+	}
+
+	if len(profile.URLs) == 0 {
+		return nil, false
+	}
+
+	profile.SessionCount = nt.sessions.GetVisitorSessionCount(visitorID)
+	profile.Properties = nt.GetVisitorProperties(visitorID)
+
+	sort.Slice(profile.URLs, func(i, j int) bool {
+		a, b := profile.URLs[i], profile.URLs[j]
+		if !a.LastSeen.Equal(b.LastSeen) {
+			return a.LastSeen.After(b.LastSeen)
+		}
+		return a.URL < b.URL
+	})
+
+	return profile, true
+}
+
+// visitorRecord returns a copy of visitorID's record for url.
+func (nt *NavigationTracker) visitorRecord(url, visitorID string) (visitorRecord, bool) {
+	shard := nt.urlShard(url)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	if stats, exists := shard.lookup(url); exists {
+		if record, exists := stats.visitors[visitorID]; exists {
+			return *record, true
+		}
+	}
+	return visitorRecord{}, false
+}
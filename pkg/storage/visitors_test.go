@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestNavigationTracker_GetVisitorProfile(t *testing.T) {
+	tracker := NewNavigationTracker()
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	events := []*models.NavigationEvent{
+		{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: base},
+		{VisitorID: "visitor1", URL: "https://example.com/b", Timestamp: base.Add(time.Minute)},
+		{VisitorID: "visitor1", URL: "https://example.com/a", Timestamp: base.Add(2 * time.Hour)},
+		{VisitorID: "visitor2", URL: "https://example.com/a", Timestamp: base},
+	}
+	for _, event := range events {
+		if err := tracker.RecordEvent(event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	profile, ok := tracker.GetVisitorProfile("visitor1")
+	if !ok {
+		t.Fatal("Expected profile for visitor1")
+	}
+
+	if len(profile.URLs) != 2 || profile.URLs[0].URL != "https://example.com/a" || profile.URLs[0].PageViews != 2 {
+		t.Errorf("Unexpected URLs: %+v", profile.URLs)
+	}
+
+	if profile.TotalPageViews != 3 {
+		t.Errorf("Expected 3 total page views, got %d", profile.TotalPageViews)
+	}
+
+	if !profile.FirstSeen.Equal(base) || !profile.LastSeen.Equal(base.Add(2*time.Hour)) {
+		t.Errorf("Unexpected first/last seen: %v - %v", profile.FirstSeen, profile.LastSeen)
+	}
+
+	if profile.SessionCount != 2 {
+		t.Errorf("Expected 2 sessions, got %d", profile.SessionCount)
+	}
+
+	if _, ok := tracker.GetVisitorProfile("missing"); ok {
+		t.Error("Expected no profile for unknown visitor")
+	}
+}
@@ -0,0 +1,134 @@
+package stream
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"nav-tracker/pkg/models"
+)
+
+// Filter restricts a subscription to events for one URL or URL prefix. The
+// zero value matches every event.
+type Filter struct {
+	URL       string
+	URLPrefix string
+}
+
+func (f Filter) Match(url string) bool {
+	if f.URL != "" && url != f.URL {
+		return false
+	}
+	return strings.HasPrefix(url, f.URLPrefix)
+}
+
+// Subscription receives matching events on a bounded channel. When the
+// subscriber falls behind, new events are dropped and counted instead of
+// blocking ingestion.
+type Subscription struct {
+	filter  Filter
+	events  chan models.NavigationEvent
+	dropped int64
+	closed  bool
+}
+
+func (s *Subscription) Events() <-chan models.NavigationEvent {
+	return s.events
+}
+
+// TakeDropped returns the number of events dropped since the last call.
+func (s *Subscription) TakeDropped() int64 {
+	return atomic.SwapInt64(&s.dropped, 0)
+}
+
+// Hub fans recorded events out to live subscribers.
+type Hub struct {
+	subscriptions map[*Subscription]struct{}
+	published     int64
+	closed        bool
+	mutex         sync.RWMutex
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+func (h *Hub) Subscribe(filter Filter, bufferSize int) *Subscription {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+
+	sub := &Subscription{
+		filter: filter,
+		events: make(chan models.NavigationEvent, bufferSize),
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	// A closed hub hands out subscriptions that have already ended
+	if h.closed {
+		sub.closed = true
+		close(sub.events)
+		return sub
+	}
+	h.subscriptions[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe removes sub and closes its channel. It is safe to call more than once.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(h.subscriptions, sub)
+	close(sub.events)
+}
+
+// Close ends every subscription, so streaming handlers return and their
+// connections close, and ends any made afterwards at once. It is safe to call
+// more than once.
+func (h *Hub) Close() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.closed = true
+	for sub := range h.subscriptions {
+		sub.closed = true
+		close(sub.events)
+	}
+	h.subscriptions = make(map[*Subscription]struct{})
+}
+
+// Publish delivers event to every matching subscriber without blocking.
+func (h *Hub) Publish(event models.NavigationEvent) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	atomic.AddInt64(&h.published, 1)
+
+	for sub := range h.subscriptions {
+		if !sub.filter.Match(event.URL) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			atomic.AddInt64(&sub.dropped, 1)
+		}
+	}
+}
+
+func (h *Hub) Subscribers() int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	return len(h.subscriptions)
+}
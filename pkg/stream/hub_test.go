@@ -0,0 +1,95 @@
+package stream
+
+import (
+	"testing"
+
+	"nav-tracker/pkg/models"
+)
+
+func TestFilter_Match(t *testing.T) {
+	tests := []struct {
+		filter Filter
+		url    string
+		want   bool
+	}{
+		{Filter{}, "https://example.com/a", true},
+		{Filter{URL: "https://example.com/a"}, "https://example.com/a", true},
+		{Filter{URL: "https://example.com/a"}, "https://example.com/ab", false},
+		{Filter{URLPrefix: "https://example.com/blog"}, "https://example.com/blog/post", true},
+		{Filter{URLPrefix: "https://example.com/blog"}, "https://example.com/shop", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filter.Match(tt.url); got != tt.want {
+			t.Errorf("%+v.Match(%s): expected %v, got %v", tt.filter, tt.url, tt.want, got)
+		}
+	}
+}
+
+func TestHub_PublishFiltersAndDrops(t *testing.T) {
+	hub := NewHub()
+
+	all := hub.Subscribe(Filter{}, 1)
+	blog := hub.Subscribe(Filter{URLPrefix: "https://example.com/blog"}, 10)
+
+	hub.Publish(models.NavigationEvent{VisitorID: "v1", URL: "https://example.com/blog/a"})
+	hub.Publish(models.NavigationEvent{VisitorID: "v2", URL: "https://example.com/shop"})
+
+	if len(blog.Events()) != 1 {
+		t.Errorf("Expected 1 blog event, got %d", len(blog.Events()))
+	}
+
+	if len(all.Events()) != 1 {
+		t.Errorf("Expected full buffer of 1 event, got %d", len(all.Events()))
+	}
+
+	if dropped := all.TakeDropped(); dropped != 1 {
+		t.Errorf("Expected 1 dropped event, got %d", dropped)
+	}
+
+	if dropped := all.TakeDropped(); dropped != 0 {
+		t.Errorf("Expected dropped counter to reset, got %d", dropped)
+	}
+}
+
+func TestHub_Unsubscribe(t *testing.T) {
+	hub := NewHub()
+	sub := hub.Subscribe(Filter{}, 1)
+
+	if hub.Subscribers() != 1 {
+		t.Fatalf("Expected 1 subscriber, got %d", hub.Subscribers())
+	}
+
+	hub.Unsubscribe(sub)
+	hub.Unsubscribe(sub)
+
+	if hub.Subscribers() != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", hub.Subscribers())
+	}
+
+	if _, ok := <-sub.Events(); ok {
+		t.Error("Expected events channel to be closed")
+	}
+
+	hub.Publish(models.NavigationEvent{URL: "https://example.com/a"})
+}
+
+func TestHub_Close(t *testing.T) {
+	hub := NewHub()
+	sub := hub.Subscribe(Filter{}, 1)
+
+	hub.Close()
+	hub.Close()
+	hub.Unsubscribe(sub)
+
+	if _, ok := <-sub.Events(); ok {
+		t.Error("Expected events channel to be closed")
+	}
+	if late := hub.Subscribe(Filter{}, 1); hub.Subscribers() != 0 {
+		t.Errorf("Expected no subscribers after close, got %d", hub.Subscribers())
+	} else if _, ok := <-late.Events(); ok {
+		t.Error("Expected a subscription after close to be closed")
+	}
+
+	hub.Publish(models.NavigationEvent{URL: "https://example.com/a"})
+}
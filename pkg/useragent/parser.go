@@ -0,0 +1,93 @@
+package useragent
+
+import "strings"
+
+const (
+	Unknown = "unknown"
+	Other   = "other"
+
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+)
+
+type Info struct {
+	Browser string `json:"browser"`
+	OS      string `json:"os"`
+	Device  string `json:"device"`
+}
+
+type rule struct {
+	name     string
+	contains []string
+	excludes []string
+}
+
+// Rules are checked in order; tokens shared by several browsers (every
+// Chromium UA says "Safari", Edge says "Chrome") put the more specific first.
+var browserRules = []rule{
+	{name: "Edge", contains: []string{"edg/", "edge/", "edga/", "edgios/"}},
+	{name: "Opera", contains: []string{"opr/", "opera"}},
+	{name: "Samsung Internet", contains: []string{"samsungbrowser/"}},
+	{name: "Internet Explorer", contains: []string{"msie ", "trident/"}},
+	{name: "Firefox", contains: []string{"firefox/", "fxios/"}},
+	{name: "Chrome", contains: []string{"chrome/", "crios/"}, excludes: []string{"chromium/"}},
+	{name: "Chromium", contains: []string{"chromium/"}},
+	{name: "Safari", contains: []string{"safari/"}},
+}
+
+var osRules = []rule{
+	{name: "iOS", contains: []string{"iphone", "ipad", "ipod"}},
+	{name: "Android", contains: []string{"android"}},
+	{name: "Windows", contains: []string{"windows"}},
+	{name: "ChromeOS", contains: []string{"cros"}},
+	{name: "macOS", contains: []string{"mac os x", "macintosh"}},
+	{name: "Linux", contains: []string{"linux", "x11"}},
+}
+
+var botTokens = []string{"bot", "crawler", "spider", "crawling", "slurp", "headless", "curl/", "wget/", "python-requests"}
+
+func Parse(userAgent string) Info {
+	if strings.TrimSpace(userAgent) == "" {
+		return Info{Browser: Unknown, OS: Unknown, Device: Unknown}
+	}
+
+	ua := strings.ToLower(userAgent)
+
+	info := Info{
+		Browser: match(ua, browserRules),
+		OS:      match(ua, osRules),
+		Device:  DeviceDesktop,
+	}
+
+	switch {
+	case containsAny(ua, botTokens):
+		info.Device = DeviceBot
+	case strings.Contains(ua, "ipad") || strings.Contains(ua, "tablet") ||
+		(strings.Contains(ua, "android") && !strings.Contains(ua, "mobile")):
+		info.Device = DeviceTablet
+	case strings.Contains(ua, "mobi") || strings.Contains(ua, "iphone") || strings.Contains(ua, "ipod"):
+		info.Device = DeviceMobile
+	}
+
+	return info
+}
+
+func match(ua string, rules []rule) string {
+	for _, r := range rules {
+		if containsAny(ua, r.contains) && !containsAny(ua, r.excludes) {
+			return r.name
+		}
+	}
+	return Other
+}
+
+func containsAny(s string, tokens []string) bool {
+	for _, token := range tokens {
+		if strings.Contains(s, token) {
+			return true
+		}
+	}
+	return false
+}
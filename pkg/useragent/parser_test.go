@@ -0,0 +1,57 @@
+package useragent
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		ua   string
+		want Info
+	}{
+		{
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			want: Info{Browser: "Chrome", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0",
+			want: Info{Browser: "Edge", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15",
+			want: Info{Browser: "Safari", OS: "macOS", Device: DeviceDesktop},
+		},
+		{
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+			want: Info{Browser: "Safari", OS: "iOS", Device: DeviceMobile},
+		},
+		{
+			ua:   "Mozilla/5.0 (iPad; CPU OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.0.0 Mobile/15E148 Safari/604.1",
+			want: Info{Browser: "Chrome", OS: "iOS", Device: DeviceTablet},
+		},
+		{
+			ua:   "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+			want: Info{Browser: "Chrome", OS: "Android", Device: DeviceMobile},
+		},
+		{
+			ua:   "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Safari/537.36",
+			want: Info{Browser: "Samsung Internet", OS: "Android", Device: DeviceTablet},
+		},
+		{
+			ua:   "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			want: Info{Browser: "Firefox", OS: "Linux", Device: DeviceDesktop},
+		},
+		{
+			ua:   "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want: Info{Browser: Other, OS: Other, Device: DeviceBot},
+		},
+		{
+			ua:   "",
+			want: Info{Browser: Unknown, OS: Unknown, Device: Unknown},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := Parse(tt.ua); got != tt.want {
+			t.Errorf("Parse(%q): expected %+v, got %+v", tt.ua, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Package version identifies the running binary. Version, Commit and
+// BuildDate are injected at build time, e.g.
+//
+//	go build -ldflags "-X nav-tracker/pkg/version.Version=v1.4.0 \
+//	  -X nav-tracker/pkg/version.Commit=$(git rev-parse HEAD) \
+//	  -X nav-tracker/pkg/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// When they are not, the commit and date recorded by the Go toolchain for
+// builds from a git checkout are used instead.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"nav-tracker/pkg/models"
+)
+
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// Info returns the build metadata of the running binary.
+func Info() models.BuildInfo {
+	info := models.BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	return info
+}
+
+// String formats the build for log lines, e.g. "v1.4.0 (commit 3f2a9c1, built 2024-05-01T10:00:00Z, go1.21.5)".
+func String() string {
+	info := Info()
+	commit := info.Commit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	if commit == "" {
+		commit = "unknown"
+	}
+	if info.Modified {
+		commit += "-dirty"
+	}
+	date := info.BuildDate
+	if date == "" {
+		date = "unknown"
+	}
+	return fmt.Sprintf("%s (commit %s, built %s, %s)", info.Version, commit, date, info.GoVersion)
+}
@@ -0,0 +1,28 @@
+package version
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInfo_Injected(t *testing.T) {
+	defer func(v, c, d string) { Version, Commit, BuildDate = v, c, d }(Version, Commit, BuildDate)
+	Version, Commit, BuildDate = "v1.4.0", "3f2a9c1d8e7b6a5f", "2024-05-01T10:00:00Z"
+
+	info := Info()
+	if info.Version != "v1.4.0" || info.Commit != "3f2a9c1d8e7b6a5f" || info.BuildDate != "2024-05-01T10:00:00Z" {
+		t.Errorf("Expected injected values, got %+v", info)
+	}
+	if info.GoVersion == "" {
+		t.Error("Expected Go version to be set")
+	}
+	if s := String(); !strings.HasPrefix(s, "v1.4.0 (commit 3f2a9c1") || !strings.Contains(s, "built 2024-05-01T10:00:00Z") {
+		t.Errorf("Unexpected version string %q", s)
+	}
+}
+
+func TestInfo_Defaults(t *testing.T) {
+	if info := Info(); info.Version != "dev" {
+		t.Errorf("Expected version dev without ldflags, got %s", info.Version)
+	}
+}